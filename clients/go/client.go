@@ -0,0 +1,575 @@
+// Code generated by "api gen sdk"; DO NOT EDIT.
+
+// Package client is the client of GinAPI, API version 1.0.0. Regenerate it with
+// "api gen sdk" whenever the routes change.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls the API at BaseURL. It is safe for concurrent use once configured.
+type Client struct {
+	// BaseURL is the scheme and host of the API, e.g. https://api.example.com.
+	BaseURL string
+	// HTTPClient sends the requests; http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// Token is sent as a bearer token with every request when set.
+	Token string
+}
+
+// New returns a client of the API at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Response is the envelope of every API response.
+type Response struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	Meta    json.RawMessage `json:"meta,omitempty"`
+}
+
+// Decode decodes the data of the response into v.
+func (r *Response) Decode(v interface{}) error {
+	if len(r.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Data, v)
+}
+
+// Error is an error returned by the API. Its codes are listed by GET /api/errors.
+type Error struct {
+	// Status is the HTTP status code of the response.
+	Status    int          `json:"-"`
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   string       `json:"details,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("%d %s: %s: %s", e.Status, e.Code, e.Message, e.Details)
+	}
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// FieldError describes an invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PostAdminRegistrationCodesRequest is the body of PostAdminRegistrationCodes.
+type PostAdminRegistrationCodesRequest struct {
+	ExpiresAt *string `json:"expires_at,omitempty"`
+	MaxUses   *int64  `json:"max_uses,omitempty"`
+	Note      *string `json:"note,omitempty"`
+}
+
+// PostAdminUsersBatchRequest is the body of PostAdminUsersBatch.
+type PostAdminUsersBatchRequest struct {
+	Atomic     *bool                                  `json:"atomic,omitempty"`
+	Operations []PostAdminUsersBatchRequestOperations `json:"operations,omitempty"`
+}
+
+// PostAdminUsersBatchRequestOperations is part of the body of PostAdminUsersBatch.
+type PostAdminUsersBatchRequestOperations struct {
+	Data *PostAdminUsersBatchRequestOperationsData `json:"data,omitempty"`
+	ID   *int64                                    `json:"id,omitempty"`
+	Op   *string                                   `json:"op,omitempty"`
+}
+
+// PostAdminUsersBatchRequestOperationsData is part of the body of PostAdminUsersBatch.
+type PostAdminUsersBatchRequestOperationsData struct {
+	Email    *string `json:"email,omitempty"`
+	Password *string `json:"password,omitempty"`
+	Role     *string `json:"role,omitempty"`
+	Username *string `json:"username,omitempty"`
+}
+
+// PostAuthEmailConfirmRequest is the body of PostAuthEmailConfirm.
+type PostAuthEmailConfirmRequest struct {
+	Token string `json:"token"`
+}
+
+// PostAuthEmailRevertRequest is the body of PostAuthEmailRevert.
+type PostAuthEmailRevertRequest struct {
+	Token string `json:"token"`
+}
+
+// PostAuthLoginRequest is the body of PostAuthLogin.
+type PostAuthLoginRequest struct {
+	Password *string `json:"password,omitempty"`
+	Username *string `json:"username,omitempty"`
+}
+
+// PostAuthLoginVerifyRequest is the body of PostAuthLoginVerify.
+type PostAuthLoginVerifyRequest struct {
+	ChallengeID string `json:"challenge_id"`
+	Code        string `json:"code"`
+}
+
+// PostAuthRegisterRequest is the body of PostAuthRegister.
+type PostAuthRegisterRequest struct {
+	AcceptTerms    *bool   `json:"accept_terms,omitempty"`
+	Email          *string `json:"email,omitempty"`
+	InvitationCode *string `json:"invitation_code,omitempty"`
+	Password       *string `json:"password,omitempty"`
+	Username       *string `json:"username,omitempty"`
+}
+
+// PostInvitationsAcceptRequest is the body of PostInvitationsAccept.
+type PostInvitationsAcceptRequest struct {
+	Token string `json:"token"`
+}
+
+// PostKeysRequest is the body of PostKeys.
+type PostKeysRequest struct {
+	Name *string `json:"name,omitempty"`
+}
+
+// PostLoginRequest is the body of PostLogin.
+type PostLoginRequest struct {
+	Password *string `json:"password,omitempty"`
+	Username *string `json:"username,omitempty"`
+}
+
+// PostOrgsRequest is the body of PostOrgs.
+type PostOrgsRequest struct {
+	Name *string `json:"name,omitempty"`
+	Slug *string `json:"slug,omitempty"`
+}
+
+// PostOrgsByOrgInvitationsRequest is the body of PostOrgsByOrgInvitations.
+type PostOrgsByOrgInvitationsRequest struct {
+	Email *string `json:"email,omitempty"`
+	Role  *string `json:"role,omitempty"`
+}
+
+// PatchOrgsByOrgMembersByUserIDRequest is the body of PatchOrgsByOrgMembersByUserID.
+type PatchOrgsByOrgMembersByUserIDRequest struct {
+	Role *string `json:"role,omitempty"`
+}
+
+// PostOrgsByOrgRegistrationCodesRequest is the body of PostOrgsByOrgRegistrationCodes.
+type PostOrgsByOrgRegistrationCodesRequest struct {
+	ExpiresAt *string `json:"expires_at,omitempty"`
+	MaxUses   *int64  `json:"max_uses,omitempty"`
+	Note      *string `json:"note,omitempty"`
+}
+
+// PostRegisterRequest is the body of PostRegister.
+type PostRegisterRequest struct {
+	AcceptTerms    *bool   `json:"accept_terms,omitempty"`
+	Email          *string `json:"email,omitempty"`
+	InvitationCode *string `json:"invitation_code,omitempty"`
+	Password       *string `json:"password,omitempty"`
+	Username       *string `json:"username,omitempty"`
+}
+
+// PatchUsersMeRequest is the body of PatchUsersMe.
+type PatchUsersMeRequest struct {
+	Email    *string `json:"email,omitempty"`
+	Username *string `json:"username,omitempty"`
+}
+
+// PostUsersMeConsentsRequest is the body of PostUsersMeConsents.
+type PostUsersMeConsentsRequest struct {
+	Kind    *string `json:"kind,omitempty"`
+	Version *string `json:"version,omitempty"`
+}
+
+// PutUsersMeEmailRequest is the body of PutUsersMeEmail.
+type PutUsersMeEmailRequest struct {
+	Email    *string `json:"email,omitempty"`
+	Password *string `json:"password,omitempty"`
+}
+
+// GetAdminRegistrationCodes calls GET /api/admin/registration-codes. handlers.ListRegistrationCodes. Requires a bearer token with role: admin.
+func (c *Client) GetAdminRegistrationCodes(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/admin/registration-codes", nil, "")
+}
+
+// PostAdminRegistrationCodes calls POST /api/admin/registration-codes. handlers.CreateRegistrationCode. Requires a bearer token with role: admin.
+func (c *Client) PostAdminRegistrationCodes(ctx context.Context, body PostAdminRegistrationCodesRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/admin/registration-codes", bytes.NewReader(payload), "application/json")
+}
+
+// DeleteAdminRegistrationCodesByID calls DELETE /api/admin/registration-codes/{id}. handlers.RevokeRegistrationCode. Requires a bearer token with role: admin.
+func (c *Client) DeleteAdminRegistrationCodesByID(ctx context.Context, id string) (*Response, error) {
+	return c.do(ctx, http.MethodDelete, "/api/admin/registration-codes/"+url.PathEscape(id), nil, "")
+}
+
+// GetAdminRoutes calls GET /api/admin/routes. routes.listRoutes. Requires a bearer token with role: admin.
+func (c *Client) GetAdminRoutes(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/admin/routes", nil, "")
+}
+
+// GetAdminUsers calls GET /api/admin/users. handlers.ListUsers. Requires a bearer token with role: admin.
+func (c *Client) GetAdminUsers(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/admin/users", nil, "")
+}
+
+// PostAdminUsersBatch calls POST /api/admin/users/batch. handlers.BatchUsers. Requires a bearer token with role: admin.
+func (c *Client) PostAdminUsersBatch(ctx context.Context, body PostAdminUsersBatchRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/admin/users/batch", bytes.NewReader(payload), "application/json")
+}
+
+// GetAdminUsersByIDUsernameHistory calls GET /api/admin/users/{id}/username-history. handlers.UsernameHistory. Requires a bearer token with role: admin.
+func (c *Client) GetAdminUsersByIDUsernameHistory(ctx context.Context, id string) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/admin/users/"+url.PathEscape(id)+"/username-history", nil, "")
+}
+
+// PostAuthEmailConfirm calls POST /api/auth/email/confirm. handlers.ConfirmEmailChange. Public.
+func (c *Client) PostAuthEmailConfirm(ctx context.Context, body PostAuthEmailConfirmRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/auth/email/confirm", bytes.NewReader(payload), "application/json")
+}
+
+// PostAuthEmailRevert calls POST /api/auth/email/revert. handlers.RevertEmailChange. Public.
+func (c *Client) PostAuthEmailRevert(ctx context.Context, body PostAuthEmailRevertRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/auth/email/revert", bytes.NewReader(payload), "application/json")
+}
+
+// PostAuthLogin calls POST /api/auth/login. handlers.Login. Public.
+func (c *Client) PostAuthLogin(ctx context.Context, body PostAuthLoginRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/auth/login", bytes.NewReader(payload), "application/json")
+}
+
+// PostAuthLoginVerify calls POST /api/auth/login/verify. handlers.VerifyLogin. Public.
+func (c *Client) PostAuthLoginVerify(ctx context.Context, body PostAuthLoginVerifyRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/auth/login/verify", bytes.NewReader(payload), "application/json")
+}
+
+// PostAuthRegister calls POST /api/auth/register. handlers.Register. Public.
+func (c *Client) PostAuthRegister(ctx context.Context, body PostAuthRegisterRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/auth/register", bytes.NewReader(payload), "application/json")
+}
+
+// GetErrors calls GET /api/errors. handlers.ListErrorCodes. Public.
+func (c *Client) GetErrors(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/errors", nil, "")
+}
+
+// PostInvitationsAccept calls POST /api/invitations/accept. handlers.AcceptInvitation. Requires a bearer token.
+func (c *Client) PostInvitationsAccept(ctx context.Context, body PostInvitationsAcceptRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/invitations/accept", bytes.NewReader(payload), "application/json")
+}
+
+// GetKeys calls GET /api/keys. handlers.ListAPIKeys. Requires a bearer token.
+func (c *Client) GetKeys(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/keys", nil, "")
+}
+
+// PostKeys calls POST /api/keys. handlers.CreateAPIKey. Requires a bearer token.
+func (c *Client) PostKeys(ctx context.Context, body PostKeysRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/keys", bytes.NewReader(payload), "application/json")
+}
+
+// DeleteKeysByID calls DELETE /api/keys/{id}. handlers.RevokeAPIKey. Requires a bearer token.
+func (c *Client) DeleteKeysByID(ctx context.Context, id string) (*Response, error) {
+	return c.do(ctx, http.MethodDelete, "/api/keys/"+url.PathEscape(id), nil, "")
+}
+
+// GetKeysByIDUsage calls GET /api/keys/{id}/usage. handlers.APIKeyUsage. Requires a bearer token.
+func (c *Client) GetKeysByIDUsage(ctx context.Context, id string) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/keys/"+url.PathEscape(id)+"/usage", nil, "")
+}
+
+// PostLogin calls POST /api/login. handlers.Login. Public.
+func (c *Client) PostLogin(ctx context.Context, body PostLoginRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/login", bytes.NewReader(payload), "application/json")
+}
+
+// GetOperationsByID calls GET /api/operations/{id}. handlers.GetOperation. Requires a bearer token.
+func (c *Client) GetOperationsByID(ctx context.Context, id string) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/operations/"+url.PathEscape(id), nil, "")
+}
+
+// PostOperationsByIDCancel calls POST /api/operations/{id}/cancel. handlers.CancelOperation. Requires a bearer token.
+func (c *Client) PostOperationsByIDCancel(ctx context.Context, id string) (*Response, error) {
+	return c.do(ctx, http.MethodPost, "/api/operations/"+url.PathEscape(id)+"/cancel", nil, "")
+}
+
+// GetOrgs calls GET /api/orgs. handlers.ListOrganizations. Requires a bearer token.
+func (c *Client) GetOrgs(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/orgs", nil, "")
+}
+
+// PostOrgs calls POST /api/orgs. handlers.CreateOrganization. Requires a bearer token.
+func (c *Client) PostOrgs(ctx context.Context, body PostOrgsRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/orgs", bytes.NewReader(payload), "application/json")
+}
+
+// DeleteOrgsByOrg calls DELETE /api/orgs/{org}. handlers.DeleteOrganization. Requires a bearer token.
+func (c *Client) DeleteOrgsByOrg(ctx context.Context, org string) (*Response, error) {
+	return c.do(ctx, http.MethodDelete, "/api/orgs/"+url.PathEscape(org), nil, "")
+}
+
+// GetOrgsByOrg calls GET /api/orgs/{org}. handlers.GetOrganization. Requires a bearer token.
+func (c *Client) GetOrgsByOrg(ctx context.Context, org string) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/orgs/"+url.PathEscape(org), nil, "")
+}
+
+// GetOrgsByOrgInvitations calls GET /api/orgs/{org}/invitations. handlers.ListInvitations. Requires a bearer token.
+func (c *Client) GetOrgsByOrgInvitations(ctx context.Context, org string) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/orgs/"+url.PathEscape(org)+"/invitations", nil, "")
+}
+
+// PostOrgsByOrgInvitations calls POST /api/orgs/{org}/invitations. handlers.CreateInvitation. Requires a bearer token.
+func (c *Client) PostOrgsByOrgInvitations(ctx context.Context, org string, body PostOrgsByOrgInvitationsRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/orgs/"+url.PathEscape(org)+"/invitations", bytes.NewReader(payload), "application/json")
+}
+
+// DeleteOrgsByOrgInvitationsByID calls DELETE /api/orgs/{org}/invitations/{id}. handlers.RevokeInvitation. Requires a bearer token.
+func (c *Client) DeleteOrgsByOrgInvitationsByID(ctx context.Context, org string, id string) (*Response, error) {
+	return c.do(ctx, http.MethodDelete, "/api/orgs/"+url.PathEscape(org)+"/invitations/"+url.PathEscape(id), nil, "")
+}
+
+// GetOrgsByOrgMembers calls GET /api/orgs/{org}/members. handlers.ListMembers. Requires a bearer token.
+func (c *Client) GetOrgsByOrgMembers(ctx context.Context, org string) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/orgs/"+url.PathEscape(org)+"/members", nil, "")
+}
+
+// DeleteOrgsByOrgMembersByUserID calls DELETE /api/orgs/{org}/members/{user_id}. handlers.RemoveMember. Requires a bearer token.
+func (c *Client) DeleteOrgsByOrgMembersByUserID(ctx context.Context, org string, userID string) (*Response, error) {
+	return c.do(ctx, http.MethodDelete, "/api/orgs/"+url.PathEscape(org)+"/members/"+url.PathEscape(userID), nil, "")
+}
+
+// PatchOrgsByOrgMembersByUserID calls PATCH /api/orgs/{org}/members/{user_id}. handlers.UpdateMember. Requires a bearer token.
+func (c *Client) PatchOrgsByOrgMembersByUserID(ctx context.Context, org string, userID string, body PatchOrgsByOrgMembersByUserIDRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPatch, "/api/orgs/"+url.PathEscape(org)+"/members/"+url.PathEscape(userID), bytes.NewReader(payload), "application/json")
+}
+
+// GetOrgsByOrgRegistrationCodes calls GET /api/orgs/{org}/registration-codes. handlers.ListRegistrationCodes. Requires a bearer token.
+func (c *Client) GetOrgsByOrgRegistrationCodes(ctx context.Context, org string) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/orgs/"+url.PathEscape(org)+"/registration-codes", nil, "")
+}
+
+// PostOrgsByOrgRegistrationCodes calls POST /api/orgs/{org}/registration-codes. handlers.CreateRegistrationCode. Requires a bearer token.
+func (c *Client) PostOrgsByOrgRegistrationCodes(ctx context.Context, org string, body PostOrgsByOrgRegistrationCodesRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/orgs/"+url.PathEscape(org)+"/registration-codes", bytes.NewReader(payload), "application/json")
+}
+
+// DeleteOrgsByOrgRegistrationCodesByID calls DELETE /api/orgs/{org}/registration-codes/{id}. handlers.RevokeRegistrationCode. Requires a bearer token.
+func (c *Client) DeleteOrgsByOrgRegistrationCodesByID(ctx context.Context, org string, id string) (*Response, error) {
+	return c.do(ctx, http.MethodDelete, "/api/orgs/"+url.PathEscape(org)+"/registration-codes/"+url.PathEscape(id), nil, "")
+}
+
+// GetProtected calls GET /api/protected/. middlewares.ProtectedHandler. Requires a bearer token.
+func (c *Client) GetProtected(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/protected/", nil, "")
+}
+
+// GetProtectedProfile calls GET /api/protected/profile. routes.getUserProfile. Requires a bearer token.
+func (c *Client) GetProtectedProfile(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/protected/profile", nil, "")
+}
+
+// PostRegister calls POST /api/register. handlers.Register. Public.
+func (c *Client) PostRegister(ctx context.Context, body PostRegisterRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/register", bytes.NewReader(payload), "application/json")
+}
+
+// GetUsers calls GET /api/users. handlers.GetUsersByIDs. Requires a bearer token.
+func (c *Client) GetUsers(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/users", nil, "")
+}
+
+// GetUsersMe calls GET /api/users/me. routes.getUserProfile. Requires a bearer token.
+func (c *Client) GetUsersMe(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/users/me", nil, "")
+}
+
+// PatchUsersMe calls PATCH /api/users/me. handlers.UpdateProfile. Requires a bearer token.
+func (c *Client) PatchUsersMe(ctx context.Context, body PatchUsersMeRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPatch, "/api/users/me", bytes.NewReader(payload), "application/json")
+}
+
+// GetUsersMeConsents calls GET /api/users/me/consents. handlers.ListConsents. Requires a bearer token.
+func (c *Client) GetUsersMeConsents(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/users/me/consents", nil, "")
+}
+
+// PostUsersMeConsents calls POST /api/users/me/consents. handlers.RecordConsent. Requires a bearer token.
+func (c *Client) PostUsersMeConsents(ctx context.Context, body PostUsersMeConsentsRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/users/me/consents", bytes.NewReader(payload), "application/json")
+}
+
+// PutUsersMeEmail calls PUT /api/users/me/email. handlers.RequestEmailChange. Requires a bearer token.
+func (c *Client) PutUsersMeEmail(ctx context.Context, body PutUsersMeEmailRequest) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPut, "/api/users/me/email", bytes.NewReader(payload), "application/json")
+}
+
+// GetUsersMeNotifications calls GET /api/users/me/notifications. handlers.ListNotifications. Requires a bearer token.
+func (c *Client) GetUsersMeNotifications(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/users/me/notifications", nil, "")
+}
+
+// PostUsersMeNotificationsByIDRead calls POST /api/users/me/notifications/{id}/read. handlers.MarkNotificationRead. Requires a bearer token.
+func (c *Client) PostUsersMeNotificationsByIDRead(ctx context.Context, id string) (*Response, error) {
+	return c.do(ctx, http.MethodPost, "/api/users/me/notifications/"+url.PathEscape(id)+"/read", nil, "")
+}
+
+// GetUsersMePreferences calls GET /api/users/me/preferences. handlers.GetPreferences. Requires a bearer token.
+func (c *Client) GetUsersMePreferences(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/users/me/preferences", nil, "")
+}
+
+// PatchUsersMePreferences calls PATCH /api/users/me/preferences. handlers.UpdatePreferences. Requires a bearer token.
+func (c *Client) PatchUsersMePreferences(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodPatch, "/api/users/me/preferences", nil, "")
+}
+
+// GetUsersMePreferencesSchema calls GET /api/users/me/preferences/schema. handlers.PreferencesSchema. Requires a bearer token.
+func (c *Client) GetUsersMePreferencesSchema(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/api/users/me/preferences/schema", nil, "")
+}
+
+// GetHealth calls GET /health/. handlers.HealthCheck. Public.
+func (c *Client) GetHealth(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/health/", nil, "")
+}
+
+// GetHealthLive calls GET /health/live. handlers.LivenessCheck. Public.
+func (c *Client) GetHealthLive(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/health/live", nil, "")
+}
+
+// GetHealthReady calls GET /health/ready. handlers.ReadinessCheck. Public.
+func (c *Client) GetHealthReady(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/health/ready", nil, "")
+}
+
+// GetHealthStartup calls GET /health/startup. handlers.StartupCheck. Public.
+func (c *Client) GetHealthStartup(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, "/health/startup", nil, "")
+}
+
+// do sends a request and decodes the envelope of its response. Responses that are not
+// successful are returned along with an *Error.
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, contentType string) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	var out Response
+	switch err := json.NewDecoder(res.Body).Decode(&out); {
+	case err == io.EOF:
+		// Responses without a body, such as 204 No Content
+		out.Success = res.StatusCode < http.StatusBadRequest
+	case err != nil:
+		return nil, fmt.Errorf("%s %s: unexpected %d response: %w", method, path, res.StatusCode, err)
+	}
+	if res.StatusCode >= http.StatusBadRequest || !out.Success {
+		if out.Error == nil {
+			out.Error = &Error{Code: http.StatusText(res.StatusCode), Message: out.Message}
+		}
+		out.Error.Status = res.StatusCode
+		return &out, out.Error
+	}
+	return &out, nil
+}
@@ -0,0 +1,470 @@
+// Code generated by "api gen sdk"; DO NOT EDIT.
+//
+// Client of GinAPI, API version 1.0.0. Regenerate it with "api gen sdk"
+// whenever the routes change.
+
+/** Envelope of every API response. */
+export interface ApiResponse<T = unknown> {
+  success: boolean;
+  message?: string;
+  data?: T;
+  error?: ApiErrorBody;
+  meta?: Record<string, unknown>;
+}
+
+/** Error returned by the API. Its codes are listed by GET /api/errors. */
+export interface ApiErrorBody {
+  code: string;
+  message: string;
+  details?: string;
+  fields?: FieldError[];
+  request_id?: string;
+}
+
+/** Invalid field of a request. */
+export interface FieldError {
+  field: string;
+  code: string;
+  message: string;
+}
+
+/** Thrown for responses that are not successful. */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly body: ApiErrorBody,
+    readonly response: ApiResponse,
+  ) {
+    super(`${status} ${body.code}: ${body.message}`);
+    this.name = "ApiError";
+  }
+}
+
+export interface ClientOptions {
+  /** Sent as a bearer token with every request when set. */
+  token?: string;
+  /** Replaces the global fetch, e.g. in tests. */
+  fetch?: typeof fetch;
+}
+
+/** Body of PostAdminRegistrationCodes. */
+export interface PostAdminRegistrationCodesRequest {
+  expires_at?: string | null;
+  max_uses?: number;
+  note?: string;
+}
+
+/** Body of PostAdminUsersBatch. */
+export interface PostAdminUsersBatchRequest {
+  atomic?: boolean;
+  operations?: PostAdminUsersBatchRequestOperations[];
+}
+
+/** Part of the body of PostAdminUsersBatch. */
+export interface PostAdminUsersBatchRequestOperations {
+  data?: PostAdminUsersBatchRequestOperationsData | null;
+  id?: number;
+  op?: string;
+}
+
+/** Part of the body of PostAdminUsersBatch. */
+export interface PostAdminUsersBatchRequestOperationsData {
+  email?: string | null;
+  password?: string | null;
+  role?: string | null;
+  username?: string | null;
+}
+
+/** Body of PostAuthEmailConfirm. */
+export interface PostAuthEmailConfirmRequest {
+  token: string;
+}
+
+/** Body of PostAuthEmailRevert. */
+export interface PostAuthEmailRevertRequest {
+  token: string;
+}
+
+/** Body of PostAuthLogin. */
+export interface PostAuthLoginRequest {
+  password?: string;
+  username?: string;
+}
+
+/** Body of PostAuthLoginVerify. */
+export interface PostAuthLoginVerifyRequest {
+  challenge_id: string;
+  code: string;
+}
+
+/** Body of PostAuthRegister. */
+export interface PostAuthRegisterRequest {
+  accept_terms?: boolean;
+  email?: string;
+  invitation_code?: string;
+  password?: string;
+  username?: string;
+}
+
+/** Body of PostInvitationsAccept. */
+export interface PostInvitationsAcceptRequest {
+  token: string;
+}
+
+/** Body of PostKeys. */
+export interface PostKeysRequest {
+  name?: string;
+}
+
+/** Body of PostLogin. */
+export interface PostLoginRequest {
+  password?: string;
+  username?: string;
+}
+
+/** Body of PostOrgs. */
+export interface PostOrgsRequest {
+  name?: string;
+  slug?: string;
+}
+
+/** Body of PostOrgsByOrgInvitations. */
+export interface PostOrgsByOrgInvitationsRequest {
+  email?: string;
+  role?: string;
+}
+
+/** Body of PatchOrgsByOrgMembersByUserID. */
+export interface PatchOrgsByOrgMembersByUserIDRequest {
+  role?: string | null;
+}
+
+/** Body of PostOrgsByOrgRegistrationCodes. */
+export interface PostOrgsByOrgRegistrationCodesRequest {
+  expires_at?: string | null;
+  max_uses?: number;
+  note?: string;
+}
+
+/** Body of PostRegister. */
+export interface PostRegisterRequest {
+  accept_terms?: boolean;
+  email?: string;
+  invitation_code?: string;
+  password?: string;
+  username?: string;
+}
+
+/** Body of PatchUsersMe. */
+export interface PatchUsersMeRequest {
+  email?: string | null;
+  username?: string | null;
+}
+
+/** Body of PostUsersMeConsents. */
+export interface PostUsersMeConsentsRequest {
+  kind?: string;
+  version?: string;
+}
+
+/** Body of PutUsersMeEmail. */
+export interface PutUsersMeEmailRequest {
+  email?: string;
+  password?: string;
+}
+
+/** Calls the API at baseURL, e.g. https://api.example.com. */
+export class Client {
+  private readonly baseURL: string;
+
+  constructor(
+    baseURL: string,
+    private readonly options: ClientOptions = {},
+  ) {
+    this.baseURL = baseURL.replace(/\/+$/, "");
+  }
+
+  /** Sets or clears the bearer token sent with every request. */
+  setToken(token?: string): void {
+    this.options.token = token;
+  }
+
+  /** GET /api/admin/registration-codes. handlers.ListRegistrationCodes. Requires a bearer token with role: admin. */
+  getAdminRegistrationCodes(): Promise<ApiResponse> {
+    return this.request("GET", `/api/admin/registration-codes`);
+  }
+
+  /** POST /api/admin/registration-codes. handlers.CreateRegistrationCode. Requires a bearer token with role: admin. */
+  postAdminRegistrationCodes(body: PostAdminRegistrationCodesRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/admin/registration-codes`, JSON.stringify(body), "application/json");
+  }
+
+  /** DELETE /api/admin/registration-codes/{id}. handlers.RevokeRegistrationCode. Requires a bearer token with role: admin. */
+  deleteAdminRegistrationCodesByID(id: string): Promise<ApiResponse> {
+    return this.request("DELETE", `/api/admin/registration-codes/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/admin/routes. routes.listRoutes. Requires a bearer token with role: admin. */
+  getAdminRoutes(): Promise<ApiResponse> {
+    return this.request("GET", `/api/admin/routes`);
+  }
+
+  /** GET /api/admin/users. handlers.ListUsers. Requires a bearer token with role: admin. */
+  getAdminUsers(): Promise<ApiResponse> {
+    return this.request("GET", `/api/admin/users`);
+  }
+
+  /** POST /api/admin/users/batch. handlers.BatchUsers. Requires a bearer token with role: admin. */
+  postAdminUsersBatch(body: PostAdminUsersBatchRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/admin/users/batch`, JSON.stringify(body), "application/json");
+  }
+
+  /** GET /api/admin/users/{id}/username-history. handlers.UsernameHistory. Requires a bearer token with role: admin. */
+  getAdminUsersByIDUsernameHistory(id: string): Promise<ApiResponse> {
+    return this.request("GET", `/api/admin/users/${encodeURIComponent(id)}/username-history`);
+  }
+
+  /** POST /api/auth/email/confirm. handlers.ConfirmEmailChange. Public. */
+  postAuthEmailConfirm(body: PostAuthEmailConfirmRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/auth/email/confirm`, JSON.stringify(body), "application/json");
+  }
+
+  /** POST /api/auth/email/revert. handlers.RevertEmailChange. Public. */
+  postAuthEmailRevert(body: PostAuthEmailRevertRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/auth/email/revert`, JSON.stringify(body), "application/json");
+  }
+
+  /** POST /api/auth/login. handlers.Login. Public. */
+  postAuthLogin(body: PostAuthLoginRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/auth/login`, JSON.stringify(body), "application/json");
+  }
+
+  /** POST /api/auth/login/verify. handlers.VerifyLogin. Public. */
+  postAuthLoginVerify(body: PostAuthLoginVerifyRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/auth/login/verify`, JSON.stringify(body), "application/json");
+  }
+
+  /** POST /api/auth/register. handlers.Register. Public. */
+  postAuthRegister(body: PostAuthRegisterRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/auth/register`, JSON.stringify(body), "application/json");
+  }
+
+  /** GET /api/errors. handlers.ListErrorCodes. Public. */
+  getErrors(): Promise<ApiResponse> {
+    return this.request("GET", `/api/errors`);
+  }
+
+  /** POST /api/invitations/accept. handlers.AcceptInvitation. Requires a bearer token. */
+  postInvitationsAccept(body: PostInvitationsAcceptRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/invitations/accept`, JSON.stringify(body), "application/json");
+  }
+
+  /** GET /api/keys. handlers.ListAPIKeys. Requires a bearer token. */
+  getKeys(): Promise<ApiResponse> {
+    return this.request("GET", `/api/keys`);
+  }
+
+  /** POST /api/keys. handlers.CreateAPIKey. Requires a bearer token. */
+  postKeys(body: PostKeysRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/keys`, JSON.stringify(body), "application/json");
+  }
+
+  /** DELETE /api/keys/{id}. handlers.RevokeAPIKey. Requires a bearer token. */
+  deleteKeysByID(id: string): Promise<ApiResponse> {
+    return this.request("DELETE", `/api/keys/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/keys/{id}/usage. handlers.APIKeyUsage. Requires a bearer token. */
+  getKeysByIDUsage(id: string): Promise<ApiResponse> {
+    return this.request("GET", `/api/keys/${encodeURIComponent(id)}/usage`);
+  }
+
+  /** POST /api/login. handlers.Login. Public. */
+  postLogin(body: PostLoginRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/login`, JSON.stringify(body), "application/json");
+  }
+
+  /** GET /api/operations/{id}. handlers.GetOperation. Requires a bearer token. */
+  getOperationsByID(id: string): Promise<ApiResponse> {
+    return this.request("GET", `/api/operations/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /api/operations/{id}/cancel. handlers.CancelOperation. Requires a bearer token. */
+  postOperationsByIDCancel(id: string): Promise<ApiResponse> {
+    return this.request("POST", `/api/operations/${encodeURIComponent(id)}/cancel`);
+  }
+
+  /** GET /api/orgs. handlers.ListOrganizations. Requires a bearer token. */
+  getOrgs(): Promise<ApiResponse> {
+    return this.request("GET", `/api/orgs`);
+  }
+
+  /** POST /api/orgs. handlers.CreateOrganization. Requires a bearer token. */
+  postOrgs(body: PostOrgsRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/orgs`, JSON.stringify(body), "application/json");
+  }
+
+  /** DELETE /api/orgs/{org}. handlers.DeleteOrganization. Requires a bearer token. */
+  deleteOrgsByOrg(org: string): Promise<ApiResponse> {
+    return this.request("DELETE", `/api/orgs/${encodeURIComponent(org)}`);
+  }
+
+  /** GET /api/orgs/{org}. handlers.GetOrganization. Requires a bearer token. */
+  getOrgsByOrg(org: string): Promise<ApiResponse> {
+    return this.request("GET", `/api/orgs/${encodeURIComponent(org)}`);
+  }
+
+  /** GET /api/orgs/{org}/invitations. handlers.ListInvitations. Requires a bearer token. */
+  getOrgsByOrgInvitations(org: string): Promise<ApiResponse> {
+    return this.request("GET", `/api/orgs/${encodeURIComponent(org)}/invitations`);
+  }
+
+  /** POST /api/orgs/{org}/invitations. handlers.CreateInvitation. Requires a bearer token. */
+  postOrgsByOrgInvitations(org: string, body: PostOrgsByOrgInvitationsRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/orgs/${encodeURIComponent(org)}/invitations`, JSON.stringify(body), "application/json");
+  }
+
+  /** DELETE /api/orgs/{org}/invitations/{id}. handlers.RevokeInvitation. Requires a bearer token. */
+  deleteOrgsByOrgInvitationsByID(org: string, id: string): Promise<ApiResponse> {
+    return this.request("DELETE", `/api/orgs/${encodeURIComponent(org)}/invitations/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/orgs/{org}/members. handlers.ListMembers. Requires a bearer token. */
+  getOrgsByOrgMembers(org: string): Promise<ApiResponse> {
+    return this.request("GET", `/api/orgs/${encodeURIComponent(org)}/members`);
+  }
+
+  /** DELETE /api/orgs/{org}/members/{user_id}. handlers.RemoveMember. Requires a bearer token. */
+  deleteOrgsByOrgMembersByUserID(org: string, userID: string): Promise<ApiResponse> {
+    return this.request("DELETE", `/api/orgs/${encodeURIComponent(org)}/members/${encodeURIComponent(userID)}`);
+  }
+
+  /** PATCH /api/orgs/{org}/members/{user_id}. handlers.UpdateMember. Requires a bearer token. */
+  patchOrgsByOrgMembersByUserID(org: string, userID: string, body: PatchOrgsByOrgMembersByUserIDRequest): Promise<ApiResponse> {
+    return this.request("PATCH", `/api/orgs/${encodeURIComponent(org)}/members/${encodeURIComponent(userID)}`, JSON.stringify(body), "application/json");
+  }
+
+  /** GET /api/orgs/{org}/registration-codes. handlers.ListRegistrationCodes. Requires a bearer token. */
+  getOrgsByOrgRegistrationCodes(org: string): Promise<ApiResponse> {
+    return this.request("GET", `/api/orgs/${encodeURIComponent(org)}/registration-codes`);
+  }
+
+  /** POST /api/orgs/{org}/registration-codes. handlers.CreateRegistrationCode. Requires a bearer token. */
+  postOrgsByOrgRegistrationCodes(org: string, body: PostOrgsByOrgRegistrationCodesRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/orgs/${encodeURIComponent(org)}/registration-codes`, JSON.stringify(body), "application/json");
+  }
+
+  /** DELETE /api/orgs/{org}/registration-codes/{id}. handlers.RevokeRegistrationCode. Requires a bearer token. */
+  deleteOrgsByOrgRegistrationCodesByID(org: string, id: string): Promise<ApiResponse> {
+    return this.request("DELETE", `/api/orgs/${encodeURIComponent(org)}/registration-codes/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/protected/. middlewares.ProtectedHandler. Requires a bearer token. */
+  getProtected(): Promise<ApiResponse> {
+    return this.request("GET", `/api/protected/`);
+  }
+
+  /** GET /api/protected/profile. routes.getUserProfile. Requires a bearer token. */
+  getProtectedProfile(): Promise<ApiResponse> {
+    return this.request("GET", `/api/protected/profile`);
+  }
+
+  /** POST /api/register. handlers.Register. Public. */
+  postRegister(body: PostRegisterRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/register`, JSON.stringify(body), "application/json");
+  }
+
+  /** GET /api/users. handlers.GetUsersByIDs. Requires a bearer token. */
+  getUsers(): Promise<ApiResponse> {
+    return this.request("GET", `/api/users`);
+  }
+
+  /** GET /api/users/me. routes.getUserProfile. Requires a bearer token. */
+  getUsersMe(): Promise<ApiResponse> {
+    return this.request("GET", `/api/users/me`);
+  }
+
+  /** PATCH /api/users/me. handlers.UpdateProfile. Requires a bearer token. */
+  patchUsersMe(body: PatchUsersMeRequest): Promise<ApiResponse> {
+    return this.request("PATCH", `/api/users/me`, JSON.stringify(body), "application/json");
+  }
+
+  /** GET /api/users/me/consents. handlers.ListConsents. Requires a bearer token. */
+  getUsersMeConsents(): Promise<ApiResponse> {
+    return this.request("GET", `/api/users/me/consents`);
+  }
+
+  /** POST /api/users/me/consents. handlers.RecordConsent. Requires a bearer token. */
+  postUsersMeConsents(body: PostUsersMeConsentsRequest): Promise<ApiResponse> {
+    return this.request("POST", `/api/users/me/consents`, JSON.stringify(body), "application/json");
+  }
+
+  /** PUT /api/users/me/email. handlers.RequestEmailChange. Requires a bearer token. */
+  putUsersMeEmail(body: PutUsersMeEmailRequest): Promise<ApiResponse> {
+    return this.request("PUT", `/api/users/me/email`, JSON.stringify(body), "application/json");
+  }
+
+  /** GET /api/users/me/notifications. handlers.ListNotifications. Requires a bearer token. */
+  getUsersMeNotifications(): Promise<ApiResponse> {
+    return this.request("GET", `/api/users/me/notifications`);
+  }
+
+  /** POST /api/users/me/notifications/{id}/read. handlers.MarkNotificationRead. Requires a bearer token. */
+  postUsersMeNotificationsByIDRead(id: string): Promise<ApiResponse> {
+    return this.request("POST", `/api/users/me/notifications/${encodeURIComponent(id)}/read`);
+  }
+
+  /** GET /api/users/me/preferences. handlers.GetPreferences. Requires a bearer token. */
+  getUsersMePreferences(): Promise<ApiResponse> {
+    return this.request("GET", `/api/users/me/preferences`);
+  }
+
+  /** PATCH /api/users/me/preferences. handlers.UpdatePreferences. Requires a bearer token. */
+  patchUsersMePreferences(): Promise<ApiResponse> {
+    return this.request("PATCH", `/api/users/me/preferences`);
+  }
+
+  /** GET /api/users/me/preferences/schema. handlers.PreferencesSchema. Requires a bearer token. */
+  getUsersMePreferencesSchema(): Promise<ApiResponse> {
+    return this.request("GET", `/api/users/me/preferences/schema`);
+  }
+
+  /** GET /health/. handlers.HealthCheck. Public. */
+  getHealth(): Promise<ApiResponse> {
+    return this.request("GET", `/health/`);
+  }
+
+  /** GET /health/live. handlers.LivenessCheck. Public. */
+  getHealthLive(): Promise<ApiResponse> {
+    return this.request("GET", `/health/live`);
+  }
+
+  /** GET /health/ready. handlers.ReadinessCheck. Public. */
+  getHealthReady(): Promise<ApiResponse> {
+    return this.request("GET", `/health/ready`);
+  }
+
+  /** GET /health/startup. handlers.StartupCheck. Public. */
+  getHealthStartup(): Promise<ApiResponse> {
+    return this.request("GET", `/health/startup`);
+  }
+
+  private async request(method: string, path: string, body?: string, contentType?: string): Promise<ApiResponse> {
+    const headers: Record<string, string> = { Accept: "application/json" };
+    if (contentType) {
+      headers["Content-Type"] = contentType;
+    }
+    if (this.options.token) {
+      headers.Authorization = `Bearer ${this.options.token}`;
+    }
+
+    const send = this.options.fetch ?? fetch;
+    const res = await send(this.baseURL + path, { method, headers, body });
+    const text = await res.text();
+    const out: ApiResponse = text ? JSON.parse(text) : { success: res.ok };
+    if (!res.ok || !out.success) {
+      throw new ApiError(res.status, out.error ?? { code: String(res.status), message: out.message ?? res.statusText }, out);
+    }
+    return out;
+  }
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/internal/backup"
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/pkg/httpclient"
+)
+
+// backupTimeout bounds each request to the backup storage, including reading a whole backup.
+const backupTimeout = time.Hour
+
+func newBackupCmd() *cobra.Command {
+	var noPrune bool
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the database to the configured storage",
+		Long: "Dump the database (a SQLite snapshot, pg_dump, or mysqldump), compress it, and stream it\n" +
+			"to the storage set by BACKUP_STORAGE, then delete the backups past the retention policy\n" +
+			"(BACKUP_RETENTION_COUNT, BACKUP_RETENTION_AGE).",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := loadConfig()
+			db, err := openDatabase(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer database.CloseDB(db)
+
+			service, err := app.NewBackupService(cfg, db, backupHTTPClient(cfg))
+			if err != nil {
+				return fmt.Errorf("invalid backup configuration: %w", err)
+			}
+			object, err := service.Backup(cmd.Context())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Backed up the database to %s (%d bytes)\n", object.Key, object.Size)
+
+			if noPrune {
+				return nil
+			}
+			pruned, err := service.Prune(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to prune old backups: %w", err)
+			}
+			if pruned > 0 {
+				fmt.Printf("Deleted %d old backup(s)\n", pruned)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&noPrune, "no-prune", false, "Keep the backups past the retention policy")
+	cmd.AddCommand(newBackupListCmd())
+	return cmd
+}
+
+func newBackupListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the database backups, oldest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			service, err := restoreService(loadConfig())
+			if err != nil {
+				return err
+			}
+			backups, err := service.List(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if len(backups) == 0 {
+				fmt.Println("No backups")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "KEY\tSIZE\tLAST MODIFIED")
+			for _, b := range backups {
+				_, _ = fmt.Fprintf(w, "%s\t%d\t%s\n", b.Key, b.Size, b.LastModified.UTC().Format(time.RFC3339))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newRestoreCmd() *cobra.Command {
+	var (
+		latest bool
+		yes    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore [key]",
+		Short: "Replace the database with a backup",
+		Long: "Replace the contents of the database with the backup stored under key (see \"backup list\"),\n" +
+			"or the newest one with --latest. Stop the server first: SQLite databases are replaced\n" +
+			"on disk, and PostgreSQL and MySQL ones have their tables dropped and recreated.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if latest == (len(args) == 1) {
+				return errors.New("pass either the key of a backup or --latest")
+			}
+			if !yes {
+				return errors.New("restoring overwrites the database; pass --yes to confirm")
+			}
+
+			service, err := restoreService(loadConfig())
+			if err != nil {
+				return err
+			}
+			var key string
+			if latest {
+				object, err := service.Latest(cmd.Context())
+				if err != nil {
+					return err
+				}
+				key = object.Key
+			} else {
+				key = args[0]
+			}
+
+			if err := service.Restore(cmd.Context(), key); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", key, err)
+			}
+			fmt.Printf("Restored the database from %s\n", key)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&latest, "latest", false, "Restore the newest backup")
+	cmd.Flags().BoolVar(&yes, "yes", false, "Confirm that the database may be overwritten")
+	return cmd
+}
+
+// restoreService creates a backup service without connecting to the database, which restores
+// must not hold open.
+func restoreService(cfg *config.Config) (*backup.Service, error) {
+	service, err := app.NewBackupService(cfg, nil, backupHTTPClient(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup configuration: %w", err)
+	}
+	return service, nil
+}
+
+// backupHTTPClient returns the client of the S3 backup storage, whose requests carry whole
+// backups and so get a longer timeout than other outbound calls.
+func backupHTTPClient(cfg *config.Config) *http.Client {
+	return httpclient.New(httpclient.Config{
+		Name:         "backup",
+		Timeout:      backupTimeout,
+		MaxRetries:   cfg.HTTPClient.MaxRetries,
+		RetryBackoff: cfg.HTTPClient.RetryBackoff,
+		MaxBackoff:   cfg.HTTPClient.MaxBackoff,
+	})
+}
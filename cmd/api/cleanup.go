@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/internal/database"
+)
+
+func newCleanupCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cleanup",
+		Short: "Delete expired records once",
+		Long: "Run the cleanup tasks the server runs every CLEANUP_INTERVAL: delete login challenges,\n" +
+			"device authorizations, pending invitations and email changes that expired more than\n" +
+			"CLEANUP_GRACE_PERIOD ago, and audit logs, processed webhook events, finished sagas and\n" +
+			"failed queued jobs older than AUDIT_LOG_RETENTION, WEBHOOK_INBOX_RETENTION,\n" +
+			"SAGA_RETENTION and JOBS_FAILED_RETENTION when they are set.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := loadConfig()
+			db, err := openDatabase(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer database.CloseDB(db)
+
+			service, err := app.NewCleanupService(cfg, db)
+			if err != nil {
+				return fmt.Errorf("invalid cleanup configuration: %w", err)
+			}
+			results, runErr := service.RunOnce(cmd.Context())
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "TASK\tPURGED\tERROR")
+			for _, r := range results {
+				errText := ""
+				if r.Err != nil {
+					errText = r.Err.Error()
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%d\t%s\n", r.Task, r.Purged, errText)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			return runErr
+		},
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigDumpCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "config-dump",
+		Short: "Print the effective configuration as JSON with secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			cfg := loadConfig()
+
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(cfg.Redacted())
+		},
+	}
+}
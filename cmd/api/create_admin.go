@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/validators"
+)
+
+func newCreateAdminCmd() *cobra.Command {
+	var req validators.AuthRequest
+
+	cmd := &cobra.Command{
+		Use:   "create-admin",
+		Short: "Create an administrator, or promote an existing user to administrator",
+		Long: "Create an administrator account. If a user with the same username already exists it is\n" +
+			"promoted to administrator and its password is left unchanged.\n\n" +
+			"The password is read from --password or, to keep it out of shell history, ADMIN_PASSWORD.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if req.Password == "" {
+				req.Password = os.Getenv("ADMIN_PASSWORD")
+			}
+			req.Normalize()
+
+			cfg := loadConfig()
+			db, err := openDatabase(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer database.CloseDB(db)
+
+			var existing []models.User
+			err = db.Where("username_normalized = ?", models.NormalizeUsername(req.Username)).Limit(1).Find(&existing).Error
+			if err != nil {
+				return err
+			}
+			if len(existing) > 0 {
+				user := existing[0]
+				if err := db.Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+					return err
+				}
+				fmt.Printf("Promoted user %q (id %d) to administrator\n", user.Username, user.ID)
+				return nil
+			}
+
+			if err := validators.ValidateUserRegistration(cmd.Context(), &req); err != nil {
+				return err
+			}
+
+			hashed, err := auth.HashPassword(cmd.Context(), req.Password)
+			if err != nil {
+				return fmt.Errorf("failed to hash password: %w", err)
+			}
+
+			user := models.User{
+				Username: req.Username,
+				Email:    req.Email,
+				Password: hashed,
+				Role:     models.RoleAdmin,
+			}
+			if err := db.Create(&user).Error; err != nil {
+				if database.IsDuplicateKeyError(err) {
+					return errors.New("a user with this email already exists")
+				}
+				return err
+			}
+			fmt.Printf("Created administrator %q (id %d)\n", user.Username, user.ID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&req.Username, "username", "", "Administrator username")
+	cmd.Flags().StringVar(&req.Email, "email", "", "Administrator email")
+	cmd.Flags().StringVar(&req.Password, "password", "", "Administrator password (defaults to $ADMIN_PASSWORD)")
+	_ = cmd.MarkFlagRequired("username")
+	return cmd
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/generator"
+	"github.com/yeferson59/gin-template/pkg/crypto"
+)
+
+func newGenCmd() *cobra.Command {
+	gen := &cobra.Command{
+		Use:   "gen",
+		Short: "Generate code following the template's conventions",
+	}
+
+	var (
+		fields string
+		force  bool
+	)
+	resource := &cobra.Command{
+		Use:   "resource <Name>",
+		Short: "Scaffold a model, repository, service, handler, validator, routes, and tests",
+		Long: "Scaffold a CRUD resource and register its model and routes. Run it from the project root.\n\n" +
+			"Field types: string, text, int, int64, uint, float64, bool, time.",
+		Example: `  api gen resource BlogPost --fields "title:string,body:text,published_at:time"`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			module, err := generator.ModulePath(".")
+			if err != nil {
+				return fmt.Errorf("run gen from the project root: %w", err)
+			}
+
+			res, err := generator.NewResource(module, args[0], fields)
+			if err != nil {
+				return err
+			}
+
+			written, err := generator.Generate(".", res, force)
+			for _, path := range written {
+				fmt.Println("  wrote", path)
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("\nGenerated %s. Review the files, then run: go test ./...\n", res.Name)
+			return nil
+		},
+	}
+	resource.Flags().StringVar(&fields, "fields", "", `Comma-separated name:type pairs, e.g. "title:string,price:float64"`)
+	resource.Flags().BoolVar(&force, "force", false, "Overwrite existing files")
+	_ = resource.MarkFlagRequired("fields")
+
+	var keyID string
+	key := &cobra.Command{
+		Use:   "key",
+		Short: "Generate a random key for ENCRYPTION_KEYS",
+		Long: "Print a new id:key entry for ENCRYPTION_KEYS. To rotate keys, put the new entry first,\n" +
+			"keep the old ones after it and run api rotate-keys, then remove the old ones.",
+		Example: `  api gen key --id 2026-10`,
+		Args:    cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			encoded, err := crypto.GenerateKey()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s:%s\n", keyID, encoded)
+			return nil
+		},
+	}
+	key.Flags().StringVar(&keyID, "id", "k1", "Key ID stored with every value it encrypts")
+
+	var (
+		output    string
+		languages []string
+	)
+	sdk := &cobra.Command{
+		Use:   "sdk",
+		Short: "Generate Go and TypeScript API clients from the OpenAPI specification",
+		Long: "Write typed clients of the API, one method per route with its path parameters and\n" +
+			"request body, generated from the specification printed by api openapi. Run it from the\n" +
+			"project root after changing the routes; the Go client is the package clients/go.",
+		Example: "  api gen sdk\n  api gen sdk --lang typescript --output web/src/api",
+		Args:    cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			spec, err := openAPISpec(loadConfig())
+			if err != nil {
+				return err
+			}
+			clients, err := generator.NewSDK(spec)
+			if err != nil {
+				return err
+			}
+			written, err := generator.GenerateSDK(output, clients, languages)
+			for _, path := range written {
+				fmt.Println("  wrote", path)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\nGenerated clients for %d operations.\n", len(clients.Operations))
+			return nil
+		},
+	}
+	sdk.Flags().StringVarP(&output, "output", "o", "clients", "Directory the clients are written to, one subdirectory per language")
+	sdk.Flags().StringSliceVar(&languages, "lang", generator.SDKLanguages, "Languages to generate: go, typescript")
+
+	gen.AddCommand(resource, key, sdk)
+	return gen
+}
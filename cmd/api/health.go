@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// performHealthCheck performs a health check for Docker HEALTHCHECK.
+// It connects through UNIX_SOCKET_PATH when set, and to localhost:PORT otherwise.
+func performHealthCheck() error {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	client := &http.Client{
+		Timeout: 3 * time.Second,
+	}
+	if socket := os.Getenv("UNIX_SOCKET_PATH"); socket != "" {
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}
+	}
+
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%s/livez", port))
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			fmt.Printf("Error closing response body: %v\n", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed with status: %d", resp.StatusCode)
+	}
+
+	fmt.Println("Health check passed")
+	return nil
+}
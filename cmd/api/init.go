@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/generator"
+)
+
+func newInitCmd() *cobra.Command {
+	var opts generator.RenameOptions
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Rename the module path and application name of a freshly cloned template",
+		Long: "Rewrite go.mod, every import path, and optionally the application name throughout the\n" +
+			"project. Run it once from the project root, then run go build ./... to verify.",
+		Example: `  api init --module github.com/acme/shop --name ShopAPI`,
+		Args:    cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			changed, err := generator.Rename(".", opts)
+			if err != nil {
+				return err
+			}
+			if len(changed) == 0 {
+				fmt.Println("Nothing to rename")
+				return nil
+			}
+
+			verb := "updated"
+			if opts.DryRun {
+				verb = "would update"
+			}
+			for _, path := range changed {
+				fmt.Printf("  %s %s\n", verb, path)
+			}
+			if !opts.DryRun {
+				fmt.Printf("\nRenamed %d file(s). Run: go mod tidy && go build ./...\n", len(changed))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.Module, "module", "", "New Go module path, e.g. github.com/acme/shop")
+	cmd.Flags().StringVar(&opts.AppName, "name", "", "New application name (replaces "+generator.TemplateAppName+")")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "List the files that would change without writing them")
+	_ = cmd.MarkFlagRequired("module")
+	return cmd
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/database"
+)
+
+func newMigrateCmd() *cobra.Command {
+	var status bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply database migrations",
+		Long:  "Create or update the tables for all models and apply pending versioned migrations.",
+		Args:  cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			cfg := loadConfig()
+			db, err := openDatabase(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer database.CloseDB(db)
+
+			pending, err := database.PendingMigrations(db)
+			if err != nil {
+				return err
+			}
+
+			if status {
+				if len(pending) == 0 {
+					fmt.Println("Database is up to date")
+					return nil
+				}
+				for _, m := range pending {
+					fmt.Printf("pending  %s  %s\n", m.Version, m.Description)
+				}
+				return nil
+			}
+
+			if err := database.Migrate(db); err != nil {
+				return err
+			}
+			fmt.Printf("Migrations completed (%d versioned migration(s) applied)\n", len(pending))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&status, "status", false, "List pending versioned migrations without applying them")
+	return cmd
+}
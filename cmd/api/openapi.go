@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/routes"
+)
+
+func newOpenAPICmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Export the routes as an OpenAPI 3 specification",
+		Long: "Generate an OpenAPI 3.0 specification from the route table, with request schemas derived\n" +
+			"from the example bodies and their binding tags. This is the specification requests are\n" +
+			"validated against when OPENAPI_VALIDATION is enabled.",
+		Example: "  api openapi --output openapi.json",
+		Args:    cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			spec, err := openAPISpec(loadConfig())
+			if err != nil {
+				return err
+			}
+
+			out := os.Stdout
+			if output != "" {
+				if out, err = os.Create(output); err != nil {
+					return err
+				}
+				defer func() { _ = out.Close() }()
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(spec)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write instead of standard output")
+	return cmd
+}
+
+// openAPISpec returns the OpenAPI specification of the routes registered with cfg. As in
+// `api routes`, the table is built without connecting to the database.
+func openAPISpec(cfg *config.Config) (*openapi3.T, error) {
+	gin.DefaultWriter = io.Discard
+	_, table, err := newRouter(cfg, nil, routes.Services{})
+	if err != nil {
+		return nil, err
+	}
+	return routes.OpenAPI(cfg.Server.AppName, cfg.Response.APIVersion, table.Routes())
+}
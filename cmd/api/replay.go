@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/pkg/recorder"
+)
+
+func newReplayCmd() *cobra.Command {
+	var (
+		dir     string
+		target  string
+		headers []string
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Re-issue recorded requests against an instance and compare the statuses",
+		Long: "Re-issue the requests recorded with RECORD_TRAFFIC=true, oldest first, and compare each\n" +
+			"response status with the recorded one. Credentials are redacted in recordings, so pass\n" +
+			"them again with --header, e.g. --header \"Authorization: Bearer $TOKEN\". Requests whose\n" +
+			"body was not recorded in full are skipped. Exits with an error when a status differs.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			extra := make(http.Header)
+			for _, h := range headers {
+				name, value, ok := strings.Cut(h, ":")
+				if !ok || strings.TrimSpace(name) == "" {
+					return fmt.Errorf("invalid header %q, want \"Name: value\"", h)
+				}
+				extra.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+			}
+
+			exchanges, err := recorder.Load(dir)
+			if err != nil {
+				return err
+			}
+			if len(exchanges) == 0 {
+				return fmt.Errorf("no recordings in %s", dir)
+			}
+
+			client := &http.Client{Timeout: timeout}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "METHOD\tURL\tRECORDED\tREPLAYED\tRESULT")
+			var differ, skipped int
+			for _, e := range exchanges {
+				status, err := recorder.Replay(cmd.Context(), client, target, e, extra)
+				result := "ok"
+				replayed := fmt.Sprint(status)
+				switch {
+				case errors.Is(err, recorder.ErrIncomplete):
+					skipped++
+					result, replayed = "skipped: "+err.Error(), "-"
+				case err != nil:
+					differ++
+					result, replayed = "error: "+err.Error(), "-"
+				case status != e.Status:
+					differ++
+					result = "differs"
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", e.Method, e.URL, e.Status, replayed, result)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			fmt.Printf("\n%d replayed, %d skipped, %d differ\n", len(exchanges)-skipped, skipped, differ)
+			if differ > 0 {
+				return fmt.Errorf("%d of %d replayed requests got a different status", differ, len(exchanges)-skipped)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "recordings", "Directory of the recordings (RECORD_DIR)")
+	cmd.Flags().StringVar(&target, "target", "http://localhost:8080", "Base URL of the instance to replay against")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "Header added to every request, replacing the recorded one (repeatable)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout of each request")
+	return cmd
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/crypto"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// version is reported by --version.
+const version = "v1.0.0"
+
+// newRootCmd builds the command tree. Running the binary without a subcommand starts the
+// server, so existing deployments and the --health-check/--check flags keep working.
+func newRootCmd() *cobra.Command {
+	var (
+		healthCheck bool
+		check       bool
+		mock        bool
+	)
+
+	root := &cobra.Command{
+		Use:          "api",
+		Short:        "Gin Template API server and management commands",
+		Version:      version,
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			// Docker HEALTHCHECK
+			if healthCheck {
+				return performHealthCheck()
+			}
+			return runServe(check, mock)
+		},
+	}
+	root.SetVersionTemplate("Gin Template API {{.Version}}\n")
+	root.Flags().BoolVar(&healthCheck, "health-check", false, "Perform health check and exit")
+	root.Flags().BoolVar(&check, "check", false, "Verify dependencies (database, migrations, secrets) and exit")
+	root.Flags().BoolVar(&mock, "mock", false, "Answer unimplemented endpoints with the examples of MOCK_SPEC (same as MOCK_MODE=true)")
+
+	root.AddCommand(
+		newServeCmd(),
+		newMigrateCmd(),
+		newSeedCmd(),
+		newBackupCmd(),
+		newRestoreCmd(),
+		newCleanupCmd(),
+		newRotateKeysCmd(),
+		newCreateAdminCmd(),
+		newRoutesCmd(),
+		newCollectionCmd(),
+		newOpenAPICmd(),
+		newConfigDumpCmd(),
+		newReplayCmd(),
+		newSmokeCmd(),
+		newGenCmd(),
+		newInitCmd(),
+	)
+	return root
+}
+
+// loadConfig loads the environment and configuration and applies the package-level
+// settings (logging, response format, password policy) every command relies on.
+func loadConfig() *config.Config {
+	_ = godotenv.Load()
+
+	// Initialize logger first
+	logger.Init()
+
+	config.LoadConfig()
+	cfg := config.Cfg
+
+	// Mask personal data and credentials in log entries
+	logger.SetRedactor(logger.NewRedactor(cfg.Logging.RedactFields, cfg.Logging.RedactEmails))
+
+	// Configure error rendering (standard envelope or RFC 9457 Problem Details)
+	response.SetErrorFormat(response.ErrorFormat(cfg.Response.ErrorFormat))
+	response.SetProblemTypeBase(cfg.Response.ProblemTypeBase)
+	response.SetSerializer(envelopeSerializer(cfg))
+
+	// Apply the configured password policy
+	validators.SetPasswordPolicy(validators.PasswordPolicy{
+		MinLength:        cfg.Password.MinLength,
+		MaxLength:        cfg.Password.MaxLength,
+		RequireUpper:     cfg.Password.RequireUpper,
+		RequireLower:     cfg.Password.RequireLower,
+		RequireNumber:    cfg.Password.RequireNumber,
+		RequireSpecial:   cfg.Password.RequireSpecial,
+		MaxRepeats:       cfg.Password.MaxRepeats,
+		DictionaryWords:  cfg.Password.DictionaryWords,
+		DisallowUserInfo: cfg.Password.DisallowUserInfo,
+	})
+
+	// Hash passwords with the configured cost, a bounded number at a time
+	auth.SetPasswordHasher(auth.NewPasswordHasher(cfg.Security.BcryptCost, cfg.Security.PasswordHashConcurrency))
+
+	return cfg
+}
+
+// envelopeSerializer returns the response envelope configured by cfg.
+func envelopeSerializer(cfg *config.Config) response.Serializer {
+	return response.EnvelopeSerializer{
+		IncludeRequestID: cfg.Response.IncludeRequestID,
+		IncludeTimestamp: cfg.Response.IncludeTimestamp,
+		APIVersion:       cfg.Response.APIVersion,
+	}
+}
+
+// openDatabase connects to the database and configures its connection pool and transaction
+// retries.
+func openDatabase(cfg *config.Config) (*gorm.DB, error) {
+	// Fields tagged serializer:encrypted need the keyring before any row is read or written
+	if cfg.Security.EncryptionKeys != "" {
+		keyring, err := crypto.ParseKeyring(cfg.Security.EncryptionKeys)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEYS: %w", err)
+		}
+		crypto.SetKeyring(keyring)
+	}
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		database.CloseDB(db)
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+	database.SetTxRetry(database.TxRetry{
+		MaxAttempts: cfg.Database.TxMaxAttempts,
+		Backoff:     cfg.Database.TxRetryBackoff,
+		MaxBackoff:  cfg.Database.TxMaxBackoff,
+	})
+	return db, nil
+}
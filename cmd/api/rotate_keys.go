@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/crypto"
+)
+
+func newRotateKeysCmd() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "rotate-keys",
+		Short: "Re-wrap encrypted fields with the primary encryption key",
+		Long: "Re-wrap every value of the fields tagged serializer:encrypted that was encrypted with a\n" +
+			"key other than the first one in ENCRYPTION_KEYS. Only the data keys are re-encrypted.\n" +
+			"Once it reports nothing left to rotate, the other keys can be removed.",
+		Example: `  ENCRYPTION_KEYS=2027-01:<new>,2026-10:<old> api rotate-keys --dry-run`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := loadConfig()
+			if cfg.Security.EncryptionKeys == "" {
+				return errors.New("ENCRYPTION_KEYS is not set")
+			}
+			db, err := openDatabase(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer database.CloseDB(db)
+
+			keyring := crypto.CurrentKeyring()
+			results, rotateErr := database.RotateEncryptionKeys(cmd.Context(), db, keyring, models.All(), dryRun)
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "TABLE\tCOLUMN\tROTATED")
+			total := 0
+			for _, r := range results {
+				total += r.Rotated
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%d\n", r.Table, r.Column, r.Rotated)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if rotateErr != nil {
+				return rotateErr
+			}
+
+			switch {
+			case len(results) == 0:
+				fmt.Println("\nNo model has encrypted fields.")
+			case dryRun:
+				fmt.Printf("\n%d values would be re-wrapped with key %s.\n", total, keyring.PrimaryKeyID())
+			default:
+				fmt.Printf("\n%d values re-wrapped with key %s; the other keys can be removed once no\n"+
+					"instance writes with them.\n", total, keyring.PrimaryKeyID())
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Count the values to rotate without writing them")
+	return cmd
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/routes"
+)
+
+func newRoutesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "routes",
+		Short: "Print the route table",
+		Args:  cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			cfg := loadConfig()
+
+			// Handlers only use the database when serving requests, so the table can be
+			// built without connecting to it. Gin's debug route log would duplicate the table.
+			gin.DefaultWriter = io.Discard
+			_, table, err := newRouter(cfg, nil, routes.Services{})
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "METHOD\tPATH\tAUTH\tHANDLER\tMIDDLEWARES")
+			for _, r := range table.Routes() {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Path, authLabel(r), r.Handler, strings.Join(r.Middlewares, ","))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// authLabel summarizes the authentication a route requires.
+func authLabel(r routes.RouteInfo) string {
+	switch {
+	case len(r.Roles) > 0:
+		return "role:" + strings.Join(r.Roles, ",")
+	case r.AuthRequired:
+		return "jwt"
+	default:
+		return "-"
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/database"
+)
+
+func newSeedCmd() *cobra.Command {
+	var opts database.SeedOptions
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Insert demo data for local development",
+		Args:  cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			cfg := loadConfig()
+			if config.IsProduction() {
+				return errors.New("refusing to seed demo data in production")
+			}
+
+			db, err := openDatabase(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer database.CloseDB(db)
+
+			if err := database.Migrate(db); err != nil {
+				return err
+			}
+
+			created, err := database.Seed(context.Background(), db, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Seeded %d demo user(s)\n", created)
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&opts.Users, "users", 10, "Number of demo users (demo1, demo2, ...)")
+	cmd.Flags().StringVar(&opts.Password, "password", "Demo123!pass", "Password set on every demo user")
+	return cmd
+}
@@ -0,0 +1,736 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/entitlements"
+	"github.com/yeferson59/gin-template/internal/handlers"
+	"github.com/yeferson59/gin-template/internal/hooks"
+	"github.com/yeferson59/gin-template/internal/metering"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/operations"
+	"github.com/yeferson59/gin-template/internal/realtime"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/routes"
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/cache"
+	"github.com/yeferson59/gin-template/pkg/cachecontrol"
+	"github.com/yeferson59/gin-template/pkg/circuitbreaker"
+	"github.com/yeferson59/gin-template/pkg/events"
+	"github.com/yeferson59/gin-template/pkg/hibp"
+	"github.com/yeferson59/gin-template/pkg/httpclient"
+	"github.com/yeferson59/gin-template/pkg/listener"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/readonly"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+func newServeCmd() *cobra.Command {
+	var check, mock bool
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Start the HTTP server",
+		Args:  cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			return runServe(check, mock)
+		},
+	}
+	cmd.Flags().BoolVar(&check, "check", false, "Verify dependencies (database, migrations, secrets) and exit")
+	cmd.Flags().BoolVar(&mock, "mock", false, "Answer unimplemented endpoints with the examples of MOCK_SPEC (same as MOCK_MODE=true)")
+	return cmd
+}
+
+// runServe starts the server. With checkOnly it verifies the dependencies and returns instead,
+// and mock turns on mock mode like MOCK_MODE.
+func runServe(checkOnly, mock bool) (err error) {
+	cfg := loadConfig()
+	if mock {
+		cfg.Mock.Enabled = true
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"app_name":    cfg.Server.AppName,
+		"environment": cfg.Server.Environment,
+		"port":        cfg.Server.Port,
+		"db_driver":   cfg.Database.Driver,
+	}).Info("Starting application with configuration")
+
+	// Circuit breakers shared by every caller of a downstream dependency
+	logBreakerChange := func(name string, from, to circuitbreaker.State) {
+		logger.WithFields(map[string]interface{}{
+			"dependency": name,
+			"from":       from.String(),
+			"to":         to.String(),
+		}).Warn("Circuit breaker state changed")
+	}
+	breakers := circuitbreaker.NewRegistry(circuitbreaker.Settings{
+		FailureThreshold: cfg.HTTPClient.BreakerThreshold,
+		OpenTimeout:      cfg.HTTPClient.BreakerTimeout,
+		OnStateChange:    logBreakerChange,
+	})
+
+	// Reject passwords found in known breaches when enabled
+	if cfg.Security.PasswordBreachCheck {
+		httpClient := httpclient.New(httpclient.Config{
+			Name:         "hibp",
+			Timeout:      cfg.Security.PasswordBreachTimeout,
+			MaxRetries:   cfg.HTTPClient.MaxRetries,
+			RetryBackoff: cfg.HTTPClient.RetryBackoff,
+			MaxBackoff:   cfg.HTTPClient.MaxBackoff,
+			Breaker:      breakers.Get("hibp"),
+		})
+		validators.SetBreachChecker(hibp.NewClient(cfg.Security.PasswordBreachTimeout).WithHTTPClient(httpClient))
+		logger.Info("Password breach checking enabled")
+	}
+
+	// Resolve client locations when a GeoIP database is configured
+	geoLocator, err := app.NewGeoLocator(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	if geoLocator != nil {
+		security.SetGeoLocator(geoLocator)
+		logger.Info("GeoIP lookups enabled")
+	}
+
+	// Initialize database
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// Each subsystem registers how to stop it once started; the hooks run on shutdown, or when
+	// startup fails half-way, phase by phase within SHUTDOWN_TIMEOUT
+	shutdown := app.NewShutdownHooks()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		logShutdownResults(shutdown.Run(ctx))
+	}()
+	shutdown.Register("database", app.PhaseConnections, connectionCloseTimeout, func(context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+
+	// Write timestamps in the time zone of the user, or as Unix epoch for clients asking for it
+	if cfg.Response.LocalizeTimestamps {
+		response.SetSerializer(response.LocalizedSerializer{
+			Next:     envelopeSerializer(cfg),
+			Location: app.UserTimeZone(db),
+		})
+	}
+
+	// Fail fast while the database is unreachable
+	var dbBreaker *circuitbreaker.Breaker
+	if cfg.Database.BreakerEnabled {
+		dbBreaker = breakers.Register(database.BreakerName, circuitbreaker.Settings{
+			FailureThreshold: cfg.Database.BreakerThreshold,
+			OpenTimeout:      cfg.Database.BreakerTimeout,
+			OnStateChange:    logBreakerChange,
+		})
+		if err := database.UseCircuitBreaker(db, dbBreaker); err != nil {
+			return fmt.Errorf("failed to install database circuit breaker: %w", err)
+		}
+	}
+
+	// Serve authenticated users from memory instead of one query per request
+	if cfg.JWT.UserCacheTTL > 0 {
+		if err := auth.UseUserCache(db, auth.NewUserCache(cfg.JWT.UserCacheTTL, cfg.JWT.UserCacheSize)); err != nil {
+			return fmt.Errorf("failed to install user cache: %w", err)
+		}
+	}
+
+	// Serve repository reads from a cache until the tables they read are written to
+	queryCache, err := app.NewQueryCache(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid query cache configuration: %w", err)
+	}
+	if queryCache != nil {
+		if err := repository.UseQueryCache(db, queryCache); err != nil {
+			return fmt.Errorf("failed to install query cache: %w", err)
+		}
+		shutdown.Register("query_cache", app.PhaseConnections, connectionCloseTimeout, func(context.Context) error {
+			return queryCache.Close()
+		})
+		logger.WithField("backend", cfg.Database.QueryCacheBackend).Info("Query cache enabled")
+	}
+
+	// Reuse or estimate the totals of paginated listings instead of counting every page
+	counter, err := app.NewCounter(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid count configuration: %w", err)
+	}
+	if counter != nil {
+		if err := repository.UseCounter(db, counter); err != nil {
+			return fmt.Errorf("failed to install pagination counter: %w", err)
+		}
+	}
+
+	// Publish the lifecycle events of the audited models and record them in the audit log
+	bus := events.NewBus()
+	bus.Subscribe("*", func(_ context.Context, e events.Event) {
+		logger.WithFields(map[string]interface{}{
+			"event":      e.Name,
+			"entity_id":  e.EntityID,
+			"actor_id":   e.ActorID,
+			"request_id": e.RequestID,
+		}).Debug("Domain event published")
+	})
+	registry, err := app.NewHookRegistry(cfg, bus)
+	if err != nil {
+		return fmt.Errorf("failed to register model hooks: %w", err)
+	}
+	if err := hooks.Use(db, registry); err != nil {
+		return fmt.Errorf("failed to install model hooks: %w", err)
+	}
+
+	// With --check, verify every dependency without changing anything and exit
+	if checkOnly {
+		results, err := app.RunChecks(context.Background(), startupChecks(cfg, db), cfg.Server.StartupCheckTimeout)
+		printCheckResults(results)
+		return err
+	}
+
+	// Migrate models and apply versioned migrations
+	if cfg.Database.AutoMigrate {
+		if err := database.Migrate(db); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		logger.Info("Database migrations completed successfully")
+	}
+
+	// Verify dependencies before binding the port so a broken deploy fails fast
+	results, err := app.RunChecks(context.Background(), startupChecks(cfg, db), cfg.Server.StartupCheckTimeout)
+	logCheckResults(results)
+	if err != nil {
+		return err
+	}
+
+	// Record the start of this instance and, on shutdown, why it stopped, so that the next start
+	// reports the cause of the restart
+	instance := app.NewInstance(cfg, version)
+	if err := instance.RecordStart(context.Background(), db); err != nil {
+		logger.WithField("error", err.Error()).Warn("Failed to record instance start")
+	}
+	defer func() {
+		if err != nil {
+			instance.SetStopReason("startup failed: " + err.Error())
+		}
+	}()
+	// Runs even after a shutdown that took all of SHUTDOWN_TIMEOUT, before the database closes
+	shutdown.Register("instance_events", app.PhaseFlush, connectionCloseTimeout, func(ctx context.Context) error {
+		return instance.RecordStop(ctx, db)
+	})
+
+	// Start the background job queue and the async operation janitor.
+	// Canceling bgCtx stops every background loop on shutdown.
+	queue, err := app.NewJobQueue(cfg, db)
+	if err != nil {
+		return fmt.Errorf("invalid job queue configuration: %w", err)
+	}
+	ops := operations.NewManager(db, queue, cfg.Jobs.OperationRetention)
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	shutdown.Register("background_loops", app.PhaseWorkers, 0, func(context.Context) error {
+		stopBackground()
+		return nil
+	})
+	// Let running background jobs finish within the grace period
+	shutdown.Register("jobs", app.PhaseWorkers, 0, queue.Shutdown)
+	go ops.RunJanitor(bgCtx, cfg.Jobs.OperationCleanupInterval)
+
+	// Watch database availability for degraded mode and close the breaker as soon as it recovers
+	dbMonitor := database.NewMonitor(db, 2*time.Second, func(available bool) {
+		if available && dbBreaker != nil {
+			dbBreaker.Reset()
+		}
+	})
+	go dbMonitor.Run(bgCtx, cfg.Database.HealthCheckInterval)
+
+	// Export connection pool statistics and optionally size the pool from observed waits
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	if err := database.RegisterPoolMetrics(sqlDB, cfg.Database.Driver); err != nil {
+		return fmt.Errorf("failed to register database pool metrics: %w", err)
+	}
+	if cfg.Database.PoolStatsInterval > 0 {
+		poolMonitor := database.NewPoolMonitor(sqlDB, cfg.Database.MaxOpenConns, database.PoolTuning{
+			Enabled:           cfg.Database.PoolAutoTune,
+			MaxOpenConnsLimit: cfg.Database.PoolMaxOpenConnsLimit,
+			WaitThreshold:     cfg.Database.PoolWaitThreshold,
+		})
+		go poolMonitor.Run(bgCtx, cfg.Database.PoolStatsInterval)
+	}
+
+	// Back up the database every BACKUP_INTERVAL
+	if cfg.Backup.Interval > 0 {
+		backups, err := app.NewBackupService(cfg, db, backupHTTPClient(cfg))
+		if err != nil {
+			return fmt.Errorf("invalid backup configuration: %w", err)
+		}
+		go backups.Run(bgCtx, cfg.Backup.Interval)
+		logger.WithFields(map[string]interface{}{
+			"storage":  cfg.Backup.Storage,
+			"interval": cfg.Backup.Interval.String(),
+		}).Info("Scheduled database backups enabled")
+	}
+
+	// Delete expired records every CLEANUP_INTERVAL
+	cleaner, err := app.NewCleanupService(cfg, db)
+	if err != nil {
+		return fmt.Errorf("invalid cleanup configuration: %w", err)
+	}
+	if cfg.Jobs.CleanupInterval > 0 {
+		go cleaner.Run(bgCtx, cfg.Jobs.CleanupInterval)
+	}
+
+	// Run multi-step processes on the queue, resuming those interrupted by a restart
+	sagas, err := app.NewSagaRunner(cfg, db, queue)
+	if err != nil {
+		return fmt.Errorf("invalid saga configuration: %w", err)
+	}
+	if cfg.Jobs.SagaResumeInterval > 0 {
+		go sagas.Run(bgCtx, cfg.Jobs.SagaResumeInterval)
+	}
+
+	lifecycle := app.NewLifecycle()
+	svc := routes.Services{
+		Operations: ops,
+		Sagas:      sagas,
+		Breakers:   breakers,
+		DBMonitor:  dbMonitor,
+		Cache:      cache.NewMemory(cfg.Database.DegradedCacheSize),
+		Lifecycle:  lifecycle,
+		Instance:   instance,
+		RouteDebug: logger.NewRouteDebug(),
+		ReadOnly:   readonly.NewSwitch(cfg.Server.ReadOnly),
+	}
+	if cfg.Server.ReadOnly {
+		logger.Warn("Starting in read-only mode: mutating requests are rejected")
+	}
+	if cfg.Mock.Enabled {
+		logger.WithField("spec", cfg.Mock.Spec).Warn("Starting in mock mode: unimplemented endpoints answer with the examples of the specification")
+	}
+	if cfg.Metering.Enabled {
+		svc.Meter = metering.NewMeter(db, cfg.Metering.MonthlyQuota)
+		go svc.Meter.Run(bgCtx, cfg.Metering.FlushInterval)
+		// Write the API usage counted since the last flush
+		shutdown.Register("api_usage", app.PhaseFlush, 0, svc.Meter.Flush)
+	}
+	if cfg.Realtime.Enabled {
+		svc.Realtime = realtime.NewHub(cfg.Realtime.PresenceTTL, cfg.Realtime.MaxConnections)
+		go svc.Realtime.Run(bgCtx, svc.Realtime.TTL()/2)
+		// The servers do not wait for hijacked connections, so close the WebSockets with them
+		shutdown.Register("realtime", app.PhaseServers, 0, svc.Realtime.Close)
+	}
+	if cfg.Plans.Enabled {
+		svc.Entitlements = entitlements.NewService(db, cfg.Plans.DefaultPlan)
+	}
+	if cfg.Metrics.Enabled {
+		// Already validated by the startup checks
+		if svc.SLO, err = app.NewSLOTracker(cfg); err != nil {
+			return fmt.Errorf("invalid SLO configuration: %w", err)
+		}
+	}
+	if svc.Geo, err = app.NewGeoRules(cfg); err != nil {
+		return fmt.Errorf("invalid geo rules: %w", err)
+	}
+	if svc.Notifier, err = app.NewNotifier(cfg, db, queue); err != nil {
+		return fmt.Errorf("invalid notification configuration: %w", err)
+	}
+	if svc.Mailer, err = app.NewMailer(cfg, queue); err != nil {
+		return fmt.Errorf("invalid login verification configuration: %w", err)
+	}
+	if svc.InviteMailer, err = app.NewInvitationMailer(cfg, queue); err != nil {
+		return fmt.Errorf("invalid invitation configuration: %w", err)
+	}
+	if svc.EmailInbox = app.EmailInbox(cfg); svc.EmailInbox != nil {
+		logger.Warn("Emails are captured instead of sent; read them at /dev/emails/inbox")
+	}
+	if svc.Canary, err = app.NewCanaryRouter(cfg); err != nil {
+		return fmt.Errorf("invalid canary rollouts: %w", err)
+	}
+	if svc.CachePolicies, err = cachecontrol.ParseRules(cfg.Cache.Policies); err != nil {
+		return fmt.Errorf("invalid cache policies: %w", err)
+	}
+	if cfg.Cache.PurgeProvider != "" {
+		purgeClient := httpclient.New(httpclient.Config{
+			Name:         "cdn",
+			Timeout:      cfg.HTTPClient.Timeout,
+			MaxRetries:   cfg.HTTPClient.MaxRetries,
+			RetryBackoff: cfg.HTTPClient.RetryBackoff,
+			MaxBackoff:   cfg.HTTPClient.MaxBackoff,
+			Breaker:      breakers.Get("cdn"),
+		})
+		if svc.Purger, err = app.NewCachePurger(cfg, purgeClient); err != nil {
+			return fmt.Errorf("invalid cache purge configuration: %w", err)
+		}
+	}
+
+	if cfg.SAML.Enabled() {
+		samlClient := httpclient.New(httpclient.Config{
+			Name:         "saml",
+			Timeout:      cfg.HTTPClient.Timeout,
+			MaxRetries:   cfg.HTTPClient.MaxRetries,
+			RetryBackoff: cfg.HTTPClient.RetryBackoff,
+			MaxBackoff:   cfg.HTTPClient.MaxBackoff,
+			Breaker:      breakers.Get("saml"),
+		})
+		if svc.SAML, err = app.NewSAMLServiceProvider(bgCtx, cfg, samlClient); err != nil {
+			return fmt.Errorf("failed to load SAML IdP metadata: %w", err)
+		}
+		logger.WithField("entity_id", svc.SAML.EntityID).Info("SAML single sign-on enabled")
+	}
+
+	if cfg.Billing.Enabled() {
+		stripeClient := httpclient.New(httpclient.Config{
+			Name:         "stripe",
+			Timeout:      cfg.HTTPClient.Timeout,
+			MaxRetries:   cfg.HTTPClient.MaxRetries,
+			RetryBackoff: cfg.HTTPClient.RetryBackoff,
+			MaxBackoff:   cfg.HTTPClient.MaxBackoff,
+			Breaker:      breakers.Get("stripe"),
+		})
+		if svc.Billing, err = app.NewBilling(cfg, db, queue, stripeClient); err != nil {
+			return fmt.Errorf("invalid billing configuration: %w", err)
+		}
+	}
+
+	if svc.Assets, err = app.NewAssets(cfg); err != nil {
+		return fmt.Errorf("invalid static files configuration: %w", err)
+	}
+
+	// Report the object store on GET /health; a broken one only affects backups
+	objectStoreClient := httpclient.New(httpclient.Config{Name: "object_storage", Timeout: cfg.HTTPClient.Timeout})
+	if probe, err := app.NewObjectStoreProbe(cfg, objectStoreClient); err != nil {
+		logger.WithField("error", err.Error()).Warn("Invalid object storage configuration; not probing it")
+	} else if probe != nil {
+		svc.HealthProbes = map[string]func(context.Context) error{"object_storage": probe}
+	}
+	if svc.CrashReports, err = app.NewCrashReports(cfg, objectStoreClient); err != nil {
+		return fmt.Errorf("invalid crash report configuration: %w", err)
+	}
+	if svc.Watchdog, err = app.NewWatchdog(cfg); err != nil {
+		return fmt.Errorf("invalid watchdog configuration: %w", err)
+	} else if svc.Watchdog != nil {
+		go svc.Watchdog.Run(bgCtx, cfg.Server.WatchdogInterval)
+	}
+	if svc.Profiler, err = app.NewProfiler(cfg, objectStoreClient); err != nil {
+		return fmt.Errorf("invalid profiler configuration: %w", err)
+	} else if svc.Profiler != nil {
+		go svc.Profiler.Run(bgCtx)
+	}
+
+	router, table, err := newRouter(cfg, db, svc)
+	if err != nil {
+		return err
+	}
+	if gin.IsDebugging() {
+		logRouteTable(table)
+	}
+
+	// Create HTTP servers with timeouts: the public API and, when configured, the internal
+	// metrics and health listeners
+	servers := []*managedServer{{
+		name:       "api",
+		addrs:      cfg.Server.ListenAddrs,
+		unixSocket: cfg.Server.UnixSocket,
+		unixMode:   cfg.Server.UnixSocketMode,
+		server: &http.Server{
+			Handler:        handlers.Livez(router),
+			ReadTimeout:    cfg.Server.ReadTimeout,
+			WriteTimeout:   cfg.Server.WriteTimeout,
+			MaxHeaderBytes: int(cfg.Server.MaxBodySize),
+		},
+	}}
+	if len(cfg.Server.InternalAddrs) > 0 {
+		servers = append(servers, &managedServer{
+			name:  "internal",
+			addrs: cfg.Server.InternalAddrs,
+			server: &http.Server{
+				Handler:     handlers.Livez(newInternalRouter(cfg, db, svc)),
+				ReadTimeout: cfg.Server.ReadTimeout,
+			},
+		})
+	}
+
+	// Bind every address (or take over the sockets of the process being upgraded) before
+	// reporting the startup probe as successful
+	upgrader, err := listener.NewUpgrader(cfg.Server.PIDFile)
+	if err != nil {
+		return fmt.Errorf("failed to inherit listeners: %w", err)
+	}
+	for i, s := range servers {
+		if err := s.listen(upgrader); err != nil {
+			for _, opened := range servers[:i] {
+				listener.CloseAll(opened.listeners)
+			}
+			return fmt.Errorf("failed to start %s server: %w", s.name, err)
+		}
+	}
+	for _, s := range servers {
+		s.serve(cfg)
+		// Give outstanding requests SHUTDOWN_TIMEOUT to complete
+		shutdown.Register(s.name+"_server", app.PhaseServers, 0, s.server.Shutdown)
+	}
+	lifecycle.MarkStarted()
+	if upgrader.HasParent() {
+		logger.Info("Took over listeners from the previous process")
+	}
+	if err := upgrader.Ready(); err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to report readiness to the previous process")
+	}
+
+	// Wait for interrupt signal to gracefully shutdown the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	reason, upgraded := waitForShutdown(cfg, upgrader, quit)
+	instance.SetStopReason(reason)
+
+	logger.Info("Shutting down server...")
+	if upgraded {
+		// The new process is already accepting on the same sockets
+		lifecycle.MarkDraining()
+	} else {
+		drain(lifecycle, cfg.Server.ShutdownDelay, quit)
+	}
+	return nil
+}
+
+// connectionCloseTimeout bounds closing the database and caches, which runs even after a
+// shutdown that took all of SHUTDOWN_TIMEOUT.
+const connectionCloseTimeout = 5 * time.Second
+
+// managedServer is an http.Server with the listeners it serves on.
+type managedServer struct {
+	name       string
+	addrs      []string
+	unixSocket string
+	unixMode   os.FileMode
+	server     *http.Server
+	listeners  []net.Listener
+}
+
+// listen opens the configured addresses and unix socket. The socket file is removed when the
+// server shuts down and closes its listeners.
+// After a binary upgrade the listeners inherited from the previous process are used instead.
+func (s *managedServer) listen(upgrader *listener.Upgrader) error {
+	listeners, err := upgrader.Listen(s.name, func() ([]net.Listener, error) {
+		listeners, err := listener.OpenAll(s.addrs)
+		if err != nil {
+			return nil, err
+		}
+		if s.unixSocket != "" {
+			l, err := listener.Unix(s.unixSocket, s.unixMode)
+			if err != nil {
+				listener.CloseAll(listeners)
+				return nil, fmt.Errorf("listen on unix socket %s: %w", s.unixSocket, err)
+			}
+			listeners = append(listeners, l)
+		}
+		return listeners, nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(listeners) == 0 {
+		return fmt.Errorf("no listen addresses configured")
+	}
+	s.listeners = listeners
+	return nil
+}
+
+// serve starts serving on every listener in the background.
+func (s *managedServer) serve(cfg *config.Config) {
+	for _, l := range s.listeners {
+		go func(l net.Listener) {
+			logger.WithFields(map[string]interface{}{
+				"server":        s.name,
+				"addr":          l.Addr().String(),
+				"network":       l.Addr().Network(),
+				"environment":   cfg.Server.Environment,
+				"read_timeout":  cfg.Server.ReadTimeout,
+				"write_timeout": cfg.Server.WriteTimeout,
+			}).Info("Starting HTTP server")
+
+			if err := s.server.Serve(l); err != nil && err != http.ErrServerClosed {
+				logger.WithField("error", err.Error()).Fatal("Failed to start server")
+			}
+		}(l)
+	}
+}
+
+// waitForShutdown blocks until a signal arrives on quit or, with GRACEFUL_UPGRADE, until a
+// SIGHUP-triggered upgrade has started a new process that is ready to serve. It returns the
+// reason of the shutdown and whether the process was replaced by an upgrade. A failed upgrade
+// keeps the current process.
+func waitForShutdown(cfg *config.Config, upgrader *listener.Upgrader, quit <-chan os.Signal) (string, bool) {
+	upgrade := make(chan os.Signal, 1)
+	if cfg.Server.GracefulUpgrade {
+		signal.Notify(upgrade, syscall.SIGHUP)
+		defer signal.Stop(upgrade)
+	}
+
+	for {
+		select {
+		case sig := <-quit:
+			return "signal: " + sig.String(), false
+		case <-upgrade:
+			logger.Info("Upgrade requested, starting new process")
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.UpgradeTimeout)
+			err := upgrader.Upgrade(ctx)
+			cancel()
+			if err != nil {
+				logger.WithField("error", err.Error()).Error("Upgrade failed, the current process keeps serving")
+				continue
+			}
+			logger.Info("New process is ready, draining connections")
+			return "upgrade", true
+		}
+	}
+}
+
+// drain flips the readiness probe to not ready and keeps serving for delay, so that load
+// balancers and Kubernetes endpoints stop routing new requests to this instance before its
+// connections are closed. A second signal on quit skips the rest of the delay.
+func drain(lifecycle *app.Lifecycle, delay time.Duration, quit <-chan os.Signal) {
+	lifecycle.MarkDraining()
+	if delay <= 0 {
+		return
+	}
+
+	logger.WithField("delay", delay.String()).Info("Readiness set to not ready, waiting before draining connections")
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-quit:
+		logger.Warn("Second shutdown signal received, skipping shutdown delay")
+	}
+}
+
+// newRouter builds the Gin engine with the global middleware pipeline and every API route.
+func newRouter(cfg *config.Config, db *gorm.DB, svc routes.Services) (*gin.Engine, *routes.Table, error) {
+	// Set Gin mode based on environment
+	switch {
+	case config.IsProduction():
+		gin.SetMode(gin.ReleaseMode)
+	case config.IsTest():
+		gin.SetMode(gin.TestMode)
+	default:
+		gin.SetMode(gin.DebugMode)
+	}
+
+	router, err := app.NewBuilder(cfg,
+		app.WithExemptPaths(routes.IsHealthPath),
+		app.WithSLOTracker(svc.SLO),
+		app.WithCrashReports(svc.CrashReports),
+		app.WithWatchdog(svc.Watchdog),
+		app.WithProfiler(svc.Profiler),
+		app.WithRouteDebug(svc.RouteDebug),
+	).Build()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid router configuration: %w", err)
+	}
+
+	table := routes.RegisterAPIRoutes(router, db, cfg, svc)
+	return router, table, nil
+}
+
+// logRouteTable logs one entry per route with its middlewares and auth requirements.
+func logRouteTable(table *routes.Table) {
+	for _, r := range table.Routes() {
+		logger.WithFields(map[string]interface{}{
+			"method":        r.Method,
+			"path":          r.Path,
+			"handler":       r.Handler,
+			"middlewares":   strings.Join(r.Middlewares, ","),
+			"auth_required": r.AuthRequired,
+			"roles":         strings.Join(r.Roles, ","),
+		}).Info("Route registered")
+	}
+}
+
+// newInternalRouter builds the Gin engine for the internal listeners (metrics and health).
+func newInternalRouter(cfg *config.Config, db *gorm.DB, svc routes.Services) *gin.Engine {
+	router := gin.New()
+	router.Use(middlewares.ErrorHandler())
+	routes.RegisterInternalRoutes(router, db, cfg, svc)
+	return router
+}
+
+// startupChecks lists the dependencies verified before the server starts.
+// Migrations are applied before the checks run unless DB_AUTO_MIGRATE is off,
+// so a pending migration always means the schema is behind the code.
+func startupChecks(cfg *config.Config, db *gorm.DB) []app.Check {
+	return append(app.ConfigChecks(cfg), app.DatabaseChecks(db, true)...)
+}
+
+// logCheckResults logs failed checks as errors (required) or warnings (optional).
+func logCheckResults(results []app.CheckResult) {
+	for _, r := range results {
+		entry := logger.WithFields(map[string]interface{}{
+			"check":    r.Name,
+			"duration": r.Duration.String(),
+		})
+		switch {
+		case r.Err == nil:
+			entry.Debug("Startup check passed")
+		case r.Required:
+			entry.WithField("error", r.Err.Error()).Error("Startup check failed")
+		default:
+			entry.WithField("error", r.Err.Error()).Warn("Startup check failed")
+		}
+	}
+}
+
+// logShutdownResults logs the outcome of each shutdown hook.
+func logShutdownResults(results []app.ShutdownResult) {
+	for _, r := range results {
+		entry := logger.WithFields(map[string]interface{}{
+			"hook":     r.Name,
+			"duration": r.Duration.String(),
+		})
+		if r.Err != nil {
+			entry.WithField("error", r.Err.Error()).Error("Shutdown hook failed")
+		} else {
+			entry.Info("Shutdown hook completed")
+		}
+	}
+}
+
+// printCheckResults prints one line per check for --check.
+func printCheckResults(results []app.CheckResult) {
+	for _, r := range results {
+		switch {
+		case r.Err == nil:
+			fmt.Printf("ok    %-12s %s\n", r.Name, r.Duration.Round(time.Millisecond))
+		case r.Required:
+			fmt.Printf("FAIL  %-12s %v\n", r.Name, r.Err)
+		default:
+			fmt.Printf("WARN  %-12s %v\n", r.Name, r.Err)
+		}
+	}
+}
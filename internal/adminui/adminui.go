@@ -0,0 +1,45 @@
+// Package adminui serves the embedded single-page admin dashboard. The dashboard is a static
+// client of the admin API: it signs in through /api/auth/login and sends the JWT with every
+// request, so it has no privileges of its own.
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed static
+var static embed.FS
+
+// Register serves the dashboard under prefix (e.g. "/admin"). Unknown paths fall back to
+// index.html so that client-side routes survive a page reload.
+func Register(router gin.IRouter, prefix string) {
+	prefix = "/" + strings.Trim(prefix, "/")
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // the embedded directory is part of the binary
+	}
+	fileServer := http.StripPrefix(prefix, http.FileServer(http.FS(files)))
+
+	serve := func(c *gin.Context) {
+		name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+		if name == "" || !exists(files, name) {
+			c.Request.URL.Path = prefix + "/"
+		}
+		// Always revalidate so a new release is picked up immediately.
+		c.Header("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	}
+	router.GET(prefix+"/*filepath", serve)
+	router.HEAD(prefix+"/*filepath", serve)
+}
+
+func exists(files fs.FS, name string) bool {
+	info, err := fs.Stat(files, name)
+	return err == nil && !info.IsDir()
+}
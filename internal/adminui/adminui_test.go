@@ -0,0 +1,51 @@
+package adminui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterServesDashboard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Register(r, "/admin/")
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{"/admin/", http.StatusOK, "text/html", `<script src="app.js">`},
+		{"/admin/app.js", http.StatusOK, "javascript", "/api/admin/users"},
+		{"/admin/style.css", http.StatusOK, "text/css", "body"},
+		// Unknown paths fall back to the SPA entry point.
+		{"/admin/users/42", http.StatusOK, "text/html", `<script src="app.js">`},
+		{"/admin/../go.mod", http.StatusOK, "text/html", `<script src="app.js">`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.path, w.Code, tt.status)
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, tt.contentType) {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.path, ct, tt.contentType)
+		}
+		if !strings.Contains(w.Body.String(), tt.contains) {
+			t.Errorf("%s: body does not contain %q", tt.path, tt.contains)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("/admin: status = %d, want redirect to /admin/", w.Code)
+	}
+}
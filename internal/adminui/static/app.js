@@ -0,0 +1,191 @@
+// Admin dashboard: a thin client of the admin API. The JWT is kept in sessionStorage
+// so it is dropped when the browser tab is closed.
+(function () {
+  'use strict';
+
+  const TOKEN_KEY = 'admin_token';
+  const tabs = ['users', 'audit', 'flags', 'health'];
+  const $ = (selector) => document.querySelector(selector);
+
+  let page = 1;
+  let totalPages = 1;
+
+  function token() {
+    return sessionStorage.getItem(TOKEN_KEY);
+  }
+
+  async function api(path, options = {}) {
+    const headers = { 'Content-Type': 'application/json', ...(options.headers || {}) };
+    if (token()) {
+      headers.Authorization = 'Bearer ' + token();
+    }
+    const res = await fetch(path, { ...options, headers });
+    let body = null;
+    try {
+      body = await res.json();
+    } catch (_) {
+      // Non-JSON responses (e.g. 404 from an unknown route) carry no envelope.
+    }
+    if (res.status === 401) {
+      signOut('Your session has expired. Please sign in again.');
+    }
+    return { status: res.status, body };
+  }
+
+  function errorMessage(result) {
+    const err = result.body && result.body.error;
+    return err ? err.message + (err.details ? ': ' + err.details : '') : 'Request failed with status ' + result.status;
+  }
+
+  function showMessage(text) {
+    const el = $('#message');
+    el.textContent = text || '';
+    el.hidden = !text;
+  }
+
+  function cell(row, text) {
+    const td = document.createElement('td');
+    td.textContent = text == null ? '' : String(text);
+    row.appendChild(td);
+  }
+
+  function fillTable(section, rows, columns) {
+    const tbody = $('#' + section + ' tbody');
+    tbody.replaceChildren();
+    for (const item of rows) {
+      const tr = document.createElement('tr');
+      columns.forEach((col) => cell(tr, typeof col === 'function' ? col(item) : item[col]));
+      tbody.appendChild(tr);
+    }
+  }
+
+  function formatDate(value) {
+    return value ? new Date(value).toLocaleString() : '';
+  }
+
+  async function loadUsers() {
+    const params = new URLSearchParams(new FormData($('#user-filter')));
+    params.set('page', page);
+    for (const [key, value] of [...params]) {
+      if (!value) params.delete(key);
+    }
+    const result = await api('/api/admin/users?' + params);
+    if (result.status !== 200) {
+      showMessage(result.status === 403 ? 'This account is not an administrator.' : errorMessage(result));
+      return;
+    }
+    const data = result.body.data;
+    totalPages = Math.max(data.pagination.total_pages, 1);
+    fillTable('users', data.users, ['id', 'username', 'email', 'role', (u) => formatDate(u.created_at)]);
+    $('#users .page-info').textContent = 'Page ' + data.pagination.page + ' of ' + totalPages + ' (' + data.pagination.total + ' users)';
+  }
+
+  // loadOptional renders endpoints that are not part of every build. A 404 means the
+  // feature is not enabled on this server.
+  async function loadOptional(section, path, key, columns, label) {
+    const result = await api(path);
+    if (result.status === 404) {
+      fillTable(section, [], columns);
+      showMessage(label + ' are not available on this server.');
+      return;
+    }
+    if (result.status !== 200) {
+      showMessage(errorMessage(result));
+      return;
+    }
+    const data = result.body.data || {};
+    fillTable(section, Array.isArray(data) ? data : data[key] || [], columns);
+  }
+
+  async function loadHealth() {
+    const result = await api('/health/');
+    const data = result.body && result.body.data;
+    const dl = $('#health dl');
+    dl.replaceChildren();
+    if (!data) {
+      showMessage(errorMessage(result));
+      return;
+    }
+    const entries = [['status', data.status], ['version', data.version], ['checked at', formatDate(data.timestamp)]];
+    for (const [name, state] of Object.entries(data.services || {})) entries.push(['service: ' + name, state]);
+    for (const [name, state] of Object.entries(data.circuit_breakers || {})) entries.push(['breaker: ' + name, state]);
+    for (const [name, value] of entries) {
+      const dt = document.createElement('dt');
+      const dd = document.createElement('dd');
+      dt.textContent = name;
+      dd.textContent = value;
+      if (['ok', 'degraded', 'error'].includes(value)) dd.className = value;
+      dl.append(dt, dd);
+    }
+  }
+
+  const loaders = {
+    users: loadUsers,
+    audit: () => loadOptional('audit', '/api/admin/audit-logs', 'logs', [(l) => formatDate(l.created_at), 'actor', 'action', 'target'], 'Audit logs'),
+    flags: () => loadOptional('flags', '/api/admin/feature-flags', 'flags', ['name', (f) => (f.enabled ? 'yes' : 'no'), 'description'], 'Feature flags'),
+    health: loadHealth,
+  };
+
+  function show(tab) {
+    if (!tabs.includes(tab)) tab = 'users';
+    showMessage('');
+    for (const name of tabs) {
+      $('#' + name).hidden = name !== tab;
+      $('nav a[data-tab="' + name + '"]').classList.toggle('active', name === tab);
+    }
+    loaders[tab]();
+  }
+
+  function render() {
+    const signedIn = Boolean(token());
+    $('#login').hidden = signedIn;
+    $('#tabs').hidden = !signedIn;
+    $('#logout').hidden = !signedIn;
+    if (signedIn) {
+      show(location.hash.slice(1));
+    } else {
+      tabs.forEach((name) => ($('#' + name).hidden = true));
+    }
+  }
+
+  function signOut(message) {
+    sessionStorage.removeItem(TOKEN_KEY);
+    render();
+    showMessage(message);
+  }
+
+  $('#login').addEventListener('submit', async (event) => {
+    event.preventDefault();
+    const form = new FormData(event.target);
+    const result = await api('/api/auth/login', {
+      method: 'POST',
+      body: JSON.stringify({ username: form.get('username'), password: form.get('password') }),
+    });
+    if (result.status !== 200) {
+      showMessage(errorMessage(result));
+      return;
+    }
+    sessionStorage.setItem(TOKEN_KEY, result.body.data.token);
+    event.target.reset();
+    render();
+  });
+
+  $('#logout').addEventListener('click', () => signOut(''));
+
+  $('#user-filter').addEventListener('submit', (event) => {
+    event.preventDefault();
+    page = 1;
+    loadUsers();
+  });
+
+  $('#users .pager').addEventListener('click', (event) => {
+    const dir = event.target.dataset.page;
+    if (dir === 'prev' && page > 1) page--;
+    else if (dir === 'next' && page < totalPages) page++;
+    else return;
+    loadUsers();
+  });
+
+  window.addEventListener('hashchange', render);
+  render();
+})();
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yeferson59/gin-template/internal/assets"
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+var errStaticPath = errors.New("STATIC_PATH must be a path prefix such as /static, outside /api")
+
+// NewAssets hashes the static files served under STATIC_PATH: the directory STATIC_DIR, or the
+// files embedded in the binary when it is empty. It returns nil when STATIC_ENABLED is not set.
+func NewAssets(cfg *config.Config) (*assets.Server, error) {
+	if !cfg.Static.Enabled {
+		return nil, nil
+	}
+	// Files are served under a catch-all route, which cannot share a segment with other routes
+	prefix := "/" + strings.Trim(cfg.Static.Path, "/")
+	if prefix == "/" || prefix == "/api" || strings.HasPrefix(prefix, "/api/") {
+		return nil, errStaticPath
+	}
+	files := assets.Embedded()
+	if cfg.Static.Dir != "" {
+		info, err := os.Stat(cfg.Static.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("STATIC_DIR: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("STATIC_DIR %s is not a directory", cfg.Static.Dir)
+		}
+		files = os.DirFS(cfg.Static.Dir)
+	}
+	return assets.New(files, prefix)
+}
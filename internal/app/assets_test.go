@@ -0,0 +1,50 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewAssets(t *testing.T) {
+	cfg := &config.Config{Static: config.StaticConfig{Path: "/static"}}
+	if s, err := NewAssets(cfg); s != nil || err != nil {
+		t.Errorf("NewAssets() without STATIC_ENABLED = %v, %v", s, err)
+	}
+
+	cfg.Static.Enabled = true
+	s, err := NewAssets(cfg)
+	if err != nil {
+		t.Fatalf("NewAssets() with the embedded files returned error: %v", err)
+	}
+	if url := s.URL("README.txt"); url == "/static/README.txt" {
+		t.Errorf("URL(README.txt) = %q, want the embedded file hashed", url)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "logo.svg"), []byte("<svg/>"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Static.Dir = dir
+	s, err = NewAssets(cfg)
+	if err != nil {
+		t.Fatalf("NewAssets() with STATIC_DIR returned error: %v", err)
+	}
+	if url := s.URL("logo.svg"); !strings.HasPrefix(url, "/static/logo.") || url == "/static/logo.svg" {
+		t.Errorf("URL(logo.svg) = %q, want the file of STATIC_DIR hashed", url)
+	}
+
+	for _, invalid := range []config.StaticConfig{
+		{Enabled: true, Path: "/static", Dir: filepath.Join(dir, "missing")},
+		{Enabled: true, Path: "/static", Dir: filepath.Join(dir, "logo.svg")},
+		{Enabled: true, Path: "/"},
+		{Enabled: true, Path: "/api/static"},
+	} {
+		if _, err := NewAssets(&config.Config{Static: invalid}); err == nil {
+			t.Errorf("NewAssets(%+v) accepted an invalid configuration", invalid)
+		}
+	}
+}
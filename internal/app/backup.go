@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/backup"
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/storage"
+)
+
+// Backup storages (BACKUP_STORAGE).
+const (
+	BackupStorageLocal = "local"
+	BackupStorageS3    = "s3"
+)
+
+// NewBackupStorage creates the storage of database backups configured in cfg.
+func NewBackupStorage(cfg *config.Config, httpClient *http.Client) (storage.Storage, error) {
+	b := cfg.Backup
+	switch b.Storage {
+	case BackupStorageLocal:
+		if b.Dir == "" {
+			return nil, fmt.Errorf("the local backup storage needs BACKUP_DIR")
+		}
+		return storage.NewLocal(b.Dir), nil
+	case BackupStorageS3:
+		return newBackupBucket(cfg, httpClient)
+	default:
+		return nil, fmt.Errorf("unknown backup storage %q (want %s or %s)", b.Storage, BackupStorageLocal, BackupStorageS3)
+	}
+}
+
+// newBackupBucket creates the storage of the S3 bucket of the backups.
+func newBackupBucket(cfg *config.Config, httpClient *http.Client) (*storage.S3, error) {
+	b := cfg.Backup
+	return storage.NewS3(storage.S3Config{
+		Bucket:          b.S3Bucket,
+		Region:          b.S3Region,
+		Endpoint:        b.S3Endpoint,
+		PathStyle:       b.S3PathStyle,
+		AccessKeyID:     b.S3AccessKeyID,
+		SecretAccessKey: b.S3SecretAccessKey,
+	}, httpClient)
+}
+
+// newSharedStorage creates the storage of kind (local or s3) of what, such as crash reports:
+// dir on the local disk, named by dirEnv in errors, or the S3 bucket of the backups.
+func newSharedStorage(cfg *config.Config, httpClient *http.Client, what, kind, dir, dirEnv string) (storage.Storage, error) {
+	switch kind {
+	case BackupStorageLocal:
+		if dir == "" {
+			return nil, fmt.Errorf("the local %s storage needs %s", what, dirEnv)
+		}
+		return storage.NewLocal(dir), nil
+	case BackupStorageS3:
+		return newBackupBucket(cfg, httpClient)
+	default:
+		return nil, fmt.Errorf("unknown %s storage %q (want %s or %s)", what, kind, BackupStorageLocal, BackupStorageS3)
+	}
+}
+
+// NewBackupService creates the backup service of the database db configured in cfg.
+func NewBackupService(cfg *config.Config, db *gorm.DB, httpClient *http.Client) (*backup.Service, error) {
+	b := cfg.Backup
+	if b.Interval < 0 || b.RetentionCount < 0 || b.RetentionAge < 0 {
+		return nil, fmt.Errorf("BACKUP_INTERVAL, BACKUP_RETENTION_COUNT and BACKUP_RETENTION_AGE must not be negative")
+	}
+	store, err := NewBackupStorage(cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	dumper, err := backup.NewDumper(cfg.Database.Driver, cfg.Database.DSN, db)
+	if err != nil {
+		return nil, err
+	}
+	retention := backup.Retention{Count: b.RetentionCount, MaxAge: b.RetentionAge}
+	return backup.NewService(dumper, store, b.Prefix, retention), nil
+}
+
+// NewObjectStoreProbe returns the health probe of the S3 bucket holding the backups, which
+// checks that it exists and is accessible, or nil when backups are stored on the local disk.
+func NewObjectStoreProbe(cfg *config.Config, httpClient *http.Client) (func(context.Context) error, error) {
+	if cfg.Backup.Storage != BackupStorageS3 {
+		return nil, nil
+	}
+	store, err := NewBackupStorage(cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return store.(*storage.S3).Ping, nil
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewBackupService(t *testing.T) {
+	database := config.DatabaseConfig{Driver: "sqlite", DSN: "data/app.db"}
+	tests := []struct {
+		name    string
+		backup  config.BackupConfig
+		wantErr bool
+	}{
+		{name: "local", backup: config.BackupConfig{Storage: "local", Dir: "backups", RetentionCount: 7}},
+		{name: "local without dir", backup: config.BackupConfig{Storage: "local"}, wantErr: true},
+		{name: "s3", backup: config.BackupConfig{Storage: "s3", S3Bucket: "b", S3Region: "eu-west-1", S3AccessKeyID: "id", S3SecretAccessKey: "secret"}},
+		{name: "s3 without credentials", backup: config.BackupConfig{Storage: "s3", S3Bucket: "b", S3Region: "eu-west-1"}, wantErr: true},
+		{name: "unknown storage", backup: config.BackupConfig{Storage: "gcs"}, wantErr: true},
+		{name: "negative interval", backup: config.BackupConfig{Storage: "local", Dir: "backups", Interval: -time.Hour}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Database: database, Backup: tt.backup}
+			service, err := NewBackupService(cfg, nil, http.DefaultClient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewBackupService() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && service == nil {
+				t.Fatal("expected a service")
+			}
+		})
+	}
+}
+
+func TestNewObjectStoreProbe(t *testing.T) {
+	cfg := &config.Config{Backup: config.BackupConfig{Storage: "local", Dir: "backups"}}
+	if probe, err := NewObjectStoreProbe(cfg, http.DefaultClient); err != nil || probe != nil {
+		t.Errorf("NewObjectStoreProbe() = %v, %v; want no probe for local backups", probe != nil, err)
+	}
+	cfg.Backup = config.BackupConfig{Storage: "s3", S3Bucket: "b", S3Region: "eu-west-1", S3AccessKeyID: "id", S3SecretAccessKey: "secret"}
+	if probe, err := NewObjectStoreProbe(cfg, http.DefaultClient); err != nil || probe == nil {
+		t.Errorf("NewObjectStoreProbe() = %v, %v; want the bucket probed", probe != nil, err)
+	}
+	cfg.Backup.S3AccessKeyID = ""
+	if _, err := NewObjectStoreProbe(cfg, http.DefaultClient); err == nil {
+		t.Error("NewObjectStoreProbe() accepted S3 storage without credentials")
+	}
+}
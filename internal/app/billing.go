@@ -0,0 +1,48 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/billing"
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/jobs"
+)
+
+// NewBilling creates the Stripe billing service, or nil when STRIPE_WEBHOOK_SECRET is not set.
+// Customers are created with httpClient when BILLING_CREATE_CUSTOMERS is set.
+func NewBilling(cfg *config.Config, db *gorm.DB, queue jobs.Queue, httpClient *http.Client) (*billing.Service, error) {
+	b := cfg.Billing
+	if err := ValidateBillingConfig(cfg); err != nil || !b.Enabled() {
+		return nil, err
+	}
+	plans, err := billing.ParsePricePlans(b.PricePlans)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := billing.Options{
+		Queue:            queue,
+		WebhookSecret:    b.StripeWebhookSecret,
+		WebhookTolerance: b.WebhookTolerance,
+		PricePlans:       plans,
+	}
+	if b.CreateCustomers {
+		opts.Stripe = billing.NewStripe(b.StripeSecretKey, httpClient).WithBaseURL(b.StripeAPIURL)
+	}
+	return billing.NewService(db, opts), nil
+}
+
+// ValidateBillingConfig checks the Stripe settings.
+func ValidateBillingConfig(cfg *config.Config) error {
+	b := cfg.Billing
+	if b.CreateCustomers && (b.StripeSecretKey == "" || !b.Enabled()) {
+		return fmt.Errorf("BILLING_CREATE_CUSTOMERS needs STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET")
+	}
+	if _, err := billing.ParsePricePlans(b.PricePlans); err != nil {
+		return fmt.Errorf("invalid STRIPE_PRICE_PLANS: %w", err)
+	}
+	return nil
+}
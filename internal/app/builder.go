@@ -0,0 +1,364 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/pkg/crashreport"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/profiler"
+	"github.com/yeferson59/gin-template/pkg/slo"
+	"github.com/yeferson59/gin-template/pkg/watchdog"
+)
+
+// Names of the built-in pipeline stages, in their default order. Metrics come first so they
+// see the status of recovered panics and shed requests; load shedding comes right after
+// recovery so rejected requests cost as little as possible.
+const (
+	StageMetrics         = "metrics"
+	StageProfiler        = "profiler" // CPU and heap snapshots when latency or errors degrade, when PROFILER_ENABLED is set
+	StageRecord          = "record"   // sanitized copies of requests for `api replay`, when RECORD_TRAFFIC is set
+	StageRecovery        = "recovery"
+	StageLoadShedding    = "load_shedding"
+	StageLogger          = "logger"
+	StageSecurityHeaders = "security_headers"
+	StageRequestID       = "request_id" // request IDs and W3C trace context propagation
+	StageGeoIP           = "geoip"      // client location, when a GeoIP database is configured
+	StageCORS            = "cors"
+	StageCompression     = "compression"
+	StageRateLimit       = "rate_limit"
+	StageFaultInjection  = "fault_injection" // outside production, when FAULT_INJECTION_ENABLED is set
+)
+
+// Middleware is a named stage of the global middleware pipeline.
+type Middleware struct {
+	Name    string
+	Handler gin.HandlerFunc
+}
+
+// Builder assembles the Gin engine with the global middleware pipeline and the server profile
+// (see Profile). The built-in stages
+// are enabled from the configuration (CORS_ENABLED, COMPRESSION_ENABLED, RATE_LIMIT_ENABLED),
+// MIDDLEWARE_DISABLED removes any of them and MIDDLEWARE_ORDER moves stages to the front.
+// Options customize the pipeline from code:
+//
+//	router, err := app.NewBuilder(cfg,
+//		app.WithMiddleware("tenant", tenantMiddleware),
+//		app.WithoutMiddleware(app.StageCORS),
+//	).Build()
+type Builder struct {
+	cfg      *config.Config
+	disabled []string
+	order    []string
+	exempt   func(path string) bool
+	tracker  *slo.Tracker
+	reports  *crashreport.Store
+	watchdog *watchdog.Watchdog
+	profiler *profiler.Profiler
+	debug    *logger.RouteDebug
+	profile  *Profile
+	extra    []Middleware
+}
+
+// Option customizes a Builder.
+type Option func(*Builder)
+
+// WithMiddleware adds a stage after the built-in ones, or replaces the stage with that name.
+func WithMiddleware(name string, handler gin.HandlerFunc) Option {
+	return func(b *Builder) {
+		b.extra = append(b.extra, Middleware{Name: name, Handler: handler})
+	}
+}
+
+// WithoutMiddleware removes stages, in addition to MIDDLEWARE_DISABLED.
+func WithoutMiddleware(names ...string) Option {
+	return func(b *Builder) {
+		b.disabled = append(b.disabled, names...)
+	}
+}
+
+// WithOrder moves the named stages to the front of the pipeline, in that order, replacing
+// MIDDLEWARE_ORDER. The other stages keep their relative order.
+func WithOrder(names ...string) Option {
+	return func(b *Builder) {
+		b.order = names
+	}
+}
+
+// WithExemptPaths sets the paths that bypass load shedding and rate limiting, such as health
+// probes. The metrics path is always exempt from rate limiting.
+func WithExemptPaths(exempt func(path string) bool) Option {
+	return func(b *Builder) {
+		b.exempt = exempt
+	}
+}
+
+// WithSLOTracker feeds the metrics stage's request outcomes to tracker.
+func WithSLOTracker(tracker *slo.Tracker) Option {
+	return func(b *Builder) {
+		b.tracker = tracker
+	}
+}
+
+// WithCrashReports saves a crash report of each panic recovered by the recovery stage to
+// reports.
+func WithCrashReports(reports *crashreport.Store) Option {
+	return func(b *Builder) {
+		b.reports = reports
+	}
+}
+
+// WithWatchdog makes the load shedding stage also reject requests while w reports the process
+// overloaded.
+func WithWatchdog(w *watchdog.Watchdog) Option {
+	return func(b *Builder) {
+		b.watchdog = w
+	}
+}
+
+// WithProfiler adds the profiler stage, feeding p with the outcome of each request.
+func WithProfiler(p *profiler.Profiler) Option {
+	return func(b *Builder) {
+		b.profiler = p
+	}
+}
+
+// WithRouteDebug makes the logger stage log the debug entries of the requests of the routes
+// debugged by routes, whatever the log level.
+func WithRouteDebug(routes *logger.RouteDebug) Option {
+	return func(b *Builder) {
+		b.debug = routes
+	}
+}
+
+// WithProfile tunes the engine with profile, replacing the one of SERVER_PROFILE.
+func WithProfile(profile Profile) Option {
+	return func(b *Builder) {
+		b.profile = &profile
+	}
+}
+
+// NewSLOTracker creates the tracker for the objectives in cfg (SLO_* variables).
+func NewSLOTracker(cfg *config.Config) (*slo.Tracker, error) {
+	m := cfg.Metrics
+	tracker, err := slo.NewTracker(slo.Objective{
+		Availability:  m.SLOAvailability,
+		Latency:       m.SLOLatency,
+		LatencyTarget: m.SLOLatencyTarget,
+	}, m.SLOWindow)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := slo.ParseLatencyOverrides(m.SLOLatencyOverrides)
+	if err != nil {
+		return nil, err
+	}
+	if err := tracker.SetLatency(overrides); err != nil {
+		return nil, err
+	}
+	return tracker, nil
+}
+
+// NewBuilder creates a Builder for cfg.
+func NewBuilder(cfg *config.Config, opts ...Option) *Builder {
+	b := &Builder{
+		cfg:      cfg,
+		disabled: append([]string(nil), cfg.Middleware.Disabled...),
+		order:    cfg.Middleware.Order,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// defaultStages returns the built-in stages enabled by the configuration.
+func (b *Builder) defaultStages() ([]Middleware, error) {
+	cfg := b.cfg
+	var stages []Middleware
+	if cfg.Metrics.Enabled {
+		stages = append(stages, Middleware{StageMetrics, middlewares.Metrics(b.tracker, b.infrastructurePath)})
+	}
+	if b.profiler != nil {
+		stages = append(stages, Middleware{StageProfiler, skipPaths(middlewares.Profiler(b.profiler), b.infrastructurePath)})
+	}
+	if cfg.Recording.Enabled {
+		rec, err := NewRecorder(cfg)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, Middleware{StageRecord, skipPaths(middlewares.Record(rec), b.infrastructurePath)})
+	}
+	var overloaded func() bool
+	if b.watchdog != nil {
+		overloaded = b.watchdog.Overloaded
+	}
+	stages = append(stages,
+		Middleware{StageRecovery, middlewares.ErrorHandlerWithReports(b.reports)},
+		Middleware{StageLoadShedding, middlewares.LoadShedding(cfg.Server.MaxConcurrentRequests, overloaded, b.exempt)},
+		Middleware{StageLogger, middlewares.RequestLoggerWithDebug(b.debug)},
+		Middleware{StageSecurityHeaders, middlewares.SecurityHeaders()},
+		Middleware{StageRequestID, middlewares.RequestID(cfg.Security.TrustRequestID)},
+	)
+	if cfg.GeoIP.Enabled() {
+		stages = append(stages, Middleware{StageGeoIP, skipPaths(middlewares.GeoIP(), b.infrastructurePath)})
+	}
+	if cfg.Security.CORSEnabled {
+		stages = append(stages, Middleware{StageCORS, middlewares.CORS()})
+	}
+	if cfg.Middleware.CompressionEnabled {
+		stages = append(stages, Middleware{StageCompression, middlewares.Compression(cfg.Middleware.CompressionLevel)})
+	}
+	if cfg.Security.RateLimitEnabled {
+		limit := middlewares.RateLimitWithConfig(rate.Limit(cfg.Security.RateLimitRPS), cfg.Security.RateLimitBurst)
+		stages = append(stages, Middleware{StageRateLimit, skipPaths(limit, b.infrastructurePath)})
+	}
+	if cfg.Faults.Enabled {
+		rules, err := NewFaultRules(cfg)
+		if err != nil {
+			return nil, err
+		}
+		faults := middlewares.FaultInjection(rules, cfg.Faults.Headers)
+		stages = append(stages, Middleware{StageFaultInjection, skipPaths(faults, b.infrastructurePath)})
+	}
+	return stages, nil
+}
+
+// infrastructurePath reports whether path is a health probe or the metrics endpoint, which
+// bypass the rate limiter and are not measured.
+func (b *Builder) infrastructurePath(path string) bool {
+	if b.cfg.Metrics.Enabled && path == b.cfg.Metrics.Path {
+		return true
+	}
+	return b.exempt != nil && b.exempt(path)
+}
+
+// Middlewares returns the pipeline in the order it runs. Unknown names in the disabled list
+// or the order are an error, so that a typo does not silently leave a stage enabled, and so
+// are invalid fault injection rules and recording settings.
+func (b *Builder) Middlewares() ([]Middleware, error) {
+	stages, err := b.defaultStages()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range b.extra {
+		if i := indexOf(stages, m.Name); i >= 0 {
+			stages[i] = m
+		} else {
+			stages = append(stages, m)
+		}
+	}
+
+	for _, name := range b.disabled {
+		i := indexOf(stages, name)
+		if i < 0 && !knownStage(name) {
+			return nil, fmt.Errorf("unknown middleware %q in disabled list", name)
+		}
+		if i >= 0 {
+			stages = append(stages[:i], stages[i+1:]...)
+		}
+	}
+
+	ordered := make([]Middleware, 0, len(stages))
+	for _, name := range b.order {
+		i := indexOf(stages, name)
+		if i < 0 {
+			if knownStage(name) || b.isDisabled(name) {
+				// Ordering a stage that is turned off is not an error
+				continue
+			}
+			return nil, fmt.Errorf("unknown middleware %q in order", name)
+		}
+		ordered = append(ordered, stages[i])
+		stages = append(stages[:i], stages[i+1:]...)
+	}
+	return append(ordered, stages...), nil
+}
+
+// Build creates a Gin engine tuned by the server profile, using the pipeline. Requests that
+// match no route get JSON 404 and 405 errors, also through the pipeline, or in mock mode the
+// examples of the mocked specification. Routes are registered on the engine afterwards.
+func (b *Builder) Build() (*gin.Engine, error) {
+	stages, err := b.Middlewares()
+	if err != nil {
+		return nil, err
+	}
+	profile, err := b.Profile()
+	if err != nil {
+		return nil, err
+	}
+
+	mock, err := NewMockServer(b.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	router := gin.New()
+	profile.Apply(router)
+	for _, m := range stages {
+		router.Use(m.Handler)
+	}
+	var routes func() gin.RoutesInfo
+	if profile.SuggestRoutes {
+		routes = router.Routes
+	}
+	if mock != nil {
+		router.NoRoute(middlewares.Mock(mock), middlewares.NoRoute(routes))
+		router.NoMethod(middlewares.Mock(mock), middlewares.NoMethod())
+	} else {
+		router.NoRoute(middlewares.NoRoute(routes))
+		router.NoMethod(middlewares.NoMethod())
+	}
+	return router, nil
+}
+
+// Profile returns the profile the engine is tuned with.
+func (b *Builder) Profile() (Profile, error) {
+	if b.profile != nil {
+		return *b.profile, nil
+	}
+	return NewProfile(b.cfg)
+}
+
+func (b *Builder) isDisabled(name string) bool {
+	for _, disabled := range b.disabled {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// knownStage reports whether name is a built-in stage, enabled or not.
+func knownStage(name string) bool {
+	switch name {
+	case StageMetrics, StageProfiler, StageRecord, StageRecovery, StageLoadShedding, StageLogger, StageSecurityHeaders,
+		StageRequestID, StageGeoIP, StageCORS, StageCompression, StageRateLimit, StageFaultInjection:
+		return true
+	}
+	return false
+}
+
+func indexOf(stages []Middleware, name string) int {
+	for i, m := range stages {
+		if m.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// skipPaths runs handler only for paths for which exempt returns false.
+func skipPaths(handler gin.HandlerFunc, exempt func(path string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if exempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		handler(c)
+	}
+}
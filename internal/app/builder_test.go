@@ -0,0 +1,209 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func builderConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Security.CORSEnabled = true
+	cfg.Security.RateLimitEnabled = true
+	cfg.Security.RateLimitRPS = 1
+	cfg.Security.RateLimitBurst = 1
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.Path = "/metrics"
+	return cfg
+}
+
+func stageNames(t *testing.T, b *Builder) []string {
+	t.Helper()
+	stages, err := b.Middlewares()
+	if err != nil {
+		t.Fatalf("Middlewares() error = %v", err)
+	}
+	names := make([]string, len(stages))
+	for i, m := range stages {
+		names[i] = m.Name
+	}
+	return names
+}
+
+func TestBuilder_DefaultPipelineFollowsConfig(t *testing.T) {
+	cfg := builderConfig()
+	want := []string{StageMetrics, StageRecovery, StageLoadShedding, StageLogger, StageSecurityHeaders, StageRequestID, StageCORS, StageRateLimit}
+	if got := stageNames(t, NewBuilder(cfg)); !reflect.DeepEqual(got, want) {
+		t.Errorf("stages = %v, want %v", got, want)
+	}
+
+	cfg.Security.CORSEnabled = false
+	cfg.Metrics.Enabled = false
+	cfg.Middleware.CompressionEnabled = true
+	cfg.GeoIP.ASNDatabasePath = "GeoLite2-ASN.mmdb"
+	want = []string{StageRecovery, StageLoadShedding, StageLogger, StageSecurityHeaders, StageRequestID, StageGeoIP, StageCompression, StageRateLimit}
+	if got := stageNames(t, NewBuilder(cfg)); !reflect.DeepEqual(got, want) {
+		t.Errorf("stages = %v, want %v", got, want)
+	}
+}
+
+func TestBuilder_DisableAndOrder(t *testing.T) {
+	cfg := builderConfig()
+	cfg.Middleware.Disabled = []string{StageSecurityHeaders, StageCompression}
+	cfg.Middleware.Order = []string{StageRequestID, StageRecovery}
+
+	noop := func(c *gin.Context) { c.Next() }
+	b := NewBuilder(cfg, WithMiddleware("tenant", noop), WithoutMiddleware(StageLogger))
+
+	want := []string{StageRequestID, StageRecovery, StageMetrics, StageLoadShedding, StageCORS, StageRateLimit, "tenant"}
+	if got := stageNames(t, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("stages = %v, want %v", got, want)
+	}
+}
+
+func TestBuilder_WithMiddlewareReplacesStage(t *testing.T) {
+	called := false
+	b := NewBuilder(builderConfig(), WithMiddleware(StageCORS, func(c *gin.Context) { called = true }))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if !called {
+		t.Error("replacement CORS stage did not run")
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("built-in CORS stage still ran")
+	}
+}
+
+func TestBuilder_RejectsUnknownStages(t *testing.T) {
+	cfg := builderConfig()
+	cfg.Middleware.Disabled = []string{"cros"}
+	if _, err := NewBuilder(cfg).Build(); err == nil {
+		t.Error("expected an error for an unknown disabled stage")
+	}
+
+	cfg = builderConfig()
+	cfg.Middleware.Order = []string{"recovery", "loger"}
+	if _, err := NewBuilder(cfg).Build(); err == nil {
+		t.Error("expected an error for an unknown stage in the order")
+	}
+
+	// Built-in stages that are turned off can still be named
+	cfg = builderConfig()
+	cfg.Middleware.Disabled = []string{StageCompression}
+	cfg.Middleware.Order = []string{StageCompression}
+	if _, err := NewBuilder(cfg).Build(); err != nil {
+		t.Errorf("Build() error = %v", err)
+	}
+}
+
+func TestBuilder_RateLimitExemptsHealthAndMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router, err := NewBuilder(builderConfig(), WithExemptPaths(func(path string) bool { return path == "/health" })).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	for _, path := range []string{"/health", "/metrics", "/api/ping"} {
+		router.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	status := func(path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+	for i := 0; i < 3; i++ {
+		if code := status("/health"); code != http.StatusOK {
+			t.Fatalf("/health request %d: status %d", i, code)
+		}
+		if code := status("/metrics"); code != http.StatusOK {
+			t.Fatalf("/metrics request %d: status %d", i, code)
+		}
+	}
+	if status("/api/ping") != http.StatusOK || status("/api/ping") != http.StatusTooManyRequests {
+		t.Error("expected the second API request to exceed the burst of 1")
+	}
+}
+
+func TestBuilder_MetricsStageFeedsSLOTracker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := builderConfig()
+	cfg.Security.RateLimitEnabled = false
+	cfg.Metrics.SLOAvailability = 0.99
+	cfg.Metrics.SLOLatency = time.Second
+	cfg.Metrics.SLOLatencyTarget = 0.9
+	cfg.Metrics.SLOLatencyOverrides = []string{"GET /api/items/:id=2s"}
+	cfg.Metrics.SLOWindow = 10
+	tracker, err := NewSLOTracker(cfg)
+	if err != nil {
+		t.Fatalf("NewSLOTracker() error = %v", err)
+	}
+	if got := tracker.Objective("GET /api/items/:id").Latency; got != 2*time.Second {
+		t.Errorf("override latency = %s, want 2s", got)
+	}
+
+	router, err := NewBuilder(cfg,
+		WithExemptPaths(func(path string) bool { return path == "/health" }),
+		WithSLOTracker(tracker),
+	).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/items/:id", func(c *gin.Context) { panic("boom") })
+
+	for _, path := range []string{"/health", "/api/items/1", "/api/items/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	routes := tracker.Report().Routes
+	if len(routes) != 1 || routes[0].Route != "GET /api/items/:id" {
+		t.Fatalf("tracked routes = %+v, want only the item route", routes)
+	}
+	if routes[0].Requests != 2 || routes[0].Errors != 2 {
+		t.Errorf("recovered panics not counted as errors: %+v", routes[0])
+	}
+}
+
+func TestNewSLOTracker_RejectsInvalidConfig(t *testing.T) {
+	cfg := builderConfig()
+	cfg.Metrics.SLOAvailability = 0.999
+	cfg.Metrics.SLOLatency = time.Second
+	cfg.Metrics.SLOLatencyTarget = 0.99
+	cfg.Metrics.SLOWindow = 100
+	cfg.Metrics.SLOLatencyOverrides = []string{"/api/items=1s"}
+	if _, err := NewSLOTracker(cfg); err == nil {
+		t.Error("expected an error for an override without a method")
+	}
+
+	cfg.Metrics.SLOLatencyOverrides = nil
+	cfg.Metrics.SLOAvailability = 1
+	if _, err := NewSLOTracker(cfg); err == nil {
+		t.Error("expected an error for an availability target without error budget")
+	}
+}
+
+func TestBuilder_RecordStage(t *testing.T) {
+	cfg := builderConfig()
+	cfg.Recording = config.RecordingConfig{Enabled: true, Dir: t.TempDir(), MaxBodyBytes: 1024}
+	want := []string{StageMetrics, StageRecord, StageRecovery, StageLoadShedding, StageLogger, StageSecurityHeaders, StageRequestID, StageCORS, StageRateLimit}
+	if got := stageNames(t, NewBuilder(cfg)); !reflect.DeepEqual(got, want) {
+		t.Errorf("stages = %v, want %v", got, want)
+	}
+
+	cfg.Recording.MaxBodyBytes = 0
+	if _, err := NewBuilder(cfg).Middlewares(); err == nil {
+		t.Error("Middlewares() accepted RECORD_MAX_BODY_BYTES=0")
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/cache"
+	"github.com/yeferson59/gin-template/pkg/cachecontrol"
+)
+
+// Cache purge providers (CACHE_PURGE_PROVIDER).
+const (
+	PurgeProviderFastly  = "fastly"
+	PurgeProviderWebhook = "webhook"
+)
+
+// Query cache backends (DB_QUERY_CACHE_BACKEND).
+const (
+	QueryCacheMemory = "memory"
+	QueryCacheRedis  = "redis"
+)
+
+// NewCachePurger creates the CDN purger configured in cfg, or nil when none is configured.
+func NewCachePurger(cfg *config.Config, httpClient *http.Client) (cachecontrol.Purger, error) {
+	c := cfg.Cache
+	switch c.PurgeProvider {
+	case "":
+		return nil, nil
+	case PurgeProviderFastly:
+		if c.PurgeServiceID == "" || c.PurgeToken == "" {
+			return nil, fmt.Errorf("the fastly purge provider needs CACHE_PURGE_SERVICE_ID and CACHE_PURGE_TOKEN")
+		}
+		purger := cachecontrol.NewFastly(c.PurgeServiceID, c.PurgeToken, c.PurgeSoft, httpClient)
+		if c.PurgeURL != "" {
+			purger.WithBaseURL(c.PurgeURL)
+		}
+		return purger, nil
+	case PurgeProviderWebhook:
+		if c.PurgeURL == "" {
+			return nil, fmt.Errorf("the webhook purge provider needs CACHE_PURGE_URL")
+		}
+		return cachecontrol.NewWebhook(c.PurgeURL, c.PurgeToken, c.PurgeSoft, httpClient), nil
+	default:
+		return nil, fmt.Errorf("unknown cache purge provider %q (want %s or %s)", c.PurgeProvider, PurgeProviderFastly, PurgeProviderWebhook)
+	}
+}
+
+// NewQueryCache creates the repository query cache configured in cfg, or nil when it is
+// disabled. The Redis backend connects on first use.
+func NewQueryCache(cfg *config.Config) (*repository.QueryCache, error) {
+	d := cfg.Database
+	if !d.QueryCacheEnabled {
+		return nil, nil
+	}
+	if d.QueryCacheTTL <= 0 {
+		return nil, fmt.Errorf("DB_QUERY_CACHE_TTL must be positive")
+	}
+	switch d.QueryCacheBackend {
+	case QueryCacheMemory:
+		return repository.NewQueryCache(cache.NewMemory(d.QueryCacheSize), d.QueryCacheTTL), nil
+	case QueryCacheRedis:
+		if d.QueryCacheRedisURL == "" {
+			return nil, fmt.Errorf("the redis query cache backend needs DB_QUERY_CACHE_REDIS_URL")
+		}
+		redis, err := cache.NewRedis(d.QueryCacheRedisURL)
+		if err != nil {
+			return nil, err
+		}
+		return repository.NewQueryCache(redis, d.QueryCacheTTL), nil
+	default:
+		return nil, fmt.Errorf("unknown query cache backend %q (want %s or %s)", d.QueryCacheBackend, QueryCacheMemory, QueryCacheRedis)
+	}
+}
+
+// NewCounter creates the pagination counter configured in cfg, or nil when totals are exact.
+func NewCounter(cfg *config.Config) (*repository.Counter, error) {
+	d := cfg.Database
+	mode, err := repository.ParseCountMode(d.CountMode)
+	if err != nil {
+		return nil, err
+	}
+	if mode == repository.CountExact {
+		return nil, nil
+	}
+	if d.CountCacheTTL <= 0 {
+		return nil, fmt.Errorf("DB_COUNT_CACHE_TTL must be positive")
+	}
+	return repository.NewCounter(mode, d.CountCacheTTL, d.CountEstimateThreshold), nil
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/cachecontrol"
+)
+
+func TestNewCachePurger(t *testing.T) {
+	tests := []struct {
+		name    string
+		cache   config.CacheConfig
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "none", want: nil},
+		{name: "fastly", cache: config.CacheConfig{PurgeProvider: "fastly", PurgeServiceID: "svc", PurgeToken: "t"}, want: &cachecontrol.Fastly{}},
+		{name: "fastly without token", cache: config.CacheConfig{PurgeProvider: "fastly", PurgeServiceID: "svc"}, wantErr: true},
+		{name: "webhook", cache: config.CacheConfig{PurgeProvider: "webhook", PurgeURL: "http://purge.internal"}, want: &cachecontrol.Webhook{}},
+		{name: "webhook without URL", cache: config.CacheConfig{PurgeProvider: "webhook"}, wantErr: true},
+		{name: "unknown", cache: config.CacheConfig{PurgeProvider: "akamai"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			purger, err := NewCachePurger(&config.Config{Cache: tt.cache}, http.DefaultClient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCachePurger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			switch tt.want.(type) {
+			case nil:
+				if purger != nil {
+					t.Errorf("NewCachePurger() = %T, want nil", purger)
+				}
+			case *cachecontrol.Fastly:
+				if _, ok := purger.(*cachecontrol.Fastly); !ok {
+					t.Errorf("NewCachePurger() = %T, want *Fastly", purger)
+				}
+			case *cachecontrol.Webhook:
+				if _, ok := purger.(*cachecontrol.Webhook); !ok {
+					t.Errorf("NewCachePurger() = %T, want *Webhook", purger)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigChecks_CachePolicies(t *testing.T) {
+	cfg := &config.Config{Cache: config.CacheConfig{Policies: []string{"/api/errors=public max-age=forever"}}}
+	for _, check := range ConfigChecks(cfg) {
+		if check.Name == "cache" {
+			if check.Run(t.Context()) == nil || !check.Required {
+				t.Error("cache check accepted an invalid policy")
+			}
+			return
+		}
+	}
+	t.Fatal("no cache check")
+}
+
+func TestNewQueryCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		db      config.DatabaseConfig
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", db: config.DatabaseConfig{QueryCacheBackend: "memory"}, wantNil: true},
+		{name: "memory", db: config.DatabaseConfig{QueryCacheEnabled: true, QueryCacheBackend: "memory", QueryCacheTTL: time.Minute}},
+		{name: "redis", db: config.DatabaseConfig{QueryCacheEnabled: true, QueryCacheBackend: "redis", QueryCacheRedisURL: "redis://cache:6379/1", QueryCacheTTL: time.Minute}},
+		{name: "redis without URL", db: config.DatabaseConfig{QueryCacheEnabled: true, QueryCacheBackend: "redis", QueryCacheTTL: time.Minute}, wantErr: true},
+		{name: "invalid redis URL", db: config.DatabaseConfig{QueryCacheEnabled: true, QueryCacheBackend: "redis", QueryCacheRedisURL: "cache:6379", QueryCacheTTL: time.Minute}, wantErr: true},
+		{name: "no TTL", db: config.DatabaseConfig{QueryCacheEnabled: true, QueryCacheBackend: "memory"}, wantErr: true},
+		{name: "unknown", db: config.DatabaseConfig{QueryCacheEnabled: true, QueryCacheBackend: "memcached", QueryCacheTTL: time.Minute}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qc, err := NewQueryCache(&config.Config{Database: tt.db})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewQueryCache() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (qc == nil) != tt.wantNil {
+				t.Fatalf("NewQueryCache() = %v, want nil %v", qc, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestNewCounter(t *testing.T) {
+	tests := []struct {
+		name    string
+		db      config.DatabaseConfig
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "exact", db: config.DatabaseConfig{CountMode: "exact"}, wantNil: true},
+		{name: "cached", db: config.DatabaseConfig{CountMode: "cached", CountCacheTTL: time.Minute}},
+		{name: "estimated", db: config.DatabaseConfig{CountMode: "estimated", CountCacheTTL: time.Minute, CountEstimateThreshold: 1000}},
+		{name: "no TTL", db: config.DatabaseConfig{CountMode: "cached"}, wantErr: true},
+		{name: "unknown", db: config.DatabaseConfig{CountMode: "approximate", CountCacheTTL: time.Minute}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, err := NewCounter(&config.Config{Database: tt.db})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCounter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (counter == nil) != tt.wantNil {
+				t.Fatalf("NewCounter() = %v, want nil %v", counter, tt.wantNil)
+			}
+		})
+	}
+}
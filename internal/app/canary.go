@@ -0,0 +1,29 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/canary"
+)
+
+// NewCanaryRouter creates the router of the canary rollouts in cfg (CANARY_* variables), or
+// returns nil when there are none.
+func NewCanaryRouter(cfg *config.Config) (*canary.Router, error) {
+	c := cfg.Canary
+	rollouts, err := canary.ParseRollouts(c.Rollouts)
+	if err != nil {
+		return nil, err
+	}
+	users, err := canary.ParseUsers(c.Users)
+	if err != nil {
+		return nil, err
+	}
+	if len(rollouts) == 0 {
+		if len(users) > 0 || c.Header != "" {
+			return nil, fmt.Errorf("CANARY_USERS and CANARY_HEADER need CANARY_ROLLOUTS")
+		}
+		return nil, nil
+	}
+	return canary.NewRouter(rollouts, users, c.Header), nil
+}
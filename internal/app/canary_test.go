@@ -0,0 +1,23 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewCanaryRouter(t *testing.T) {
+	router, err := NewCanaryRouter(&config.Config{})
+	if router != nil || err != nil {
+		t.Errorf("NewCanaryRouter() without rollouts = %v, %v", router, err)
+	}
+	if _, err := NewCanaryRouter(&config.Config{Canary: config.CanaryConfig{Users: []string{"1"}}}); err == nil {
+		t.Error("NewCanaryRouter() accepted canary users without rollouts")
+	}
+
+	cfg := &config.Config{Canary: config.CanaryConfig{Rollouts: []string{"profile-v2=5"}, Header: "X-Canary"}}
+	router, err = NewCanaryRouter(cfg)
+	if err != nil || router.Percent("profile-v2") != 5 || router.Header() != "X-Canary" {
+		t.Errorf("NewCanaryRouter() = %+v, %v", router, err)
+	}
+}
@@ -0,0 +1,349 @@
+// Package app contains the application bootstrap: dependency checks run before the
+// server binds its port, the global middleware pipeline, and the lifecycle state reported by
+// the health probes.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/pkg/cachecontrol"
+)
+
+// MinJWTSecretLength is the minimum JWT secret length in bytes (256 bits for HS256).
+const MinJWTSecretLength = 32
+
+// Check verifies a single startup dependency.
+type Check struct {
+	// Name identifies the check in logs and --check output.
+	Name string
+	// Required checks abort startup when they fail; others only log a warning.
+	Required bool
+	// Run performs the check.
+	Run func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a Check.
+type CheckResult struct {
+	Name     string
+	Required bool
+	Err      error
+	Duration time.Duration
+}
+
+// ErrChecksFailed is returned by RunChecks when a required check failed.
+var ErrChecksFailed = errors.New("startup checks failed")
+
+// RunChecks executes checks in order, each bounded by timeout. It returns every result and
+// ErrChecksFailed when at least one required check failed.
+func RunChecks(ctx context.Context, checks []Check, timeout time.Duration) ([]CheckResult, error) {
+	results := make([]CheckResult, 0, len(checks))
+	var failed error
+
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		err := check.Run(checkCtx)
+		cancel()
+
+		results = append(results, CheckResult{
+			Name:     check.Name,
+			Required: check.Required,
+			Err:      err,
+			Duration: time.Since(start),
+		})
+		if err != nil && check.Required {
+			failed = ErrChecksFailed
+		}
+	}
+
+	return results, failed
+}
+
+// ConfigChecks verifies configuration that does not need any connection.
+// A weak JWT secret is fatal in production and a warning elsewhere.
+func ConfigChecks(cfg *config.Config) []Check {
+	return []Check{
+		{
+			Name:     "jwt_secret",
+			Required: cfg.Server.Environment == "production",
+			Run: func(context.Context) error {
+				return ValidateJWTSecret(cfg.JWT.Secret)
+			},
+		},
+		{
+			Name:     "bcrypt_cost",
+			Required: true,
+			Run: func(context.Context) error {
+				return auth.ValidateBcryptCost(cfg.Security.BcryptCost)
+			},
+		},
+		{
+			Name:     "slo",
+			Required: cfg.Metrics.Enabled,
+			Run: func(context.Context) error {
+				_, err := NewSLOTracker(cfg)
+				return err
+			},
+		},
+		{
+			Name: "metrics_endpoint",
+			Run: func(context.Context) error {
+				if cfg.Metrics.Enabled && cfg.Metrics.Token == "" && len(cfg.Server.InternalAddrs) == 0 {
+					return errors.New("metrics are collected but not served: set METRICS_TOKEN or INTERNAL_LISTEN_ADDRS to scrape them")
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "cache",
+			Required: true,
+			Run: func(context.Context) error {
+				if _, err := cachecontrol.ParseRules(cfg.Cache.Policies); err != nil {
+					return err
+				}
+				if _, err := NewCachePurger(cfg, http.DefaultClient); err != nil {
+					return err
+				}
+				if _, err := NewQueryCache(cfg); err != nil {
+					return err
+				}
+				_, err := NewCounter(cfg)
+				return err
+			},
+		},
+		{
+			Name:     "geoip",
+			Required: true,
+			Run: func(context.Context) error {
+				if _, err := NewGeoRules(cfg); err != nil {
+					return err
+				}
+				_, err := NewGeoLocator(cfg)
+				return err
+			},
+		},
+		{
+			Name:     "notify",
+			Required: true,
+			Run: func(context.Context) error {
+				if _, err := NewNotifier(cfg, nil, nil); err != nil {
+					return err
+				}
+				if _, err := NewMailer(cfg, nil); err != nil {
+					return err
+				}
+				_, err := NewInvitationMailer(cfg, nil)
+				return err
+			},
+		},
+		{
+			Name:     "saml",
+			Required: true,
+			Run: func(context.Context) error {
+				return ValidateSAMLConfig(cfg)
+			},
+		},
+		{
+			Name:     "device_auth",
+			Required: true,
+			Run: func(context.Context) error {
+				return ValidateDeviceAuthConfig(cfg)
+			},
+		},
+		{
+			Name:     "billing",
+			Required: true,
+			Run: func(context.Context) error {
+				return ValidateBillingConfig(cfg)
+			},
+		},
+		{
+			Name:     "profile",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewProfile(cfg)
+				return err
+			},
+		},
+		{
+			Name:     "faults",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewFaultRules(cfg)
+				return err
+			},
+		},
+		{
+			Name:     "cleanup",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewCleanupService(cfg, nil)
+				return err
+			},
+		},
+		{
+			Name:     "jobs",
+			Required: true,
+			Run: func(context.Context) error {
+				return ValidateJobsConfig(cfg)
+			},
+		},
+		{
+			Name:     "sagas",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewSagaRunner(cfg, nil, nil)
+				return err
+			},
+		},
+		{
+			Name:     "static",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewAssets(cfg)
+				return err
+			},
+		},
+		{
+			Name:     "mock",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewMockServer(cfg)
+				return err
+			},
+		},
+		{
+			Name:     "canary",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewCanaryRouter(cfg)
+				return err
+			},
+		},
+		{
+			Name:     "backup",
+			Required: cfg.Backup.Interval > 0,
+			Run: func(context.Context) error {
+				_, err := NewBackupService(cfg, nil, http.DefaultClient)
+				return err
+			},
+		},
+		{
+			Name:     "watchdog",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewWatchdog(cfg)
+				return err
+			},
+		},
+		{
+			Name:     "profiler",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewProfiler(cfg, http.DefaultClient)
+				return err
+			},
+		},
+		{
+			Name:     "crash_reports",
+			Required: true,
+			Run: func(context.Context) error {
+				_, err := NewCrashReports(cfg, http.DefaultClient)
+				return err
+			},
+		},
+	}
+}
+
+// DatabaseChecks verifies that the database is reachable and, when requireMigrations is
+// true, that no migration is pending.
+func DatabaseChecks(db *gorm.DB, requireMigrations bool) []Check {
+	return []Check{
+		{
+			Name:     "database",
+			Required: true,
+			Run: func(ctx context.Context) error {
+				sqlDB, err := db.DB()
+				if err != nil {
+					return err
+				}
+				return sqlDB.PingContext(ctx)
+			},
+		},
+		{
+			Name:     "migrations",
+			Required: requireMigrations,
+			Run: func(ctx context.Context) error {
+				pending, err := database.PendingMigrations(db.WithContext(ctx))
+				if err != nil {
+					return err
+				}
+				if len(pending) > 0 {
+					versions := make([]string, len(pending))
+					for i, m := range pending {
+						versions[i] = m.Version
+					}
+					return fmt.Errorf("%d pending migration(s): %s", len(pending), strings.Join(versions, ", "))
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// ValidateDeviceAuthConfig checks the settings of the device authorization grant when enabled.
+func ValidateDeviceAuthConfig(cfg *config.Config) error {
+	d := cfg.DeviceAuth
+	switch {
+	case !d.Enabled:
+		return nil
+	case d.CodeTTL < time.Minute:
+		return fmt.Errorf("DEVICE_AUTH_CODE_TTL must be at least 1m, got %s", d.CodeTTL)
+	case d.PollInterval < time.Second:
+		return fmt.Errorf("DEVICE_AUTH_POLL_INTERVAL must be at least 1s, got %s", d.PollInterval)
+	case strings.Trim(d.VerificationPath, "/") == "":
+		return errors.New("DEVICE_AUTH_VERIFICATION_PATH must not be empty")
+	case d.VerificationURL == "" && cfg.Server.Environment == "production":
+		// Built from the Host header, the URL shown to users could point at any site
+		return errors.New("DEVICE_AUTH_VERIFICATION_URL is required in production")
+	}
+	if d.VerificationURL != "" {
+		u, err := url.Parse(d.VerificationURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("DEVICE_AUTH_VERIFICATION_URL must be an absolute URL, got %q", d.VerificationURL)
+		}
+	}
+	return nil
+}
+
+// ValidateJWTSecret rejects empty, default, short, or trivially repetitive secrets.
+func ValidateJWTSecret(secret string) error {
+	switch {
+	case secret == "":
+		return errors.New("JWT_SECRET is not set")
+	case secret == "supersecretkey":
+		return errors.New("JWT_SECRET uses the template default value")
+	case len(secret) < MinJWTSecretLength:
+		return fmt.Errorf("JWT_SECRET must be at least %d bytes long, got %d", MinJWTSecretLength, len(secret))
+	}
+
+	distinct := make(map[rune]struct{})
+	for _, r := range secret {
+		distinct[r] = struct{}{}
+	}
+	if len(distinct) < 8 {
+		return errors.New("JWT_SECRET has too few distinct characters")
+	}
+	return nil
+}
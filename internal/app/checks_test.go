@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/database"
+)
+
+func TestRunChecks_RequiredFailureFailsRun(t *testing.T) {
+	boom := errors.New("boom")
+	checks := []Check{
+		{Name: "ok", Required: true, Run: func(context.Context) error { return nil }},
+		{Name: "optional", Run: func(context.Context) error { return boom }},
+		{Name: "required", Required: true, Run: func(context.Context) error { return boom }},
+	}
+
+	results, err := RunChecks(context.Background(), checks, time.Second)
+	if !errors.Is(err, ErrChecksFailed) {
+		t.Fatalf("expected ErrChecksFailed, got %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected every check to run, got %d results", len(results))
+	}
+	if results[1].Err == nil || results[2].Err == nil {
+		t.Fatalf("expected failures to be reported, got %+v", results)
+	}
+}
+
+func TestRunChecks_OptionalFailureDoesNotFailRun(t *testing.T) {
+	checks := []Check{
+		{Name: "optional", Run: func(context.Context) error { return errors.New("weak") }},
+	}
+	if _, err := RunChecks(context.Background(), checks, time.Second); err != nil {
+		t.Fatalf("expected optional failure to be tolerated, got %v", err)
+	}
+}
+
+func TestRunChecks_AppliesTimeout(t *testing.T) {
+	checks := []Check{
+		{Name: "slow", Required: true, Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+	results, err := RunChecks(context.Background(), checks, 10*time.Millisecond)
+	if err == nil || !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", results[0].Err)
+	}
+}
+
+func TestValidateJWTSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		valid  bool
+	}{
+		{"empty", "", false},
+		{"template default", "supersecretkey", false},
+		{"too short", "abc123XYZ", false},
+		{"repetitive", strings.Repeat("ab", 20), false},
+		{"strong", "k7Gq2pX9vLw4Rz8NcT1mB6yH3sJ5dF0a", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJWTSecret(tt.secret)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ValidateJWTSecret(%q) = %v, want valid=%v", tt.secret, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateDeviceAuthConfig(t *testing.T) {
+	valid := config.DeviceAuthConfig{Enabled: true, VerificationPath: "/device", CodeTTL: 10 * time.Minute, PollInterval: 5 * time.Second}
+	tests := []struct {
+		name   string
+		env    string
+		modify func(d *config.DeviceAuthConfig)
+		valid  bool
+	}{
+		{"disabled", "production", func(d *config.DeviceAuthConfig) { *d = config.DeviceAuthConfig{} }, true},
+		{"defaults", "development", func(d *config.DeviceAuthConfig) {}, true},
+		{"no verification URL in production", "production", func(d *config.DeviceAuthConfig) {}, false},
+		{"verification URL", "production", func(d *config.DeviceAuthConfig) { d.VerificationURL = "https://example.com/device" }, true},
+		{"relative verification URL", "development", func(d *config.DeviceAuthConfig) { d.VerificationURL = "/device" }, false},
+		{"short code TTL", "development", func(d *config.DeviceAuthConfig) { d.CodeTTL = 30 * time.Second }, false},
+		{"no poll interval", "development", func(d *config.DeviceAuthConfig) { d.PollInterval = 0 }, false},
+		{"root path", "development", func(d *config.DeviceAuthConfig) { d.VerificationPath = "/" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := valid
+			tt.modify(&d)
+			cfg := &config.Config{DeviceAuth: d}
+			cfg.Server.Environment = tt.env
+			err := ValidateDeviceAuthConfig(cfg)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ValidateDeviceAuthConfig() = %v, want valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestConfigChecks_JWTSecretRequiredInProduction(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "supersecretkey"
+	cfg.Security.BcryptCost = 10
+
+	cfg.Server.Environment = "development"
+	if _, err := RunChecks(context.Background(), ConfigChecks(cfg), time.Second); err != nil {
+		t.Fatalf("expected weak secret to be a warning in development, got %v", err)
+	}
+
+	cfg.Server.Environment = "production"
+	if _, err := RunChecks(context.Background(), ConfigChecks(cfg), time.Second); err == nil {
+		t.Fatal("expected weak secret to fail in production")
+	}
+}
+
+func TestConfigChecks_BcryptCost(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "k7Gq2pX9vLw4Rz8NcT1mB6yH3sJ5dF0a"
+
+	cfg.Security.BcryptCost = 12
+	if _, err := RunChecks(context.Background(), ConfigChecks(cfg), time.Second); err != nil {
+		t.Fatalf("expected cost 12 to be accepted, got %v", err)
+	}
+
+	cfg.Security.BcryptCost = 32
+	if _, err := RunChecks(context.Background(), ConfigChecks(cfg), time.Second); err == nil {
+		t.Fatal("expected an out-of-range bcrypt cost to fail")
+	}
+}
+
+func TestDatabaseChecks_DetectsPendingMigrations(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	results, err := RunChecks(context.Background(), DatabaseChecks(db, true), time.Second)
+	if err == nil {
+		t.Fatal("expected pending migrations to fail the checks")
+	}
+	if results[0].Err != nil {
+		t.Fatalf("expected database ping to pass, got %v", results[0].Err)
+	}
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if _, err := RunChecks(context.Background(), DatabaseChecks(db, true), time.Second); err != nil {
+		t.Fatalf("expected checks to pass after migrating, got %v", err)
+	}
+}
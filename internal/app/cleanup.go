@@ -0,0 +1,55 @@
+package app
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/cleanup"
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+var errCleanupNegative = errors.New("CLEANUP_INTERVAL, CLEANUP_GRACE_PERIOD, CLEANUP_BATCH_SIZE, AUDIT_LOG_RETENTION and WEBHOOK_INBOX_RETENTION must not be negative")
+
+// CleanupTasks returns the cleanup tasks configured in cfg: login challenges, device
+// authorizations, pending invitations and email changes past their expiry and the grace
+// period, and audit logs, processed webhook events, finished sagas and failed queued jobs past
+// their retention, when it is set.
+func CleanupTasks(cfg *config.Config) []cleanup.Task {
+	grace := cfg.Jobs.CleanupGracePeriod
+	tasks := []cleanup.Task{
+		{Name: "login_challenges", Model: &models.LoginChallenge{}, Column: "expires_at", Age: grace},
+		{Name: "device_authorizations", Model: &models.DeviceAuthorization{}, Column: "expires_at", Age: grace},
+		{Name: "invitations", Model: &models.Invitation{}, Column: "expires_at", Age: grace, Where: "accepted_at IS NULL"},
+		// Changes can be reverted until RevertExpiresAt, also after they were confirmed
+		{Name: "email_changes", Model: &models.EmailChange{}, Column: "revert_expires_at", Age: grace},
+	}
+	if retention := cfg.Database.AuditLogRetention; retention > 0 {
+		tasks = append(tasks, cleanup.Task{Name: "audit_logs", Model: &models.AuditLog{}, Column: "created_at", Age: retention})
+	}
+	if retention := cfg.Database.InboxRetention; retention > 0 {
+		tasks = append(tasks, cleanup.Task{Name: "inbox_messages", Model: &models.InboxMessage{}, Column: "created_at", Age: retention})
+	}
+	if retention := cfg.Jobs.SagaRetention; retention > 0 {
+		tasks = append(tasks, cleanup.Task{Name: "sagas", Model: &models.Saga{}, Column: "finished_at", Age: retention})
+	}
+	if retention := cfg.Jobs.FailedRetention; retention > 0 {
+		tasks = append(tasks, cleanup.Task{
+			Name: "queued_jobs", Model: &models.QueuedJob{}, Column: "updated_at", Age: retention,
+			Where: "status = '" + models.QueuedJobFailed + "'",
+		})
+	}
+	return tasks
+}
+
+// NewCleanupService creates the service deleting the expired records of db configured in cfg
+// (CLEANUP_* variables and AUDIT_LOG_RETENTION).
+func NewCleanupService(cfg *config.Config, db *gorm.DB) (*cleanup.Service, error) {
+	j := cfg.Jobs
+	if j.CleanupInterval < 0 || j.CleanupGracePeriod < 0 || j.CleanupBatchSize < 0 ||
+		cfg.Database.AuditLogRetention < 0 || cfg.Database.InboxRetention < 0 {
+		return nil, errCleanupNegative
+	}
+	return cleanup.NewService(db, CleanupTasks(cfg), j.CleanupBatchSize), nil
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestCleanupTasks(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Jobs.CleanupGracePeriod = time.Hour
+	names := func() []string {
+		var names []string
+		for _, task := range CleanupTasks(cfg) {
+			names = append(names, task.Name)
+		}
+		return names
+	}
+	if got := names(); len(got) != 4 || got[3] == "audit_logs" {
+		t.Errorf("tasks = %v, want no audit log retention by default", got)
+	}
+
+	cfg.Database.AuditLogRetention = 90 * 24 * time.Hour
+	cfg.Database.InboxRetention = 30 * 24 * time.Hour
+	cfg.Jobs.SagaRetention = 7 * 24 * time.Hour
+	cfg.Jobs.FailedRetention = 7 * 24 * time.Hour
+	if got := names(); len(got) != 8 || got[4] != "audit_logs" || got[5] != "inbox_messages" || got[6] != "sagas" || got[7] != "queued_jobs" {
+		t.Errorf("tasks = %v, want audit logs, inbox messages, sagas and failed jobs with their retention", got)
+	}
+}
+
+func TestNewCleanupService(t *testing.T) {
+	cfg := &config.Config{}
+	if _, err := NewCleanupService(cfg, nil); err != nil {
+		t.Errorf("NewCleanupService() error = %v", err)
+	}
+	cfg.Jobs.CleanupBatchSize = -1
+	if _, err := NewCleanupService(cfg, nil); err == nil {
+		t.Error("NewCleanupService() accepted a negative batch size")
+	}
+	cfg.Jobs.CleanupBatchSize = 100
+	cfg.Database.AuditLogRetention = -time.Hour
+	if _, err := NewCleanupService(cfg, nil); err == nil {
+		t.Error("NewCleanupService() accepted a negative retention")
+	}
+}
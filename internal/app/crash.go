@@ -0,0 +1,30 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/crashreport"
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// NewCrashReports creates the store of crash reports in cfg (CRASH_REPORTS_* variables), which
+// sanitizes with the logging redaction settings, or returns nil when CRASH_REPORTS_ENABLED is
+// not set. The s3 storage uses the bucket and credentials of the backups.
+func NewCrashReports(cfg *config.Config, httpClient *http.Client) (*crashreport.Store, error) {
+	c := cfg.Crash
+	if !c.Enabled {
+		return nil, nil
+	}
+	if c.MaxReports < 0 {
+		return nil, fmt.Errorf("CRASH_REPORTS_MAX must not be negative")
+	}
+
+	store, err := newSharedStorage(cfg, httpClient, "crash report", c.Storage, c.Dir, "CRASH_REPORTS_DIR")
+	if err != nil {
+		return nil, err
+	}
+	redactor := logger.NewRedactor(cfg.Logging.RedactFields, cfg.Logging.RedactEmails)
+	return crashreport.NewStore(store, c.Prefix, c.MaxReports, redactor), nil
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewCrashReports(t *testing.T) {
+	cfg := &config.Config{}
+	if reports, err := NewCrashReports(cfg, http.DefaultClient); reports != nil || err != nil {
+		t.Fatalf("disabled: got %v, %v; want nil", reports, err)
+	}
+
+	cfg.Crash = config.CrashConfig{Enabled: true, Storage: BackupStorageLocal, Dir: t.TempDir(), MaxReports: 10}
+	if reports, err := NewCrashReports(cfg, http.DefaultClient); reports == nil || err != nil {
+		t.Fatalf("local: got %v, %v; want a store", reports, err)
+	}
+
+	for name, crash := range map[string]config.CrashConfig{
+		"unknown storage": {Enabled: true, Storage: "ftp", Dir: "crash-reports"},
+		"no directory":    {Enabled: true, Storage: BackupStorageLocal},
+		"negative limit":  {Enabled: true, Storage: BackupStorageLocal, Dir: "crash-reports", MaxReports: -1},
+		"no bucket":       {Enabled: true, Storage: BackupStorageS3},
+	} {
+		cfg.Crash = crash
+		if _, err := NewCrashReports(cfg, http.DefaultClient); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/faults"
+)
+
+var (
+	errFaultsInProduction = errors.New("FAULT_INJECTION_ENABLED must not be set in production")
+	errFaultsWithoutRules = errors.New("FAULT_INJECTION_ENABLED needs FAULT_INJECTION_RULES or FAULT_INJECTION_HEADERS")
+)
+
+// NewFaultRules parses the fault injection rules in cfg (FAULT_INJECTION_* variables). Fault
+// injection is refused in production.
+func NewFaultRules(cfg *config.Config) (faults.Rules, error) {
+	f := cfg.Faults
+	rules, err := faults.ParseRules(f.Rules)
+	if err != nil || !f.Enabled {
+		return rules, err
+	}
+	if cfg.Server.Environment == "production" {
+		return nil, errFaultsInProduction
+	}
+	if len(rules) == 0 && !f.Headers {
+		return nil, errFaultsWithoutRules
+	}
+	return rules, nil
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewFaultRules(t *testing.T) {
+	cfg := &config.Config{Faults: config.FaultsConfig{Enabled: true}}
+	if _, err := NewFaultRules(cfg); err == nil {
+		t.Error("NewFaultRules() accepted fault injection without rules or headers")
+	}
+
+	cfg.Faults.Rules = []string{"/api=error:503@0.1"}
+	if rules, err := NewFaultRules(cfg); err != nil || len(rules) != 1 {
+		t.Errorf("NewFaultRules() = %v, %v", rules, err)
+	}
+
+	cfg.Server.Environment = "production"
+	if _, err := NewFaultRules(cfg); err == nil {
+		t.Error("NewFaultRules() enabled fault injection in production")
+	}
+	if _, err := NewBuilder(cfg).Build(); err == nil {
+		t.Error("Build() added fault injection in production")
+	}
+}
+
+func TestBuilder_FaultInjectionStage(t *testing.T) {
+	cfg := builderConfig()
+	cfg.Faults = config.FaultsConfig{Enabled: true, Headers: true}
+	want := []string{StageMetrics, StageRecovery, StageLoadShedding, StageLogger, StageSecurityHeaders, StageRequestID, StageCORS, StageRateLimit, StageFaultInjection}
+	if got := stageNames(t, NewBuilder(cfg)); !reflect.DeepEqual(got, want) {
+		t.Errorf("stages = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/pkg/geoip"
+)
+
+// GeoRules are the geo rules of the route groups parsed from cfg (GEOIP_* variables).
+type GeoRules struct {
+	Block      geoip.BlockRules
+	RateLimits geoip.RateLimitRules
+}
+
+var errGeoRulesWithoutDatabase = errors.New("GEOIP_BLOCK_RULES and GEOIP_RATE_LIMITS need GEOIP_DB_PATH or GEOIP_ASN_DB_PATH")
+
+// NewGeoLocator opens the GeoIP databases in cfg, or returns nil when none is configured.
+func NewGeoLocator(cfg *config.Config) (security.GeoLocator, error) {
+	if !cfg.GeoIP.Enabled() {
+		return nil, nil
+	}
+	db, err := geoip.OpenDB(cfg.GeoIP.DatabasePath, cfg.GeoIP.ASNDatabasePath)
+	if err != nil {
+		return nil, err
+	}
+	return security.NewGeoIPLocator(db), nil
+}
+
+// NewGeoRules parses the geo rules in cfg. Rules without a GeoIP database are an error, since
+// they would never apply.
+func NewGeoRules(cfg *config.Config) (GeoRules, error) {
+	var rules GeoRules
+	var err error
+	if rules.Block, err = geoip.ParseBlockRules(cfg.GeoIP.BlockRules); err != nil {
+		return GeoRules{}, err
+	}
+	if rules.RateLimits, err = geoip.ParseRateLimitRules(cfg.GeoIP.RateLimits); err != nil {
+		return GeoRules{}, err
+	}
+	if (len(rules.Block) > 0 || len(rules.RateLimits) > 0) && !cfg.GeoIP.Enabled() {
+		return GeoRules{}, errGeoRulesWithoutDatabase
+	}
+	return rules, nil
+}
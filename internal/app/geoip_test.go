@@ -0,0 +1,31 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewGeoRules(t *testing.T) {
+	cfg := &config.Config{GeoIP: config.GeoIPConfig{BlockRules: []string{"/api/admin=allow:CO"}}}
+	if _, err := NewGeoRules(cfg); err == nil {
+		t.Error("NewGeoRules() accepted rules without a GeoIP database")
+	}
+
+	cfg.GeoIP.DatabasePath = "GeoLite2-Country.mmdb"
+	cfg.GeoIP.RateLimits = []string{"/api/auth=asn:5:20"}
+	rules, err := NewGeoRules(cfg)
+	if err != nil || len(rules.Block) != 1 || len(rules.RateLimits) != 1 {
+		t.Errorf("NewGeoRules() = %+v, %v", rules, err)
+	}
+}
+
+func TestNewGeoLocator(t *testing.T) {
+	locator, err := NewGeoLocator(&config.Config{})
+	if locator != nil || err != nil {
+		t.Errorf("NewGeoLocator() without databases = %v, %v", locator, err)
+	}
+	if _, err := NewGeoLocator(&config.Config{GeoIP: config.GeoIPConfig{DatabasePath: "missing.mmdb"}}); err == nil {
+		t.Error("NewGeoLocator() opened a missing database")
+	}
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/hooks"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/events"
+)
+
+// auditedModels are the models whose lifecycle events are published, with their event prefix.
+var auditedModels = []struct {
+	model interface{}
+	event string
+}{
+	{&models.User{}, "user"},
+	{&models.Organization{}, "organization"},
+	{&models.Membership{}, "membership"},
+	{&models.Invitation{}, "invitation"},
+	{&models.APIKey{}, "api_key"},
+	{&models.Plan{}, "plan"},
+	{&models.UserPlan{}, "user_plan"},
+}
+
+// NewHookRegistry creates the model hook registry publishing the lifecycle events of the
+// audited models on bus, and recording them in the audit log when AUDIT_LOG_ENABLED is set.
+func NewHookRegistry(cfg *config.Config, bus *events.Bus) (*hooks.Registry, error) {
+	registry := hooks.NewRegistry(bus)
+	for _, m := range auditedModels {
+		opts := hooks.Options{Event: m.event, Audit: cfg.Database.AuditLogEnabled}
+		if err := registry.Register(m.model, opts); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/hooks"
+	"github.com/yeferson59/gin-template/pkg/events"
+)
+
+func TestNewHookRegistry(t *testing.T) {
+	registry, err := NewHookRegistry(&config.Config{}, events.NewBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range auditedModels {
+		if err := registry.On(m.model, hooks.AfterCreate, func(context.Context, *gorm.DB, interface{}) error { return nil }); err != nil {
+			t.Errorf("expected %T to be registered: %v", m.model, err)
+		}
+	}
+}
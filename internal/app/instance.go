@@ -0,0 +1,273 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestid"
+)
+
+// uncleanExit is the restart cause reported when the previous instance recorded its start but
+// not its shutdown.
+const uncleanExit = "exited without shutting down (crash, OOM kill or SIGKILL)"
+
+// maxStopReason is the size of the reason column of instance_events.
+const maxStopReason = 512
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	// Revision, CommitTime and Modified come from the version control information stamped by
+	// go build, when built from a checkout.
+	Revision   string `json:"revision,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified,omitempty"`
+}
+
+// RestartCause describes how the previous instance on the same host ended.
+type RestartCause struct {
+	InstanceID string     `json:"instance_id"`
+	Version    string     `json:"version"`
+	StartedAt  time.Time  `json:"started_at"`
+	StoppedAt  *time.Time `json:"stopped_at,omitempty"`
+	Reason     string     `json:"reason"`
+	// Clean is false when the previous instance did not record its shutdown.
+	Clean bool `json:"clean"`
+}
+
+// InstanceInfo describes the running instance, as returned by GET /api/admin/instance.
+type InstanceInfo struct {
+	ID                string        `json:"id"`
+	Host              string        `json:"host,omitempty"`
+	PID               int           `json:"pid"`
+	StartedAt         time.Time     `json:"started_at"`
+	Uptime            string        `json:"uptime"`
+	UptimeSeconds     float64       `json:"uptime_seconds"`
+	Build             BuildInfo     `json:"build"`
+	ConfigFingerprint string        `json:"config_fingerprint"`
+	Migrations        []string      `json:"migrations"`
+	LastRestart       *RestartCause `json:"last_restart,omitempty"`
+}
+
+// Instance records the lifecycle events of the server process in the instance_events table,
+// so that the next process started on the same host can tell why this one stopped, and logs
+// them as structured entries. It is safe for concurrent use.
+type Instance struct {
+	id          string
+	host        string
+	pid         int
+	startedAt   time.Time
+	build       BuildInfo
+	fingerprint string
+	now         func() time.Time
+
+	mu          sync.Mutex
+	migrations  []string
+	lastRestart *RestartCause
+	stopReason  string
+}
+
+// NewInstance describes the current process, running version with cfg.
+func NewInstance(cfg *config.Config, version string) *Instance {
+	host, _ := os.Hostname()
+	return &Instance{
+		id:          requestid.New(),
+		host:        host,
+		pid:         os.Getpid(),
+		startedAt:   time.Now().UTC(),
+		build:       ReadBuildInfo(version),
+		fingerprint: ConfigFingerprint(cfg),
+		now:         time.Now,
+	}
+}
+
+// ReadBuildInfo returns the build information of the binary, reported as version.
+func ReadBuildInfo(version string) BuildInfo {
+	build := BuildInfo{Version: version, GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			build.Revision = s.Value
+		case "vcs.time":
+			build.CommitTime = s.Value
+		case "vcs.modified":
+			build.Modified = s.Value == "true"
+		}
+	}
+	return build
+}
+
+// ConfigFingerprint returns a SHA-256 hash of cfg without its secrets, which changes with any
+// other setting, so that instances running different configurations can be told apart.
+func ConfigFingerprint(cfg *config.Config) string {
+	body, err := json.Marshal(cfg.Redacted())
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// ID returns the identifier of the instance, shared by its events.
+func (i *Instance) ID() string {
+	return i.id
+}
+
+// RecordStart reads the applied migrations and how the previous instance on this host ended,
+// then records and logs the start of this one. Call it once the database is migrated.
+func (i *Instance) RecordStart(ctx context.Context, db *gorm.DB) error {
+	migrations, err := database.AppliedMigrations(db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	last, err := i.previousRestart(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	i.migrations, i.lastRestart = migrations, last
+	i.mu.Unlock()
+
+	fields := map[string]interface{}{
+		"instance_id":        i.id,
+		"host":               i.host,
+		"pid":                i.pid,
+		"version":            i.build.Version,
+		"revision":           i.build.Revision,
+		"config_fingerprint": i.fingerprint,
+		"migrations":         strings.Join(migrations, ","),
+	}
+	if last != nil {
+		fields["last_restart_cause"] = last.Reason
+	}
+	logger.WithFields(fields).Info("Instance started")
+
+	return db.WithContext(ctx).Create(i.event(models.InstanceStarted)).Error
+}
+
+// previousRestart returns how the last instance started on this host ended, or nil when none
+// was recorded.
+func (i *Instance) previousRestart(ctx context.Context, db *gorm.DB) (*RestartCause, error) {
+	var last models.InstanceEvent
+	err := db.WithContext(ctx).Where("host = ? AND instance_id <> ?", i.host, i.id).Order("id DESC").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the previous instance events: %w", err)
+	}
+	if last.Event != models.InstanceStopped {
+		return &RestartCause{
+			InstanceID: last.InstanceID,
+			Version:    last.Version,
+			StartedAt:  last.CreatedAt,
+			Reason:     uncleanExit,
+		}, nil
+	}
+
+	cause := &RestartCause{
+		InstanceID: last.InstanceID,
+		Version:    last.Version,
+		StoppedAt:  &last.CreatedAt,
+		Reason:     last.Reason,
+		Clean:      true,
+	}
+	var started models.InstanceEvent
+	err = db.WithContext(ctx).Where("instance_id = ? AND event = ?", last.InstanceID, models.InstanceStarted).First(&started).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to read the previous instance events: %w", err)
+	}
+	cause.StartedAt = started.CreatedAt
+	return cause, nil
+}
+
+// SetStopReason records why the instance is stopping, such as the signal received. Only the
+// first reason is kept.
+func (i *Instance) SetStopReason(reason string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if len(reason) > maxStopReason {
+		reason = reason[:maxStopReason]
+	}
+	if i.stopReason == "" {
+		i.stopReason = reason
+	}
+}
+
+// RecordStop records and logs the shutdown of the instance with the reason set by
+// SetStopReason. Run it before the database is closed.
+func (i *Instance) RecordStop(ctx context.Context, db *gorm.DB) error {
+	i.mu.Lock()
+	if i.stopReason == "" {
+		i.stopReason = "unknown"
+	}
+	i.mu.Unlock()
+	event := i.event(models.InstanceStopped)
+
+	logger.WithFields(map[string]interface{}{
+		"instance_id": i.id,
+		"reason":      event.Reason,
+		"uptime":      i.now().Sub(i.startedAt).Round(time.Second).String(),
+	}).Info("Instance stopping")
+
+	return db.WithContext(ctx).Create(event).Error
+}
+
+// event returns the record of kind for the instance.
+func (i *Instance) event(kind string) *models.InstanceEvent {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	e := &models.InstanceEvent{
+		InstanceID:        i.id,
+		Host:              i.host,
+		Event:             kind,
+		Version:           i.build.Version,
+		ConfigFingerprint: i.fingerprint,
+		Migrations:        strings.Join(i.migrations, ","),
+	}
+	if kind == models.InstanceStopped {
+		e.Reason = i.stopReason
+	}
+	return e
+}
+
+// Info returns the description of the instance and its uptime.
+func (i *Instance) Info() InstanceInfo {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	uptime := i.now().Sub(i.startedAt)
+	return InstanceInfo{
+		ID:                i.id,
+		Host:              i.host,
+		PID:               i.pid,
+		StartedAt:         i.startedAt,
+		Uptime:            uptime.Round(time.Second).String(),
+		UptimeSeconds:     uptime.Seconds(),
+		Build:             i.build,
+		ConfigFingerprint: i.fingerprint,
+		Migrations:        append([]string{}, i.migrations...),
+		LastRestart:       i.lastRestart,
+	}
+}
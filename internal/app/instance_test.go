@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestInstance_RestartCause(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	cfg := &config.Config{}
+
+	first := NewInstance(cfg, "v1.0.0")
+	if err := first.RecordStart(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	info := first.Info()
+	if info.LastRestart != nil {
+		t.Errorf("first start: last restart = %+v, want none", info.LastRestart)
+	}
+	if len(info.Migrations) == 0 || info.Build.Version != "v1.0.0" || len(info.ConfigFingerprint) != 64 {
+		t.Errorf("info = %+v, want the migrations, version and config fingerprint", info)
+	}
+	first.SetStopReason("signal: terminated")
+	first.SetStopReason("startup failed")
+	if err := first.RecordStop(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	second := NewInstance(cfg, "v1.1.0")
+	if err := second.RecordStart(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	last := second.Info().LastRestart
+	if last == nil || last.InstanceID != first.ID() || !last.Clean || last.Reason != "signal: terminated" || last.StartedAt.IsZero() {
+		t.Fatalf("last restart = %+v, want the clean stop of the first instance", last)
+	}
+
+	// The second instance never records its stop, as when it is killed
+	third := NewInstance(cfg, "v1.1.0")
+	if err := third.RecordStart(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	last = third.Info().LastRestart
+	if last == nil || last.InstanceID != second.ID() || last.Clean || last.Reason != uncleanExit {
+		t.Errorf("last restart = %+v, want the unclean exit of the second instance", last)
+	}
+
+	var events []models.InstanceEvent
+	if err := db.Order("id").Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 || events[1].Event != models.InstanceStopped || events[3].Version != "v1.1.0" {
+		t.Errorf("events = %+v, want started, stopped, started, started", events)
+	}
+}
+
+func TestConfigFingerprint(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.Port = "8080"
+	cfg.JWT.Secret = "one"
+	fingerprint := ConfigFingerprint(cfg)
+
+	cfg.JWT.Secret = "two"
+	if got := ConfigFingerprint(cfg); got != fingerprint {
+		t.Error("the fingerprint changed with a secret, want secrets left out")
+	}
+	cfg.Server.Port = "9090"
+	if got := ConfigFingerprint(cfg); got == fingerprint || strings.Contains(got, "9090") {
+		t.Errorf("fingerprint = %s, want a different hash for another port", got)
+	}
+}
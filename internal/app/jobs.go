@@ -0,0 +1,48 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/jobs"
+)
+
+var errJobsNegative = errors.New("JOBS_POLL_INTERVAL, JOBS_MAX_ATTEMPTS, JOBS_RETRY_BACKOFF, JOBS_LEASE and JOBS_FAILED_RETENTION must not be negative")
+
+// ValidateJobsConfig checks the job queue settings.
+func ValidateJobsConfig(cfg *config.Config) error {
+	j := cfg.Jobs
+	switch strings.ToLower(j.Backend) {
+	case "", "memory", "database":
+	default:
+		return fmt.Errorf("JOBS_BACKEND must be memory or database, got %q", j.Backend)
+	}
+	if j.PollInterval < 0 || j.MaxAttempts < 0 || j.RetryBackoff < 0 || j.Lease < 0 || j.FailedRetention < 0 {
+		return errJobsNegative
+	}
+	return nil
+}
+
+// NewJobQueue starts the background job queue selected by JOBS_BACKEND: in memory, or stored
+// in db.
+func NewJobQueue(cfg *config.Config, db *gorm.DB) (jobs.Queue, error) {
+	if err := ValidateJobsConfig(cfg); err != nil {
+		return nil, err
+	}
+	j := cfg.Jobs
+	if !strings.EqualFold(j.Backend, "database") {
+		return jobs.NewMemoryQueue(j.Workers, j.QueueSize), nil
+	}
+	return jobs.NewDurableQueue(db, jobs.DurableOptions{
+		Workers:      j.Workers,
+		QueueSize:    j.QueueSize,
+		PollInterval: j.PollInterval,
+		MaxAttempts:  j.MaxAttempts,
+		RetryBackoff: j.RetryBackoff,
+		Lease:        j.Lease,
+	}), nil
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/jobs"
+)
+
+func TestNewJobQueue(t *testing.T) {
+	cfg := &config.Config{}
+	queue, err := NewJobQueue(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewJobQueue() error = %v", err)
+	}
+	if _, ok := queue.(*jobs.MemoryQueue); !ok {
+		t.Errorf("NewJobQueue() = %T, want a memory queue by default", queue)
+	}
+	_ = queue.Shutdown(context.Background())
+
+	cfg.Jobs.Backend = "Database"
+	queue, err = NewJobQueue(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewJobQueue() error = %v", err)
+	}
+	if _, ok := queue.(*jobs.DurableQueue); !ok {
+		t.Errorf("NewJobQueue() = %T, want a durable queue", queue)
+	}
+	_ = queue.Shutdown(context.Background())
+}
+
+func TestValidateJobsConfig(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Jobs.Backend = "redis"
+	if err := ValidateJobsConfig(cfg); err == nil {
+		t.Error("ValidateJobsConfig() accepted an unknown backend")
+	}
+	cfg.Jobs.Backend = "database"
+	cfg.Jobs.Lease = -time.Minute
+	if err := ValidateJobsConfig(cfg); err == nil {
+		t.Error("ValidateJobsConfig() accepted a negative lease")
+	}
+}
@@ -0,0 +1,38 @@
+package app
+
+import "sync/atomic"
+
+// Lifecycle tracks the startup and shutdown phases of the server for the health probes:
+// /health/startup succeeds once the server is listening, and /health/ready fails as soon as
+// shutdown begins so the load balancer stops routing new requests before connections drain.
+//
+// A nil *Lifecycle reports a started, non-draining server.
+type Lifecycle struct {
+	started  atomic.Bool
+	draining atomic.Bool
+}
+
+// NewLifecycle returns a Lifecycle in the starting phase.
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// MarkStarted records that the server is listening for requests.
+func (l *Lifecycle) MarkStarted() {
+	l.started.Store(true)
+}
+
+// MarkDraining records that shutdown has begun.
+func (l *Lifecycle) MarkDraining() {
+	l.draining.Store(true)
+}
+
+// Started reports whether the server has finished starting.
+func (l *Lifecycle) Started() bool {
+	return l == nil || l.started.Load()
+}
+
+// Draining reports whether the server is shutting down.
+func (l *Lifecycle) Draining() bool {
+	return l != nil && l.draining.Load()
+}
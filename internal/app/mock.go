@@ -0,0 +1,22 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/apimock"
+)
+
+var errMockInProduction = errors.New("MOCK_MODE must not be set in production")
+
+// NewMockServer loads the specification whose examples answer unimplemented endpoints
+// (MOCK_SPEC), or returns nil when MOCK_MODE is not set. Mock mode is refused in production.
+func NewMockServer(cfg *config.Config) (*apimock.Server, error) {
+	if !cfg.Mock.Enabled {
+		return nil, nil
+	}
+	if cfg.Server.Environment == "production" {
+		return nil, errMockInProduction
+	}
+	return apimock.Load(cfg.Mock.Spec)
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+)
+
+const mockSpec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Test", "version": "1"},
+  "paths": {
+    "/api/widgets": {
+      "get": {"responses": {"200": {"description": "ok", "content": {"application/json": {"example": [{"id": 1}]}}}}},
+      "post": {"responses": {"201": {"description": "created", "content": {"application/json": {"example": {"id": 2}}}}}}
+    },
+    "/api/health": {
+      "get": {"responses": {"200": {"description": "ok", "content": {"application/json": {"example": {"mocked": true}}}}}}
+    }
+  }
+}`
+
+func TestNewMockServer(t *testing.T) {
+	cfg := &config.Config{Mock: config.MockConfig{Spec: filepath.Join(t.TempDir(), "missing.json")}}
+	if s, err := NewMockServer(cfg); s != nil || err != nil {
+		t.Errorf("NewMockServer() without MOCK_MODE = %v, %v", s, err)
+	}
+
+	cfg.Mock.Enabled = true
+	if _, err := NewMockServer(cfg); err == nil {
+		t.Error("NewMockServer() accepted a missing specification")
+	}
+
+	cfg.Mock.Spec = filepath.Join(t.TempDir(), "openapi.json")
+	if err := os.WriteFile(cfg.Mock.Spec, []byte(mockSpec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Server.Environment = "production"
+	if _, err := NewMockServer(cfg); err == nil {
+		t.Error("NewMockServer() enabled mock mode in production")
+	}
+	if _, err := NewBuilder(cfg).Build(); err == nil {
+		t.Error("Build() enabled mock mode in production")
+	}
+}
+
+func TestBuilder_MockUnimplementedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Mock: config.MockConfig{Enabled: true, Spec: filepath.Join(t.TempDir(), "openapi.json")}}
+	if err := os.WriteFile(cfg.Mock.Spec, []byte(mockSpec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	router, err := NewBuilder(cfg).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	router.GET("/api/health", func(c *gin.Context) { c.String(http.StatusOK, "implemented") })
+	router.DELETE("/api/widgets", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+		mocked       bool
+	}{
+		{http.MethodGet, "/api/widgets", http.StatusOK, `[{"id":1}]`, true},
+		{http.MethodPost, "/api/widgets", http.StatusCreated, `{"id":2}`, true},
+		{http.MethodGet, "/api/health", http.StatusOK, "implemented", false},
+		{http.MethodGet, "/api/gadgets", http.StatusNotFound, "", false},
+		{http.MethodPut, "/api/widgets", http.StatusMethodNotAllowed, "", false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		mocked := w.Header().Get(middlewares.MockResponseHeader) != ""
+		if w.Code != tt.status || mocked != tt.mocked || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s %s = %d %q (mocked %v), want %d %q (mocked %v)", tt.method, tt.path, w.Code, w.Body.String(), mocked,
+				tt.status, tt.body, tt.mocked)
+		}
+		if tt.mocked && w.Header().Get("Allow") != "" {
+			t.Errorf("%s %s kept the Allow header of a 405", tt.method, tt.path)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/notify"
+)
+
+// Login verification modes (LOGIN_VERIFICATION): which logins must confirm a code sent by
+// email before they receive a token.
+const (
+	LoginVerificationOff        = "off"
+	LoginVerificationNewDevice  = "new_device"
+	LoginVerificationNewCountry = "new_country"
+	LoginVerificationAny        = "any"
+)
+
+var errEmailCaptureInProduction = errors.New("EMAIL_CAPTURE must not be set in production")
+
+// capturedEmails is the inbox of EMAIL_CAPTURE, shared by every mailer of the process.
+var capturedEmails = notify.NewInbox(100)
+
+// EmailInbox returns the inbox that keeps emails instead of sending them, or nil when
+// EMAIL_CAPTURE is not set.
+func EmailInbox(cfg *config.Config) *notify.Inbox {
+	if !cfg.Notify.EmailCapture {
+		return nil
+	}
+	return capturedEmails
+}
+
+// NewNotifier creates the notifier of the channels in cfg (NOTIFY_CHANNELS), or nil when
+// notifications are disabled. With a queue, messages are delivered in the background.
+func NewNotifier(cfg *config.Config, db *gorm.DB, queue jobs.Queue) (notify.Notifier, error) {
+	var notifiers notify.Multi
+	for _, channel := range cfg.Notify.Channels {
+		switch channel {
+		case "none":
+		case notify.ChannelInApp:
+			notifiers = append(notifiers, notify.NewInApp(db))
+		case notify.ChannelLog:
+			notifiers = append(notifiers, notify.Log{})
+		case notify.ChannelEmail:
+			email, err := newEmail(cfg)
+			if err != nil {
+				return nil, err
+			}
+			notifiers = append(notifiers, email)
+		default:
+			return nil, fmt.Errorf("unknown notification channel %q (want %s, %s or %s)",
+				channel, notify.ChannelInApp, notify.ChannelEmail, notify.ChannelLog)
+		}
+	}
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+	return withQueue(notifiers, queue), nil
+}
+
+// NewMailer creates the email notifier that sends login verification codes, or nil when login
+// verification is off. Verification needs the SMTP settings whatever NOTIFY_CHANNELS lists,
+// since the code must reach the user outside the app.
+func NewMailer(cfg *config.Config, queue jobs.Queue) (notify.Notifier, error) {
+	switch cfg.Security.LoginVerification {
+	case LoginVerificationOff, "":
+		return nil, nil
+	case LoginVerificationNewDevice, LoginVerificationNewCountry, LoginVerificationAny:
+	default:
+		return nil, fmt.Errorf("unknown login verification mode %q (want %s, %s, %s or %s)", cfg.Security.LoginVerification,
+			LoginVerificationOff, LoginVerificationNewDevice, LoginVerificationNewCountry, LoginVerificationAny)
+	}
+	if cfg.Security.LoginVerificationTTL <= 0 {
+		return nil, fmt.Errorf("LOGIN_VERIFICATION_TTL must be positive")
+	}
+	email, err := newEmail(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("login verification: %w", err)
+	}
+	return withQueue(email, queue), nil
+}
+
+// NewInvitationMailer creates the email notifier that sends organization invitations, or nil
+// when neither SMTP nor EMAIL_CAPTURE is configured, in which case inviters share the tokens
+// themselves. It also sends the confirmations of email changes.
+func NewInvitationMailer(cfg *config.Config, queue jobs.Queue) (notify.Notifier, error) {
+	if cfg.Notify.SMTPHost == "" && !cfg.Notify.EmailCapture {
+		return nil, nil
+	}
+	email, err := newEmail(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("organization invitations: %w", err)
+	}
+	return withQueue(email, queue), nil
+}
+
+// newEmail creates the email channel: the SMTP server, or the inbox of EMAIL_CAPTURE.
+func newEmail(cfg *config.Config) (notify.Notifier, error) {
+	if cfg.Notify.EmailCapture {
+		if cfg.Server.Environment == "production" {
+			return nil, errEmailCaptureInProduction
+		}
+		return capturedEmails, nil
+	}
+	email, err := notify.NewEmail(notify.SMTPConfig{
+		Host:     cfg.Notify.SMTPHost,
+		Port:     cfg.Notify.SMTPPort,
+		Username: cfg.Notify.SMTPUsername,
+		Password: cfg.Notify.SMTPPassword,
+		From:     cfg.Notify.SMTPFrom,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return email, nil
+}
+
+func withQueue(n notify.Notifier, queue jobs.Queue) notify.Notifier {
+	if queue == nil {
+		return n
+	}
+	return notify.NewAsync(n, queue)
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/notify"
+)
+
+func TestNewNotifier(t *testing.T) {
+	for _, channels := range [][]string{nil, {"none"}} {
+		if n, err := NewNotifier(&config.Config{Notify: config.NotifyConfig{Channels: channels}}, nil, nil); n != nil || err != nil {
+			t.Errorf("NewNotifier(%v) = %v, %v; want none", channels, n, err)
+		}
+	}
+	if n, err := NewNotifier(&config.Config{Notify: config.NotifyConfig{Channels: []string{"inapp", "log"}}}, nil, nil); n == nil || err != nil {
+		t.Errorf("NewNotifier(inapp, log) = %v, %v", n, err)
+	}
+	if _, err := NewNotifier(&config.Config{Notify: config.NotifyConfig{Channels: []string{"email"}}}, nil, nil); err == nil {
+		t.Error("NewNotifier() accepted the email channel without SMTP settings")
+	}
+	if _, err := NewNotifier(&config.Config{Notify: config.NotifyConfig{Channels: []string{"sms"}}}, nil, nil); err == nil {
+		t.Error("NewNotifier() accepted an unknown channel")
+	}
+}
+
+func TestNewMailer(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Security.LoginVerification = LoginVerificationOff
+	if m, err := NewMailer(cfg, nil); m != nil || err != nil {
+		t.Errorf("NewMailer(off) = %v, %v", m, err)
+	}
+
+	cfg.Security.LoginVerification = LoginVerificationNewDevice
+	cfg.Security.LoginVerificationTTL = 10 * time.Minute
+	if _, err := NewMailer(cfg, nil); err == nil {
+		t.Error("NewMailer() accepted verification without SMTP settings")
+	}
+	cfg.Notify.SMTPHost, cfg.Notify.SMTPFrom = "smtp.example.com", "security@example.com"
+	if m, err := NewMailer(cfg, nil); m == nil || err != nil {
+		t.Errorf("NewMailer() = %v, %v", m, err)
+	}
+
+	cfg.Security.LoginVerification = "always"
+	if _, err := NewMailer(cfg, nil); err == nil {
+		t.Error("NewMailer() accepted an unknown mode")
+	}
+}
+
+func TestNewInvitationMailer(t *testing.T) {
+	cfg := &config.Config{}
+	if m, err := NewInvitationMailer(cfg, nil); m != nil || err != nil {
+		t.Errorf("NewInvitationMailer(no SMTP) = %v, %v; want none", m, err)
+	}
+	cfg.Notify.SMTPHost = "smtp.example.com"
+	if _, err := NewInvitationMailer(cfg, nil); err == nil {
+		t.Error("NewInvitationMailer() accepted SMTP settings without a sender")
+	}
+	cfg.Notify.SMTPFrom = "team@example.com"
+	if m, err := NewInvitationMailer(cfg, nil); m == nil || err != nil {
+		t.Errorf("NewInvitationMailer() = %v, %v", m, err)
+	}
+}
+
+func TestEmailCapture(t *testing.T) {
+	cfg := &config.Config{}
+	if inbox := EmailInbox(cfg); inbox != nil {
+		t.Errorf("EmailInbox() without EMAIL_CAPTURE = %v", inbox)
+	}
+
+	cfg.Notify.EmailCapture = true
+	cfg.Security.LoginVerification = LoginVerificationAny
+	cfg.Security.LoginVerificationTTL = 10 * time.Minute
+	inbox := EmailInbox(cfg)
+	inbox.Clear()
+	t.Cleanup(inbox.Clear)
+	mailer, err := NewMailer(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewMailer() without SMTP settings returned error: %v", err)
+	}
+	invitations, err := NewInvitationMailer(cfg, nil)
+	if err != nil || invitations == nil {
+		t.Fatalf("NewInvitationMailer() = %v, %v; want the inbox", invitations, err)
+	}
+	for _, m := range []notify.Notifier{mailer, invitations} {
+		if err := m.Notify(context.Background(), notify.Message{Email: "user@example.com", Subject: "Hi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if emails := inbox.Emails(); len(emails) != 2 {
+		t.Errorf("captured %d emails, want both mailers to share the inbox", len(emails))
+	}
+
+	cfg.Server.Environment = "production"
+	if _, err := NewInvitationMailer(cfg, nil); err == nil {
+		t.Error("NewInvitationMailer() accepted EMAIL_CAPTURE in production")
+	}
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+// Names of the built-in server profiles.
+const (
+	ProfileDevelopment = "development"
+	ProfileProduction  = "production"
+	ProfileTest        = "test"
+)
+
+// Profile tunes the Gin engine for an environment: how it matches paths that are not exactly
+// those of a route, and which header holds the client IP behind a known platform.
+type Profile struct {
+	Name string
+	// RedirectTrailingSlash redirects /users/ to /users (and back) when only the other exists.
+	RedirectTrailingSlash bool
+	// RedirectFixedPath redirects paths that match a route once cleaned and lowercased.
+	RedirectFixedPath bool
+	// RemoveExtraSlash matches paths with repeated slashes, such as //api//users, as if cleaned.
+	RemoveExtraSlash bool
+	// HandleMethodNotAllowed answers 405 with an Allow header, instead of 404, when the path
+	// exists for other methods.
+	HandleMethodNotAllowed bool
+	// TrustedPlatform is the header set by the platform in front of the server with the
+	// client IP, such as gin.PlatformCloudflare; empty uses X-Forwarded-For and X-Real-IP.
+	TrustedPlatform string
+	// SuggestRoutes adds "did you mean" suggestions of the closest routes to 404 errors, which
+	// reveals the route table to clients.
+	SuggestRoutes bool
+}
+
+// profiles are the built-in profiles. Development is forgiving with typed URLs and suggests
+// routes on 404; production and test never redirect, since API clients rarely follow redirects of non-GET requests, and
+// tests should see what production does.
+var profiles = map[string]Profile{
+	ProfileDevelopment: {
+		Name:                   ProfileDevelopment,
+		RedirectTrailingSlash:  true,
+		RedirectFixedPath:      true,
+		RemoveExtraSlash:       true,
+		HandleMethodNotAllowed: true,
+		SuggestRoutes:          true,
+	},
+	ProfileProduction: {
+		Name:                   ProfileProduction,
+		RemoveExtraSlash:       true,
+		HandleMethodNotAllowed: true,
+	},
+	ProfileTest: {
+		Name:                   ProfileTest,
+		RemoveExtraSlash:       true,
+		HandleMethodNotAllowed: true,
+	},
+}
+
+// trustedPlatforms maps the TRUSTED_PLATFORM names to the header of each platform.
+var trustedPlatforms = map[string]string{
+	"cloudflare":        gin.PlatformCloudflare,
+	"google-app-engine": gin.PlatformGoogleAppEngine,
+	"fly":               gin.PlatformFlyIO,
+}
+
+// NewProfile returns the profile named by SERVER_PROFILE, or by default the one of APP_ENV
+// (production for environments other than development and test), with the platform of
+// TRUSTED_PLATFORM: cloudflare, google-app-engine, fly, or the name of a header.
+func NewProfile(cfg *config.Config) (Profile, error) {
+	name := cfg.Server.Profile
+	if name == "" {
+		switch cfg.Server.Environment {
+		case ProfileDevelopment, ProfileTest:
+			name = cfg.Server.Environment
+		default:
+			name = ProfileProduction
+		}
+	}
+	p, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown server profile %q (want %s, %s or %s)",
+			name, ProfileDevelopment, ProfileProduction, ProfileTest)
+	}
+
+	if platform := cfg.Server.TrustedPlatform; platform != "" {
+		if header, ok := trustedPlatforms[strings.ToLower(platform)]; ok {
+			p.TrustedPlatform = header
+		} else {
+			p.TrustedPlatform = platform
+		}
+	}
+	return p, nil
+}
+
+// Apply sets the options of p on engine.
+func (p Profile) Apply(engine *gin.Engine) {
+	engine.RedirectTrailingSlash = p.RedirectTrailingSlash
+	engine.RedirectFixedPath = p.RedirectFixedPath
+	engine.RemoveExtraSlash = p.RemoveExtraSlash
+	engine.HandleMethodNotAllowed = p.HandleMethodNotAllowed
+	engine.TrustedPlatform = p.TrustedPlatform
+}
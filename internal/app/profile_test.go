@@ -0,0 +1,107 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewProfile(t *testing.T) {
+	for _, tt := range []struct {
+		env, profile, platform string
+		want                   Profile
+	}{
+		{env: "development", want: profiles[ProfileDevelopment]},
+		{env: "test", want: profiles[ProfileTest]},
+		{env: "staging", want: profiles[ProfileProduction]},
+		{env: "development", profile: "production", want: profiles[ProfileProduction]},
+		{env: "production", platform: "Cloudflare", want: Profile{
+			Name: ProfileProduction, RemoveExtraSlash: true, HandleMethodNotAllowed: true, TrustedPlatform: gin.PlatformCloudflare,
+		}},
+		{env: "production", platform: "X-Client-IP", want: Profile{
+			Name: ProfileProduction, RemoveExtraSlash: true, HandleMethodNotAllowed: true, TrustedPlatform: "X-Client-IP",
+		}},
+	} {
+		cfg := &config.Config{}
+		cfg.Server.Environment = tt.env
+		cfg.Server.Profile = tt.profile
+		cfg.Server.TrustedPlatform = tt.platform
+		got, err := NewProfile(cfg)
+		if err != nil || got != tt.want {
+			t.Errorf("NewProfile(%q, %q, %q) = %+v, %v, want %+v", tt.env, tt.profile, tt.platform, got, err, tt.want)
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.Server.Profile = "prod"
+	if _, err := NewProfile(cfg); err == nil {
+		t.Error("NewProfile() accepted an unknown profile")
+	}
+	if _, err := NewBuilder(cfg).Build(); err == nil {
+		t.Error("Build() accepted an unknown profile")
+	}
+}
+
+func TestBuilder_ProfileAndJSONErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := builderConfig()
+	cfg.Security.RateLimitEnabled = false
+	router, err := NewBuilder(cfg).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	router.GET("/api/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/api/items", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	errorCode := func(w *httptest.ResponseRecorder) string {
+		var body struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return body.Error.Code
+	}
+
+	if w := serve(http.MethodGet, "//api//items"); w.Code != http.StatusOK {
+		t.Errorf("extra slashes: status %d, want 200", w.Code)
+	}
+	w := serve(http.MethodGet, "/api/missing")
+	if w.Code != http.StatusNotFound || errorCode(w) != "NOT_FOUND" {
+		t.Errorf("unknown route: %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Request-ID") == "" {
+		t.Error("unknown route did not go through the pipeline")
+	}
+	w = serve(http.MethodDelete, "/api/items")
+	if w.Code != http.StatusMethodNotAllowed || errorCode(w) != "METHOD_NOT_ALLOWED" || w.Header().Get("Allow") != "GET, POST" {
+		t.Errorf("wrong method: %d %s (Allow %q)", w.Code, w.Body.String(), w.Header().Get("Allow"))
+	}
+	// Production neither redirects trailing slashes nor suggests routes
+	if w := serve(http.MethodGet, "/api/items/"); w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "did you mean") {
+		t.Errorf("trailing slash: %d %s, want 404 without suggestions", w.Code, w.Body.String())
+	}
+
+	router, err = NewBuilder(cfg, WithProfile(profiles[ProfileDevelopment])).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	router.GET("/api/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+	if w := serve(http.MethodGet, "/api/items/"); w.Code != http.StatusMovedPermanently {
+		t.Errorf("development trailing slash: status %d, want 301", w.Code)
+	}
+	if w := serve(http.MethodGet, "/api/item"); !strings.Contains(w.Body.String(), "did you mean /api/items?") {
+		t.Errorf("development 404 without suggestions: %s", w.Body.String())
+	}
+}
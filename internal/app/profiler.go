@@ -0,0 +1,32 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/profiler"
+)
+
+// NewProfiler creates the profiler of cfg (PROFILER_* variables), or returns nil when
+// PROFILER_ENABLED is not set. The s3 storage uses the bucket and credentials of the backups.
+func NewProfiler(cfg *config.Config, httpClient *http.Client) (*profiler.Profiler, error) {
+	p := cfg.Profiler
+	if !p.Enabled {
+		return nil, nil
+	}
+	store, err := newSharedStorage(cfg, httpClient, "profile", p.Storage, p.Dir, "PROFILER_DIR")
+	if err != nil {
+		return nil, err
+	}
+	return profiler.New(profiler.Options{
+		P99:          p.P99,
+		ErrorRate:    p.ErrorRate,
+		MinRequests:  p.MinRequests,
+		Window:       p.Window,
+		CPUDuration:  p.CPUDuration,
+		Cooldown:     p.Cooldown,
+		Store:        store,
+		Prefix:       p.Prefix,
+		MaxSnapshots: p.MaxSnapshots,
+	})
+}
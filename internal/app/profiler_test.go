@@ -0,0 +1,40 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewProfiler(t *testing.T) {
+	cfg := &config.Config{}
+	if p, err := NewProfiler(cfg, http.DefaultClient); p != nil || err != nil {
+		t.Fatalf("disabled: got %v, %v; want nil", p, err)
+	}
+
+	valid := config.ProfilerConfig{
+		Enabled:     true,
+		P99:         time.Second,
+		Window:      time.Minute,
+		CPUDuration: 10 * time.Second,
+		Storage:     BackupStorageLocal,
+		Dir:         t.TempDir(),
+	}
+	cfg.Profiler = valid
+	if p, err := NewProfiler(cfg, http.DefaultClient); p == nil || err != nil {
+		t.Fatalf("local: got %v, %v; want a profiler", p, err)
+	}
+
+	noWindow := valid
+	noWindow.Window = 0
+	unknownStorage := valid
+	unknownStorage.Storage = "ftp"
+	for name, profilerCfg := range map[string]config.ProfilerConfig{"no window": noWindow, "unknown storage": unknownStorage} {
+		cfg.Profiler = profilerCfg
+		if _, err := NewProfiler(cfg, http.DefaultClient); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/recorder"
+)
+
+var errRecordingBodyLimit = errors.New("RECORD_MAX_BODY_BYTES must be positive")
+
+// NewRecorder creates the recorder of API traffic in cfg (RECORD_* variables), which sanitizes
+// with the logging redaction settings, or returns nil when RECORD_TRAFFIC is not set.
+func NewRecorder(cfg *config.Config) (*recorder.Recorder, error) {
+	r := cfg.Recording
+	if !r.Enabled {
+		return nil, nil
+	}
+	if r.MaxBodyBytes <= 0 {
+		return nil, errRecordingBodyLimit
+	}
+	return recorder.New(recorder.Options{
+		Dir:          r.Dir,
+		Paths:        r.Paths,
+		MaxBodyBytes: r.MaxBodyBytes,
+		Redactor:     logger.NewRedactor(cfg.Logging.RedactFields, cfg.Logging.RedactEmails),
+	})
+}
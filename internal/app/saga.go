@@ -0,0 +1,29 @@
+package app
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/saga"
+)
+
+var (
+	errSagaNegative   = errors.New("SAGA_RESUME_INTERVAL, SAGA_STALE_AFTER and SAGA_RETENTION must not be negative")
+	errSagaStaleAfter = errors.New("SAGA_STALE_AFTER must be set when SAGA_RESUME_INTERVAL is")
+)
+
+// NewSagaRunner creates the runner of the sagas stored in db, run on queue and resumed as
+// configured in cfg (SAGA_* variables).
+func NewSagaRunner(cfg *config.Config, db *gorm.DB, queue jobs.Queue) (*saga.Runner, error) {
+	j := cfg.Jobs
+	if j.SagaResumeInterval < 0 || j.SagaStaleAfter < 0 || j.SagaRetention < 0 {
+		return nil, errSagaNegative
+	}
+	if j.SagaResumeInterval > 0 && j.SagaStaleAfter == 0 {
+		return nil, errSagaStaleAfter
+	}
+	return saga.NewRunner(db, queue, j.SagaStaleAfter), nil
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewSagaRunner(t *testing.T) {
+	cfg := &config.Config{}
+	if _, err := NewSagaRunner(cfg, nil, nil); err != nil {
+		t.Errorf("NewSagaRunner() error = %v", err)
+	}
+	cfg.Jobs.SagaResumeInterval = time.Minute
+	if _, err := NewSagaRunner(cfg, nil, nil); err == nil {
+		t.Error("NewSagaRunner() resumed sagas without a stale period")
+	}
+	cfg.Jobs.SagaStaleAfter = 10 * time.Minute
+	if _, err := NewSagaRunner(cfg, nil, nil); err != nil {
+		t.Errorf("NewSagaRunner() error = %v", err)
+	}
+	cfg.Jobs.SagaRetention = -time.Hour
+	if _, err := NewSagaRunner(cfg, nil, nil); err == nil {
+		t.Error("NewSagaRunner() accepted a negative retention")
+	}
+}
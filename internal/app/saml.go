@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/saml"
+)
+
+// maxSAMLMetadataSize bounds the IdP metadata read from a URL or file.
+const maxSAMLMetadataSize = 1 << 20
+
+// NewSAMLServiceProvider loads the IdP metadata in cfg and creates the SAML service provider,
+// or returns nil when SSO is not configured. Metadata URLs are fetched with httpClient.
+func NewSAMLServiceProvider(ctx context.Context, cfg *config.Config, httpClient *http.Client) (*saml.ServiceProvider, error) {
+	s := cfg.SAML
+	if err := ValidateSAMLConfig(cfg); err != nil || !s.Enabled() {
+		return nil, err
+	}
+
+	var data []byte
+	var err error
+	if s.IdPMetadataPath != "" {
+		data, err = readSAMLMetadataFile(s.IdPMetadataPath)
+	} else {
+		data, err = fetchSAMLMetadata(ctx, s.IdPMetadataURL, httpClient)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	idp, err := saml.ParseIdPMetadata(data)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := saml.NewServiceProvider(s.BaseURL, idp)
+	if err != nil {
+		return nil, err
+	}
+	sp.AllowIdPInitiated = s.AllowIdPInitiated
+	return sp, nil
+}
+
+// ValidateSAMLConfig checks the SAML settings without loading the IdP metadata.
+func ValidateSAMLConfig(cfg *config.Config) error {
+	s := cfg.SAML
+	if !s.Enabled() {
+		return nil
+	}
+	if _, err := saml.NewServiceProvider(s.BaseURL, nil); err != nil {
+		return err
+	}
+	if (s.IdPMetadataURL == "") == (s.IdPMetadataPath == "") {
+		return errors.New("SAML_BASE_URL needs exactly one of SAML_IDP_METADATA_URL and SAML_IDP_METADATA_PATH")
+	}
+	return nil
+}
+
+func readSAMLMetadataFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open SAML IdP metadata: %w", err)
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxSAMLMetadataSize))
+}
+
+func fetchSAMLMetadata(ctx context.Context, url string, httpClient *http.Client) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch SAML IdP metadata: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch SAML IdP metadata: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch SAML IdP metadata: unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSAMLMetadataSize))
+}
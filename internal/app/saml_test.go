@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/testutil/vcr"
+)
+
+func TestValidateSAMLConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		saml    config.SAMLConfig
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{name: "url", saml: config.SAMLConfig{BaseURL: "https://api.example.com/api/auth/saml", IdPMetadataURL: "https://idp.example.com/metadata"}},
+		{name: "file", saml: config.SAMLConfig{BaseURL: "https://api.example.com/api/auth/saml", IdPMetadataPath: "idp.xml"}},
+		{name: "no metadata", saml: config.SAMLConfig{BaseURL: "https://api.example.com/api/auth/saml"}, wantErr: true},
+		{name: "both sources", saml: config.SAMLConfig{BaseURL: "https://api.example.com/api/auth/saml", IdPMetadataURL: "https://idp.example.com/metadata", IdPMetadataPath: "idp.xml"}, wantErr: true},
+		{name: "relative base URL", saml: config.SAMLConfig{BaseURL: "/api/auth/saml", IdPMetadataPath: "idp.xml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidateSAMLConfig(&config.Config{SAML: tt.saml}); (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateSAMLConfig() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewSAMLServiceProvider(t *testing.T) {
+	if sp, err := NewSAMLServiceProvider(context.Background(), &config.Config{}, http.DefaultClient); sp != nil || err != nil {
+		t.Errorf("NewSAMLServiceProvider(disabled) = %v, %v", sp, err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "idp"}}, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := `<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.com">
+  <IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <KeyDescriptor use="signing"><KeyInfo xmlns="http://www.w3.org/2000/09/xmldsig#"><X509Data>
+      <X509Certificate>` + base64.StdEncoding.EncodeToString(der) + `</X509Certificate>
+    </X509Data></KeyInfo></KeyDescriptor>
+    <SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso"/>
+  </IDPSSODescriptor>
+</EntityDescriptor>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(metadata))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{SAML: config.SAMLConfig{
+		BaseURL:           "https://api.example.com/api/auth/saml",
+		IdPMetadataURL:    server.URL,
+		AllowIdPInitiated: true,
+	}}
+	sp, err := NewSAMLServiceProvider(context.Background(), cfg, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp.IdP.EntityID != "https://idp.example.com" || sp.ACSURL != "https://api.example.com/api/auth/saml/acs" || !sp.AllowIdPInitiated {
+		t.Errorf("service provider = %+v", sp)
+	}
+
+	cfg.SAML.IdPMetadataURL, cfg.SAML.IdPMetadataPath = "", filepath.Join(t.TempDir(), "missing.xml")
+	if _, err := NewSAMLServiceProvider(context.Background(), cfg, nil); err == nil {
+		t.Error("NewSAMLServiceProvider() accepted a missing metadata file")
+	}
+}
+
+func TestNewSAMLServiceProvider_Cassette(t *testing.T) {
+	cfg := &config.Config{SAML: config.SAMLConfig{
+		BaseURL:        "https://api.example.com/api/auth/saml",
+		IdPMetadataURL: "https://idp.example.com/saml/metadata",
+	}}
+	sp, err := NewSAMLServiceProvider(context.Background(), cfg, vcr.New(t, "saml_idp_metadata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp.IdP.EntityID != "https://idp.example.com/saml" || sp.IdP.SSOURL != "https://idp.example.com/saml/sso" {
+		t.Errorf("identity provider = %+v", sp.IdP)
+	}
+
+	if _, err := NewSAMLServiceProvider(context.Background(), cfg, vcr.New(t, "saml_idp_metadata_unavailable")); err == nil {
+		t.Error("NewSAMLServiceProvider() accepted a 503 metadata response")
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ShutdownPhase orders the shutdown hooks. The hooks of a phase start once every hook of the
+// earlier phases has returned, and run concurrently with the other hooks of their phase.
+type ShutdownPhase int
+
+// Shutdown phases, in the order they run.
+const (
+	// PhaseServers stops accepting requests and waits for those in flight.
+	PhaseServers ShutdownPhase = iota
+	// PhaseFlush writes the state buffered by the requests, such as counters.
+	PhaseFlush
+	// PhaseWorkers waits for background jobs and stops the background loops.
+	PhaseWorkers
+	// PhaseConnections closes the connections used by everything above: caches and the
+	// database.
+	PhaseConnections
+)
+
+// ShutdownHook stops a subsystem.
+type ShutdownHook struct {
+	// Name identifies the hook in logs.
+	Name  string
+	Phase ShutdownPhase
+	// Timeout bounds the hook on its own, even once the deadline of the whole shutdown has
+	// passed, for hooks that must run anyway such as closing the database. Hooks without one
+	// are bounded by that deadline.
+	Timeout time.Duration
+	// Run stops the subsystem, returning early when ctx is done.
+	Run func(ctx context.Context) error
+}
+
+// ShutdownResult is the outcome of a ShutdownHook.
+type ShutdownResult struct {
+	Name     string
+	Phase    ShutdownPhase
+	Err      error
+	Duration time.Duration
+}
+
+// ShutdownHooks is a registry of the shutdown hooks of the subsystems, so that each one is
+// stopped where it is started instead of in a hand-written sequence at the end of main. It is
+// safe for concurrent use.
+type ShutdownHooks struct {
+	mu    sync.Mutex
+	hooks []ShutdownHook
+}
+
+// NewShutdownHooks returns an empty registry.
+func NewShutdownHooks() *ShutdownHooks {
+	return &ShutdownHooks{}
+}
+
+// Register adds a hook run by Run in phase, bounded by timeout (zero for none).
+func (h *ShutdownHooks) Register(name string, phase ShutdownPhase, timeout time.Duration, run func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, ShutdownHook{Name: name, Phase: phase, Timeout: timeout, Run: run})
+}
+
+// Run runs the registered hooks phase by phase and returns their results, ordered by phase
+// and registration. A hook still running when its timeout, or the deadline of ctx, passes is
+// abandoned: it fails with the context error and the next phase starts without it.
+func (h *ShutdownHooks) Run(ctx context.Context) []ShutdownResult {
+	h.mu.Lock()
+	hooks := append([]ShutdownHook(nil), h.hooks...)
+	h.mu.Unlock()
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].Phase < hooks[j].Phase })
+
+	results := make([]ShutdownResult, len(hooks))
+	for start := 0; start < len(hooks); {
+		end := start
+		for end < len(hooks) && hooks[end].Phase == hooks[start].Phase {
+			end++
+		}
+
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = runShutdownHook(ctx, hooks[i])
+			}(i)
+		}
+		wg.Wait()
+		start = end
+	}
+	return results
+}
+
+func runShutdownHook(ctx context.Context, hook ShutdownHook) ShutdownResult {
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), hook.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- hook.Run(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		select {
+		case err = <-done:
+		default:
+			err = fmt.Errorf("abandoned: %w", ctx.Err())
+		}
+	}
+	return ShutdownResult{Name: hook.Name, Phase: hook.Phase, Err: err, Duration: time.Since(start)}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownHooks_RunsPhasesInOrder(t *testing.T) {
+	hooks := NewShutdownHooks()
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+	hooks.Register("database", PhaseConnections, 0, record("database"))
+	hooks.Register("jobs", PhaseWorkers, 0, record("jobs"))
+	hooks.Register("api", PhaseServers, 0, record("api"))
+	hooks.Register("usage", PhaseFlush, 0, record("usage"))
+
+	results := hooks.Run(context.Background())
+	want := []string{"api", "usage", "jobs", "database"}
+	for i, name := range want {
+		if order[i] != name || results[i].Name != name || results[i].Err != nil {
+			t.Fatalf("ran %v with results %+v, want %v", order, results, want)
+		}
+	}
+}
+
+func TestShutdownHooks_PhaseRunsConcurrently(t *testing.T) {
+	hooks := NewShutdownHooks()
+	// Each hook waits for the other, so they only return when run together
+	a, b := make(chan struct{}), make(chan struct{})
+	hooks.Register("a", PhaseServers, time.Second, func(ctx context.Context) error {
+		close(a)
+		select {
+		case <-b:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	hooks.Register("b", PhaseServers, time.Second, func(ctx context.Context) error {
+		close(b)
+		select {
+		case <-a:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	for _, r := range hooks.Run(context.Background()) {
+		if r.Err != nil {
+			t.Errorf("hook %s: %v", r.Name, r.Err)
+		}
+	}
+}
+
+func TestShutdownHooks_AbandonsSlowHooks(t *testing.T) {
+	hooks := NewShutdownHooks()
+	release := make(chan struct{})
+	defer close(release)
+	hooks.Register("stuck", PhaseWorkers, 20*time.Millisecond, func(context.Context) error {
+		<-release // ignores its context
+		return nil
+	})
+	failed := errors.New("flush failed")
+	hooks.Register("flush", PhaseFlush, 0, func(context.Context) error { return failed })
+	closed := false
+	hooks.Register("database", PhaseConnections, 0, func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	start := time.Now()
+	results := hooks.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Run took %v, want the stuck hook abandoned after its timeout", elapsed)
+	}
+	if !errors.Is(results[0].Err, failed) {
+		t.Errorf("flush: %v, want its error", results[0].Err)
+	}
+	if !errors.Is(results[1].Err, context.DeadlineExceeded) {
+		t.Errorf("stuck: %v, want DeadlineExceeded", results[1].Err)
+	}
+	if !closed || results[2].Err != nil {
+		t.Errorf("database: closed = %v, %v; want later phases to run", closed, results[2].Err)
+	}
+}
+
+func TestShutdownHooks_OwnTimeoutOutlivesDeadline(t *testing.T) {
+	hooks := NewShutdownHooks()
+	hooks.Register("api", PhaseServers, 0, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	hooks.Register("database", PhaseConnections, time.Second, func(ctx context.Context) error {
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results := hooks.Run(ctx)
+	if !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Errorf("api: %v, want DeadlineExceeded", results[0].Err)
+	}
+	if results[1].Err != nil {
+		t.Errorf("database: %v, want its own timeout to apply", results[1].Err)
+	}
+}
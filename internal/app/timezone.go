@@ -0,0 +1,48 @@
+package app
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/preferences"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+)
+
+// UserTimeZone returns the Location of response.LocalizedSerializer: the locale.timezone
+// preference of the signed-in user. Anonymous requests, users who kept the UTC default, and
+// failed lookups get nil, which keeps timestamps in UTC.
+func UserTimeZone(db *gorm.DB) func(c *gin.Context) *time.Location {
+	setting, _ := preferences.Lookup("locale", "timezone")
+	key := preferences.Key("locale", "timezone")
+
+	return func(c *gin.Context) *time.Location {
+		userID := requestctx.UserID(c)
+		if userID == 0 {
+			return nil
+		}
+		var stored []models.Preference
+		err := db.WithContext(c.Request.Context()).
+			Where("user_id = ? AND setting = ?", userID, key).Limit(1).Find(&stored).Error
+		if err != nil {
+			logger.WithField("error", err.Error()).Warn("Failed to load the time zone of the user")
+			return nil
+		}
+		if len(stored) == 0 {
+			return nil
+		}
+		value, err := setting.Decode(json.RawMessage(stored[0].Value))
+		if err != nil {
+			return nil
+		}
+		loc, err := time.LoadLocation(value.(string))
+		if err != nil {
+			return nil
+		}
+		return loc
+	}
+}
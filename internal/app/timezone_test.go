@@ -0,0 +1,40 @@
+package app
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestUserTimeZone(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := testutil.CreateUser(t, db)
+	other := testutil.CreateUser(t, db)
+	if err := db.Create(&models.Preference{UserID: user.ID, Setting: "locale.timezone", Value: `"America/Bogota"`}).Error; err != nil {
+		t.Fatal(err)
+	}
+	location := UserTimeZone(db)
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	if loc := location(c); loc != nil {
+		t.Errorf("anonymous request: location = %v, want nil", loc)
+	}
+
+	requestctx.SetUser(c, other, time.Time{})
+	if loc := location(c); loc != nil {
+		t.Errorf("user with the default: location = %v, want nil", loc)
+	}
+
+	requestctx.SetUser(c, user, time.Time{})
+	if loc := location(c); loc == nil || loc.String() != "America/Bogota" {
+		t.Errorf("location = %v, want the preference of the user", loc)
+	}
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/watchdog"
+)
+
+// NewWatchdog creates the runtime watchdog of cfg (WATCHDOG_* variables), or returns nil when
+// neither WATCHDOG_HEAP_LIMIT nor WATCHDOG_GOROUTINE_LIMIT is set.
+func NewWatchdog(cfg *config.Config) (*watchdog.Watchdog, error) {
+	s := cfg.Server
+	if s.WatchdogHeapLimit < 0 || s.WatchdogGoroutineLimit < 0 {
+		return nil, fmt.Errorf("WATCHDOG_HEAP_LIMIT and WATCHDOG_GOROUTINE_LIMIT must not be negative")
+	}
+	if s.WatchdogHeapLimit == 0 && s.WatchdogGoroutineLimit == 0 {
+		return nil, nil
+	}
+	if s.WatchdogInterval <= 0 {
+		return nil, fmt.Errorf("WATCHDOG_INTERVAL must be positive when a watchdog limit is set")
+	}
+	return watchdog.New(watchdog.Limits{
+		HeapBytes:  uint64(s.WatchdogHeapLimit),
+		Goroutines: s.WatchdogGoroutineLimit,
+		Shed:       s.WatchdogShedLoad,
+	}), nil
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/config"
+)
+
+func TestNewWatchdog(t *testing.T) {
+	cfg := &config.Config{Server: config.ServerConfig{WatchdogInterval: 10 * time.Second}}
+	if w, err := NewWatchdog(cfg); w != nil || err != nil {
+		t.Fatalf("without limits: got %v, %v; want nil", w, err)
+	}
+
+	cfg.Server.WatchdogGoroutineLimit = 10000
+	w, err := NewWatchdog(cfg)
+	if w == nil || err != nil {
+		t.Fatalf("with a limit: got %v, %v; want a watchdog", w, err)
+	}
+	if stats := w.Stats(); stats.GoroutineLimit != 10000 {
+		t.Errorf("Stats() = %+v, want the configured limit", stats)
+	}
+
+	for name, server := range map[string]config.ServerConfig{
+		"negative heap limit": {WatchdogInterval: time.Second, WatchdogHeapLimit: -1},
+		"no interval":         {WatchdogHeapLimit: 1 << 30},
+	} {
+		cfg.Server = server
+		if _, err := NewWatchdog(cfg); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Package assets serves static files, such as images, stylesheets, scripts and media, under a
+// configurable prefix. Besides its own name, every file is served under a name carrying a hash
+// of its content (app.css as app.3f2a9c1be0.css). A new version of the file gets a new name,
+// so browsers and CDNs may cache those for a year without revalidating; URL returns the name
+// to link. Byte-range requests are answered with 206, so that players can seek in media.
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+//go:embed static
+var static embed.FS
+
+// hashLength is the number of hex digits of the content hash in file names.
+const hashLength = 10
+
+// Cache-Control of the hashed names, which never change content, and of the plain names,
+// which are revalidated with their ETag on every use.
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "no-cache"
+)
+
+// Embedded returns the files of the static directory of this package, compiled into the binary
+// for single-binary deployments.
+func Embedded() fs.FS {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // the embedded directory is part of the binary
+	}
+	return files
+}
+
+// Server serves a set of files. The files are listed and hashed by New: files added later are
+// not served, and files changed later keep their previous hash until the next start.
+type Server struct {
+	files  fs.FS
+	prefix string
+	// hashes maps the names of the files to the hash of their content, and hashed maps the
+	// hashed names back to the names.
+	hashes map[string]string
+	hashed map[string]string
+}
+
+// New hashes every regular file of files, except those whose name starts with a dot, to serve
+// them under prefix (e.g. "/static").
+func New(files fs.FS, prefix string) (*Server, error) {
+	s := &Server{
+		files:  files,
+		prefix: "/" + strings.Trim(prefix, "/"),
+		hashes: make(map[string]string),
+		hashed: make(map[string]string),
+	}
+	err := fs.WalkDir(files, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		hash, err := hashFile(files, name)
+		if err != nil {
+			return err
+		}
+		s.hashes[name] = hash
+		s.hashed[hashedName(name, hash)] = name
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash static files: %w", err)
+	}
+	return s, nil
+}
+
+// Register serves the files under the prefix of s.
+func (s *Server) Register(router gin.IRouter) {
+	router.GET(s.prefix+"/*filepath", s.serve)
+	router.HEAD(s.prefix+"/*filepath", s.serve)
+}
+
+// URL returns the path of the hashed name of the file name (e.g. "css/app.css"), or its plain
+// path when there is no such file.
+func (s *Server) URL(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if hash, ok := s.hashes[name]; ok {
+		name = hashedName(name, hash)
+	}
+	return s.prefix + "/" + name
+}
+
+func (s *Server) serve(c *gin.Context) {
+	name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+	cacheControl := revalidateCacheControl
+	if original, ok := s.hashed[name]; ok {
+		name = original
+		cacheControl = immutableCacheControl
+	}
+	hash, ok := s.hashes[name]
+	if !ok {
+		response.NotFoundError(c, "File not found", "No static file matches "+c.Request.URL.Path)
+		return
+	}
+
+	file, err := s.files.Open(name)
+	if err != nil {
+		response.NotFoundError(c, "File not found", "No static file matches "+c.Request.URL.Path)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		response.InternalServerError(c, "Failed to read static file", "An unexpected error occurred")
+		return
+	}
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			response.InternalServerError(c, "Failed to read static file", "An unexpected error occurred")
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	// The ETag answers If-None-Match with 304 and lets If-Range resume a download only while
+	// the file is unchanged
+	c.Header("Cache-Control", cacheControl)
+	c.Header("ETag", `"`+hash+`"`)
+	http.ServeContent(c.Writer, c.Request, name, info.ModTime(), content)
+}
+
+// hashFile returns the first hashLength hex digits of the SHA-256 of the file name.
+func hashFile(files fs.FS, name string) (string, error) {
+	file, err := files.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:hashLength], nil
+}
+
+// hashedName inserts hash before the extension of name: css/app.css becomes
+// css/app.<hash>.css.
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
@@ -0,0 +1,139 @@
+package assets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(t *testing.T) (*gin.Engine, *Server) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	files := fstest.MapFS{
+		"css/app.css":    {Data: []byte("body { margin: 0 }")},
+		"media/clip.mp4": {Data: []byte("0123456789abcdefghij")},
+		"robots":         {Data: []byte("User-agent: *")},
+		".env":           {Data: []byte("SECRET=1")},
+		".git/config":    {Data: []byte("[core]")},
+	}
+	s, err := New(files, "/static/")
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	r := gin.New()
+	s.Register(r)
+	return r, s
+}
+
+func get(r http.Handler, path string, header map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestURLReturnsHashedNames(t *testing.T) {
+	_, s := newTestRouter(t)
+
+	url := s.URL("css/app.css")
+	if !strings.HasPrefix(url, "/static/css/app.") || !strings.HasSuffix(url, ".css") || len(url) != len("/static/css/app.css")+hashLength+1 {
+		t.Errorf("URL(css/app.css) = %q, want a hashed name", url)
+	}
+	if url := s.URL("/robots"); !strings.HasPrefix(url, "/static/robots.") {
+		t.Errorf("URL(/robots) = %q, want a hashed name without extension", url)
+	}
+	if url := s.URL("missing.js"); url != "/static/missing.js" {
+		t.Errorf("URL(missing.js) = %q, want the plain path", url)
+	}
+}
+
+func TestServeSetsCacheHeaders(t *testing.T) {
+	r, s := newTestRouter(t)
+
+	hashed := get(r, s.URL("css/app.css"), nil)
+	if hashed.Code != http.StatusOK || hashed.Body.String() != "body { margin: 0 }" {
+		t.Fatalf("hashed: status = %d, body = %q", hashed.Code, hashed.Body.String())
+	}
+	if cc := hashed.Header().Get("Cache-Control"); cc != immutableCacheControl {
+		t.Errorf("hashed: Cache-Control = %q, want immutable", cc)
+	}
+	if ct := hashed.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/css") {
+		t.Errorf("hashed: Content-Type = %q, want text/css", ct)
+	}
+
+	plain := get(r, "/static/css/app.css", nil)
+	if plain.Code != http.StatusOK || plain.Header().Get("Cache-Control") != revalidateCacheControl {
+		t.Fatalf("plain: status = %d, Cache-Control = %q", plain.Code, plain.Header().Get("Cache-Control"))
+	}
+	etag := plain.Header().Get("ETag")
+	if etag == "" || etag != hashed.Header().Get("ETag") {
+		t.Fatalf("ETag = %q, want the content hash on both names", etag)
+	}
+
+	if w := get(r, "/static/css/app.css", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: status = %d, want 304", w.Code)
+	}
+}
+
+func TestServeRanges(t *testing.T) {
+	r, s := newTestRouter(t)
+
+	w := get(r, "/static/media/clip.mp4", map[string]string{"Range": "bytes=10-14"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "abcde" {
+		t.Fatalf("status = %d, body = %q; want 206 with bytes 10-14", w.Code, w.Body.String())
+	}
+	if cr := w.Header().Get("Content-Range"); cr != "bytes 10-14/20" {
+		t.Errorf("Content-Range = %q", cr)
+	}
+	if ar := w.Header().Get("Accept-Ranges"); ar != "bytes" {
+		t.Errorf("Accept-Ranges = %q, want bytes", ar)
+	}
+
+	if w := get(r, s.URL("media/clip.mp4"), map[string]string{"Range": "bytes=15-"}); w.Code != http.StatusPartialContent || w.Body.String() != "fghij" {
+		t.Errorf("hashed: status = %d, body = %q; want the last 5 bytes", w.Code, w.Body.String())
+	}
+
+	// A resumed download of another version of the file gets the whole file
+	stale := map[string]string{"Range": "bytes=10-", "If-Range": `"0000000000"`}
+	if w := get(r, "/static/media/clip.mp4", stale); w.Code != http.StatusOK || w.Body.Len() != 20 {
+		t.Errorf("If-Range: status = %d, %d bytes; want the whole file", w.Code, w.Body.Len())
+	}
+
+	if w := get(r, "/static/media/clip.mp4", map[string]string{"Range": "bytes=50-60"}); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("out of range: status = %d, want 416", w.Code)
+	}
+}
+
+func TestServeRejectsUnknownFiles(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	for _, path := range []string{
+		"/static/missing.css",
+		"/static/css/app.0000000000.css", // a hash of another version
+		"/static/.env",
+		"/static/.git/config",
+		"/static/css/",
+		"/static/../go.mod",
+	} {
+		if w := get(r, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", path, w.Code)
+		}
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	s, err := New(Embedded(), "/static")
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	if url := s.URL("README.txt"); url == "/static/README.txt" {
+		t.Errorf("URL(README.txt) = %q, want the embedded file hashed", url)
+	}
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+// APIKeyHeader is the request header that carries an API key.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix marks API keys, so they are recognizable in logs and by secret scanners.
+const apiKeyPrefix = "gtk_"
+
+// apiKeyDisplayLength is how many leading characters of a key are kept to identify it.
+const apiKeyDisplayLength = 12
+
+// NewAPIKey generates an API key. It returns the key, shown once to its owner, and the
+// prefix and hash to store.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = apiKeyPrefix + hex.EncodeToString(b)
+	return key, key[:apiKeyDisplayLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the stored hash of an API key. Keys are random, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadAPIKey returns the API key matching key.
+func LoadAPIKey(db *gorm.DB, key string) (models.APIKey, error) {
+	var apiKey models.APIKey
+	err := db.Where("key_hash = ?", HashAPIKey(key)).First(&apiKey).Error
+	return apiKey, err
+}
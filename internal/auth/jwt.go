@@ -5,20 +5,54 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/yeferson59/gin-template/pkg/clock"
+)
+
+var (
+	authClock   = clock.Real
+	authClockMu sync.RWMutex
 )
 
+// SetClock replaces the clock with which tokens are issued and their expiry is checked; nil
+// restores the system clock. Tests use it to expire tokens without waiting.
+func SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.Real
+	}
+	authClockMu.Lock()
+	defer authClockMu.Unlock()
+	authClock = c
+}
+
+// Now returns the time of the clock set with SetClock.
+func Now() time.Time {
+	authClockMu.RLock()
+	defer authClockMu.RUnlock()
+	return authClock.Now()
+}
+
 // Claims defines the structure of the JWT payload.
 type Claims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
+	// Risk is the risk score of the login that issued the token, so that requests made with it
+	// can be stepped up (see middlewares.RequireStepUp).
+	Risk int `json:"risk,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateJWT generates a JWT token for a given user.
 func GenerateJWT(userID uint, email string) (string, error) {
+	return GenerateLoginJWT(userID, email, 0)
+}
+
+// GenerateLoginJWT generates a JWT token for a user who just logged in with the given risk score.
+func GenerateLoginJWT(userID uint, email string, risk int) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		return "", errors.New("JWT_SECRET is not set")
@@ -31,13 +65,15 @@ func GenerateJWT(userID uint, email string) (string, error) {
 		}
 	}
 
-	expirationTime := time.Now().Add(time.Duration(expMinutes) * time.Minute)
+	now := Now()
+	expirationTime := now.Add(time.Duration(expMinutes) * time.Minute)
 	claims := &Claims{
 		UserID: userID,
 		Email:  email,
+		Risk:   risk,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -62,7 +98,7 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 			return nil, errors.New("invalid signing method")
 		}
 		return []byte(secret), nil
-	})
+	}, jwt.WithTimeFunc(Now))
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/yeferson59/gin-template/pkg/clock"
+)
+
+const benchSecret = "benchmark-jwt-secret-0123456789abcdef"
+
+func TestValidateJWT_RoundTrip(t *testing.T) {
+	t.Setenv("JWT_SECRET", benchSecret)
+
+	token, err := GenerateJWT(42, "user@example.com")
+	if err != nil {
+		t.Fatalf("GenerateJWT: %v", err)
+	}
+	claims, err := ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("ValidateJWT: %v", err)
+	}
+	if claims.UserID != 42 || claims.Email != "user@example.com" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	t.Setenv("JWT_SECRET", "another-secret-0123456789abcdefgh")
+	if _, err := ValidateJWT(token); err == nil {
+		t.Fatal("expected a token signed with another secret to be rejected")
+	}
+}
+
+func TestGenerateLoginJWT_CarriesRisk(t *testing.T) {
+	t.Setenv("JWT_SECRET", benchSecret)
+
+	token, err := GenerateLoginJWT(42, "user@example.com", 80)
+	if err != nil {
+		t.Fatalf("GenerateLoginJWT: %v", err)
+	}
+	claims, err := ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("ValidateJWT: %v", err)
+	}
+	if claims.Risk != 80 {
+		t.Fatalf("risk = %d, want 80", claims.Risk)
+	}
+}
+
+func BenchmarkGenerateJWT(b *testing.B) {
+	b.Setenv("JWT_SECRET", benchSecret)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := GenerateJWT(42, "user@example.com"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateJWT(b *testing.B) {
+	b.Setenv("JWT_SECRET", benchSecret)
+	token, err := GenerateJWT(42, "user@example.com")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ValidateJWT(token); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateJWT_Parallel(b *testing.B) {
+	b.Setenv("JWT_SECRET", benchSecret)
+	token, err := GenerateJWT(42, "user@example.com")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := ValidateJWT(token); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func TestValidateJWT_Expiry(t *testing.T) {
+	t.Setenv("JWT_SECRET", benchSecret)
+	t.Setenv("JWT_EXP_MINUTES", "15")
+	clk := clock.NewFake(time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC))
+	SetClock(clk)
+	t.Cleanup(func() { SetClock(nil) })
+
+	token, err := GenerateJWT(42, "user@example.com")
+	if err != nil {
+		t.Fatalf("GenerateJWT: %v", err)
+	}
+	claims, err := ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("ValidateJWT: %v", err)
+	}
+	if !claims.IssuedAt.Equal(clk.Now()) || !claims.ExpiresAt.Equal(clk.Now().Add(15*time.Minute)) {
+		t.Fatalf("issued at %v, expires at %v; want the fake clock", claims.IssuedAt, claims.ExpiresAt)
+	}
+
+	clk.Advance(14 * time.Minute)
+	if _, err := ValidateJWT(token); err != nil {
+		t.Fatalf("ValidateJWT before expiry: %v", err)
+	}
+	clk.Advance(2 * time.Minute)
+	if _, err := ValidateJWT(token); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Fatalf("ValidateJWT after expiry = %v, want ErrTokenExpired", err)
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+var passwordHashDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "password_hash_duration_seconds",
+	Help:    "Time spent hashing or verifying passwords with bcrypt, excluding the wait for a slot.",
+	Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation"})
+
+var passwordHashWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "password_hash_wait_seconds",
+	Help:    "Time spent waiting for a free password hashing slot.",
+	Buckets: []float64{.001, .01, .05, .1, .25, .5, 1, 2.5, 5},
+})
+
+func init() {
+	metrics.Registry.MustRegister(passwordHashDuration, passwordHashWait)
+}
+
+// PasswordHasher hashes and verifies passwords with bcrypt. At most concurrency operations run
+// at once; the others wait for a slot, so a burst of registrations or logins queues up instead
+// of taking every CPU away from the rest of the API.
+type PasswordHasher struct {
+	cost  int
+	slots chan struct{}
+}
+
+// NewPasswordHasher creates a hasher using the given bcrypt cost. A concurrency <= 0 allows
+// one operation per CPU.
+func NewPasswordHasher(cost, concurrency int) *PasswordHasher {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	return &PasswordHasher{cost: cost, slots: make(chan struct{}, concurrency)}
+}
+
+// Hash returns the bcrypt hash of password. It fails with ctx's error if ctx ends while
+// waiting for a slot.
+func (h *PasswordHasher) Hash(ctx context.Context, password string) (string, error) {
+	var hashed []byte
+	err := h.run(ctx, "hash", func() (err error) {
+		hashed, err = bcrypt.GenerateFromPassword([]byte(password), h.cost)
+		return err
+	})
+	return string(hashed), err
+}
+
+// Compare reports whether password matches hash, returning bcrypt.ErrMismatchedHashAndPassword
+// when it does not.
+func (h *PasswordHasher) Compare(ctx context.Context, hash, password string) error {
+	return h.run(ctx, "compare", func() error {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	})
+}
+
+func (h *PasswordHasher) run(ctx context.Context, operation string, fn func() error) error {
+	waitStart := time.Now()
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("waiting to %s password: %w", operation, ctx.Err())
+	}
+	defer func() { <-h.slots }()
+	passwordHashWait.Observe(time.Since(waitStart).Seconds())
+
+	start := time.Now()
+	err := fn()
+	passwordHashDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	return err
+}
+
+var (
+	passwordHasher   = NewPasswordHasher(bcrypt.DefaultCost, 0)
+	passwordHasherMu sync.RWMutex
+)
+
+// SetPasswordHasher replaces the hasher used by HashPassword and ComparePassword.
+func SetPasswordHasher(h *PasswordHasher) {
+	passwordHasherMu.Lock()
+	defer passwordHasherMu.Unlock()
+	passwordHasher = h
+}
+
+func currentPasswordHasher() *PasswordHasher {
+	passwordHasherMu.RLock()
+	defer passwordHasherMu.RUnlock()
+	return passwordHasher
+}
+
+// HashPassword hashes password with the configured hasher (BCRYPT_COST).
+func HashPassword(ctx context.Context, password string) (string, error) {
+	return currentPasswordHasher().Hash(ctx, password)
+}
+
+// ComparePassword verifies password against hash with the configured hasher.
+func ComparePassword(ctx context.Context, hash, password string) error {
+	return currentPasswordHasher().Compare(ctx, hash, password)
+}
+
+// ValidateBcryptCost reports whether cost is accepted by bcrypt.
+func ValidateBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHasher_HashAndCompare(t *testing.T) {
+	h := NewPasswordHasher(bcrypt.MinCost, 1)
+	ctx := context.Background()
+
+	hashed, err := h.Hash(ctx, "Password123!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(hashed)); cost != bcrypt.MinCost {
+		t.Fatalf("expected cost %d, got %d", bcrypt.MinCost, cost)
+	}
+	if err := h.Compare(ctx, hashed, "Password123!"); err != nil {
+		t.Fatalf("expected the password to match, got %v", err)
+	}
+	if err := h.Compare(ctx, hashed, "wrong"); !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		t.Fatalf("expected a mismatch, got %v", err)
+	}
+}
+
+func TestPasswordHasher_WaitRespectsContext(t *testing.T) {
+	h := NewPasswordHasher(bcrypt.MinCost, 1)
+	h.slots <- struct{}{} // occupy the only slot
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := h.Hash(ctx, "Password123!"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to end with the context, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/events"
+)
+
+// userCacheName is the name the cache is registered under as a GORM plugin.
+const userCacheName = "auth:user_cache"
+
+// UserCache keeps recently authenticated users in memory so that authenticated requests do not
+// query the database every time. Entries expire after the TTL, and the whole cache is cleared
+// whenever the users table is updated or deleted from (or a raw statement runs) through the
+// database it is attached to, so a password change, role change, or deletion takes effect on
+// the next request. Inside database.WithTransaction it is cleared again once the transaction
+// commits, since requests running meanwhile still read and cache the previous rows. Writes
+// made by other processes are only picked up once entries expire.
+type UserCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.RWMutex
+	entries map[uint]userCacheEntry
+}
+
+type userCacheEntry struct {
+	user      models.User
+	expiresAt time.Time
+}
+
+// NewUserCache creates a cache holding at most maxEntries users for ttl each.
+// maxEntries <= 0 means unbounded.
+func NewUserCache(ttl time.Duration, maxEntries int) *UserCache {
+	return &UserCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[uint]userCacheEntry),
+	}
+}
+
+// UseUserCache attaches cache to db. LoadUser then serves users from the cache, and writes
+// to the users table through db invalidate it.
+func UseUserCache(db *gorm.DB, cache *UserCache) error {
+	return db.Use(cache)
+}
+
+// LoadUser returns the user with id, from the cache attached to db when there is one.
+func LoadUser(db *gorm.DB, id uint) (models.User, error) {
+	cache, _ := db.Config.Plugins[userCacheName].(*UserCache)
+	if cache != nil {
+		if user, ok := cache.Get(id); ok {
+			return user, nil
+		}
+	}
+
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		return models.User{}, err
+	}
+	if cache != nil {
+		cache.Set(user)
+	}
+	return user, nil
+}
+
+// Name implements gorm.Plugin.
+func (c *UserCache) Name() string {
+	return userCacheName
+}
+
+// Initialize implements gorm.Plugin by registering the invalidation callbacks.
+func (c *UserCache) Initialize(db *gorm.DB) error {
+	invalidate := func(tx *gorm.DB) {
+		if tx.Statement.Table == "" || tx.Statement.Table == (models.User{}).TableName() {
+			c.Clear()
+			events.After(tx.Statement.Context, c.Clear)
+		}
+	}
+
+	cb := db.Callback()
+	if err := cb.Update().After("gorm:update").Register("auth:user_cache_update", invalidate); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("auth:user_cache_delete", invalidate); err != nil {
+		return err
+	}
+	// Raw statements cannot be attributed to a table; their Table is always empty.
+	return cb.Raw().After("gorm:raw").Register("auth:user_cache_raw", invalidate)
+}
+
+// Get returns the cached user with id if it has not expired.
+func (c *UserCache) Get(id uint) (models.User, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[id]
+	c.mu.RUnlock()
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return models.User{}, false
+	}
+	return entry.user, true
+}
+
+// Set caches user for the cache TTL. When the cache is full an arbitrary entry is evicted.
+func (c *UserCache) Set(user models.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[user.ID]; !exists && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		for id := range c.entries {
+			delete(c.entries, id)
+			break
+		}
+	}
+	c.entries[user.ID] = userCacheEntry{user: user, expiresAt: c.now().Add(c.ttl)}
+}
+
+// Invalidate removes the user with id from the cache.
+func (c *UserCache) Invalidate(id uint) {
+	c.mu.Lock()
+	delete(c.entries, id)
+	c.mu.Unlock()
+}
+
+// Clear removes every cached user.
+func (c *UserCache) Clear() {
+	c.mu.Lock()
+	clear(c.entries)
+	c.mu.Unlock()
+}
+
+// Len returns the number of cached users, including expired ones not yet replaced.
+func (c *UserCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
@@ -0,0 +1,91 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestUserCache_InvalidatedOnWrites(t *testing.T) {
+	db := testutil.NewDB(t)
+	cache := auth.NewUserCache(time.Minute, 10)
+	if err := auth.UseUserCache(db, cache); err != nil {
+		t.Fatal(err)
+	}
+	user := testutil.CreateUser(t, db)
+
+	if _, err := auth.LoadUser(db, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(user.ID); !ok {
+		t.Fatal("expected the user to be cached after loading it")
+	}
+
+	if err := db.Model(&models.User{}).Where("id = ?", user.ID).Update("role", models.RoleAdmin).Error; err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := auth.LoadUser(db, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Role != models.RoleAdmin {
+		t.Fatalf("expected the update to invalidate the cache, got role %q", loaded.Role)
+	}
+
+	if err := db.Delete(&models.User{}, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.LoadUser(db, user.ID); err == nil {
+		t.Fatal("expected a deleted user not to be served from the cache")
+	}
+}
+
+func TestUserCache_InvalidatedOnCommit(t *testing.T) {
+	db := testutil.NewDB(t)
+	cache := auth.NewUserCache(time.Minute, 10)
+	if err := auth.UseUserCache(db, cache); err != nil {
+		t.Fatal(err)
+	}
+	user := testutil.CreateUser(t, db)
+
+	err := database.WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("role", models.RoleAdmin).Error; err != nil {
+			return err
+		}
+		// A concurrent request caches the row as committed before the update
+		cache.Set(*user)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(user.ID); ok {
+		t.Fatal("expected the commit to clear users cached during the transaction")
+	}
+}
+
+func TestUserCache_ExpiryAndEviction(t *testing.T) {
+	cache := auth.NewUserCache(time.Nanosecond, 1)
+	cache.Set(models.User{ID: 1})
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.Get(1); ok {
+		t.Fatal("expected the entry to expire")
+	}
+
+	cache = auth.NewUserCache(time.Minute, 1)
+	cache.Set(models.User{ID: 1})
+	cache.Set(models.User{ID: 2})
+	if cache.Len() != 1 {
+		t.Fatalf("expected at most 1 entry, got %d", cache.Len())
+	}
+	if _, ok := cache.Get(2); !ok {
+		t.Fatal("expected the latest entry to be cached")
+	}
+}
@@ -0,0 +1,245 @@
+// Package backup takes database backups, streaming them compressed to a storage.Storage,
+// restores them, and prunes the ones past the retention policy.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+	"github.com/yeferson59/gin-template/pkg/storage"
+)
+
+// ErrNoBackups is returned by Latest when there is no backup.
+var ErrNoBackups = errors.New("no backups found")
+
+// timeFormat names backups by when they were taken, so that their keys sort chronologically.
+const timeFormat = "20060102T150405Z"
+
+var (
+	backupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_backups_total",
+		Help: "Database backups taken, by result (success or failure).",
+	}, []string{"result"})
+	lastBackup = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_backup_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful database backup.",
+	})
+	lastBackupSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_backup_last_size_bytes",
+		Help: "Compressed size of the last successful database backup.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(backupsTotal, lastBackup, lastBackupSize)
+}
+
+// Retention bounds the backups kept. Backups beyond the Count newest, or older than MaxAge, are
+// deleted; zero disables each bound. The newest backup is always kept.
+type Retention struct {
+	Count  int
+	MaxAge time.Duration
+}
+
+// Service takes and restores the backups of one database.
+type Service struct {
+	dumper    Dumper
+	store     storage.Storage
+	prefix    string
+	retention Retention
+	now       func() time.Time
+}
+
+// NewService creates a service keeping the backups of dumper's database in store, under keys
+// starting with prefix.
+func NewService(dumper Dumper, store storage.Storage, prefix string, retention Retention) *Service {
+	return &Service{dumper: dumper, store: store, prefix: prefix, retention: retention, now: time.Now}
+}
+
+// Backup dumps the database and streams it, gzip-compressed, to the storage as it is written,
+// under a key such as "<prefix>20261015T120000Z.sqlite.gz".
+func (s *Service) Backup(ctx context.Context) (storage.Object, error) {
+	object, err := s.backup(ctx)
+	if err != nil {
+		backupsTotal.WithLabelValues("failure").Inc()
+		return storage.Object{}, err
+	}
+	backupsTotal.WithLabelValues("success").Inc()
+	lastBackup.Set(float64(object.LastModified.Unix()))
+	lastBackupSize.Set(float64(object.Size))
+	return object, nil
+}
+
+func (s *Service) backup(ctx context.Context) (storage.Object, error) {
+	now := s.now().UTC()
+	object := storage.Object{
+		Key:          s.prefix + now.Format(timeFormat) + "." + s.dumper.Format() + ".gz",
+		LastModified: now,
+	}
+
+	pr, pw := io.Pipe()
+	dumped := make(chan error, 1)
+	go func() {
+		gz := gzip.NewWriter(pw)
+		err := s.dumper.Dump(ctx, gz)
+		if err == nil {
+			err = gz.Close()
+		}
+		_ = pw.CloseWithError(err)
+		dumped <- err
+	}()
+
+	counter := &countingReader{r: pr}
+	putErr := s.store.Put(ctx, object.Key, counter)
+	// Unblocks the dump if the upload stopped reading
+	_ = pr.CloseWithError(errors.New("upload ended"))
+	dumpErr := <-dumped
+
+	// A failed dump fails the upload with its own error
+	switch {
+	case putErr != nil && !errors.Is(putErr, dumpErr):
+		return storage.Object{}, fmt.Errorf("failed to store the backup: %w", putErr)
+	case dumpErr != nil:
+		return storage.Object{}, fmt.Errorf("failed to dump the database: %w", dumpErr)
+	}
+	object.Size = counter.n
+	return object, nil
+}
+
+// List returns the backups, oldest first.
+func (s *Service) List(ctx context.Context) ([]storage.Object, error) {
+	objects, err := s.store.List(ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	backups := objects[:0]
+	for _, o := range objects {
+		if _, ok := s.takenAt(o.Key); ok {
+			backups = append(backups, o)
+		}
+	}
+	slices.SortFunc(backups, func(a, b storage.Object) int { return strings.Compare(a.Key, b.Key) })
+	return backups, nil
+}
+
+// Latest returns the newest backup, or ErrNoBackups.
+func (s *Service) Latest(ctx context.Context) (storage.Object, error) {
+	backups, err := s.List(ctx)
+	if err != nil {
+		return storage.Object{}, err
+	}
+	if len(backups) == 0 {
+		return storage.Object{}, ErrNoBackups
+	}
+	return backups[len(backups)-1], nil
+}
+
+// Restore replaces the contents of the database with the backup stored under key.
+func (s *Service) Restore(ctx context.Context, key string) error {
+	if !strings.HasSuffix(key, "."+s.dumper.Format()+".gz") {
+		return fmt.Errorf("%s is not a %s backup", key, s.dumper.Format())
+	}
+	r, err := s.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to read the backup: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+	return s.dumper.Restore(ctx, gz)
+}
+
+// Prune deletes the backups past the retention policy and returns how many it deleted.
+func (s *Service) Prune(ctx context.Context) (int, error) {
+	backups, err := s.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	now := s.now()
+	// Newest first, never deleting the newest
+	for i := len(backups) - 2; i >= 0; i-- {
+		kept := len(backups) - 1 - i
+		takenAt, _ := s.takenAt(backups[i].Key)
+		tooMany := s.retention.Count > 0 && kept >= s.retention.Count
+		tooOld := s.retention.MaxAge > 0 && now.Sub(takenAt) > s.retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := s.store.Delete(ctx, backups[i].Key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Run takes a backup and prunes old ones every interval until ctx is canceled.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			object, err := s.Backup(ctx)
+			if err != nil {
+				logger.WithField("error", err.Error()).Error("Failed to back up the database")
+				continue
+			}
+			logger.WithFields(map[string]interface{}{"key": object.Key, "size": object.Size}).
+				Info("Database backup completed")
+
+			pruned, err := s.Prune(ctx)
+			if err != nil {
+				logger.WithField("error", err.Error()).Error("Failed to prune old database backups")
+				continue
+			}
+			if pruned > 0 {
+				logger.WithField("pruned", pruned).Info("Pruned old database backups")
+			}
+		}
+	}
+}
+
+// takenAt parses the time a backup of this service's format was taken from its key.
+func (s *Service) takenAt(key string) (time.Time, bool) {
+	name, ok := strings.CutPrefix(key, s.prefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	stamp, ok := strings.CutSuffix(name, "."+s.dumper.Format()+".gz")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(timeFormat, stamp)
+	return t, err == nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
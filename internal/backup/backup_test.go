@@ -0,0 +1,214 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/storage"
+)
+
+func openSQLite(t *testing.T, path string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return db
+}
+
+func countUsers(t *testing.T, path string) int64 {
+	t.Helper()
+	var count int64
+	if err := openSQLite(t, path).Model(&models.User{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestSQLiteBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.db")
+	db := openSQLite(t, path)
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.User{Username: "alice", Email: "alice@example.com", Password: "hash"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dumper, err := NewDumper("sqlite", "file:"+path+"?_busy_timeout=5000", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewService(dumper, storage.NewLocal(filepath.Join(dir, "backups")), "db/", Retention{})
+	object, err := service.Backup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(object.Key, "db/") || !strings.HasSuffix(object.Key, ".sqlite.gz") || object.Size == 0 {
+		t.Fatalf("unexpected backup %+v", object)
+	}
+
+	// Changes made after the backup are undone by the restore
+	if err := db.Create(&models.User{Username: "bob", Email: "bob@example.com", Password: "hash"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	latest, err := service.Latest(context.Background())
+	if err != nil || latest.Key != object.Key {
+		t.Fatalf("Latest() = %+v, %v", latest, err)
+	}
+	if err := service.Restore(context.Background(), latest.Key); err != nil {
+		t.Fatal(err)
+	}
+	if got := countUsers(t, path); got != 1 {
+		t.Fatalf("expected the restored database to have 1 user, got %d", got)
+	}
+}
+
+// fakeDumper dumps a fixed string, or fails with err.
+type fakeDumper struct {
+	data     string
+	err      error
+	restored string
+}
+
+func (d *fakeDumper) Format() string { return "fake" }
+
+func (d *fakeDumper) Dump(_ context.Context, w io.Writer) error {
+	if _, err := io.WriteString(w, d.data); err != nil {
+		return err
+	}
+	return d.err
+}
+
+func (d *fakeDumper) Restore(_ context.Context, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	d.restored = string(data)
+	return err
+}
+
+func TestBackupFailures(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	errDump := errors.New("disk full")
+
+	service := NewService(&fakeDumper{data: "partial", err: errDump}, store, "", Retention{})
+	if _, err := service.Backup(ctx); !errors.Is(err, errDump) || !strings.Contains(err.Error(), "dump") {
+		t.Fatalf("expected the dump error, got %v", err)
+	}
+	if backups, _ := service.List(ctx); len(backups) != 0 {
+		t.Fatalf("expected a failed dump to store nothing, got %+v", backups)
+	}
+
+	if err := service.Restore(ctx, "20260101T000000Z.sqlite.gz"); err == nil {
+		t.Fatal("expected a backup of another format to be rejected")
+	}
+	if err := service.Restore(ctx, "20260101T000000Z.fake.gz"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := service.Latest(ctx); !errors.Is(err, ErrNoBackups) {
+		t.Fatalf("expected ErrNoBackups, got %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	dumper := &fakeDumper{data: "dump"}
+	service := NewService(dumper, store, "", Retention{Count: 3, MaxAge: 72 * time.Hour})
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for day := range 5 {
+		service.now = func() time.Time { return start.Add(time.Duration(day) * 24 * time.Hour) }
+		if _, err := service.Backup(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Other files under the prefix are left alone
+	if err := store.Put(ctx, "notes.txt", strings.NewReader("keep")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Days 0 and 1 are beyond the 3 newest
+	pruned, err := service.Prune(ctx)
+	if err != nil || pruned != 2 {
+		t.Fatalf("Prune() = %d, %v, want 2", pruned, err)
+	}
+
+	// A week later only the newest remains, however old
+	service.now = func() time.Time { return start.Add(10 * 24 * time.Hour) }
+	if pruned, err := service.Prune(ctx); err != nil || pruned != 2 {
+		t.Fatalf("Prune() = %d, %v, want 2", pruned, err)
+	}
+	backups, _ := service.List(ctx)
+	if len(backups) != 1 || backups[0].Key != "20261005T000000Z.fake.gz" {
+		t.Fatalf("expected only the newest backup to remain, got %+v", backups)
+	}
+	if objects, _ := store.List(ctx, ""); len(objects) != 2 {
+		t.Fatalf("expected the other file to be kept, got %+v", objects)
+	}
+
+	if err := service.Restore(ctx, backups[0].Key); err != nil || dumper.restored != "dump" {
+		t.Fatalf("Restore() = %v, restored %q", err, dumper.restored)
+	}
+}
+
+func TestNewDumper(t *testing.T) {
+	pg, err := NewDumper("postgres", "postgres://app:s3cret@db:5432/app?sslmode=disable", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := pg.(*postgresDumper).dumpCommand()
+	if dump.name != "pg_dump" || !strings.Contains(strings.Join(dump.args, " "), "--dbname=postgres://app@db:5432/app?sslmode=disable") {
+		t.Errorf("unexpected pg_dump command %+v", dump)
+	}
+	if len(dump.env) != 1 || dump.env[0] != "PGPASSWORD=s3cret" {
+		t.Errorf("expected the password in the environment, got %v", dump.env)
+	}
+
+	pg, err = NewDumper("postgresql", "host=db user=app password='s3 cret' dbname=app", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := pg.(*postgresDumper); d.dsn != "host=db user=app dbname=app" || d.password != "s3 cret" {
+		t.Errorf("expected the password to be moved out of the DSN, got %q and %q", d.dsn, d.password)
+	}
+
+	my, err := NewDumper("mysql", "app:s3cret@tcp(db:3306)/app?parseTime=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := my.(*mysqlDumper).restoreCommand()
+	if got := strings.Join(restore.args, " "); got != "--user=app --host=db --port=3306 --protocol=TCP app" {
+		t.Errorf("unexpected mysql arguments %q", got)
+	}
+	if bytes.Contains([]byte(strings.Join(restore.args, " ")), []byte("s3cret")) || restore.env[0] != "MYSQL_PWD=s3cret" {
+		t.Errorf("expected the password only in the environment, got %+v", restore)
+	}
+
+	if _, err := NewDumper("mysql", "app@tcp(db:3306)/", nil); err == nil {
+		t.Error("expected a MySQL DSN without a database to be rejected")
+	}
+	if _, err := NewDumper("sqlserver", "", nil); err == nil {
+		t.Error("expected an unsupported driver to be rejected")
+	}
+}
@@ -0,0 +1,272 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Dumper writes and restores the contents of one database.
+type Dumper interface {
+	// Dump writes a snapshot of the database to w.
+	Dump(ctx context.Context, w io.Writer) error
+	// Restore replaces the contents of the database with a snapshot written by Dump.
+	Restore(ctx context.Context, r io.Reader) error
+	// Format names the snapshot format, used as the extension of backup files.
+	Format() string
+}
+
+// NewDumper creates the dumper of the database at dsn for driver ("sqlite", "postgres", or
+// "mysql"). SQLite snapshots are taken through db; PostgreSQL and MySQL ones by running
+// pg_dump/pg_restore and mysqldump/mysql, which must be on the PATH.
+func NewDumper(driver, dsn string, db *gorm.DB) (Dumper, error) {
+	switch strings.ToLower(driver) {
+	case "sqlite":
+		return &sqliteDumper{db: db, path: sqlitePath(dsn)}, nil
+	case "postgres", "postgresql":
+		return newPostgresDumper(dsn)
+	case "mysql":
+		return newMySQLDumper(dsn)
+	default:
+		return nil, fmt.Errorf("backups are not supported for database driver %q", driver)
+	}
+}
+
+// sqliteDumper copies the database file, snapshotted with VACUUM INTO so that writes made while
+// it runs are not half-copied. Encrypted (SQLCipher) databases stay encrypted with their key.
+type sqliteDumper struct {
+	db   *gorm.DB
+	path string
+}
+
+func (d *sqliteDumper) Format() string { return "sqlite" }
+
+func (d *sqliteDumper) Dump(ctx context.Context, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "sqlite-backup-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	snapshot := filepath.Join(dir, "snapshot.db")
+	if err := d.db.WithContext(ctx).Exec("VACUUM INTO ?", snapshot).Error; err != nil {
+		return fmt.Errorf("failed to snapshot the database: %w", err)
+	}
+	f, err := os.Open(snapshot)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Restore writes the snapshot next to the database file and renames it over the file, so that
+// an interrupted restore leaves the database untouched. The server must be stopped.
+func (d *sqliteDumper) Restore(_ context.Context, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(d.path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(d.path), ".restore-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// The write-ahead log of the replaced database must not be applied to the restored one
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(d.path + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(f.Name(), d.path)
+}
+
+// sqlitePath returns the file of a SQLite DSN such as "file:data/app.db?_fk=1".
+func sqlitePath(dsn string) string {
+	path, _, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	return path
+}
+
+// command is an external program run by a dumper.
+type command struct {
+	name string
+	args []string
+	env  []string
+}
+
+// run runs c with stdin and stdout, returning its standard error in the error on failure.
+func (c command) run(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
+	cmd := exec.CommandContext(ctx, c.name, c.args...)
+	cmd.Env = append(os.Environ(), c.env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &limitedWriter{w: &stderr, n: 4 << 10}
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %w: %s", c.name, err, msg)
+		}
+		return fmt.Errorf("%s failed: %w", c.name, err)
+	}
+	return nil
+}
+
+// limitedWriter keeps the first n bytes written to it.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.n > 0 {
+		keep := p
+		if len(keep) > l.n {
+			keep = keep[:l.n]
+		}
+		l.n -= len(keep)
+		_, _ = l.w.Write(keep)
+	}
+	return len(p), nil
+}
+
+// postgresDumper runs pg_dump in its custom format, restored with pg_restore. The password is
+// passed in PGPASSWORD rather than on the command line, where other users could see it.
+type postgresDumper struct {
+	dsn      string
+	password string
+}
+
+var passwordParam = regexp.MustCompile(`\s*\bpassword\s*=\s*('(\\.|[^'])*'|\S+)`)
+
+func newPostgresDumper(dsn string) (*postgresDumper, error) {
+	cfg, err := pgconn.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PostgreSQL DSN: %w", err)
+	}
+	d := &postgresDumper{dsn: dsn, password: cfg.Password}
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		if u.User != nil {
+			u.User = url.User(u.User.Username())
+		}
+		d.dsn = u.String()
+	} else {
+		d.dsn = strings.TrimSpace(passwordParam.ReplaceAllString(dsn, ""))
+	}
+	return d, nil
+}
+
+func (d *postgresDumper) Format() string { return "pgdump" }
+
+func (d *postgresDumper) env() []string {
+	if d.password == "" {
+		return nil
+	}
+	return []string{"PGPASSWORD=" + d.password}
+}
+
+func (d *postgresDumper) dumpCommand() command {
+	return command{
+		name: "pg_dump",
+		args: []string{"--format=custom", "--no-owner", "--no-privileges", "--dbname=" + d.dsn},
+		env:  d.env(),
+	}
+}
+
+func (d *postgresDumper) restoreCommand() command {
+	return command{
+		name: "pg_restore",
+		args: []string{"--clean", "--if-exists", "--no-owner", "--no-privileges", "--single-transaction",
+			"--exit-on-error", "--dbname=" + d.dsn},
+		env: d.env(),
+	}
+}
+
+func (d *postgresDumper) Dump(ctx context.Context, w io.Writer) error {
+	return d.dumpCommand().run(ctx, nil, w)
+}
+
+func (d *postgresDumper) Restore(ctx context.Context, r io.Reader) error {
+	return d.restoreCommand().run(ctx, r, io.Discard)
+}
+
+// mysqlDumper runs mysqldump in a single transaction, restored with the mysql client. The
+// password is passed in MYSQL_PWD rather than on the command line.
+type mysqlDumper struct {
+	cfg *mysql.Config
+}
+
+func newMySQLDumper(dsn string) (*mysqlDumper, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MySQL DSN: %w", err)
+	}
+	if cfg.DBName == "" {
+		return nil, errors.New("the MySQL DSN names no database")
+	}
+	return &mysqlDumper{cfg: cfg}, nil
+}
+
+func (d *mysqlDumper) Format() string { return "sql" }
+
+// connArgs returns the options connecting the mysql tools to the database.
+func (d *mysqlDumper) connArgs() []string {
+	args := []string{"--user=" + d.cfg.User}
+	if d.cfg.Net == "unix" {
+		return append(args, "--socket="+d.cfg.Addr)
+	}
+	host, port, err := net.SplitHostPort(d.cfg.Addr)
+	if err != nil {
+		return append(args, "--host="+d.cfg.Addr)
+	}
+	return append(args, "--host="+host, "--port="+port, "--protocol=TCP")
+}
+
+func (d *mysqlDumper) env() []string {
+	if d.cfg.Passwd == "" {
+		return nil
+	}
+	return []string{"MYSQL_PWD=" + d.cfg.Passwd}
+}
+
+func (d *mysqlDumper) dumpCommand() command {
+	args := append(d.connArgs(), "--single-transaction", "--quick", "--routines", "--triggers",
+		"--no-tablespaces", d.cfg.DBName)
+	return command{name: "mysqldump", args: args, env: d.env()}
+}
+
+func (d *mysqlDumper) restoreCommand() command {
+	return command{name: "mysql", args: append(d.connArgs(), d.cfg.DBName), env: d.env()}
+}
+
+func (d *mysqlDumper) Dump(ctx context.Context, w io.Writer) error {
+	return d.dumpCommand().run(ctx, nil, w)
+}
+
+func (d *mysqlDumper) Restore(ctx context.Context, r io.Reader) error {
+	return d.restoreCommand().run(ctx, r, io.Discard)
+}
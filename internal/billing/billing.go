@@ -0,0 +1,274 @@
+// Package billing integrates with Stripe: it creates a Stripe customer for new users and keeps
+// their subscriptions up to date from signed webhook events, so that routes can be gated by
+// plan (see middlewares.RequirePlan). Checkout and the customer portal are left to Stripe.
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/inbox"
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// PlanFree is the plan of users without an active subscription.
+const PlanFree = "free"
+
+// InboxProvider names Stripe in the inbox of processed webhook events.
+const InboxProvider = "stripe"
+
+// Subscription events kept in sync with models.Subscription.
+const (
+	EventSubscriptionCreated = "customer.subscription.created"
+	EventSubscriptionUpdated = "customer.subscription.updated"
+	EventSubscriptionDeleted = "customer.subscription.deleted"
+	EventSubscriptionPaused  = "customer.subscription.paused"
+	EventSubscriptionResumed = "customer.subscription.resumed"
+)
+
+// Options configures a Service.
+type Options struct {
+	// Stripe creates customers; nil disables creating them.
+	Stripe *Stripe
+	// Queue creates customers in the background, so that Stripe outages do not fail
+	// registrations.
+	Queue jobs.Queue
+	// WebhookSecret verifies webhook events, which may be WebhookTolerance old.
+	WebhookSecret    string
+	WebhookTolerance time.Duration
+	// PricePlans maps Stripe price IDs to plan names. Prices not listed use their lookup key.
+	PricePlans map[string]string
+}
+
+// Service creates customers and applies webhook events.
+type Service struct {
+	db    *gorm.DB
+	inbox *inbox.Inbox
+	opts  Options
+	now   func() time.Time
+}
+
+// NewService creates a billing service storing customers and subscriptions in db, and the IDs
+// of the webhook events it applied in db's inbox.
+func NewService(db *gorm.DB, opts Options) *Service {
+	return &Service{db: db, inbox: inbox.New(db), opts: opts, now: time.Now}
+}
+
+// ParsePricePlans parses price_id=plan pairs (STRIPE_PRICE_PLANS).
+func ParsePricePlans(pairs []string) (map[string]string, error) {
+	plans := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		price, plan, ok := strings.Cut(pair, "=")
+		price, plan = strings.TrimSpace(price), strings.TrimSpace(plan)
+		if !ok || price == "" || plan == "" {
+			return nil, fmt.Errorf("invalid price plan %q (want price_id=plan)", pair)
+		}
+		plans[price] = plan
+	}
+	return plans, nil
+}
+
+// CreateCustomer creates the Stripe customer of a new user in the background. Failures are
+// logged; the user can still subscribe, since Checkout creates a customer when none exists.
+func (s *Service) CreateCustomer(user models.User) {
+	if s.opts.Stripe == nil {
+		return
+	}
+	err := s.opts.Queue.Enqueue(jobs.Job{
+		ID:   "user-" + strconv.FormatUint(uint64(user.ID), 10),
+		Name: "billing.customer",
+		Run: func(ctx context.Context) error {
+			return s.createCustomer(ctx, user)
+		},
+	})
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"user_id": user.ID,
+			"error":   err.Error(),
+		}).Warn("Failed to enqueue Stripe customer creation")
+	}
+}
+
+func (s *Service) createCustomer(ctx context.Context, user models.User) error {
+	var existing int64
+	if err := s.db.WithContext(ctx).Model(&models.BillingCustomer{}).Where("user_id = ?", user.ID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+	customerID, err := s.opts.Stripe.CreateCustomer(ctx, user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.BillingCustomer{UserID: user.ID, CustomerID: customerID}).Error
+}
+
+// HandleWebhook verifies the signature of a webhook payload and applies the event, once: a
+// delivery of an event already applied returns inbox.ErrDuplicate. It returns
+// ErrInvalidSignature, ErrSignatureExpired or ErrMalformedEvent for events that must be
+// rejected, and other errors when the event should be retried. Events of other types are
+// ignored.
+func (s *Service) HandleWebhook(ctx context.Context, payload []byte, signature string) (*Event, error) {
+	if s.opts.WebhookSecret == "" {
+		return nil, ErrInvalidSignature
+	}
+	if err := VerifySignature(payload, signature, s.opts.WebhookSecret, s.opts.WebhookTolerance, s.now()); err != nil {
+		return nil, err
+	}
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedEvent, err)
+	}
+
+	switch event.Type {
+	case EventSubscriptionCreated, EventSubscriptionUpdated, EventSubscriptionDeleted,
+		EventSubscriptionPaused, EventSubscriptionResumed:
+		if event.ID == "" {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedEvent, inbox.ErrMissingEventID)
+		}
+		return &event, s.inbox.Process(ctx, InboxProvider, event.ID, event.Type, func(tx *gorm.DB) error {
+			return s.applySubscription(ctx, tx, &event)
+		})
+	}
+	return &event, nil
+}
+
+// stripeSubscription is the part of a Stripe subscription object the service uses. Newer API
+// versions moved current_period_end to the subscription items.
+type stripeSubscription struct {
+	ID                string            `json:"id"`
+	Customer          string            `json:"customer"`
+	Status            string            `json:"status"`
+	CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+	CurrentPeriodEnd  int64             `json:"current_period_end"`
+	Metadata          map[string]string `json:"metadata"`
+	Items             struct {
+		Data []struct {
+			CurrentPeriodEnd int64 `json:"current_period_end"`
+			Price            struct {
+				ID        string `json:"id"`
+				LookupKey string `json:"lookup_key"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// applySubscription stores the subscription of a subscription event through db, unless a newer
+// event was already applied: Stripe does not deliver events in order.
+func (s *Service) applySubscription(ctx context.Context, db *gorm.DB, event *Event) error {
+	var sub stripeSubscription
+	if err := json.Unmarshal(event.Data.Object, &sub); err != nil || sub.ID == "" {
+		return fmt.Errorf("%w: not a subscription", ErrMalformedEvent)
+	}
+	userID, err := s.userID(ctx, db, &sub)
+	if err != nil {
+		return err
+	}
+	if userID == 0 {
+		logger.WithFields(map[string]interface{}{
+			"event_id":        event.ID,
+			"subscription_id": sub.ID,
+			"customer_id":     sub.Customer,
+		}).Warn("Ignoring Stripe subscription of an unknown customer")
+		return nil
+	}
+
+	record := models.Subscription{
+		UserID:            userID,
+		CustomerID:        sub.Customer,
+		SubscriptionID:    sub.ID,
+		Status:            sub.Status,
+		CancelAtPeriodEnd: sub.CancelAtPeriodEnd,
+		EventCreatedAt:    time.Unix(event.Created, 0).UTC(),
+	}
+	periodEnd := sub.CurrentPeriodEnd
+	if len(sub.Items.Data) > 0 {
+		item := sub.Items.Data[0]
+		record.PriceID = item.Price.ID
+		record.Plan = item.Price.LookupKey
+		if plan, ok := s.opts.PricePlans[item.Price.ID]; ok {
+			record.Plan = plan
+		}
+		if periodEnd == 0 {
+			periodEnd = item.CurrentPeriodEnd
+		}
+	}
+	if periodEnd > 0 {
+		end := time.Unix(periodEnd, 0).UTC()
+		record.CurrentPeriodEnd = &end
+	}
+
+	return database.WithTransaction(ctx, db, func(tx *gorm.DB) error {
+		var current models.Subscription
+		err := tx.Where("subscription_id = ?", sub.ID).First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(&record).Error
+		}
+		if err != nil {
+			return err
+		}
+		if current.EventCreatedAt.After(record.EventCreatedAt) {
+			return nil
+		}
+		record.ID = current.ID
+		record.CreatedAt = current.CreatedAt
+		return tx.Save(&record).Error
+	})
+}
+
+// userID returns the user of a subscription from its customer, or from the user_id metadata
+// of subscriptions created for customers the service did not create. It returns 0 when the
+// user is unknown.
+func (s *Service) userID(ctx context.Context, db *gorm.DB, sub *stripeSubscription) (uint, error) {
+	var customer models.BillingCustomer
+	err := db.WithContext(ctx).Where("customer_id = ?", sub.Customer).First(&customer).Error
+	if err == nil {
+		return customer.UserID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	id, err := strconv.ParseUint(sub.Metadata["user_id"], 10, 64)
+	if err != nil || id == 0 {
+		return 0, nil
+	}
+	var user models.User
+	if err := db.WithContext(ctx).Select("id").First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// CurrentPlan returns the plan of the newest active subscription in subs, or PlanFree.
+func CurrentPlan(subs []models.Subscription) string {
+	for _, sub := range subs {
+		if sub.Active() && sub.Plan != "" {
+			return sub.Plan
+		}
+	}
+	return PlanFree
+}
+
+// Subscriptions returns the subscriptions of userID, newest first.
+func (s *Service) Subscriptions(ctx context.Context, userID uint) ([]models.Subscription, error) {
+	subs := []models.Subscription{}
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&subs).Error
+	return subs, err
+}
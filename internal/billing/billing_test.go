@@ -0,0 +1,237 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/inbox"
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+const testSecret = "whsec_test"
+
+// eventSeq numbers the test events, which Stripe identifies by a unique ID.
+var eventSeq atomic.Int64
+
+func setupService(t *testing.T, opts Options) (*Service, *gorm.DB) {
+	t.Helper()
+	db := testutil.NewDB(t)
+	opts.WebhookSecret = testSecret
+	return NewService(db, opts), db
+}
+
+// subscriptionEvent builds a signed subscription event created at the given unix time.
+func subscriptionEvent(t *testing.T, eventType string, created int64, sub map[string]interface{}) ([]byte, string) {
+	t.Helper()
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":      fmt.Sprint("evt_", eventSeq.Add(1)),
+		"type":    eventType,
+		"created": created,
+		"data":    map[string]interface{}{"object": sub},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload, Sign(payload, testSecret, time.Now())
+}
+
+func proSubscription(status string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                   "sub_1",
+		"customer":             "cus_1",
+		"status":               status,
+		"cancel_at_period_end": false,
+		"items": map[string]interface{}{"data": []map[string]interface{}{{
+			"current_period_end": 1790000000,
+			"price":              map[string]interface{}{"id": "price_pro", "lookup_key": "pro_monthly"},
+		}}},
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1700000000, 0)
+	valid := Sign(payload, testSecret, now)
+
+	tests := []struct {
+		name   string
+		header string
+		now    time.Time
+		want   error
+	}{
+		{"valid", valid, now, nil},
+		{"several signatures", valid + ",v1=deadbeef,v0=ignored", now, nil},
+		{"other secret", Sign(payload, "other", now), now, ErrInvalidSignature},
+		{"no timestamp", "v1=abc", now, ErrInvalidSignature},
+		{"empty", "", now, ErrInvalidSignature},
+		{"replayed", valid, now.Add(10 * time.Minute), ErrSignatureExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifySignature(payload, tt.header, testSecret, 5*time.Minute, tt.now); !errors.Is(err, tt.want) {
+				t.Errorf("VerifySignature() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if err := VerifySignature([]byte(`{"id":"evt_2"}`), valid, testSecret, 5*time.Minute, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature() of a modified payload = %v", err)
+	}
+}
+
+func TestHandleWebhookAppliesSubscriptions(t *testing.T) {
+	svc, db := setupService(t, Options{PricePlans: map[string]string{"price_pro": "pro"}})
+	db.Create(&models.BillingCustomer{UserID: 7, CustomerID: "cus_1"})
+	ctx := context.Background()
+
+	payload, sig := subscriptionEvent(t, EventSubscriptionCreated, 100, proSubscription("active"))
+	if _, err := svc.HandleWebhook(ctx, payload, sig); err != nil {
+		t.Fatal(err)
+	}
+	subs, _ := svc.Subscriptions(ctx, 7)
+	if len(subs) != 1 || subs[0].Plan != "pro" || subs[0].CurrentPeriodEnd == nil || CurrentPlan(subs) != "pro" {
+		t.Fatalf("subscriptions = %+v", subs)
+	}
+
+	// The cancellation applies, and the older update that arrives after it does not
+	payload, sig = subscriptionEvent(t, EventSubscriptionDeleted, 300, proSubscription("canceled"))
+	if _, err := svc.HandleWebhook(ctx, payload, sig); err != nil {
+		t.Fatal(err)
+	}
+	payload, sig = subscriptionEvent(t, EventSubscriptionUpdated, 200, proSubscription("active"))
+	if _, err := svc.HandleWebhook(ctx, payload, sig); err != nil {
+		t.Fatal(err)
+	}
+	subs, _ = svc.Subscriptions(ctx, 7)
+	if len(subs) != 1 || subs[0].Status != "canceled" || CurrentPlan(subs) != PlanFree {
+		t.Fatalf("subscriptions = %+v, want it canceled", subs)
+	}
+
+	if _, err := svc.HandleWebhook(ctx, payload, Sign(payload, "forged", time.Now())); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("forged event: err = %v", err)
+	}
+}
+
+func TestHandleWebhookAppliesEventsOnce(t *testing.T) {
+	svc, db := setupService(t, Options{})
+	db.Create(&models.BillingCustomer{UserID: 7, CustomerID: "cus_1"})
+	ctx := context.Background()
+
+	// A delivery that fails is not recorded, so the retry applies the event
+	payload, sig := subscriptionEvent(t, EventSubscriptionCreated, 100, proSubscription("active"))
+	if err := db.Migrator().RenameTable(&models.Subscription{}, "subscriptions_moved"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.HandleWebhook(ctx, payload, sig); err == nil || errors.Is(err, inbox.ErrDuplicate) {
+		t.Fatalf("delivery without the subscriptions table: err = %v", err)
+	}
+	if err := db.Migrator().RenameTable("subscriptions_moved", &models.Subscription{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.HandleWebhook(ctx, payload, sig); err != nil {
+		t.Fatalf("retried delivery: %v", err)
+	}
+
+	// Redeliveries of the applied event are skipped
+	cancel, cancelSig := subscriptionEvent(t, EventSubscriptionDeleted, 300, proSubscription("canceled"))
+	if _, err := svc.HandleWebhook(ctx, cancel, cancelSig); err != nil {
+		t.Fatal(err)
+	}
+	event, err := svc.HandleWebhook(ctx, payload, sig)
+	if !errors.Is(err, inbox.ErrDuplicate) || event == nil {
+		t.Fatalf("redelivery: event = %v, err = %v, want inbox.ErrDuplicate", event, err)
+	}
+	subs, _ := svc.Subscriptions(ctx, 7)
+	if len(subs) != 1 || subs[0].Status != "canceled" {
+		t.Errorf("subscriptions = %+v, want the cancellation kept", subs)
+	}
+	var count int64
+	db.Model(&models.InboxMessage{}).Where("provider = ?", InboxProvider).Count(&count)
+	if count != 2 {
+		t.Errorf("%d events in the inbox, want 2", count)
+	}
+}
+
+func TestHandleWebhookResolvesUsers(t *testing.T) {
+	svc, db := setupService(t, Options{})
+	user := models.User{Username: "ana", Email: "ana@example.com", Password: "x"}
+	db.Create(&user)
+	ctx := context.Background()
+
+	// Unknown customers are acknowledged and ignored
+	payload, sig := subscriptionEvent(t, EventSubscriptionCreated, 100, proSubscription("active"))
+	if _, err := svc.HandleWebhook(ctx, payload, sig); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&models.Subscription{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("stored %d subscriptions of an unknown customer", count)
+	}
+
+	// Subscriptions may name the user in their metadata; unlisted prices use their lookup key
+	sub := proSubscription("trialing")
+	sub["metadata"] = map[string]string{"user_id": "1"}
+	payload, sig = subscriptionEvent(t, EventSubscriptionCreated, 100, sub)
+	if _, err := svc.HandleWebhook(ctx, payload, sig); err != nil {
+		t.Fatal(err)
+	}
+	subs, _ := svc.Subscriptions(ctx, user.ID)
+	if len(subs) != 1 || CurrentPlan(subs) != "pro_monthly" {
+		t.Fatalf("subscriptions = %+v", subs)
+	}
+}
+
+func TestCreateCustomer(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v1/customers" || r.Header.Get("Authorization") != "Bearer sk_test" ||
+			r.Header.Get("Idempotency-Key") != "customer-user-1" || r.FormValue("metadata[user_id]") != "1" {
+			http.Error(w, `{"error":{"message":"unexpected request"}}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"cus_new"}`))
+	}))
+	defer srv.Close()
+
+	queue := jobs.NewMemoryQueue(1, 4)
+	svc, db := setupService(t, Options{
+		Stripe: NewStripe("sk_test", srv.Client()).WithBaseURL(srv.URL),
+		Queue:  queue,
+	})
+	user := models.User{ID: 1, Email: "ana@example.com"}
+	svc.CreateCustomer(user)
+	svc.CreateCustomer(user)
+	if err := queue.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var customer models.BillingCustomer
+	if err := db.Where("user_id = ?", 1).First(&customer).Error; err != nil || customer.CustomerID != "cus_new" {
+		t.Fatalf("customer = %+v, err = %v", customer, err)
+	}
+	if requests != 1 {
+		t.Errorf("Stripe got %d requests, want 1 for an existing customer", requests)
+	}
+}
+
+func TestParsePricePlans(t *testing.T) {
+	plans, err := ParsePricePlans([]string{"price_1=pro", " price_2 = team "})
+	if err != nil || plans["price_1"] != "pro" || plans["price_2"] != "team" {
+		t.Errorf("ParsePricePlans() = %v, %v", plans, err)
+	}
+	if _, err := ParsePricePlans([]string{"price_1"}); err == nil {
+		t.Error("ParsePricePlans() accepted a pair without plan")
+	}
+}
@@ -0,0 +1,91 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultStripeURL is the base URL of the Stripe API.
+const DefaultStripeURL = "https://api.stripe.com"
+
+// maxStripeResponseSize bounds the Stripe API responses read.
+const maxStripeResponseSize = 1 << 20
+
+// Stripe calls the Stripe API with a secret key.
+type Stripe struct {
+	baseURL    string
+	secretKey  string
+	httpClient *http.Client
+}
+
+// NewStripe creates a Stripe API client authenticated with secretKey.
+func NewStripe(secretKey string, httpClient *http.Client) *Stripe {
+	return &Stripe{
+		baseURL:    DefaultStripeURL,
+		secretKey:  secretKey,
+		httpClient: httpClient,
+	}
+}
+
+// WithBaseURL overrides the API base URL, for tests and mocks.
+func (s *Stripe) WithBaseURL(baseURL string) *Stripe {
+	s.baseURL = strings.TrimSuffix(baseURL, "/")
+	return s
+}
+
+// CreateCustomer creates a customer for userID and returns its ID. The user ID is stored in
+// the customer metadata, and also keys the request so that retries create a single customer.
+func (s *Stripe) CreateCustomer(ctx context.Context, userID uint, email string) (string, error) {
+	id := strconv.FormatUint(uint64(userID), 10)
+	form := url.Values{}
+	form.Set("email", email)
+	form.Set("metadata[user_id]", id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/v1/customers", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Idempotency-Key", "customer-user-"+id)
+
+	var customer struct {
+		ID string `json:"id"`
+	}
+	if err := s.send(req, &customer); err != nil {
+		return "", fmt.Errorf("create Stripe customer: %w", err)
+	}
+	return customer.ID, nil
+}
+
+// send performs req and decodes a successful response into out.
+func (s *Stripe) send(req *http.Request, out interface{}) error {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxStripeResponseSize))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
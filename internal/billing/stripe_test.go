@@ -0,0 +1,40 @@
+package billing
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yeferson59/gin-template/pkg/testutil/vcr"
+)
+
+// stripeTestKey is the secret key of the cassette tests. Replays ignore it; recording them
+// again (vcr.RecordEnv) needs a test mode key in STRIPE_SECRET_KEY.
+func stripeTestKey() string {
+	if key := os.Getenv("STRIPE_SECRET_KEY"); key != "" {
+		return key
+	}
+	return "sk_test_replay"
+}
+
+func TestStripeCreateCustomer_Cassette(t *testing.T) {
+	stripe := NewStripe(stripeTestKey(), vcr.New(t, "stripe_create_customer"))
+
+	id, err := stripe.CreateCustomer(context.Background(), 1, "ana@example.com")
+	if err != nil {
+		t.Fatalf("CreateCustomer() error = %v", err)
+	}
+	if !strings.HasPrefix(id, "cus_") {
+		t.Errorf("CreateCustomer() = %q, want a customer ID", id)
+	}
+}
+
+func TestStripeCreateCustomer_CassetteError(t *testing.T) {
+	stripe := NewStripe(stripeTestKey(), vcr.New(t, "stripe_create_customer_invalid_email"))
+
+	_, err := stripe.CreateCustomer(context.Background(), 2, "ana@")
+	if err == nil || !strings.Contains(err.Error(), "status 400: Invalid email address") {
+		t.Fatalf("CreateCustomer() error = %v, want the message of Stripe", err)
+	}
+}
@@ -0,0 +1,161 @@
+// Package cleanup periodically deletes the records that expired or outlived their retention,
+// such as unused login challenges and old audit logs, in batches so that large tables are not
+// locked for long.
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+// DefaultBatchSize is the rows deleted per statement when no batch size is given.
+const DefaultBatchSize = 1000
+
+var (
+	rowsPurgedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cleanup_rows_purged_total",
+		Help: "Rows deleted by the cleanup service, by task.",
+	}, []string{"task"})
+	lastRowsPurged = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cleanup_last_run_rows_purged",
+		Help: "Rows deleted by the last cleanup run, by task.",
+	}, []string{"task"})
+	failuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cleanup_failures_total",
+		Help: "Cleanup tasks that failed, by task.",
+	}, []string{"task"})
+	lastRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "cleanup_last_run_timestamp_seconds",
+		Help: "Unix time the last cleanup run finished.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(rowsPurgedTotal, lastRowsPurged, failuresTotal, lastRun)
+}
+
+// Task deletes the rows of one table whose Column is older than Age.
+type Task struct {
+	// Name identifies the task in logs and metrics, e.g. "audit_logs".
+	Name string
+	// Model is the model of the table, e.g. &models.AuditLog{}. Its primary key must be "id".
+	Model any
+	// Column holds the time a row expires or was created, e.g. "expires_at".
+	Column string
+	// Age is how long rows are kept after that time.
+	Age time.Duration
+	// Where further restricts the deleted rows, e.g. "accepted_at IS NULL"; optional.
+	Where string
+}
+
+// Result is the outcome of a task in one run.
+type Result struct {
+	Task   string
+	Purged int64
+	Err    error
+}
+
+// Service runs the cleanup tasks.
+type Service struct {
+	db        *gorm.DB
+	tasks     []Task
+	batchSize int
+	now       func() time.Time
+}
+
+// NewService creates a service running tasks against db, deleting at most batchSize rows per
+// statement (DefaultBatchSize when not positive).
+func NewService(db *gorm.DB, tasks []Task, batchSize int) *Service {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &Service{db: db, tasks: tasks, batchSize: batchSize, now: time.Now}
+}
+
+// Tasks returns the tasks of the service.
+func (s *Service) Tasks() []Task {
+	return s.tasks
+}
+
+// RunOnce runs every task, even after one fails, and returns their results in order with the
+// errors of the failed ones.
+func (s *Service) RunOnce(ctx context.Context) ([]Result, error) {
+	now := s.now()
+	results := make([]Result, 0, len(s.tasks))
+	var errs []error
+	for _, task := range s.tasks {
+		purged, err := s.purge(ctx, task, now)
+		rowsPurgedTotal.WithLabelValues(task.Name).Add(float64(purged))
+		lastRowsPurged.WithLabelValues(task.Name).Set(float64(purged))
+		if err != nil {
+			failuresTotal.WithLabelValues(task.Name).Inc()
+			errs = append(errs, fmt.Errorf("%s: %w", task.Name, err))
+		}
+		results = append(results, Result{Task: task.Name, Purged: purged, Err: err})
+	}
+	lastRun.SetToCurrentTime()
+	return results, errors.Join(errs...)
+}
+
+// purge deletes the rows of task older than now minus its age, a batch at a time.
+func (s *Service) purge(ctx context.Context, task Task, now time.Time) (int64, error) {
+	cutoff := now.Add(-task.Age)
+	var purged int64
+	for {
+		query := s.db.WithContext(ctx).Unscoped().Model(task.Model).
+			Where(task.Column+" < ?", cutoff)
+		if task.Where != "" {
+			query = query.Where(task.Where)
+		}
+		var ids []any
+		if err := query.Order("id").Limit(s.batchSize).Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			return purged, nil
+		}
+		result := s.db.WithContext(ctx).Unscoped().Where("id IN ?", ids).Delete(task.Model)
+		purged += result.RowsAffected
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		if len(ids) < s.batchSize {
+			return purged, nil
+		}
+	}
+}
+
+// Run runs the tasks every interval until ctx is canceled.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			results, err := s.RunOnce(ctx)
+			if err != nil {
+				logger.WithField("error", err.Error()).Error("Failed to clean up expired records")
+			}
+			fields := make(map[string]interface{}, len(results))
+			var total int64
+			for _, r := range results {
+				fields[r.Task] = r.Purged
+				total += r.Purged
+			}
+			if total > 0 {
+				logger.WithFields(fields).Info("Purged expired records")
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestService_RunOnce(t *testing.T) {
+	db := testutil.NewDB(t)
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	for i := range 7 {
+		// Challenges 0-4 expired more than an hour ago
+		expires := now.Add(time.Duration(i-5)*time.Hour - time.Minute)
+		challenge := models.LoginChallenge{ID: fmt.Sprint("c", i), UserID: 1, CodeHash: "x", ExpiresAt: expires}
+		if err := db.Create(&challenge).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	accepted := now.Add(-72 * time.Hour)
+	invitations := []models.Invitation{
+		{OrganizationID: 1, Email: "a@example.com", Role: "member", TokenHash: "a", InvitedByID: 1, ExpiresAt: now.Add(-48 * time.Hour)},
+		{OrganizationID: 1, Email: "b@example.com", Role: "member", TokenHash: "b", InvitedByID: 1, ExpiresAt: now.Add(-48 * time.Hour), AcceptedAt: &accepted},
+		{OrganizationID: 1, Email: "c@example.com", Role: "member", TokenHash: "c", InvitedByID: 1, ExpiresAt: now.Add(time.Hour)},
+	}
+	if err := db.Create(&invitations).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewService(db, []Task{
+		{Name: "login_challenges", Model: &models.LoginChallenge{}, Column: "expires_at", Age: time.Hour},
+		{Name: "invitations", Model: &models.Invitation{}, Column: "expires_at", Age: time.Hour, Where: "accepted_at IS NULL"},
+	}, 2)
+	s.now = func() time.Time { return now }
+
+	before := prom.ToFloat64(rowsPurgedTotal.WithLabelValues("login_challenges"))
+	results, err := s.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	want := []Result{{Task: "login_challenges", Purged: 5}, {Task: "invitations", Purged: 1}}
+	if len(results) != len(want) || results[0] != want[0] || results[1] != want[1] {
+		t.Errorf("RunOnce() = %+v, want %+v", results, want)
+	}
+
+	var left int64
+	db.Model(&models.LoginChallenge{}).Where("expires_at < ?", now.Add(-time.Hour)).Count(&left)
+	if left != 0 {
+		t.Errorf("%d expired challenges left", left)
+	}
+	db.Model(&models.LoginChallenge{}).Count(&left)
+	if left != 2 {
+		t.Errorf("%d challenges left, want 2", left)
+	}
+	db.Model(&models.Invitation{}).Count(&left)
+	if left != 2 {
+		t.Errorf("%d invitations left, want the accepted and the pending ones", left)
+	}
+
+	if got := prom.ToFloat64(rowsPurgedTotal.WithLabelValues("login_challenges")) - before; got != 5 {
+		t.Errorf("cleanup_rows_purged_total grew by %v, want 5", got)
+	}
+	if got := prom.ToFloat64(lastRowsPurged.WithLabelValues("invitations")); got != 1 {
+		t.Errorf("cleanup_last_run_rows_purged = %v, want 1", got)
+	}
+
+	// A second run finds nothing left to delete
+	if results, err := s.RunOnce(context.Background()); err != nil || results[0].Purged != 0 || results[1].Purged != 0 {
+		t.Errorf("second RunOnce() = %+v, %v", results, err)
+	}
+}
+
+func TestService_RunOnceKeepsGoingAfterAFailure(t *testing.T) {
+	db := testutil.NewDB(t)
+	// The "missing" task fails on a table that does not exist
+	if err := db.Migrator().DropTable(&models.AuditLog{}); err != nil {
+		t.Fatal(err)
+	}
+	expired := models.LoginChallenge{ID: "c", UserID: 1, CodeHash: "x", ExpiresAt: time.Now().Add(-time.Hour)}
+	if err := db.Create(&expired).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewService(db, []Task{
+		{Name: "missing", Model: &models.AuditLog{}, Column: "created_at"},
+		{Name: "login_challenges", Model: &models.LoginChallenge{}, Column: "expires_at"},
+	}, 100)
+	before := prom.ToFloat64(failuresTotal.WithLabelValues("missing"))
+	results, err := s.RunOnce(context.Background())
+	if err == nil || results[0].Err == nil {
+		t.Fatal("RunOnce() ignored the failed task")
+	}
+	if results[1].Err != nil || results[1].Purged != 1 {
+		t.Errorf("task after the failure = %+v", results[1])
+	}
+	if got := prom.ToFloat64(failuresTotal.WithLabelValues("missing")) - before; got != 1 {
+		t.Errorf("cleanup_failures_total grew by %v, want 1", got)
+	}
+}
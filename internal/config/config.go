@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -12,11 +13,38 @@ import (
 
 // Config contains the global configuration for the application.
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	JWT      JWTConfig      `json:"jwt"`
-	Logging  LoggingConfig  `json:"logging"`
-	Security SecurityConfig `json:"security"`
+	Server     ServerConfig     `json:"server"`
+	Database   DatabaseConfig   `json:"database"`
+	JWT        JWTConfig        `json:"jwt"`
+	Logging    LoggingConfig    `json:"logging"`
+	Security   SecurityConfig   `json:"security"`
+	Password   PasswordConfig   `json:"password"`
+	Response   ResponseConfig   `json:"response"`
+	Jobs       JobsConfig       `json:"jobs"`
+	HTTPClient HTTPClientConfig `json:"http_client"`
+	Metrics    MetricsConfig    `json:"metrics"`
+	AdminUI    AdminUIConfig    `json:"admin_ui"`
+	Static     StaticConfig     `json:"static"`
+	Middleware MiddlewareConfig `json:"middleware"`
+	Cache      CacheConfig      `json:"cache"`
+	GeoIP      GeoIPConfig      `json:"geoip"`
+	Notify     NotifyConfig     `json:"notify"`
+	SAML       SAMLConfig       `json:"saml"`
+	DeviceAuth DeviceAuthConfig `json:"device_auth"`
+	Orgs       OrgsConfig       `json:"orgs"`
+	Terms      TermsConfig      `json:"terms"`
+	Account    AccountConfig    `json:"account"`
+	Metering   MeteringConfig   `json:"metering"`
+	Realtime   RealtimeConfig   `json:"realtime"`
+	Billing    BillingConfig    `json:"billing"`
+	Plans      PlansConfig      `json:"plans"`
+	Canary     CanaryConfig     `json:"canary"`
+	Faults     FaultsConfig     `json:"faults"`
+	Recording  RecordingConfig  `json:"recording"`
+	Mock       MockConfig       `json:"mock"`
+	Backup     BackupConfig     `json:"backup"`
+	Crash      CrashConfig      `json:"crash"`
+	Profiler   ProfilerConfig   `json:"profiler"`
 }
 
 // ServerConfig contains server-related configuration.
@@ -27,6 +55,44 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `json:"read_timeout"`
 	WriteTimeout time.Duration `json:"write_timeout"`
 	MaxBodySize  int64         `json:"max_body_size"`
+	// MaxConcurrentRequests sheds load with 503 beyond this many in-flight requests; 0 disables it.
+	MaxConcurrentRequests int `json:"max_concurrent_requests"`
+	// ReadOnly starts the API in read-only mode, rejecting mutating requests with 503; admins
+	// toggle it at runtime with PUT /api/admin/read-only.
+	ReadOnly bool `json:"read_only"`
+	// WatchdogHeapLimit (bytes) and WatchdogGoroutineLimit bound the heap and the goroutine
+	// count, checked every WatchdogInterval; 0 disables each. Above them the watchdog logs,
+	// forces a garbage collection and, with WatchdogShedLoad, sheds load with 503 until the
+	// process recovers.
+	WatchdogInterval       time.Duration `json:"watchdog_interval"`
+	WatchdogHeapLimit      int64         `json:"watchdog_heap_limit"`
+	WatchdogGoroutineLimit int           `json:"watchdog_goroutine_limit"`
+	WatchdogShedLoad       bool          `json:"watchdog_shed_load"`
+
+	// Profile names the Gin engine tuning (see app.Profile); empty picks the one of Environment.
+	Profile string `json:"profile"`
+	// TrustedPlatform is the platform in front of the server whose header holds the client IP:
+	// cloudflare, google-app-engine, fly, or a header name.
+	TrustedPlatform string `json:"trusted_platform"`
+
+	StartupCheckTimeout time.Duration `json:"startup_check_timeout"`
+	ShutdownDelay       time.Duration `json:"shutdown_delay"`
+	ShutdownTimeout     time.Duration `json:"shutdown_timeout"`
+
+	// ListenAddrs are the public API addresses (see pkg/listener); defaults to ":" + Port.
+	ListenAddrs []string `json:"listen_addrs"`
+	// InternalAddrs serve metrics and health checks only; when set, metrics leave the public API.
+	InternalAddrs []string `json:"internal_addrs"`
+	// UnixSocket is a unix domain socket path for the public API. When set without LISTEN_ADDRS,
+	// the API is served on the socket only.
+	UnixSocket     string      `json:"unix_socket"`
+	UnixSocketMode os.FileMode `json:"unix_socket_mode"`
+
+	// GracefulUpgrade makes SIGHUP start the current executable as a new process that takes
+	// over the listeners, after which this process drains and exits.
+	GracefulUpgrade bool          `json:"graceful_upgrade"`
+	UpgradeTimeout  time.Duration `json:"upgrade_timeout"`
+	PIDFile         string        `json:"pid_file"`
 }
 
 // DatabaseConfig contains database-related configuration.
@@ -36,6 +102,64 @@ type DatabaseConfig struct {
 	MaxOpenConns    int           `json:"max_open_conns"`
 	MaxIdleConns    int           `json:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `json:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time"`
+	AutoMigrate     bool          `json:"auto_migrate"`
+
+	// EncryptionKey encrypts SQLite database files with SQLCipher; see database.OpenSQLCipher.
+	EncryptionKey string `json:"encryption_key"`
+
+	// GORM performance options, see database.GormConfig.
+	PrepareStmt            bool `json:"prepare_stmt"`
+	SkipDefaultTransaction bool `json:"skip_default_transaction"`
+	CreateBatchSize        int  `json:"create_batch_size"`
+
+	// PoolStatsInterval is how often pool statistics are logged and, with PoolAutoTune,
+	// MaxOpenConns is adjusted (up to PoolMaxOpenConnsLimit while waits exceed PoolWaitThreshold).
+	PoolStatsInterval     time.Duration `json:"pool_stats_interval"`
+	PoolAutoTune          bool          `json:"pool_auto_tune"`
+	PoolMaxOpenConnsLimit int           `json:"pool_max_open_conns_limit"`
+	PoolWaitThreshold     time.Duration `json:"pool_wait_threshold"`
+
+	BreakerEnabled   bool          `json:"breaker_enabled"`
+	BreakerThreshold int           `json:"breaker_threshold"`
+	BreakerTimeout   time.Duration `json:"breaker_timeout"`
+
+	// Transactions that fail with a deadlock or serialization failure are run again up to
+	// TxMaxAttempts times in all, see database.WithTransaction.
+	TxMaxAttempts  int           `json:"tx_max_attempts"`
+	TxRetryBackoff time.Duration `json:"tx_retry_backoff"`
+	TxMaxBackoff   time.Duration `json:"tx_max_backoff"`
+
+	HealthCheckInterval time.Duration `json:"health_check_interval"`
+	DegradedMode        bool          `json:"degraded_mode"`
+	DegradedCacheTTL    time.Duration `json:"degraded_cache_ttl"`
+	DegradedCacheSize   int           `json:"degraded_cache_size"`
+
+	// QueryCacheEnabled serves repository reads from a cache until the tables they read are
+	// written to (see repository.QueryCache). QueryCacheBackend is "memory", holding at most
+	// QueryCacheSize entries per instance, or "redis" at QueryCacheRedisURL, shared by every
+	// instance.
+	QueryCacheEnabled  bool          `json:"query_cache_enabled"`
+	QueryCacheBackend  string        `json:"query_cache_backend"`
+	QueryCacheRedisURL string        `json:"query_cache_redis_url"`
+	QueryCacheTTL      time.Duration `json:"query_cache_ttl"`
+	QueryCacheSize     int           `json:"query_cache_size"`
+
+	// CountMode computes pagination totals: "exact", "cached" for up to CountCacheTTL, or
+	// "estimated" from the PostgreSQL planner when at least CountEstimateThreshold rows (see
+	// repository.Counter).
+	CountMode              string        `json:"count_mode"`
+	CountCacheTTL          time.Duration `json:"count_cache_ttl"`
+	CountEstimateThreshold int64         `json:"count_estimate_threshold"`
+
+	// AuditLogEnabled records the lifecycle events of the audited models (users, organizations,
+	// API keys...) in the audit_logs table (see hooks.Registry).
+	AuditLogEnabled bool `json:"audit_log_enabled"`
+	// AuditLogRetention is how long audit logs are kept by the cleanup service; 0 keeps them.
+	AuditLogRetention time.Duration `json:"audit_log_retention"`
+	// InboxRetention is how long the IDs of processed webhook events are kept to recognize
+	// redeliveries (see inbox.Inbox); 0 keeps them.
+	InboxRetention time.Duration `json:"inbox_retention"`
 }
 
 // JWTConfig contains JWT-related configuration.
@@ -44,21 +168,494 @@ type JWTConfig struct {
 	ExpirationTime time.Duration `json:"expiration_time"`
 	RefreshTime    time.Duration `json:"refresh_time"`
 	Issuer         string        `json:"issuer"`
+
+	// UserCacheTTL caches authenticated users in memory for this long; 0 queries the database
+	// on every request.
+	UserCacheTTL  time.Duration `json:"user_cache_ttl"`
+	UserCacheSize int           `json:"user_cache_size"`
 }
 
 // LoggingConfig contains logging-related configuration.
 type LoggingConfig struct {
 	Level  string `json:"level"`
 	Format string `json:"format"`
+
+	// RedactFields lists field names redacted in addition to logger.DefaultRedactFields;
+	// RedactEmails masks email addresses in every logged value.
+	RedactFields []string `json:"redact_fields"`
+	RedactEmails bool     `json:"redact_emails"`
 }
 
 // SecurityConfig contains security-related configuration.
 type SecurityConfig struct {
-	RateLimitRPS   float64 `json:"rate_limit_rps"`
-	RateLimitBurst int     `json:"rate_limit_burst"`
-	AuthRateLimit  int     `json:"auth_rate_limit"`
-	CORSEnabled    bool    `json:"cors_enabled"`
-	CORSOrigins    string  `json:"cors_origins"`
+	RateLimitEnabled bool    `json:"rate_limit_enabled"`
+	RateLimitRPS     float64 `json:"rate_limit_rps"`
+	RateLimitBurst   int     `json:"rate_limit_burst"`
+	AuthRateLimit    int     `json:"auth_rate_limit"`
+	CORSEnabled      bool    `json:"cors_enabled"`
+	CORSOrigins      string  `json:"cors_origins"`
+	TrustRequestID   bool    `json:"trust_request_id"`
+
+	BotDetectionEnabled bool          `json:"bot_detection_enabled"`
+	BotHoneypotField    string        `json:"bot_honeypot_field"`
+	BotBlockThreshold   int           `json:"bot_block_threshold"`
+	StepUpRiskThreshold int           `json:"step_up_risk_threshold"`
+	StepUpMaxAge        time.Duration `json:"step_up_max_age"`
+
+	PasswordBreachCheck   bool          `json:"password_breach_check"`
+	PasswordBreachTimeout time.Duration `json:"password_breach_timeout"`
+
+	// BcryptCost is the work factor of new password hashes; PasswordHashConcurrency bounds how
+	// many hashes are computed at once (0 means one per CPU).
+	BcryptCost              int `json:"bcrypt_cost"`
+	PasswordHashConcurrency int `json:"password_hash_concurrency"`
+
+	// EncryptionKeys is the keyring of the encrypted model field serializer, written as
+	// id:base64key pairs separated by commas; the first key encrypts new values.
+	EncryptionKeys string `json:"encryption_keys"`
+
+	// LoginAlertNewDevice and LoginAlertNewCountry notify users of logins from a device or a
+	// country they never logged in from. LoginVerification withholds the token of such logins
+	// until the user confirms a code sent by email: "off", "new_device", "new_country" or "any".
+	LoginAlertNewDevice  bool          `json:"login_alert_new_device"`
+	LoginAlertNewCountry bool          `json:"login_alert_new_country"`
+	LoginVerification    string        `json:"login_verification"`
+	LoginVerificationTTL time.Duration `json:"login_verification_ttl"`
+
+	// RegistrationInviteOnly closes open registration: new users need a registration code
+	// created by an admin or an organization owner. RegistrationCodeTTL is how long codes
+	// last when their creator sets no expiry.
+	RegistrationInviteOnly bool          `json:"registration_invite_only"`
+	RegistrationCodeTTL    time.Duration `json:"registration_code_ttl"`
+}
+
+// PasswordConfig contains the password complexity policy.
+type PasswordConfig struct {
+	MinLength        int      `json:"min_length"`
+	MaxLength        int      `json:"max_length"`
+	RequireUpper     bool     `json:"require_upper"`
+	RequireLower     bool     `json:"require_lower"`
+	RequireNumber    bool     `json:"require_number"`
+	RequireSpecial   bool     `json:"require_special"`
+	MaxRepeats       int      `json:"max_repeats"`
+	DictionaryWords  []string `json:"dictionary_words"`
+	DisallowUserInfo bool     `json:"disallow_user_info"`
+}
+
+// ResponseConfig contains API response rendering configuration.
+type ResponseConfig struct {
+	ErrorFormat      string `json:"error_format"`
+	ProblemTypeBase  string `json:"problem_type_base"`
+	IncludeRequestID bool   `json:"include_request_id"`
+	IncludeTimestamp bool   `json:"include_timestamp"`
+	APIVersion       string `json:"api_version"`
+	// LocalizeTimestamps writes the timestamps of responses in the time zone preference of the
+	// user, or as Unix epoch when the client sends X-Time-Format: unix or unix_ms.
+	LocalizeTimestamps bool `json:"localize_timestamps"`
+}
+
+// JobsConfig contains background job queue and async operation configuration.
+type JobsConfig struct {
+	Workers                  int           `json:"workers"`
+	QueueSize                int           `json:"queue_size"`
+	OperationRetention       time.Duration `json:"operation_retention"`
+	OperationCleanupInterval time.Duration `json:"operation_cleanup_interval"`
+
+	// Backend is "memory" (the default) or "database", which stores the jobs with a registered
+	// handler in the application's database so that they survive restarts (see
+	// jobs.DurableQueue). The settings below only apply to it.
+	Backend      string        `json:"backend"`
+	PollInterval time.Duration `json:"poll_interval"`
+	MaxAttempts  int           `json:"max_attempts"`
+	RetryBackoff time.Duration `json:"retry_backoff"`
+	Lease        time.Duration `json:"lease"`
+	// FailedRetention keeps jobs that failed their last attempt this long before the cleanup
+	// service deletes them; 0 keeps them.
+	FailedRetention time.Duration `json:"failed_retention"`
+
+	// CleanupInterval is how often expired records are deleted (see cleanup.Service); 0
+	// disables the cleanup service.
+	CleanupInterval time.Duration `json:"cleanup_interval"`
+	// CleanupGracePeriod keeps expired records this long before deleting them, so that late
+	// requests still get an "expired" error instead of "not found".
+	CleanupGracePeriod time.Duration `json:"cleanup_grace_period"`
+	// CleanupBatchSize bounds the rows deleted per statement; 0 uses cleanup.DefaultBatchSize.
+	CleanupBatchSize int `json:"cleanup_batch_size"`
+
+	// SagaResumeInterval is how often interrupted sagas are looked for and resumed (see
+	// saga.Runner); 0 disables resuming them.
+	SagaResumeInterval time.Duration `json:"saga_resume_interval"`
+	// SagaStaleAfter is how long an unfinished saga can go without progress before it is
+	// considered interrupted. It must be longer than the slowest saga step.
+	SagaStaleAfter time.Duration `json:"saga_stale_after"`
+	// SagaRetention keeps finished sagas this long before the cleanup service deletes them; 0
+	// keeps them.
+	SagaRetention time.Duration `json:"saga_retention"`
+}
+
+// HTTPClientConfig contains defaults for outbound HTTP clients built with pkg/httpclient.
+type HTTPClientConfig struct {
+	Timeout          time.Duration `json:"timeout"`
+	MaxRetries       int           `json:"max_retries"`
+	RetryBackoff     time.Duration `json:"retry_backoff"`
+	MaxBackoff       time.Duration `json:"max_backoff"`
+	BreakerThreshold int           `json:"breaker_threshold"`
+	BreakerTimeout   time.Duration `json:"breaker_timeout"`
+}
+
+// MetricsConfig contains Prometheus metrics endpoint configuration.
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"`
+	// Token is the bearer token scrapers must present. The public listener serves the metrics
+	// only when it is set; the internal listeners serve them regardless.
+	Token string `json:"token"`
+
+	// Service level objectives tracked per route: the fraction of requests answered without
+	// a 5xx error, and the fraction answered within SLOLatency. SLOLatencyOverrides sets other
+	// thresholds as "METHOD /path=duration" entries; SLOWindow is the number of recent
+	// requests per route the percentiles are computed from.
+	SLOAvailability     float64       `json:"slo_availability"`
+	SLOLatency          time.Duration `json:"slo_latency"`
+	SLOLatencyTarget    float64       `json:"slo_latency_target"`
+	SLOLatencyOverrides []string      `json:"slo_latency_overrides"`
+	SLOWindow           int           `json:"slo_window"`
+}
+
+// AdminUIConfig contains the embedded admin dashboard configuration.
+type AdminUIConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"`
+}
+
+// StaticConfig contains the static assets route.
+type StaticConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"`
+	// Dir serves a directory from disk; empty serves the files embedded in the binary.
+	Dir string `json:"dir"`
+}
+
+// MiddlewareConfig controls the global middleware pipeline assembled by app.Builder.
+type MiddlewareConfig struct {
+	// Disabled lists pipeline stages to leave out, and Order the stages that run first, in
+	// that order; see app.Builder for the stage names.
+	Disabled []string `json:"disabled"`
+	Order    []string `json:"order"`
+
+	CompressionEnabled bool `json:"compression_enabled"`
+	CompressionLevel   int  `json:"compression_level"`
+
+	// DedupRoutes lists the GET route templates whose simultaneous identical requests share one
+	// handler run (see middlewares.Deduplicate).
+	DedupRoutes []string `json:"dedup_routes"`
+
+	// OpenAPIValidation validates API requests against the OpenAPI specification generated
+	// from the route table (see middlewares.OpenAPIValidation); meant for development and staging.
+	OpenAPIValidation bool `json:"openapi_validation"`
+}
+
+// CacheConfig contains HTTP caching policies and the CDN purge integration.
+type CacheConfig struct {
+	// Policies set the Cache-Control header of successful GET responses per route group, as
+	// "/route/prefix=directives" entries such as
+	// "/api/errors=public max-age=3600 stale-while-revalidate=60" (see cachecontrol.ParsePolicy).
+	Policies           []string `json:"policies"`
+	SurrogateKeyHeader string   `json:"surrogate_key_header"`
+
+	// PurgeProvider enables POST /api/admin/cache/purge: "fastly" or "webhook". PurgeURL is
+	// the webhook URL, or overrides the Fastly API URL; PurgeSoft marks purged responses stale
+	// instead of removing them.
+	PurgeProvider  string `json:"purge_provider"`
+	PurgeURL       string `json:"purge_url"`
+	PurgeServiceID string `json:"purge_service_id"`
+	PurgeToken     string `json:"purge_token"`
+	PurgeSoft      bool   `json:"purge_soft"`
+}
+
+// GeoIPConfig contains the MaxMind databases and the geo rules of the route groups.
+type GeoIPConfig struct {
+	// DatabasePath is a GeoLite2/GeoIP2 Country or City database and ASNDatabasePath an ASN
+	// database, both .mmdb files; lookups are enabled when either is set.
+	DatabasePath    string `json:"database_path"`
+	ASNDatabasePath string `json:"asn_database_path"`
+
+	// BlockRules are "/route/prefix=allow:CO US" or "/route/prefix=deny:KP AS64500" entries and
+	// RateLimits "/route/prefix=country:RPS:BURST" or "/route/prefix=asn:RPS:BURST" entries
+	// (see pkg/geoip).
+	BlockRules []string `json:"block_rules"`
+	RateLimits []string `json:"rate_limits"`
+}
+
+// NotifyConfig contains the channels of user notifications, such as login alerts.
+type NotifyConfig struct {
+	// Channels are "inapp", "email" and "log"; "none" disables notifications.
+	Channels []string `json:"channels"`
+
+	// SMTP server of the email channel and of login verification codes.
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPPassword string `json:"smtp_password"`
+	SMTPFrom     string `json:"smtp_from"`
+
+	// EmailCapture keeps emails in memory, listed at /dev/emails, instead of sending them; for
+	// development, it is refused in production.
+	EmailCapture bool `json:"email_capture"`
+}
+
+// SAMLConfig contains single sign-on through a SAML 2.0 identity provider, such as Okta or
+// Azure AD.
+type SAMLConfig struct {
+	// BaseURL is the public URL of the SAML endpoints, such as
+	// https://api.example.com/api/auth/saml; SSO is enabled when it is set. The IdP metadata is
+	// read from IdPMetadataURL or IdPMetadataPath.
+	BaseURL         string `json:"base_url"`
+	IdPMetadataURL  string `json:"idp_metadata_url"`
+	IdPMetadataPath string `json:"idp_metadata_path"`
+	// AllowIdPInitiated accepts logins started from the IdP dashboard.
+	AllowIdPInitiated bool `json:"allow_idp_initiated"`
+
+	// JITProvisioning creates users on their first SSO login; otherwise only existing users
+	// may log in.
+	JITProvisioning bool `json:"jit_provisioning"`
+	// TrustedDomains are the email domains the IdP is authoritative for. An existing account
+	// is linked to an SSO identity with the same email only when its domain is listed, so that
+	// an IdP asserting any email cannot take over local accounts.
+	TrustedDomains []string `json:"trusted_domains"`
+	// EmailAttribute, UsernameAttribute and GroupsAttribute name the assertion attributes
+	// mapped to users; the NameID is the email when the attribute is missing. With
+	// AdminGroups set, members of those groups get the admin role and everyone else the user
+	// role whenever the assertion carries the groups; otherwise the local role is kept.
+	EmailAttribute    string   `json:"email_attribute"`
+	UsernameAttribute string   `json:"username_attribute"`
+	GroupsAttribute   string   `json:"groups_attribute"`
+	AdminGroups       []string `json:"admin_groups"`
+
+	// RedirectURL receives the token after login as RedirectURL#token=...; without it the ACS
+	// answers with the JSON login response.
+	RedirectURL string `json:"redirect_url"`
+}
+
+// DeviceAuthConfig contains the OAuth device authorization grant (RFC 8628), with which CLI
+// tools and other input-constrained clients obtain a token that the user approves in a browser.
+type DeviceAuthConfig struct {
+	Enabled bool `json:"enabled"`
+	// ClientIDs are the accepted client IDs; empty accepts any.
+	ClientIDs []string `json:"client_ids"`
+	// VerificationPath serves the page where users enter the code shown by the client.
+	// VerificationURL is its public URL shown to users; by default it is built from the request.
+	VerificationPath string `json:"verification_path"`
+	VerificationURL  string `json:"verification_url"`
+	// CodeTTL is how long a code may wait for approval and PollInterval the minimum time
+	// between polls of the token endpoint.
+	CodeTTL      time.Duration `json:"code_ttl"`
+	PollInterval time.Duration `json:"poll_interval"`
+}
+
+// OrgsConfig contains organization invitations. Invitations are emailed when SMTP is
+// configured (see NotifyConfig); otherwise the inviter receives the token to share it.
+type OrgsConfig struct {
+	InvitationTTL time.Duration `json:"invitation_ttl"`
+	// InvitationURL is the frontend page that accepts invitations, linked from the emails with
+	// the token as ?token=.
+	InvitationURL string `json:"invitation_url"`
+}
+
+// TermsConfig contains the terms of service users must accept. Changing Version asks every
+// user to accept the new terms before using the authenticated API again.
+type TermsConfig struct {
+	// Version of the current terms; empty disables consent tracking.
+	Version string `json:"version"`
+	// URL of the terms, shown to users who have not accepted them.
+	URL string `json:"url"`
+}
+
+// AccountConfig contains the limits on changes to user accounts.
+type AccountConfig struct {
+	// UsernameChangeCooldown is the minimum time between two username changes of a user, and
+	// UsernameReservation how long a former username stays reserved for its previous owner,
+	// so that nobody can impersonate them. Zero disables each.
+	UsernameChangeCooldown time.Duration `json:"username_change_cooldown"`
+	UsernameReservation    time.Duration `json:"username_reservation"`
+	// EmailChangeTTL is how long the new address of an email change can be confirmed, and
+	// EmailRevertTTL how long the previous address can revert the change.
+	EmailChangeTTL time.Duration `json:"email_change_ttl"`
+	EmailRevertTTL time.Duration `json:"email_revert_ttl"`
+	// EmailConfirmURL and EmailRevertURL are the frontend pages that confirm and revert email
+	// changes, linked from the emails with the token as ?token=.
+	EmailConfirmURL string `json:"email_confirm_url"`
+	EmailRevertURL  string `json:"email_revert_url"`
+}
+
+// MeteringConfig contains the usage metering of API keys and users.
+type MeteringConfig struct {
+	// Enabled records the requests and bytes of every authenticated request per API key or
+	// user and day, and enforces MonthlyQuota.
+	Enabled bool `json:"enabled"`
+	// MonthlyQuota is how many requests each API key may make per calendar month (UTC); 0
+	// leaves keys unlimited.
+	MonthlyQuota int64 `json:"monthly_quota"`
+	// FlushInterval is how often the usage counted in memory is written to the database.
+	FlushInterval time.Duration `json:"flush_interval"`
+}
+
+// RealtimeConfig contains the WebSocket hub with the presence of users in channels.
+type RealtimeConfig struct {
+	// Enabled serves the WebSocket hub at GET /api/realtime and the presence of its channels.
+	Enabled bool `json:"enabled"`
+	// PresenceTTL is how long a client stays online without sending a message; clients send
+	// heartbeats more often than that.
+	PresenceTTL time.Duration `json:"presence_ttl"`
+	// MaxConnections limits the WebSocket connections of each instance; 0 leaves them
+	// unlimited. Each connection also counts towards MaxConcurrentRequests while open.
+	MaxConnections int `json:"max_connections"`
+}
+
+// BillingConfig contains the Stripe integration.
+type BillingConfig struct {
+	// StripeSecretKey authenticates calls to the Stripe API; empty disables creating customers.
+	StripeSecretKey string `json:"stripe_secret_key"`
+	// StripeWebhookSecret verifies the signature of webhook events; empty disables the webhook.
+	StripeWebhookSecret string `json:"stripe_webhook_secret"`
+	// StripeAPIURL is the base URL of the Stripe API, overridden for tests and mocks.
+	StripeAPIURL string `json:"stripe_api_url"`
+	// WebhookTolerance is how old a signed webhook event may be, against replays.
+	WebhookTolerance time.Duration `json:"webhook_tolerance"`
+	// CreateCustomers creates a Stripe customer for every new user after registration.
+	CreateCustomers bool `json:"create_customers"`
+	// PricePlans maps Stripe price IDs to plan names, as price_id=plan pairs. Prices not listed
+	// use their lookup key as the plan.
+	PricePlans []string `json:"price_plans"`
+}
+
+// PlansConfig contains the plans whose entitlements limit what users can do.
+type PlansConfig struct {
+	// Enabled checks the entitlements of the user's plan, such as the maximum number of
+	// organizations, and serves the plan admin endpoints.
+	Enabled bool `json:"enabled"`
+	// DefaultPlan is the plan of users without an assigned plan or active subscription.
+	DefaultPlan string `json:"default_plan"`
+}
+
+// CanaryConfig contains the canary rollouts of rewritten endpoints.
+type CanaryConfig struct {
+	// Rollouts are the share of traffic of each canary, as name=percent pairs.
+	Rollouts []string `json:"rollouts"`
+	// Users are user IDs that always get every canary.
+	Users []string `json:"users"`
+	// Header is a request header that forces the canary ("always") or the stable
+	// implementation ("never"); empty ignores it.
+	Header string `json:"header"`
+}
+
+// FaultsConfig contains the faults injected to test resilience outside production.
+type FaultsConfig struct {
+	// Enabled injects faults; the server refuses to start with it in production.
+	Enabled bool `json:"enabled"`
+	// Rules are the faults of each route group, as /route/prefix=faults entries.
+	Rules []string `json:"rules"`
+	// Headers lets requests inject faults into themselves with the X-Fault-* headers.
+	Headers bool `json:"headers"`
+}
+
+// MockConfig contains the mock server answering unimplemented endpoints.
+type MockConfig struct {
+	// Enabled answers requests matching no route with the examples of Spec; the server refuses
+	// to start with it in production.
+	Enabled bool `json:"enabled"`
+	// Spec is the path of the OpenAPI specification (JSON or YAML).
+	Spec string `json:"spec"`
+}
+
+// RecordingConfig contains the recording of API traffic for `api replay`.
+type RecordingConfig struct {
+	// Enabled saves a sanitized copy of each request and response under Paths to Dir.
+	Enabled bool   `json:"enabled"`
+	Dir     string `json:"dir"`
+	// Paths are the path prefixes of the recorded requests.
+	Paths []string `json:"paths"`
+	// MaxBodyBytes bounds the body kept of each request and response; requests with larger
+	// bodies cannot be replayed.
+	MaxBodyBytes int `json:"max_body_bytes"`
+}
+
+// BackupConfig contains database backups, taken with the backup and restore commands and, when
+// Interval is set, by the server on a schedule.
+type BackupConfig struct {
+	// Storage is "local", keeping backups under Dir, or "s3", in S3Bucket; keys start with
+	// Prefix in both.
+	Storage string `json:"storage"`
+	Dir     string `json:"dir"`
+	Prefix  string `json:"prefix"`
+
+	// S3Endpoint is the URL of an S3-compatible service such as MinIO, addressed with
+	// S3PathStyle; empty uses AWS.
+	S3Bucket          string `json:"s3_bucket"`
+	S3Region          string `json:"s3_region"`
+	S3Endpoint        string `json:"s3_endpoint"`
+	S3PathStyle       bool   `json:"s3_path_style"`
+	S3AccessKeyID     string `json:"s3_access_key_id"`
+	S3SecretAccessKey string `json:"s3_secret_access_key"`
+
+	// Interval schedules a backup every interval; 0 disables scheduled backups.
+	Interval time.Duration `json:"interval"`
+	// RetentionCount keeps the newest backups and RetentionAge those younger than it; 0
+	// disables each limit.
+	RetentionCount int           `json:"retention_count"`
+	RetentionAge   time.Duration `json:"retention_age"`
+}
+
+// CrashConfig contains the crash reports saved when a handler panics, listed under
+// /api/admin/crash-reports.
+type CrashConfig struct {
+	// Enabled saves a report of each recovered panic, with its stack, the request and a dump of
+	// every goroutine.
+	Enabled bool `json:"enabled"`
+	// Storage is "local", keeping reports under Dir, or "s3", in the bucket of the backups
+	// (BACKUP_S3_*); keys start with Prefix in both.
+	Storage string `json:"storage"`
+	Dir     string `json:"dir"`
+	Prefix  string `json:"prefix"`
+	// MaxReports keeps the newest reports; 0 keeps them all.
+	MaxReports int `json:"max_reports"`
+}
+
+// ProfilerConfig contains the CPU and heap profiles captured when the latency or the error
+// rate of the API degrades.
+type ProfilerConfig struct {
+	Enabled bool `json:"enabled"`
+	// P99 and ErrorRate are the thresholds of a Window that trigger a capture, over at least
+	// MinRequests requests; 0 disables each.
+	P99         time.Duration `json:"p99"`
+	ErrorRate   float64       `json:"error_rate"`
+	MinRequests int           `json:"min_requests"`
+	Window      time.Duration `json:"window"`
+	// CPUDuration is how long the CPU is profiled; Cooldown the minimum time between captures.
+	CPUDuration time.Duration `json:"cpu_duration"`
+	Cooldown    time.Duration `json:"cooldown"`
+	// Storage is "local", keeping snapshots under Dir, or "s3", in the bucket of the backups
+	// (BACKUP_S3_*); keys start with Prefix in both. MaxSnapshots keeps the newest snapshots; 0
+	// keeps them all.
+	Storage      string `json:"storage"`
+	Dir          string `json:"dir"`
+	Prefix       string `json:"prefix"`
+	MaxSnapshots int    `json:"max_snapshots"`
+}
+
+// Enabled reports whether the Stripe webhook is configured.
+func (b BillingConfig) Enabled() bool {
+	return b.StripeWebhookSecret != ""
+}
+
+// Enabled reports whether SAML single sign-on is configured.
+func (s SAMLConfig) Enabled() bool {
+	return s.BaseURL != ""
+}
+
+// Enabled reports whether a GeoIP database is configured.
+func (g GeoIPConfig) Enabled() bool {
+	return g.DatabasePath != "" || g.ASNDatabasePath != ""
 }
 
 // Cfg is the loaded global configuration instance.
@@ -69,14 +666,44 @@ func LoadConfig() {
 	// Load .env if it exists
 	_ = godotenv.Load()
 
+	port := getEnv("PORT", "8080")
+	unixSocket := getEnv("UNIX_SOCKET_PATH", "")
+	defaultListenAddrs := []string{":" + port}
+	if unixSocket != "" {
+		defaultListenAddrs = nil
+	}
+
 	Cfg = &Config{
 		Server: ServerConfig{
 			AppName:      getEnv("APP_NAME", "GinAPI"),
-			Port:         getEnv("PORT", "8080"),
+			Port:         port,
 			Environment:  getEnv("APP_ENV", "development"),
 			ReadTimeout:  getDurationEnv("READ_TIMEOUT", 10*time.Second),
 			WriteTimeout: getDurationEnv("WRITE_TIMEOUT", 10*time.Second),
 			MaxBodySize:  getInt64Env("MAX_BODY_SIZE", 32<<20), // 32MB
+
+			MaxConcurrentRequests: getIntEnv("MAX_CONCURRENT_REQUESTS", 0),
+			ReadOnly:              getBoolEnv("READ_ONLY_MODE", false),
+			Profile:               getEnv("SERVER_PROFILE", ""),
+			TrustedPlatform:       getEnv("TRUSTED_PLATFORM", ""),
+
+			WatchdogInterval:       getDurationEnv("WATCHDOG_INTERVAL", 10*time.Second),
+			WatchdogHeapLimit:      getInt64Env("WATCHDOG_HEAP_LIMIT", 0),
+			WatchdogGoroutineLimit: getIntEnv("WATCHDOG_GOROUTINE_LIMIT", 0),
+			WatchdogShedLoad:       getBoolEnv("WATCHDOG_SHED_LOAD", true),
+
+			StartupCheckTimeout: getDurationEnv("STARTUP_CHECK_TIMEOUT", 5*time.Second),
+			ShutdownDelay:       getDurationEnv("SHUTDOWN_DELAY", 0),
+			ShutdownTimeout:     getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+			ListenAddrs:    getListEnv("LISTEN_ADDRS", defaultListenAddrs),
+			InternalAddrs:  getListEnv("INTERNAL_LISTEN_ADDRS", nil),
+			UnixSocket:     unixSocket,
+			UnixSocketMode: getFileModeEnv("UNIX_SOCKET_MODE", 0o660),
+
+			GracefulUpgrade: getBoolEnv("GRACEFUL_UPGRADE", false),
+			UpgradeTimeout:  getDurationEnv("UPGRADE_TIMEOUT", time.Minute),
+			PIDFile:         getEnv("PID_FILE", ""),
 		},
 		Database: DatabaseConfig{
 			Driver:          getEnv("DB_DRIVER", "sqlite"),
@@ -84,23 +711,316 @@ func LoadConfig() {
 			MaxOpenConns:    getIntEnv("DB_MAX_OPEN_CONNS", 25),
 			MaxIdleConns:    getIntEnv("DB_MAX_IDLE_CONNS", 5),
 			ConnMaxLifetime: getDurationEnv("DB_CONN_MAX_LIFETIME", time.Hour),
+			ConnMaxIdleTime: getDurationEnv("DB_CONN_MAX_IDLE_TIME", 0),
+			AutoMigrate:     getBoolEnv("DB_AUTO_MIGRATE", true),
+
+			EncryptionKey: getEnv("DB_ENCRYPTION_KEY", ""),
+
+			PrepareStmt:            getBoolEnv("DB_PREPARE_STMT", false),
+			SkipDefaultTransaction: getBoolEnv("DB_SKIP_DEFAULT_TRANSACTION", false),
+			CreateBatchSize:        getIntEnv("DB_CREATE_BATCH_SIZE", 0),
+
+			PoolStatsInterval:     getDurationEnv("DB_POOL_STATS_INTERVAL", time.Minute),
+			PoolAutoTune:          getBoolEnv("DB_POOL_AUTO_TUNE", false),
+			PoolMaxOpenConnsLimit: getIntEnv("DB_POOL_MAX_OPEN_CONNS_LIMIT", 100),
+			PoolWaitThreshold:     getDurationEnv("DB_POOL_WAIT_THRESHOLD", 10*time.Millisecond),
+
+			BreakerEnabled:   getBoolEnv("DB_BREAKER_ENABLED", true),
+			BreakerThreshold: getIntEnv("DB_BREAKER_THRESHOLD", 5),
+			BreakerTimeout:   getDurationEnv("DB_BREAKER_TIMEOUT", 10*time.Second),
+
+			TxMaxAttempts:  getIntEnv("DB_TX_MAX_ATTEMPTS", 3),
+			TxRetryBackoff: getDurationEnv("DB_TX_RETRY_BACKOFF", 20*time.Millisecond),
+			TxMaxBackoff:   getDurationEnv("DB_TX_MAX_BACKOFF", time.Second),
+
+			HealthCheckInterval: getDurationEnv("DB_HEALTH_CHECK_INTERVAL", 5*time.Second),
+			DegradedMode:        getBoolEnv("DEGRADED_MODE_ENABLED", true),
+			DegradedCacheTTL:    getDurationEnv("DEGRADED_CACHE_TTL", 10*time.Minute),
+			DegradedCacheSize:   getIntEnv("DEGRADED_CACHE_SIZE", 10000),
+
+			QueryCacheEnabled:  getBoolEnv("DB_QUERY_CACHE_ENABLED", false),
+			QueryCacheBackend:  getEnv("DB_QUERY_CACHE_BACKEND", "memory"),
+			QueryCacheRedisURL: getEnv("DB_QUERY_CACHE_REDIS_URL", ""),
+			QueryCacheTTL:      getDurationEnv("DB_QUERY_CACHE_TTL", time.Minute),
+			QueryCacheSize:     getIntEnv("DB_QUERY_CACHE_SIZE", 10000),
+
+			CountMode:              getEnv("DB_COUNT_MODE", "exact"),
+			CountCacheTTL:          getDurationEnv("DB_COUNT_CACHE_TTL", 30*time.Second),
+			CountEstimateThreshold: int64(getIntEnv("DB_COUNT_ESTIMATE_THRESHOLD", 100000)),
+
+			AuditLogEnabled:   getBoolEnv("AUDIT_LOG_ENABLED", true),
+			AuditLogRetention: getDurationEnv("AUDIT_LOG_RETENTION", 0),
+			InboxRetention:    getDurationEnv("WEBHOOK_INBOX_RETENTION", 30*24*time.Hour),
 		},
 		JWT: JWTConfig{
 			Secret:         getEnv("JWT_SECRET", "supersecretkey"),
 			ExpirationTime: getDurationEnv("JWT_EXP_MINUTES", 60*time.Minute),
 			RefreshTime:    getDurationEnv("JWT_REFRESH_MINUTES", 24*time.Hour),
 			Issuer:         getEnv("JWT_ISSUER", "gin-api"),
+
+			UserCacheTTL:  getDurationEnv("AUTH_USER_CACHE_TTL", 0),
+			UserCacheSize: getIntEnv("AUTH_USER_CACHE_SIZE", 10000),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "text"),
+
+			RedactFields: getListEnv("LOG_REDACT_FIELDS", nil),
+			RedactEmails: getBoolEnv("LOG_REDACT_EMAILS", true),
 		},
 		Security: SecurityConfig{
-			RateLimitRPS:   getFloat64Env("RATE_LIMIT_RPS", 10.0),
-			RateLimitBurst: getIntEnv("RATE_LIMIT_BURST", 20),
-			AuthRateLimit:  getIntEnv("AUTH_RATE_LIMIT", 5),
-			CORSEnabled:    getBoolEnv("CORS_ENABLED", true),
-			CORSOrigins:    getEnv("CORS_ORIGINS", "*"),
+			RateLimitEnabled: getBoolEnv("RATE_LIMIT_ENABLED", true),
+			RateLimitRPS:     getFloat64Env("RATE_LIMIT_RPS", 10.0),
+			RateLimitBurst:   getIntEnv("RATE_LIMIT_BURST", 20),
+			AuthRateLimit:    getIntEnv("AUTH_RATE_LIMIT", 5),
+			CORSEnabled:      getBoolEnv("CORS_ENABLED", true),
+			CORSOrigins:      getEnv("CORS_ORIGINS", "*"),
+			TrustRequestID:   getBoolEnv("TRUST_REQUEST_ID", true),
+
+			BotDetectionEnabled: getBoolEnv("BOT_DETECTION_ENABLED", true),
+			BotHoneypotField:    getEnv("BOT_HONEYPOT_FIELD", "website"),
+			BotBlockThreshold:   getIntEnv("BOT_BLOCK_THRESHOLD", 100),
+			StepUpRiskThreshold: getIntEnv("STEP_UP_RISK_THRESHOLD", 70),
+			StepUpMaxAge:        getDurationEnv("STEP_UP_MAX_AGE", 5*time.Minute),
+
+			PasswordBreachCheck:   getBoolEnv("PASSWORD_BREACH_CHECK", false),
+			PasswordBreachTimeout: getDurationEnv("PASSWORD_BREACH_TIMEOUT", 2*time.Second),
+
+			BcryptCost:              getIntEnv("BCRYPT_COST", 10),
+			PasswordHashConcurrency: getIntEnv("PASSWORD_HASH_CONCURRENCY", 0),
+
+			EncryptionKeys: getEnv("ENCRYPTION_KEYS", ""),
+
+			LoginAlertNewDevice:  getBoolEnv("LOGIN_ALERT_NEW_DEVICE", true),
+			LoginAlertNewCountry: getBoolEnv("LOGIN_ALERT_NEW_COUNTRY", true),
+			LoginVerification:    getEnv("LOGIN_VERIFICATION", "off"),
+			LoginVerificationTTL: getDurationEnv("LOGIN_VERIFICATION_TTL", 10*time.Minute),
+
+			RegistrationInviteOnly: getBoolEnv("REGISTRATION_INVITE_ONLY", false),
+			RegistrationCodeTTL:    getDurationEnv("REGISTRATION_CODE_TTL", 7*24*time.Hour),
+		},
+		Password: PasswordConfig{
+			MinLength:        getIntEnv("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:        getIntEnv("PASSWORD_MAX_LENGTH", 128),
+			RequireUpper:     getBoolEnv("PASSWORD_REQUIRE_UPPER", true),
+			RequireLower:     getBoolEnv("PASSWORD_REQUIRE_LOWER", true),
+			RequireNumber:    getBoolEnv("PASSWORD_REQUIRE_NUMBER", true),
+			RequireSpecial:   getBoolEnv("PASSWORD_REQUIRE_SPECIAL", true),
+			MaxRepeats:       getIntEnv("PASSWORD_MAX_REPEATS", 0),
+			DictionaryWords:  getListEnv("PASSWORD_DICTIONARY_WORDS", nil),
+			DisallowUserInfo: getBoolEnv("PASSWORD_DISALLOW_USER_INFO", true),
+		},
+		Response: ResponseConfig{
+			ErrorFormat:      getEnv("RESPONSE_ERROR_FORMAT", "envelope"),
+			ProblemTypeBase:  getEnv("PROBLEM_TYPE_BASE_URL", ""),
+			IncludeRequestID: getBoolEnv("RESPONSE_INCLUDE_REQUEST_ID", false),
+			IncludeTimestamp: getBoolEnv("RESPONSE_INCLUDE_TIMESTAMP", false),
+			APIVersion:       getEnv("API_VERSION", ""),
+
+			LocalizeTimestamps: getBoolEnv("RESPONSE_LOCALIZE_TIMESTAMPS", false),
+		},
+		Jobs: JobsConfig{
+			Workers:                  getIntEnv("JOBS_WORKERS", 4),
+			QueueSize:                getIntEnv("JOBS_QUEUE_SIZE", 100),
+			OperationRetention:       getDurationEnv("OPERATION_RETENTION", 24*time.Hour),
+			OperationCleanupInterval: getDurationEnv("OPERATION_CLEANUP_INTERVAL", time.Hour),
+
+			Backend:         getEnv("JOBS_BACKEND", "memory"),
+			PollInterval:    getDurationEnv("JOBS_POLL_INTERVAL", time.Second),
+			MaxAttempts:     getIntEnv("JOBS_MAX_ATTEMPTS", 5),
+			RetryBackoff:    getDurationEnv("JOBS_RETRY_BACKOFF", 10*time.Second),
+			Lease:           getDurationEnv("JOBS_LEASE", 5*time.Minute),
+			FailedRetention: getDurationEnv("JOBS_FAILED_RETENTION", 7*24*time.Hour),
+
+			CleanupInterval:    getDurationEnv("CLEANUP_INTERVAL", time.Hour),
+			CleanupGracePeriod: getDurationEnv("CLEANUP_GRACE_PERIOD", 24*time.Hour),
+			CleanupBatchSize:   getIntEnv("CLEANUP_BATCH_SIZE", 1000),
+
+			SagaResumeInterval: getDurationEnv("SAGA_RESUME_INTERVAL", time.Minute),
+			SagaStaleAfter:     getDurationEnv("SAGA_STALE_AFTER", 10*time.Minute),
+			SagaRetention:      getDurationEnv("SAGA_RETENTION", 7*24*time.Hour),
+		},
+		HTTPClient: HTTPClientConfig{
+			Timeout:          getDurationEnv("HTTP_CLIENT_TIMEOUT", 10*time.Second),
+			MaxRetries:       getIntEnv("HTTP_CLIENT_MAX_RETRIES", 2),
+			RetryBackoff:     getDurationEnv("HTTP_CLIENT_RETRY_BACKOFF", 100*time.Millisecond),
+			MaxBackoff:       getDurationEnv("HTTP_CLIENT_MAX_BACKOFF", 2*time.Second),
+			BreakerThreshold: getIntEnv("HTTP_CLIENT_BREAKER_THRESHOLD", 5),
+			BreakerTimeout:   getDurationEnv("HTTP_CLIENT_BREAKER_TIMEOUT", 30*time.Second),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getBoolEnv("METRICS_ENABLED", true),
+			Path:    getEnv("METRICS_PATH", "/metrics"),
+			Token:   getEnv("METRICS_TOKEN", ""),
+
+			SLOAvailability:     getFloat64Env("SLO_AVAILABILITY_TARGET", 0.999),
+			SLOLatency:          getDurationEnv("SLO_LATENCY_THRESHOLD", 500*time.Millisecond),
+			SLOLatencyTarget:    getFloat64Env("SLO_LATENCY_TARGET", 0.99),
+			SLOLatencyOverrides: getListEnv("SLO_LATENCY_OVERRIDES", nil),
+			SLOWindow:           getIntEnv("SLO_WINDOW", 1000),
+		},
+		AdminUI: AdminUIConfig{
+			Enabled: getBoolEnv("ADMIN_UI_ENABLED", false),
+			Path:    getEnv("ADMIN_UI_PATH", "/admin"),
+		},
+		Static: StaticConfig{
+			Enabled: getBoolEnv("STATIC_ENABLED", false),
+			Path:    getEnv("STATIC_PATH", "/static"),
+			Dir:     getEnv("STATIC_DIR", ""),
+		},
+		Middleware: MiddlewareConfig{
+			Disabled: getListEnv("MIDDLEWARE_DISABLED", nil),
+			Order:    getListEnv("MIDDLEWARE_ORDER", nil),
+
+			CompressionEnabled: getBoolEnv("COMPRESSION_ENABLED", false),
+			CompressionLevel:   getIntEnv("COMPRESSION_LEVEL", -1),
+
+			DedupRoutes: getListEnv("REQUEST_DEDUP_ROUTES", nil),
+
+			OpenAPIValidation: getBoolEnv("OPENAPI_VALIDATION", false),
+		},
+		Cache: CacheConfig{
+			Policies:           getListEnv("CACHE_POLICIES", nil),
+			SurrogateKeyHeader: getEnv("CACHE_SURROGATE_KEY_HEADER", "Surrogate-Key"),
+
+			PurgeProvider:  getEnv("CACHE_PURGE_PROVIDER", ""),
+			PurgeURL:       getEnv("CACHE_PURGE_URL", ""),
+			PurgeServiceID: getEnv("CACHE_PURGE_SERVICE_ID", ""),
+			PurgeToken:     getEnv("CACHE_PURGE_TOKEN", ""),
+			PurgeSoft:      getBoolEnv("CACHE_PURGE_SOFT", true),
+		},
+		GeoIP: GeoIPConfig{
+			DatabasePath:    getEnv("GEOIP_DB_PATH", ""),
+			ASNDatabasePath: getEnv("GEOIP_ASN_DB_PATH", ""),
+			BlockRules:      getListEnv("GEOIP_BLOCK_RULES", nil),
+			RateLimits:      getListEnv("GEOIP_RATE_LIMITS", nil),
+		},
+		Notify: NotifyConfig{
+			Channels: getListEnv("NOTIFY_CHANNELS", []string{"inapp"}),
+
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getIntEnv("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			SMTPFrom:     getEnv("SMTP_FROM", ""),
+			EmailCapture: getBoolEnv("EMAIL_CAPTURE", false),
+		},
+		SAML: SAMLConfig{
+			BaseURL:           getEnv("SAML_BASE_URL", ""),
+			IdPMetadataURL:    getEnv("SAML_IDP_METADATA_URL", ""),
+			IdPMetadataPath:   getEnv("SAML_IDP_METADATA_PATH", ""),
+			AllowIdPInitiated: getBoolEnv("SAML_ALLOW_IDP_INITIATED", false),
+
+			JITProvisioning:   getBoolEnv("SAML_JIT_PROVISIONING", true),
+			TrustedDomains:    getListEnv("SAML_TRUSTED_DOMAINS", nil),
+			EmailAttribute:    getEnv("SAML_ATTRIBUTE_EMAIL", "email"),
+			UsernameAttribute: getEnv("SAML_ATTRIBUTE_USERNAME", "username"),
+			GroupsAttribute:   getEnv("SAML_ATTRIBUTE_GROUPS", "groups"),
+			AdminGroups:       getListEnv("SAML_ADMIN_GROUPS", nil),
+
+			RedirectURL: getEnv("SAML_REDIRECT_URL", ""),
+		},
+		DeviceAuth: DeviceAuthConfig{
+			Enabled:          getBoolEnv("DEVICE_AUTH_ENABLED", false),
+			ClientIDs:        getListEnv("DEVICE_AUTH_CLIENT_IDS", nil),
+			VerificationPath: getEnv("DEVICE_AUTH_VERIFICATION_PATH", "/device"),
+			VerificationURL:  getEnv("DEVICE_AUTH_VERIFICATION_URL", ""),
+			CodeTTL:          getDurationEnv("DEVICE_AUTH_CODE_TTL", 10*time.Minute),
+			PollInterval:     getDurationEnv("DEVICE_AUTH_POLL_INTERVAL", 5*time.Second),
+		},
+		Orgs: OrgsConfig{
+			InvitationTTL: getDurationEnv("ORG_INVITATION_TTL", 7*24*time.Hour),
+			InvitationURL: getEnv("ORG_INVITATION_URL", ""),
+		},
+		Account: AccountConfig{
+			UsernameChangeCooldown: getDurationEnv("USERNAME_CHANGE_COOLDOWN", 30*24*time.Hour),
+			UsernameReservation:    getDurationEnv("USERNAME_RESERVATION", 90*24*time.Hour),
+			EmailChangeTTL:         getDurationEnv("EMAIL_CHANGE_TTL", 24*time.Hour),
+			EmailRevertTTL:         getDurationEnv("EMAIL_REVERT_TTL", 7*24*time.Hour),
+			EmailConfirmURL:        getEnv("EMAIL_CONFIRM_URL", ""),
+			EmailRevertURL:         getEnv("EMAIL_REVERT_URL", ""),
+		},
+		Metering: MeteringConfig{
+			Enabled:       getBoolEnv("METERING_ENABLED", true),
+			MonthlyQuota:  getInt64Env("API_KEY_MONTHLY_QUOTA", 100000),
+			FlushInterval: getDurationEnv("METERING_FLUSH_INTERVAL", 10*time.Second),
+		},
+		Realtime: RealtimeConfig{
+			Enabled:        getBoolEnv("REALTIME_ENABLED", false),
+			PresenceTTL:    getDurationEnv("REALTIME_PRESENCE_TTL", time.Minute),
+			MaxConnections: getIntEnv("REALTIME_MAX_CONNECTIONS", 1000),
+		},
+		Billing: BillingConfig{
+			StripeSecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
+			StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+			StripeAPIURL:        getEnv("STRIPE_API_URL", "https://api.stripe.com"),
+			WebhookTolerance:    getDurationEnv("STRIPE_WEBHOOK_TOLERANCE", 5*time.Minute),
+			CreateCustomers:     getBoolEnv("BILLING_CREATE_CUSTOMERS", false),
+			PricePlans:          getListEnv("STRIPE_PRICE_PLANS", nil),
+		},
+		Plans: PlansConfig{
+			Enabled:     getBoolEnv("PLAN_ENTITLEMENTS", false),
+			DefaultPlan: getEnv("DEFAULT_PLAN", "free"),
+		},
+		Canary: CanaryConfig{
+			Rollouts: getListEnv("CANARY_ROLLOUTS", nil),
+			Users:    getListEnv("CANARY_USERS", nil),
+			Header:   getEnv("CANARY_HEADER", ""),
+		},
+		Faults: FaultsConfig{
+			Enabled: getBoolEnv("FAULT_INJECTION_ENABLED", false),
+			Rules:   getListEnv("FAULT_INJECTION_RULES", nil),
+			Headers: getBoolEnv("FAULT_INJECTION_HEADERS", false),
+		},
+		Recording: RecordingConfig{
+			Enabled:      getBoolEnv("RECORD_TRAFFIC", false),
+			Dir:          getEnv("RECORD_DIR", "recordings"),
+			Paths:        getListEnv("RECORD_PATHS", []string{"/api"}),
+			MaxBodyBytes: getIntEnv("RECORD_MAX_BODY_BYTES", 64<<10),
+		},
+		Mock: MockConfig{
+			Enabled: getBoolEnv("MOCK_MODE", false),
+			Spec:    getEnv("MOCK_SPEC", "openapi.yaml"),
+		},
+		Terms: TermsConfig{
+			Version: getEnv("TERMS_VERSION", ""),
+			URL:     getEnv("TERMS_URL", ""),
+		},
+		Backup: BackupConfig{
+			Storage: getEnv("BACKUP_STORAGE", "local"),
+			Dir:     getEnv("BACKUP_DIR", "backups"),
+			Prefix:  getEnv("BACKUP_PREFIX", ""),
+
+			S3Bucket:          getEnv("BACKUP_S3_BUCKET", ""),
+			S3Region:          getEnv("BACKUP_S3_REGION", "us-east-1"),
+			S3Endpoint:        getEnv("BACKUP_S3_ENDPOINT", ""),
+			S3PathStyle:       getBoolEnv("BACKUP_S3_PATH_STYLE", false),
+			S3AccessKeyID:     getEnv("BACKUP_S3_ACCESS_KEY_ID", ""),
+			S3SecretAccessKey: getEnv("BACKUP_S3_SECRET_ACCESS_KEY", ""),
+
+			Interval:       getDurationEnv("BACKUP_INTERVAL", 0),
+			RetentionCount: getIntEnv("BACKUP_RETENTION_COUNT", 7),
+			RetentionAge:   getDurationEnv("BACKUP_RETENTION_AGE", 0),
+		},
+		Crash: CrashConfig{
+			Enabled:    getBoolEnv("CRASH_REPORTS_ENABLED", false),
+			Storage:    getEnv("CRASH_REPORTS_STORAGE", "local"),
+			Dir:        getEnv("CRASH_REPORTS_DIR", "crash-reports"),
+			Prefix:     getEnv("CRASH_REPORTS_PREFIX", ""),
+			MaxReports: getIntEnv("CRASH_REPORTS_MAX", 100),
+		},
+		Profiler: ProfilerConfig{
+			Enabled:      getBoolEnv("PROFILER_ENABLED", false),
+			P99:          getDurationEnv("PROFILER_P99_THRESHOLD", time.Second),
+			ErrorRate:    getFloat64Env("PROFILER_ERROR_RATE_THRESHOLD", 0.05),
+			MinRequests:  getIntEnv("PROFILER_MIN_REQUESTS", 100),
+			Window:       getDurationEnv("PROFILER_WINDOW", time.Minute),
+			CPUDuration:  getDurationEnv("PROFILER_CPU_DURATION", 10*time.Second),
+			Cooldown:     getDurationEnv("PROFILER_COOLDOWN", 15*time.Minute),
+			Storage:      getEnv("PROFILER_STORAGE", "local"),
+			Dir:          getEnv("PROFILER_DIR", "profiles"),
+			Prefix:       getEnv("PROFILER_PREFIX", ""),
+			MaxSnapshots: getIntEnv("PROFILER_MAX_SNAPSHOTS", 20),
 		},
 	}
 }
@@ -148,6 +1068,29 @@ func getBoolEnv(key string, fallback bool) bool {
 	return fallback
 }
 
+func getListEnv(key string, fallback []string) []string {
+	if value, exists := os.LookupEnv(key); exists && value != "" {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return fallback
+}
+
+// getFileModeEnv parses an octal permission such as "0660".
+func getFileModeEnv(key string, fallback os.FileMode) os.FileMode {
+	if value, exists := os.LookupEnv(key); exists && value != "" {
+		if mode, err := strconv.ParseUint(value, 8, 32); err == nil && mode <= 0o777 {
+			return os.FileMode(mode)
+		}
+	}
+	return fallback
+}
+
 func getDurationEnv(key string, fallback time.Duration) time.Duration {
 	if value, exists := os.LookupEnv(key); exists && value != "" {
 		if durationVal, err := time.ParseDuration(value); err == nil {
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{"sqlite", "./data/app.db", "./data/app.db"},
+		{"url", "postgres://api:s3cret@db:5432/app", "postgres://api:%5BREDACTED%5D@db:5432/app"},
+		{"key value", "host=db user=api password=s3cret dbname=app", "host=db user=api password=[REDACTED] dbname=app"},
+		{"mysql", "api:s3cret@tcp(127.0.0.1:3306)/app?parseTime=True", "api:[REDACTED]@tcp(127.0.0.1:3306)/app?parseTime=True"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactDSN(tt.dsn); got != tt.want {
+				t.Fatalf("RedactDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedacted_HidesSecrets(t *testing.T) {
+	cfg := Config{}
+	cfg.JWT.Secret = "topsecret"
+	cfg.Database.DSN = "host=db password=hunter2"
+	cfg.Database.EncryptionKey = "sqlcipherkey"
+	cfg.Database.QueryCacheRedisURL = "redis://:redispass@cache:6379/0"
+	cfg.Security.EncryptionKeys = "k1:c2VjcmV0"
+	cfg.Cache.PurgeToken = "cdntoken"
+	cfg.Notify.SMTPPassword = "smtppass"
+	cfg.Billing.StripeSecretKey = "sk_live_x"
+	cfg.Billing.StripeWebhookSecret = "whsec_x"
+	cfg.Backup.S3SecretAccessKey = "s3secret"
+
+	out := cfg.Redacted()
+	if strings.Contains(out.JWT.Secret, "topsecret") || strings.Contains(out.Database.DSN, "hunter2") ||
+		strings.Contains(out.Database.QueryCacheRedisURL, "redispass") ||
+		out.Database.EncryptionKey != redacted || out.Security.EncryptionKeys != redacted ||
+		out.Cache.PurgeToken != redacted || out.Notify.SMTPPassword != redacted ||
+		out.Billing.StripeSecretKey != redacted || out.Billing.StripeWebhookSecret != redacted ||
+		out.Backup.S3SecretAccessKey != redacted {
+		t.Fatalf("secrets leaked: %+v", out)
+	}
+	if cfg.JWT.Secret != "topsecret" {
+		t.Fatal("Redacted must not modify the original configuration")
+	}
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/pkg/circuitbreaker"
+)
+
+// BreakerName is the circuit breaker name used for the primary database.
+const BreakerName = "database"
+
+// breakerAllowedKey marks statements that passed the breaker and must report their outcome.
+const breakerAllowedKey = "circuit_breaker:allowed"
+
+// UseCircuitBreaker guards every statement executed through db with breaker. Connectivity
+// failures count against the breaker; query errors such as "record not found" or constraint
+// violations do not, nor do statements cut short by the deadline or cancellation of their
+// caller's context, which say nothing about the database. While the breaker is open, statements fail immediately with
+// circuitbreaker.ErrOpen instead of waiting for connection timeouts.
+func UseCircuitBreaker(db *gorm.DB, breaker *circuitbreaker.Breaker) error {
+	before := func(tx *gorm.DB) {
+		if err := breaker.Allow(); err != nil {
+			// GORM skips executing the statement once an error is set.
+			_ = tx.AddError(err)
+			return
+		}
+		tx.InstanceSet(breakerAllowedKey, true)
+	}
+	after := func(tx *gorm.DB) {
+		if _, ok := tx.InstanceGet(breakerAllowedKey); !ok {
+			return
+		}
+		switch {
+		case canceledByCaller(tx):
+			breaker.Ignore()
+		case IsConnectionError(tx.Error):
+			breaker.Failure()
+		default:
+			breaker.Success()
+		}
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("circuit_breaker:before_create", before); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("circuit_breaker:after_create", after); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("circuit_breaker:before_query", before); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("circuit_breaker:after_query", after); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("circuit_breaker:before_update", before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("circuit_breaker:after_update", after); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("circuit_breaker:before_delete", before); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("circuit_breaker:after_delete", after); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("circuit_breaker:before_row", before); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("circuit_breaker:after_row", after); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("circuit_breaker:before_raw", before); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("circuit_breaker:after_raw", after)
+}
+
+// canceledByCaller reports whether the statement of tx failed because its context expired or
+// was canceled.
+func canceledByCaller(tx *gorm.DB) bool {
+	if tx.Error == nil {
+		return false
+	}
+	if errors.Is(tx.Error, context.Canceled) || errors.Is(tx.Error, context.DeadlineExceeded) {
+		return true
+	}
+	return tx.Statement.Context != nil && tx.Statement.Context.Err() != nil
+}
+
+// IsConnectionError reports whether err means the database could not be reached,
+// as opposed to a query that the database rejected.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
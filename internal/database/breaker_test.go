@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/circuitbreaker"
+)
+
+func TestUseCircuitBreakerFailsFastWhenOpen(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	breaker := circuitbreaker.New(BreakerName, circuitbreaker.Settings{FailureThreshold: 1, OpenTimeout: time.Hour})
+	if err := UseCircuitBreaker(db, breaker); err != nil {
+		t.Fatalf("UseCircuitBreaker returned error: %v", err)
+	}
+
+	var user models.User
+	if err := db.First(&user, 1).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected record not found, got %v", err)
+	}
+	if breaker.State() != circuitbreaker.StateClosed {
+		t.Fatal("query errors must not open the breaker")
+	}
+
+	breaker.Failure()
+	if err := db.First(&user, 1).Error; !errors.Is(err, circuitbreaker.ErrOpen) {
+		t.Fatalf("expected ErrOpen while the breaker is open, got %v", err)
+	}
+}
+
+func TestUseCircuitBreakerIgnoresCanceledStatements(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	breaker := circuitbreaker.New(BreakerName, circuitbreaker.Settings{FailureThreshold: 1, OpenTimeout: time.Hour})
+	if err := UseCircuitBreaker(db, breaker); err != nil {
+		t.Fatalf("UseCircuitBreaker returned error: %v", err)
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	var users []models.User
+	if err := db.WithContext(expired).Find(&users).Error; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the expired context to fail the query, got %v", err)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := db.WithContext(canceled).Find(&users).Error; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the canceled context to fail the query, got %v", err)
+	}
+	if breaker.State() != circuitbreaker.StateClosed {
+		t.Fatal("statements canceled by their caller must not open the breaker")
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	if !IsConnectionError(fmt.Errorf("query failed: %w", driver.ErrBadConn)) {
+		t.Error("expected driver.ErrBadConn to be a connection error")
+	}
+	if IsConnectionError(gorm.ErrRecordNotFound) {
+		t.Error("expected record not found not to be a connection error")
+	}
+	if IsConnectionError(nil) {
+		t.Error("expected nil not to be a connection error")
+	}
+}
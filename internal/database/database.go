@@ -32,13 +32,18 @@ func InitDB(cfg *config.Config) (*gorm.DB, error) {
 	var db *gorm.DB
 	var err error
 
+	gormCfg := GormConfig(cfg)
 	switch strings.ToLower(driver) {
 	case "sqlite":
-		db, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		if cfg.Database.EncryptionKey != "" {
+			db, err = OpenSQLCipher(dsn, cfg.Database.EncryptionKey, gormCfg)
+		} else {
+			db, err = gorm.Open(sqlite.Open(dsn), gormCfg)
+		}
 	case "postgres", "postgresql":
-		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		db, err = gorm.Open(postgres.Open(dsn), gormCfg)
 	case "mysql":
-		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+		db, err = gorm.Open(mysql.Open(dsn), gormCfg)
 	default:
 		return nil, fmt.Errorf("unsupported database driver: %s", driver)
 	}
@@ -60,6 +65,19 @@ func InitDB(cfg *config.Config) (*gorm.DB, error) {
 	return db, nil
 }
 
+// GormConfig returns the GORM settings for cfg:
+//   - PrepareStmt caches prepared statements per connection, saving a parse on repeated queries.
+//   - SkipDefaultTransaction stops GORM from wrapping every single create, update, and delete
+//     in its own transaction; explicit Transaction calls are unaffected.
+//   - CreateBatchSize splits Create calls with a slice into inserts of that many rows.
+func GormConfig(cfg *config.Config) *gorm.Config {
+	return &gorm.Config{
+		PrepareStmt:            cfg.Database.PrepareStmt,
+		SkipDefaultTransaction: cfg.Database.SkipDefaultTransaction,
+		CreateBatchSize:        cfg.Database.CreateBatchSize,
+	}
+}
+
 // CloseDB closes the database connection.
 func CloseDB(db *gorm.DB) {
 	sqlDB, err := db.DB()
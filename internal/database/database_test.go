@@ -0,0 +1,62 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+func TestInitDB_PerformanceOptions(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Database.Driver = "sqlite"
+	cfg.Database.DSN = filepath.Join(t.TempDir(), "app.db")
+	cfg.Database.PrepareStmt = true
+	cfg.Database.SkipDefaultTransaction = true
+	cfg.Database.CreateBatchSize = 2
+
+	db, err := InitDB(cfg)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	defer CloseDB(db)
+
+	if !db.PrepareStmt || !db.SkipDefaultTransaction || db.CreateBatchSize != 2 {
+		t.Fatalf("options not applied: prepare=%v skip_tx=%v batch=%d", db.PrepareStmt, db.SkipDefaultTransaction, db.CreateBatchSize)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	users := []models.User{
+		{Username: "a", Email: "a@example.com", Password: "x"},
+		{Username: "b", Email: "b@example.com", Password: "x"},
+		{Username: "c", Email: "c@example.com", Password: "x"},
+	}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatalf("batched create: %v", err)
+	}
+	var count int64
+	if err := db.Model(&models.User{}).Count(&count).Error; err != nil || count != 3 {
+		t.Fatalf("count = %d, err %v; want 3", count, err)
+	}
+}
+
+func TestInitDB_EncryptionKeyRequiresSQLCipher(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Database.Driver = "sqlite"
+	cfg.Database.DSN = filepath.Join(t.TempDir(), "app.db")
+	cfg.Database.EncryptionKey = "it's a secret"
+
+	// The bundled SQLite has no SQLCipher, so the key must not be silently ignored.
+	db, err := InitDB(cfg)
+	if err == nil {
+		CloseDB(db)
+		t.Skip("linked against SQLCipher")
+	}
+	if !errors.Is(err, ErrSQLCipherUnavailable) {
+		t.Fatalf("expected ErrSQLCipherUnavailable, got %v", err)
+	}
+}
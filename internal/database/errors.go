@@ -0,0 +1,117 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+const (
+	// postgresUniqueViolation is the SQLSTATE for unique_violation
+	postgresUniqueViolation = "23505"
+	// postgresForeignKeyViolation is the SQLSTATE for foreign_key_violation
+	postgresForeignKeyViolation = "23503"
+	// postgresDeadlock and postgresSerializationFailure are the SQLSTATEs for
+	// deadlock_detected and serialization_failure
+	postgresDeadlock             = "40P01"
+	postgresSerializationFailure = "40001"
+
+	// mysqlDuplicateEntry is the MySQL error number for ER_DUP_ENTRY
+	mysqlDuplicateEntry = 1062
+	// mysqlRowReferenced and mysqlNoReferencedRow are the MySQL error numbers for
+	// ER_ROW_IS_REFERENCED_2 (deleting a parent) and ER_NO_REFERENCED_ROW_2 (inserting an orphan)
+	mysqlRowReferenced   = 1451
+	mysqlNoReferencedRow = 1452
+	// mysqlDeadlock and mysqlLockWaitTimeout are the MySQL error numbers for ER_LOCK_DEADLOCK and
+	// ER_LOCK_WAIT_TIMEOUT
+	mysqlDeadlock        = 1213
+	mysqlLockWaitTimeout = 1205
+)
+
+// IsDuplicateKeyError reports whether err was caused by a unique constraint violation,
+// regardless of the database driver in use.
+func IsDuplicateKeyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == postgresUniqueViolation
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+
+	return false
+}
+
+// IsForeignKeyError reports whether err was caused by a foreign key violation: a row references
+// one that does not exist, or a row that others reference was deleted.
+func IsForeignKeyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == postgresForeignKeyViolation
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlRowReferenced || mysqlErr.Number == mysqlNoReferencedRow
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+	}
+
+	return false
+}
+
+// IsDeadlockError reports whether err means the transaction lost to a concurrent one: a
+// deadlock, a serialization failure, or a lock that could not be acquired in time. Unlike
+// other errors, the same request usually succeeds when retried.
+func IsDeadlockError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == postgresDeadlock || pgErr.Code == postgresSerializationFailure
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDeadlock || mysqlErr.Number == mysqlLockWaitTimeout
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	return false
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestIsDuplicateKeyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"Nil", nil, false},
+		{"Generic", errors.New("boom"), false},
+		{"GORM translated", gorm.ErrDuplicatedKey, true},
+		{"Postgres unique violation", &pgconn.PgError{Code: "23505"}, true},
+		{"Postgres foreign key violation", &pgconn.PgError{Code: "23503"}, false},
+		{"MySQL duplicate entry", &mysql.MySQLError{Number: 1062}, true},
+		{"MySQL other error", &mysql.MySQLError{Number: 1213}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDuplicateKeyError(tt.err); got != tt.want {
+				t.Errorf("IsDuplicateKeyError() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDuplicateKeyErrorSQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	type item struct {
+		ID   uint   `gorm:"primaryKey"`
+		Name string `gorm:"unique"`
+	}
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	if err := db.Create(&item{Name: "a"}).Error; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = db.Create(&item{Name: "a"}).Error
+	if !IsDuplicateKeyError(err) {
+		t.Errorf("expected duplicate key error, got %v", err)
+	}
+}
+
+func TestIsForeignKeyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"Nil", nil, false},
+		{"Generic", errors.New("boom"), false},
+		{"GORM translated", gorm.ErrForeignKeyViolated, true},
+		{"Postgres foreign key violation", &pgconn.PgError{Code: "23503"}, true},
+		{"Postgres unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"MySQL row referenced", &mysql.MySQLError{Number: 1451}, true},
+		{"MySQL no referenced row", &mysql.MySQLError{Number: 1452}, true},
+		{"MySQL duplicate entry", &mysql.MySQLError{Number: 1062}, false},
+		{"SQLite foreign key", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsForeignKeyError(tt.err); got != tt.want {
+				t.Errorf("IsForeignKeyError() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDeadlockError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"Nil", nil, false},
+		{"Generic", errors.New("boom"), false},
+		{"Postgres deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"Postgres serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"Postgres unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"MySQL deadlock", &mysql.MySQLError{Number: 1213}, true},
+		{"MySQL lock wait timeout", &mysql.MySQLError{Number: 1205}, true},
+		{"MySQL duplicate entry", &mysql.MySQLError{Number: 1062}, false},
+		{"SQLite busy", sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDeadlockError(tt.err); got != tt.want {
+				t.Errorf("IsDeadlockError() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// Migration is a versioned schema change that runs once, after AutoMigrate.
+// Use it for changes AutoMigrate can't express, such as functional indexes or data fixes.
+type Migration struct {
+	Version     string
+	Description string
+	Up          func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration.
+type SchemaMigration struct {
+	Version     string    `gorm:"primaryKey;size:32"`
+	Description string    `gorm:"size:255"`
+	AppliedAt   time.Time `gorm:"not null"`
+}
+
+// TableName sets the table used to track applied migrations.
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migrations lists all versioned migrations in the order they must be applied.
+var migrations = []Migration{
+	{
+		Version:     "20261015000001",
+		Description: "Case-insensitive unique indexes on users.username and users.email",
+		Up:          caseInsensitiveUserIndexes,
+	},
+	{
+		Version:     "20261016000001",
+		Description: "Unicode-normalized usernames with a unique index",
+		Up:          normalizedUsernames,
+	},
+}
+
+// Migrate creates or updates the tables for all models and applies pending versioned migrations.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(append(models.All(), &SchemaMigration{})...); err != nil {
+		return fmt.Errorf("auto-migration failed: %w", err)
+	}
+
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:     migration.Version,
+				Description: migration.Description,
+				AppliedAt:   time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", migration.Version, err)
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"version":     migration.Version,
+			"description": migration.Description,
+		}).Info("Applied database migration")
+	}
+
+	return nil
+}
+
+// PendingMigrations returns the versioned migrations that have not been applied yet.
+func PendingMigrations(db *gorm.DB) ([]Migration, error) {
+	versions, err := AppliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]bool)
+	for _, version := range versions {
+		applied[version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// AppliedMigrations returns the versions of the applied migrations, oldest first.
+func AppliedMigrations(db *gorm.DB) ([]string, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil, nil
+	}
+	var versions []string
+	if err := db.Model(&SchemaMigration{}).Order("version").Pluck("version", &versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	return versions, nil
+}
+
+// caseInsensitiveUserIndexes lowercases stored emails and adds unique indexes on
+// LOWER(username) and LOWER(email), so "Foo@Bar.com" and "foo@bar.com" can't coexist.
+func caseInsensitiveUserIndexes(tx *gorm.DB) error {
+	// Report every collision at once rather than the first one the database hits
+	for _, column := range []string{"email", "username"} {
+		var duplicates []string
+		err := tx.Table("users").Select("LOWER("+column+")").
+			Group("LOWER("+column+")").Having("COUNT(*) > 1").
+			Pluck("LOWER("+column+")", &duplicates).Error
+		if err != nil {
+			return fmt.Errorf("failed to look for case-only duplicate %ss: %w", column, err)
+		}
+		if len(duplicates) > 0 {
+			return duplicateUsersError(column, duplicates)
+		}
+	}
+
+	if err := tx.Exec("UPDATE users SET email = LOWER(email)").Error; err != nil {
+		return fmt.Errorf("failed to normalize emails (check for case-only duplicates): %w", err)
+	}
+
+	indexes := map[string]string{
+		"idx_users_username_lower": "username",
+		"idx_users_email_lower":    "email",
+	}
+
+	for name, column := range indexes {
+		if tx.Migrator().HasIndex(&models.User{}, name) {
+			continue
+		}
+
+		var statement string
+		switch tx.Dialector.Name() {
+		case "mysql":
+			// MySQL 8.0.13+ requires functional key parts wrapped in their own parentheses
+			statement = fmt.Sprintf("CREATE UNIQUE INDEX %s ON users ((LOWER(%s)))", name, column)
+		default:
+			statement = fmt.Sprintf("CREATE UNIQUE INDEX %s ON users (LOWER(%s))", name, column)
+		}
+
+		if err := tx.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to create index %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// normalizedUsernames fills users.username_normalized and username_changes.old_username_normalized
+// with models.NormalizeUsername, which lowercases every script unlike SQLite's LOWER(), and
+// makes the former unique. Usernames that collide once normalized are reported and must be
+// renamed first.
+func normalizedUsernames(tx *gorm.DB) error {
+	type row struct {
+		ID       uint
+		Username string
+	}
+
+	var users []row
+	if err := tx.Table("users").Select("id, username").Order("id").Scan(&users).Error; err != nil {
+		return fmt.Errorf("failed to read usernames: %w", err)
+	}
+	owners := make(map[string][]uint, len(users))
+	for _, u := range users {
+		normalized := models.NormalizeUsername(u.Username)
+		owners[normalized] = append(owners[normalized], u.ID)
+	}
+	var duplicates []string
+	for normalized, ids := range owners {
+		if len(ids) > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("%s (users %v)", normalized, ids))
+		}
+	}
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		return duplicateUsersError("username", duplicates)
+	}
+
+	for _, u := range users {
+		err := tx.Table("users").Where("id = ?", u.ID).
+			UpdateColumn("username_normalized", models.NormalizeUsername(u.Username)).Error
+		if err != nil {
+			return fmt.Errorf("failed to normalize username of user %d: %w", u.ID, err)
+		}
+	}
+
+	var changes []struct {
+		ID          uint
+		OldUsername string
+	}
+	if err := tx.Table("username_changes").Select("id, old_username").Scan(&changes).Error; err != nil {
+		return fmt.Errorf("failed to read username changes: %w", err)
+	}
+	for _, c := range changes {
+		err := tx.Table("username_changes").Where("id = ?", c.ID).
+			UpdateColumn("old_username_normalized", models.NormalizeUsername(c.OldUsername)).Error
+		if err != nil {
+			return fmt.Errorf("failed to normalize username change %d: %w", c.ID, err)
+		}
+	}
+
+	const index = "idx_users_username_normalized"
+	if tx.Migrator().HasIndex(&models.User{}, index) {
+		return nil
+	}
+	if err := tx.Exec("CREATE UNIQUE INDEX " + index + " ON users (username_normalized)").Error; err != nil {
+		return fmt.Errorf("failed to create index %s: %w", index, err)
+	}
+	return nil
+}
+
+// duplicateUsersError reports the values of column shared by several users, which block a
+// unique index until they are changed.
+func duplicateUsersError(column string, duplicates []string) error {
+	return fmt.Errorf("%d %ss are used by several users once lowercased, rename all but one of each before migrating: %s",
+		len(duplicates), column, strings.Join(duplicates, ", "))
+}
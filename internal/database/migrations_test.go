@@ -0,0 +1,64 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+func TestNormalizedUsernames(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UsernameChange{}); err != nil {
+		t.Fatal(err)
+	}
+	// Rows written before the column existed
+	db.Exec("INSERT INTO users (username, email, password) VALUES ('Émile', 'a@x.com', 'x'), ('ÉMILE', 'b@x.com', 'x'), ('zoë', 'c@x.com', 'x')")
+
+	err = normalizedUsernames(db)
+	if err == nil || !strings.Contains(err.Error(), "émile (users [1 2])") {
+		t.Fatalf("err = %v; want the colliding usernames reported", err)
+	}
+
+	db.Exec("UPDATE users SET username = 'emile' WHERE id = 2")
+	db.Exec("INSERT INTO username_changes (user_id, old_username, new_username, changed_by_id) VALUES (3, 'ZOË', 'zoë', 3)")
+	if err := normalizedUsernames(db); err != nil {
+		t.Fatalf("normalizedUsernames() = %v", err)
+	}
+
+	var normalized []string
+	db.Table("users").Order("id").Pluck("username_normalized", &normalized)
+	if strings.Join(normalized, ",") != "émile,emile,zoë" {
+		t.Errorf("username_normalized = %v", normalized)
+	}
+	var old string
+	db.Table("username_changes").Select("old_username_normalized").Scan(&old)
+	if old != "zoë" {
+		t.Errorf("old_username_normalized = %q; want zoë", old)
+	}
+	if err := db.Exec("INSERT INTO users (username, username_normalized, email, password) VALUES ('Zoë', 'zoë', 'd@x.com', 'x')").Error; err == nil {
+		t.Error("expected the unique index to reject a duplicate normalized username")
+	}
+}
+
+func TestCaseInsensitiveUserIndexes_ReportsDuplicates(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("INSERT INTO users (username, email, password) VALUES ('a', 'Foo@Bar.com', 'x'), ('b', 'foo@bar.com', 'x')")
+
+	err = caseInsensitiveUserIndexes(db)
+	if err == nil || !strings.Contains(err.Error(), "foo@bar.com") {
+		t.Fatalf("err = %v; want the duplicate email reported", err)
+	}
+}
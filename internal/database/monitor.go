@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+var databaseUp = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "database_up",
+	Help: "Whether the last database health check succeeded (1) or failed (0).",
+})
+
+func init() {
+	metrics.Registry.MustRegister(databaseUp)
+}
+
+// Monitor periodically pings the database so the API can switch to degraded mode while it
+// is unreachable and detect when it reconnects.
+type Monitor struct {
+	db       *gorm.DB
+	timeout  time.Duration
+	onChange func(available bool)
+
+	available atomic.Bool
+}
+
+// NewMonitor creates a monitor that assumes the database is available until a check fails.
+// onChange, which may be nil, is called whenever availability changes.
+func NewMonitor(db *gorm.DB, timeout time.Duration, onChange func(available bool)) *Monitor {
+	m := &Monitor{db: db, timeout: timeout, onChange: onChange}
+	m.available.Store(true)
+	databaseUp.Set(1)
+	return m
+}
+
+// Available reports the result of the last check.
+func (m *Monitor) Available() bool {
+	return m.available.Load()
+}
+
+// Check pings the database and updates the availability.
+func (m *Monitor) Check(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	available := false
+	if sqlDB, err := m.db.DB(); err == nil {
+		available = sqlDB.PingContext(ctx) == nil
+	}
+
+	if available {
+		databaseUp.Set(1)
+	} else {
+		databaseUp.Set(0)
+	}
+
+	if m.available.Swap(available) != available {
+		if available {
+			logger.Info("Database connection recovered, leaving degraded mode")
+		} else {
+			logger.Warn("Database unreachable, entering degraded mode")
+		}
+		if m.onChange != nil {
+			m.onChange(available)
+		}
+	}
+	return available
+}
+
+// Run checks the database every interval until ctx is canceled.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+// poolShrinkAfter is the number of consecutive quiet checks before an auto-tuned pool shrinks.
+const poolShrinkAfter = 5
+
+// RegisterPoolMetrics exposes the connection pool statistics of sqlDB (open, in use, idle,
+// wait count and duration, closed connections) as go_sql_* metrics labeled db_name=name.
+func RegisterPoolMetrics(sqlDB *sql.DB, name string) error {
+	err := metrics.Registry.Register(collectors.NewDBStatsCollector(sqlDB, name))
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		return nil
+	}
+	return err
+}
+
+// PoolTuning configures automatic sizing of the connection pool.
+type PoolTuning struct {
+	// Enabled turns on auto-tuning; otherwise the pool statistics are only logged.
+	Enabled bool
+	// MaxOpenConnsLimit is the largest MaxOpenConns auto-tuning may set.
+	MaxOpenConnsLimit int
+	// WaitThreshold is the average wait for a connection that makes the pool grow.
+	WaitThreshold time.Duration
+}
+
+// PoolMonitor logs connection pool statistics and, when tuning is enabled, raises MaxOpenConns
+// while requests wait too long for a connection. Once the pool has been quiet for a while it
+// shrinks back step by step, never below the configured size.
+type PoolMonitor struct {
+	db      *sql.DB
+	base    int
+	maxOpen int
+	tuning  PoolTuning
+
+	last  sql.DBStats
+	quiet int
+}
+
+// NewPoolMonitor creates a monitor for sqlDB, whose MaxOpenConns was set to maxOpenConns.
+func NewPoolMonitor(sqlDB *sql.DB, maxOpenConns int, tuning PoolTuning) *PoolMonitor {
+	return &PoolMonitor{
+		db:      sqlDB,
+		base:    maxOpenConns,
+		maxOpen: maxOpenConns,
+		tuning:  tuning,
+		last:    sqlDB.Stats(),
+	}
+}
+
+// MaxOpenConns returns the pool size currently in effect.
+func (m *PoolMonitor) MaxOpenConns() int {
+	return m.maxOpen
+}
+
+// Check reads the pool statistics, logs them, and tunes the pool size.
+func (m *PoolMonitor) Check() {
+	m.observe(m.db.Stats())
+}
+
+func (m *PoolMonitor) observe(stats sql.DBStats) {
+	waits := stats.WaitCount - m.last.WaitCount
+	waited := stats.WaitDuration - m.last.WaitDuration
+	m.last = stats
+
+	var avgWait time.Duration
+	if waits > 0 {
+		avgWait = waited / time.Duration(waits)
+	}
+
+	entry := logger.WithFields(map[string]interface{}{
+		"open":            stats.OpenConnections,
+		"in_use":          stats.InUse,
+		"idle":            stats.Idle,
+		"max_open":        m.maxOpen,
+		"waits":           waits,
+		"avg_wait":        avgWait.String(),
+		"idle_closed":     stats.MaxIdleClosed + stats.MaxIdleTimeClosed,
+		"lifetime_closed": stats.MaxLifetimeClosed,
+	})
+
+	// Unlimited pools (MaxOpenConns <= 0) never wait, so there is nothing to tune.
+	if !m.tuning.Enabled || m.maxOpen <= 0 {
+		if waits > 0 && avgWait >= m.tuning.WaitThreshold {
+			entry.Warn("Requests are waiting for database connections; consider raising DB_MAX_OPEN_CONNS")
+		} else {
+			entry.Debug("Database connection pool statistics")
+		}
+		return
+	}
+
+	switch {
+	case waits > 0 && avgWait >= m.tuning.WaitThreshold:
+		m.quiet = 0
+		if m.maxOpen >= m.tuning.MaxOpenConnsLimit {
+			entry.Warn("Requests are waiting for database connections and the pool is at its limit")
+			return
+		}
+		m.resize(min(m.tuning.MaxOpenConnsLimit, m.maxOpen+poolStep(m.maxOpen)))
+		entry.WithField("new_max_open", m.maxOpen).Info("Increased database connection pool size")
+	case waits == 0 && stats.InUse <= m.maxOpen/2 && m.maxOpen > m.base:
+		m.quiet++
+		if m.quiet < poolShrinkAfter {
+			entry.Debug("Database connection pool statistics")
+			return
+		}
+		m.quiet = 0
+		m.resize(max(m.base, m.maxOpen-poolStep(m.maxOpen)))
+		entry.WithField("new_max_open", m.maxOpen).Info("Decreased database connection pool size")
+	default:
+		m.quiet = 0
+		entry.Debug("Database connection pool statistics")
+	}
+}
+
+func (m *PoolMonitor) resize(maxOpen int) {
+	m.maxOpen = maxOpen
+	m.db.SetMaxOpenConns(maxOpen)
+}
+
+// poolStep grows or shrinks the pool by a quarter, at least one connection.
+func poolStep(size int) int {
+	return max(1, size/4)
+}
+
+// Run checks the pool every interval until ctx is canceled.
+func (m *PoolMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
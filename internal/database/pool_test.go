@@ -0,0 +1,73 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPoolMonitor_AutoTune(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(4)
+
+	m := NewPoolMonitor(sqlDB, 4, PoolTuning{Enabled: true, MaxOpenConnsLimit: 6, WaitThreshold: 10 * time.Millisecond})
+	stats := sql.DBStats{}
+
+	// Long waits grow the pool by a quarter until the limit.
+	stats.WaitCount, stats.WaitDuration = 10, 500*time.Millisecond
+	m.observe(stats)
+	if got := m.MaxOpenConns(); got != 5 {
+		t.Fatalf("MaxOpenConns = %d after long waits, want 5", got)
+	}
+	stats.WaitCount, stats.WaitDuration = 20, time.Second
+	m.observe(stats)
+	m.observe(sql.DBStats{WaitCount: 30, WaitDuration: 2 * time.Second})
+	if got := m.MaxOpenConns(); got != 6 {
+		t.Fatalf("MaxOpenConns = %d, want the limit 6", got)
+	}
+	if got := sqlDB.Stats().MaxOpenConnections; got != 6 {
+		t.Fatalf("sql.DB MaxOpenConnections = %d, want 6", got)
+	}
+
+	// Short waits do not count.
+	m.observe(sql.DBStats{WaitCount: 40, WaitDuration: 2*time.Second + time.Millisecond})
+
+	// A quiet pool shrinks back, but not below the configured size.
+	quiet := sql.DBStats{WaitCount: 40, WaitDuration: 2*time.Second + time.Millisecond}
+	for i := 0; i < 3*poolShrinkAfter; i++ {
+		m.observe(quiet)
+	}
+	if got := m.MaxOpenConns(); got != 4 {
+		t.Fatalf("MaxOpenConns = %d after a quiet period, want 4", got)
+	}
+}
+
+func TestPoolMonitor_DisabledOnlyObserves(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewPoolMonitor(sqlDB, 4, PoolTuning{WaitThreshold: time.Millisecond})
+	m.observe(sql.DBStats{WaitCount: 10, WaitDuration: time.Second})
+	if got := m.MaxOpenConns(); got != 4 {
+		t.Fatalf("MaxOpenConns = %d with tuning disabled, want 4", got)
+	}
+	if err := RegisterPoolMetrics(sqlDB, "test"); err != nil {
+		t.Fatal(err)
+	}
+}
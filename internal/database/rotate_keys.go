@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/yeferson59/gin-template/pkg/crypto"
+)
+
+// rotateBatchSize is the number of rows read at a time by RotateEncryptionKeys.
+const rotateBatchSize = 500
+
+// KeyRotation is the outcome of RotateEncryptionKeys for one encrypted column.
+type KeyRotation struct {
+	Table  string
+	Column string
+	// Rotated is the number of values re-wrapped with the primary key, or that would be on a
+	// dry run.
+	Rotated int
+}
+
+// RotateEncryptionKeys re-wraps with the primary key of kr the values of every field of
+// models tagged serializer:encrypted that were encrypted with another key, including those of
+// soft-deleted rows, so that the other keys can then be removed from ENCRYPTION_KEYS. Only
+// the small data keys are re-encrypted, a batch of rows at a time. With dryRun it only counts
+// the values to rotate.
+func RotateEncryptionKeys(ctx context.Context, db *gorm.DB, kr *crypto.Keyring, models []interface{}, dryRun bool) ([]KeyRotation, error) {
+	var results []KeyRotation
+	cache := &sync.Map{}
+	for _, model := range models {
+		s, err := schema.Parse(model, cache, db.NamingStrategy)
+		if err != nil {
+			return results, fmt.Errorf("failed to parse %T: %w", model, err)
+		}
+		if s.PrioritizedPrimaryField == nil {
+			continue
+		}
+		for _, field := range s.Fields {
+			if field.TagSettings["SERIALIZER"] != crypto.SerializerName || field.DBName == "" {
+				continue
+			}
+			rotated, err := rotateColumn(ctx, db, kr, s.Table, s.PrioritizedPrimaryField.DBName, field.DBName, dryRun)
+			results = append(results, KeyRotation{Table: s.Table, Column: field.DBName, Rotated: rotated})
+			if err != nil {
+				return results, fmt.Errorf("failed to rotate %s.%s: %w", s.Table, field.DBName, err)
+			}
+		}
+	}
+	return results, nil
+}
+
+// rotationRow is the primary key and stored value of an encrypted column.
+type rotationRow struct {
+	id    interface{}
+	value string
+}
+
+// rotateColumn re-wraps the values of one column, walking the table by primary key. The rows
+// are read and written without the model, so the serializer neither decrypts nor re-encrypts
+// them.
+func rotateColumn(ctx context.Context, db *gorm.DB, kr *crypto.Keyring, table, pk, column string, dryRun bool) (int, error) {
+	rotated := 0
+	var last interface{}
+	for {
+		query := db.WithContext(ctx).Table(table).
+			Select(pk, column).
+			Where(column + " IS NOT NULL").
+			Order(pk).
+			Limit(rotateBatchSize)
+		if last != nil {
+			query = query.Where(pk+" > ?", last)
+		}
+		rows, err := readRotationBatch(query)
+		if err != nil {
+			return rotated, err
+		}
+
+		for _, r := range rows {
+			if !kr.NeedsRotation(r.value) {
+				continue
+			}
+			value, err := kr.Rotate(r.value)
+			if err != nil {
+				return rotated, fmt.Errorf("row %v: %w", r.id, err)
+			}
+			if dryRun {
+				rotated++
+				continue
+			}
+			// Matching the old value leaves rows written meanwhile, already under the primary key
+			result := db.WithContext(ctx).Table(table).
+				Where(pk+" = ? AND "+column+" = ?", r.id, r.value).
+				UpdateColumn(column, value)
+			if result.Error != nil {
+				return rotated, result.Error
+			}
+			rotated += int(result.RowsAffected)
+		}
+
+		if len(rows) < rotateBatchSize {
+			return rotated, nil
+		}
+		last = rows[len(rows)-1].id
+	}
+}
+
+// readRotationBatch reads the rows selected by query. The primary key is scanned into an
+// interface so that any key type can be compared and written back.
+func readRotationBatch(query *gorm.DB) ([]rotationRow, error) {
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var batch []rotationRow
+	for rows.Next() {
+		var r rotationRow
+		if err := rows.Scan(&r.id, &r.value); err != nil {
+			return nil, err
+		}
+		if b, ok := r.id.([]byte); ok {
+			r.id = string(b)
+		}
+		batch = append(batch, r)
+	}
+	return batch, rows.Err()
+}
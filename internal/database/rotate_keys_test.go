@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/pkg/crypto"
+)
+
+type secretNote struct {
+	gorm.Model
+	Body  string  `gorm:"serializer:encrypted"`
+	Extra *string `gorm:"serializer:encrypted"`
+	Title string
+}
+
+func testKeyring(t *testing.T, primary string, ids ...string) *crypto.Keyring {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for _, id := range append(ids, primary) {
+		keys[id] = []byte(strings.Repeat(id[:1], crypto.KeySize))
+	}
+	kr, err := crypto.NewKeyring(primary, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+func TestRotateEncryptionKeys(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&secretNote{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { crypto.SetKeyring(nil) })
+
+	crypto.SetKeyring(testKeyring(t, "old"))
+	extra := "extra"
+	notes := []secretNote{{Body: "first", Extra: &extra}, {Body: "second"}, {Body: "third"}}
+	if err := db.Create(&notes).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Delete(&notes[2])
+
+	rotation := testKeyring(t, "new", "old")
+	crypto.SetKeyring(rotation)
+	results, err := RotateEncryptionKeys(context.Background(), db, rotation, []interface{}{&secretNote{}}, true)
+	if err != nil || len(results) != 2 || results[0].Rotated != 3 || results[1].Rotated != 1 {
+		t.Fatalf("dry run = %+v, %v; want 3 bodies and 1 extra", results, err)
+	}
+	results, err = RotateEncryptionKeys(context.Background(), db, rotation, []interface{}{&secretNote{}}, false)
+	if err != nil || results[0].Rotated != 3 || results[1].Rotated != 1 {
+		t.Fatalf("rotation = %+v, %v; want 3 bodies and 1 extra", results, err)
+	}
+	results, err = RotateEncryptionKeys(context.Background(), db, rotation, []interface{}{&secretNote{}}, false)
+	if err != nil || results[0].Rotated != 0 || results[1].Rotated != 0 {
+		t.Fatalf("second rotation = %+v, %v; want nothing left", results, err)
+	}
+
+	// The old key is no longer needed
+	crypto.SetKeyring(testKeyring(t, "new"))
+	var stored []secretNote
+	if err := db.Unscoped().Order("id").Find(&stored).Error; err != nil {
+		t.Fatalf("read with the new key only: %v", err)
+	}
+	if len(stored) != 3 || stored[0].Body != "first" || *stored[0].Extra != "extra" || stored[2].Body != "third" {
+		t.Fatalf("stored = %+v", stored)
+	}
+}
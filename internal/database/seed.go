@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+// SeedOptions configures Seed.
+type SeedOptions struct {
+	// Users is the number of demo users to create, named demo1, demo2, ...
+	Users int
+	// Password is set on every demo user.
+	Password string
+}
+
+// Seed inserts demo users for local development. It is idempotent: users that already exist
+// are left untouched. It returns the number of users created.
+func Seed(ctx context.Context, db *gorm.DB, opts SeedOptions) (int, error) {
+	hashed, err := auth.HashPassword(ctx, opts.Password)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash seed password: %w", err)
+	}
+
+	created := 0
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := 1; i <= opts.Users; i++ {
+			user := models.User{
+				Username: fmt.Sprintf("demo%d", i),
+				Email:    fmt.Sprintf("demo%d@example.com", i),
+				Password: hashed,
+				Role:     models.RoleUser,
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&user)
+			if result.Error != nil {
+				return fmt.Errorf("failed to seed user %s: %w", user.Username, result.Error)
+			}
+			created += int(result.RowsAffected)
+		}
+		return nil
+	})
+	return created, err
+}
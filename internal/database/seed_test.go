@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+func TestSeed_IsIdempotent(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	opts := SeedOptions{Users: 3, Password: "Demo123!pass"}
+	created, err := Seed(context.Background(), db, opts)
+	if err != nil || created != 3 {
+		t.Fatalf("first seed created %d users, err %v; want 3", created, err)
+	}
+
+	created, err = Seed(context.Background(), db, opts)
+	if err != nil || created != 0 {
+		t.Fatalf("second seed created %d users, err %v; want 0", created, err)
+	}
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 3 {
+		t.Fatalf("expected 3 users, got %d", count)
+	}
+}
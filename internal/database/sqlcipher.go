@@ -0,0 +1,64 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// ErrSQLCipherUnavailable is returned when DB_ENCRYPTION_KEY is set but the binary is not linked
+// against SQLCipher.
+var ErrSQLCipherUnavailable = errors.New("SQLCipher is not available: build with -tags libsqlite3 " +
+	"and CGO_LDFLAGS=-lsqlcipher (libsqlcipher-dev) to encrypt SQLite databases")
+
+var (
+	sqlcipherDrivers   = make(map[string]string)
+	sqlcipherDriversMu sync.Mutex
+)
+
+// sqlcipherDriver returns the name of a database/sql driver that sets key on every new
+// connection, registering it the first time. Drivers cannot be unregistered, so one is kept
+// per key.
+func sqlcipherDriver(key string) string {
+	sqlcipherDriversMu.Lock()
+	defer sqlcipherDriversMu.Unlock()
+
+	if name, ok := sqlcipherDrivers[key]; ok {
+		return name
+	}
+	name := fmt.Sprintf("sqlcipher_%d", len(sqlcipherDrivers))
+	pragma := "PRAGMA key = '" + strings.ReplaceAll(key, "'", "''") + "'"
+	sql.Register(name, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			// The key must be set before anything else reads the database file.
+			_, err := conn.Exec(pragma, nil)
+			return err
+		},
+	})
+	sqlcipherDrivers[key] = name
+	return name
+}
+
+// OpenSQLCipher opens the SQLite database at dsn encrypted with key. New databases are
+// encrypted when first written; an existing plaintext database cannot be opened with a key and
+// has to be exported with sqlcipher_export first.
+func OpenSQLCipher(dsn, key string, gormCfg *gorm.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.New(sqlite.Config{DriverName: sqlcipherDriver(key), DSN: dsn}), gormCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plain SQLite silently ignores PRAGMA key, which would leave the file unencrypted.
+	var version string
+	if err := db.Raw("PRAGMA cipher_version").Scan(&version).Error; err != nil || version == "" {
+		CloseDB(db)
+		return nil, ErrSQLCipherUnavailable
+	}
+	return db, nil
+}
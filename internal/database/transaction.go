@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/pkg/events"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+// TxRetry configures how WithTransaction retries transactions that lose to a concurrent one.
+type TxRetry struct {
+	// MaxAttempts is the number of attempts including the first; 1 disables retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled on every retry with jitter.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+}
+
+// DefaultTxRetry returns the retry settings used until SetTxRetry is called.
+func DefaultTxRetry() TxRetry {
+	return TxRetry{MaxAttempts: 3, Backoff: 20 * time.Millisecond, MaxBackoff: time.Second}
+}
+
+var (
+	txRetry   = DefaultTxRetry()
+	txRetryMu sync.RWMutex
+)
+
+// SetTxRetry replaces the retry settings used by WithTransaction.
+func SetTxRetry(r TxRetry) {
+	txRetryMu.Lock()
+	defer txRetryMu.Unlock()
+	txRetry = r
+}
+
+// CurrentTxRetry returns the retry settings used by WithTransaction.
+func CurrentTxRetry() TxRetry {
+	txRetryMu.RLock()
+	defer txRetryMu.RUnlock()
+	return txRetry
+}
+
+var (
+	txRetriesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "db_transaction_retries_total",
+		Help: "Transactions retried after a deadlock or serialization failure.",
+	})
+	txRetriesExhaustedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "db_transaction_retries_exhausted_total",
+		Help: "Transactions that still failed with a deadlock or serialization failure on their last attempt.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(txRetriesTotal, txRetriesExhaustedTotal)
+}
+
+// WithTransaction runs fn in a transaction of db bound to ctx, and runs it again from the start
+// when it fails with a deadlock or serialization failure (see IsDeadlockError), up to
+// MaxAttempts times, waiting between attempts. fn must therefore have no side effects outside
+// tx, such as sending email. Any other error rolls back and is returned at once.
+//
+// Domain events published by fn with events.Bus.PublishAfter, such as those of the model hooks,
+// are delivered once the transaction commits, and dropped with the attempts that roll back.
+// So are the functions registered with events.After, such as cache invalidations.
+//
+// Inside another transaction there is no retry: the database aborted the outer transaction
+// too, so only its own WithTransaction can start over.
+func WithTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	retry := CurrentTxRetry()
+	_, nested := db.Statement.ConnPool.(gorm.TxCommitter)
+	if nested || retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+	if nested {
+		// Events are published when the outer transaction commits
+		ctx = events.Join(ctx, db.Statement.Context)
+	}
+
+	backoff := retry.Backoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, done := events.Defer(ctx)
+		err := db.WithContext(attemptCtx).Transaction(fn)
+		done(err == nil)
+		if err == nil || !IsDeadlockError(err) {
+			return err
+		}
+		if attempt >= retry.MaxAttempts {
+			if retry.MaxAttempts > 1 {
+				txRetriesExhaustedTotal.Inc()
+			}
+			return err
+		}
+
+		delay := backoff
+		if half := delay / 2; half > 0 {
+			delay = half + rand.N(half)
+		}
+		if retry.MaxBackoff > 0 && delay > retry.MaxBackoff {
+			delay = retry.MaxBackoff
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		txRetriesTotal.Inc()
+		backoff *= 2
+	}
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type txItem struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func openTxDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(&txItem{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func setTxRetry(t *testing.T, r TxRetry) {
+	t.Helper()
+	previous := CurrentTxRetry()
+	SetTxRetry(r)
+	t.Cleanup(func() { SetTxRetry(previous) })
+}
+
+var errDeadlock = &pgconn.PgError{Code: "40P01"}
+
+func TestWithTransactionRetriesDeadlocks(t *testing.T) {
+	db := openTxDB(t)
+	setTxRetry(t, TxRetry{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	retries := testutil.ToFloat64(txRetriesTotal)
+
+	attempts := 0
+	err := WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		attempts++
+		if err := tx.Create(&txItem{Name: "a"}).Error; err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return errDeadlock
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+	var count int64
+	db.Model(&txItem{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("expected the failed attempts to roll back, got %d rows", count)
+	}
+	if got := testutil.ToFloat64(txRetriesTotal) - retries; got != 2 {
+		t.Fatalf("expected 2 retries counted, got %v", got)
+	}
+}
+
+func TestWithTransactionGivesUp(t *testing.T) {
+	db := openTxDB(t)
+	setTxRetry(t, TxRetry{MaxAttempts: 2, Backoff: time.Millisecond})
+	exhausted := testutil.ToFloat64(txRetriesExhaustedTotal)
+
+	attempts := 0
+	err := WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		attempts++
+		return errDeadlock
+	})
+	if !errors.Is(err, errDeadlock) || attempts != 2 {
+		t.Fatalf("expected the deadlock after 2 attempts, got %v after %d", err, attempts)
+	}
+	if got := testutil.ToFloat64(txRetriesExhaustedTotal) - exhausted; got != 1 {
+		t.Fatalf("expected 1 exhausted retry counted, got %v", got)
+	}
+
+	// Other errors are not retried
+	attempts = 0
+	boom := errors.New("boom")
+	if err := WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		attempts++
+		return boom
+	}); !errors.Is(err, boom) || attempts != 1 {
+		t.Fatalf("expected boom after 1 attempt, got %v after %d", err, attempts)
+	}
+}
+
+func TestWithTransactionDoesNotRetryNestedTransactions(t *testing.T) {
+	db := openTxDB(t)
+	setTxRetry(t, TxRetry{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	inner := 0
+	err := WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		return WithTransaction(context.Background(), tx, func(*gorm.DB) error {
+			inner++
+			return errDeadlock
+		})
+	})
+	if !errors.Is(err, errDeadlock) {
+		t.Fatalf("expected the deadlock, got %v", err)
+	}
+	// The outer transaction retries, running the inner one once per attempt
+	if inner != 3 {
+		t.Fatalf("expected 3 inner attempts, got %d", inner)
+	}
+}
+
+func TestWithTransactionStopsWhenCanceled(t *testing.T) {
+	db := openTxDB(t)
+	setTxRetry(t, TxRetry{MaxAttempts: 5, Backoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	attempts := 0
+	err := WithTransaction(ctx, db, func(tx *gorm.DB) error {
+		attempts++
+		return errDeadlock
+	})
+	if !errors.Is(err, errDeadlock) || attempts != 1 {
+		t.Fatalf("expected the deadlock after 1 attempt, got %v after %d", err, attempts)
+	}
+}
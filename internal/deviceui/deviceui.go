@@ -0,0 +1,45 @@
+// Package deviceui serves the embedded verification page of the OAuth device flow, where
+// users sign in and approve the code shown by a CLI or another input-constrained client. Like
+// the admin dashboard it is a static client of the API with no privileges of its own.
+package deviceui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed static
+var static embed.FS
+
+// Register serves the page under prefix (e.g. "/device"); requests for prefix itself are
+// redirected to prefix + "/" with their query, which carries the user_code of
+// verification_uri_complete.
+func Register(router gin.IRouter, prefix string) {
+	prefix = "/" + strings.Trim(prefix, "/")
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // the embedded directory is part of the binary
+	}
+	fileServer := http.StripPrefix(prefix, http.FileServer(http.FS(files)))
+
+	serve := func(c *gin.Context) {
+		name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+		if name == "" || !exists(files, name) {
+			c.Request.URL.Path = prefix + "/"
+		}
+		c.Header("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	}
+	router.GET(prefix+"/*filepath", serve)
+	router.HEAD(prefix+"/*filepath", serve)
+}
+
+func exists(files fs.FS, name string) bool {
+	info, err := fs.Stat(files, name)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,49 @@
+package deviceui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterServesVerificationPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Register(r, "/device")
+
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/device/?user_code=BCDF-GHJK", "text/html", `<script src="app.js">`},
+		{"/device/app.js", "javascript", "/api/oauth/device/verify"},
+		{"/device/style.css", "text/css", "body"},
+		{"/device/../go.mod", "text/html", `<script src="app.js">`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", tt.path, w.Code)
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, tt.contentType) {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.path, ct, tt.contentType)
+		}
+		if !strings.Contains(w.Body.String(), tt.contains) {
+			t.Errorf("%s: body does not contain %q", tt.path, tt.contains)
+		}
+	}
+
+	// verification_uri_complete points at the bare path; the redirect keeps the code
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/device?user_code=BCDF-GHJK", nil))
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/device/?user_code=BCDF-GHJK" {
+		t.Errorf("/device: status = %d, Location = %q", w.Code, w.Header().Get("Location"))
+	}
+}
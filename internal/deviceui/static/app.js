@@ -0,0 +1,117 @@
+// Device verification page: signs in through /api/auth/login and approves or denies the code
+// shown by the device. The JWT is kept in sessionStorage so it is dropped with the tab.
+(function () {
+  'use strict';
+
+  const TOKEN_KEY = 'device_token';
+  const $ = (selector) => document.querySelector(selector);
+
+  let userCode = new URLSearchParams(location.search).get('user_code') || '';
+
+  async function api(path, options = {}) {
+    const headers = { 'Content-Type': 'application/json' };
+    const token = sessionStorage.getItem(TOKEN_KEY);
+    if (token) {
+      headers.Authorization = 'Bearer ' + token;
+    }
+    const res = await fetch(path, { ...options, headers });
+    let body = null;
+    try {
+      body = await res.json();
+    } catch (_) {
+      // Non-JSON responses carry no envelope.
+    }
+    if (res.status === 401 && token) {
+      sessionStorage.removeItem(TOKEN_KEY);
+      show('login');
+    }
+    return { status: res.status, body };
+  }
+
+  function errorMessage(result) {
+    const err = result.body && result.body.error;
+    return err ? err.message + (err.details ? ': ' + err.details : '') : 'Request failed with status ' + result.status;
+  }
+
+  function message(text, isError) {
+    const el = $('#message');
+    el.textContent = text;
+    el.className = isError ? 'error' : '';
+    el.hidden = !text;
+  }
+
+  function show(id) {
+    for (const section of ['login', 'code', 'confirm']) {
+      $('#' + section).hidden = section !== id;
+    }
+  }
+
+  async function lookup() {
+    if (!userCode) {
+      show('code');
+      return;
+    }
+    const result = await api('/api/oauth/device/verify?user_code=' + encodeURIComponent(userCode));
+    if (result.status !== 200) {
+      if (result.status !== 401) {
+        message(errorMessage(result), true);
+        show('code');
+      }
+      return;
+    }
+    const data = result.body.data;
+    $('#client').textContent = data.client_id;
+    $('#scope').textContent = data.scope ? ' (' + data.scope + ')' : '';
+    $('#user-code').textContent = data.user_code;
+    message('');
+    show('confirm');
+  }
+
+  async function verify(approve) {
+    const result = await api('/api/oauth/device/verify', {
+      method: 'POST',
+      body: JSON.stringify({ user_code: userCode, approve }),
+    });
+    if (result.status !== 200) {
+      message(errorMessage(result), true);
+      return;
+    }
+    show(null);
+    message(approve
+      ? 'Device approved. You can close this page and return to your device.'
+      : 'Request denied. The device was not connected.');
+  }
+
+  $('#login').addEventListener('submit', async (event) => {
+    event.preventDefault();
+    const form = new FormData(event.target);
+    const result = await api('/api/auth/login', {
+      method: 'POST',
+      body: JSON.stringify({ username: form.get('username'), password: form.get('password') }),
+    });
+    if (result.status !== 200) {
+      message(result.status === 202
+        ? 'This sign-in needs the verification code sent by email; complete it in the app, then reload this page.'
+        : errorMessage(result), true);
+      return;
+    }
+    sessionStorage.setItem(TOKEN_KEY, result.body.data.token);
+    message('');
+    lookup();
+  });
+
+  $('#code').addEventListener('submit', (event) => {
+    event.preventDefault();
+    userCode = new FormData(event.target).get('user_code').trim();
+    lookup();
+  });
+
+  $('#approve').addEventListener('click', () => verify(true));
+  $('#deny').addEventListener('click', () => verify(false));
+
+  if (sessionStorage.getItem(TOKEN_KEY)) {
+    lookup();
+  } else {
+    show('login');
+  }
+})();
@@ -0,0 +1,173 @@
+// Package entitlements resolves the plan of each user and what it entitles them to, whatever
+// the payment provider. A user's plan is, in order: the plan assigned by an administrator, the
+// plan of their active subscription, or the default plan. Entitlements are either features,
+// granted or not, or limits such as the maximum number of organizations; a plan that does not
+// list a key grants neither.
+package entitlements
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// Entitlement keys checked by the API. Plans may list any other key for handlers added later.
+const (
+	// MaxOrganizations limits the organizations a user owns.
+	MaxOrganizations = "max_organizations"
+)
+
+// Sources of a user's plan.
+const (
+	SourceAssigned     = "assigned"
+	SourceSubscription = "subscription"
+	SourceDefault      = "default"
+	SourceNone         = "none"
+)
+
+// Set is the plan of a user and its entitlements.
+type Set struct {
+	Plan   string `json:"plan"`
+	Source string `json:"source"`
+	// Entitlements maps each key to its limit, nil for features and unlimited resources.
+	Entitlements map[string]*int64 `json:"entitlements"`
+}
+
+// Allows reports whether the set grants key: a feature, or a limit above zero.
+func (s *Set) Allows(key string) bool {
+	limit, ok := s.Entitlements[key]
+	return ok && (limit == nil || *limit > 0)
+}
+
+// Limit returns the limit of key; unlimited is true for keys granted without one. Keys not
+// granted have a limit of 0.
+func (s *Set) Limit(key string) (limit int64, unlimited bool) {
+	value, ok := s.Entitlements[key]
+	if !ok {
+		return 0, false
+	}
+	if value == nil {
+		return 0, true
+	}
+	return *value, false
+}
+
+// Service resolves the entitlements of users.
+type Service struct {
+	db          *gorm.DB
+	defaultPlan string
+	now         func() time.Time
+}
+
+// NewService creates a service reading plans from db. defaultPlan is the plan of users without
+// an assigned plan or active subscription; empty leaves them without entitlements.
+func NewService(db *gorm.DB, defaultPlan string) *Service {
+	return &Service{db: db, defaultPlan: defaultPlan, now: time.Now}
+}
+
+// Resolve returns the plan of userID and its entitlements.
+func (s *Service) Resolve(ctx context.Context, userID uint) (*Set, error) {
+	db := s.db.WithContext(ctx)
+
+	var assigned models.UserPlan
+	err := db.Preload("Plan.Entitlements").
+		Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", userID, s.now()).
+		First(&assigned).Error
+	if err == nil {
+		return newSet(&assigned.Plan, SourceAssigned), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var subscription models.Subscription
+	err = db.Where("user_id = ? AND status IN ? AND plan <> ''", userID, models.ActiveSubscriptionStatuses).
+		Order("created_at DESC, id DESC").
+		First(&subscription).Error
+	if err == nil {
+		plan, err := s.plan(ctx, subscription.Plan)
+		if err != nil {
+			return nil, err
+		}
+		if plan != nil {
+			return newSet(plan, SourceSubscription), nil
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if s.defaultPlan != "" {
+		plan, err := s.plan(ctx, s.defaultPlan)
+		if err != nil {
+			return nil, err
+		}
+		if plan != nil {
+			return newSet(plan, SourceDefault), nil
+		}
+	}
+	return &Set{Source: SourceNone, Entitlements: map[string]*int64{}}, nil
+}
+
+// plan returns the plan named name with its entitlements, or nil when there is none.
+func (s *Service) plan(ctx context.Context, name string) (*models.Plan, error) {
+	var plan models.Plan
+	err := s.db.WithContext(ctx).Preload("Entitlements").Where("name = ?", name).First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func newSet(plan *models.Plan, source string) *Set {
+	set := &Set{Plan: plan.Name, Source: source, Entitlements: make(map[string]*int64, len(plan.Entitlements))}
+	for _, e := range plan.Entitlements {
+		set.Entitlements[e.Key] = e.Limit
+	}
+	return set
+}
+
+// Require returns a 402 PLAN_REQUIRED error unless the plan of userID grants the feature key.
+func (s *Service) Require(ctx context.Context, userID uint, key string) error {
+	set, err := s.Resolve(ctx, userID)
+	if err != nil {
+		return repository.TranslateError(err, "Could not verify plan")
+	}
+	if !set.Allows(key) {
+		return apperrors.New(response.CodePlanRequired, "Plan required").
+			WithDetails("The " + planName(set) + " plan does not include " + key)
+	}
+	return nil
+}
+
+// CheckLimit returns a 403 PLAN_LIMIT_REACHED error when userID already uses the limit of key
+// in their plan, used being how many they have. Call it before creating one more.
+func (s *Service) CheckLimit(ctx context.Context, userID uint, key string, used int64) error {
+	set, err := s.Resolve(ctx, userID)
+	if err != nil {
+		return repository.TranslateError(err, "Could not verify plan")
+	}
+	limit, unlimited := set.Limit(key)
+	if !unlimited && used >= limit {
+		return apperrors.New(response.CodePlanLimitReached, "Plan limit reached").
+			WithDetails("The " + planName(set) + " plan limits " + key + " to " + strconv.FormatInt(limit, 10))
+	}
+	return nil
+}
+
+func planName(set *Set) string {
+	if set.Plan == "" {
+		return "current"
+	}
+	return set.Plan
+}
@@ -0,0 +1,110 @@
+package entitlements
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/response"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func setupService(t *testing.T) (*Service, *gorm.DB) {
+	t.Helper()
+	db := testutil.NewDB(t)
+	return NewService(db, "free"), db
+}
+
+func limit(n int64) *int64 {
+	return &n
+}
+
+func createPlan(t *testing.T, db *gorm.DB, name string, entitlements ...models.Entitlement) models.Plan {
+	t.Helper()
+	plan := models.Plan{Name: name, Entitlements: entitlements}
+	if err := db.Create(&plan).Error; err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestResolvePlanPrecedence(t *testing.T) {
+	svc, db := setupService(t)
+	ctx := context.Background()
+
+	set, err := svc.Resolve(ctx, 1)
+	if err != nil || set.Source != SourceNone || set.Allows(MaxOrganizations) {
+		t.Fatalf("Resolve() without plans = %+v, %v", set, err)
+	}
+
+	createPlan(t, db, "free", models.Entitlement{Key: MaxOrganizations, Limit: limit(1)})
+	pro := createPlan(t, db, "pro", models.Entitlement{Key: MaxOrganizations, Limit: limit(10)}, models.Entitlement{Key: "feature.export"})
+	enterprise := createPlan(t, db, "enterprise", models.Entitlement{Key: MaxOrganizations})
+
+	tests := []struct {
+		name   string
+		setup  func()
+		plan   string
+		source string
+	}{
+		{"default plan", func() {}, "free", SourceDefault},
+		{"subscription of an unknown plan", func() {
+			db.Create(&models.Subscription{UserID: 1, CustomerID: "cus", SubscriptionID: "sub_1", Plan: "legacy", Status: "active"})
+		}, "free", SourceDefault},
+		{"active subscription", func() {
+			db.Create(&models.Subscription{UserID: 1, CustomerID: "cus", SubscriptionID: "sub_2", Plan: pro.Name, Status: "trialing"})
+		}, "pro", SourceSubscription},
+		{"expired assignment", func() {
+			expired := time.Now().Add(-time.Hour)
+			db.Create(&models.UserPlan{UserID: 1, PlanID: enterprise.ID, ExpiresAt: &expired})
+		}, "pro", SourceSubscription},
+		{"assignment", func() {
+			db.Where("user_id = ?", 1).Delete(&models.UserPlan{})
+			db.Create(&models.UserPlan{UserID: 1, PlanID: enterprise.ID})
+		}, "enterprise", SourceAssigned},
+	}
+	for _, tt := range tests {
+		tt.setup()
+		set, err := svc.Resolve(ctx, 1)
+		if err != nil || set.Plan != tt.plan || set.Source != tt.source {
+			t.Errorf("%s: Resolve() = %+v, %v, want plan %s from %s", tt.name, set, err, tt.plan, tt.source)
+		}
+	}
+}
+
+func TestRequireAndCheckLimit(t *testing.T) {
+	svc, db := setupService(t)
+	ctx := context.Background()
+	createPlan(t, db, "free", models.Entitlement{Key: MaxOrganizations, Limit: limit(2)}, models.Entitlement{Key: "feature.off", Limit: limit(0)})
+	unlimited := createPlan(t, db, "unlimited", models.Entitlement{Key: MaxOrganizations}, models.Entitlement{Key: "feature.export"})
+
+	if err := svc.CheckLimit(ctx, 1, MaxOrganizations, 1); err != nil {
+		t.Errorf("CheckLimit() under the limit = %v", err)
+	}
+	err := svc.CheckLimit(ctx, 1, MaxOrganizations, 2)
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Code != response.CodePlanLimitReached {
+		t.Errorf("CheckLimit() at the limit = %v, want PLAN_LIMIT_REACHED", err)
+	}
+	if err := svc.CheckLimit(ctx, 1, "max_projects", 0); err == nil {
+		t.Error("CheckLimit() of a key the plan does not list = nil, want an error")
+	}
+	for _, key := range []string{"feature.export", "feature.off"} {
+		if err := svc.Require(ctx, 1, key); !errors.As(err, &appErr) || appErr.Code != response.CodePlanRequired {
+			t.Errorf("Require(%s) = %v, want PLAN_REQUIRED", key, err)
+		}
+	}
+
+	db.Create(&models.UserPlan{UserID: 1, PlanID: unlimited.ID})
+	if err := svc.CheckLimit(ctx, 1, MaxOrganizations, 1000); err != nil {
+		t.Errorf("CheckLimit() of an unlimited entitlement = %v", err)
+	}
+	if err := svc.Require(ctx, 1, "feature.export"); err != nil {
+		t.Errorf("Require() of a granted feature = %v", err)
+	}
+}
@@ -0,0 +1,388 @@
+// Package generator scaffolds new API resources (model, repository and its mock, service,
+// handler, validator, routes, and tests) following the template's conventions.
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates/*.tmpl templates/sdk/*.tmpl
+var templateFS embed.FS
+
+// Markers are the comments in existing files above which generated registrations are inserted.
+const (
+	ModelsMarker = "// gen:models"
+	RoutesMarker = "// gen:routes"
+)
+
+var (
+	identRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+	fieldRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+	// ErrExists is returned when a generated file already exists and Force is not set.
+	ErrExists = errors.New("file already exists")
+)
+
+// fieldTypes maps the types accepted in a field spec to their Go type and GORM tag.
+var fieldTypes = map[string]struct{ goType, gormTag string }{
+	"string":  {"string", "size:255;not null"},
+	"text":    {"string", "type:text"},
+	"int":     {"int", "not null;default:0"},
+	"int64":   {"int64", "not null;default:0"},
+	"uint":    {"uint", "not null;default:0"},
+	"float64": {"float64", "not null;default:0"},
+	"bool":    {"bool", "not null;default:false"},
+	"time":    {"*time.Time", ""},
+}
+
+// Field is a column of a generated resource.
+type Field struct {
+	// Name is the Go field name, e.g. PublishedAt.
+	Name string
+	// JSON is the snake_case JSON key and column name, e.g. published_at.
+	JSON string
+	// Type is the type from the field spec, e.g. time.
+	Type string
+	// GoType is the Go type of the model field, e.g. *time.Time.
+	GoType string
+	// GormTag is the gorm struct tag value.
+	GormTag string
+}
+
+// Required reports whether the validator should reject an empty value.
+func (f Field) Required() bool {
+	return f.Type == "string"
+}
+
+// Resource describes the resource to generate.
+type Resource struct {
+	// Module is the Go module path read from go.mod.
+	Module string
+	// Name is the singular PascalCase name, e.g. BlogPost.
+	Name string
+	// Fields are the resource columns besides ID and timestamps.
+	Fields []Field
+}
+
+// Plural returns the PascalCase plural name, e.g. BlogPosts.
+func (r Resource) Plural() string { return pluralize(r.Name) }
+
+// Var returns the lowerCamelCase singular name, e.g. blogPost.
+func (r Resource) Var() string { return lowerFirst(r.Name) }
+
+// PluralVar returns the lowerCamelCase plural name, e.g. blogPosts.
+func (r Resource) PluralVar() string { return lowerFirst(r.Plural()) }
+
+// Human returns the lowercase singular name used in messages, e.g. blog post.
+func (r Resource) Human() string { return strings.ReplaceAll(r.Snake(), "_", " ") }
+
+// HumanPlural returns the lowercase plural name used in messages, e.g. blog posts.
+func (r Resource) HumanPlural() string { return strings.ReplaceAll(r.Table(), "_", " ") }
+
+// Title returns the capitalized singular name used in messages, e.g. Blog post.
+func (r Resource) Title() string { return upperFirst(r.Human()) }
+
+// TitlePlural returns the capitalized plural name used in messages, e.g. Blog posts.
+func (r Resource) TitlePlural() string { return upperFirst(r.HumanPlural()) }
+
+// Snake returns the snake_case singular name used for file names, e.g. blog_post.
+func (r Resource) Snake() string { return toSnake(r.Name) }
+
+// Table returns the table name and URL segment, e.g. blog_posts.
+func (r Resource) Table() string { return toSnake(r.Plural()) }
+
+// Path returns the URL path segment, e.g. blog-posts.
+func (r Resource) Path() string { return strings.ReplaceAll(r.Table(), "_", "-") }
+
+// HasTime reports whether any field needs the time package.
+func (r Resource) HasTime() bool {
+	for _, f := range r.Fields {
+		if f.Type == "time" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRequired reports whether any field is validated as required.
+func (r Resource) HasRequired() bool {
+	for _, f := range r.Fields {
+		if f.Required() {
+			return true
+		}
+	}
+	return false
+}
+
+// SampleJSON returns a JSON object with a valid value for every field, used by generated tests.
+func (r Resource) SampleJSON() string {
+	parts := make([]string, 0, len(r.Fields))
+	for _, f := range r.Fields {
+		var value string
+		switch f.Type {
+		case "string", "text":
+			value = `"sample ` + f.JSON + `"`
+		case "bool":
+			value = "true"
+		case "time":
+			value = `"2026-01-02T15:04:05Z"`
+		default:
+			value = "1"
+		}
+		parts = append(parts, fmt.Sprintf("%q: %s", f.JSON, value))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// NewResource validates name and parses a field spec such as "title:string,price:float64".
+func NewResource(module, name, fieldSpec string) (Resource, error) {
+	if !identRegex.MatchString(name) {
+		return Resource{}, fmt.Errorf("invalid resource name %q: use letters and digits, e.g. BlogPost", name)
+	}
+	res := Resource{Module: module, Name: upperFirst(name)}
+
+	seen := make(map[string]bool)
+	for _, spec := range strings.Split(fieldSpec, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		name, typ, ok := strings.Cut(spec, ":")
+		if !ok {
+			typ = "string"
+		}
+		name, typ = strings.TrimSpace(name), strings.TrimSpace(typ)
+
+		if !fieldRegex.MatchString(name) {
+			return Resource{}, fmt.Errorf("invalid field name %q: use snake_case, e.g. published_at", name)
+		}
+		switch name {
+		case "id", "created_at", "updated_at", "deleted_at":
+			return Resource{}, fmt.Errorf("field %q is added automatically", name)
+		}
+		if seen[name] {
+			return Resource{}, fmt.Errorf("duplicate field %q", name)
+		}
+		seen[name] = true
+
+		t, ok := fieldTypes[typ]
+		if !ok {
+			return Resource{}, fmt.Errorf("unsupported type %q for field %q (supported: string, text, int, int64, uint, float64, bool, time)", typ, name)
+		}
+		res.Fields = append(res.Fields, Field{
+			Name:    toPascal(name),
+			JSON:    name,
+			Type:    typ,
+			GoType:  t.goType,
+			GormTag: t.gormTag,
+		})
+	}
+
+	if len(res.Fields) == 0 {
+		return Resource{}, errors.New("at least one field is required, e.g. --fields \"title:string\"")
+	}
+	return res, nil
+}
+
+// ModulePath reads the module path from the go.mod file in root.
+func ModulePath(root string) (string, error) {
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if module, ok := strings.CutPrefix(line, "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("go.mod has no module directive")
+}
+
+// file is a generated file: the template to render and its path relative to the root.
+type file struct {
+	template string
+	path     string
+	// optional files are skipped instead of failing when they already exist.
+	optional bool
+}
+
+func (r Resource) files() []file {
+	return []file{
+		{"model.go.tmpl", filepath.Join("internal", "models", r.Snake()+".go"), false},
+		{"repository.go.tmpl", filepath.Join("internal", "repository", r.Snake()+"_repository.go"), false},
+		{"mock_repository.go.tmpl", filepath.Join("internal", "mocks", r.Snake()+"_repository.go"), false},
+		{"service_doc.go.tmpl", filepath.Join("internal", "services", "doc.go"), true},
+		{"service.go.tmpl", filepath.Join("internal", "services", r.Snake()+"_service.go"), false},
+		{"validator.go.tmpl", filepath.Join("internal", "validators", r.Snake()+".go"), false},
+		{"handler.go.tmpl", filepath.Join("internal", "handlers", r.Snake()+"_handler.go"), false},
+		{"handler_test.go.tmpl", filepath.Join("internal", "handlers", r.Snake()+"_handler_test.go"), false},
+		{"routes.go.tmpl", filepath.Join("internal", "routes", r.Snake()+".go"), false},
+	}
+}
+
+// Generate writes the files for res under root and registers the model and routes at
+// ModelsMarker and RoutesMarker. Existing files are only overwritten when force is true.
+// It returns the paths written or modified, relative to root.
+func Generate(root string, res Resource, force bool) ([]string, error) {
+	tmpl, err := template.ParseFS(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	// Render everything first so a template error leaves the tree untouched.
+	rendered := make(map[string][]byte)
+	var order []string
+	for _, f := range res.files() {
+		target := filepath.Join(root, f.path)
+		if _, err := os.Stat(target); err == nil {
+			if f.optional {
+				continue
+			}
+			if !force {
+				return nil, fmt.Errorf("%s: %w (use --force to overwrite)", f.path, ErrExists)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, f.template, res); err != nil {
+			return nil, fmt.Errorf("render %s: %w", f.template, err)
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("format %s: %w", f.path, err)
+		}
+		rendered[f.path] = src
+		order = append(order, f.path)
+	}
+
+	for _, path := range order {
+		target := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, rendered[path], 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	registrations := []struct{ path, marker, line string }{
+		{filepath.Join("internal", "models", "models.go"), ModelsMarker, "&" + res.Name + "{},"},
+		{filepath.Join("internal", "routes", "routes.go"), RoutesMarker, "register" + res.Plural() + "Routes(api, db)"},
+	}
+	for _, reg := range registrations {
+		changed, err := insertAtMarker(filepath.Join(root, reg.path), reg.marker, reg.line)
+		if err != nil {
+			return order, err
+		}
+		if changed {
+			order = append(order, reg.path)
+		}
+	}
+
+	return order, nil
+}
+
+// insertAtMarker adds line above the marker comment, with the marker's indentation, unless
+// the file already contains it. Keeping the marker last preserves the generation order.
+func insertAtMarker(path, marker, line string) (bool, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	lines := strings.Split(string(src), "\n")
+	for _, l := range lines {
+		if strings.TrimSpace(l) == line {
+			return false, nil
+		}
+	}
+
+	for i, l := range lines {
+		if strings.TrimSpace(l) != marker {
+			continue
+		}
+		indent := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+		lines = append(lines[:i], append([]string{indent + line}, lines[i:]...)...)
+		return true, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644)
+	}
+	return false, fmt.Errorf("%s: marker %q not found", path, marker)
+}
+
+func toPascal(snake string) string {
+	parts := strings.Split(snake, "_")
+	for i, p := range parts {
+		switch p {
+		case "id", "url", "api", "http", "ip", "uuid":
+			parts[i] = strings.ToUpper(p)
+		default:
+			parts[i] = upperFirst(p)
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func toSnake(pascal string) string {
+	var b strings.Builder
+	runes := []rune(pascal)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word at a lower-to-upper boundary or at the end of an acronym.
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func pluralize(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasSuffix(lower, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	}
+	return s + "s"
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
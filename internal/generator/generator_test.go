@@ -0,0 +1,110 @@
+package generator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewResource_ParsesFields(t *testing.T) {
+	res, err := NewResource("example.com/app", "blogPost", "title:string, published_at:time,author_id:uint")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Name != "BlogPost" || res.Table() != "blog_posts" || res.Path() != "blog-posts" || res.Human() != "blog post" {
+		t.Fatalf("unexpected names: %s %s %s %s", res.Name, res.Table(), res.Path(), res.Human())
+	}
+	if len(res.Fields) != 3 || res.Fields[1].Name != "PublishedAt" || res.Fields[1].GoType != "*time.Time" || res.Fields[2].Name != "AuthorID" {
+		t.Fatalf("unexpected fields: %+v", res.Fields)
+	}
+}
+
+func TestNewResource_RejectsInvalidInput(t *testing.T) {
+	tests := []struct{ name, fields string }{
+		{"bad-name", "title:string"},
+		{"Post", ""},
+		{"Post", "Title:string"},
+		{"Post", "title:decimal"},
+		{"Post", "id:uint"},
+		{"Post", "title,title"},
+	}
+	for _, tt := range tests {
+		if _, err := NewResource("example.com/app", tt.name, tt.fields); err == nil {
+			t.Errorf("NewResource(%q, %q) succeeded, want error", tt.name, tt.fields)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	for in, want := range map[string]string{"Post": "Posts", "Category": "Categories", "Day": "Days", "Box": "Boxes", "Address": "Addresses"} {
+		if got := pluralize(in); got != want {
+			t.Errorf("pluralize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGenerate_WritesFilesAndRegistrations(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/app\n\ngo 1.24\n")
+	writeFile(t, root, "internal/models/models.go", "package models\n\nfunc All() []interface{} {\n\treturn []interface{}{\n\t\t// gen:models\n\t}\n}\n")
+	writeFile(t, root, "internal/routes/routes.go", "package routes\n\nfunc register() {\n\t// gen:routes\n}\n")
+
+	module, err := ModulePath(root)
+	if err != nil || module != "example.com/app" {
+		t.Fatalf("ModulePath = %q, %v", module, err)
+	}
+
+	res, err := NewResource(module, "Post", "title:string")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written, err := Generate(root, res, false)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(written) != 11 {
+		t.Fatalf("expected 11 files written, got %d: %v", len(written), written)
+	}
+
+	handler := readFile(t, root, "internal/handlers/post_handler.go")
+	if !strings.Contains(handler, `"example.com/app/internal/services"`) {
+		t.Fatalf("generated handler does not import the module's packages:\n%s", handler)
+	}
+	if models := readFile(t, root, "internal/models/models.go"); !strings.Contains(models, "&Post{},\n\t\t// gen:models") {
+		t.Fatalf("model not registered above the marker:\n%s", models)
+	}
+
+	// Existing files are protected, and registrations are not duplicated on --force.
+	if _, err := Generate(root, res, false); !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if _, err := Generate(root, res, true); err != nil {
+		t.Fatalf("forced Generate failed: %v", err)
+	}
+	if routes := readFile(t, root, "internal/routes/routes.go"); strings.Count(routes, "registerPostsRoutes(api, db)") != 1 {
+		t.Fatalf("routes registered more than once:\n%s", routes)
+	}
+}
+
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	target := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, root, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
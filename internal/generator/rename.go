@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TemplateAppName is the application name shipped with the template.
+const TemplateAppName = "GinAPI"
+
+// templateDisplayName is the human readable name shipped with the template.
+const templateDisplayName = "Gin Template API"
+
+var modulePathRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._~-]*(/[A-Za-z0-9._~-]+)*$`)
+
+// textExtensions and textNames select the files the renamer rewrites.
+var (
+	textExtensions = map[string]bool{
+		".go": true, ".mod": true, ".md": true, ".yaml": true, ".yml": true, ".sh": true,
+		".json": true, ".toml": true, ".txt": true, ".tmpl": true, ".example": true,
+	}
+	textNames = map[string]bool{
+		"Makefile": true, "Dockerfile": true, ".dockerignore": true, ".gitignore": true,
+	}
+	skipDirs = map[string]bool{
+		".git": true, "vendor": true, "node_modules": true, "data": true, "bin": true,
+	}
+	// selfDir holds the renamer itself, whose constants and tests name the template on purpose.
+	selfDir = filepath.Join("internal", "generator")
+)
+
+// RenameOptions configures Rename.
+type RenameOptions struct {
+	// Module is the new Go module path, e.g. github.com/acme/shop.
+	Module string
+	// AppName replaces the template's application name when set.
+	AppName string
+	// DryRun reports the files that would change without writing them.
+	DryRun bool
+}
+
+// Rename rewrites the module path (go.mod and every import) and, optionally, the application
+// name throughout the project in root. It returns the paths changed, relative to root.
+func Rename(root string, opts RenameOptions) ([]string, error) {
+	if !modulePathRegex.MatchString(opts.Module) {
+		return nil, fmt.Errorf("invalid module path %q, e.g. github.com/acme/shop", opts.Module)
+	}
+
+	oldModule, err := ModulePath(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var replacements []string
+	if oldModule != opts.Module {
+		replacements = append(replacements, oldModule, opts.Module)
+	}
+	if opts.AppName != "" {
+		replacements = append(replacements, templateDisplayName, opts.AppName, TemplateAppName, opts.AppName)
+	}
+	if len(replacements) == 0 {
+		return nil, nil
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	var changed []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			if path != root && (skipDirs[d.Name()] || rel == selfDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || (!textExtensions[filepath.Ext(path)] && !textNames[d.Name()]) {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(src, 0) >= 0 {
+			return nil
+		}
+
+		out := replacer.Replace(string(src))
+		if out == string(src) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, rel)
+		if opts.DryRun {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(out), info.Mode().Perm())
+	})
+	return changed, err
+}
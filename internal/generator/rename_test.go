@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRename_RewritesModuleAndAppName(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module github.com/yeferson59/gin-template\n\ngo 1.24\n")
+	writeFile(t, root, "cmd/api/main.go", "package main\n\nimport _ \"github.com/yeferson59/gin-template/internal/config\"\n")
+	writeFile(t, root, ".env.example", "APP_NAME=GinAPI\n")
+	writeFile(t, root, "README.md", "# Gin Template API\n")
+	writeFile(t, root, ".git/config", "github.com/yeferson59/gin-template\n")
+	writeFile(t, root, "logo.png", "github.com/yeferson59/gin-template\x00")
+
+	changed, err := Rename(root, RenameOptions{Module: "github.com/acme/shop", AppName: "ShopAPI"})
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if len(changed) != 4 {
+		t.Fatalf("expected 4 changed files, got %v", changed)
+	}
+
+	if got := readFile(t, root, "go.mod"); !strings.HasPrefix(got, "module github.com/acme/shop\n") {
+		t.Fatalf("go.mod not rewritten:\n%s", got)
+	}
+	if got := readFile(t, root, "cmd/api/main.go"); !strings.Contains(got, `"github.com/acme/shop/internal/config"`) {
+		t.Fatalf("import not rewritten:\n%s", got)
+	}
+	if got := readFile(t, root, ".env.example"); got != "APP_NAME=ShopAPI\n" {
+		t.Fatalf("app name not rewritten: %q", got)
+	}
+	if got := readFile(t, root, ".git/config"); !strings.Contains(got, "yeferson59") {
+		t.Fatal(".git must not be rewritten")
+	}
+	if got := readFile(t, root, "logo.png"); !strings.Contains(got, "yeferson59") {
+		t.Fatal("binary files must not be rewritten")
+	}
+}
+
+func TestRename_DryRunAndValidation(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module github.com/yeferson59/gin-template\n")
+
+	changed, err := Rename(root, RenameOptions{Module: "github.com/acme/shop", DryRun: true})
+	if err != nil || len(changed) != 1 {
+		t.Fatalf("dry run = %v, %v", changed, err)
+	}
+	if got := readFile(t, root, "go.mod"); !strings.Contains(got, "yeferson59") {
+		t.Fatal("dry run must not write files")
+	}
+
+	if _, err := Rename(root, RenameOptions{Module: "Not A Module"}); err == nil {
+		t.Fatal("expected invalid module path to be rejected")
+	}
+}
@@ -0,0 +1,405 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SDK languages accepted by GenerateSDK.
+const (
+	SDKGo         = "go"
+	SDKTypeScript = "typescript"
+)
+
+// SDKLanguages lists the client languages GenerateSDK can write.
+var SDKLanguages = []string{SDKGo, SDKTypeScript}
+
+var tsIdentRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// SDK describes the clients generated from an OpenAPI specification.
+type SDK struct {
+	// Title and Version come from the info of the specification.
+	Title   string
+	Version string
+	// Operations are sorted by path, then method.
+	Operations []SDKOperation
+	// Types are the request bodies and the objects nested in them, in order of appearance.
+	Types []SDKType
+}
+
+// SDKOperation is an operation of the API, called by one client method.
+type SDKOperation struct {
+	// Name is the PascalCase method name derived from the method and path, e.g.
+	// PatchOrgsByOrgMembersByUserID for PATCH /api/orgs/{org}/members/{user_id}.
+	Name string
+	// Method and Path are the HTTP method and OpenAPI path, e.g. /api/orgs/{org}.
+	Method string
+	Path   string
+	// Description is the description of the operation.
+	Description string
+	// Params are the path parameters, in order.
+	Params []SDKParam
+	// Body is "json", "form" or "" for operations without a request body.
+	Body string
+	// ContentType is the media type of a JSON body.
+	ContentType string
+	// GoBody and TSBody are the type of the body argument.
+	GoBody string
+	TSBody string
+	// Auth reports whether the operation requires a bearer token.
+	Auth bool
+}
+
+// SDKParam is a path parameter.
+type SDKParam struct {
+	// Name is the OpenAPI name, e.g. user_id.
+	Name string
+	// Ident is the argument name, e.g. userID.
+	Ident string
+}
+
+// SDKType is an object schema, generated as a Go struct and a TypeScript interface.
+type SDKType struct {
+	Name   string
+	Fields []SDKField
+	// Operation is the name of the operation whose body is, or contains, the type.
+	Operation string
+}
+
+// Body reports whether the type is the whole body of its operation rather than part of it.
+func (t SDKType) Body() bool { return t.Name == t.Operation+"Request" }
+
+// SDKField is a property of an SDKType.
+type SDKField struct {
+	// Name is the Go field name and JSON the property name.
+	Name string
+	JSON string
+	// GoType and TSType are the type of the field in each language. Optional and nullable
+	// Go fields are pointers, except slices and maps.
+	GoType string
+	TSType string
+	// Optional fields may be omitted from the body.
+	Optional bool
+}
+
+// GoName returns the Go method name.
+func (o SDKOperation) GoName() string { return o.Name }
+
+// TSName returns the lowerCamelCase TypeScript method name.
+func (o SDKOperation) TSName() string { return lowerFirst(o.Name) }
+
+// GoPath returns the Go expression of the path, with the parameters escaped.
+func (o SDKOperation) GoPath() string {
+	expr := strconv.Quote(o.Path)
+	for _, p := range o.Params {
+		expr = strings.Replace(expr, "{"+p.Name+"}", `" + url.PathEscape(`+p.Ident+`) + "`, 1)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(expr, `"" + `), ` + ""`)
+}
+
+// TSPath returns the TypeScript template literal of the path, with the parameters escaped.
+func (o SDKOperation) TSPath() string {
+	path := o.Path
+	for _, p := range o.Params {
+		path = strings.Replace(path, "{"+p.Name+"}", "${encodeURIComponent("+p.Ident+")}", 1)
+	}
+	return "`" + path + "`"
+}
+
+// TSKey returns the property name as written in a TypeScript interface.
+func (f SDKField) TSKey() string {
+	if tsIdentRegex.MatchString(f.JSON) {
+		return f.JSON
+	}
+	return strconv.Quote(f.JSON)
+}
+
+// NeedsURL reports whether the Go client escapes path parameters or encodes forms.
+func (s SDK) NeedsURL() bool {
+	for _, op := range s.Operations {
+		if len(op.Params) > 0 || op.Body == "form" {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSDK describes the clients of spec: one method per operation, with typed path parameters
+// and request bodies. Responses are the API envelope, whose data the caller decodes.
+func NewSDK(spec *openapi3.T) (SDK, error) {
+	sdk := SDK{Title: "API", Version: "1.0.0"}
+	if spec.Info != nil {
+		sdk.Title, sdk.Version = spec.Info.Title, spec.Info.Version
+	}
+	if spec.Paths == nil {
+		return sdk, nil
+	}
+
+	paths := spec.Paths.Keys()
+	sort.Strings(paths)
+	names := make(map[string]string)
+	for _, path := range paths {
+		item := spec.Paths.Value(path)
+		methods := make([]string, 0, len(item.Operations()))
+		for method := range item.Operations() {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			op, err := sdk.operation(method, path, item.GetOperation(method))
+			if err != nil {
+				return SDK{}, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			if other, ok := names[op.Name]; ok {
+				return SDK{}, fmt.Errorf("%s %s and %s are both named %s", method, path, other, op.Name)
+			}
+			names[op.Name] = method + " " + path
+			sdk.Operations = append(sdk.Operations, op)
+		}
+	}
+	return sdk, nil
+}
+
+// operation describes one operation and adds the types of its body to s.
+func (s *SDK) operation(method, path string, op *openapi3.Operation) (SDKOperation, error) {
+	out := SDKOperation{
+		Name:        operationName(method, path),
+		Method:      method,
+		Path:        path,
+		Description: strings.TrimSpace(op.Description),
+		Auth:        op.Security != nil && len(*op.Security) > 0,
+	}
+	for _, name := range pathParamNames(path) {
+		out.Params = append(out.Params, SDKParam{Name: name, Ident: paramIdent(name)})
+	}
+
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return out, nil
+	}
+	content := op.RequestBody.Value.Content
+	if media := content.Get("application/json"); media != nil && media.Schema != nil {
+		out.Body, out.ContentType = "json", "application/json"
+		first := len(s.Types)
+		goType, tsType, err := s.schemaType(out.Name+"Request", media.Schema.Value)
+		if err != nil {
+			return out, err
+		}
+		for i := first; i < len(s.Types); i++ {
+			s.Types[i].Operation = out.Name
+		}
+		out.GoBody, out.TSBody = goType, tsType
+		return out, nil
+	}
+	// Forms are sent URL-encoded, which the handlers bind as well as multipart ones
+	if content.Get("application/x-www-form-urlencoded") != nil || content.Get("multipart/form-data") != nil {
+		out.Body, out.GoBody, out.TSBody = "form", "url.Values", "Record<string, string>"
+	}
+	return out, nil
+}
+
+// schemaType returns the Go and TypeScript types of schema, adding a type named name for
+// objects with properties.
+func (s *SDK) schemaType(name string, schema *openapi3.Schema) (string, string, error) {
+	if schema == nil || schema.Type == nil {
+		return "interface{}", "unknown", nil
+	}
+	switch {
+	case schema.Type.Is(openapi3.TypeString):
+		if len(schema.Enum) > 0 {
+			values := make([]string, 0, len(schema.Enum))
+			for _, v := range schema.Enum {
+				values = append(values, strconv.Quote(fmt.Sprint(v)))
+			}
+			return "string", strings.Join(values, " | "), nil
+		}
+		return "string", "string", nil
+	case schema.Type.Is(openapi3.TypeInteger):
+		return "int64", "number", nil
+	case schema.Type.Is(openapi3.TypeNumber):
+		return "float64", "number", nil
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return "bool", "boolean", nil
+	case schema.Type.Is(openapi3.TypeArray):
+		var items *openapi3.Schema
+		if schema.Items != nil {
+			items = schema.Items.Value
+		}
+		goType, tsType, err := s.schemaType(name, items)
+		if err != nil {
+			return "", "", err
+		}
+		if strings.Contains(tsType, " ") {
+			tsType = "(" + tsType + ")"
+		}
+		return "[]" + goType, tsType + "[]", nil
+	case schema.Type.Is(openapi3.TypeObject):
+		if len(schema.Properties) == 0 {
+			return "map[string]interface{}", "Record<string, unknown>", nil
+		}
+		return name, name, s.addType(name, schema)
+	}
+	return "interface{}", "unknown", nil
+}
+
+// addType adds the object schema named name and the objects nested in it.
+func (s *SDK) addType(name string, schema *openapi3.Schema) error {
+	for _, t := range s.Types {
+		if t.Name == name {
+			return fmt.Errorf("type %s is generated twice", name)
+		}
+	}
+	index := len(s.Types)
+	s.Types = append(s.Types, SDKType{Name: name})
+
+	properties := make([]string, 0, len(schema.Properties))
+	for property := range schema.Properties {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+	required := make(map[string]bool, len(schema.Required))
+	for _, property := range schema.Required {
+		required[property] = true
+	}
+
+	fields := make([]SDKField, 0, len(properties))
+	for _, property := range properties {
+		prop := schema.Properties[property].Value
+		fieldName := toPascal(strings.ReplaceAll(property, "-", "_"))
+		goType, tsType, err := s.schemaType(name+fieldName, prop)
+		if err != nil {
+			return err
+		}
+		nullable := prop != nil && prop.PermitsNull()
+		optional := !required[property]
+		if (optional || nullable) && !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") && goType != "interface{}" {
+			goType = "*" + goType
+		}
+		if nullable {
+			tsType += " | null"
+		}
+		fields = append(fields, SDKField{Name: fieldName, JSON: property, GoType: goType, TSType: tsType, Optional: optional})
+	}
+	s.Types[index].Fields = fields
+	return nil
+}
+
+// operationName returns the method name of an operation: the HTTP method followed by the
+// segments of the path after /api, with parameters prefixed by "By".
+func operationName(method, path string) string {
+	name := upperFirst(strings.ToLower(method))
+	for i, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		switch {
+		case segment == "" || (i == 0 && segment == "api"):
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			name += "By" + toPascal(strings.Trim(segment, "{}"))
+		default:
+			name += toPascal(strings.NewReplacer("-", "_", ".", "_").Replace(segment))
+		}
+	}
+	return name
+}
+
+// pathParamNames returns the names of the parameters of an OpenAPI path, in order.
+func pathParamNames(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.Trim(segment, "{}"))
+		}
+	}
+	return names
+}
+
+// paramIdent returns the argument name of a path parameter, e.g. userID for user_id.
+func paramIdent(name string) string {
+	pascal := toPascal(name)
+	ident := lowerFirst(pascal)
+	for _, acronym := range []string{"ID", "URL", "API", "HTTP", "IP", "UUID"} {
+		if strings.HasPrefix(pascal, acronym) {
+			ident = strings.ToLower(acronym) + pascal[len(acronym):]
+			break
+		}
+	}
+	switch {
+	case token.IsKeyword(ident), ident == "ctx", ident == "body", ident == "c":
+		return ident + "Param"
+	}
+	return ident
+}
+
+// sdkFile is a generated client file.
+type sdkFile struct {
+	template string
+	path     string
+	gofmt    bool
+}
+
+var sdkFiles = map[string]sdkFile{
+	SDKGo:         {"client.go.tmpl", filepath.Join("go", "client.go"), true},
+	SDKTypeScript: {"client.ts.tmpl", filepath.Join("typescript", "client.ts"), false},
+}
+
+// GenerateSDK writes the clients of sdk in languages under dir, replacing the previous ones,
+// and returns the paths written. The Go client is the package client.
+func GenerateSDK(dir string, sdk SDK, languages []string) ([]string, error) {
+	tmpl, err := template.New("").Funcs(template.FuncMap{"method": methodConstant}).
+		ParseFS(templateFS, "templates/sdk/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	// Render everything first so a template error leaves the clients untouched.
+	rendered := make(map[string][]byte)
+	var order []string
+	for _, lang := range languages {
+		f, ok := sdkFiles[lang]
+		if !ok {
+			return nil, fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(SDKLanguages, ", "))
+		}
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, f.template, sdk); err != nil {
+			return nil, fmt.Errorf("render %s: %w", f.template, err)
+		}
+		src := buf.Bytes()
+		if f.gofmt {
+			if src, err = format.Source(src); err != nil {
+				return nil, fmt.Errorf("format %s: %w", f.path, err)
+			}
+		}
+		path := filepath.Join(dir, f.path)
+		rendered[path] = src
+		order = append(order, path)
+	}
+
+	for _, path := range order {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, rendered[path], 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// methodConstant returns the net/http constant of an HTTP method, e.g. http.MethodGet.
+func methodConstant(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead, http.MethodOptions:
+		return "http.Method" + upperFirst(strings.ToLower(method))
+	}
+	return strconv.Quote(method)
+}
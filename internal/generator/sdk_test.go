@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func sdkSpec() *openapi3.T {
+	spec := &openapi3.T{OpenAPI: "3.0.3", Info: &openapi3.Info{Title: "Shop", Version: "2.0.0"}, Paths: openapi3.NewPaths()}
+
+	member := openapi3.NewObjectSchema().
+		WithProperty("role", openapi3.NewStringSchema().WithEnum("admin", "member")).
+		WithProperty("user_id", openapi3.NewIntegerSchema())
+	member.Required = []string{"user_id"}
+	create := openapi3.NewObjectSchema().
+		WithProperty("name", openapi3.NewStringSchema()).
+		WithProperty("members", openapi3.NewArraySchema().WithItems(member)).
+		WithProperty("settings", openapi3.NewObjectSchema().WithAnyAdditionalProperties())
+	create.Required = []string{"name"}
+	post := openapi3.NewOperation()
+	post.Description = "Creates an organization."
+	post.Security = openapi3.NewSecurityRequirements().With(openapi3.NewSecurityRequirement().Authenticate("bearerAuth"))
+	post.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithJSONSchema(create)}
+	spec.AddOperation("/api/orgs", "POST", post)
+
+	spec.AddOperation("/api/orgs/{org}/members/{user_id}", "DELETE", openapi3.NewOperation())
+
+	form := openapi3.NewOperation()
+	form.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+		WithFormDataSchema(openapi3.NewObjectSchema().WithProperty("code", openapi3.NewStringSchema()))}
+	spec.AddOperation("/api/oauth/token", "POST", form)
+	return spec
+}
+
+func TestNewSDK(t *testing.T) {
+	sdk, err := NewSDK(sdkSpec())
+	if err != nil {
+		t.Fatalf("NewSDK() error = %v", err)
+	}
+
+	var names []string
+	for _, op := range sdk.Operations {
+		names = append(names, op.Name)
+	}
+	if got := strings.Join(names, ","); got != "PostOauthToken,PostOrgs,DeleteOrgsByOrgMembersByUserID" {
+		t.Fatalf("operations = %s", got)
+	}
+
+	remove := sdk.Operations[2]
+	if len(remove.Params) != 2 || remove.Params[1].Ident != "userID" || remove.Body != "" {
+		t.Errorf("DELETE operation = %+v", remove)
+	}
+	if got := remove.GoPath(); got != `"/api/orgs/" + url.PathEscape(org) + "/members/" + url.PathEscape(userID)` {
+		t.Errorf("GoPath() = %s", got)
+	}
+	if form := sdk.Operations[0]; form.Body != "form" || form.GoBody != "url.Values" {
+		t.Errorf("form operation = %+v", form)
+	}
+	if create := sdk.Operations[1]; !create.Auth || create.GoBody != "PostOrgsRequest" {
+		t.Errorf("POST operation = %+v", create)
+	}
+
+	if len(sdk.Types) != 2 || sdk.Types[0].Name != "PostOrgsRequest" || sdk.Types[1].Name != "PostOrgsRequestMembers" {
+		t.Fatalf("types = %+v", sdk.Types)
+	}
+	fields := map[string]SDKField{}
+	for _, f := range append(sdk.Types[0].Fields, sdk.Types[1].Fields...) {
+		fields[f.JSON] = f
+	}
+	for json, want := range map[string][2]string{
+		"name":     {"string", "string"},
+		"members":  {"[]PostOrgsRequestMembers", "PostOrgsRequestMembers[]"},
+		"settings": {"map[string]interface{}", "Record<string, unknown>"},
+		"role":     {"*string", `"admin" | "member"`},
+		"user_id":  {"int64", "number"},
+	} {
+		if f := fields[json]; f.GoType != want[0] || f.TSType != want[1] {
+			t.Errorf("field %s = %s / %s, want %s / %s", json, f.GoType, f.TSType, want[0], want[1])
+		}
+	}
+	if fields["name"].Optional || !fields["role"].Optional {
+		t.Error("expected required properties not to be optional and the others to be")
+	}
+}
+
+func TestNewSDK_RejectsNameCollisions(t *testing.T) {
+	spec := &openapi3.T{Paths: openapi3.NewPaths()}
+	spec.AddOperation("/api/user-keys", "GET", openapi3.NewOperation())
+	spec.AddOperation("/api/user_keys", "GET", openapi3.NewOperation())
+	if _, err := NewSDK(spec); err == nil {
+		t.Fatal("expected operations with the same name to be rejected")
+	}
+}
+
+func TestGenerateSDK(t *testing.T) {
+	sdk, err := NewSDK(sdkSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	written, err := GenerateSDK(dir, sdk, SDKLanguages)
+	if err != nil {
+		t.Fatalf("GenerateSDK() error = %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("written = %v", written)
+	}
+
+	goClient, err := os.ReadFile(filepath.Join(dir, "go", "client.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package client",
+		"func (c *Client) PostOrgs(ctx context.Context, body PostOrgsRequest) (*Response, error)",
+		"func (c *Client) DeleteOrgsByOrgMembersByUserID(ctx context.Context, org string, userID string) (*Response, error)",
+		"Members  []PostOrgsRequestMembers `json:\"members,omitempty\"`",
+		`strings.NewReader(body.Encode()), "application/x-www-form-urlencoded"`,
+	} {
+		if !strings.Contains(string(goClient), want) {
+			t.Errorf("Go client does not contain %q", want)
+		}
+	}
+
+	tsClient, err := os.ReadFile(filepath.Join(dir, "typescript", "client.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"postOrgs(body: PostOrgsRequest): Promise<ApiResponse>",
+		"`/api/orgs/${encodeURIComponent(org)}/members/${encodeURIComponent(userID)}`",
+		`role?: "admin" | "member";`,
+	} {
+		if !strings.Contains(string(tsClient), want) {
+			t.Errorf("TypeScript client does not contain %q", want)
+		}
+	}
+
+	if _, err := GenerateSDK(dir, sdk, []string{"python"}); err == nil {
+		t.Error("expected an unsupported language to be rejected")
+	}
+}
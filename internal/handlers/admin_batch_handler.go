@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/operations"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// MaxBatchOperations is the largest number of operations accepted in a single batch request.
+const MaxBatchOperations = 100
+
+// Batch operation types.
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// BatchUserRequest is the body of POST /api/admin/users/batch.
+// When Atomic is true a single failed operation rolls back the whole batch; otherwise
+// each failed operation is rolled back on its own and the rest are committed.
+type BatchUserRequest struct {
+	Atomic     bool                 `json:"atomic"`
+	Operations []BatchUserOperation `json:"operations"`
+}
+
+// BatchUserOperation is a single create, update, or delete in a batch.
+// ID is required for update and delete; Data is required for create and update.
+type BatchUserOperation struct {
+	Op   string         `json:"op"`
+	ID   uint           `json:"id,omitempty"`
+	Data *BatchUserData `json:"data,omitempty"`
+}
+
+// BatchUserData holds the user fields of a create or update operation.
+// Omitted fields are left unchanged on update.
+type BatchUserData struct {
+	Username *string `json:"username,omitempty"`
+	Email    *string `json:"email,omitempty"`
+	Password *string `json:"password,omitempty"`
+	Role     *string `json:"role,omitempty"`
+}
+
+// BatchResult reports the outcome of a single operation.
+type BatchResult struct {
+	Index  int                `json:"index"`
+	Op     string             `json:"op"`
+	Status int                `json:"status"`
+	User   *AdminUserResponse `json:"user,omitempty"`
+	Error  *response.APIError `json:"error,omitempty"`
+}
+
+// BatchResponse summarises a batch. Committed is false when an atomic batch was rolled back;
+// its items that did not fail themselves are then counted in RolledBack.
+type BatchResponse struct {
+	Committed  bool          `json:"committed"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	RolledBack int           `json:"rolled_back,omitempty"`
+	Results    []BatchResult `json:"results"`
+}
+
+// errBatchItemFailed aborts the transaction of an atomic batch after an operation fails.
+var errBatchItemFailed = errors.New("batch operation failed")
+
+// BatchUsers executes a list of user create/update/delete operations in one transaction.
+// Every operation runs inside its own savepoint so failures can be reported per item.
+// The response is 200 when every operation succeeded and 207 Multi-Status otherwise.
+// With ?async=true the batch runs in the background and 202 is returned with an operation.
+func BatchUsers(db *gorm.DB, ops *operations.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BatchUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.WithField("error", err.Error()).Warn("Invalid JSON data for user batch")
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+
+		if len(req.Operations) == 0 {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", "operations must not be empty"))
+			return
+		}
+		if len(req.Operations) > MaxBatchOperations {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", fmt.Sprintf("a batch can contain at most %d operations", MaxBatchOperations)))
+			return
+		}
+
+		adminID := requestctx.UserID(c)
+
+		if c.Query("async") == "true" && ops != nil {
+			op, err := ops.Start(c.Request.Context(), adminID, "users.batch", func(ctx context.Context, p *operations.Progress) (interface{}, error) {
+				return processUserBatch(ctx, db, req, adminID, p)
+			})
+			if err != nil {
+				_ = c.Error(apperrors.Unavailable("Could not start batch", "The job queue is not accepting work").Wrap(err))
+				return
+			}
+			respondOperationAccepted(c, op)
+			return
+		}
+
+		resp, err := processUserBatch(c.Request.Context(), db, req, adminID, nil)
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not process batch"))
+			return
+		}
+
+		status, message := http.StatusOK, "Batch processed successfully"
+		if resp.Failed > 0 || !resp.Committed {
+			status, message = http.StatusMultiStatus, "Batch processed with errors"
+		}
+		response.SuccessResponse(c, status, message, resp)
+	}
+}
+
+// processUserBatch runs a batch and summarises the results. An error is returned only when
+// the transaction itself failed. Progress is reported after each operation when p is not nil.
+func processUserBatch(ctx context.Context, db *gorm.DB, req BatchUserRequest, adminID uint, p *operations.Progress) (*BatchResponse, error) {
+	results := make([]BatchResult, len(req.Operations))
+
+	err := database.WithTransaction(ctx, db, func(tx *gorm.DB) error {
+		for i, op := range req.Operations {
+			var result BatchResult
+			// Nested transactions are executed as savepoints, so a failed item only
+			// rolls back its own changes.
+			err := tx.Transaction(func(itemTx *gorm.DB) error {
+				result = applyUserOperation(itemTx, op, adminID)
+				if result.Error != nil {
+					return errBatchItemFailed
+				}
+				return nil
+			})
+			result.Index = i
+			result.Op = op.Op
+			results[i] = result
+
+			if err != nil && !errors.Is(err, errBatchItemFailed) {
+				return err
+			}
+			if err != nil && req.Atomic {
+				return err
+			}
+			if p != nil {
+				p.Update((i+1)*100/len(req.Operations), fmt.Sprintf("%d of %d operations processed", i+1, len(req.Operations)))
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchItemFailed) {
+		return nil, err
+	}
+
+	resp := &BatchResponse{Committed: err == nil, Results: results}
+	for i, result := range results {
+		switch {
+		case result.Error != nil:
+			resp.Failed++
+		case !resp.Committed:
+			// Nothing from an aborted atomic batch was persisted, whether it ran or not
+			results[i] = batchError(response.CodeRolledBack, "Operation rolled back", "Another operation of the atomic batch failed")
+			results[i].Index, results[i].Op = i, req.Operations[i].Op
+			resp.RolledBack++
+		default:
+			resp.Succeeded++
+		}
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"admin_id":   adminID,
+		"operations": len(req.Operations),
+		"succeeded":  resp.Succeeded,
+		"failed":     resp.Failed,
+		"committed":  resp.Committed,
+	}).Info("User batch processed")
+
+	return resp, nil
+}
+
+// applyUserOperation runs one batch operation and describes its outcome.
+func applyUserOperation(tx *gorm.DB, op BatchUserOperation, adminID uint) BatchResult {
+	switch op.Op {
+	case BatchOpCreate:
+		return createUserOperation(tx, op)
+	case BatchOpUpdate:
+		return updateUserOperation(tx, op, adminID)
+	case BatchOpDelete:
+		return deleteUserOperation(tx, op, adminID)
+	default:
+		return batchError(response.CodeBadRequest, "Invalid operation", "op must be one of: create, update, delete")
+	}
+}
+
+func createUserOperation(tx *gorm.DB, op BatchUserOperation) BatchResult {
+	if op.Data == nil {
+		return batchError(response.CodeBadRequest, "Invalid operation", "data is required for create")
+	}
+
+	req := validators.AuthRequest{
+		Username: stringValue(op.Data.Username),
+		Email:    stringValue(op.Data.Email),
+		Password: stringValue(op.Data.Password),
+	}
+	req.Normalize()
+	role := models.RoleUser
+	if op.Data.Role != nil {
+		role = *op.Data.Role
+	}
+
+	// ValidateUserRegistration always reports its failures as ValidationErrors.
+	var errs validators.ValidationErrors
+	if err := validators.ValidateUserRegistration(tx.Statement.Context, &req); err != nil {
+		errors.As(err, &errs)
+	}
+	errs.Add("role", validators.ValidateRole(role))
+	if err := errs.Err(); err != nil {
+		return batchValidationError(err)
+	}
+
+	hashed, err := auth.HashPassword(tx.Statement.Context, req.Password)
+	if err != nil {
+		return batchError(response.CodeInternal, "Error processing password", "Failed to secure password")
+	}
+
+	user := models.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: hashed,
+		Role:     role,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return batchDatabaseError(err)
+	}
+
+	return batchSuccess(http.StatusCreated, &user)
+}
+
+func updateUserOperation(tx *gorm.DB, op BatchUserOperation, adminID uint) BatchResult {
+	if op.ID == 0 {
+		return batchError(response.CodeBadRequest, "Invalid operation", "id is required for update")
+	}
+	if op.Data == nil {
+		return batchError(response.CodeBadRequest, "Invalid operation", "data is required for update")
+	}
+
+	var user models.User
+	if err := tx.First(&user, op.ID).Error; err != nil {
+		return batchDatabaseError(err)
+	}
+
+	oldUsername := user.Username
+	var errs validators.ValidationErrors
+	if op.Data.Username != nil {
+		user.Username = validators.Normalize(*op.Data.Username)
+		errs.Add("username", validators.ValidateUsername(user.Username))
+	}
+	if op.Data.Email != nil {
+		user.Email = validators.Normalize(*op.Data.Email)
+		errs.Add("email", validators.ValidateEmail(user.Email))
+	}
+	if op.Data.Role != nil {
+		user.Role = *op.Data.Role
+		errs.Add("role", validators.ValidateRole(user.Role))
+	}
+	if op.Data.Password != nil {
+		password := validators.NormalizePassword(*op.Data.Password)
+		if err := validators.ValidatePasswordFor(tx.Statement.Context, password, user.Username, user.Email); err != nil {
+			errs.Add("password", err)
+		} else if hashed, err := auth.HashPassword(tx.Statement.Context, password); err != nil {
+			return batchError(response.CodeInternal, "Error processing password", "Failed to secure password")
+		} else {
+			user.Password = hashed
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return batchValidationError(err)
+	}
+
+	if err := tx.Save(&user).Error; err != nil {
+		return batchDatabaseError(err)
+	}
+	// Administrators are not limited by the cooldown and reservations, but the change is
+	// recorded in the user's history
+	if usernameChanged(oldUsername, user.Username) {
+		if err := recordUsernameChange(tx, user.ID, oldUsername, user.Username, adminID); err != nil {
+			return batchDatabaseError(err)
+		}
+	}
+
+	return batchSuccess(http.StatusOK, &user)
+}
+
+func deleteUserOperation(tx *gorm.DB, op BatchUserOperation, adminID uint) BatchResult {
+	if op.ID == 0 {
+		return batchError(response.CodeBadRequest, "Invalid operation", "id is required for delete")
+	}
+	if op.ID == adminID {
+		return batchError(response.CodeConflict, "Cannot delete own account", "Administrators cannot delete themselves in a batch")
+	}
+
+	var user models.User
+	if err := tx.First(&user, op.ID).Error; err != nil {
+		return batchDatabaseError(err)
+	}
+	if err := tx.Delete(&user).Error; err != nil {
+		return batchDatabaseError(err)
+	}
+
+	return BatchResult{Status: http.StatusNoContent}
+}
+
+func batchSuccess(status int, user *models.User) BatchResult {
+	resp := toAdminUserResponse(user)
+	return BatchResult{Status: status, User: &resp}
+}
+
+func batchError(code *response.ErrorCode, message, details string) BatchResult {
+	return BatchResult{
+		Status: code.Status,
+		Error:  &response.APIError{Code: code.Code, Message: message, Details: details},
+	}
+}
+
+func batchValidationError(err error) BatchResult {
+	result := batchError(response.CodeValidation, "Validation failed", err.Error())
+	result.Error.Fields = validators.FieldErrorsOf(err)
+	return result
+}
+
+// batchDatabaseError maps a database error to the item status the equivalent single-item endpoint would return.
+func batchDatabaseError(err error) BatchResult {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return batchError(response.CodeNotFound, "User not found", "No user exists with the given id")
+	case database.IsDuplicateKeyError(err):
+		return batchError(response.CodeConflict, "User already exists", "Username or email already exists")
+	default:
+		logger.WithField("error", err.Error()).Error("User batch operation failed")
+		return batchError(response.CodeInternal, "Database error occurred", "")
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func performBatch(t *testing.T, db *gorm.DB, body string) (int, BatchResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler())
+	r.POST("/admin/users/batch", func(c *gin.Context) { requestctx.SetUser(c, &models.User{ID: 1, Role: models.RoleAdmin}, time.Time{}) }, BatchUsers(db, nil))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/users/batch", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	var resp struct {
+		Data BatchResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %v, body: %s", err, w.Body.String())
+	}
+	return w.Code, resp.Data
+}
+
+func TestBatchUsersReportsPartialFailures(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedUsers(t, db, 3)
+
+	status, resp := performBatch(t, db, `{"operations": [
+		{"op": "create", "data": {"username": "newuser", "email": "new@example.com", "password": "TestPass123!"}},
+		{"op": "create", "data": {"username": "user02", "email": "dup@example.com", "password": "TestPass123!"}},
+		{"op": "update", "id": 2, "data": {"role": "admin"}},
+		{"op": "update", "id": 3, "data": {"role": "owner"}},
+		{"op": "delete", "id": 3},
+		{"op": "delete", "id": 99}
+	]}`)
+
+	if status != http.StatusMultiStatus {
+		t.Fatalf("expected status 207, got %d", status)
+	}
+	if !resp.Committed || resp.Succeeded != 3 || resp.Failed != 3 {
+		t.Fatalf("unexpected summary: %+v", resp)
+	}
+
+	wantStatuses := []int{201, 409, 200, 400, 204, 404}
+	for i, want := range wantStatuses {
+		if resp.Results[i].Status != want {
+			t.Errorf("result %d: expected status %d, got %d", i, want, resp.Results[i].Status)
+		}
+	}
+	if resp.Results[3].Error == nil || len(resp.Results[3].Error.Fields) != 1 || resp.Results[3].Error.Fields[0].Field != "role" {
+		t.Errorf("expected a role field error, got %+v", resp.Results[3].Error)
+	}
+
+	var count int64
+	db.Model(&models.User{}).Where("username = ?", "newuser").Count(&count)
+	if count != 1 {
+		t.Errorf("expected created user to be committed")
+	}
+	var promoted models.User
+	db.First(&promoted, 2)
+	if promoted.Role != models.RoleAdmin {
+		t.Errorf("expected user 2 to be promoted, got role %q", promoted.Role)
+	}
+}
+
+func TestBatchUsersAtomicRollsBack(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedUsers(t, db, 1)
+
+	status, resp := performBatch(t, db, `{"atomic": true, "operations": [
+		{"op": "create", "data": {"username": "newuser", "email": "new@example.com", "password": "TestPass123!"}},
+		{"op": "delete", "id": 99},
+		{"op": "delete", "id": 1}
+	]}`)
+
+	if status != http.StatusMultiStatus {
+		t.Fatalf("expected status 207, got %d", status)
+	}
+	if resp.Committed || resp.Succeeded != 0 || resp.Failed != 1 || resp.RolledBack != 2 {
+		t.Fatalf("unexpected summary: %+v", resp)
+	}
+	// The create that ran and the delete that never did are both reported as rolled back
+	for _, i := range []int{0, 2} {
+		result := resp.Results[i]
+		if result.Index != i || result.Status != http.StatusFailedDependency || result.User != nil ||
+			result.Error == nil || result.Error.Code != "ROLLED_BACK" {
+			t.Errorf("result %d = %+v, want ROLLED_BACK", i, result)
+		}
+	}
+	if resp.Results[1].Status != http.StatusNotFound {
+		t.Errorf("failed result = %+v, want its own 404", resp.Results[1])
+	}
+
+	var count int64
+	db.Model(&models.User{}).Where("username = ?", "newuser").Count(&count)
+	if count != 0 {
+		t.Errorf("expected atomic batch to be rolled back")
+	}
+}
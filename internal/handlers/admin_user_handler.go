@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/export"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/pagination"
+	"github.com/yeferson59/gin-template/pkg/projection"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// exportBatchSize is the number of users loaded per query while streaming an export.
+const exportBatchSize = 500
+
+// AdminUserResponse represents user data exposed to administrators.
+type AdminUserResponse struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserListResponse represents a page of users.
+type UserListResponse struct {
+	Users      []AdminUserResponse `json:"users"`
+	Pagination pagination.Meta     `json:"pagination"`
+}
+
+// userExportColumns maps the exportable column names to their cell values.
+var userExportColumns = map[string]func(u *models.User) string{
+	"id":         func(u *models.User) string { return strconv.FormatUint(uint64(u.ID), 10) },
+	"username":   func(u *models.User) string { return u.Username },
+	"email":      func(u *models.User) string { return u.Email },
+	"role":       func(u *models.User) string { return u.Role },
+	"created_at": func(u *models.User) string { return u.CreatedAt.UTC().Format(time.RFC3339) },
+	"updated_at": func(u *models.User) string { return u.UpdatedAt.UTC().Format(time.RFC3339) },
+}
+
+// adminUserColumns maps the fields of AdminUserResponse to their users column, so that a
+// ?fields= selection only loads what is returned.
+var adminUserColumns = map[string]string{
+	"id":         "id",
+	"username":   "username",
+	"email":      "email",
+	"role":       "role",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// defaultUserExportColumns is the column order used when ?columns= is not given.
+var defaultUserExportColumns = []string{"id", "username", "email", "role", "created_at", "updated_at"}
+
+// ListUsers returns a paginated, filterable list of users for administrators.
+// With ?format=csv or ?format=xlsx the full filtered result is streamed as a file instead.
+func ListUsers(repo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := parseUserFilter(c)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid filter", err.Error()))
+			return
+		}
+
+		if format := c.Query("format"); format != "" {
+			exportUsers(c, repo, filter, format)
+			return
+		}
+
+		fields, err := projection.FromContext(c, projection.JSONFields(AdminUserResponse{}))
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid fields", err.Error()))
+			return
+		}
+		filter.Columns = fields.Columns(adminUserColumns)
+
+		params := pagination.FromContext(c)
+		ctx := repository.WithCountReport(c.Request.Context())
+		users, total, err := repo.List(ctx, filter, params)
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not list users"))
+			return
+		}
+
+		items := make([]AdminUserResponse, len(users))
+		for i := range users {
+			items[i] = toAdminUserResponse(&users[i])
+		}
+
+		meta := pagination.NewMeta(params, total)
+		meta.TotalEstimated = repository.EstimatedCount(ctx)
+		pagination.SetLinkHeader(c, meta)
+		data, err := fields.Apply(UserListResponse{Users: items, Pagination: meta}, "users")
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Could not list users", "Failed to encode the response", err))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Users retrieved successfully", data)
+	}
+}
+
+// exportUsers streams every user matching the filter in the requested format.
+func exportUsers(c *gin.Context, repo repository.UserRepository, filter repository.UserFilter, formatName string) {
+	format, err := export.ParseFormat(formatName)
+	if err != nil {
+		_ = c.Error(apperrors.BadRequest("Invalid export format", "Supported formats are csv and xlsx"))
+		return
+	}
+
+	columns, err := parseExportColumns(c.Query("columns"))
+	if err != nil {
+		_ = c.Error(apperrors.BadRequest("Invalid export columns", err.Error()))
+		return
+	}
+
+	filename := fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102-150405"), format.Extension())
+	rows := 0
+
+	err = response.StreamAttachment(c, filename, format.ContentType(), func(w io.Writer) error {
+		writer, err := export.NewWriter(format, w)
+		if err != nil {
+			return err
+		}
+
+		if err := writer.WriteRow(columns); err != nil {
+			return err
+		}
+
+		err = repo.Each(c.Request.Context(), filter, exportBatchSize, func(users []models.User) error {
+			for i := range users {
+				cells := make([]string, len(columns))
+				for j, column := range columns {
+					cells[j] = userExportColumns[column](&users[i])
+				}
+				if err := writer.WriteRow(cells); err != nil {
+					return err
+				}
+				rows++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return writer.Close()
+	})
+
+	fields := map[string]interface{}{
+		"admin_id": requestctx.UserID(c),
+		"format":   format,
+		"rows":     rows,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		logger.WithFields(fields).Error("User export failed while streaming")
+		return
+	}
+	logger.WithFields(fields).Info("Users exported")
+}
+
+// parseUserFilter reads the list filters from the query string.
+func parseUserFilter(c *gin.Context) (repository.UserFilter, error) {
+	filter := repository.UserFilter{
+		Search: c.Query("q"),
+		Role:   c.Query("role"),
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTimeQuery(c, "created_before"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseTimeQuery parses an optional RFC 3339 timestamp or YYYY-MM-DD date query parameter.
+func parseTimeQuery(c *gin.Context, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", key)
+}
+
+// parseExportColumns validates the comma-separated ?columns= list.
+func parseExportColumns(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return defaultUserExportColumns, nil
+	}
+
+	var columns []string
+	for _, column := range strings.Split(value, ",") {
+		column = strings.TrimSpace(column)
+		if _, ok := userExportColumns[column]; !ok {
+			return nil, fmt.Errorf("unknown column %q, allowed: %s", column, strings.Join(defaultUserExportColumns, ", "))
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func toAdminUserResponse(u *models.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Role:      u.Role,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
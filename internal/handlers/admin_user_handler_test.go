@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/mocks"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/pagination"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+// setupAdminRouter registers the admin user listing behind a fake authentication step
+// that sets the given role.
+func setupAdminRouter(db *gorm.DB, role string) *gin.Engine {
+	return setupAdminRouterWithRepo(repository.NewUserRepository(db), role)
+}
+
+// setupAdminRouterWithRepo is setupAdminRouter for any repository, such as a mock.
+func setupAdminRouterWithRepo(repo repository.UserRepository, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler())
+	r.GET("/admin/users",
+		func(c *gin.Context) { requestctx.SetUser(c, &models.User{ID: 1, Role: role}, time.Time{}) },
+		middlewares.RequireRole(models.RoleAdmin),
+		ListUsers(repo),
+	)
+	return r
+}
+
+func seedUsers(t *testing.T, db *gorm.DB, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		user := models.User{
+			Username: fmt.Sprintf("user%02d", i),
+			Email:    fmt.Sprintf("user%02d@example.com", i),
+			Password: "hashed",
+			Role:     models.RoleUser,
+		}
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+	}
+}
+
+func TestListUsersRequiresAdmin(t *testing.T) {
+	router := setupAdminRouter(testutil.NewDB(t), models.RoleUser)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/users", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+}
+
+func TestListUsersPaginatesAndFilters(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedUsers(t, db, 25)
+	router := setupAdminRouter(db, models.RoleAdmin)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/users?page=2&per_page=10", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data UserListResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if len(resp.Data.Users) != 10 || resp.Data.Users[0].Username != "user11" {
+		t.Fatalf("unexpected page contents: %+v", resp.Data.Users)
+	}
+	if resp.Data.Pagination.Total != 25 || resp.Data.Pagination.TotalPages != 3 {
+		t.Fatalf("unexpected pagination: %+v", resp.Data.Pagination)
+	}
+	if link := w.Header().Get("Link"); !strings.Contains(link, `</admin/users?page=3&per_page=10>; rel="next"`) {
+		t.Fatalf("unexpected Link header: %q", link)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin/users?q=USER2", nil)
+	router.ServeHTTP(w, req)
+
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if resp.Data.Pagination.Total != 6 {
+		t.Fatalf("expected 6 users matching 'user2', got %d", resp.Data.Pagination.Total)
+	}
+}
+
+func TestListUsersSelectsFields(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedUsers(t, db, 2)
+	router := setupAdminRouter(db, models.RoleAdmin)
+
+	w := testutil.Get("/admin/users?fields=id,username").Do(t, router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var resp struct {
+		Data struct {
+			Users      []map[string]interface{} `json:"users"`
+			Pagination pagination.Meta          `json:"pagination"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if resp.Data.Pagination.Total != 2 || len(resp.Data.Users) != 2 {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+	for _, user := range resp.Data.Users {
+		if len(user) != 2 || user["id"] == nil || user["username"] == nil {
+			t.Fatalf("expected only id and username, got %v", user)
+		}
+	}
+
+	w = testutil.Get("/admin/users?fields=id,password").Do(t, router)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+}
+
+func TestListUsersExportCSV(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedUsers(t, db, 3)
+	router := setupAdminRouter(db, models.RoleAdmin)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/users?format=csv&columns=id,username", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d, body: %s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), ".csv") {
+		t.Fatalf("unexpected content disposition %q", w.Header().Get("Content-Disposition"))
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected header and 3 rows, got %d records", len(records))
+	}
+	if strings.Join(records[0], ",") != "id,username" || records[3][1] != "user03" {
+		t.Fatalf("unexpected CSV contents: %v", records)
+	}
+}
+
+func TestListUsersExportRejectsUnknownColumn(t *testing.T) {
+	router := setupAdminRouter(testutil.NewDB(t), models.RoleAdmin)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/users?format=csv&columns=password", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+// The tests below use mocks.UserRepository, so they exercise the handler without a database.
+
+func TestListUsersPassesFiltersToRepository(t *testing.T) {
+	repo := &mocks.UserRepository{
+		ListFunc: func(_ context.Context, _ repository.UserFilter, _ pagination.Params) ([]models.User, int64, error) {
+			return []models.User{{ID: 7, Username: "alice", Role: models.RoleAdmin}}, 41, nil
+		},
+	}
+	router := setupAdminRouterWithRepo(repo, models.RoleAdmin)
+
+	w := testutil.Get("/admin/users?q=ali&role=admin&created_after=2026-01-01&page=3&per_page=20").Do(t, router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	calls := repo.ListCalls()
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 List call, got %d", len(calls))
+	}
+	call := calls[0]
+	if call.Filter.Search != "ali" || call.Filter.Role != "admin" || call.Filter.CreatedAfter == nil || call.Page.Page != 3 {
+		t.Fatalf("unexpected List arguments: %+v", call)
+	}
+	if call.Filter.Columns != nil {
+		t.Fatalf("expected every column without ?fields=, got %v", call.Filter.Columns)
+	}
+
+	var resp UserListResponse
+	testutil.DecodeData(t, w, &resp)
+	if resp.Pagination.TotalPages != 3 || len(resp.Users) != 1 || resp.Users[0].Username != "alice" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestListUsersRepositoryErrorReturns500(t *testing.T) {
+	repo := &mocks.UserRepository{
+		ListFunc: func(_ context.Context, _ repository.UserFilter, _ pagination.Params) ([]models.User, int64, error) {
+			return nil, 0, errors.New("connection reset")
+		},
+	}
+	router := setupAdminRouterWithRepo(repo, models.RoleAdmin)
+
+	w := testutil.Get("/admin/users").Do(t, router)
+	testutil.AssertError(t, w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR")
+}
+
+func TestListUsersExportStreamsEveryBatch(t *testing.T) {
+	users := make([]models.User, exportBatchSize+1)
+	for i := range users {
+		users[i] = models.User{ID: uint(i + 1), Username: fmt.Sprintf("user%d", i+1)}
+	}
+	repo := &mocks.UserRepository{EachFunc: mocks.UsersInBatches(users)}
+	router := setupAdminRouterWithRepo(repo, models.RoleAdmin)
+
+	w := testutil.Get("/admin/users?format=csv&columns=id").Do(t, router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != len(users)+1 {
+		t.Fatalf("expected header and %d rows, got %d records", len(users), len(records))
+	}
+	if calls := repo.EachCalls(); len(calls) != 1 || calls[0].BatchSize != exportBatchSize {
+		t.Fatalf("unexpected Each calls: %+v", calls)
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/metering"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// maxAPIKeysPerUser is how many API keys a user may have at once.
+const maxAPIKeysPerUser = 20
+
+// APIKeyResponse is a created API key. Key is only returned once.
+type APIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyUsageResponse is the usage of an API key in the current calendar month (UTC).
+// Remaining is omitted for keys without a quota.
+type APIKeyUsageResponse struct {
+	APIKeyID    uint              `json:"api_key_id"`
+	PeriodStart time.Time         `json:"period_start"`
+	ResetsAt    time.Time         `json:"resets_at"`
+	Quota       int64             `json:"quota"`
+	Used        int64             `json:"used"`
+	Remaining   *int64            `json:"remaining,omitempty"`
+	Bytes       int64             `json:"bytes"`
+	Days        []models.APIUsage `json:"days"`
+}
+
+var errAPIKeyNotFound = apperrors.NotFound("API key not found", "The API key does not exist or belongs to another user")
+
+// CreateAPIKey creates an API key for the current user. Keys cannot create keys, so a leaked
+// key cannot be used to keep access after it is revoked.
+func CreateAPIKey(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := requestctx.APIKey(c); ok {
+			_ = c.Error(apperrors.Forbidden("Access denied", "API keys cannot create API keys; sign in to create one"))
+			return
+		}
+		var req validators.APIKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		req.Normalize()
+		if err := validators.ValidateAPIKey(&req); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		key, prefix, hash, err := auth.NewAPIKey()
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Could not create API key", "Could not generate key", err))
+			return
+		}
+		apiKey := models.APIKey{UserID: requestctx.UserID(c), Name: req.Name, Prefix: prefix, KeyHash: hash}
+		err = database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			var keys int64
+			if err := tx.Model(&models.APIKey{}).Where("user_id = ?", apiKey.UserID).Count(&keys).Error; err != nil {
+				return err
+			}
+			if keys >= maxAPIKeysPerUser {
+				return apperrors.Conflict("API key not created", "You have too many API keys; revoke one first")
+			}
+			return tx.Create(&apiKey).Error
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not create API key"))
+			return
+		}
+
+		requestctx.Logger(c).WithField("api_key_id", apiKey.ID).Info("API key created")
+		response.SuccessResponse(c, http.StatusCreated, "API key created successfully", APIKeyResponse{APIKey: apiKey, Key: key})
+	}
+}
+
+// ListAPIKeys returns the current user's API keys, newest first.
+func ListAPIKeys(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys := []models.APIKey{}
+		err := db.WithContext(c.Request.Context()).
+			Where("user_id = ?", requestctx.UserID(c)).
+			Order("created_at DESC, id DESC").
+			Find(&keys).Error
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve API keys"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", keys)
+	}
+}
+
+// RevokeAPIKey deletes an API key of the current user. Its usage history is kept.
+func RevokeAPIKey(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid API key ID", "The API key ID must be a positive integer"))
+			return
+		}
+		result := db.WithContext(c.Request.Context()).
+			Where("id = ? AND user_id = ?", id, requestctx.UserID(c)).
+			Delete(&models.APIKey{})
+		if result.Error != nil {
+			_ = c.Error(repository.TranslateError(result.Error, "Could not revoke API key"))
+			return
+		}
+		if result.RowsAffected == 0 {
+			_ = c.Error(errAPIKeyNotFound)
+			return
+		}
+
+		requestctx.Logger(c).WithField("api_key_id", id).Info("API key revoked")
+		response.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+	}
+}
+
+// APIKeyUsage returns the requests and bytes of an API key of the current user this month,
+// per day, against its monthly quota.
+func APIKeyUsage(db *gorm.DB, meter *metering.Meter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if meter == nil {
+			_ = c.Error(apperrors.Unavailable("Usage not available", "Usage metering is disabled"))
+			return
+		}
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid API key ID", "The API key ID must be a positive integer"))
+			return
+		}
+		var apiKey models.APIKey
+		err = db.WithContext(c.Request.Context()).Where("id = ? AND user_id = ?", id, requestctx.UserID(c)).First(&apiKey).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_ = c.Error(errAPIKeyNotFound)
+			return
+		}
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve usage"))
+			return
+		}
+
+		days, err := meter.Usage(c.Request.Context(), apiKey.UserID, apiKey.ID)
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve usage"))
+			return
+		}
+		start, reset := metering.Period(time.Now())
+		usage := APIKeyUsageResponse{
+			APIKeyID:    apiKey.ID,
+			PeriodStart: start,
+			ResetsAt:    reset,
+			Quota:       meter.Quota(),
+			Days:        days,
+		}
+		for _, day := range days {
+			usage.Used += day.Requests
+			usage.Bytes += day.Bytes
+		}
+		if usage.Quota > 0 {
+			remaining := usage.Quota - usage.Used
+			if remaining < 0 {
+				remaining = 0
+			}
+			usage.Remaining = &remaining
+		}
+		response.SuccessResponse(c, http.StatusOK, "API key usage retrieved successfully", usage)
+	}
+}
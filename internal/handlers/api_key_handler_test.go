@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/metering"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func newAPIKeyApp(t *testing.T, meter *metering.Meter) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		keys := a.Router.Group("/keys", middlewares.AuthRequired(a.DB), middlewares.Metering(meter))
+		keys.POST("", CreateAPIKey(a.DB))
+		keys.GET("", ListAPIKeys(a.DB))
+		keys.DELETE("/:id", RevokeAPIKey(a.DB))
+		keys.GET("/:id/usage", APIKeyUsage(a.DB, meter))
+	})
+}
+
+func createAPIKey(t *testing.T, app *testutil.App, user *models.User) APIKeyResponse {
+	t.Helper()
+	w := testutil.Post("/keys").WithJWT(user).WithJSON(map[string]string{"name": "CI"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	var key APIKeyResponse
+	testutil.DecodeData(t, w, &key)
+	if key.Key == "" || key.Prefix == "" || key.Key[:len(key.Prefix)] != key.Prefix {
+		t.Fatalf("created key = %+v", key)
+	}
+	return key
+}
+
+func TestAPIKeys(t *testing.T) {
+	app := newAPIKeyApp(t, nil)
+	user := testutil.CreateUser(t, app.DB)
+	other := testutil.CreateUser(t, app.DB)
+
+	w := testutil.Post("/keys").WithJWT(user).WithJSON(map[string]string{"name": ""}).Do(t, app.Router)
+	testutil.AssertFieldError(t, w, "name", "required")
+	key := createAPIKey(t, app, user)
+
+	// The key authenticates as its owner, but cannot create keys
+	var keys []models.APIKey
+	testutil.DecodeData(t, testutil.Get("/keys").WithHeader("X-API-Key", key.Key).Do(t, app.Router), &keys)
+	if len(keys) != 1 || keys[0].ID != key.ID || keys[0].LastUsedAt == nil {
+		t.Errorf("keys = %+v", keys)
+	}
+	w = testutil.Post("/keys").WithHeader("X-API-Key", key.Key).WithJSON(map[string]string{"name": "Copy"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+
+	// Usage is not available without metering
+	w = testutil.Get(fmt.Sprintf("/keys/%d/usage", key.ID)).WithJWT(user).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE")
+
+	w = testutil.NewRequest(http.MethodDelete, fmt.Sprintf("/keys/%d", key.ID)).WithJWT(other).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+	w = testutil.NewRequest(http.MethodDelete, fmt.Sprintf("/keys/%d", key.ID)).WithJWT(user).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	w = testutil.Get("/keys").WithHeader("X-API-Key", key.Key).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusUnauthorized, "UNAUTHORIZED")
+}
+
+func TestAPIKeyQuotaAndUsage(t *testing.T) {
+	app := testutil.NewApp(t, nil)
+	m := metering.NewMeter(app.DB, 3)
+	keys := app.Router.Group("/keys", middlewares.AuthRequired(app.DB), middlewares.Metering(m))
+	keys.POST("", CreateAPIKey(app.DB))
+	keys.GET("/:id/usage", APIKeyUsage(app.DB, m))
+	keys.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	user := testutil.CreateUser(t, app.DB)
+	key := createAPIKey(t, app, user)
+
+	for i, remaining := range []string{"2", "1", "0"} {
+		w := testutil.Get("/keys/ping").WithHeader("X-API-Key", key.Key).Do(t, app.Router)
+		testutil.AssertStatus(t, w, http.StatusOK)
+		if got := w.Header().Get(middlewares.QuotaRemainingHeader); got != remaining {
+			t.Errorf("request %d: %s = %q, want %s", i+1, middlewares.QuotaRemainingHeader, got, remaining)
+		}
+		if i == 1 {
+			// Flushed usage counts as much as the usage in memory
+			if err := m.Flush(t.Context()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	w := testutil.Get("/keys/ping").WithHeader("X-API-Key", key.Key).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusTooManyRequests, "QUOTA_EXCEEDED")
+	if w.Header().Get(middlewares.QuotaLimitHeader) != "3" || w.Header().Get(middlewares.QuotaResetHeader) == "" || w.Header().Get("Retry-After") == "" {
+		t.Errorf("quota headers = %v", w.Header())
+	}
+
+	// Session requests are metered apart and not limited
+	w = testutil.Get(fmt.Sprintf("/keys/%d/usage", key.ID)).WithJWT(user).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var usage APIKeyUsageResponse
+	testutil.DecodeData(t, w, &usage)
+	if usage.Quota != 3 || usage.Used != 3 || usage.Remaining == nil || *usage.Remaining != 0 || len(usage.Days) != 1 || usage.Bytes != 12 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
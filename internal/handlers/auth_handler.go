@@ -2,15 +2,21 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/billing"
+	"github.com/yeferson59/gin-template/internal/database"
 	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/security"
 	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
 	"github.com/yeferson59/gin-template/pkg/logger"
 	"github.com/yeferson59/gin-template/pkg/response"
 )
@@ -28,59 +34,140 @@ type UserSafeResponse struct {
 	Email    string `json:"email"`
 }
 
-// Register handles user registration.
-func Register(db *gorm.DB) gin.HandlerFunc {
+// errUserExists is returned when the username or email is taken, or the username is reserved.
+var errUserExists = apperrors.Conflict("User already exists", "Username or email already exists")
+
+// errInvalidCredentials is returned for unknown usernames and wrong passwords alike, so the
+// response does not reveal which accounts exist.
+var errInvalidCredentials = apperrors.Unauthorized("Invalid credentials", "Username or password is incorrect")
+
+// RegistrationOptions configures registration (see config.SecurityConfig).
+type RegistrationOptions struct {
+	// InviteOnly requires a registration code (see CreateRegistrationCode) to register.
+	InviteOnly bool
+	// TermsVersion is the version of the terms of service new users must accept; empty when
+	// there are none.
+	TermsVersion string
+	// Usernames keeps recently given up usernames from being registered by others.
+	Usernames UsernamePolicy
+	// Billing creates a Stripe customer for every new user; nil disables it.
+	Billing *billing.Service
+}
+
+// Register handles user registration. A registration code, required in invite-only mode, is
+// used up by the registration and joins the user to the organization that created it. The
+// acceptance of the terms of service is recorded with the user.
+func Register(db *gorm.DB, opts RegistrationOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req validators.AuthRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			logger.WithField("error", err.Error()).Warn("Invalid JSON data for registration")
-			response.BadRequestError(c, "Invalid request data", err.Error())
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
 			return
 		}
+		req.Normalize()
 
-		// Validate the request data
-		if err := validators.ValidateUserRegistration(&req); err != nil {
+		// Validate the request data; ValidateUserRegistration always reports its failures as
+		// ValidationErrors, so the terms are reported with them.
+		var errs validators.ValidationErrors
+		if err := validators.ValidateUserRegistration(c.Request.Context(), &req); err != nil {
+			errors.As(err, &errs)
+		}
+		if opts.TermsVersion != "" {
+			errs.Add("accept_terms", validators.ValidateTermsAcceptance(req.AcceptTerms))
+		}
+		if err := errs.Err(); err != nil {
 			logger.WithField("error", err.Error()).Warn("Validation failed for registration")
-			response.ValidationError(c, err.Error())
+			_ = c.Error(err)
 			return
 		}
-
-		// Check if the user already exists by username or email
-		var existing models.User
-		if err := db.Where("username = ? OR email = ?", req.Username, req.Email).First(&existing).Error; err == nil {
-			logger.WithFields(map[string]interface{}{
-				"username": req.Username,
-				"email":    req.Email,
-			}).Warn("Attempt to register with existing username or email")
-			response.ConflictError(c, "User already exists", "Username or email already exists")
+		if opts.InviteOnly && req.InvitationCode == "" {
+			_ = c.Error(apperrors.Forbidden("Registration not allowed", "Registration requires an invitation code"))
 			return
 		}
 
 		// Hash the password
-		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		hashed, err := auth.HashPassword(c.Request.Context(), req.Password)
 		if err != nil {
-			logger.WithField("error", err.Error()).Error("Failed to hash password")
-			response.InternalServerError(c, "Error processing password", "Failed to secure password")
+			_ = c.Error(apperrors.Internal("Error processing password", "Failed to secure password", err))
 			return
 		}
 
 		user := models.User{
 			Username: req.Username,
 			Email:    req.Email,
-			Password: string(hashed),
+			Password: hashed,
 		}
 
-		if err := db.Create(&user).Error; err != nil {
-			logger.WithField("error", err.Error()).Error("Failed to create user in database")
-			response.InternalServerError(c, "Could not create user", "Database error occurred")
+		// Uniqueness is enforced by the database constraints, which avoids the race of
+		// checking for an existing user before inserting under concurrent requests. A failed
+		// registration rolls back the use of the code.
+		var code *models.RegistrationCode
+		err = database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			if req.InvitationCode != "" {
+				if code, err = redeemRegistrationCode(tx, req.InvitationCode); err != nil {
+					return err
+				}
+			}
+			reserved, err := opts.Usernames.reserved(tx, user.Username, 0)
+			if err != nil {
+				return err
+			}
+			if reserved {
+				return errUserExists
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			if opts.TermsVersion != "" {
+				consent := newConsent(c, user.ID, models.ConsentTerms, opts.TermsVersion)
+				if err := tx.Create(&consent).Error; err != nil {
+					return err
+				}
+			}
+			if code != nil && code.OrganizationID != nil {
+				return tx.Create(&models.Membership{
+					OrganizationID: *code.OrganizationID,
+					UserID:         user.ID,
+					Role:           models.OrgRoleMember,
+				}).Error
+			}
+			return nil
+		})
+		if err != nil {
+			if database.IsDuplicateKeyError(err) {
+				logger.WithFields(map[string]interface{}{
+					"username": req.Username,
+					"email":    req.Email,
+				}).Warn("Attempt to register with existing username or email")
+				_ = c.Error(errUserExists.Wrap(err))
+				return
+			}
+			var appErr *apperrors.Error
+			if errors.As(err, &appErr) {
+				logger.WithField("username", req.Username).Warn("Registration rejected")
+				_ = c.Error(appErr)
+				return
+			}
+
+			_ = c.Error(repository.TranslateError(err, "Could not create user"))
 			return
 		}
+		if code != nil {
+			logger.WithFields(map[string]interface{}{
+				"user_id":              user.ID,
+				"registration_code_id": code.ID,
+			}).Info("Registration code redeemed")
+		}
 
 		logger.WithFields(map[string]interface{}{
 			"user_id":  user.ID,
 			"username": user.Username,
 			"email":    user.Email,
 		}).Info("User registered successfully")
+		if opts.Billing != nil {
+			opts.Billing.CreateCustomer(user)
+		}
 
 		userResponse := &UserSafeResponse{
 			ID:       user.ID,
@@ -92,64 +179,135 @@ func Register(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// Login handles user login.
-func Login(db *gorm.DB) gin.HandlerFunc {
+// Login handles user login. Logins from a new device or country alert the user and, when
+// alerts require it, answer 202 Accepted with a challenge instead of a token (see VerifyLogin).
+func Login(db *gorm.DB, alerts *LoginAlerts) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req validators.LoginRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			logger.WithField("error", err.Error()).Warn("Invalid JSON data for login")
-			response.BadRequestError(c, "Invalid request data", err.Error())
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
 			return
 		}
+		req.Normalize()
 
 		// Validate the request data
 		if err := validators.ValidateUserLogin(&req); err != nil {
 			logger.WithField("error", err.Error()).Warn("Validation failed for login")
-			response.ValidationError(c, err.Error())
+			_ = c.Error(err)
 			return
 		}
 
 		var user models.User
-		if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
+		if err := db.Where("username_normalized = ?", models.NormalizeUsername(req.Username)).First(&user).Error; err != nil {
 			logger.WithField("username", req.Username).Warn("Login attempt with non-existent username")
-			response.UnauthorizedError(c, "Invalid credentials", "Username or password is incorrect")
+			security.RecordFailedLogin(c, security.LoginUnknownUser)
+			_ = c.Error(errInvalidCredentials)
 			return
 		}
 
 		// Verify password
-		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		if err := auth.ComparePassword(c.Request.Context(), user.Password, req.Password); err != nil {
 			logger.WithFields(map[string]interface{}{
 				"username": req.Username,
 				"user_id":  user.ID,
 			}).Warn("Login attempt with incorrect password")
-			response.UnauthorizedError(c, "Invalid credentials", "Username or password is incorrect")
+			security.RecordFailedLogin(c, security.LoginWrongPassword)
+			_ = c.Error(errInvalidCredentials)
 			return
 		}
 
-		// Generate JWT token using the centralized function
-		token, err := auth.GenerateJWT(user.ID, user.Email)
-		if err != nil {
-			logger.WithField("error", err.Error()).Error("Failed to generate JWT token")
-			response.InternalServerError(c, "Authentication failed", "Could not generate access token")
+		origin := inspectLogin(c, db, &user)
+		if alerts.requiresVerification(origin) {
+			alerts.startVerification(c, db, &user, origin)
 			return
 		}
+		if completeLogin(c, db, &user, origin) {
+			alerts.notify(c, &user, origin)
+		}
+	}
+}
 
-		logger.WithFields(map[string]interface{}{
-			"user_id":  user.ID,
-			"username": user.Username,
-		}).Info("User logged in successfully")
+// completeLogin issues the token of an authenticated user, records the login, and writes the
+// response. It reports whether the login succeeded.
+func completeLogin(c *gin.Context, db *gorm.DB, user *models.User, origin loginOrigin) bool {
+	risk := recordLoginEvent(c, db, user, origin)
 
-		userResponse := &UserSafeResponse{
-			ID:       user.ID,
-			Username: user.Username,
-			Email:    user.Email,
-		}
+	// Generate JWT token using the centralized function
+	token, err := auth.GenerateLoginJWT(user.ID, user.Email, risk)
+	if err != nil {
+		_ = c.Error(apperrors.Internal("Authentication failed", "Could not generate access token", err))
+		return false
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"user_id":  user.ID,
+		"username": user.Username,
+	}).Info("User logged in successfully")
 
-		authResponse := AuthResponse{
-			Token: token,
-			User:  userResponse,
+	userResponse := &UserSafeResponse{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+	}
+
+	authResponse := AuthResponse{
+		Token: token,
+		User:  userResponse,
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Login successful", authResponse)
+	return true
+}
+
+// recordLoginEvent stores the login origin and flags new devices and countries, and impossible
+// travel against the previous login. It returns the risk score of the login, which the token
+// carries. Failures are logged but never block the login itself.
+func recordLoginEvent(c *gin.Context, db *gorm.DB, user *models.User, origin loginOrigin) int {
+	assessment := security.AssessmentFromContext(c)
+	for _, signal := range origin.reasons() {
+		assessment.Add(signal)
+	}
+	event := models.LoginEvent{
+		UserID:            user.ID,
+		IP:                c.ClientIP(),
+		UserAgent:         c.Request.UserAgent(),
+		DeviceFingerprint: origin.fingerprint,
+	}
+
+	loc := origin.location
+	ok := loc != nil
+	if ok {
+		event.Country = loc.Country
+		event.ASN = loc.ASN
+	}
+	if ok && !loc.CountryOnly {
+		event.Latitude = &loc.Latitude
+		event.Longitude = &loc.Longitude
+
+		var previous models.LoginEvent
+		err := db.Where("user_id = ? AND latitude IS NOT NULL AND longitude IS NOT NULL", user.ID).
+			Order("created_at DESC").First(&previous).Error
+		if err == nil && previous.HasLocation() {
+			from := security.Location{Country: previous.Country, Latitude: *previous.Latitude, Longitude: *previous.Longitude}
+			if security.IsImpossibleTravel(from, *loc, time.Since(previous.CreatedAt)) {
+				assessment.Add(security.SignalImpossibleTravel)
+				logger.WithFields(map[string]interface{}{
+					"user_id":      user.ID,
+					"from_country": previous.Country,
+					"to_country":   loc.Country,
+					"since":        time.Since(previous.CreatedAt).String(),
+				}).Warn("Impossible travel detected on login")
+			}
 		}
+	}
+
+	event.RiskScore = assessment.Score
+	event.Signals = assessment.SignalNames()
+	c.Set(security.ContextKey, assessment)
 
-		response.SuccessResponse(c, http.StatusOK, "Login successful", authResponse)
+	if err := db.Create(&event).Error; err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to record login event")
 	}
+	return assessment.Score
 }
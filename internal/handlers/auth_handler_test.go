@@ -1,106 +1,123 @@
 package handlers
 
 import (
-	"bytes"
-	"encoding/json"
 	"net/http"
-	"net/http/httptest"
-	"os"
 	"testing"
 
-	"github.com/gin-gonic/gin"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
-
 	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
 )
 
-// setupTestDB creates an in-memory SQLite database for testing.
-func setupTestDB() *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		panic("failed to connect database")
-	}
-	_ = db.AutoMigrate(&models.User{})
-	return db
-}
-
-// setupRouter configures a Gin router for testing.
-func setupRouter(db *gorm.DB) *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	r := gin.Default()
-	r.POST("/register", Register(db))
-	r.POST("/login", Login(db))
-	return r
+// setupAuthApp registers the authentication endpoints on an in-memory app.
+func setupAuthApp(t *testing.T) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		a.Router.POST("/register", Register(a.DB, RegistrationOptions{}))
+		a.Router.POST("/login", Login(a.DB, nil))
+	})
 }
 
 func TestRegisterAndLogin(t *testing.T) {
-	db := setupTestDB()
-	router := setupRouter(db)
-	if err := os.Setenv("JWT_SECRET", "testsecret"); err != nil {
-		t.Fatalf("failed to set JWT_SECRET: %v", err)
-	}
+	app := setupAuthApp(t)
 
-	// Test data
-	user := map[string]string{
+	// Registration test
+	w := testutil.Post("/register").WithJSON(map[string]string{
 		"username": "testuser",
 		"email":    "testuser@example.com",
 		"password": "TestPass123!",
-	}
-	body, _ := json.Marshal(user)
-
-	// Registration test
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/register", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
-	router.ServeHTTP(w, req)
-
-	if w.Code != http.StatusCreated {
-		t.Fatalf("expected status 201, got %d, body: %s", w.Code, w.Body.String())
-	}
+	}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
 
 	// Verify that the email was saved correctly after registration
 	var userRecord models.User
-	if err := db.Where("username = ?", "testuser").First(&userRecord).Error; err != nil {
+	if err := app.DB.Where("username = ?", "testuser").First(&userRecord).Error; err != nil {
 		t.Fatalf("user not found after registration: %v", err)
 	}
 	if userRecord.Email != "testuser@example.com" {
 		t.Fatalf("user email not saved correctly: got %s, want %s", userRecord.Email, "testuser@example.com")
 	}
 
-	// Login test (also sending the email field to match the binding)
-	loginData := map[string]string{
+	// Login test
+	w = testutil.Post("/login").WithJSON(map[string]string{
 		"username": "testuser",
 		"password": "TestPass123!",
+	}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var resp AuthResponse
+	testutil.DecodeData(t, w, &resp)
+	if resp.Token == "" {
+		t.Fatalf("expected a JWT token, got empty string")
+	}
+}
+
+func TestRegisterReportsAllInvalidFields(t *testing.T) {
+	app := setupAuthApp(t)
+
+	w := testutil.Post("/register").WithJSON(map[string]string{
+		"username": "ab",
+		"email":    "not-an-email",
+	}).Do(t, app.Router)
+
+	apiErr := testutil.AssertError(t, w, http.StatusBadRequest, "VALIDATION_ERROR")
+	if len(apiErr.Fields) != 3 {
+		t.Fatalf("expected 3 field errors, got %+v", apiErr.Fields)
+	}
+	testutil.AssertFieldError(t, w, "password", "required")
+}
+
+func TestRegisterIsCaseInsensitive(t *testing.T) {
+	app := setupAuthApp(t)
+
+	register := func(username, email string) int {
+		return testutil.Post("/register").WithJSON(map[string]string{
+			"username": username,
+			"email":    email,
+			"password": "Str0ng!Secret",
+		}).Do(t, app.Router).Code
 	}
-	loginBody, _ := json.Marshal(loginData)
-	w = httptest.NewRecorder()
-	req, _ = http.NewRequest("POST", "/login", bytes.NewBuffer(loginBody))
-	req.Header.Set("Content-Type", "application/json")
-	router.ServeHTTP(w, req)
 
+	if code := register("MixedCase", "Foo@Bar.com"); code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", code)
+	}
+
+	var stored models.User
+	if err := app.DB.Where("username = ?", "MixedCase").First(&stored).Error; err != nil {
+		t.Fatalf("user not found: %v", err)
+	}
+	if stored.Email != "foo@bar.com" {
+		t.Errorf("stored email = %s; want foo@bar.com", stored.Email)
+	}
+
+	if code := register("other", "foo@bar.com"); code != http.StatusConflict {
+		t.Errorf("duplicate email with different case: expected 409, got %d", code)
+	}
+	if code := register("mixedcase", "new@bar.com"); code != http.StatusConflict {
+		t.Errorf("duplicate username with different case: expected 409, got %d", code)
+	}
+
+	// Login matches the username regardless of case
+	w := testutil.Post("/login").WithJSON(map[string]string{"username": "MIXEDCASE", "password": "Str0ng!Secret"}).Do(t, app.Router)
 	if w.Code != http.StatusOK {
-		t.Fatalf("expected status 200, got %d, body: %s", w.Code, w.Body.String())
+		t.Errorf("expected case-insensitive login to succeed, got %d: %s", w.Code, w.Body.String())
 	}
 
-	var resp struct {
-		Success bool `json:"success"`
-		Data    struct {
-			Token string `json:"token"`
-			User  struct {
-				ID       int    `json:"id"`
-				Username string `json:"username"`
-				Email    string `json:"email"`
-			} `json:"user"`
-		} `json:"data"`
+	// The functional index rejects case-only duplicates even when the handler check is bypassed
+	if err := app.DB.Create(&models.User{Username: "MIXEDCASE", Email: "x@bar.com", Password: "x"}).Error; err == nil {
+		t.Error("expected unique index on LOWER(username) to reject duplicate")
 	}
-	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
-		t.Fatalf("failed to parse login response: %v", err)
+
+	// Non-ASCII letters are matched too, which SQLite's LOWER() leaves as they are
+	if code := register("Émile", "emile@bar.com"); code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", code)
 	}
-	if !resp.Success {
-		t.Fatalf("expected successful response, got: %s", w.Body.String())
+	if code := register("ÉMILE", "emile2@bar.com"); code != http.StatusConflict {
+		t.Errorf("duplicate non-ASCII username with different case: expected 409, got %d", code)
 	}
-	if resp.Data.Token == "" {
-		t.Fatalf("expected a JWT token, got empty string")
+	w = testutil.Post("/login").WithJSON(map[string]string{"username": "éMILE", "password": "Str0ng!Secret"}).Do(t, app.Router)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected case-insensitive non-ASCII login to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := app.DB.Create(&models.User{Username: "émile", Email: "y@bar.com", Password: "x"}).Error; err == nil {
+		t.Error("expected unique index on username_normalized to reject duplicate")
 	}
 }
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/billing"
+	"github.com/yeferson59/gin-template/internal/inbox"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// maxWebhookSize bounds the webhook payloads read.
+const maxWebhookSize = 1 << 20
+
+// SubscriptionResponse is the plan of the current user and their subscriptions.
+type SubscriptionResponse struct {
+	Plan          string                `json:"plan"`
+	Subscriptions []models.Subscription `json:"subscriptions"`
+}
+
+// StripeWebhook receives the Stripe webhook events. Events are authenticated by their
+// signature: forged, replayed or malformed events get 400, and events that could not be stored
+// get 500 so that Stripe retries them. Deliveries of events already applied are acknowledged
+// with 200 without applying them again.
+func StripeWebhook(svc *billing.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookSize))
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid webhook", "Could not read the request body"))
+			return
+		}
+
+		event, err := svc.HandleWebhook(c.Request.Context(), payload, c.GetHeader(billing.SignatureHeader))
+		if errors.Is(err, billing.ErrInvalidSignature) || errors.Is(err, billing.ErrSignatureExpired) ||
+			errors.Is(err, billing.ErrMalformedEvent) {
+			requestctx.Logger(c).WithField("error", err.Error()).Warn("Stripe webhook rejected")
+			_ = c.Error(apperrors.BadRequest("Invalid webhook", err.Error()))
+			return
+		}
+		if errors.Is(err, inbox.ErrDuplicate) {
+			requestctx.Logger(c).WithFields(map[string]interface{}{
+				"event_id":   event.ID,
+				"event_type": event.Type,
+			}).Info("Stripe webhook already processed")
+			response.SuccessResponse(c, http.StatusOK, "Webhook already processed", nil)
+			return
+		}
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not process webhook"))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+		}).Info("Stripe webhook received")
+		response.SuccessResponse(c, http.StatusOK, "Webhook received", nil)
+	}
+}
+
+// GetSubscription returns the plan and subscriptions of the current user.
+func GetSubscription(svc *billing.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subs, err := svc.Subscriptions(c.Request.Context(), requestctx.UserID(c))
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve subscription"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Subscription retrieved successfully",
+			SubscriptionResponse{Plan: billing.CurrentPlan(subs), Subscriptions: subs})
+	}
+}
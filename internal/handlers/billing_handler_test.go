@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/billing"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+const webhookSecret = "whsec_test"
+
+func newBillingApp(t *testing.T) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		svc := billing.NewService(a.DB, billing.Options{
+			WebhookSecret:    webhookSecret,
+			WebhookTolerance: 5 * time.Minute,
+			PricePlans:       map[string]string{"price_pro": "pro"},
+		})
+		a.Router.POST("/billing/webhook", StripeWebhook(svc))
+		a.Router.GET("/billing/subscription", middlewares.AuthRequired(a.DB), GetSubscription(svc))
+		a.Router.GET("/pro", middlewares.AuthRequired(a.DB), middlewares.RequirePlan(a.DB, "pro"), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+	})
+}
+
+// postWebhook sends a Stripe event signed with secret.
+func postWebhook(t *testing.T, app *testutil.App, event map[string]interface{}, secret string) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/billing/webhook", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(billing.SignatureHeader, billing.Sign(payload, secret, time.Now()))
+	w := httptest.NewRecorder()
+	app.Router.ServeHTTP(w, req)
+	return w
+}
+
+func subscriptionEvent(status string, created int64) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      fmt.Sprint("evt_", created),
+		"type":    billing.EventSubscriptionUpdated,
+		"created": created,
+		"data": map[string]interface{}{"object": map[string]interface{}{
+			"id":       "sub_1",
+			"customer": "cus_1",
+			"status":   status,
+			"items": map[string]interface{}{"data": []map[string]interface{}{{
+				"price": map[string]interface{}{"id": "price_pro"},
+			}}},
+		}},
+	}
+}
+
+func TestStripeWebhookGatesPlan(t *testing.T) {
+	app := newBillingApp(t)
+	user := testutil.CreateUser(t, app.DB)
+	app.DB.Create(&models.BillingCustomer{UserID: user.ID, CustomerID: "cus_1"})
+
+	w := testutil.Get("/pro").WithJWT(user).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusPaymentRequired, "PLAN_REQUIRED")
+
+	w = postWebhook(t, app, subscriptionEvent("active", 100), "whsec_forged")
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+	testutil.AssertError(t, testutil.Get("/pro").WithJWT(user).Do(t, app.Router), http.StatusPaymentRequired, "PLAN_REQUIRED")
+
+	testutil.AssertStatus(t, postWebhook(t, app, subscriptionEvent("active", 100), webhookSecret), http.StatusOK)
+	testutil.AssertStatus(t, testutil.Get("/pro").WithJWT(user).Do(t, app.Router), http.StatusNoContent)
+	var sub SubscriptionResponse
+	testutil.DecodeData(t, testutil.Get("/billing/subscription").WithJWT(user).Do(t, app.Router), &sub)
+	if sub.Plan != "pro" || len(sub.Subscriptions) != 1 {
+		t.Errorf("subscription = %+v", sub)
+	}
+
+	// Unpaid subscriptions lose the plan
+	testutil.AssertStatus(t, postWebhook(t, app, subscriptionEvent("unpaid", 200), webhookSecret), http.StatusOK)
+	testutil.AssertError(t, testutil.Get("/pro").WithJWT(user).Do(t, app.Router), http.StatusPaymentRequired, "PLAN_REQUIRED")
+	testutil.DecodeData(t, testutil.Get("/billing/subscription").WithJWT(user).Do(t, app.Router), &sub)
+	if sub.Plan != billing.PlanFree {
+		t.Errorf("plan = %s, want %s", sub.Plan, billing.PlanFree)
+	}
+
+	// Redeliveries are acknowledged without being applied again
+	w = postWebhook(t, app, subscriptionEvent("unpaid", 200), webhookSecret)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if !bytes.Contains(w.Body.Bytes(), []byte("Webhook already processed")) {
+		t.Errorf("redelivery: body = %s", w.Body.String())
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/cachecontrol"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// PurgeCacheRequest is the body of POST /api/admin/cache/purge.
+type PurgeCacheRequest struct {
+	Keys []string `json:"keys" binding:"required"`
+}
+
+// PurgeCacheResponse lists the surrogate keys that were purged.
+type PurgeCacheResponse struct {
+	Keys []string `json:"keys"`
+}
+
+// PurgeCache invalidates the CDN-cached responses tagged with any of the given surrogate keys.
+func PurgeCache(purger cachecontrol.Purger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req PurgeCacheRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		if len(req.Keys) == 0 || len(req.Keys) > cachecontrol.MaxPurgeKeys {
+			_ = c.Error(apperrors.BadRequest("Invalid request data",
+				fmt.Sprintf("keys must list between 1 and %d surrogate keys", cachecontrol.MaxPurgeKeys)))
+			return
+		}
+		for _, key := range req.Keys {
+			if err := cachecontrol.ValidateKey(key); err != nil {
+				_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+				return
+			}
+		}
+
+		if err := purger.Purge(c.Request.Context(), req.Keys); err != nil {
+			_ = c.Error(apperrors.Unavailable("Could not purge cache", "The CDN did not accept the purge request").Wrap(err))
+			return
+		}
+
+		requestctx.Logger(c).WithField("keys", req.Keys).Info("CDN cache purged")
+		response.SuccessResponse(c, http.StatusOK, "Cache purged successfully", PurgeCacheResponse(req))
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+type fakePurger struct {
+	keys [][]string
+	err  error
+}
+
+func (p *fakePurger) Purge(_ context.Context, keys []string) error {
+	p.keys = append(p.keys, keys)
+	return p.err
+}
+
+func purgeRouter(purger *fakePurger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler())
+	r.POST("/api/admin/cache/purge", PurgeCache(purger))
+	return r
+}
+
+func TestPurgeCache(t *testing.T) {
+	purger := &fakePurger{}
+	w := testutil.Post("/api/admin/cache/purge").
+		WithJSON(map[string]interface{}{"keys": []string{"api/errors", "user-1"}}).
+		Do(t, purgeRouter(purger))
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var resp PurgeCacheResponse
+	testutil.DecodeData(t, w, &resp)
+	if len(resp.Keys) != 2 || len(purger.keys) != 1 || purger.keys[0][1] != "user-1" {
+		t.Errorf("response %+v, purged %v", resp, purger.keys)
+	}
+}
+
+func TestPurgeCache_InvalidKeys(t *testing.T) {
+	purger := &fakePurger{}
+	r := purgeRouter(purger)
+
+	for _, keys := range [][]string{{}, {"two words"}, {""}} {
+		w := testutil.Post("/api/admin/cache/purge").WithJSON(map[string]interface{}{"keys": keys}).Do(t, r)
+		testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+	}
+	if len(purger.keys) != 0 {
+		t.Errorf("purged %v, want nothing", purger.keys)
+	}
+}
+
+func TestPurgeCache_CDNFailure(t *testing.T) {
+	w := testutil.Post("/api/admin/cache/purge").
+		WithJSON(map[string]interface{}{"keys": []string{"users"}}).
+		Do(t, purgeRouter(&fakePurger{err: errors.New("forbidden")}))
+	testutil.AssertStatus(t, w, http.StatusServiceUnavailable)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// RecordConsent records that the current user accepts the current version of the terms of
+// service (see config.TermsConfig). Accepting them again returns the existing record.
+func RecordConsent(db *gorm.DB, termsVersion string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if termsVersion == "" {
+			_ = c.Error(apperrors.NotFound("No terms to accept", "This service has no terms of service"))
+			return
+		}
+		var req validators.ConsentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		req.Normalize()
+		if req.Kind == "" {
+			req.Kind = models.ConsentTerms
+		}
+		if err := validators.ValidateConsent(&req, termsVersion); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		consent := newConsent(c, requestctx.UserID(c), req.Kind, req.Version)
+		err := db.WithContext(c.Request.Context()).Create(&consent).Error
+		if database.IsDuplicateKeyError(err) {
+			err = db.WithContext(c.Request.Context()).
+				Where("user_id = ? AND kind = ? AND version = ?", consent.UserID, consent.Kind, consent.Version).
+				First(&consent).Error
+			if err == nil {
+				response.SuccessResponse(c, http.StatusOK, "Consent already recorded", consent)
+				return
+			}
+		}
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not record consent"))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"consent_kind":    consent.Kind,
+			"consent_version": consent.Version,
+		}).Info("Consent recorded")
+		response.SuccessResponse(c, http.StatusCreated, "Consent recorded successfully", consent)
+	}
+}
+
+// ListConsents returns the consents of the current user, newest first.
+func ListConsents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		consents := []models.Consent{}
+		err := db.WithContext(c.Request.Context()).
+			Where("user_id = ?", requestctx.UserID(c)).
+			Order("accepted_at DESC, id DESC").
+			Find(&consents).Error
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve consents"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Consents retrieved successfully", consents)
+	}
+}
+
+// newConsent returns the consent of a user to a document version, with the request origin as
+// evidence.
+func newConsent(c *gin.Context, userID uint, kind, version string) models.Consent {
+	return models.Consent{
+		UserID:     userID,
+		Kind:       kind,
+		Version:    version,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		AcceptedAt: time.Now(),
+	}
+}
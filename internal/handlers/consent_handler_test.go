@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func newConsentApp(t *testing.T, version string) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		a.Router.POST("/register", Register(a.DB, RegistrationOptions{TermsVersion: version}))
+		a.Router.GET("/me", middlewares.AuthRequired(a.DB), middlewares.RequireConsent(a.DB, version, "https://example.com/terms"),
+			func(c *gin.Context) { response.SuccessResponse(c, http.StatusOK, "ok", requestctx.UserID(c)) })
+		consents := a.Router.Group("/consents", middlewares.AuthRequired(a.DB))
+		consents.GET("", ListConsents(a.DB))
+		consents.POST("", RecordConsent(a.DB, version))
+	})
+}
+
+func TestRegister_RecordsTermsAcceptance(t *testing.T) {
+	app := newConsentApp(t, "2026-01")
+
+	body := map[string]any{"username": "reader", "email": "reader@example.com", "password": "Str0ng!Secret"}
+	w := testutil.Post("/register").WithJSON(body).Do(t, app.Router)
+	testutil.AssertFieldError(t, w, "accept_terms", "required")
+
+	body["accept_terms"] = true
+	w = testutil.Post("/register").WithHeader("User-Agent", "consent-test").WithJSON(body).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+
+	var consent models.Consent
+	if err := app.DB.Joins("JOIN users ON users.id = consents.user_id").Where("users.username = ?", "reader").First(&consent).Error; err != nil {
+		t.Fatal(err)
+	}
+	if consent.Kind != models.ConsentTerms || consent.Version != "2026-01" || consent.UserAgent != "consent-test" || consent.IP == "" {
+		t.Errorf("consent = %+v", consent)
+	}
+}
+
+func TestRequireConsent(t *testing.T) {
+	app := newConsentApp(t, "2026-01")
+	user := testutil.CreateUser(t, app.DB)
+
+	w := testutil.Get("/me").WithJWT(user).Do(t, app.Router)
+	apiErr := testutil.AssertError(t, w, http.StatusUnavailableForLegalReasons, "TERMS_NOT_ACCEPTED")
+	if apiErr.Details == "" {
+		t.Error("expected the terms version in the error details")
+	}
+
+	w = testutil.Post("/consents").WithJWT(user).WithJSON(map[string]string{"version": "2025-06"}).Do(t, app.Router)
+	testutil.AssertFieldError(t, w, "version", "invalid_value")
+	w = testutil.Post("/consents").WithJWT(user).WithJSON(map[string]string{"kind": "privacy", "version": "2026-01"}).Do(t, app.Router)
+	testutil.AssertFieldError(t, w, "kind", "invalid_value")
+
+	w = testutil.Post("/consents").WithJWT(user).WithJSON(map[string]string{"version": "2026-01"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	w = testutil.Post("/consents").WithJWT(user).WithJSON(map[string]string{"kind": "terms", "version": "2026-01"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	testutil.AssertStatus(t, testutil.Get("/me").WithJWT(user).Do(t, app.Router), http.StatusOK)
+
+	var consents []models.Consent
+	testutil.DecodeData(t, testutil.Get("/consents").WithJWT(user).Do(t, app.Router), &consents)
+	if len(consents) != 1 || consents[0].Version != "2026-01" {
+		t.Errorf("consents = %+v", consents)
+	}
+}
+
+func TestRequireConsent_WithoutTerms(t *testing.T) {
+	app := newConsentApp(t, "")
+	user := testutil.CreateUser(t, app.DB)
+
+	testutil.AssertStatus(t, testutil.Get("/me").WithJWT(user).Do(t, app.Router), http.StatusOK)
+	w := testutil.Post("/consents").WithJWT(user).WithJSON(map[string]string{"version": "1"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+	w = testutil.Post("/register").WithJSON(map[string]string{
+		"username": "reader", "email": "reader@example.com", "password": "Str0ng!Secret",
+	}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+}
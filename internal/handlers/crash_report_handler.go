@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/crashreport"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// Crash reports listed by default and at most.
+const (
+	defaultCrashReportLimit = 20
+	maxCrashReportLimit     = 100
+)
+
+// ListCrashReports returns the summaries of the most recent crash reports, newest first; the
+// limit query parameter sets how many (20 by default, up to 100).
+func ListCrashReports(reports *crashreport.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := defaultCrashReportLimit
+		if value := c.Query("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxCrashReportLimit {
+				_ = c.Error(apperrors.BadRequest("Invalid limit", "limit must be between 1 and 100"))
+				return
+			}
+			limit = n
+		}
+
+		summaries, err := reports.List(c.Request.Context(), limit)
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Failed to list crash reports", "", err))
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		response.SuccessResponse(c, http.StatusOK, "Crash reports retrieved successfully", summaries)
+	}
+}
+
+// GetCrashReport returns a crash report with its stacks, by the ID returned in the details of
+// the error of the request that panicked.
+func GetCrashReport(reports *crashreport.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := reports.Get(c.Request.Context(), c.Param("id"))
+		if errors.Is(err, crashreport.ErrNotFound) {
+			_ = c.Error(apperrors.NotFound("Crash report not found", ""))
+			return
+		}
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Failed to read the crash report", "", err))
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		response.SuccessResponse(c, http.StatusOK, "Crash report retrieved successfully", report)
+	}
+}
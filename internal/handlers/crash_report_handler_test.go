@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/pkg/crashreport"
+	"github.com/yeferson59/gin-template/pkg/storage"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestCrashReports(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reports := crashreport.NewStore(storage.NewLocal(t.TempDir()), "", 10, nil)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandlerWithReports(reports))
+	r.GET("/panic", func(*gin.Context) { panic("handler bug") })
+	r.GET("/api/admin/crash-reports", ListCrashReports(reports))
+	r.GET("/api/admin/crash-reports/:id", GetCrashReport(reports))
+
+	w := testutil.Get("/panic").Do(t, r)
+	details := testutil.AssertError(t, w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR").Details
+	if !strings.Contains(details, "crash report ") {
+		t.Fatalf("details = %q, want the crash report ID", details)
+	}
+	id := strings.TrimSuffix(details[strings.Index(details, "crash report ")+len("crash report "):], ")")
+
+	w = testutil.Get("/api/admin/crash-reports").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var summaries []crashreport.Summary
+	testutil.DecodeData(t, w, &summaries)
+	if len(summaries) != 1 || summaries[0].ID != id || summaries[0].Request.Route != "/panic" {
+		t.Fatalf("summaries = %+v, want the report %s", summaries, id)
+	}
+
+	w = testutil.Get("/api/admin/crash-reports/"+id).Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var report crashreport.Report
+	testutil.DecodeData(t, w, &report)
+	if report.Panic != "handler bug" || !strings.Contains(report.Stack, "crash_report_handler_test.go") {
+		t.Errorf("report = %s, want the panic and its stack", report.Panic)
+	}
+
+	w = testutil.Get("/api/admin/crash-reports/unknown").Do(t, r)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+	w = testutil.Get("/api/admin/crash-reports?limit=1000").Do(t, r)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/notify"
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// EmailPreviewOptions are the options of the handlers that send emails, so that previews show
+// the links and expiry times of the deployment.
+type EmailPreviewOptions struct {
+	Invitations          InvitationOptions
+	EmailChanges         EmailChangeOptions
+	LoginVerificationTTL time.Duration
+}
+
+// EmailPreview is a transactional email rendered with sample data.
+type EmailPreview struct {
+	Kind    string `json:"kind"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// ListEmailPreviews renders every transactional email with sample data, for iterating on their
+// wording in development. Origins are described with the IP and User-Agent of the request.
+func ListEmailPreviews(opts EmailPreviewOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		user := &models.User{ID: 1, Username: "jane", Email: "jane@example.com"}
+		origin := loginOrigin{location: &security.Location{Country: "DE"}, newDevice: true, newCountry: true}
+		org := &models.Organization{Name: "Acme"}
+		invitation := &models.Invitation{Email: "sam@example.com", Role: models.OrgRoleMember, ExpiresAt: now.Add(DefaultInvitationTTL)}
+		change := &models.EmailChange{
+			OldEmail:        user.Email,
+			NewEmail:        "jane.doe@example.com",
+			ExpiresAt:       now.Add(time.Hour),
+			RevertExpiresAt: now.Add(7 * 24 * time.Hour),
+		}
+		const token = "sample-token"
+
+		messages := []notify.Message{
+			loginAlertMessage(c, user, origin, KindLoginNewDevice),
+			loginAlertMessage(c, user, origin, KindLoginNewCountry),
+			loginVerificationMessage(c, user, origin, "123456", opts.LoginVerificationTTL),
+			invitationMessage(c, org, invitation, token, opts.Invitations),
+			emailConfirmMessage(user, change, token, opts.EmailChanges),
+			emailNoticeMessage(user, change, token, opts.EmailChanges),
+		}
+		previews := make([]EmailPreview, len(messages))
+		for i, msg := range messages {
+			previews[i] = EmailPreview{Kind: msg.Kind, To: msg.Email, Subject: msg.Subject, Body: msg.Body}
+		}
+		response.SuccessResponse(c, http.StatusOK, "Email previews rendered successfully", previews)
+	}
+}
+
+// ListCapturedEmails returns the emails kept by inbox instead of being sent, newest first.
+func ListCapturedEmails(inbox *notify.Inbox) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.SuccessResponse(c, http.StatusOK, "Captured emails retrieved successfully", inbox.Emails())
+	}
+}
+
+// ClearCapturedEmails empties inbox.
+func ClearCapturedEmails(inbox *notify.Inbox) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inbox.Clear()
+		response.SuccessResponse(c, http.StatusOK, "Captured emails deleted successfully", nil)
+	}
+}
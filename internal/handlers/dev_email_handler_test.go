@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/notify"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestListEmailPreviews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/dev/emails", ListEmailPreviews(EmailPreviewOptions{
+		Invitations:          InvitationOptions{AcceptURL: "https://app.example.com/invitations"},
+		LoginVerificationTTL: 10 * time.Minute,
+	}))
+
+	var previews []EmailPreview
+	testutil.DecodeData(t, testutil.Get("/dev/emails").Do(t, r), &previews)
+
+	kinds := make(map[string]EmailPreview)
+	for _, p := range previews {
+		kinds[p.Kind] = p
+	}
+	for _, kind := range []string{
+		KindLoginNewDevice, KindLoginNewCountry, KindLoginVerification,
+		KindOrgInvitation, KindEmailChangeConfirm, KindEmailChangeNotice,
+	} {
+		if p, ok := kinds[kind]; !ok || p.To == "" || p.Subject == "" || p.Body == "" {
+			t.Errorf("preview of %s = %+v, want it rendered", kind, p)
+		}
+	}
+	if body := kinds[KindOrgInvitation].Body; !strings.Contains(body, "https://app.example.com/invitations?token=") {
+		t.Errorf("invitation body = %q, want the configured accept URL", body)
+	}
+	if body := kinds[KindLoginVerification].Body; !strings.Contains(body, "expires in 10m0s") {
+		t.Errorf("verification body = %q, want the configured TTL", body)
+	}
+}
+
+func TestCapturedEmails(t *testing.T) {
+	inbox := notify.NewInbox(10)
+	_ = inbox.Notify(context.Background(), notify.Message{Email: "jane@example.com", Kind: KindOrgInvitation, Subject: "Join Acme"})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/dev/emails/inbox", ListCapturedEmails(inbox))
+	r.DELETE("/dev/emails/inbox", ClearCapturedEmails(inbox))
+
+	var emails []notify.CapturedEmail
+	testutil.DecodeData(t, testutil.Get("/dev/emails/inbox").Do(t, r), &emails)
+	if len(emails) != 1 || emails[0].To != "jane@example.com" || emails[0].Subject != "Join Acme" {
+		t.Fatalf("captured emails = %+v", emails)
+	}
+
+	testutil.AssertStatus(t, testutil.NewRequest(http.MethodDelete, "/dev/emails/inbox").Do(t, r), http.StatusOK)
+	testutil.DecodeData(t, testutil.Get("/dev/emails/inbox").Do(t, r), &emails)
+	if len(emails) != 0 {
+		t.Errorf("captured emails after DELETE = %+v", emails)
+	}
+}
@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// DeviceCodeGrantType is the grant_type of token requests with a device code.
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// userCodeAlphabet has no vowels, so codes spell no words, and no characters that are easily
+// confused (RFC 8628, section 6.1).
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters of a user code, shown as XXXX-XXXX.
+const userCodeLength = 8
+
+// slowDownStep is added to the polling interval of a client that polls too fast.
+const slowDownStep = 5 * time.Second
+
+// OAuth error codes of the device flow (RFC 8628, section 3.5, and RFC 6749, section 5.2).
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthAuthorizationPending = "authorization_pending"
+	OAuthSlowDown             = "slow_down"
+	OAuthAccessDenied         = "access_denied"
+	OAuthExpiredToken         = "expired_token"
+)
+
+// DeviceAuthOptions configures the device authorization grant (see config.DeviceAuthConfig).
+type DeviceAuthOptions struct {
+	ClientIDs []string
+	// VerificationPath serves the verification page (see deviceui); VerificationURL overrides
+	// the URL built from the request and this path, and is required in production.
+	VerificationPath string
+	VerificationURL  string
+	CodeTTL          time.Duration
+	PollInterval     time.Duration
+}
+
+// DeviceCodeRequest starts a device authorization.
+type DeviceCodeRequest struct {
+	ClientID string `json:"client_id" form:"client_id"`
+	Scope    string `json:"scope" form:"scope"`
+}
+
+// DeviceCodeResponse is the device authorization response (RFC 8628, section 3.2). The client
+// shows the user code and verification URI, and polls the token endpoint with the device code.
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeviceTokenRequest polls for the token of a device authorization.
+type DeviceTokenRequest struct {
+	GrantType  string `json:"grant_type" form:"grant_type"`
+	DeviceCode string `json:"device_code" form:"device_code"`
+	ClientID   string `json:"client_id" form:"client_id"`
+}
+
+// TokenResponse is the OAuth access token response (RFC 6749, section 5.1).
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// OAuthErrorResponse is the OAuth error response (RFC 6749, section 5.2). OAuth clients expect
+// this body rather than the API error envelope.
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// DeviceVerificationResponse describes a pending device authorization to the user who is
+// asked to approve it.
+type DeviceVerificationResponse struct {
+	UserCode  string    `json:"user_code"`
+	ClientID  string    `json:"client_id"`
+	Scope     string    `json:"scope,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DeviceVerifyRequest approves or denies a device authorization.
+type DeviceVerifyRequest struct {
+	UserCode string `json:"user_code"`
+	Approve  bool   `json:"approve"`
+}
+
+var errUnknownUserCode = apperrors.NotFound("Unknown code", "The code is invalid or has expired")
+
+// DeviceAuthorize issues a device code and a user code to a client that cannot open a
+// browser, such as a CLI.
+func DeviceAuthorize(db *gorm.DB, opts DeviceAuthOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req DeviceCodeRequest
+		if err := c.ShouldBind(&req); err != nil || req.ClientID == "" {
+			oauthError(c, http.StatusBadRequest, OAuthInvalidRequest, "client_id is required")
+			return
+		}
+		if !opts.allowsClient(req.ClientID) {
+			oauthError(c, http.StatusUnauthorized, OAuthInvalidClient, "Unknown client_id")
+			return
+		}
+
+		deviceCode, err := randomToken()
+		if err != nil {
+			oauthError(c, http.StatusInternalServerError, "server_error", "")
+			return
+		}
+		authorization := models.DeviceAuthorization{
+			DeviceCodeHash: hashCode(deviceCode),
+			ClientID:       req.ClientID,
+			Scope:          req.Scope,
+			Status:         models.DeviceAuthPending,
+			Interval:       int(opts.PollInterval / time.Second),
+			ExpiresAt:      auth.Now().Add(opts.CodeTTL),
+		}
+		// User codes are short, so a collision with a live code is possible if unlikely
+		for attempt := 0; attempt < 3; attempt++ {
+			if authorization.UserCode, err = randomUserCode(); err != nil {
+				break
+			}
+			if err = db.Create(&authorization).Error; err == nil {
+				break
+			}
+		}
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to create device authorization")
+			oauthError(c, http.StatusInternalServerError, "server_error", "")
+			return
+		}
+
+		verificationURI := opts.verificationURL(c)
+		userCode := formatUserCode(authorization.UserCode)
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, DeviceCodeResponse{
+			DeviceCode:              deviceCode,
+			UserCode:                userCode,
+			VerificationURI:         verificationURI,
+			VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(userCode),
+			ExpiresIn:               int(opts.CodeTTL / time.Second),
+			Interval:                authorization.Interval,
+		})
+	}
+}
+
+// DeviceToken is the token endpoint polled by the client with its device code. It answers
+// authorization_pending until the user approves or denies the code, and slow_down when the
+// client polls faster than the interval, which then grows by 5 seconds.
+func DeviceToken(db *gorm.DB, opts DeviceAuthOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req DeviceTokenRequest
+		if err := c.ShouldBind(&req); err != nil || req.DeviceCode == "" {
+			oauthError(c, http.StatusBadRequest, OAuthInvalidRequest, "device_code is required")
+			return
+		}
+		if req.GrantType != DeviceCodeGrantType {
+			oauthError(c, http.StatusBadRequest, OAuthUnsupportedGrantType, "Only the device_code grant is supported")
+			return
+		}
+
+		var authorization models.DeviceAuthorization
+		if err := db.Where("device_code_hash = ?", hashCode(req.DeviceCode)).First(&authorization).Error; err != nil {
+			oauthError(c, http.StatusBadRequest, OAuthInvalidGrant, "Unknown device_code")
+			return
+		}
+		if req.ClientID != authorization.ClientID {
+			oauthError(c, http.StatusUnauthorized, OAuthInvalidClient, "The device_code belongs to another client")
+			return
+		}
+
+		now := auth.Now()
+		if now.After(authorization.ExpiresAt) {
+			db.Delete(&authorization)
+			oauthError(c, http.StatusBadRequest, OAuthExpiredToken, "The device_code has expired")
+			return
+		}
+
+		switch authorization.Status {
+		case models.DeviceAuthDenied:
+			db.Delete(&authorization)
+			oauthError(c, http.StatusBadRequest, OAuthAccessDenied, "The user denied the request")
+		case models.DeviceAuthApproved:
+			issueDeviceToken(c, db, &authorization)
+		default:
+			interval := time.Duration(authorization.Interval) * time.Second
+			tooFast := authorization.LastPolledAt != nil && now.Sub(*authorization.LastPolledAt) < interval
+			updates := map[string]interface{}{"last_polled_at": now}
+			if tooFast {
+				updates["interval"] = authorization.Interval + int(slowDownStep/time.Second)
+			}
+			db.Model(&authorization).Updates(updates)
+			if tooFast {
+				oauthError(c, http.StatusBadRequest, OAuthSlowDown, "Polling too fast")
+				return
+			}
+			oauthError(c, http.StatusBadRequest, OAuthAuthorizationPending, "")
+		}
+	}
+}
+
+// issueDeviceToken redeems an approved authorization, once, for a token of the approving user.
+func issueDeviceToken(c *gin.Context, db *gorm.DB, authorization *models.DeviceAuthorization) {
+	if result := db.Delete(authorization); result.Error != nil || result.RowsAffected == 0 {
+		oauthError(c, http.StatusBadRequest, OAuthInvalidGrant, "The device_code was already used")
+		return
+	}
+
+	var user models.User
+	if authorization.UserID == nil || db.First(&user, *authorization.UserID).Error != nil {
+		oauthError(c, http.StatusBadRequest, OAuthInvalidGrant, "The approving user no longer exists")
+		return
+	}
+	risk := recordLoginEvent(c, db, &user, inspectLogin(c, db, &user))
+	token, err := auth.GenerateLoginJWT(user.ID, user.Email, risk)
+	var claims *auth.Claims
+	if err == nil {
+		claims, err = auth.ValidateJWT(token)
+	}
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to generate device token")
+		oauthError(c, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"user_id":   user.ID,
+		"client_id": authorization.ClientID,
+	}).Info("Device authorization completed")
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(claims.ExpiresAt.Time) / time.Second),
+	})
+}
+
+// DeviceVerification shows the signed-in user the pending authorization of a user code, so
+// they can check the client before approving it.
+func DeviceVerification(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorization, err := findPendingDevice(db, c.Query("user_code"))
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Device authorization pending", DeviceVerificationResponse{
+			UserCode:  formatUserCode(authorization.UserCode),
+			ClientID:  authorization.ClientID,
+			Scope:     authorization.Scope,
+			ExpiresAt: authorization.ExpiresAt,
+		})
+	}
+}
+
+// VerifyDevice approves or denies the pending authorization of a user code for the signed-in
+// user. An approved client receives a token for this user on its next poll.
+func VerifyDevice(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := requestctx.CurrentUser(c)
+		if !ok {
+			_ = c.Error(apperrors.Unauthorized("User not authenticated", "Please log in to access this resource"))
+			return
+		}
+		var req DeviceVerifyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+
+		authorization, err := findPendingDevice(db, req.UserCode)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		status := models.DeviceAuthDenied
+		if req.Approve {
+			status = models.DeviceAuthApproved
+		}
+		// Only a pending authorization changes, so a code is approved or denied once
+		result := db.Model(&models.DeviceAuthorization{}).
+			Where("id = ? AND status = ?", authorization.ID, models.DeviceAuthPending).
+			Updates(map[string]interface{}{"status": status, "user_id": user.ID})
+		if result.Error != nil {
+			_ = c.Error(repository.TranslateError(result.Error, "Failed to verify device"))
+			return
+		}
+		if result.RowsAffected == 0 {
+			_ = c.Error(errUnknownUserCode)
+			return
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"user_id":   user.ID,
+			"client_id": authorization.ClientID,
+			"status":    status,
+		}).Info("Device authorization verified")
+		message := "Device denied"
+		if req.Approve {
+			message = "Device approved"
+		}
+		response.SuccessResponse(c, http.StatusOK, message, gin.H{"status": status})
+	}
+}
+
+// findPendingDevice returns the pending, unexpired authorization of a user code as typed by
+// a user: case, dashes and spaces are ignored.
+func findPendingDevice(db *gorm.DB, userCode string) (*models.DeviceAuthorization, error) {
+	code := normalizeUserCode(userCode)
+	if len(code) != userCodeLength {
+		return nil, errUnknownUserCode
+	}
+	var authorization models.DeviceAuthorization
+	err := db.Where("user_code = ? AND status = ? AND expires_at > ?", code, models.DeviceAuthPending, auth.Now()).
+		First(&authorization).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errUnknownUserCode
+	}
+	if err != nil {
+		return nil, repository.TranslateError(err, "Failed to find device authorization")
+	}
+	return &authorization, nil
+}
+
+func (o DeviceAuthOptions) allowsClient(clientID string) bool {
+	if len(o.ClientIDs) == 0 {
+		return true
+	}
+	for _, id := range o.ClientIDs {
+		if id == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// verificationURL returns the configured URL of the verification page, or else builds it from
+// the request, which the startup checks only allow outside production since clients choose
+// the Host header.
+func (o DeviceAuthOptions) verificationURL(c *gin.Context) string {
+	if o.VerificationURL != "" {
+		return o.VerificationURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := strings.ToLower(c.GetHeader("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + o.VerificationPath
+}
+
+func oauthError(c *gin.Context, status int, code, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.AbortWithStatusJSON(status, OAuthErrorResponse{Error: code, ErrorDescription: description})
+}
+
+func randomUserCode() (string, error) {
+	b := make([]byte, userCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+func normalizeUserCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if strings.ContainsRune(userCodeAlphabet, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func formatUserCode(code string) string {
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
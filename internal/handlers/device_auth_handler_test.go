@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestDeviceFlow(t *testing.T) {
+	testutil.SetJWTSecret(t)
+	db := testutil.NewDB(t)
+	user := testutil.CreateUser(t, db)
+	opts := DeviceAuthOptions{
+		ClientIDs:        []string{"cli"},
+		VerificationPath: "/device",
+		CodeTTL:          10 * time.Minute,
+		PollInterval:     5 * time.Second,
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler())
+	r.POST("/device/code", DeviceAuthorize(db, opts))
+	r.POST("/token", DeviceToken(db, opts))
+	verify := r.Group("/device/verify", func(c *gin.Context) {
+		requestctx.SetUser(c, user, time.Time{})
+	})
+	verify.GET("", DeviceVerification(db))
+	verify.POST("", VerifyDevice(db))
+
+	form := func(path string, values url.Values) *httptest.ResponseRecorder {
+		return testutil.Post(path).
+			WithJSON(values.Encode()).
+			WithHeader("Content-Type", "application/x-www-form-urlencoded").
+			Do(t, r)
+	}
+	start := func() DeviceCodeResponse {
+		w := form("/device/code", url.Values{"client_id": {"cli"}})
+		testutil.AssertStatus(t, w, http.StatusOK)
+		var code DeviceCodeResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &code); err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+	poll := func(code DeviceCodeResponse) *httptest.ResponseRecorder {
+		// Keep the polls of the test apart by more than the interval
+		db.Model(&models.DeviceAuthorization{}).Where("1 = 1").Update("last_polled_at", time.Now().Add(-time.Minute))
+		return form("/token", url.Values{
+			"grant_type":  {DeviceCodeGrantType},
+			"device_code": {code.DeviceCode},
+			"client_id":   {"cli"},
+		})
+	}
+	assertOAuthError := func(w *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		var body OAuthErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != status || body.Error != code {
+			t.Fatalf("got %d %s, want %d %s", w.Code, w.Body.String(), status, code)
+		}
+	}
+
+	code := start()
+	if !strings.HasPrefix(code.VerificationURIComplete, "http://example.com/device?user_code=") ||
+		len(code.UserCode) != 9 || code.ExpiresIn != 600 || code.Interval != 5 {
+		t.Fatalf("device code response = %+v", code)
+	}
+	assertOAuthError(form("/device/code", url.Values{"client_id": {"other"}}), http.StatusUnauthorized, OAuthInvalidClient)
+
+	assertOAuthError(poll(code), http.StatusBadRequest, OAuthAuthorizationPending)
+	// Polling again right away slows the client down
+	w := form("/token", url.Values{"grant_type": {DeviceCodeGrantType}, "device_code": {code.DeviceCode}, "client_id": {"cli"}})
+	assertOAuthError(w, http.StatusBadRequest, OAuthSlowDown)
+	var pending models.DeviceAuthorization
+	db.First(&pending)
+	if pending.Interval != 10 {
+		t.Errorf("interval after slow_down = %d, want 10", pending.Interval)
+	}
+
+	// The user looks up the code as typed and approves it
+	typed := strings.ToLower(strings.ReplaceAll(code.UserCode, "-", " "))
+	var verification DeviceVerificationResponse
+	testutil.DecodeData(t, testutil.Get("/device/verify?user_code="+url.QueryEscape(typed)).Do(t, r), &verification)
+	if verification.ClientID != "cli" || verification.UserCode != code.UserCode {
+		t.Fatalf("verification = %+v", verification)
+	}
+	w = testutil.Post("/device/verify").WithJSON(DeviceVerifyRequest{UserCode: typed, Approve: true}).Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	// A code is approved or denied once
+	w = testutil.Post("/device/verify").WithJSON(DeviceVerifyRequest{UserCode: typed}).Do(t, r)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+
+	w = poll(code)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var token TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &token); err != nil {
+		t.Fatal(err)
+	}
+	claims, err := auth.ValidateJWT(token.AccessToken)
+	if err != nil || claims.UserID != user.ID || token.TokenType != "Bearer" || token.ExpiresIn <= 0 {
+		t.Fatalf("token = %+v, claims = %+v, %v", token, claims, err)
+	}
+	// The device code is redeemed once
+	assertOAuthError(poll(code), http.StatusBadRequest, OAuthInvalidGrant)
+
+	// Denied codes end the flow
+	denied := start()
+	w = testutil.Post("/device/verify").WithJSON(DeviceVerifyRequest{UserCode: denied.UserCode}).Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	assertOAuthError(poll(denied), http.StatusBadRequest, OAuthAccessDenied)
+
+	// Expired codes can no longer be approved or polled
+	expired := start()
+	db.Model(&models.DeviceAuthorization{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Second))
+	w = testutil.Get("/device/verify?user_code="+expired.UserCode).Do(t, r)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+	assertOAuthError(poll(expired), http.StatusBadRequest, OAuthExpiredToken)
+
+	assertOAuthError(form("/token", url.Values{"grant_type": {"password"}, "device_code": {"x"}}), http.StatusBadRequest, OAuthUnsupportedGrantType)
+}
+
+func TestDeviceVerificationURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		proto      string
+		want       string
+	}{
+		{"configured", "https://app.example.com/device", "http", "https://app.example.com/device"},
+		{"from the request", "", "", "http://example.com/device"},
+		{"behind a TLS proxy", "", "https", "https://example.com/device"},
+		{"invalid forwarded proto", "", "javascript", "http://example.com/device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/device/code", nil)
+			if tt.proto != "" {
+				c.Request.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			opts := DeviceAuthOptions{VerificationPath: "/device", VerificationURL: tt.configured}
+			if got := opts.verificationURL(c); got != tt.want {
+				t.Errorf("verificationURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
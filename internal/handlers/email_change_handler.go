@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/notify"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// Notification kinds of email changes.
+const (
+	KindEmailChangeConfirm = "email.change_confirm"
+	KindEmailChangeNotice  = "email.change_notice"
+)
+
+// Defaults of EmailChangeOptions.
+const (
+	DefaultEmailChangeTTL = 24 * time.Hour
+	DefaultEmailRevertTTL = 7 * 24 * time.Hour
+)
+
+// EmailChangeOptions configures email changes (see config.AccountConfig).
+type EmailChangeOptions struct {
+	// Mailer sends the confirmation to the new address and the revert link to the old one;
+	// without it email changes are not available.
+	Mailer notify.Notifier
+	// ConfirmTTL and RevertTTL are how long the new address can confirm the change and the old
+	// one revert it; zero means DefaultEmailChangeTTL and DefaultEmailRevertTTL.
+	ConfirmTTL time.Duration
+	RevertTTL  time.Duration
+	// ConfirmURL and RevertURL are the frontend pages that confirm and revert changes; the
+	// token is appended as ?token=.
+	ConfirmURL string
+	RevertURL  string
+}
+
+// EmailTokenRequest confirms or reverts an email change with its emailed token.
+type EmailTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+var (
+	errEmailTaken          = apperrors.Conflict("Email not changed", "The email address is already in use")
+	errUnknownEmailChange  = apperrors.NotFound("Email change not found", "The link is invalid, expired, or was already used")
+	errEmailChangeDisabled = apperrors.Unavailable("Email changes are not available", "Email delivery is not configured")
+	errEmailChangeNotSent  = apperrors.Unavailable("Could not send confirmation email", "Please try again later")
+)
+
+// RequestEmailChange starts changing the current user's email: the new address receives a link
+// to confirm it, and the current address, which stays in use until then, a notice with a link
+// to revert the change. A new request replaces the pending one.
+func RequestEmailChange(db *gorm.DB, opts EmailChangeOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := requestctx.CurrentUser(c)
+		if !ok {
+			_ = c.Error(apperrors.Unauthorized("Authorization required", "No authenticated user"))
+			return
+		}
+		if opts.Mailer == nil {
+			_ = c.Error(errEmailChangeDisabled)
+			return
+		}
+		var req validators.EmailChangeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		req.Normalize()
+		if err := validators.ValidateEmailChange(&req); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		if err := auth.ComparePassword(c.Request.Context(), user.Password, req.Password); err != nil {
+			_ = c.Error(apperrors.Forbidden("Email not changed", "The password is incorrect"))
+			return
+		}
+		email := models.NormalizeEmail(req.Email)
+		if email == models.NormalizeEmail(user.Email) {
+			_ = c.Error(apperrors.BadRequest("Email not changed", "The new email is your current one"))
+			return
+		}
+
+		confirmToken, err := randomToken()
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Could not change email", "Could not generate token", err))
+			return
+		}
+		revertToken, err := randomToken()
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Could not change email", "Could not generate token", err))
+			return
+		}
+		confirmTTL, revertTTL := opts.ConfirmTTL, opts.RevertTTL
+		if confirmTTL <= 0 {
+			confirmTTL = DefaultEmailChangeTTL
+		}
+		if revertTTL <= 0 {
+			revertTTL = DefaultEmailRevertTTL
+		}
+		now := auth.Now()
+		change := models.EmailChange{
+			UserID:           user.ID,
+			OldEmail:         models.NormalizeEmail(user.Email),
+			NewEmail:         email,
+			ConfirmTokenHash: hashCode(confirmToken),
+			RevertTokenHash:  hashCode(revertToken),
+			ExpiresAt:        now.Add(confirmTTL),
+			RevertExpiresAt:  now.Add(revertTTL),
+		}
+		err = database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			var taken int64
+			if err := tx.Model(&models.User{}).Where("email = ?", email).Count(&taken).Error; err != nil {
+				return err
+			}
+			if taken > 0 {
+				return errEmailTaken
+			}
+			err := tx.Where("user_id = ? AND confirmed_at IS NULL AND reverted_at IS NULL", user.ID).
+				Delete(&models.EmailChange{}).Error
+			if err != nil {
+				return err
+			}
+			return tx.Create(&change).Error
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not change email"))
+			return
+		}
+
+		ctx := c.Request.Context()
+		for _, msg := range []notify.Message{
+			emailConfirmMessage(user, &change, confirmToken, opts),
+			emailNoticeMessage(user, &change, revertToken, opts),
+		} {
+			if err := opts.Mailer.Notify(ctx, msg); err != nil {
+				db.Delete(&change)
+				_ = c.Error(errEmailChangeNotSent.Wrap(err))
+				return
+			}
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"user_id":         user.ID,
+			"email_change_id": change.ID,
+		}).Info("Email change requested")
+		response.SuccessResponse(c, http.StatusAccepted, "Confirmation email sent", change)
+	}
+}
+
+// emailConfirmMessage is the email to the new address that confirms the change.
+func emailConfirmMessage(user *models.User, change *models.EmailChange, token string, opts EmailChangeOptions) notify.Message {
+	confirm := "Confirm it by sending this token to POST /api/auth/email/confirm:\n\n" + token
+	if opts.ConfirmURL != "" {
+		confirm = "Confirm it at:\n\n" + opts.ConfirmURL + "?token=" + url.QueryEscape(token)
+	}
+	return notify.Message{
+		Email:   change.NewEmail,
+		Kind:    KindEmailChangeConfirm,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("%s asked to use this address for their account.\n\n%s\n\n"+
+			"The link expires on %s. If you did not ask for it, ignore this email.",
+			user.Username, confirm, change.ExpiresAt.UTC().Format(time.RFC1123)),
+	}
+}
+
+// emailNoticeMessage is the email to the old address with the link that reverts the change.
+func emailNoticeMessage(user *models.User, change *models.EmailChange, token string, opts EmailChangeOptions) notify.Message {
+	revert := "revert it by sending this token to POST /api/auth/email/revert:\n\n" + token
+	if opts.RevertURL != "" {
+		revert = "revert it at:\n\n" + opts.RevertURL + "?token=" + url.QueryEscape(token)
+	}
+	return notify.Message{
+		UserID:  user.ID,
+		Email:   change.OldEmail,
+		Kind:    KindEmailChangeNotice,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Someone asked to change the email of your account %s to %s. This address stays "+
+			"in use until the new one is confirmed.\n\nIf this was not you, %s\n\nThen change your password. "+
+			"The link works until %s, also after the change is confirmed.",
+			user.Username, change.NewEmail, revert, change.RevertExpiresAt.UTC().Format(time.RFC1123)),
+	}
+}
+
+// ConfirmEmailChange makes the new address of a pending change the user's email. It needs no
+// session: the token proves access to the new address.
+func ConfirmEmailChange(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req EmailTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+
+		var change models.EmailChange
+		var user models.User
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			err := tx.Where("confirm_token_hash = ? AND confirmed_at IS NULL AND reverted_at IS NULL AND expires_at > ?",
+				hashCode(req.Token), auth.Now()).First(&change).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errUnknownEmailChange
+			}
+			if err != nil {
+				return err
+			}
+
+			// The change applies to the address it was requested from; a change made since
+			// then, such as by an admin, voids it
+			result := tx.Model(&models.User{}).
+				Where("id = ? AND email = ?", change.UserID, change.OldEmail).
+				Update("email", change.NewEmail)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errUnknownEmailChange
+			}
+			result = tx.Model(&models.EmailChange{}).
+				Where("id = ? AND confirmed_at IS NULL", change.ID).
+				Update("confirmed_at", auth.Now())
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errUnknownEmailChange
+			}
+			return tx.First(&user, change.UserID).Error
+		})
+		if err != nil {
+			if database.IsDuplicateKeyError(err) {
+				_ = c.Error(errEmailTaken.Wrap(err))
+				return
+			}
+			_ = c.Error(repository.TranslateError(err, "Could not confirm email change"))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"user_id":         user.ID,
+			"email_change_id": change.ID,
+		}).Info("Email change confirmed")
+		response.SuccessResponse(c, http.StatusOK, "Email changed successfully", &UserSafeResponse{
+			ID:       user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+		})
+	}
+}
+
+// RevertEmailChange cancels a pending email change or, once confirmed, restores the previous
+// address. It needs no session, since whoever changed the email may control it.
+func RevertEmailChange(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req EmailTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+
+		var change models.EmailChange
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			err := tx.Where("revert_token_hash = ? AND reverted_at IS NULL AND revert_expires_at > ?",
+				hashCode(req.Token), auth.Now()).First(&change).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errUnknownEmailChange
+			}
+			if err != nil {
+				return err
+			}
+
+			result := tx.Model(&models.EmailChange{}).
+				Where("id = ? AND reverted_at IS NULL", change.ID).
+				Update("reverted_at", auth.Now())
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errUnknownEmailChange
+			}
+			if change.ConfirmedAt == nil {
+				return nil
+			}
+			return tx.Model(&models.User{}).
+				Where("id = ? AND email = ?", change.UserID, change.NewEmail).
+				Update("email", change.OldEmail).Error
+		})
+		if err != nil {
+			if database.IsDuplicateKeyError(err) {
+				_ = c.Error(apperrors.Conflict("Could not revert email change", "The previous email address is now in use").Wrap(err))
+				return
+			}
+			_ = c.Error(repository.TranslateError(err, "Could not revert email change"))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"user_id":         change.UserID,
+			"email_change_id": change.ID,
+			"confirmed":       change.ConfirmedAt != nil,
+		}).Warn("Email change reverted")
+		response.SuccessResponse(c, http.StatusOK, "Email change reverted successfully", nil)
+	}
+}
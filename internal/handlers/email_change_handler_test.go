@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/clock"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func newEmailChangeApp(t *testing.T, opts EmailChangeOptions) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		a.Router.PUT("/users/me/email", middlewares.AuthRequired(a.DB), RequestEmailChange(a.DB, opts))
+		a.Router.PATCH("/users/me", middlewares.AuthRequired(a.DB),
+			UpdateProfile(a.DB, ProfileOptions{ConfirmEmailChanges: opts.Mailer != nil}))
+		a.Router.POST("/auth/email/confirm", ConfirmEmailChange(a.DB))
+		a.Router.POST("/auth/email/revert", RevertEmailChange(a.DB))
+	})
+}
+
+func requestEmailChange(user *models.User, email, password string) *testutil.Request {
+	return testutil.NewRequest(http.MethodPut, "/users/me/email").WithJWT(user).
+		WithJSON(map[string]string{"email": email, "password": password})
+}
+
+// emailToken returns the token following prefix in the last message sent to email.
+func emailToken(t *testing.T, mail *outbox, email, prefix string) string {
+	t.Helper()
+	for i := len(mail.messages) - 1; i >= 0; i-- {
+		msg := mail.messages[i]
+		if msg.Email != email {
+			continue
+		}
+		if _, token, found := strings.Cut(msg.Body, prefix); found {
+			return strings.Fields(token)[0]
+		}
+	}
+	t.Fatalf("no token after %q sent to %s in %+v", prefix, email, mail.messages)
+	return ""
+}
+
+func currentEmail(t *testing.T, app *testutil.App, user *models.User) string {
+	t.Helper()
+	var got models.User
+	if err := app.DB.First(&got, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return got.Email
+}
+
+func TestEmailChangeConfirmAndRevert(t *testing.T) {
+	mail := &outbox{}
+	app := newEmailChangeApp(t, EmailChangeOptions{
+		Mailer:     mail,
+		ConfirmURL: "https://app.example.com/email/confirm",
+		RevertURL:  "https://app.example.com/email/revert",
+	})
+	user := testutil.CreateUser(t, app.DB, testutil.WithEmail("old@example.com"))
+	testutil.CreateUser(t, app.DB, testutil.WithEmail("taken@example.com"))
+
+	w := requestEmailChange(user, "new@example.com", "wrong-password").Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+	w = requestEmailChange(user, "Taken@Example.com", testutil.DefaultPassword).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+	w = requestEmailChange(user, "not-an-email", testutil.DefaultPassword).Do(t, app.Router)
+	testutil.AssertFieldError(t, w, "email", "invalid_format")
+
+	// PATCH cannot skip the confirmation
+	w = testutil.Patch("/users/me").WithJWT(user).WithJSON(map[string]string{"email": "new@example.com"}).
+		WithHeader("Content-Type", "application/merge-patch+json").Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+
+	w = requestEmailChange(user, "New@Example.com", testutil.DefaultPassword).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusAccepted)
+	if len(mail.messages) != 2 {
+		t.Fatalf("sent %d messages, want the confirmation and the notice", len(mail.messages))
+	}
+	confirm := emailToken(t, mail, "new@example.com", "https://app.example.com/email/confirm?token=")
+	revert := emailToken(t, mail, "old@example.com", "https://app.example.com/email/revert?token=")
+	if got := currentEmail(t, app, user); got != "old@example.com" {
+		t.Fatalf("email = %s before confirmation, want the old one", got)
+	}
+
+	// The revert token does not confirm, and tokens are used once
+	w = testutil.Post("/auth/email/confirm").WithJSON(map[string]string{"token": revert}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+	w = testutil.Post("/auth/email/confirm").WithJSON(map[string]string{"token": confirm}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := currentEmail(t, app, user); got != "new@example.com" {
+		t.Fatalf("email = %s after confirmation", got)
+	}
+	w = testutil.Post("/auth/email/confirm").WithJSON(map[string]string{"token": confirm}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+
+	// The old address takes the account back after the confirmation
+	w = testutil.Post("/auth/email/revert").WithJSON(map[string]string{"token": revert}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := currentEmail(t, app, user); got != "old@example.com" {
+		t.Fatalf("email = %s after revert", got)
+	}
+	w = testutil.Post("/auth/email/revert").WithJSON(map[string]string{"token": revert}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+}
+
+func TestEmailChangeRevertCancelsPendingChange(t *testing.T) {
+	mail := &outbox{}
+	app := newEmailChangeApp(t, EmailChangeOptions{Mailer: mail})
+	user := testutil.CreateUser(t, app.DB, testutil.WithEmail("old@example.com"))
+
+	testutil.AssertStatus(t, requestEmailChange(user, "first@example.com", testutil.DefaultPassword).Do(t, app.Router), http.StatusAccepted)
+	first := emailToken(t, mail, "first@example.com", "POST /api/auth/email/confirm:\n\n")
+	testutil.AssertStatus(t, requestEmailChange(user, "second@example.com", testutil.DefaultPassword).Do(t, app.Router), http.StatusAccepted)
+	confirm := emailToken(t, mail, "second@example.com", "POST /api/auth/email/confirm:\n\n")
+	revert := emailToken(t, mail, "old@example.com", "POST /api/auth/email/revert:\n\n")
+
+	// A new request replaces the pending one
+	w := testutil.Post("/auth/email/confirm").WithJSON(map[string]string{"token": first}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+
+	testutil.AssertStatus(t, testutil.Post("/auth/email/revert").WithJSON(map[string]string{"token": revert}).Do(t, app.Router), http.StatusOK)
+	w = testutil.Post("/auth/email/confirm").WithJSON(map[string]string{"token": confirm}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+	if got := currentEmail(t, app, user); got != "old@example.com" {
+		t.Errorf("email = %s, want the old one", got)
+	}
+}
+
+func TestEmailChangeExpires(t *testing.T) {
+	mail := &outbox{}
+	app := newEmailChangeApp(t, EmailChangeOptions{Mailer: mail, ConfirmTTL: time.Hour})
+	user := testutil.CreateUser(t, app.DB)
+
+	testutil.AssertStatus(t, requestEmailChange(user, "new@example.com", testutil.DefaultPassword).Do(t, app.Router), http.StatusAccepted)
+	confirm := emailToken(t, mail, "new@example.com", "POST /api/auth/email/confirm:\n\n")
+	app.DB.Model(&models.EmailChange{}).Where("user_id = ?", user.ID).Update("expires_at", time.Now().Add(-time.Minute))
+
+	w := testutil.Post("/auth/email/confirm").WithJSON(map[string]string{"token": confirm}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+}
+
+func TestEmailChangeExpiresWithClock(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auth.SetClock(clk)
+	t.Cleanup(func() { auth.SetClock(nil) })
+	mail := &outbox{}
+	app := newEmailChangeApp(t, EmailChangeOptions{Mailer: mail, ConfirmTTL: time.Hour})
+	user := testutil.CreateUser(t, app.DB)
+
+	testutil.AssertStatus(t, requestEmailChange(user, "new@example.com", testutil.DefaultPassword).Do(t, app.Router), http.StatusAccepted)
+	confirm := emailToken(t, mail, "new@example.com", "POST /api/auth/email/confirm:\n\n")
+
+	clk.Advance(61 * time.Minute)
+	w := testutil.Post("/auth/email/confirm").WithJSON(map[string]string{"token": confirm}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+	if got := currentEmail(t, app, user); got == "new@example.com" {
+		t.Error("an expired change was confirmed")
+	}
+}
+
+func TestEmailChangeWithoutMailer(t *testing.T) {
+	app := newEmailChangeApp(t, EmailChangeOptions{})
+	user := testutil.CreateUser(t, app.DB)
+
+	w := requestEmailChange(user, "new@example.com", testutil.DefaultPassword).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE")
+
+	// Without email delivery, PATCH changes the email directly as before
+	w = testutil.Patch("/users/me").WithJWT(user).WithJSON(map[string]string{"email": "new@example.com"}).
+		WithHeader("Content-Type", "application/merge-patch+json").Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// ErrorCatalogResponse lists every error code the API can return.
+type ErrorCatalogResponse struct {
+	Codes []response.ErrorCode `json:"codes"`
+}
+
+// ListErrorCodes returns the error code catalog, so clients can map codes to their own
+// messages and tooling can check that every code is handled.
+func ListErrorCodes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The catalog only changes with a new release
+		c.Header("Cache-Control", "public, max-age=3600")
+		response.SuccessResponse(c, http.StatusOK, "Error codes retrieved successfully", ErrorCatalogResponse{
+			Codes: response.ErrorCodes(),
+		})
+	}
+}
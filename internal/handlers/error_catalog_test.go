@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/response"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestListErrorCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/errors", ListErrorCodes())
+
+	w := testutil.Get("/api/errors").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var catalog ErrorCatalogResponse
+	testutil.DecodeData(t, w, &catalog)
+	listed := make(map[string]int, len(catalog.Codes))
+	for _, code := range catalog.Codes {
+		listed[code.Code] = code.Status
+	}
+	for _, code := range []*response.ErrorCode{response.CodeValidation, response.CodeNotFound, response.CodeRateLimitExceeded} {
+		if listed[code.Code] != code.Status {
+			t.Errorf("%s missing or with the wrong status in %v", code.Code, listed)
+		}
+	}
+}
@@ -2,14 +2,19 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/pkg/circuitbreaker"
 	"github.com/yeferson59/gin-template/pkg/logger"
 	"github.com/yeferson59/gin-template/pkg/response"
+	"github.com/yeferson59/gin-template/pkg/watchdog"
 )
 
 // HealthCheckResponse represents the structure for health check responses.
@@ -18,10 +23,20 @@ type HealthCheckResponse struct {
 	Timestamp time.Time         `json:"timestamp"`
 	Version   string            `json:"version,omitempty"`
 	Services  map[string]string `json:"services"`
+	Breakers  map[string]string `json:"circuit_breakers,omitempty"`
+	// Runtime holds the heap and goroutine figures, with ?detail=true.
+	Runtime *watchdog.Stats `json:"runtime,omitempty"`
 }
 
+// healthProbeTimeout bounds each probe of HealthCheck.
+const healthProbeTimeout = 2 * time.Second
+
 // HealthCheck provides a comprehensive health check endpoint.
-func HealthCheck(db *gorm.DB) gin.HandlerFunc {
+// An open circuit breaker in breakers (which may be nil) reports the service as degraded, as
+// does a failing probe, keyed by the name of the service it checks (e.g. "object_storage"), and
+// so does guard, which may be nil, while it sheds load. With ?detail=true the response includes
+// the runtime figures of the last check of guard, or the current ones without a watchdog.
+func HealthCheck(db *gorm.DB, breakers *circuitbreaker.Registry, probes map[string]func(context.Context) error, guard *watchdog.Watchdog) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		healthResp := HealthCheckResponse{
 			Status:    "ok",
@@ -48,8 +63,50 @@ func HealthCheck(db *gorm.DB) gin.HandlerFunc {
 			healthResp.Services["database"] = "not_configured"
 		}
 
-		// Add more service checks here as needed
-		// For example: Redis, external APIs, etc.
+		// Report the state of every downstream circuit breaker
+		if breakers != nil {
+			for _, b := range breakers.All() {
+				state := b.State()
+				if healthResp.Breakers == nil {
+					healthResp.Breakers = make(map[string]string)
+				}
+				healthResp.Breakers[b.Name()] = state.String()
+				if state == circuitbreaker.StateOpen && healthResp.Status == "ok" {
+					healthResp.Status = "degraded"
+				}
+			}
+		}
+
+		// Check the other dependencies, such as the object store
+		for name, probe := range probes {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), healthProbeTimeout)
+			err := probe(ctx)
+			cancel()
+			if err != nil {
+				logger.WithFields(map[string]interface{}{
+					"service": name,
+					"error":   err.Error(),
+				}).Error("Health probe failed")
+				healthResp.Services[name] = "error"
+				if healthResp.Status == "ok" {
+					healthResp.Status = "degraded"
+				}
+				continue
+			}
+			healthResp.Services[name] = "ok"
+		}
+
+		// Report the heap and goroutines, and the load shed by the watchdog
+		if guard != nil && guard.Overloaded() && healthResp.Status == "ok" {
+			healthResp.Status = "degraded"
+		}
+		if detail, _ := strconv.ParseBool(c.Query("detail")); detail {
+			stats := watchdog.Read()
+			if guard != nil {
+				stats = guard.Stats()
+			}
+			healthResp.Runtime = &stats
+		}
 
 		statusCode := http.StatusOK
 		switch healthResp.Status {
@@ -64,13 +121,28 @@ func HealthCheck(db *gorm.DB) gin.HandlerFunc {
 }
 
 // ReadinessCheck provides a readiness check endpoint for Kubernetes.
-func ReadinessCheck(db *gorm.DB) gin.HandlerFunc {
+// When allowDegraded is true the instance stays ready while the database is down, so that
+// degraded mode can keep serving cached reads instead of the instance leaving the load balancer.
+// The instance reports not ready as soon as lifecycle (which may be nil) starts draining.
+func ReadinessCheck(db *gorm.DB, allowDegraded bool, lifecycle *app.Lifecycle) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if lifecycle.Draining() {
+			response.ErrorResponse(c, response.CodeServiceUnavailable, "Service not ready", "Server is shutting down")
+			return
+		}
+
 		// Check if all critical services are ready
 		if db != nil {
 			sqlDB, err := db.DB()
 			if err != nil || sqlDB.Ping() != nil {
-				response.ErrorResponse(c, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Service not ready", "Database connection failed")
+				if allowDegraded {
+					response.SuccessResponse(c, http.StatusOK, "Service is ready in degraded mode", gin.H{
+						"status":    "degraded",
+						"timestamp": time.Now(),
+					})
+					return
+				}
+				response.ErrorResponse(c, response.CodeServiceUnavailable, "Service not ready", "Database connection failed")
 				return
 			}
 		}
@@ -82,6 +154,23 @@ func ReadinessCheck(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// StartupCheck provides a startup probe endpoint for Kubernetes. It fails until the server is
+// listening, so slow starts (migrations, dependency checks) are not mistaken for a hung process
+// by the liveness probe.
+func StartupCheck(lifecycle *app.Lifecycle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !lifecycle.Started() {
+			response.ErrorResponse(c, response.CodeServiceUnavailable, "Service is starting", "Server is not listening yet")
+			return
+		}
+
+		response.SuccessResponse(c, http.StatusOK, "Service has started", gin.H{
+			"status":    "started",
+			"timestamp": time.Now(),
+		})
+	}
+}
+
 // LivenessCheck provides a liveness check endpoint for Kubernetes.
 func LivenessCheck() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -92,3 +181,28 @@ func LivenessCheck() gin.HandlerFunc {
 		})
 	}
 }
+
+// LivezPath is the liveness path answered by Livez ahead of the router.
+const LivezPath = "/livez"
+
+var (
+	livezBody        = []byte("ok")
+	livezContentType = []string{"text/plain; charset=utf-8"}
+)
+
+// Livez wraps next so that GET and HEAD requests for LivezPath are answered before the router
+// and its middlewares, without allocating. Liveness probes then succeed on an instance that is
+// busy or shedding load, and cannot fail because of a slow dependency.
+func Livez(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != LivezPath || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header()["Content-Type"] = livezContentType
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(livezBody)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+	"github.com/yeferson59/gin-template/pkg/watchdog"
+)
+
+func TestLifecycleProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lifecycle := app.NewLifecycle()
+	db := testutil.NewDB(t)
+
+	r := gin.New()
+	r.GET("/health/startup", StartupCheck(lifecycle))
+	r.GET("/health/ready", ReadinessCheck(db, false, lifecycle))
+
+	testutil.AssertError(t, testutil.Get("/health/startup").Do(t, r), http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE")
+
+	lifecycle.MarkStarted()
+	testutil.AssertStatus(t, testutil.Get("/health/startup").Do(t, r), http.StatusOK)
+	testutil.AssertStatus(t, testutil.Get("/health/ready").Do(t, r), http.StatusOK)
+
+	// Readiness fails as soon as shutdown begins; the startup probe is unaffected.
+	lifecycle.MarkDraining()
+	testutil.AssertError(t, testutil.Get("/health/ready").Do(t, r), http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE")
+	testutil.AssertStatus(t, testutil.Get("/health/startup").Do(t, r), http.StatusOK)
+}
+
+func TestLifecycleProbes_NilLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/health/startup", StartupCheck(nil))
+	r.GET("/health/ready", ReadinessCheck(nil, false, nil))
+
+	testutil.AssertStatus(t, testutil.Get("/health/startup").Do(t, r), http.StatusOK)
+	testutil.AssertStatus(t, testutil.Get("/health/ready").Do(t, r), http.StatusOK)
+}
+
+func TestHealthCheckProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	healthy := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("bucket not found") }
+
+	for _, tt := range []struct {
+		name       string
+		probes     map[string]func(context.Context) error
+		wantStatus int
+		want       string
+	}{
+		{"healthy", map[string]func(context.Context) error{"object_storage": healthy}, http.StatusOK, "ok"},
+		{"failing", map[string]func(context.Context) error{"object_storage": failing}, http.StatusPartialContent, "error"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/health", HealthCheck(nil, nil, tt.probes, nil))
+			w := testutil.Get("/health").Do(t, r)
+			testutil.AssertStatus(t, w, tt.wantStatus)
+			var health HealthCheckResponse
+			testutil.DecodeData(t, w, &health)
+			if health.Services["object_storage"] != tt.want {
+				t.Errorf("object_storage = %q, want %q", health.Services["object_storage"], tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthCheckRuntimeDetail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	guard := watchdog.New(watchdog.Limits{Goroutines: 1, Shed: true})
+	r := gin.New()
+	r.GET("/health", HealthCheck(nil, nil, nil, guard))
+
+	w := testutil.Get("/health").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var health HealthCheckResponse
+	testutil.DecodeData(t, w, &health)
+	if health.Runtime != nil {
+		t.Errorf("runtime = %+v, want it only with ?detail=true", health.Runtime)
+	}
+
+	// A test binary always runs more than one goroutine
+	guard.Check()
+	w = testutil.Get("/health?detail=true").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusPartialContent)
+	health = HealthCheckResponse{}
+	testutil.DecodeData(t, w, &health)
+	if health.Status != "degraded" || health.Runtime == nil || !health.Runtime.Overloaded ||
+		health.Runtime.GoroutineLimit != 1 || health.Runtime.HeapBytes == 0 {
+		t.Errorf("health = %+v, runtime = %+v; want degraded with the watchdog figures", health, health.Runtime)
+	}
+}
+
+// discardWriter is a ResponseWriter that allocates nothing per request.
+type discardWriter struct{ header http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func TestLivez(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := Livez(next)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, LivezPath, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Fatalf("expected 200 ok, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusTeapot {
+		t.Fatalf("expected other paths to reach the router, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, LivezPath, nil)
+	dw := &discardWriter{header: make(http.Header)}
+	if allocs := testing.AllocsPerRun(100, func() { h.ServeHTTP(dw, req) }); allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+}
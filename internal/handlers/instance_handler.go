@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// InstanceInfo returns the instance serving the request: its uptime, build, configuration
+// fingerprint, applied migrations and how the previous instance on its host stopped.
+func InstanceInfo(instance *app.Instance) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		response.SuccessResponse(c, http.StatusOK, "Instance retrieved successfully", instance.Info())
+	}
+}
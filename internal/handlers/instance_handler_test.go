@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestInstanceInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.NewDB(t)
+	instance := app.NewInstance(&config.Config{}, "v1.0.0")
+	if err := instance.RecordStart(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.GET("/api/admin/instance", InstanceInfo(instance))
+
+	w := testutil.Get("/api/admin/instance").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+	var info app.InstanceInfo
+	testutil.DecodeData(t, w, &info)
+	if info.ID != instance.ID() || info.Build.Version != "v1.0.0" || info.Uptime == "" || info.StartedAt.IsZero() {
+		t.Errorf("info = %+v, want the instance with its uptime", info)
+	}
+}
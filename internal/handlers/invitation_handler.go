@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/notify"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// KindOrgInvitation is the notification kind of organization invitations.
+const KindOrgInvitation = "org.invitation"
+
+// DefaultInvitationTTL is how long invitations last when InvitationOptions.TTL is not set.
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+// InvitationOptions configures organization invitations (see config.OrgsConfig).
+type InvitationOptions struct {
+	// Mailer emails the invitation tokens; without it the token is returned to the inviter,
+	// who shares it with the invitee.
+	Mailer notify.Notifier
+	// TTL is how long invitations last; zero means DefaultInvitationTTL.
+	TTL time.Duration
+	// AcceptURL is the frontend page that accepts invitations; the token is appended as
+	// ?token=.
+	AcceptURL string
+}
+
+// InvitationResponse is a created invitation. Token is only set when invitations are not
+// emailed.
+type InvitationResponse struct {
+	models.Invitation
+	Token string `json:"token,omitempty"`
+}
+
+// AcceptInvitationRequest accepts an invitation with its token.
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+var errUnknownInvitation = apperrors.NotFound("Invitation not found", "The invitation is invalid, expired, or was already accepted")
+
+// CreateInvitation invites an email address to the organization of the route, replacing any
+// pending invitation of the same address. Only owners invite owners.
+func CreateInvitation(db *gorm.DB, opts InvitationOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req validators.InvitationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		req.Normalize()
+		if req.Role == "" {
+			req.Role = models.OrgRoleMember
+		}
+		if err := validators.ValidateInvitation(&req); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		org, _ := requestctx.Organization(c)
+		actor, _ := requestctx.Membership(c)
+		if req.Role == models.OrgRoleOwner && actor.Role != models.OrgRoleOwner {
+			_ = c.Error(errOwnerRequired)
+			return
+		}
+
+		token, err := randomToken()
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Could not create invitation", "Could not generate token", err))
+			return
+		}
+		ttl := opts.TTL
+		if ttl <= 0 {
+			ttl = DefaultInvitationTTL
+		}
+		invitation := models.Invitation{
+			OrganizationID: org.ID,
+			Email:          models.NormalizeEmail(req.Email),
+			Role:           req.Role,
+			TokenHash:      hashCode(token),
+			InvitedByID:    actor.UserID,
+			ExpiresAt:      auth.Now().Add(ttl),
+		}
+		err = database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			var members int64
+			err := tx.Model(&models.Membership{}).
+				Joins("JOIN users ON users.id = memberships.user_id AND users.deleted_at IS NULL").
+				Where("memberships.organization_id = ? AND users.email = ?", org.ID, invitation.Email).
+				Count(&members).Error
+			if err != nil {
+				return err
+			}
+			if members > 0 {
+				return apperrors.Conflict("Invitation not created", "The user is already a member of this organization")
+			}
+			err = tx.Where("organization_id = ? AND email = ? AND accepted_at IS NULL", org.ID, invitation.Email).
+				Delete(&models.Invitation{}).Error
+			if err != nil {
+				return err
+			}
+			return tx.Create(&invitation).Error
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not create invitation"))
+			return
+		}
+
+		result := InvitationResponse{Invitation: invitation}
+		if opts.Mailer == nil {
+			result.Token = token
+		} else if err := opts.Mailer.Notify(c.Request.Context(), invitationMessage(c, org, &invitation, token, opts)); err != nil {
+			db.Delete(&invitation)
+			_ = c.Error(apperrors.Unavailable("Could not send invitation", "Please try again later").Wrap(err))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"organization_id": org.ID,
+			"invitation_id":   invitation.ID,
+			"org_role":        invitation.Role,
+		}).Info("Organization invitation created")
+		response.SuccessResponse(c, http.StatusCreated, "Invitation created successfully", result)
+	}
+}
+
+// invitationMessage is the email that delivers an invitation token.
+func invitationMessage(c *gin.Context, org *models.Organization, invitation *models.Invitation, token string, opts InvitationOptions) notify.Message {
+	inviter := "A member"
+	if user, ok := requestctx.CurrentUser(c); ok {
+		inviter = user.Username
+	}
+	accept := "Accept it by signing in with this email address and sending this token to " +
+		"POST /api/invitations/accept:\n\n" + token
+	if opts.AcceptURL != "" {
+		accept = "Accept it by signing in with this email address at:\n\n" + opts.AcceptURL + "?token=" + url.QueryEscape(token)
+	}
+	return notify.Message{
+		Email:   invitation.Email,
+		Kind:    KindOrgInvitation,
+		Subject: fmt.Sprintf("You are invited to join %s", org.Name),
+		Body: fmt.Sprintf("%s invited you to join %s as %s.\n\n%s\n\nThe invitation expires on %s.",
+			inviter, org.Name, invitation.Role, accept, invitation.ExpiresAt.UTC().Format(time.RFC1123)),
+	}
+}
+
+// ListInvitations returns the pending invitations of the organization of the route.
+func ListInvitations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, _ := requestctx.Organization(c)
+		invitations := []models.Invitation{}
+		err := db.WithContext(c.Request.Context()).
+			Where("organization_id = ? AND accepted_at IS NULL AND expires_at > ?", org.ID, auth.Now()).
+			Order("created_at DESC, id DESC").
+			Find(&invitations).Error
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve invitations"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Invitations retrieved successfully", invitations)
+	}
+}
+
+// RevokeInvitation deletes a pending invitation of the organization of the route.
+func RevokeInvitation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid invitation ID", "The invitation ID must be a positive integer"))
+			return
+		}
+		org, _ := requestctx.Organization(c)
+		result := db.WithContext(c.Request.Context()).
+			Where("id = ? AND organization_id = ? AND accepted_at IS NULL", id, org.ID).
+			Delete(&models.Invitation{})
+		if result.Error != nil {
+			_ = c.Error(repository.TranslateError(result.Error, "Could not revoke invitation"))
+			return
+		}
+		if result.RowsAffected == 0 {
+			_ = c.Error(errUnknownInvitation)
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Invitation revoked successfully", nil)
+	}
+}
+
+// AcceptInvitation adds the current user to the organization of an invitation sent to their
+// email address. Each invitation is accepted once, before it expires.
+func AcceptInvitation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := requestctx.CurrentUser(c)
+		if !ok {
+			_ = c.Error(apperrors.Unauthorized("Authorization required", "No authenticated user"))
+			return
+		}
+		var req AcceptInvitationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+
+		var invitation models.Invitation
+		var org models.Organization
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			err := tx.Where("token_hash = ? AND accepted_at IS NULL AND expires_at > ?", hashCode(req.Token), auth.Now()).
+				First(&invitation).Error
+			if err == nil {
+				err = tx.First(&org, invitation.OrganizationID).Error
+			}
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errUnknownInvitation
+			}
+			if err != nil {
+				return err
+			}
+			if invitation.Email != models.NormalizeEmail(user.Email) {
+				return apperrors.Forbidden("Invitation not accepted", "The invitation was sent to another email address")
+			}
+
+			// Each invitation is accepted once; losing a concurrent acceptance is a failure
+			now := auth.Now()
+			result := tx.Model(&models.Invitation{}).
+				Where("id = ? AND accepted_at IS NULL", invitation.ID).
+				Update("accepted_at", now)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errUnknownInvitation
+			}
+			return tx.Create(&models.Membership{
+				OrganizationID: org.ID,
+				UserID:         user.ID,
+				Role:           invitation.Role,
+			}).Error
+		})
+		if err != nil {
+			if database.IsDuplicateKeyError(err) {
+				_ = c.Error(apperrors.Conflict("Invitation not accepted", "You are already a member of this organization").Wrap(err))
+				return
+			}
+			_ = c.Error(repository.TranslateError(err, "Could not accept invitation"))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"organization_id": org.ID,
+			"invitation_id":   invitation.ID,
+		}).Info("Organization invitation accepted")
+		response.SuccessResponse(c, http.StatusOK, "Invitation accepted successfully",
+			OrganizationResponse{Organization: org, Role: invitation.Role})
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// Duration routes are debugged for by default and at most.
+const (
+	defaultRouteDebugDuration = 15 * time.Minute
+	maxRouteDebugDuration     = 24 * time.Hour
+)
+
+// LoggingSettings are the log settings of the instance.
+type LoggingSettings struct {
+	Level       string              `json:"level"`
+	Format      string              `json:"format"`
+	DebugRoutes []logger.DebugRoute `json:"debug_routes"`
+}
+
+// LoggingUpdate is the body of PUT /api/admin/logging. Omitted settings are left unchanged.
+type LoggingUpdate struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+	// DebugRoutes are path patterns, such as /api/users/:id, whose requests log their debug
+	// entries for DebugDuration (15m by default, "0s" to stop).
+	DebugRoutes   []string `json:"debug_routes"`
+	DebugDuration string   `json:"debug_duration"`
+}
+
+// GetLogging returns the log level and format and the routes being debugged.
+func GetLogging(routes *logger.RouteDebug) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		response.SuccessResponse(c, http.StatusOK, "Logging settings retrieved successfully", loggingSettings(routes))
+	}
+}
+
+// SetLogging changes the log level and format of this instance and turns debug logging on or
+// off for some routes. known reports whether a path pattern is a registered route; nil accepts
+// any. Nothing is changed unless every setting is valid.
+func SetLogging(routes *logger.RouteDebug, known func(route string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req LoggingUpdate
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+
+		level := logger.Level()
+		if req.Level != "" {
+			parsed, err := logger.ParseLevel(req.Level)
+			if err != nil {
+				_ = c.Error(apperrors.BadRequest("Invalid log level", err.Error()))
+				return
+			}
+			level = parsed
+		}
+		format := logger.Format()
+		if req.Format != "" {
+			parsed, err := logger.ParseFormat(req.Format)
+			if err != nil {
+				_ = c.Error(apperrors.BadRequest("Invalid log format", err.Error()))
+				return
+			}
+			format = parsed
+		}
+		duration := defaultRouteDebugDuration
+		if req.DebugDuration != "" {
+			parsed, err := time.ParseDuration(req.DebugDuration)
+			if err != nil || parsed < 0 || parsed > maxRouteDebugDuration {
+				_ = c.Error(apperrors.BadRequest("Invalid debug duration", "debug_duration must be a duration between 0s and 24h"))
+				return
+			}
+			duration = parsed
+		}
+		for _, route := range req.DebugRoutes {
+			if known != nil && !known(route) {
+				_ = c.Error(apperrors.BadRequest("Unknown route", fmt.Sprintf("%s is not a registered route; use the path pattern, such as /api/users/:id", route)))
+				return
+			}
+		}
+
+		logger.SetLevel(level)
+		_ = logger.SetFormat(format)
+		for _, route := range req.DebugRoutes {
+			routes.Enable(route, duration)
+		}
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"level":          level.String(),
+			"format":         format,
+			"debug_routes":   req.DebugRoutes,
+			"debug_duration": duration.String(),
+		}).Warn("Logging settings changed")
+
+		response.SuccessResponse(c, http.StatusOK, "Logging settings updated successfully", loggingSettings(routes))
+	}
+}
+
+func loggingSettings(routes *logger.RouteDebug) LoggingSettings {
+	return LoggingSettings{
+		Level:       logger.Level().String(),
+		Format:      logger.Format(),
+		DebugRoutes: routes.Routes(),
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestLoggingSettings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("LOG_LEVEL", "info")
+	logger.Init()
+	t.Cleanup(func() { logger.Init() })
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	routes := logger.NewRouteDebug()
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler(), middlewares.RequestLoggerWithDebug(routes))
+	r.GET("/api/users/:id", func(c *gin.Context) {
+		requestctx.Logger(c).WithField("id", c.Param("id")).Debug("Loading user")
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/api/admin/logging", GetLogging(routes))
+	r.PUT("/api/admin/logging", SetLogging(routes, func(route string) bool { return route == "/api/users/:id" }))
+
+	testutil.Get("/api/users/1").Do(t, r)
+	if strings.Contains(buf.String(), "Loading user") {
+		t.Fatal("a debug entry was logged at info level")
+	}
+
+	w := testutil.NewRequest(http.MethodPut, "/api/admin/logging").
+		WithJSON(map[string]interface{}{"format": "json", "debug_routes": []string{"/api/users/:id"}, "debug_duration": "5m"}).
+		Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var settings LoggingSettings
+	testutil.DecodeData(t, w, &settings)
+	if settings.Level != "info" || settings.Format != "json" || len(settings.DebugRoutes) != 1 {
+		t.Fatalf("settings = %+v, want info level, json format and the debugged route", settings)
+	}
+
+	buf.Reset()
+	testutil.Get("/api/users/2").Do(t, r)
+	if !strings.Contains(buf.String(), `"msg":"Loading user"`) {
+		t.Errorf("output = %s, want the debug entry of the debugged route", buf.String())
+	}
+
+	w = testutil.NewRequest(http.MethodPut, "/api/admin/logging").
+		WithJSON(map[string]interface{}{"level": "debug", "debug_routes": []string{"/api/users"}}).
+		Do(t, r)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+	w = testutil.NewRequest(http.MethodPut, "/api/admin/logging").
+		WithJSON(map[string]interface{}{"level": "verbose"}).
+		Do(t, r)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+	if logger.Level().String() != "info" {
+		t.Errorf("level = %s, want invalid requests to change nothing", logger.Level())
+	}
+
+	w = testutil.NewRequest(http.MethodPut, "/api/admin/logging").
+		WithJSON(map[string]interface{}{"level": "warn", "debug_routes": []string{"/api/users/:id"}, "debug_duration": "0s"}).
+		Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	w = testutil.Get("/api/admin/logging").Do(t, r)
+	testutil.DecodeData(t, w, &settings)
+	if settings.Level != "warning" || len(settings.DebugRoutes) != 0 {
+		t.Errorf("settings = %+v, want warning level and no debugged route", settings)
+	}
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/notify"
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// maxVerificationAttempts is how many wrong codes a login challenge accepts before it is discarded.
+const maxVerificationAttempts = 5
+
+// Notification kinds of login alerts.
+const (
+	KindLoginNewDevice    = "login.new_device"
+	KindLoginNewCountry   = "login.new_country"
+	KindLoginVerification = "login.verification"
+)
+
+// LoginAlerts configures how logins from a new device or country are handled. A nil
+// *LoginAlerts disables alerts and verification.
+type LoginAlerts struct {
+	// Notifier delivers the alerts; nil disables them.
+	Notifier   notify.Notifier
+	NewDevice  bool
+	NewCountry bool
+	// Verification is one of the app.LoginVerification* modes; Mailer sends the codes.
+	Verification    string
+	Mailer          notify.Notifier
+	VerificationTTL time.Duration
+}
+
+// LoginVerificationResponse is returned with 202 Accepted instead of a token when the login
+// must be confirmed with the code sent by email to POST /api/auth/login/verify.
+type LoginVerificationResponse struct {
+	ChallengeID string    `json:"challenge_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// Reasons are the signals that required verification: new_device and new_country.
+	Reasons []security.Signal `json:"reasons"`
+}
+
+// LoginVerifyRequest is the body of POST /api/auth/login/verify.
+type LoginVerifyRequest struct {
+	ChallengeID string `json:"challenge_id" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+}
+
+// errInvalidVerification covers unknown, expired, and wrong codes alike.
+var errInvalidVerification = apperrors.Unauthorized("Invalid verification code", "The code is wrong or expired; log in again to get a new one")
+
+// loginOrigin describes where a login comes from, compared with the user's previous logins.
+type loginOrigin struct {
+	fingerprint string
+	location    *security.Location
+	newDevice   bool
+	newCountry  bool
+}
+
+// reasons returns the new device and new country signals of the login.
+func (o loginOrigin) reasons() []security.Signal {
+	var signals []security.Signal
+	if o.newDevice {
+		signals = append(signals, security.SignalNewDevice)
+	}
+	if o.newCountry {
+		signals = append(signals, security.SignalNewCountry)
+	}
+	return signals
+}
+
+// inspectLogin fingerprints the device and locates the request, and compares both with the
+// user's previous logins. The first login, and logins recorded before fingerprints or
+// locations were available, are never new. Lookup failures are logged and treated as known
+// so they never block a login.
+func inspectLogin(c *gin.Context, db *gorm.DB, user *models.User) loginOrigin {
+	origin := loginOrigin{fingerprint: security.DeviceFingerprint(c.Request)}
+	if loc, ok := security.LocationFromContext(c); ok {
+		origin.location = loc
+	} else if loc, ok := security.Locate(c.ClientIP()); ok {
+		origin.location = loc
+	}
+
+	seen := func(column, value string) (known, compared bool) {
+		var matches, total int64
+		events := db.Model(&models.LoginEvent{}).Where("user_id = ? AND "+column+" <> ''", user.ID)
+		if err := events.Count(&total).Error; err != nil || total == 0 {
+			return true, false
+		}
+		if err := db.Model(&models.LoginEvent{}).Where("user_id = ? AND "+column+" = ?", user.ID, value).
+			Count(&matches).Error; err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to compare login origin")
+			return true, true
+		}
+		return matches > 0, true
+	}
+
+	if known, compared := seen("device_fingerprint", origin.fingerprint); compared {
+		origin.newDevice = !known
+	}
+	if origin.location != nil && origin.location.Country != "" {
+		if known, compared := seen("country", origin.location.Country); compared {
+			origin.newCountry = !known
+		}
+	}
+	return origin
+}
+
+// requiresVerification reports whether the login must confirm a code before receiving a token.
+func (a *LoginAlerts) requiresVerification(origin loginOrigin) bool {
+	if a == nil || a.Mailer == nil {
+		return false
+	}
+	switch a.Verification {
+	case app.LoginVerificationNewDevice:
+		return origin.newDevice
+	case app.LoginVerificationNewCountry:
+		return origin.newCountry
+	case app.LoginVerificationAny:
+		return origin.newDevice || origin.newCountry
+	default:
+		return false
+	}
+}
+
+// notify alerts the user of a login from a new device or country. Failures are logged but
+// never block the login.
+func (a *LoginAlerts) notify(c *gin.Context, user *models.User, origin loginOrigin) {
+	if a == nil || a.Notifier == nil {
+		return
+	}
+
+	var kind string
+	switch {
+	case origin.newCountry && a.NewCountry:
+		kind = KindLoginNewCountry
+	case origin.newDevice && a.NewDevice:
+		kind = KindLoginNewDevice
+	default:
+		return
+	}
+	msg := loginAlertMessage(c, user, origin, kind)
+
+	if err := a.Notifier.Notify(c.Request.Context(), msg); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"user_id": user.ID,
+			"kind":    msg.Kind,
+			"error":   err.Error(),
+		}).Error("Failed to send login alert")
+	}
+}
+
+// loginAlertMessage is the alert of a login from a new country or device, depending on kind.
+func loginAlertMessage(c *gin.Context, user *models.User, origin loginOrigin, kind string) notify.Message {
+	subject := "New sign-in from a new device"
+	if kind == KindLoginNewCountry {
+		subject = "New sign-in from " + origin.location.Country
+	}
+	return notify.Message{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Kind:    kind,
+		Subject: subject,
+		Body: fmt.Sprintf("Your account %s signed in %s.\n\nIf this was not you, change your password now.",
+			user.Username, describeOrigin(c, origin)),
+	}
+}
+
+// startVerification stores a login challenge, emails its code, and answers 202 Accepted.
+func (a *LoginAlerts) startVerification(c *gin.Context, db *gorm.DB, user *models.User, origin loginOrigin) {
+	code, err := randomCode()
+	if err != nil {
+		_ = c.Error(apperrors.Internal("Authentication failed", "Could not create verification code", err))
+		return
+	}
+	id, err := randomToken()
+	if err != nil {
+		_ = c.Error(apperrors.Internal("Authentication failed", "Could not create verification code", err))
+		return
+	}
+
+	now := auth.Now()
+	// Expired challenges are never used again
+	db.Where("expires_at < ?", now).Delete(&models.LoginChallenge{})
+
+	challenge := models.LoginChallenge{
+		ID:                id,
+		UserID:            user.ID,
+		CodeHash:          hashCode(code),
+		DeviceFingerprint: origin.fingerprint,
+		ExpiresAt:         now.Add(a.VerificationTTL),
+	}
+	if err := db.Create(&challenge).Error; err != nil {
+		_ = c.Error(apperrors.Internal("Authentication failed", "Could not create verification code", err))
+		return
+	}
+
+	msg := loginVerificationMessage(c, user, origin, code, a.VerificationTTL)
+	if err := a.Mailer.Notify(c.Request.Context(), msg); err != nil {
+		db.Delete(&challenge)
+		_ = c.Error(apperrors.Unavailable("Could not send verification code", "Please try again later").Wrap(err))
+		return
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"user_id": user.ID,
+		"reasons": origin.reasons(),
+	}).Warn("Login verification required")
+	response.SuccessResponse(c, http.StatusAccepted, "Login verification required", LoginVerificationResponse{
+		ChallengeID: challenge.ID,
+		ExpiresAt:   challenge.ExpiresAt,
+		Reasons:     origin.reasons(),
+	})
+}
+
+// loginVerificationMessage is the email with the code that confirms a login.
+func loginVerificationMessage(c *gin.Context, user *models.User, origin loginOrigin, code string, ttl time.Duration) notify.Message {
+	return notify.Message{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Kind:    KindLoginVerification,
+		Subject: "Your sign-in verification code",
+		Body: fmt.Sprintf("Someone is signing in to your account %s %s.\n\nVerification code: %s\n\n"+
+			"The code expires in %s. If this was not you, change your password now.",
+			user.Username, describeOrigin(c, origin), code, ttl),
+	}
+}
+
+// VerifyLogin completes a login that required verification, exchanging the challenge and its
+// emailed code for a token. The request must come from the device that started the login.
+func VerifyLogin(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req LoginVerifyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+
+		var challenge models.LoginChallenge
+		if err := db.Where("id = ?", req.ChallengeID).First(&challenge).Error; err != nil {
+			security.RecordFailedLogin(c, security.LoginInvalidChallenge)
+			_ = c.Error(errInvalidVerification)
+			return
+		}
+		origin := inspectLogin(c, db, &models.User{ID: challenge.UserID})
+		if auth.Now().After(challenge.ExpiresAt) || origin.fingerprint != challenge.DeviceFingerprint {
+			security.RecordFailedLogin(c, security.LoginInvalidChallenge)
+			_ = c.Error(errInvalidVerification)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(hashCode(strings.TrimSpace(req.Code))), []byte(challenge.CodeHash)) != 1 {
+			challenge.Attempts++
+			if challenge.Attempts >= maxVerificationAttempts {
+				db.Delete(&challenge)
+				security.RecordLockout(c)
+			} else {
+				db.Model(&challenge).Update("attempts", challenge.Attempts)
+			}
+			logger.WithFields(map[string]interface{}{
+				"user_id":  challenge.UserID,
+				"attempts": challenge.Attempts,
+			}).Warn("Login verification with wrong code")
+			security.RecordFailedLogin(c, security.LoginWrongCode)
+			_ = c.Error(errInvalidVerification)
+			return
+		}
+
+		// Each challenge is redeemed once; losing a concurrent redemption is a failure
+		if result := db.Delete(&challenge); result.Error != nil || result.RowsAffected == 0 {
+			_ = c.Error(errInvalidVerification)
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, challenge.UserID).Error; err != nil {
+			_ = c.Error(errInvalidVerification)
+			return
+		}
+		completeLogin(c, db, &user, origin)
+	}
+}
+
+// describeOrigin summarizes the device and location of a login for notifications.
+func describeOrigin(c *gin.Context, origin loginOrigin) string {
+	where := "from " + c.ClientIP()
+	if origin.location != nil && origin.location.Country != "" {
+		where += " (" + origin.location.Country + ")"
+	}
+	if ua := c.Request.UserAgent(); ua != "" {
+		where += " using " + ua
+	}
+	return where
+}
+
+// randomCode returns a 6-digit verification code.
+func randomCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// randomToken returns an unguessable challenge id.
+func randomToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
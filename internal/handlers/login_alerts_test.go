@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/notify"
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+const (
+	laptop = "Mozilla/5.0 (X11; Linux x86_64) Firefox/120.0"
+	phone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0) Safari/604.1"
+)
+
+// outbox records the notifications sent.
+type outbox struct {
+	messages []notify.Message
+}
+
+func (o *outbox) Notify(_ context.Context, msg notify.Message) error {
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+func setupLoginAlertsApp(t *testing.T, alerts *LoginAlerts) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		if alerts.Notifier == nil {
+			alerts.Notifier = notify.NewInApp(a.DB)
+		}
+		a.Router.POST("/login", Login(a.DB, alerts))
+		a.Router.POST("/login/verify", VerifyLogin(a.DB))
+	})
+}
+
+func login(t *testing.T, a *testutil.App, user *models.User, userAgent string) *testutil.Request {
+	t.Helper()
+	return testutil.Post("/login").WithHeader("User-Agent", userAgent).WithJSON(map[string]string{
+		"username": user.Username,
+		"password": testutil.DefaultPassword,
+	})
+}
+
+func TestLogin_NewDeviceNotifiesUser(t *testing.T) {
+	a := setupLoginAlertsApp(t, &LoginAlerts{NewDevice: true})
+	user := testutil.CreateUser(t, a.DB)
+
+	// The first login and a known device raise no alert
+	for i := 0; i < 2; i++ {
+		testutil.AssertStatus(t, login(t, a, user, laptop).Do(t, a.Router), http.StatusOK)
+	}
+	var count int64
+	a.DB.Model(&models.Notification{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("known device created %d notifications", count)
+	}
+
+	testutil.AssertStatus(t, login(t, a, user, phone).Do(t, a.Router), http.StatusOK)
+	var notification models.Notification
+	if err := a.DB.Where("user_id = ?", user.ID).First(&notification).Error; err != nil {
+		t.Fatalf("new device created no notification: %v", err)
+	}
+	if notification.Kind != KindLoginNewDevice {
+		t.Errorf("notification kind = %q, want %q", notification.Kind, KindLoginNewDevice)
+	}
+
+	var event models.LoginEvent
+	a.DB.Where("user_id = ?", user.ID).Order("id DESC").First(&event)
+	if event.DeviceFingerprint == "" || event.Signals != string(security.SignalNewDevice) {
+		t.Errorf("last login event = %+v", event)
+	}
+}
+
+func TestLogin_VerificationOnNewDevice(t *testing.T) {
+	mail := &outbox{}
+	a := setupLoginAlertsApp(t, &LoginAlerts{
+		NewDevice:       true,
+		Verification:    app.LoginVerificationNewDevice,
+		Mailer:          mail,
+		VerificationTTL: time.Minute,
+	})
+	user := testutil.CreateUser(t, a.DB)
+	testutil.AssertStatus(t, login(t, a, user, laptop).Do(t, a.Router), http.StatusOK)
+
+	w := login(t, a, user, phone).Do(t, a.Router)
+	testutil.AssertStatus(t, w, http.StatusAccepted)
+	var challenge LoginVerificationResponse
+	testutil.DecodeData(t, w, &challenge)
+	if challenge.ChallengeID == "" || len(challenge.Reasons) != 1 || challenge.Reasons[0] != security.SignalNewDevice {
+		t.Fatalf("challenge = %+v", challenge)
+	}
+	if len(mail.messages) != 1 || mail.messages[0].Email != user.Email {
+		t.Fatalf("verification emails = %+v", mail.messages)
+	}
+	code := regexp.MustCompile(`\b\d{6}\b`).FindString(mail.messages[0].Body)
+
+	verify := func(userAgent, code string) int {
+		return testutil.Post("/login/verify").WithHeader("User-Agent", userAgent).WithJSON(map[string]string{
+			"challenge_id": challenge.ChallengeID,
+			"code":         code,
+		}).Do(t, a.Router).Code
+	}
+	if got := verify(phone, "wrong"); got != http.StatusUnauthorized {
+		t.Errorf("wrong code got %d, want 401", got)
+	}
+	if got := verify(laptop, code); got != http.StatusUnauthorized {
+		t.Errorf("code from another device got %d, want 401", got)
+	}
+	if got := verify(phone, code); got != http.StatusOK {
+		t.Fatalf("valid code got %d, want 200", got)
+	}
+	if got := verify(phone, code); got != http.StatusUnauthorized {
+		t.Errorf("reused challenge got %d, want 401", got)
+	}
+
+	// The verified device is now known
+	testutil.AssertStatus(t, login(t, a, user, phone).Do(t, a.Router), http.StatusOK)
+}
+
+func TestVerifyLogin_DiscardsAfterTooManyAttempts(t *testing.T) {
+	mail := &outbox{}
+	a := setupLoginAlertsApp(t, &LoginAlerts{Verification: app.LoginVerificationAny, Mailer: mail, VerificationTTL: time.Minute})
+	user := testutil.CreateUser(t, a.DB)
+	testutil.AssertStatus(t, login(t, a, user, laptop).Do(t, a.Router), http.StatusOK)
+
+	var challenge LoginVerificationResponse
+	testutil.DecodeData(t, login(t, a, user, phone).Do(t, a.Router), &challenge)
+	for i := 0; i < maxVerificationAttempts; i++ {
+		testutil.Post("/login/verify").WithHeader("User-Agent", phone).WithJSON(map[string]string{
+			"challenge_id": challenge.ChallengeID,
+			"code":         "000000x",
+		}).Do(t, a.Router)
+	}
+
+	var count int64
+	a.DB.Model(&models.LoginChallenge{}).Count(&count)
+	if count != 0 {
+		t.Errorf("challenge survived %d wrong codes", maxVerificationAttempts)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// maxNotifications is how many notifications GET /api/users/me/notifications returns.
+const maxNotifications = 50
+
+// ListNotifications returns the current user's latest in-app notifications, newest first.
+// ?unread=true returns only those not yet read.
+func ListNotifications(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Where("user_id = ?", requestctx.UserID(c))
+		if unread, _ := strconv.ParseBool(c.Query("unread")); unread {
+			query = query.Where("read_at IS NULL")
+		}
+
+		notifications := []models.Notification{}
+		if err := query.Order("created_at DESC, id DESC").Limit(maxNotifications).Find(&notifications).Error; err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve notifications"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Notifications retrieved successfully", notifications)
+	}
+}
+
+// MarkNotificationRead marks one of the current user's notifications as read.
+func MarkNotificationRead(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid notification ID", "The notification ID must be a positive integer"))
+			return
+		}
+
+		var notification models.Notification
+		if err := db.Where("id = ? AND user_id = ?", id, requestctx.UserID(c)).First(&notification).Error; err != nil {
+			_ = c.Error(apperrors.NotFound("Notification not found", "No notification with this ID"))
+			return
+		}
+		if notification.ReadAt == nil {
+			now := time.Now()
+			if err := db.Model(&notification).Update("read_at", now).Error; err != nil {
+				_ = c.Error(repository.TranslateError(err, "Could not update notification"))
+				return
+			}
+			notification.ReadAt = &now
+		}
+		response.SuccessResponse(c, http.StatusOK, "Notification marked as read", notification)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestNotifications(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := testutil.CreateUser(t, db)
+	other := testutil.CreateUser(t, db)
+	for _, n := range []models.Notification{
+		{UserID: user.ID, Kind: KindLoginNewDevice, Title: "first"},
+		{UserID: user.ID, Kind: KindLoginNewCountry, Title: "second"},
+		{UserID: other.ID, Kind: KindLoginNewDevice, Title: "other"},
+	} {
+		if err := db.Create(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler(), func(c *gin.Context) {
+		requestctx.SetUser(c, user, time.Time{})
+	})
+	r.GET("/notifications", ListNotifications(db))
+	r.POST("/notifications/:id/read", MarkNotificationRead(db))
+
+	var list []models.Notification
+	testutil.DecodeData(t, testutil.Get("/notifications").Do(t, r), &list)
+	if len(list) != 2 || list[0].Title != "second" {
+		t.Fatalf("notifications = %+v", list)
+	}
+
+	w := testutil.Post(fmt.Sprintf("/notifications/%d/read", list[0].ID)).Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	// Another user's notification is not visible
+	testutil.AssertError(t, testutil.Post("/notifications/3/read").Do(t, r), http.StatusNotFound, "NOT_FOUND")
+
+	testutil.DecodeData(t, testutil.Get("/notifications?unread=true").Do(t, r), &list)
+	if len(list) != 1 || list[0].Title != "first" {
+		t.Errorf("unread notifications = %+v", list)
+	}
+}
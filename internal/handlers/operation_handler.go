@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/operations"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// OperationResponse represents the state of a long-running operation.
+type OperationResponse struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Status     string          `json:"status"`
+	Progress   int             `json:"progress"`
+	Message    string          `json:"message,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// GetOperation returns the status, progress, and result of an operation.
+// Users can only see their own operations; administrators can see all of them.
+func GetOperation(ops *operations.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, ok := loadOwnedOperation(c, ops)
+		if !ok {
+			return
+		}
+
+		response.SuccessResponse(c, http.StatusOK, "Operation retrieved successfully", toOperationResponse(op))
+	}
+}
+
+// CancelOperation requests cancellation of a pending or running operation.
+// It returns 200 when the operation is already canceled and 202 while a running task winds down.
+func CancelOperation(ops *operations.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := loadOwnedOperation(c, ops); !ok {
+			return
+		}
+
+		op, err := ops.Cancel(c.Request.Context(), c.Param("id"))
+		switch {
+		case errors.Is(err, operations.ErrFinished):
+			_ = c.Error(apperrors.Conflict("Operation already finished", "Only pending or running operations can be canceled").Wrap(err))
+			return
+		case err != nil:
+			_ = c.Error(repository.TranslateError(err, "Could not cancel operation"))
+			return
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"operation_id": op.ID,
+			"user_id":      requestctx.UserID(c),
+		}).Info("Operation cancellation requested")
+
+		if op.Status == models.OperationCanceled {
+			response.SuccessResponse(c, http.StatusOK, "Operation canceled", toOperationResponse(op))
+			return
+		}
+		response.SuccessResponse(c, http.StatusAccepted, "Operation cancellation requested", toOperationResponse(op))
+	}
+}
+
+// respondOperationAccepted sends 202 Accepted pointing the client at the operation status URL.
+func respondOperationAccepted(c *gin.Context, op *models.Operation) {
+	c.Header("Location", "/api/operations/"+op.ID)
+	response.SuccessResponse(c, http.StatusAccepted, "Operation started", toOperationResponse(op))
+}
+
+// loadOwnedOperation fetches the operation in the :id path parameter and fails with a 404 when
+// it does not exist or belongs to another user, so operation IDs cannot be probed.
+func loadOwnedOperation(c *gin.Context, ops *operations.Manager) (*models.Operation, bool) {
+	op, err := ops.Get(c.Request.Context(), c.Param("id"))
+	if err != nil && !errors.Is(err, operations.ErrNotFound) {
+		_ = c.Error(repository.TranslateError(err, "Could not load operation"))
+		return nil, false
+	}
+
+	if err != nil || (op.UserID != requestctx.UserID(c) && requestctx.Role(c) != models.RoleAdmin) {
+		_ = c.Error(apperrors.NotFound("Operation not found", "No operation exists with the given id"))
+		return nil, false
+	}
+
+	return op, true
+}
+
+func toOperationResponse(op *models.Operation) OperationResponse {
+	resp := OperationResponse{
+		ID:         op.ID,
+		Type:       op.Type,
+		Status:     op.Status,
+		Progress:   op.Progress,
+		Message:    op.Message,
+		Error:      op.Error,
+		CreatedAt:  op.CreatedAt,
+		UpdatedAt:  op.UpdatedAt,
+		FinishedAt: op.FinishedAt,
+	}
+	if op.Result != "" {
+		resp.Result = json.RawMessage(op.Result)
+	}
+	return resp
+}
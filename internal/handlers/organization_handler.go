@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/entitlements"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// OrganizationResponse is an organization with the current user's role in it.
+type OrganizationResponse struct {
+	models.Organization
+	Role string `json:"role"`
+}
+
+// MemberResponse is a member of an organization.
+type MemberResponse struct {
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+var (
+	errMemberNotFound = apperrors.NotFound("Member not found", "The user is not a member of this organization")
+	errLastOwner      = apperrors.Conflict("Organization needs an owner", "Promote another member to owner first")
+	errOwnerRequired  = apperrors.Forbidden("Access denied", "Only owners can grant the owner role or change owners")
+)
+
+// CreateOrganization creates an organization owned by the current user. With plans, the
+// organizations a user owns are limited by the max_organizations entitlement; a nil ents
+// leaves them unlimited.
+func CreateOrganization(db *gorm.DB, ents *entitlements.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req validators.OrganizationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		req.Normalize()
+		if req.Slug == "" {
+			req.Slug = validators.Slugify(req.Name)
+		}
+		if err := validators.ValidateOrganization(&req); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		if ents != nil {
+			var owned int64
+			err := db.WithContext(c.Request.Context()).Model(&models.Membership{}).
+				Where("user_id = ? AND role = ?", requestctx.UserID(c), models.OrgRoleOwner).
+				Count(&owned).Error
+			if err == nil {
+				err = ents.CheckLimit(c.Request.Context(), requestctx.UserID(c), entitlements.MaxOrganizations, owned)
+			}
+			if err != nil {
+				_ = c.Error(apperrors.As(err))
+				return
+			}
+		}
+
+		org := models.Organization{Name: req.Name, Slug: req.Slug}
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			if err := tx.Create(&org).Error; err != nil {
+				return err
+			}
+			return tx.Create(&models.Membership{
+				OrganizationID: org.ID,
+				UserID:         requestctx.UserID(c),
+				Role:           models.OrgRoleOwner,
+			}).Error
+		})
+		if err != nil {
+			if database.IsDuplicateKeyError(err) {
+				_ = c.Error(apperrors.Conflict("Organization not created", "The slug is already taken").Wrap(err))
+				return
+			}
+			_ = c.Error(repository.TranslateError(err, "Could not create organization"))
+			return
+		}
+
+		requestctx.Logger(c).WithField("organization_id", org.ID).Info("Organization created")
+		response.SuccessResponse(c, http.StatusCreated, "Organization created successfully",
+			OrganizationResponse{Organization: org, Role: models.OrgRoleOwner})
+	}
+}
+
+// ListOrganizations returns the organizations of the current user with their role in each.
+func ListOrganizations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgs := []OrganizationResponse{}
+		err := db.WithContext(c.Request.Context()).Model(&models.Organization{}).
+			Select("organizations.*, memberships.role").
+			Joins("JOIN memberships ON memberships.organization_id = organizations.id").
+			Where("memberships.user_id = ?", requestctx.UserID(c)).
+			Order("organizations.name").
+			Scan(&orgs).Error
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve organizations"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Organizations retrieved successfully", orgs)
+	}
+}
+
+// GetOrganization returns the organization of the route (see middlewares.OrgScope).
+func GetOrganization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, _ := requestctx.Organization(c)
+		membership, _ := requestctx.Membership(c)
+		response.SuccessResponse(c, http.StatusOK, "Organization retrieved successfully",
+			OrganizationResponse{Organization: *org, Role: membership.Role})
+	}
+}
+
+// DeleteOrganization deletes the organization of the route with its memberships, invitations
+// and registration codes.
+func DeleteOrganization(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, _ := requestctx.Organization(c)
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			if err := tx.Where("organization_id = ?", org.ID).Delete(&models.Membership{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("organization_id = ?", org.ID).Delete(&models.Invitation{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("organization_id = ?", org.ID).Delete(&models.RegistrationCode{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(org).Error
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not delete organization"))
+			return
+		}
+
+		requestctx.Logger(c).WithField("organization_id", org.ID).Info("Organization deleted")
+		response.SuccessResponse(c, http.StatusOK, "Organization deleted successfully", nil)
+	}
+}
+
+// ListMembers returns the members of the organization of the route, oldest first.
+func ListMembers(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, _ := requestctx.Organization(c)
+		members := []MemberResponse{}
+		err := db.WithContext(c.Request.Context()).Table("memberships").
+			Select("memberships.user_id, users.username, users.email, memberships.role, memberships.created_at AS joined_at").
+			Joins("JOIN users ON users.id = memberships.user_id AND users.deleted_at IS NULL").
+			Where("memberships.organization_id = ?", org.ID).
+			Order("memberships.created_at, memberships.id").
+			Scan(&members).Error
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve members"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Members retrieved successfully", members)
+	}
+}
+
+// UpdateMember changes the role of a member. Admins manage members and admins; only owners
+// grant the owner role or change the role of owners, and the last owner cannot step down.
+func UpdateMember(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req validators.MemberUpdate
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		if err := validators.ValidateOrgRole(req.Role); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		actor, _ := requestctx.Membership(c)
+		var target models.Membership
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			if err := findMember(tx, c, &target); err != nil {
+				return err
+			}
+			if (req.Role == models.OrgRoleOwner || target.Role == models.OrgRoleOwner) && actor.Role != models.OrgRoleOwner {
+				return errOwnerRequired
+			}
+			if target.Role == models.OrgRoleOwner && req.Role != models.OrgRoleOwner {
+				if err := ensureAnotherOwner(tx, target.OrganizationID); err != nil {
+					return err
+				}
+			}
+			target.Role = req.Role
+			return tx.Model(&target).Update("role", req.Role).Error
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not update member"))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"organization_id": target.OrganizationID,
+			"member_id":       target.UserID,
+			"org_role":        target.Role,
+		}).Info("Organization member role changed")
+		response.SuccessResponse(c, http.StatusOK, "Member updated successfully", target)
+	}
+}
+
+// RemoveMember removes a member from the organization. Members may remove themselves to leave
+// it; removing others takes the admin role, and removing owners the owner role. The last owner
+// cannot leave.
+func RemoveMember(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, _ := requestctx.Membership(c)
+		var target models.Membership
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			if err := findMember(tx, c, &target); err != nil {
+				return err
+			}
+			if target.UserID != actor.UserID {
+				if !models.OrgRoleAtLeast(actor.Role, models.OrgRoleAdmin) {
+					return apperrors.Forbidden("Access denied", "You need the admin role in this organization")
+				}
+				if target.Role == models.OrgRoleOwner && actor.Role != models.OrgRoleOwner {
+					return errOwnerRequired
+				}
+			}
+			if target.Role == models.OrgRoleOwner {
+				if err := ensureAnotherOwner(tx, target.OrganizationID); err != nil {
+					return err
+				}
+			}
+			return tx.Delete(&target).Error
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not remove member"))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"organization_id": target.OrganizationID,
+			"member_id":       target.UserID,
+		}).Info("Organization member removed")
+		response.SuccessResponse(c, http.StatusOK, "Member removed successfully", nil)
+	}
+}
+
+// findMember loads the membership of the :user_id route parameter in the route's organization.
+func findMember(tx *gorm.DB, c *gin.Context, target *models.Membership) error {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return apperrors.BadRequest("Invalid user ID", "The user ID must be a positive integer")
+	}
+	org, _ := requestctx.Organization(c)
+	err = tx.Where("organization_id = ? AND user_id = ?", org.ID, userID).First(target).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errMemberNotFound
+	}
+	return err
+}
+
+// ensureAnotherOwner fails when the organization has a single owner.
+func ensureAnotherOwner(tx *gorm.DB, orgID uint) error {
+	var owners int64
+	if err := tx.Model(&models.Membership{}).
+		Where("organization_id = ? AND role = ?", orgID, models.OrgRoleOwner).
+		Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errLastOwner
+	}
+	return nil
+}
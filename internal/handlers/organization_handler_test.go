@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func newOrgApp(t *testing.T, opts InvitationOptions) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		orgs := a.Router.Group("/orgs", middlewares.AuthRequired(a.DB))
+		orgs.POST("", CreateOrganization(a.DB, nil))
+		orgs.GET("", ListOrganizations(a.DB))
+		org := orgs.Group("/:"+middlewares.OrgParam, middlewares.OrgScope(a.DB))
+		org.GET("", GetOrganization())
+		org.DELETE("", middlewares.RequireOrgRole(models.OrgRoleOwner), DeleteOrganization(a.DB))
+		org.GET("/members", ListMembers(a.DB))
+		org.PATCH("/members/:user_id", middlewares.RequireOrgRole(models.OrgRoleAdmin), UpdateMember(a.DB))
+		org.DELETE("/members/:user_id", RemoveMember(a.DB))
+		invitations := org.Group("/invitations", middlewares.RequireOrgRole(models.OrgRoleAdmin))
+		invitations.POST("", CreateInvitation(a.DB, opts))
+		invitations.GET("", ListInvitations(a.DB))
+		invitations.DELETE("/:id", RevokeInvitation(a.DB))
+		a.Router.POST("/invitations/accept", middlewares.AuthRequired(a.DB), AcceptInvitation(a.DB))
+	})
+}
+
+func addMember(t *testing.T, app *testutil.App, org OrganizationResponse, user *models.User, role string) {
+	t.Helper()
+	if err := app.DB.Create(&models.Membership{OrganizationID: org.ID, UserID: user.ID, Role: role}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrganizations(t *testing.T) {
+	app := newOrgApp(t, InvitationOptions{TTL: time.Hour})
+	owner := testutil.CreateUser(t, app.DB)
+	outsider := testutil.CreateUser(t, app.DB)
+
+	var org OrganizationResponse
+	w := testutil.Post("/orgs").WithJWT(owner).WithJSON(map[string]string{"name": "Acme Corp!"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	testutil.DecodeData(t, w, &org)
+	if org.Slug != "acme-corp" || org.Role != models.OrgRoleOwner {
+		t.Fatalf("organization = %+v", org)
+	}
+	w = testutil.Post("/orgs").WithJWT(outsider).WithJSON(map[string]string{"name": "Acme", "slug": "acme-corp"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+	w = testutil.Post("/orgs").WithJWT(outsider).WithJSON(map[string]string{"name": "Numbers", "slug": "123"}).Do(t, app.Router)
+	testutil.AssertFieldError(t, w, "slug", "invalid_format")
+
+	// The organization is found by slug or ID, only by its members
+	var got OrganizationResponse
+	testutil.DecodeData(t, testutil.Get("/orgs/acme-corp").WithJWT(owner).Do(t, app.Router), &got)
+	if got.ID != org.ID || got.Role != models.OrgRoleOwner {
+		t.Errorf("GET by slug = %+v", got)
+	}
+	w = testutil.Get(fmt.Sprintf("/orgs/%d", org.ID)).WithJWT(outsider).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+
+	var orgs []OrganizationResponse
+	testutil.DecodeData(t, testutil.Get("/orgs").WithJWT(owner).Do(t, app.Router), &orgs)
+	if len(orgs) != 1 || orgs[0].Slug != "acme-corp" || orgs[0].Role != models.OrgRoleOwner {
+		t.Errorf("organizations = %+v", orgs)
+	}
+}
+
+func TestOrganizationMembers(t *testing.T) {
+	app := newOrgApp(t, InvitationOptions{TTL: time.Hour})
+	owner := testutil.CreateUser(t, app.DB)
+	admin := testutil.CreateUser(t, app.DB)
+	member := testutil.CreateUser(t, app.DB)
+
+	var org OrganizationResponse
+	testutil.DecodeData(t, testutil.Post("/orgs").WithJWT(owner).WithJSON(map[string]string{"name": "Team"}).Do(t, app.Router), &org)
+	addMember(t, app, org, admin, models.OrgRoleAdmin)
+	addMember(t, app, org, member, models.OrgRoleMember)
+	path := func(user *models.User) string { return fmt.Sprintf("/orgs/%d/members/%d", org.ID, user.ID) }
+
+	var members []MemberResponse
+	testutil.DecodeData(t, testutil.Get(fmt.Sprintf("/orgs/%d/members", org.ID)).WithJWT(member).Do(t, app.Router), &members)
+	if len(members) != 3 || members[0].UserID != owner.ID || members[0].Role != models.OrgRoleOwner {
+		t.Fatalf("members = %+v", members)
+	}
+
+	// Members cannot manage members, admins cannot manage owners or grant ownership
+	w := testutil.Patch(path(admin)).WithJWT(member).WithJSON(map[string]string{"role": "member"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+	w = testutil.Patch(path(member)).WithJWT(admin).WithJSON(map[string]string{"role": "owner"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+	w = testutil.NewRequest(http.MethodDelete, path(owner)).WithJWT(admin).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+	w = testutil.Patch(path(member)).WithJWT(admin).WithJSON(map[string]string{"role": "admin"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	// The last owner can neither step down nor leave
+	w = testutil.Patch(path(owner)).WithJWT(owner).WithJSON(map[string]string{"role": "admin"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+	w = testutil.NewRequest(http.MethodDelete, path(owner)).WithJWT(owner).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+	w = testutil.Patch(path(admin)).WithJWT(owner).WithJSON(map[string]string{"role": "owner"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	w = testutil.NewRequest(http.MethodDelete, path(owner)).WithJWT(owner).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	// Members may leave on their own
+	w = testutil.NewRequest(http.MethodDelete, path(member)).WithJWT(member).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	w = testutil.Get(fmt.Sprintf("/orgs/%d", org.ID)).WithJWT(member).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+
+	w = testutil.NewRequest(http.MethodDelete, fmt.Sprintf("/orgs/%d", org.ID)).WithJWT(admin).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var memberships int64
+	app.DB.Model(&models.Membership{}).Count(&memberships)
+	if memberships != 0 {
+		t.Errorf("memberships after delete = %d", memberships)
+	}
+}
+
+func TestOrganizationInvitations(t *testing.T) {
+	mailer := &outbox{}
+	app := newOrgApp(t, InvitationOptions{Mailer: mailer, TTL: time.Hour, AcceptURL: "https://app.example.com/invite"})
+	owner := testutil.CreateUser(t, app.DB)
+	invitee := testutil.CreateUser(t, app.DB, testutil.WithEmail("invitee@example.com"))
+	other := testutil.CreateUser(t, app.DB)
+
+	var org OrganizationResponse
+	testutil.DecodeData(t, testutil.Post("/orgs").WithJWT(owner).WithJSON(map[string]string{"name": "Invites"}).Do(t, app.Router), &org)
+	invitations := fmt.Sprintf("/orgs/%d/invitations", org.ID)
+
+	w := testutil.Post(invitations).WithJWT(owner).WithJSON(map[string]string{"email": "Invitee@Example.com", "role": "admin"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	var created InvitationResponse
+	testutil.DecodeData(t, w, &created)
+	if created.Token != "" || created.Email != "invitee@example.com" || len(mailer.messages) != 1 {
+		t.Fatalf("invitation = %+v, emails = %d", created, len(mailer.messages))
+	}
+	_, token, found := strings.Cut(mailer.messages[0].Body, "https://app.example.com/invite?token=")
+	if !found || mailer.messages[0].Email != "invitee@example.com" {
+		t.Fatalf("invitation email = %+v", mailer.messages[0])
+	}
+	token = strings.Fields(token)[0]
+
+	var pending []models.Invitation
+	testutil.DecodeData(t, testutil.Get(invitations).WithJWT(owner).Do(t, app.Router), &pending)
+	if len(pending) != 1 {
+		t.Errorf("pending invitations = %+v", pending)
+	}
+
+	// Only the invited address may accept, once
+	w = testutil.Post("/invitations/accept").WithJWT(other).WithJSON(map[string]string{"token": token}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+	w = testutil.Post("/invitations/accept").WithJWT(invitee).WithJSON(map[string]string{"token": token}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var joined OrganizationResponse
+	testutil.DecodeData(t, w, &joined)
+	if joined.ID != org.ID || joined.Role != models.OrgRoleAdmin {
+		t.Errorf("accepted organization = %+v", joined)
+	}
+	w = testutil.Post("/invitations/accept").WithJWT(invitee).WithJSON(map[string]string{"token": token}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+
+	// Members are not invited again, and admins do not invite owners
+	w = testutil.Post(invitations).WithJWT(owner).WithJSON(map[string]string{"email": "invitee@example.com"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+	w = testutil.Post(invitations).WithJWT(invitee).WithJSON(map[string]string{"email": "boss@example.com", "role": "owner"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+
+	w = testutil.Post(invitations).WithJWT(invitee).WithJSON(map[string]string{"email": "new@example.com"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	testutil.DecodeData(t, w, &created)
+	w = testutil.NewRequest(http.MethodDelete, fmt.Sprintf("%s/%d", invitations, created.ID)).WithJWT(owner).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	testutil.DecodeData(t, testutil.Get(invitations).WithJWT(owner).Do(t, app.Router), &pending)
+	if len(pending) != 0 {
+		t.Errorf("pending invitations after revoke = %+v", pending)
+	}
+}
+
+func TestOrganizationInvitations_WithoutMailer(t *testing.T) {
+	app := newOrgApp(t, InvitationOptions{TTL: time.Hour})
+	owner := testutil.CreateUser(t, app.DB)
+
+	var org OrganizationResponse
+	testutil.DecodeData(t, testutil.Post("/orgs").WithJWT(owner).WithJSON(map[string]string{"name": "Manual"}).Do(t, app.Router), &org)
+	var created InvitationResponse
+	w := testutil.Post(fmt.Sprintf("/orgs/%d/invitations", org.ID)).WithJWT(owner).WithJSON(map[string]string{"email": "x@example.com"}).Do(t, app.Router)
+	testutil.DecodeData(t, w, &created)
+	if created.Token == "" || created.Role != models.OrgRoleMember {
+		t.Errorf("invitation = %+v, want the token returned to the inviter", created)
+	}
+}
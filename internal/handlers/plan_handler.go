@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/entitlements"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+var errPlanNotFound = apperrors.NotFound("Plan not found", "No plan exists with the given name")
+
+// GetEntitlements returns the plan of the current user and its entitlements.
+func GetEntitlements(svc *entitlements.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set, err := svc.Resolve(c.Request.Context(), requestctx.UserID(c))
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve entitlements"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Entitlements retrieved successfully", set)
+	}
+}
+
+// ListPlans returns the plans with their entitlements, by name.
+func ListPlans(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plans := []models.Plan{}
+		if err := db.WithContext(c.Request.Context()).Preload("Entitlements").Order("name").Find(&plans).Error; err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve plans"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Plans retrieved successfully", plans)
+	}
+}
+
+// PutPlan creates the plan of the route or replaces its display name and entitlements. Users
+// of the plan get the new entitlements on their next request.
+func PutPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		var req validators.PlanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		req.Normalize()
+		// ValidatePlan always reports its failures as ValidationErrors, so the name from the
+		// path is reported with them.
+		var errs validators.ValidationErrors
+		if err := validators.ValidatePlan(&req); err != nil {
+			errors.As(err, &errs)
+		}
+		errs.Add("name", validators.ValidatePlanName("name", name))
+		if err := errs.Err(); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		plan := models.Plan{Name: name}
+		status := http.StatusOK
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			err := tx.Where("name = ?", name).First(&plan).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				status = http.StatusCreated
+				plan.DisplayName = req.DisplayName
+				if err := tx.Create(&plan).Error; err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			} else if err := tx.Model(&plan).Update("display_name", req.DisplayName).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("plan_id = ?", plan.ID).Delete(&models.Entitlement{}).Error; err != nil {
+				return err
+			}
+			plan.Entitlements = make([]models.Entitlement, len(req.Entitlements))
+			for i, e := range req.Entitlements {
+				plan.Entitlements[i] = models.Entitlement{PlanID: plan.ID, Key: e.Key, Limit: e.Limit}
+			}
+			if len(plan.Entitlements) == 0 {
+				return nil
+			}
+			return tx.Create(&plan.Entitlements).Error
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not save plan"))
+			return
+		}
+
+		requestctx.Logger(c).WithField("plan", plan.Name).Info("Plan saved")
+		response.SuccessResponse(c, status, "Plan saved successfully", plan)
+	}
+}
+
+// DeletePlan deletes the plan of the route with its entitlements. Plans assigned to users
+// cannot be deleted.
+func DeletePlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			var plan models.Plan
+			if err := tx.Where("name = ?", c.Param("name")).First(&plan).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errPlanNotFound
+				}
+				return err
+			}
+			var assigned int64
+			if err := tx.Model(&models.UserPlan{}).Where("plan_id = ?", plan.ID).Count(&assigned).Error; err != nil {
+				return err
+			}
+			if assigned > 0 {
+				return apperrors.Conflict("Plan not deleted", "The plan is assigned to "+strconv.FormatInt(assigned, 10)+" users")
+			}
+			if err := tx.Where("plan_id = ?", plan.ID).Delete(&models.Entitlement{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&plan).Error
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not delete plan"))
+			return
+		}
+
+		requestctx.Logger(c).WithField("plan", c.Param("name")).Info("Plan deleted")
+		response.SuccessResponse(c, http.StatusOK, "Plan deleted successfully", nil)
+	}
+}
+
+// AssignPlan assigns a plan to the user of the route, replacing any plan assigned before. The
+// assigned plan takes precedence over the user's subscription until it expires.
+func AssignPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid user ID", "The user ID must be a positive integer"))
+			return
+		}
+		var req validators.UserPlanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		req.Normalize()
+		if err := validators.ValidateUserPlan(&req); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		var assignment models.UserPlan
+		err = database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			var user models.User
+			if err := tx.Select("id").First(&user, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return apperrors.NotFound("User not found", "No user exists with the given id")
+				}
+				return err
+			}
+			var plan models.Plan
+			if err := tx.Preload("Entitlements").Where("name = ?", req.Plan).First(&plan).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errPlanNotFound
+				}
+				return err
+			}
+			if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserPlan{}).Error; err != nil {
+				return err
+			}
+			assignment = models.UserPlan{
+				UserID:     user.ID,
+				PlanID:     plan.ID,
+				AssignedBy: requestctx.UserID(c),
+				ExpiresAt:  req.ExpiresAt,
+			}
+			if err := tx.Omit("Plan").Create(&assignment).Error; err != nil {
+				return err
+			}
+			assignment.Plan = plan
+			return nil
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not assign plan"))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"target_user_id": assignment.UserID,
+			"plan":           req.Plan,
+		}).Info("Plan assigned")
+		response.SuccessResponse(c, http.StatusOK, "Plan assigned successfully", assignment)
+	}
+}
+
+// UnassignPlan removes the plan assigned to the user of the route, who falls back to the plan
+// of their subscription or the default plan.
+func UnassignPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid user ID", "The user ID must be a positive integer"))
+			return
+		}
+		result := db.WithContext(c.Request.Context()).Where("user_id = ?", id).Delete(&models.UserPlan{})
+		if result.Error != nil {
+			_ = c.Error(repository.TranslateError(result.Error, "Could not remove plan"))
+			return
+		}
+		if result.RowsAffected == 0 {
+			_ = c.Error(apperrors.NotFound("Plan not found", "The user has no assigned plan"))
+			return
+		}
+
+		requestctx.Logger(c).WithField("target_user_id", id).Info("Plan unassigned")
+		response.SuccessResponse(c, http.StatusOK, "Plan removed successfully", nil)
+	}
+}
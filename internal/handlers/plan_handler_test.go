@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/entitlements"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func newPlanApp(t *testing.T) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		ents := entitlements.NewService(a.DB, "free")
+		admin := a.Router.Group("/admin", middlewares.AuthRequired(a.DB), middlewares.RequireRole(models.RoleAdmin))
+		admin.GET("/plans", ListPlans(a.DB))
+		admin.PUT("/plans/:name", PutPlan(a.DB))
+		admin.DELETE("/plans/:name", DeletePlan(a.DB))
+		admin.PUT("/users/:id/plan", AssignPlan(a.DB))
+		admin.DELETE("/users/:id/plan", UnassignPlan(a.DB))
+		a.Router.GET("/users/me/entitlements", middlewares.AuthRequired(a.DB), GetEntitlements(ents))
+		a.Router.POST("/orgs", middlewares.AuthRequired(a.DB), CreateOrganization(a.DB, ents))
+	})
+}
+
+func putPlan(admin *models.User, name string, body map[string]interface{}) *testutil.Request {
+	return testutil.NewRequest(http.MethodPut, "/admin/plans/"+name).WithJWT(admin).WithJSON(body)
+}
+
+func createOrg(t *testing.T, app *testutil.App, user *models.User, slug string) int {
+	t.Helper()
+	return testutil.Post("/orgs").WithJWT(user).WithJSON(map[string]string{"name": slug, "slug": slug}).Do(t, app.Router).Code
+}
+
+func TestPlansLimitOrganizations(t *testing.T) {
+	app := newPlanApp(t)
+	admin := testutil.CreateUser(t, app.DB, testutil.WithRole(models.RoleAdmin))
+	user := testutil.CreateUser(t, app.DB)
+
+	w := putPlan(admin, "free", map[string]interface{}{
+		"display_name": "Free",
+		"entitlements": []map[string]interface{}{{"key": "max_organizations", "limit": 1}},
+	}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	w = putPlan(admin, "team", map[string]interface{}{
+		"entitlements": []map[string]interface{}{{"key": "max_organizations", "limit": 3}, {"key": "feature.sso"}},
+	}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	w = putPlan(admin, "Bad.Name", map[string]interface{}{
+		"entitlements": []map[string]interface{}{{"key": "max_organizations", "limit": -1}},
+	}).Do(t, app.Router)
+	testutil.AssertFieldError(t, w, "entitlements", "invalid_value")
+	testutil.AssertFieldError(t, w, "name", "invalid_format")
+	w = testutil.NewRequest(http.MethodPut, "/admin/plans/free").WithJWT(user).WithJSON(map[string]interface{}{}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+
+	// The default plan allows one organization
+	if code := createOrg(t, app, user, "first-org"); code != http.StatusCreated {
+		t.Fatalf("first organization: status %d", code)
+	}
+	w = testutil.Post("/orgs").WithJWT(user).WithJSON(map[string]string{"name": "Second", "slug": "second-org"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "PLAN_LIMIT_REACHED")
+
+	// An assigned plan takes precedence
+	w = testutil.NewRequest(http.MethodPut, fmt.Sprintf("/admin/users/%d/plan", user.ID)).WithJWT(admin).
+		WithJSON(map[string]string{"plan": "Team"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var set entitlements.Set
+	testutil.DecodeData(t, testutil.Get("/users/me/entitlements").WithJWT(user).Do(t, app.Router), &set)
+	if set.Plan != "team" || set.Source != entitlements.SourceAssigned || !set.Allows("feature.sso") {
+		t.Errorf("entitlements = %+v", set)
+	}
+	if code := createOrg(t, app, user, "second-org"); code != http.StatusCreated {
+		t.Errorf("second organization on the team plan: status %d", code)
+	}
+
+	w = testutil.NewRequest(http.MethodPut, "/admin/users/999/plan").WithJWT(admin).
+		WithJSON(map[string]string{"plan": "team"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+	w = testutil.NewRequest(http.MethodPut, fmt.Sprintf("/admin/users/%d/plan", user.ID)).WithJWT(admin).
+		WithJSON(map[string]string{"plan": "gold"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+
+	// Assigned plans cannot be deleted
+	w = testutil.NewRequest(http.MethodDelete, "/admin/plans/team").WithJWT(admin).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+	w = testutil.NewRequest(http.MethodDelete, fmt.Sprintf("/admin/users/%d/plan", user.ID)).WithJWT(admin).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	w = testutil.NewRequest(http.MethodDelete, "/admin/plans/team").WithJWT(admin).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var plans []models.Plan
+	testutil.DecodeData(t, testutil.Get("/admin/plans").WithJWT(admin).Do(t, app.Router), &plans)
+	if len(plans) != 1 || plans[0].Name != "free" || len(plans[0].Entitlements) != 1 {
+		t.Errorf("plans = %+v", plans)
+	}
+	testutil.DecodeData(t, testutil.Get("/users/me/entitlements").WithJWT(user).Do(t, app.Router), &set)
+	if set.Plan != "free" || set.Source != entitlements.SourceDefault {
+		t.Errorf("entitlements after unassigning = %+v", set)
+	}
+}
+
+func TestPutPlanReplacesEntitlements(t *testing.T) {
+	app := newPlanApp(t)
+	admin := testutil.CreateUser(t, app.DB, testutil.WithRole(models.RoleAdmin))
+
+	testutil.AssertStatus(t, putPlan(admin, "pro", map[string]interface{}{
+		"entitlements": []map[string]interface{}{{"key": "max_organizations", "limit": 5}, {"key": "feature.export"}},
+	}).Do(t, app.Router), http.StatusCreated)
+	w := putPlan(admin, "pro", map[string]interface{}{
+		"display_name": "Pro",
+		"entitlements": []map[string]interface{}{{"key": "max_organizations", "limit": 10}},
+	}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var plan models.Plan
+	testutil.DecodeData(t, w, &plan)
+	if plan.DisplayName != "Pro" || len(plan.Entitlements) != 1 || *plan.Entitlements[0].Limit != 10 {
+		t.Errorf("plan = %+v", plan)
+	}
+	var stored int64
+	app.DB.Model(&models.Entitlement{}).Count(&stored)
+	if stored != 1 {
+		t.Errorf("stored %d entitlements, want the replaced one", stored)
+	}
+}
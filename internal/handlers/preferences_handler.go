@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/preferences"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/patch"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// GetPreferences returns every preference of the current user, with defaults for the ones
+// they never changed.
+func GetPreferences(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		values, err := loadPreferences(db.WithContext(c.Request.Context()), requestctx.UserID(c))
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve preferences"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Preferences retrieved successfully", values)
+	}
+}
+
+// UpdatePreferences applies a JSON Merge Patch or JSON Patch to the preferences of the current
+// user. Removing a setting, for example with null in a merge patch, resets it to its default.
+func UpdatePreferences(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := requestctx.UserID(c)
+		current, err := loadPreferences(db.WithContext(c.Request.Context()), userID)
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not update preferences"))
+			return
+		}
+
+		doc := make(validators.PreferencesDocument, len(current))
+		for category, settings := range current {
+			doc[category] = make(map[string]json.RawMessage, len(settings))
+			for name, v := range settings {
+				doc[category][name], _ = json.Marshal(v)
+			}
+		}
+		if err := patch.Bind(c, &doc); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		values, err := validators.ValidatePreferences(doc)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		// Only the settings that differ from their default are stored
+		var rows []models.Preference
+		for category, settings := range preferences.Schema {
+			for name, setting := range settings {
+				v := values[category][name]
+				if reflect.DeepEqual(v, setting.Default) {
+					continue
+				}
+				raw, _ := json.Marshal(v)
+				rows = append(rows, models.Preference{
+					UserID:  userID,
+					Setting: preferences.Key(category, name),
+					Value:   string(raw),
+				})
+			}
+		}
+		err = database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			if err := tx.Where("user_id = ?", userID).Delete(&models.Preference{}).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+			return tx.Create(&rows).Error
+		})
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not update preferences"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Preferences updated successfully", values)
+	}
+}
+
+// PreferencesSchema returns the JSON Schema of the preferences document.
+func PreferencesSchema() gin.HandlerFunc {
+	schema := preferences.JSONSchema()
+	return func(c *gin.Context) {
+		response.SuccessResponse(c, http.StatusOK, "Preferences schema retrieved successfully", schema)
+	}
+}
+
+// loadPreferences returns the preferences of a user resolved against their defaults.
+func loadPreferences(db *gorm.DB, userID uint) (preferences.Values, error) {
+	var rows []models.Preference
+	if err := db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	stored := make(map[string]string, len(rows))
+	for _, row := range rows {
+		stored[row.Setting] = row.Value
+	}
+	return preferences.Resolve(stored), nil
+}
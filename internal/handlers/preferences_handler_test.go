@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/preferences"
+	"github.com/yeferson59/gin-template/pkg/patch"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func newPreferencesApp(t *testing.T) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		me := a.Router.Group("/me/preferences", middlewares.AuthRequired(a.DB))
+		me.GET("", GetPreferences(a.DB))
+		me.PATCH("", UpdatePreferences(a.DB))
+		me.GET("/schema", PreferencesSchema())
+	})
+}
+
+func TestPreferences(t *testing.T) {
+	app := newPreferencesApp(t)
+	user := testutil.CreateUser(t, app.DB)
+
+	var values preferences.Values
+	testutil.DecodeData(t, testutil.Get("/me/preferences").WithJWT(user).Do(t, app.Router), &values)
+	if values["theme"]["mode"] != "system" || values["notifications"]["email"] != true {
+		t.Fatalf("default preferences = %+v", values)
+	}
+
+	w := testutil.Patch("/me/preferences").WithJWT(user).
+		WithJSON(`{"theme": {"mode": "dark", "font_scale": 120}, "locale": {"language": "pt-br", "timezone": "America/Bogota"}}`).
+		Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	testutil.DecodeData(t, w, &values)
+	if values["theme"]["mode"] != "dark" || values["theme"]["font_scale"] != float64(120) || values["locale"]["language"] != "pt-BR" {
+		t.Fatalf("patched preferences = %+v", values)
+	}
+
+	// Only the changed settings are stored, and null resets one to its default
+	var stored int64
+	app.DB.Model(&models.Preference{}).Where("user_id = ?", user.ID).Count(&stored)
+	if stored != 4 {
+		t.Errorf("stored preferences = %d, want 4", stored)
+	}
+	w = testutil.Patch("/me/preferences").WithJWT(user).WithJSON(`{"theme": {"mode": null}}`).Do(t, app.Router)
+	testutil.DecodeData(t, w, &values)
+	if values["theme"]["mode"] != "system" || values["theme"]["font_scale"] != float64(120) {
+		t.Errorf("preferences after reset = %+v", values)
+	}
+
+	w = testutil.Patch("/me/preferences").WithJWT(user).
+		WithJSON(`[{"op": "replace", "path": "/notifications/digest", "value": "weekly"}]`).
+		WithHeader("Content-Type", patch.JSONPatchType).
+		Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	testutil.DecodeData(t, testutil.Get("/me/preferences").WithJWT(user).Do(t, app.Router), &values)
+	if values["notifications"]["digest"] != "weekly" || values["locale"]["timezone"] != "America/Bogota" {
+		t.Errorf("preferences after JSON Patch = %+v", values)
+	}
+}
+
+func TestPreferences_Validation(t *testing.T) {
+	app := newPreferencesApp(t)
+	user := testutil.CreateUser(t, app.DB)
+
+	w := testutil.Patch("/me/preferences").WithJWT(user).WithJSON(`{
+		"theme": {"mode": "neon", "font_scale": 500, "sparkles": true},
+		"locale": {"timezone": "Mars/Olympus"},
+		"notifications": {"email": "yes"},
+		"billing": {}
+	}`).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusBadRequest, "VALIDATION_ERROR")
+	for _, field := range []string{"theme.mode", "theme.font_scale", "theme.sparkles", "locale.timezone", "notifications.email", "billing"} {
+		testutil.AssertFieldError(t, w, field, "invalid_value")
+	}
+
+	var stored int64
+	app.DB.Model(&models.Preference{}).Where("user_id = ?", user.ID).Count(&stored)
+	if stored != 0 {
+		t.Errorf("invalid preferences were stored: %d", stored)
+	}
+}
+
+func TestPreferencesSchema(t *testing.T) {
+	app := newPreferencesApp(t)
+	user := testutil.CreateUser(t, app.DB)
+
+	var schema map[string]any
+	testutil.DecodeData(t, testutil.Get("/me/preferences/schema").WithJWT(user).Do(t, app.Router), &schema)
+	theme := schema["properties"].(map[string]any)["theme"].(map[string]any)
+	mode := theme["properties"].(map[string]any)["mode"].(map[string]any)
+	if mode["default"] != "system" || len(mode["enum"].([]any)) != 3 {
+		t.Errorf("theme.mode schema = %+v", mode)
+	}
+}
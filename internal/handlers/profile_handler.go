@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/profiler"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// ListProfiles returns the profile snapshots captured when the latency or the error rate
+// degraded, newest first, with the storage keys of their CPU and heap profiles.
+func ListProfiles(p *profiler.Profiler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		snapshots, err := p.List(c.Request.Context())
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Failed to list profile snapshots", "", err))
+			return
+		}
+		if snapshots == nil {
+			snapshots = []profiler.Snapshot{}
+		}
+		c.Header("Cache-Control", "no-store")
+		response.SuccessResponse(c, http.StatusOK, "Profile snapshots retrieved successfully", snapshots)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/pkg/profiler"
+	"github.com/yeferson59/gin-template/pkg/storage"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestListProfiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p, err := profiler.New(profiler.Options{
+		ErrorRate:   0.4,
+		Window:      time.Minute,
+		CPUDuration: 10 * time.Millisecond,
+		Store:       storage.NewLocal(t.TempDir()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(middlewares.Profiler(p), middlewares.ErrorHandler())
+	r.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	r.GET("/api/admin/profiles", ListProfiles(p))
+
+	var snapshots []profiler.Snapshot
+	testutil.DecodeData(t, testutil.Get("/api/admin/profiles").Do(t, r), &snapshots)
+	if len(snapshots) != 0 {
+		t.Fatalf("snapshots = %+v, want none yet", snapshots)
+	}
+
+	testutil.Get("/fail").Do(t, r)
+	if _, captured, err := p.Evaluate(context.Background()); !captured || err != nil {
+		t.Fatalf("Evaluate = %v, %v; want the failed request to trigger a capture", captured, err)
+	}
+
+	w := testutil.Get("/api/admin/profiles").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	testutil.DecodeData(t, w, &snapshots)
+	if len(snapshots) != 1 || len(snapshots[0].Keys) != 2 || snapshots[0].ErrorRate != 0.5 {
+		t.Errorf("snapshots = %+v, want the capture with its profiles", snapshots)
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/readonly"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// ReadOnlyStatus is the state of read-only mode, and the body of PUT /api/admin/read-only.
+type ReadOnlyStatus struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// GetReadOnly returns whether read-only mode is on.
+func GetReadOnly(mode *readonly.Switch) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enabled := mode.Enabled()
+		response.SuccessResponse(c, http.StatusOK, "Read-only mode retrieved successfully", ReadOnlyStatus{Enabled: &enabled})
+	}
+}
+
+// SetReadOnly turns read-only mode on or off on this instance.
+func SetReadOnly(mode *readonly.Switch) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ReadOnlyStatus
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+
+		if mode.Set(*req.Enabled) {
+			requestctx.Logger(c).WithField("enabled", *req.Enabled).Warn("Read-only mode changed")
+		}
+		response.SuccessResponse(c, http.StatusOK, "Read-only mode updated successfully", req)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/pkg/readonly"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestReadOnlyToggle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mode := readonly.NewSwitch(false)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler())
+	r.GET("/api/admin/read-only", GetReadOnly(mode))
+	r.PUT("/api/admin/read-only", SetReadOnly(mode))
+
+	w := testutil.NewRequest(http.MethodPut, "/api/admin/read-only").
+		WithJSON(map[string]interface{}{"enabled": true}).
+		Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if !mode.Enabled() {
+		t.Fatal("PUT did not enable read-only mode")
+	}
+
+	w = testutil.Get("/api/admin/read-only").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var status ReadOnlyStatus
+	testutil.DecodeData(t, w, &status)
+	if status.Enabled == nil || !*status.Enabled {
+		t.Errorf("GET = %+v, want enabled", status)
+	}
+
+	w = testutil.NewRequest(http.MethodPut, "/api/admin/read-only").
+		WithJSON(map[string]interface{}{}).
+		Do(t, r)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+	if !mode.Enabled() {
+		t.Error("an invalid request changed read-only mode")
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"github.com/yeferson59/gin-template/internal/realtime"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// realtimeWriteTimeout bounds the write of each message to a WebSocket client.
+const realtimeWriteTimeout = 10 * time.Second
+
+var errInvalidChannel = apperrors.BadRequest("Invalid channel",
+	"Channels are 1 to 100 letters, digits, or the characters _ . : -")
+
+// RealtimeSocket upgrades the request to a WebSocket joining the current user to the channel
+// named by ?channel=. The client receives JSON messages with the presence of the channel and
+// who is typing, and sends {"type":"heartbeat"} or {"type":"typing"} to stay online; the
+// connection is closed once the hub's TTL passes without a message from the client.
+func RealtimeSocket(hub *realtime.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channel := c.Query("channel")
+		if !realtime.ValidChannel(channel) {
+			_ = c.Error(errInvalidChannel)
+			return
+		}
+		if !strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			_ = c.Error(apperrors.BadRequest("WebSocket upgrade required",
+				"Connect with a WebSocket client, which sends Upgrade: websocket"))
+			return
+		}
+
+		client, err := hub.Join(channel, requestctx.UserID(c))
+		if err != nil {
+			_ = c.Error(apperrors.Unavailable("Too many connections", "Try connecting again later"))
+			return
+		}
+		defer hub.Leave(client)
+
+		// Server, unlike websocket.Handler, does not check the Origin: the connection is
+		// authenticated with a header, which other sites cannot make a browser send
+		server := websocket.Server{Handler: func(ws *websocket.Conn) {
+			serveRealtime(ws, hub, client)
+		}}
+		server.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// serveRealtime writes the messages of client to ws and reads those of the client until either
+// side closes the connection or the hub drops the client.
+func serveRealtime(ws *websocket.Conn, hub *realtime.Hub, client *realtime.Client) {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		// Closing the connection also ends the read loop below
+		defer func() { _ = ws.Close() }()
+		for {
+			select {
+			case msg := <-client.Messages():
+				_ = ws.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+				if err := websocket.JSON.Send(ws, msg); err != nil {
+					return
+				}
+			case <-client.Done():
+				return
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(hub.TTL()))
+		var msg realtime.Message
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			break
+		}
+		if msg.Type == realtime.TypeTyping {
+			hub.Typing(client)
+		} else {
+			hub.Heartbeat(client)
+		}
+	}
+	close(stop)
+	<-stopped
+}
+
+// ChannelPresence returns the users online in :channel, by user ID.
+func ChannelPresence(hub *realtime.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channel := c.Param("channel")
+		if !realtime.ValidChannel(channel) {
+			_ = c.Error(errInvalidChannel)
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Presence retrieved successfully", hub.Online(channel))
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/realtime"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+// dialRealtime connects user to channel on srv.
+func dialRealtime(t *testing.T, srv *httptest.Server, user *models.User, channel string) *websocket.Conn {
+	t.Helper()
+	cfg, err := websocket.NewConfig("ws"+strings.TrimPrefix(srv.URL, "http")+"/realtime?channel="+channel, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := auth.GenerateJWT(user.ID, user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Header.Set("Authorization", "Bearer "+token)
+	ws, err := websocket.DialConfig(cfg)
+	if err != nil {
+		t.Fatalf("dial %s: %v", channel, err)
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	return ws
+}
+
+func receive(t *testing.T, ws *websocket.Conn) realtime.Message {
+	t.Helper()
+	_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg realtime.Message
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	return msg
+}
+
+func TestRealtimePresence(t *testing.T) {
+	hub := realtime.NewHub(time.Minute, 0)
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		a.Router.GET("/realtime", middlewares.AuthRequired(a.DB), RealtimeSocket(hub))
+		a.Router.GET("/channels/:channel/presence", middlewares.AuthRequired(a.DB), ChannelPresence(hub))
+	})
+	srv := httptest.NewServer(app.Router)
+	defer srv.Close()
+	alice := testutil.CreateUser(t, app.DB, testutil.WithUsername("alice"), testutil.WithEmail("alice@example.com"))
+	bob := testutil.CreateUser(t, app.DB, testutil.WithUsername("bob"), testutil.WithEmail("bob@example.com"))
+
+	aliceWS := dialRealtime(t, srv, alice, "room")
+	if msg := receive(t, aliceWS); msg.Type != realtime.TypePresenceState || len(msg.Members) != 1 {
+		t.Fatalf("alice's first message = %+v", msg)
+	}
+	bobWS := dialRealtime(t, srv, bob, "room")
+	if msg := receive(t, bobWS); msg.Type != realtime.TypePresenceState || len(msg.Members) != 2 {
+		t.Fatalf("bob's first message = %+v", msg)
+	}
+	if msg := receive(t, aliceWS); msg.Type != realtime.TypePresenceJoin || msg.UserID != bob.ID {
+		t.Fatalf("alice received %+v, want bob's join", msg)
+	}
+
+	if err := websocket.JSON.Send(bobWS, realtime.Message{Type: realtime.TypeTyping}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, aliceWS); msg.Type != realtime.TypeTyping || msg.UserID != bob.ID {
+		t.Fatalf("alice received %+v, want bob typing", msg)
+	}
+
+	w := testutil.Get("/channels/room/presence").WithJWT(alice).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var members []realtime.Member
+	testutil.DecodeData(t, w, &members)
+	if len(members) != 2 || members[0].UserID != alice.ID || members[1].UserID != bob.ID {
+		t.Fatalf("presence = %+v", members)
+	}
+
+	_ = bobWS.Close()
+	if msg := receive(t, aliceWS); msg.Type != realtime.TypePresenceLeave || msg.UserID != bob.ID {
+		t.Fatalf("alice received %+v, want bob's leave", msg)
+	}
+}
+
+func TestRealtimeSocketRejectsInvalidRequests(t *testing.T) {
+	hub := realtime.NewHub(time.Minute, 0)
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		a.Router.GET("/realtime", middlewares.AuthRequired(a.DB), RealtimeSocket(hub))
+		a.Router.GET("/channels/:channel/presence", middlewares.AuthRequired(a.DB), ChannelPresence(hub))
+	})
+	user := testutil.CreateUser(t, app.DB)
+
+	w := testutil.Get("/realtime?channel=room").Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusUnauthorized)
+	w = testutil.Get("/realtime?channel=room").WithJWT(user).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+	w = testutil.Get("/realtime?channel=no%20spaces").WithJWT(user).WithHeader("Upgrade", "websocket").Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+	w = testutil.Get("/channels/a%20b/presence").WithJWT(user).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+	if members := hub.Online("room"); len(members) != 0 {
+		t.Errorf("rejected requests joined the channel: %+v", members)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// RegistrationCodeResponse is a created registration code. Code is only returned once.
+type RegistrationCodeResponse struct {
+	models.RegistrationCode
+	Code string `json:"code"`
+}
+
+var errInvalidRegistrationCode = apperrors.Forbidden("Registration not allowed", "The invitation code is invalid, expired, or used up")
+
+// CreateRegistrationCode creates a registration code for invite-only registration. Within an
+// organization route (see middlewares.OrgScope) users registered with it join the organization.
+// Codes without an expiry last ttl.
+func CreateRegistrationCode(db *gorm.DB, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req validators.RegistrationCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid request data", err.Error()))
+			return
+		}
+		req.Normalize()
+		if req.MaxUses == 0 {
+			req.MaxUses = 1
+		}
+		if err := validators.ValidateRegistrationCode(&req); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		code, err := randomToken()
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Could not create registration code", "Could not generate code", err))
+			return
+		}
+		registration := models.RegistrationCode{
+			CodeHash:    hashCode(code),
+			Note:        req.Note,
+			CreatedByID: requestctx.UserID(c),
+			MaxUses:     req.MaxUses,
+			ExpiresAt:   auth.Now().Add(ttl),
+		}
+		if req.ExpiresAt != nil {
+			registration.ExpiresAt = *req.ExpiresAt
+		}
+		if org, ok := requestctx.Organization(c); ok {
+			registration.OrganizationID = &org.ID
+		}
+		if err := db.WithContext(c.Request.Context()).Create(&registration).Error; err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not create registration code"))
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"registration_code_id": registration.ID,
+			"max_uses":             registration.MaxUses,
+		}).Info("Registration code created")
+		response.SuccessResponse(c, http.StatusCreated, "Registration code created successfully",
+			RegistrationCodeResponse{RegistrationCode: registration, Code: code})
+	}
+}
+
+// ListRegistrationCodes returns the usable registration codes, newest first: those of the
+// organization of the route, or all of them on admin routes.
+func ListRegistrationCodes(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		codes := []models.RegistrationCode{}
+		query := db.WithContext(c.Request.Context()).Where("uses < max_uses AND expires_at > ?", auth.Now())
+		if org, ok := requestctx.Organization(c); ok {
+			query = query.Where("organization_id = ?", org.ID)
+		}
+		if err := query.Order("created_at DESC, id DESC").Find(&codes).Error; err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve registration codes"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Registration codes retrieved successfully", codes)
+	}
+}
+
+// RevokeRegistrationCode deletes a registration code, within the organization of the route
+// when there is one.
+func RevokeRegistrationCode(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid registration code ID", "The registration code ID must be a positive integer"))
+			return
+		}
+		query := db.WithContext(c.Request.Context()).Where("id = ?", id)
+		if org, ok := requestctx.Organization(c); ok {
+			query = query.Where("organization_id = ?", org.ID)
+		}
+		result := query.Delete(&models.RegistrationCode{})
+		if result.Error != nil {
+			_ = c.Error(repository.TranslateError(result.Error, "Could not revoke registration code"))
+			return
+		}
+		if result.RowsAffected == 0 {
+			_ = c.Error(apperrors.NotFound("Registration code not found", "The registration code does not exist"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Registration code revoked successfully", nil)
+	}
+}
+
+// redeemRegistrationCode uses up one use of a registration code. The conditional update keeps
+// concurrent registrations from exceeding the usage limit.
+func redeemRegistrationCode(tx *gorm.DB, code string) (*models.RegistrationCode, error) {
+	hash := hashCode(code)
+	result := tx.Model(&models.RegistrationCode{}).
+		Where("code_hash = ? AND uses < max_uses AND expires_at > ?", hash, auth.Now()).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errInvalidRegistrationCode
+	}
+	var registration models.RegistrationCode
+	if err := tx.Where("code_hash = ?", hash).First(&registration).Error; err != nil {
+		return nil, err
+	}
+	return &registration, nil
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/clock"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func newInviteOnlyApp(t *testing.T) *testutil.App {
+	return testutil.NewApp(t, func(a *testutil.App) {
+		a.Router.POST("/register", Register(a.DB, RegistrationOptions{InviteOnly: true}))
+		codes := a.Router.Group("/codes", middlewares.AuthRequired(a.DB))
+		codes.POST("", CreateRegistrationCode(a.DB, time.Hour))
+		codes.GET("", ListRegistrationCodes(a.DB))
+		codes.DELETE("/:id", RevokeRegistrationCode(a.DB))
+		org := a.Router.Group("/orgs/:"+middlewares.OrgParam+"/codes",
+			middlewares.AuthRequired(a.DB), middlewares.OrgScope(a.DB), middlewares.RequireOrgRole(models.OrgRoleOwner))
+		org.POST("", CreateRegistrationCode(a.DB, time.Hour))
+		org.GET("", ListRegistrationCodes(a.DB))
+	})
+}
+
+func registerWithCode(t *testing.T, app *testutil.App, username, code string) int {
+	t.Helper()
+	return testutil.Post("/register").WithJSON(map[string]string{
+		"username":        username,
+		"email":           username + "@example.com",
+		"password":        "Str0ng!Secret",
+		"invitation_code": code,
+	}).Do(t, app.Router).Code
+}
+
+func TestRegister_InviteOnly(t *testing.T) {
+	app := newInviteOnlyApp(t)
+	admin := testutil.CreateUser(t, app.DB)
+
+	w := testutil.Post("/register").WithJSON(map[string]string{
+		"username": "walkin",
+		"email":    "walkin@example.com",
+		"password": "Str0ng!Secret",
+	}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+	if code := registerWithCode(t, app, "guesser", "not-a-code"); code != http.StatusForbidden {
+		t.Fatalf("register with unknown code = %d, want 403", code)
+	}
+
+	var created RegistrationCodeResponse
+	w = testutil.Post("/codes").WithJWT(admin).WithJSON(map[string]any{"max_uses": 2, "note": "beta"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	testutil.DecodeData(t, w, &created)
+	if created.Code == "" || created.MaxUses != 2 || created.OrganizationID != nil {
+		t.Fatalf("registration code = %+v", created)
+	}
+
+	if code := registerWithCode(t, app, "first", created.Code); code != http.StatusCreated {
+		t.Fatalf("first registration = %d, want 201", code)
+	}
+	// A failed registration does not use up the code
+	if code := registerWithCode(t, app, "first", created.Code); code != http.StatusConflict {
+		t.Fatalf("duplicate registration = %d, want 409", code)
+	}
+	if code := registerWithCode(t, app, "second", created.Code); code != http.StatusCreated {
+		t.Fatalf("second registration = %d, want 201", code)
+	}
+	if code := registerWithCode(t, app, "third", created.Code); code != http.StatusForbidden {
+		t.Fatalf("registration past the usage limit = %d, want 403", code)
+	}
+
+	var codes []models.RegistrationCode
+	testutil.DecodeData(t, testutil.Get("/codes").WithJWT(admin).Do(t, app.Router), &codes)
+	if len(codes) != 0 {
+		t.Errorf("used up codes are still listed: %+v", codes)
+	}
+}
+
+func TestRegister_InviteOnlyExpiryAndRevocation(t *testing.T) {
+	app := newInviteOnlyApp(t)
+	admin := testutil.CreateUser(t, app.DB)
+
+	w := testutil.Post("/codes").WithJWT(admin).WithJSON(map[string]any{"max_uses": 0, "expires_at": time.Now().Add(-time.Minute)}).Do(t, app.Router)
+	testutil.AssertFieldError(t, w, "expires_at", "invalid_value")
+	w = testutil.Post("/codes").WithJWT(admin).WithJSON(map[string]any{"max_uses": 100000}).Do(t, app.Router)
+	testutil.AssertFieldError(t, w, "max_uses", "invalid_value")
+
+	var expiring, revoked RegistrationCodeResponse
+	testutil.DecodeData(t, testutil.Post("/codes").WithJWT(admin).WithJSON(map[string]any{}).Do(t, app.Router), &expiring)
+	testutil.DecodeData(t, testutil.Post("/codes").WithJWT(admin).WithJSON(map[string]any{}).Do(t, app.Router), &revoked)
+	if expiring.MaxUses != 1 || time.Until(expiring.ExpiresAt) > time.Hour {
+		t.Fatalf("default registration code = %+v", expiring)
+	}
+
+	app.DB.Model(&models.RegistrationCode{}).Where("id = ?", expiring.ID).Update("expires_at", time.Now().Add(-time.Second))
+	if code := registerWithCode(t, app, "late", expiring.Code); code != http.StatusForbidden {
+		t.Errorf("registration with an expired code = %d, want 403", code)
+	}
+
+	w = testutil.NewRequest(http.MethodDelete, fmt.Sprintf("/codes/%d", revoked.ID)).WithJWT(admin).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if code := registerWithCode(t, app, "revoked", revoked.Code); code != http.StatusForbidden {
+		t.Errorf("registration with a revoked code = %d, want 403", code)
+	}
+}
+
+func TestRegister_InviteOnlyCodeExpiresWithClock(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auth.SetClock(clk)
+	t.Cleanup(func() { auth.SetClock(nil) })
+	app := newInviteOnlyApp(t)
+	admin := testutil.CreateUser(t, app.DB)
+
+	var code RegistrationCodeResponse
+	testutil.DecodeData(t, testutil.Post("/codes").WithJWT(admin).WithJSON(map[string]any{"max_uses": 2}).Do(t, app.Router), &code)
+	if !code.ExpiresAt.Equal(clk.Now().Add(time.Hour)) {
+		t.Fatalf("expires_at = %v, want an hour after the clock", code.ExpiresAt)
+	}
+
+	clk.Advance(59 * time.Minute)
+	if status := registerWithCode(t, app, "early", code.Code); status != http.StatusCreated {
+		t.Fatalf("registration before expiry = %d, want 201", status)
+	}
+	clk.Advance(2 * time.Minute)
+	if status := registerWithCode(t, app, "late", code.Code); status != http.StatusForbidden {
+		t.Errorf("registration after expiry = %d, want 403", status)
+	}
+}
+
+func TestRegister_OrganizationCodeJoinsOrganization(t *testing.T) {
+	app := newInviteOnlyApp(t)
+	owner := testutil.CreateUser(t, app.DB)
+	admin := testutil.CreateUser(t, app.DB)
+	org := models.Organization{Name: "Acme", Slug: "acme"}
+	app.DB.Create(&org)
+	app.DB.Create(&models.Membership{OrganizationID: org.ID, UserID: owner.ID, Role: models.OrgRoleOwner})
+	app.DB.Create(&models.Membership{OrganizationID: org.ID, UserID: admin.ID, Role: models.OrgRoleAdmin})
+
+	w := testutil.Post("/orgs/acme/codes").WithJWT(admin).WithJSON(map[string]any{}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusForbidden, "FORBIDDEN")
+
+	var created RegistrationCodeResponse
+	w = testutil.Post("/orgs/acme/codes").WithJWT(owner).WithJSON(map[string]any{}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	testutil.DecodeData(t, w, &created)
+	if created.OrganizationID == nil || *created.OrganizationID != org.ID {
+		t.Fatalf("registration code = %+v", created)
+	}
+
+	if code := registerWithCode(t, app, "newcomer", created.Code); code != http.StatusCreated {
+		t.Fatalf("registration = %d, want 201", code)
+	}
+	var membership models.Membership
+	err := app.DB.Joins("JOIN users ON users.id = memberships.user_id").
+		Where("users.username = ? AND memberships.organization_id = ?", "newcomer", org.ID).
+		First(&membership).Error
+	if err != nil || membership.Role != models.OrgRoleMember {
+		t.Fatalf("membership = %+v, %v", membership, err)
+	}
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/response"
+	"github.com/yeferson59/gin-template/pkg/saml"
+)
+
+// samlRequestCookie holds the ID of the AuthnRequest between the login redirect and the ACS.
+const samlRequestCookie = "saml_request_id"
+
+// samlRequestTTL is how long a SAML login may take at the IdP.
+const samlRequestTTL = 10 * time.Minute
+
+// SAMLOptions configures how SAML assertions map to users (see config.SAMLConfig).
+type SAMLOptions struct {
+	JITProvisioning   bool
+	TrustedDomains    []string
+	EmailAttribute    string
+	UsernameAttribute string
+	GroupsAttribute   string
+	AdminGroups       []string
+	RedirectURL       string
+}
+
+// errSSONotProvisioned is returned when no user matches the identity and JIT provisioning is off.
+var errSSONotProvisioned = apperrors.Forbidden("SSO account not provisioned", "No user matches this identity; ask an administrator to create your account")
+
+// errSSONotLinked is returned when an account outside the trusted domains has the email of a
+// new SSO identity.
+var errSSONotLinked = apperrors.Forbidden("SSO account not linked", "An account with this email already exists and cannot be linked to the identity provider; sign in with your password")
+
+// SAMLMetadata serves the SP metadata to register with the identity provider.
+func SAMLMetadata(sp *saml.ServiceProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/samlmetadata+xml", sp.Metadata())
+	}
+}
+
+// SAMLLogin redirects the browser to the identity provider with an AuthnRequest.
+func SAMLLogin(sp *saml.ServiceProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		redirectURL, requestID, err := sp.AuthnRequestURL("")
+		if err != nil {
+			_ = c.Error(apperrors.Internal("SSO login failed", "Could not create the SAML request", err))
+			return
+		}
+		setSAMLRequestCookie(c, sp, requestID, int(samlRequestTTL.Seconds()))
+		c.Redirect(http.StatusFound, redirectURL)
+	}
+}
+
+// SAMLACS is the assertion consumer service: it verifies the response the IdP posts through
+// the browser, provisions or links the user, and issues a token.
+func SAMLACS(db *gorm.DB, sp *saml.ServiceProvider, opts SAMLOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID, _ := c.Cookie(samlRequestCookie)
+		setSAMLRequestCookie(c, sp, "", -1)
+
+		assertion, err := sp.ParseResponse(c.PostForm("SAMLResponse"), requestID)
+		if err != nil {
+			logger.WithField("error", err.Error()).Warn("Invalid SAML response")
+			security.RecordFailedLogin(c, security.LoginSSORejected)
+			_ = c.Error(apperrors.Unauthorized("SSO login failed", "The identity provider response is invalid or expired").Wrap(err))
+			return
+		}
+
+		user, err := resolveSAMLUser(c, db, sp.IdP.EntityID, assertion, opts)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		risk := recordLoginEvent(c, db, user, inspectLogin(c, db, user))
+		token, err := auth.GenerateLoginJWT(user.ID, user.Email, risk)
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Authentication failed", "Could not generate access token", err))
+			return
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"user_id":  user.ID,
+			"username": user.Username,
+			"idp":      sp.IdP.EntityID,
+		}).Info("User logged in with SAML")
+
+		if opts.RedirectURL != "" {
+			// The fragment never reaches servers or logs on the way to the frontend
+			c.Redirect(http.StatusSeeOther, opts.RedirectURL+"#token="+url.QueryEscape(token))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Login successful", AuthResponse{
+			Token: token,
+			User:  &UserSafeResponse{ID: user.ID, Username: user.Username, Email: user.Email},
+		})
+	}
+}
+
+// resolveSAMLUser returns the user of an assertion: the one linked to the identity, else the
+// one with its email when its domain is trusted, which is then linked, else a new user when
+// JIT provisioning is on. With an admin group list, the role follows the groups of every
+// assertion carrying them.
+func resolveSAMLUser(c *gin.Context, db *gorm.DB, provider string, assertion *saml.Assertion, opts SAMLOptions) (*models.User, error) {
+	email := assertion.Attribute(opts.EmailAttribute)
+	if email == "" && strings.Contains(assertion.NameID, "@") {
+		email = assertion.NameID
+	}
+
+	var user models.User
+	err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+		var identity models.ExternalIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider, assertion.NameID).First(&identity).Error
+		switch {
+		case err == nil:
+			if err := tx.First(&user, identity.UserID).Error; err != nil {
+				return apperrors.Unauthorized("SSO login failed", "The linked account no longer exists").Wrap(err)
+			}
+			return tx.Model(&identity).Update("last_login_at", time.Now()).Error
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		if email == "" {
+			return apperrors.Unauthorized("SSO login failed", "The identity provider did not send an email address")
+		}
+		err = tx.Where("email = ?", models.NormalizeEmail(email)).First(&user).Error
+		switch {
+		case err == nil:
+			if !trustedDomain(email, opts.TrustedDomains) {
+				logger.WithFields(map[string]interface{}{
+					"user_id": user.ID,
+					"idp":     provider,
+				}).Warn("SSO identity not linked to an account outside the trusted domains")
+				return errSSONotLinked
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if !opts.JITProvisioning {
+				return errSSONotProvisioned
+			}
+			if err := provisionSAMLUser(c, tx, &user, email, assertion.Attribute(opts.UsernameAttribute)); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return tx.Create(&models.ExternalIdentity{
+			UserID:      user.ID,
+			Provider:    provider,
+			Subject:     assertion.NameID,
+			LastLoginAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, repository.TranslateError(err, "SSO login failed")
+	}
+
+	groups, sent := assertion.Attributes[opts.GroupsAttribute]
+	if opts.GroupsAttribute != "" && len(opts.AdminGroups) > 0 && sent {
+		role := models.RoleUser
+		for _, group := range groups {
+			for _, admin := range opts.AdminGroups {
+				if group == admin {
+					role = models.RoleAdmin
+				}
+			}
+		}
+		if user.Role != role {
+			if err := db.Model(&user).Update("role", role).Error; err != nil {
+				return nil, repository.TranslateError(err, "SSO login failed")
+			}
+		}
+	}
+	return &user, nil
+}
+
+// trustedDomain reports whether the domain of email is one of domains.
+func trustedDomain(email string, domains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, trusted := range domains {
+		if strings.EqualFold(strings.TrimSpace(trusted), domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// provisionSAMLUser creates the user of a first SSO login. The password is random and never
+// revealed, so the account can only sign in through SSO until the user sets one.
+func provisionSAMLUser(c *gin.Context, tx *gorm.DB, user *models.User, email, username string) error {
+	if err := validators.ValidateEmail(email); err != nil {
+		return apperrors.Unauthorized("SSO login failed", "The identity provider sent an invalid email address")
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return err
+	}
+	hashed, err := auth.HashPassword(c.Request.Context(), secret)
+	if err != nil {
+		return err
+	}
+
+	base := ssoUsername(username, email)
+	for attempt := 0; attempt < 5; attempt++ {
+		candidate := base
+		if attempt > 0 {
+			suffix, err := randomHex(2)
+			if err != nil {
+				return err
+			}
+			candidate = fmt.Sprintf("%.25s-%s", base, suffix)
+		}
+		var taken int64
+		if err := tx.Model(&models.User{}).Where("username_normalized = ?", models.NormalizeUsername(candidate)).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			continue
+		}
+
+		*user = models.User{Username: candidate, Email: email, Password: hashed, Role: models.RoleUser}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		logger.WithFields(map[string]interface{}{
+			"user_id":  user.ID,
+			"username": user.Username,
+		}).Info("User provisioned from SAML login")
+		return nil
+	}
+	return apperrors.Conflict("SSO login failed", "Could not find a free username")
+}
+
+// ssoUsername derives a valid username from the IdP username, or else from the email.
+func ssoUsername(username, email string) string {
+	if validators.ValidateUsername(username) == nil {
+		return validators.Normalize(username)
+	}
+	local, _, _ := strings.Cut(email, "@")
+	var b strings.Builder
+	for _, r := range local {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		case r == '.' || r == '+':
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	if len(name) > 25 {
+		name = name[:25]
+	}
+	for len(name) < 3 {
+		name += "_"
+	}
+	return name
+}
+
+// setSAMLRequestCookie stores the AuthnRequest ID; maxAge -1 clears it. The IdP posts to the
+// ACS from another site, so over HTTPS the cookie must be SameSite=None to come back.
+func setSAMLRequestCookie(c *gin.Context, sp *saml.ServiceProvider, value string, maxAge int) {
+	secure := strings.HasPrefix(sp.ACSURL, "https://")
+	sameSite := http.SameSiteLaxMode
+	if secure {
+		sameSite = http.SameSiteNoneMode
+	}
+	path := "/"
+	if u, err := url.Parse(sp.ACSURL); err == nil {
+		path = u.Path
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     samlRequestCookie,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: sameSite,
+	})
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/saml"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+const testIdP = "https://idp.example.com"
+
+func TestResolveSAMLUser(t *testing.T) {
+	db := testutil.NewDB(t)
+	existing := testutil.CreateUser(t, db, testutil.WithEmail("jane@example.com"))
+	outsider := testutil.CreateUser(t, db, testutil.WithEmail("admin@other.com"), testutil.WithRole(models.RoleAdmin))
+	opts := SAMLOptions{
+		JITProvisioning:   true,
+		TrustedDomains:    []string{"example.com"},
+		EmailAttribute:    "email",
+		UsernameAttribute: "username",
+		GroupsAttribute:   "groups",
+		AdminGroups:       []string{"admins"},
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/acs", nil)
+
+	// An existing account is linked by email, and the role follows the groups
+	linked, err := resolveSAMLUser(c, db, testIdP, &saml.Assertion{
+		NameID:     "00u1",
+		Attributes: map[string][]string{"email": {"Jane@Example.com"}, "groups": {"staff", "admins"}},
+	}, opts)
+	if err != nil || linked.ID != existing.ID || linked.Role != models.RoleAdmin {
+		t.Fatalf("resolveSAMLUser() = %+v, %v; want user %d as admin", linked, err, existing.ID)
+	}
+	// Later logins use the link even when the email changes, and keep the role when the
+	// assertion carries no groups
+	again, err := resolveSAMLUser(c, db, testIdP, &saml.Assertion{
+		NameID:     "00u1",
+		Attributes: map[string][]string{"email": {"jane.doe@example.com"}},
+	}, opts)
+	if err != nil || again.ID != existing.ID || again.Role != models.RoleAdmin {
+		t.Fatalf("resolveSAMLUser() = %+v, %v; want user %d still admin", again, err, existing.ID)
+	}
+	// Without an admin group list the local role is kept
+	local, err := resolveSAMLUser(c, db, testIdP, &saml.Assertion{
+		NameID:     "00u1",
+		Attributes: map[string][]string{"groups": {"staff"}},
+	}, SAMLOptions{GroupsAttribute: "groups"})
+	if err != nil || local.Role != models.RoleAdmin {
+		t.Fatalf("resolveSAMLUser() = %+v, %v; want the role unchanged", local, err)
+	}
+	demoted, err := resolveSAMLUser(c, db, testIdP, &saml.Assertion{
+		NameID:     "00u1",
+		Attributes: map[string][]string{"email": {"jane.doe@example.com"}, "groups": {"staff"}},
+	}, opts)
+	if err != nil || demoted.Role != models.RoleUser {
+		t.Fatalf("resolveSAMLUser() = %+v, %v; want user %d demoted", demoted, err, existing.ID)
+	}
+
+	// Accounts outside the trusted domains are never linked by email
+	if _, err := resolveSAMLUser(c, db, testIdP, &saml.Assertion{
+		NameID:     "00u2",
+		Attributes: map[string][]string{"email": {"admin@other.com"}},
+	}, opts); err != errSSONotLinked {
+		t.Fatalf("resolveSAMLUser() error = %v, want not linked", err)
+	}
+
+	var kept models.User
+	db.First(&kept, outsider.ID)
+	if kept.Role != models.RoleAdmin {
+		t.Errorf("outsider role = %q, want admin", kept.Role)
+	}
+
+	// Unknown users are provisioned with a username from the email, avoiding collisions
+	testutil.CreateUser(t, db, testutil.WithUsername("john_smith"))
+	created, err := resolveSAMLUser(c, db, testIdP, &saml.Assertion{NameID: "john.smith@example.com"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Email != "john.smith@example.com" || !strings.HasPrefix(created.Username, "john_smith-") {
+		t.Errorf("provisioned user = %+v", created)
+	}
+	var identities int64
+	db.Model(&models.ExternalIdentity{}).Count(&identities)
+	if identities != 2 {
+		t.Errorf("external identities = %d, want 2", identities)
+	}
+
+	// Without JIT provisioning unknown users are rejected
+	opts.JITProvisioning = false
+	if _, err := resolveSAMLUser(c, db, testIdP, &saml.Assertion{NameID: "new@example.com"}, opts); err != errSSONotProvisioned {
+		t.Errorf("resolveSAMLUser() error = %v, want not provisioned", err)
+	}
+}
+
+func TestSSOUsername(t *testing.T) {
+	tests := []struct{ username, email, want string }{
+		{"jdoe", "x@example.com", "jdoe"},
+		{"", "Jane.Doe+sso@example.com", "Jane_Doe_sso"},
+		{"a b", "al@example.com", "al_"},
+		{"", "a-very-long-local-part-for-a-username@example.com", "a-very-long-local-part-fo"},
+	}
+	for _, tt := range tests {
+		if got := ssoUsername(tt.username, tt.email); got != tt.want {
+			t.Errorf("ssoUsername(%q, %q) = %q, want %q", tt.username, tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestSAMLEndpoints(t *testing.T) {
+	db := testutil.NewDB(t)
+	sp, err := saml.NewServiceProvider("https://api.example.com/api/auth/saml", &saml.IdPMetadata{
+		EntityID: testIdP,
+		SSOURL:   testIdP + "/sso",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler(), middlewares.ValidateContentType("/saml/acs"))
+	r.GET("/saml/metadata", SAMLMetadata(sp))
+	r.GET("/saml/login", SAMLLogin(sp))
+	r.POST("/saml/acs", SAMLACS(db, sp, SAMLOptions{JITProvisioning: true}))
+
+	w := testutil.Get("/saml/metadata").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), sp.ACSURL) {
+		t.Errorf("metadata does not name the ACS: %s", w.Body.String())
+	}
+
+	w = testutil.Get("/saml/login").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusFound)
+	if !strings.HasPrefix(w.Header().Get("Location"), testIdP+"/sso?SAMLRequest=") {
+		t.Errorf("Location = %q", w.Header().Get("Location"))
+	}
+	cookie := w.Result().Cookies()[0]
+	if cookie.Name != samlRequestCookie || !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteNoneMode {
+		t.Errorf("request cookie = %+v", cookie)
+	}
+
+	// A forged response is rejected without detail and the request cookie is cleared
+	w = testutil.Post("/saml/acs").
+		WithHeader("Content-Type", "application/x-www-form-urlencoded").
+		WithHeader("Cookie", cookie.Name+"="+cookie.Value).
+		Do(t, r)
+	testutil.AssertError(t, w, http.StatusUnauthorized, "UNAUTHORIZED")
+	if cleared := w.Result().Cookies(); len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+		t.Errorf("cookies = %+v, want the request cookie cleared", cleared)
+	}
+}
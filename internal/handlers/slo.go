@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/response"
+	"github.com/yeferson59/gin-template/pkg/slo"
+)
+
+// SLOSummary returns the latency percentiles and remaining error budgets of every route
+// served since the process started.
+func SLOSummary(tracker *slo.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		response.SuccessResponse(c, http.StatusOK, "SLO summary retrieved successfully", tracker.Report())
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/slo"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestSLOSummary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracker, err := slo.NewTracker(slo.Objective{Availability: 0.99, Latency: time.Second, LatencyTarget: 0.9}, 10)
+	if err != nil {
+		t.Fatalf("NewTracker() error = %v", err)
+	}
+	tracker.Observe("GET /api/users", http.StatusOK, 10*time.Millisecond)
+
+	r := gin.New()
+	r.GET("/api/admin/slo", SLOSummary(tracker))
+	w := testutil.Get("/api/admin/slo").Do(t, r)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var report slo.Report
+	testutil.DecodeData(t, w, &report)
+	if len(report.Routes) != 1 || report.Routes[0].Route != "GET /api/users" || !report.Routes[0].Met {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
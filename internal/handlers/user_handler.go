@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/validators"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/byid"
+	"github.com/yeferson59/gin-template/pkg/patch"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// PublicUserResponse is the user data visible to any authenticated user.
+type PublicUserResponse struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+// UsersByIDResponse holds the requested users keyed by ID and the IDs that do not exist.
+type UsersByIDResponse struct {
+	Users   map[uint]PublicUserResponse `json:"users"`
+	Missing []uint                      `json:"missing"`
+}
+
+// GetUsersByIDs returns the public profile of up to byid.MaxIDs users in one request, such as
+// the authors shown next to a list of comments: GET /api/users?ids=1,2,3.
+func GetUsersByIDs(repo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ids, err := byid.FromContext(c)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid ids", err.Error()))
+			return
+		}
+
+		users, err := repo.FindByIDs(c.Request.Context(), ids)
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve users"))
+			return
+		}
+
+		found := byid.Key(users, func(u models.User) uint { return u.ID })
+		profiles := make(map[uint]PublicUserResponse, len(found))
+		for id, u := range found {
+			profiles[id] = PublicUserResponse{ID: u.ID, Username: u.Username}
+		}
+		response.SuccessResponse(c, http.StatusOK, "Users retrieved successfully", UsersByIDResponse{
+			Users:   profiles,
+			Missing: byid.Missing(ids, found),
+		})
+	}
+}
+
+// ProfileOptions configures profile updates.
+type ProfileOptions struct {
+	Usernames UsernamePolicy
+	// ConfirmEmailChanges rejects email changes, which go through RequestEmailChange instead.
+	ConfirmEmailChanges bool
+}
+
+// errProfileTaken is returned when the username or email is taken, or the username is reserved.
+var errProfileTaken = apperrors.Conflict("Profile not updated", "Username or email already exists")
+
+// UpdateProfile applies a JSON Merge Patch or JSON Patch to the current user's username and
+// email, validates the result, and saves it. Username changes follow opts.Usernames and are
+// recorded in the user's username history.
+func UpdateProfile(db *gorm.DB, opts ProfileOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := requestctx.CurrentUser(c)
+		if !ok {
+			_ = c.Error(apperrors.Unauthorized("Authorization required", "No authenticated user"))
+			return
+		}
+
+		profile := validators.ProfileUpdate{Username: user.Username, Email: user.Email}
+		if err := patch.Bind(c, &profile); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		profile.Normalize()
+		if err := validators.ValidateProfileUpdate(&profile); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		// Emails are stored lowercased, as every lookup by email expects
+		profile.Email = models.NormalizeEmail(profile.Email)
+
+		if opts.ConfirmEmailChanges && profile.Email != models.NormalizeEmail(user.Email) {
+			_ = c.Error(apperrors.BadRequest("Profile not updated",
+				"Email changes must be confirmed; use PUT /api/users/me/email"))
+			return
+		}
+
+		policy := opts.Usernames
+		err := database.WithTransaction(c.Request.Context(), db, func(tx *gorm.DB) error {
+			renamed := usernameChanged(user.Username, profile.Username)
+			if renamed {
+				next, err := policy.cooldown(tx, user.ID)
+				if err != nil {
+					return err
+				}
+				if !next.IsZero() {
+					return apperrors.Conflict("Username recently changed",
+						"You can change your username again after "+next.UTC().Format(time.RFC3339))
+				}
+				reserved, err := policy.reserved(tx, profile.Username, user.ID)
+				if err != nil {
+					return err
+				}
+				if reserved {
+					return errProfileTaken
+				}
+			}
+
+			err := tx.Model(&models.User{ID: user.ID}).
+				Updates(map[string]interface{}{"username": profile.Username, "email": profile.Email}).Error
+			if err != nil || !renamed {
+				return err
+			}
+			return recordUsernameChange(tx, user.ID, user.Username, profile.Username, user.ID)
+		})
+		if err != nil {
+			if database.IsDuplicateKeyError(err) {
+				_ = c.Error(errProfileTaken.Wrap(err))
+				return
+			}
+			_ = c.Error(repository.TranslateError(err, "Could not update profile"))
+			return
+		}
+
+		response.SuccessResponse(c, http.StatusOK, "Profile updated successfully", &UserSafeResponse{
+			ID:       user.ID,
+			Username: profile.Username,
+			Email:    profile.Email,
+		})
+	}
+}
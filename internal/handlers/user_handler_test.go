@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/mocks"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/patch"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func setupUsersByIDRouter(repo repository.UserRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler())
+	r.GET("/users", GetUsersByIDs(repo))
+	return r
+}
+
+func TestGetUsersByIDsKeysResultsByID(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedUsers(t, db, 3)
+	router := setupUsersByIDRouter(repository.NewUserRepository(db))
+
+	w := testutil.Get("/users?ids=3,1,99,3").Do(t, router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var resp UsersByIDResponse
+	testutil.DecodeData(t, w, &resp)
+	if len(resp.Users) != 2 || resp.Users[1].Username != "user01" || resp.Users[3].Username != "user03" {
+		t.Fatalf("unexpected users: %+v", resp.Users)
+	}
+	if !reflect.DeepEqual(resp.Missing, []uint{99}) {
+		t.Fatalf("missing = %v, want [99]", resp.Missing)
+	}
+}
+
+func TestGetUsersByIDsUsesOneQuery(t *testing.T) {
+	repo := &mocks.UserRepository{
+		FindByIDsFunc: func(_ context.Context, ids []uint) ([]models.User, error) {
+			return []models.User{{ID: 2, Username: "bob", Email: "bob@example.com"}}, nil
+		},
+	}
+	router := setupUsersByIDRouter(repo)
+
+	w := testutil.Get("/users?ids=1,2").Do(t, router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	if calls := repo.FindByIDsCalls(); len(calls) != 1 || !reflect.DeepEqual(calls[0], []uint{1, 2}) {
+		t.Fatalf("unexpected FindByIDs calls: %v", calls)
+	}
+	// Only public fields are returned
+	if body := w.Body.String(); strings.Contains(body, "bob@example.com") {
+		t.Fatalf("email leaked in %s", body)
+	}
+}
+
+func TestGetUsersByIDsRejectsInvalidLists(t *testing.T) {
+	router := setupUsersByIDRouter(&mocks.UserRepository{})
+
+	for _, target := range []string{"/users", "/users?ids=1,abc", "/users?ids=0"} {
+		w := testutil.Get(target).Do(t, router)
+		testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+	}
+}
+
+// setupProfileRouter authenticates every request as user, reloaded like AuthRequired does.
+func setupProfileRouter(db *gorm.DB, user *models.User, policy UsernamePolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler(), middlewares.ValidateContentType())
+	r.PATCH("/users/me",
+		func(c *gin.Context) {
+			var current models.User
+			if err := db.First(&current, user.ID).Error; err != nil {
+				_ = c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			requestctx.SetUser(c, &current, time.Time{})
+		},
+		UpdateProfile(db, ProfileOptions{Usernames: policy}),
+	)
+	return r
+}
+
+func TestUpdateProfileWithMergePatch(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := testutil.CreateUser(t, db, testutil.WithUsername("alice"), testutil.WithEmail("alice@example.com"))
+	router := setupProfileRouter(db, user, UsernamePolicy{})
+
+	w := testutil.Patch("/users/me").
+		WithJSON(`{"username":"Alice_B"}`).
+		WithHeader("Content-Type", patch.MergePatchType).
+		Do(t, router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var saved models.User
+	if err := db.First(&saved, user.ID).Error; err != nil {
+		t.Fatalf("failed to reload user: %v", err)
+	}
+	if saved.Username != "Alice_B" || saved.UsernameNormalized != "alice_b" || saved.Email != "alice@example.com" {
+		t.Fatalf("unexpected saved profile: %+v", saved)
+	}
+}
+
+func TestUpdateProfileWithJSONPatch(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := testutil.CreateUser(t, db, testutil.WithUsername("alice"), testutil.WithEmail("alice@example.com"))
+	router := setupProfileRouter(db, user, UsernamePolicy{})
+
+	w := testutil.Patch("/users/me").
+		WithJSON(`[{"op":"test","path":"/username","value":"alice"},{"op":"replace","path":"/email","value":"new@example.com"}]`).
+		WithHeader("Content-Type", patch.JSONPatchType).
+		Do(t, router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var resp UserSafeResponse
+	testutil.DecodeData(t, w, &resp)
+	if resp.Email != "new@example.com" || resp.Username != "alice" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	// The test operation no longer matches
+	w = testutil.Patch("/users/me").
+		WithJSON(`[{"op":"test","path":"/email","value":"alice@example.com"}]`).
+		WithHeader("Content-Type", patch.JSONPatchType).
+		Do(t, router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+}
+
+func TestUpdateProfileNormalizesEmail(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := testutil.CreateUser(t, db, testutil.WithUsername("alice"), testutil.WithEmail("alice@example.com"))
+	router := setupProfileRouter(db, user, UsernamePolicy{})
+
+	w := testutil.Patch("/users/me").
+		WithJSON(`{"email":" New@Bar.COM "}`).
+		WithHeader("Content-Type", patch.MergePatchType).
+		Do(t, router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var resp UserSafeResponse
+	testutil.DecodeData(t, w, &resp)
+	if resp.Email != "new@bar.com" {
+		t.Errorf("response email = %q, want new@bar.com", resp.Email)
+	}
+	var saved models.User
+	if err := db.Where("email = ?", models.NormalizeEmail("New@Bar.COM")).First(&saved).Error; err != nil {
+		t.Fatalf("user not found by its normalized email: %v", err)
+	}
+	if saved.ID != user.ID {
+		t.Errorf("found user %d, want %d", saved.ID, user.ID)
+	}
+}
+
+func TestUpdateProfileValidatesResult(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := testutil.CreateUser(t, db)
+	testutil.CreateUser(t, db, testutil.WithUsername("taken"), testutil.WithEmail("taken@example.com"))
+	router := setupProfileRouter(db, user, UsernamePolicy{})
+
+	// Removing a required field fails validation of the patched profile
+	w := testutil.Patch("/users/me").
+		WithJSON(`[{"op":"remove","path":"/email"}]`).
+		WithHeader("Content-Type", patch.JSONPatchType).
+		Do(t, router)
+	testutil.AssertFieldError(t, w, "email", "required")
+
+	w = testutil.Patch("/users/me").WithJSON(`{"role":"admin"}`).Do(t, router)
+	testutil.AssertError(t, w, http.StatusBadRequest, "BAD_REQUEST")
+
+	w = testutil.Patch("/users/me").WithJSON(`{"username":"taken"}`).Do(t, router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// UsernamePolicy limits username changes (see config.AccountConfig). Zero durations disable
+// each limit.
+type UsernamePolicy struct {
+	// ChangeCooldown is the minimum time between two username changes of a user.
+	ChangeCooldown time.Duration
+	// Reservation is how long a former username stays reserved for its previous owner.
+	Reservation time.Duration
+}
+
+// reserved reports whether username was given up by another user less than the reservation
+// period ago. The previous owner can take it back.
+func (p UsernamePolicy) reserved(tx *gorm.DB, username string, userID uint) (bool, error) {
+	if p.Reservation <= 0 {
+		return false, nil
+	}
+	var changes int64
+	err := tx.Model(&models.UsernameChange{}).
+		Where("old_username_normalized = ? AND user_id <> ? AND created_at > ?",
+			models.NormalizeUsername(username), userID, auth.Now().Add(-p.Reservation)).
+		Count(&changes).Error
+	return changes > 0, err
+}
+
+// cooldown returns when the user may change their username again, or the zero time when they
+// may do so now.
+func (p UsernamePolicy) cooldown(tx *gorm.DB, userID uint) (time.Time, error) {
+	if p.ChangeCooldown <= 0 {
+		return time.Time{}, nil
+	}
+	var last models.UsernameChange
+	err := tx.Where("user_id = ? AND changed_by_id = ?", userID, userID).
+		Order("created_at DESC, id DESC").
+		First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if next := last.CreatedAt.Add(p.ChangeCooldown); next.After(auth.Now()) {
+		return next, nil
+	}
+	return time.Time{}, nil
+}
+
+// usernameChanged reports whether a username change is more than a change of case, which is
+// neither limited nor recorded.
+func usernameChanged(old, username string) bool {
+	return models.NormalizeUsername(old) != models.NormalizeUsername(username)
+}
+
+// recordUsernameChange adds a username change to the history of userID.
+func recordUsernameChange(tx *gorm.DB, userID uint, old, username string, changedBy uint) error {
+	return tx.Create(&models.UsernameChange{
+		UserID:      userID,
+		OldUsername: old,
+		NewUsername: username,
+		ChangedByID: changedBy,
+	}).Error
+}
+
+// UsernameHistory returns the username changes of a user, newest first, for administrators.
+func UsernameHistory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid user ID", "The user ID must be a positive integer"))
+			return
+		}
+		var user models.User
+		if err := db.WithContext(c.Request.Context()).Unscoped().First(&user, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				_ = c.Error(apperrors.NotFound("User not found", "No user exists with the given id").Wrap(err))
+				return
+			}
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve username history"))
+			return
+		}
+
+		changes := []models.UsernameChange{}
+		err = db.WithContext(c.Request.Context()).
+			Where("user_id = ?", user.ID).
+			Order("created_at DESC, id DESC").
+			Find(&changes).Error
+		if err != nil {
+			_ = c.Error(repository.TranslateError(err, "Could not retrieve username history"))
+			return
+		}
+		response.SuccessResponse(c, http.StatusOK, "Username history retrieved successfully", changes)
+	}
+}
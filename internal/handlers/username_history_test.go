@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func renameTo(t *testing.T, router http.Handler, username string) int {
+	t.Helper()
+	return testutil.Patch("/users/me").WithJSON(map[string]string{"username": username}).Do(t, router).Code
+}
+
+func TestUsernameChanges(t *testing.T) {
+	policy := UsernamePolicy{ChangeCooldown: time.Hour, Reservation: 24 * time.Hour}
+	db := testutil.NewDB(t)
+	alice := testutil.CreateUser(t, db, testutil.WithUsername("alice"))
+	mallory := testutil.CreateUser(t, db, testutil.WithUsername("mallory"))
+	router := setupProfileRouter(db, alice, policy)
+	malloryRouter := setupProfileRouter(db, mallory, policy)
+
+	if code := renameTo(t, router, "alice_new"); code != http.StatusOK {
+		t.Fatalf("first rename = %d, want 200", code)
+	}
+	// Case changes are free, other changes wait for the cooldown
+	if code := renameTo(t, router, "Alice_New"); code != http.StatusOK {
+		t.Fatalf("case change = %d, want 200", code)
+	}
+	w := testutil.Patch("/users/me").WithJSON(map[string]string{"username": "alice_again"}).Do(t, router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+
+	// The old username is reserved for alice
+	if code := renameTo(t, malloryRouter, "ALICE"); code != http.StatusConflict {
+		t.Fatalf("taking a reserved username = %d, want 409", code)
+	}
+	db.Model(&models.UsernameChange{}).Where("user_id = ?", alice.ID).Update("created_at", time.Now().Add(-2*time.Hour))
+	if code := renameTo(t, router, "alice"); code != http.StatusOK {
+		t.Fatalf("taking back the old username = %d, want 200", code)
+	}
+
+	var changes []models.UsernameChange
+	db.Where("user_id = ?", alice.ID).Order("id").Find(&changes)
+	if len(changes) != 2 || changes[0].OldUsername != "alice" || changes[0].NewUsername != "alice_new" ||
+		changes[1].OldUsername != "Alice_New" || changes[1].ChangedByID != alice.ID {
+		t.Errorf("username history = %+v", changes)
+	}
+}
+
+func TestRegister_ReservedUsername(t *testing.T) {
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		a.Router.POST("/register", Register(a.DB, RegistrationOptions{Usernames: UsernamePolicy{Reservation: time.Hour}}))
+	})
+	user := testutil.CreateUser(t, app.DB, testutil.WithUsername("renamed"))
+	app.DB.Create(&models.UsernameChange{UserID: user.ID, OldUsername: "Famous", NewUsername: "renamed", ChangedByID: user.ID})
+
+	w := testutil.Post("/register").WithJSON(map[string]string{
+		"username": "famous", "email": "famous@example.com", "password": "Str0ng!Secret",
+	}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusConflict, "CONFLICT")
+}
+
+func TestUsernameHistory(t *testing.T) {
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		a.Router.GET("/admin/users/:id/username-history", UsernameHistory(a.DB))
+	})
+	user := testutil.CreateUser(t, app.DB)
+	app.DB.Create(&models.UsernameChange{UserID: user.ID, OldUsername: "first", NewUsername: "second", ChangedByID: user.ID})
+	app.DB.Create(&models.UsernameChange{UserID: user.ID, OldUsername: "second", NewUsername: user.Username, ChangedByID: 99})
+
+	var changes []models.UsernameChange
+	testutil.DecodeData(t, testutil.Get(fmt.Sprintf("/admin/users/%d/username-history", user.ID)).Do(t, app.Router), &changes)
+	if len(changes) != 2 || changes[0].OldUsername != "second" || changes[0].ChangedByID != 99 {
+		t.Errorf("username history = %+v", changes)
+	}
+
+	w := testutil.Get("/admin/users/12345/username-history").Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusNotFound, "NOT_FOUND")
+}
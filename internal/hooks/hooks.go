@@ -0,0 +1,418 @@
+// Package hooks runs lifecycle hooks for registered models and turns their creates, updates,
+// and deletes into domain events, published on an events.Bus and recorded in the audit log,
+// so that models do not each hand-write GORM hooks for it.
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/events"
+	"github.com/yeferson59/gin-template/pkg/requestid"
+)
+
+// Stage is a point of a model's lifecycle.
+type Stage string
+
+// Lifecycle stages.
+const (
+	BeforeCreate Stage = "before_create"
+	AfterCreate  Stage = "after_create"
+	BeforeUpdate Stage = "before_update"
+	AfterUpdate  Stage = "after_update"
+	BeforeDelete Stage = "before_delete"
+	AfterDelete  Stage = "after_delete"
+)
+
+// Actions of the events, appended to the model's event prefix.
+const (
+	Created = "created"
+	Updated = "updated"
+	Deleted = "deleted"
+)
+
+// Hook runs at a stage of a write to a registered model, inside its transaction. tx is a new
+// session on that transaction, and model the record being written, or nil for writes by
+// condition such as db.Where(...).Delete(&models.User{}). An error from a Before hook aborts
+// the write, and from an After hook rolls it back.
+type Hook func(ctx context.Context, tx *gorm.DB, model interface{}) error
+
+// Options configure how a model's writes become events.
+type Options struct {
+	// Event is the prefix of the event names, such as "user" for "user.created". Defaults to
+	// the table name.
+	Event string
+	// Audit records every event in the audit log, in the transaction of the write.
+	Audit bool
+	// Omit lists columns left out of the event changes, in addition to the fields tagged
+	// json:"-", such as password hashes, which are always left out.
+	Omit []string
+}
+
+// registryName is the name the registry is registered under as a GORM plugin.
+const registryName = "hooks:registry"
+
+// eventsKey holds the events of a write until its implicit transaction commits.
+const eventsKey = "hooks:events"
+
+// Registry holds the registered models and their hooks. The events of a write are published
+// once it has committed: after the implicit transaction of a single write, or, for writes in
+// database.WithTransaction, after the transaction; writes in other transactions publish them
+// right away.
+type Registry struct {
+	bus *events.Bus
+	now func() time.Time
+
+	mu     sync.RWMutex
+	models map[string]*registration
+}
+
+type registration struct {
+	opts  Options
+	hooks map[Stage][]Hook
+}
+
+// NewRegistry creates a registry publishing events on bus; nil only records them in the audit
+// log.
+func NewRegistry(bus *events.Bus) *Registry {
+	return &Registry{bus: bus, now: time.Now, models: make(map[string]*registration)}
+}
+
+// Register makes the writes to model's table publish events. Registering a model again replaces
+// its options and keeps its hooks.
+func (r *Registry) Register(model interface{}, opts Options) error {
+	table, err := tableName(model)
+	if err != nil {
+		return err
+	}
+	if opts.Event == "" {
+		opts.Event = table
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if reg, ok := r.models[table]; ok {
+		reg.opts = opts
+		return nil
+	}
+	r.models[table] = &registration{opts: opts, hooks: make(map[Stage][]Hook)}
+	return nil
+}
+
+// On adds a hook run at stage for model, which must be registered.
+func (r *Registry) On(model interface{}, stage Stage, h Hook) error {
+	table, err := tableName(model)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reg, ok := r.models[table]
+	if !ok {
+		return fmt.Errorf("hooks: %s is not registered", table)
+	}
+	reg.hooks[stage] = append(reg.hooks[stage], h)
+	return nil
+}
+
+// Use attaches r to db, so that the writes through db run the hooks and publish the events.
+func Use(db *gorm.DB, r *Registry) error {
+	return db.Use(r)
+}
+
+// Name implements gorm.Plugin.
+func (r *Registry) Name() string {
+	return registryName
+}
+
+// Initialize implements gorm.Plugin by registering the callbacks that run the hooks.
+func (r *Registry) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("hooks:before_create", r.before(BeforeCreate)); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("hooks:after_create", r.after(AfterCreate, Created)); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("hooks:before_update", r.before(BeforeUpdate)); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("hooks:after_update", r.after(AfterUpdate, Updated)); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("hooks:before_delete", r.before(BeforeDelete)); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("hooks:after_delete", r.after(AfterDelete, Deleted)); err != nil {
+		return err
+	}
+
+	// Events of single writes are published once their implicit transaction has committed
+	if err := cb.Create().After("gorm:commit_or_rollback_transaction").Register("hooks:publish_create", r.publish); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:commit_or_rollback_transaction").Register("hooks:publish_update", r.publish); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:commit_or_rollback_transaction").Register("hooks:publish_delete", r.publish)
+}
+
+// registration returns the registration of the table written by tx, or nil.
+func (r *Registry) registration(tx *gorm.DB) *registration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.models[tx.Statement.Table]
+}
+
+func (r *Registry) before(stage Stage) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		reg := r.registration(tx)
+		if reg == nil || tx.Error != nil {
+			return
+		}
+		r.run(tx, reg, stage)
+	}
+}
+
+func (r *Registry) after(stage Stage, action string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		reg := r.registration(tx)
+		if reg == nil || tx.Error != nil || tx.RowsAffected == 0 {
+			return
+		}
+		if !r.run(tx, reg, stage) {
+			return
+		}
+
+		evts := r.events(tx, reg, action)
+		if reg.opts.Audit {
+			if err := audit(tx, evts); err != nil {
+				_ = tx.AddError(fmt.Errorf("hooks: failed to write the audit log: %w", err))
+				return
+			}
+		}
+		if r.bus == nil {
+			return
+		}
+		if _, implicit := tx.InstanceGet("gorm:started_transaction"); implicit {
+			tx.InstanceSet(eventsKey, evts)
+			return
+		}
+		for _, e := range evts {
+			r.bus.PublishAfter(tx.Statement.Context, e)
+		}
+	}
+}
+
+// publish publishes the events of a write whose implicit transaction committed.
+func (r *Registry) publish(tx *gorm.DB) {
+	held, ok := tx.InstanceGet(eventsKey)
+	if !ok || tx.Error != nil {
+		return
+	}
+	for _, e := range held.([]events.Event) {
+		r.bus.PublishAfter(tx.Statement.Context, e)
+	}
+}
+
+// run runs the hooks of stage on each record written by tx, reporting whether they all passed.
+func (r *Registry) run(tx *gorm.DB, reg *registration, stage Stage) bool {
+	r.mu.RLock()
+	hooks := reg.hooks[stage]
+	r.mu.RUnlock()
+	if len(hooks) == 0 {
+		return true
+	}
+
+	recs := records(tx)
+	if len(recs) == 0 {
+		recs = []interface{}{nil}
+	}
+	session := tx.Session(&gorm.Session{NewDB: true})
+	for _, record := range recs {
+		for _, h := range hooks {
+			if err := h(tx.Statement.Context, session, record); err != nil {
+				_ = tx.AddError(err)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// events builds the events of a write: one per record, or one for writes by condition.
+func (r *Registry) events(tx *gorm.DB, reg *registration, action string) []events.Event {
+	ctx := tx.Statement.Context
+	base := events.Event{
+		Name:       reg.opts.Event + "." + action,
+		Entity:     tx.Statement.Table,
+		ActorID:    events.ActorFromContext(ctx),
+		RequestID:  requestid.FromContext(ctx),
+		OccurredAt: r.now().UTC(),
+	}
+	if action == Updated {
+		base.Changes = assignments(tx, reg.opts.Omit)
+	}
+
+	recs := records(tx)
+	if len(recs) == 0 {
+		return []events.Event{base}
+	}
+	evts := make([]events.Event, 0, len(recs))
+	for _, record := range recs {
+		e := base
+		rv := reflect.Indirect(reflect.ValueOf(record))
+		if field := tx.Statement.Schema.PrioritizedPrimaryField; field != nil {
+			if id, zero := field.ValueOf(ctx, rv); !zero {
+				e.EntityID = fmt.Sprint(id)
+			}
+		}
+		if action == Created {
+			e.Changes = fields(ctx, tx.Statement.Schema, rv, reg.opts.Omit)
+		}
+		evts = append(evts, e)
+	}
+	return evts
+}
+
+// records returns the records written by tx, skipping the zero-valued models used to name the
+// table of writes by condition.
+func records(tx *gorm.DB) []interface{} {
+	rv := tx.Statement.ReflectValue
+	if tx.Statement.Schema == nil || !rv.IsValid() {
+		return nil
+	}
+	var recs []interface{}
+	add := func(v reflect.Value) {
+		v = reflect.Indirect(v)
+		if v.Kind() != reflect.Struct || v.IsZero() || !v.CanAddr() {
+			return
+		}
+		recs = append(recs, v.Addr().Interface())
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			add(rv.Index(i))
+		}
+	case reflect.Struct:
+		add(rv)
+	}
+	return recs
+}
+
+// fields returns the columns of a created record.
+func fields(ctx context.Context, s *schema.Schema, rv reflect.Value, omit []string) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for _, field := range s.Fields {
+		if hidden(field, field.DBName, omit) {
+			continue
+		}
+		if value, zero := field.ValueOf(ctx, rv); !zero {
+			changes[field.DBName] = value
+		}
+	}
+	return changes
+}
+
+// assignments returns the columns set by an update: the keys of a map passed to Update or
+// Updates, or the non-zero (or selected) fields of a struct.
+func assignments(tx *gorm.DB, omit []string) map[string]interface{} {
+	stmt := tx.Statement
+	changes := make(map[string]interface{})
+	set := func(name string, value interface{}) {
+		field := stmt.Schema.LookUpField(name)
+		if field != nil {
+			name = field.DBName
+		}
+		if hidden(field, name, omit) {
+			return
+		}
+		if expr, ok := value.(clause.Expr); ok {
+			value = expr.SQL
+		}
+		changes[name] = value
+	}
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		for name, value := range dest {
+			set(name, value)
+		}
+	default:
+		rv := reflect.Indirect(reflect.ValueOf(dest))
+		if rv.Kind() != reflect.Struct {
+			return nil
+		}
+		all := slices.Contains(stmt.Selects, "*")
+		for _, field := range stmt.Schema.Fields {
+			if field.PrimaryKey || hidden(field, field.DBName, omit) {
+				continue
+			}
+			value, zero := field.ValueOf(stmt.Context, rv)
+			selected := all || slices.Contains(stmt.Selects, field.DBName) || slices.Contains(stmt.Selects, field.Name)
+			if zero && !selected {
+				continue
+			}
+			set(field.DBName, value)
+		}
+	}
+	return changes
+}
+
+// hidden reports whether the column name, of field if known, is left out of event changes.
+func hidden(field *schema.Field, name string, omit []string) bool {
+	if name == "" || slices.Contains(omit, name) {
+		return true
+	}
+	return field != nil && field.Tag.Get("json") == "-"
+}
+
+// audit records evts in the audit log through the transaction of tx.
+func audit(tx *gorm.DB, evts []events.Event) error {
+	logs := make([]models.AuditLog, len(evts))
+	for i, e := range evts {
+		logs[i] = models.AuditLog{
+			Event:     e.Name,
+			Entity:    e.Entity,
+			EntityID:  e.EntityID,
+			RequestID: e.RequestID,
+			CreatedAt: e.OccurredAt,
+		}
+		if e.ActorID != 0 {
+			actor := e.ActorID
+			logs[i].ActorID = &actor
+		}
+		if len(e.Changes) > 0 {
+			changes, err := json.Marshal(e.Changes)
+			if err != nil {
+				return err
+			}
+			logs[i].Changes = string(changes)
+		}
+	}
+	return tx.Session(&gorm.Session{NewDB: true}).Create(&logs).Error
+}
+
+func tableName(model interface{}) (string, error) {
+	if model == nil {
+		return "", errors.New("hooks: nil model")
+	}
+	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return "", fmt.Errorf("hooks: %w", err)
+	}
+	return s.Table, nil
+}
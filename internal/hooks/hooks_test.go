@@ -0,0 +1,225 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/events"
+	"github.com/yeferson59/gin-template/pkg/requestid"
+)
+
+// setup opens a database with the users of a registry that audits them, and returns the events
+// published so far.
+func setup(t *testing.T) (*gorm.DB, *Registry, *[]events.Event) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(&models.User{}, &models.AuditLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe("*", func(_ context.Context, e events.Event) {
+		published = append(published, e)
+	})
+	registry := NewRegistry(bus)
+	if err := registry.Register(&models.User{}, Options{Event: "user", Audit: true, Omit: []string{"email"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Use(db, registry); err != nil {
+		t.Fatal(err)
+	}
+	return db, registry, &published
+}
+
+func auditLogs(t *testing.T, db *gorm.DB) []models.AuditLog {
+	t.Helper()
+	var logs []models.AuditLog
+	if err := db.Order("id").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	return logs
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	db, _, published := setup(t)
+	ctx := events.WithActor(requestid.WithContext(context.Background(), "req-1"), 7)
+
+	user := models.User{Username: "alice", Email: "alice@example.com", Password: "hash"}
+	if err := db.WithContext(ctx).Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(ctx).Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(ctx).Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*published) != 3 {
+		t.Fatalf("expected 3 events, got %+v", *published)
+	}
+	created, updated, deleted := (*published)[0], (*published)[1], (*published)[2]
+	if created.Name != "user.created" || updated.Name != "user.updated" || deleted.Name != "user.deleted" {
+		t.Fatalf("unexpected event names %q, %q, %q", created.Name, updated.Name, deleted.Name)
+	}
+	if created.Entity != "users" || created.EntityID != "1" || created.ActorID != 7 || created.RequestID != "req-1" {
+		t.Errorf("unexpected created event %+v", created)
+	}
+	if created.Changes["username"] != "alice" {
+		t.Errorf("expected the created fields in the changes, got %v", created.Changes)
+	}
+	// Fields tagged json:"-" and omitted columns are left out
+	if _, ok := created.Changes["password"]; ok {
+		t.Error("expected the password to be left out of the changes")
+	}
+	if _, ok := created.Changes["email"]; ok {
+		t.Error("expected the omitted email to be left out of the changes")
+	}
+	if len(updated.Changes) != 1 || updated.Changes["role"] != models.RoleAdmin {
+		t.Errorf("expected only the role in the update changes, got %v", updated.Changes)
+	}
+
+	logs := auditLogs(t, db)
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 audit logs, got %d", len(logs))
+	}
+	if logs[0].Event != "user.created" || logs[0].EntityID != "1" || logs[0].ActorID == nil || *logs[0].ActorID != 7 {
+		t.Errorf("unexpected audit log %+v", logs[0])
+	}
+	var changes map[string]interface{}
+	if err := json.Unmarshal([]byte(logs[1].Changes), &changes); err != nil || changes["role"] != models.RoleAdmin {
+		t.Errorf("unexpected audit log changes %q (%v)", logs[1].Changes, err)
+	}
+}
+
+func TestWritesByCondition(t *testing.T) {
+	db, _, published := setup(t)
+	for _, name := range []string{"alice", "bob"} {
+		if err := db.Create(&models.User{Username: name, Email: name + "@example.com", Password: "hash"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.Where("username = ?", "bob").Delete(&models.User{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	last := (*published)[len(*published)-1]
+	if last.Name != "user.deleted" || last.EntityID != "" {
+		t.Fatalf("expected a deletion event without an entity ID, got %+v", last)
+	}
+
+	// Writes matching no rows publish nothing
+	before := len(*published)
+	if err := db.Where("username = ?", "nobody").Delete(&models.User{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(*published) != before {
+		t.Fatal("expected no event for a write matching no rows")
+	}
+}
+
+func TestTransactionsPublishOnCommit(t *testing.T) {
+	db, _, published := setup(t)
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	err := database.WithTransaction(ctx, db, func(tx *gorm.DB) error {
+		if err := tx.Create(&models.User{Username: "alice", Email: "alice@example.com", Password: "hash"}).Error; err != nil {
+			return err
+		}
+		if len(*published) != 0 {
+			t.Error("expected the event to wait for the commit")
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected the rollback error, got %v", err)
+	}
+	if len(*published) != 0 || len(auditLogs(t, db)) != 0 {
+		t.Fatal("expected the rolled back write to publish and record nothing")
+	}
+
+	err = database.WithTransaction(ctx, db, func(tx *gorm.DB) error {
+		return tx.Create(&models.User{Username: "bob", Email: "bob@example.com", Password: "hash"}).Error
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*published) != 1 || len(auditLogs(t, db)) != 1 {
+		t.Fatalf("expected the committed write to be published and recorded, got %d events", len(*published))
+	}
+}
+
+func TestHooks(t *testing.T) {
+	db, registry, published := setup(t)
+	var stages []Stage
+	record := func(stage Stage) Hook {
+		return func(_ context.Context, _ *gorm.DB, model interface{}) error {
+			if _, ok := model.(*models.User); !ok {
+				t.Errorf("expected the user at %s, got %T", stage, model)
+			}
+			stages = append(stages, stage)
+			return nil
+		}
+	}
+	for _, stage := range []Stage{BeforeCreate, AfterCreate, BeforeUpdate, AfterUpdate} {
+		if err := registry.On(&models.User{}, stage, record(stage)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	errBlocked := errors.New("blocked")
+	if err := registry.On(&models.User{}, BeforeDelete, func(context.Context, *gorm.DB, interface{}) error {
+		return errBlocked
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	user := models.User{Username: "alice", Email: "alice@example.com", Password: "hash"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&user).Update("username", "alicia").Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []Stage{BeforeCreate, AfterCreate, BeforeUpdate, AfterUpdate}
+	if len(stages) != len(want) {
+		t.Fatalf("expected stages %v, got %v", want, stages)
+	}
+	for i := range want {
+		if stages[i] != want[i] {
+			t.Fatalf("expected stages %v, got %v", want, stages)
+		}
+	}
+
+	// A failing Before hook aborts the write
+	if err := db.Delete(&user).Error; !errors.Is(err, errBlocked) {
+		t.Fatalf("expected the hook error, got %v", err)
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 1 || len(*published) != 2 {
+		t.Fatalf("expected the delete to be aborted, got %d users and %d events", count, len(*published))
+	}
+
+	if err := registry.On(&models.AuditLog{}, AfterCreate, record(AfterCreate)); err == nil {
+		t.Fatal("expected hooks on an unregistered model to be rejected")
+	}
+}
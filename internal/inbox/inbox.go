@@ -0,0 +1,79 @@
+// Package inbox processes inbound webhook deliveries exactly once. Providers such as Stripe and
+// GitHub deliver an event again until they get a 2xx, and may deliver it twice anyway; the inbox
+// records the ID of each processed event in the transaction that applies it, so a delivery of an
+// event already applied is recognized and skipped.
+package inbox
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+var (
+	// ErrDuplicate is returned by Process for an event that was already processed.
+	ErrDuplicate = errors.New("event already processed")
+	// ErrMissingEventID is returned by Process for an event without an ID, which cannot be
+	// deduplicated.
+	ErrMissingEventID = errors.New("event has no ID")
+)
+
+var deliveriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "webhook_inbox_deliveries_total",
+	Help: "Inbound webhook deliveries, by provider and result (processed, duplicate or failed).",
+}, []string{"provider", "result"})
+
+func init() {
+	metrics.Registry.MustRegister(deliveriesTotal)
+}
+
+// Inbox records the processed events in the inbox_messages table.
+type Inbox struct {
+	db *gorm.DB
+}
+
+// New creates an inbox storing processed events in db.
+func New(db *gorm.DB) *Inbox {
+	return &Inbox{db: db}
+}
+
+// Process runs handle in a transaction that also records eventID as processed for provider,
+// unless it already was, in which case handle is not run and ErrDuplicate is returned. When
+// handle fails, the transaction rolls back with the record, so the next delivery of the event
+// runs it again. handle must therefore make its changes through tx; side effects outside the
+// database, such as sending email, happen at least once.
+//
+// Concurrent deliveries of the same event are serialized by the unique index on the record:
+// the second waits for the first to commit and is then a duplicate.
+func (i *Inbox) Process(ctx context.Context, provider, eventID, eventType string, handle func(tx *gorm.DB) error) error {
+	if eventID == "" {
+		return ErrMissingEventID
+	}
+	err := database.WithTransaction(ctx, i.db, func(tx *gorm.DB) error {
+		record := models.InboxMessage{Provider: provider, EventID: eventID, EventType: eventType}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDuplicate
+		}
+		return handle(tx)
+	})
+	switch {
+	case err == nil:
+		deliveriesTotal.WithLabelValues(provider, "processed").Inc()
+	case errors.Is(err, ErrDuplicate):
+		deliveriesTotal.WithLabelValues(provider, "duplicate").Inc()
+	default:
+		deliveriesTotal.WithLabelValues(provider, "failed").Inc()
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func setupInbox(t *testing.T) (*Inbox, *gorm.DB) {
+	t.Helper()
+	db := testutil.NewDB(t)
+	return New(db), db
+}
+
+func TestInbox_Process(t *testing.T) {
+	in, db := setupInbox(t)
+	ctx := context.Background()
+	notify := func(tx *gorm.DB) error {
+		return tx.Create(&models.Notification{UserID: 1, Kind: "billing", Title: "Paid"}).Error
+	}
+	notifications := func() int64 {
+		var n int64
+		db.Model(&models.Notification{}).Count(&n)
+		return n
+	}
+
+	// A failed delivery rolls back its changes and is not recorded
+	failure := errors.New("downstream unavailable")
+	err := in.Process(ctx, "github", "delivery-1", "push", func(tx *gorm.DB) error {
+		if err := notify(tx); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) || notifications() != 0 {
+		t.Fatalf("failed delivery: err = %v, %d notifications", err, notifications())
+	}
+
+	before := prom.ToFloat64(deliveriesTotal.WithLabelValues("github", "duplicate"))
+	if err := in.Process(ctx, "github", "delivery-1", "push", notify); err != nil {
+		t.Fatalf("retried delivery: %v", err)
+	}
+	if err := in.Process(ctx, "github", "delivery-1", "push", notify); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("redelivery: err = %v, want ErrDuplicate", err)
+	}
+	if got := notifications(); got != 1 {
+		t.Errorf("%d notifications, want the event applied once", got)
+	}
+	if got := prom.ToFloat64(deliveriesTotal.WithLabelValues("github", "duplicate")) - before; got != 1 {
+		t.Errorf("duplicate deliveries grew by %v, want 1", got)
+	}
+
+	// Event IDs are unique per provider
+	if err := in.Process(ctx, "stripe", "delivery-1", "invoice.paid", notify); err != nil {
+		t.Errorf("same ID from another provider: %v", err)
+	}
+	if err := in.Process(ctx, "stripe", "", "invoice.paid", notify); !errors.Is(err, ErrMissingEventID) {
+		t.Errorf("event without ID: err = %v", err)
+	}
+}
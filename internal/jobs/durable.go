@@ -0,0 +1,335 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// ErrNoHandler is returned when a job has neither a registered handler nor a Run function.
+var ErrNoHandler = errors.New("job has no handler")
+
+// maxRetryBackoff caps the wait before retrying a failed job.
+const maxRetryBackoff = time.Hour
+
+// DurableOptions configures a DurableQueue. Zero values take the defaults in parentheses.
+type DurableOptions struct {
+	// Workers is the number of jobs run at the same time (1), persisted or not.
+	Workers int
+	// QueueSize is the buffer of the jobs that are not persisted (0).
+	QueueSize int
+	// PollInterval is how often idle workers look for jobs enqueued by other instances or
+	// due for a retry (1s).
+	PollInterval time.Duration
+	// MaxAttempts is how many times a job is run before it is marked as failed (5).
+	MaxAttempts int
+	// RetryBackoff is the wait before the first retry of a failed job, doubled on each
+	// further retry (10s).
+	RetryBackoff time.Duration
+	// Lease is how long a job may run before it is presumed lost with its worker and run
+	// again (5m). It must be longer than the slowest job.
+	Lease time.Duration
+}
+
+// DurableQueue stores jobs in the database, in the queued_jobs table, so that they survive a
+// restart and are retried when they fail. With SQLite this needs no infrastructure besides the
+// application's database; with PostgreSQL and MySQL, several instances share the queue.
+//
+// Only the jobs whose Name has a handler registered with Handle are persisted, and their
+// Payload is passed to it. Other jobs only have a Run function, which cannot be stored, and are
+// run in memory like on a MemoryQueue.
+type DurableQueue struct {
+	db     *gorm.DB
+	opts   DurableOptions
+	memory *MemoryQueue
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	closed   bool
+
+	wake   chan struct{}
+	stop   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ HandlerRegistry = (*DurableQueue)(nil)
+
+// NewDurableQueue starts opts.Workers goroutines running the jobs persisted in db.
+func NewDurableQueue(db *gorm.DB, opts DurableOptions) *DurableQueue {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 10 * time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 5 * time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &DurableQueue{
+		db:       db,
+		opts:     opts,
+		memory:   NewMemoryQueue(opts.Workers, opts.QueueSize),
+		now:      time.Now,
+		handlers: make(map[string]Handler),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	q.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// Handle registers handler for the jobs named name. Persisted jobs without a handler, e.g.
+// enqueued by a newer version of the application, wait until one is registered.
+func (q *DurableQueue) Handle(name string, handler Handler) {
+	q.mu.Lock()
+	q.handlers[name] = handler
+	q.mu.Unlock()
+	q.notify()
+}
+
+// Enqueue persists a job with a registered handler, or schedules it in memory otherwise.
+func (q *DurableQueue) Enqueue(job Job) error {
+	q.mu.RLock()
+	closed := q.closed
+	_, durable := q.handlers[job.Name]
+	q.mu.RUnlock()
+	switch {
+	case closed:
+		return ErrQueueClosed
+	case !durable && job.Run == nil:
+		return fmt.Errorf("%w: %s", ErrNoHandler, job.Name)
+	case !durable:
+		return q.memory.Enqueue(job)
+	}
+
+	record := models.QueuedJob{
+		Name:    job.Name,
+		JobID:   job.ID,
+		Payload: job.Payload,
+		Status:  models.QueuedJobPending,
+		RunAt:   q.now(),
+	}
+	if err := q.db.Create(&record).Error; err != nil {
+		return fmt.Errorf("failed to persist job: %w", err)
+	}
+	q.notify()
+	return nil
+}
+
+// Shutdown stops taking jobs and waits for the running ones. When ctx expires first, running
+// jobs are canceled and ctx.Err() is returned. Persisted jobs that did not run are run on the
+// next start, as are the canceled ones.
+func (q *DurableQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.stop)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	q.cancel()
+	return errors.Join(err, q.memory.Shutdown(ctx))
+}
+
+// notify wakes an idle worker, if any.
+func (q *DurableQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *DurableQueue) work() {
+	defer q.wg.Done()
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for q.runNext() {
+			select {
+			case <-q.stop:
+				return
+			default:
+			}
+		}
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims and runs the next due job, returning false when there was none.
+func (q *DurableQueue) runNext() bool {
+	record, handler, err := q.claim()
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to claim a queued job")
+		return false
+	}
+	if record == nil {
+		return false
+	}
+	q.run(record, handler)
+	return true
+}
+
+// claim takes the oldest due job with a handler: a pending one whose time has come, or a
+// running one whose worker lost its lease. Each claim counts an attempt, which also keeps two
+// workers from claiming the same job.
+func (q *DurableQueue) claim() (*models.QueuedJob, Handler, error) {
+	q.mu.RLock()
+	names := make([]string, 0, len(q.handlers))
+	for name := range q.handlers {
+		names = append(names, name)
+	}
+	q.mu.RUnlock()
+	if len(names) == 0 {
+		return nil, nil, nil
+	}
+
+	now := q.now()
+	var due []models.QueuedJob
+	err := q.db.Where("name IN ?", names).
+		Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)",
+			models.QueuedJobPending, now, models.QueuedJobRunning, now).
+		Order("run_at, id").Limit(q.opts.Workers).Find(&due).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range due {
+		record := &due[i]
+		lockedUntil := now.Add(q.opts.Lease)
+		claimed := q.db.Model(&models.QueuedJob{}).
+			Where("id = ? AND attempts = ?", record.ID, record.Attempts).
+			Updates(map[string]interface{}{
+				"status":       models.QueuedJobRunning,
+				"locked_until": lockedUntil,
+				"attempts":     record.Attempts + 1,
+			})
+		if claimed.Error != nil {
+			return nil, nil, claimed.Error
+		}
+		if claimed.RowsAffected == 0 {
+			continue
+		}
+		record.Status = models.QueuedJobRunning
+		record.LockedUntil = &lockedUntil
+		record.Attempts++
+
+		q.mu.RLock()
+		handler := q.handlers[record.Name]
+		q.mu.RUnlock()
+		return record, handler, nil
+	}
+	return nil, nil, nil
+}
+
+// run executes a claimed job and records its outcome: the job is deleted when it succeeds,
+// scheduled for a retry when it fails, and marked as failed after its last attempt.
+func (q *DurableQueue) run(record *models.QueuedJob, handler Handler) {
+	fields := map[string]interface{}{
+		"job_id":   record.JobID,
+		"job_name": record.Name,
+		"attempt":  record.Attempts,
+	}
+
+	err := callHandler(q.ctx, handler, record.Payload)
+	var outcome *gorm.DB
+	switch {
+	case err == nil:
+		outcome = q.db.Delete(&models.QueuedJob{}, record.ID)
+		logger.WithFields(fields).Debug("Job completed")
+	case q.ctx.Err() != nil:
+		// Canceled by a shutdown: run it again on the next start, without counting the attempt
+		outcome = q.db.Model(&models.QueuedJob{}).Where("id = ?", record.ID).
+			Updates(map[string]interface{}{
+				"status":       models.QueuedJobPending,
+				"locked_until": nil,
+				"attempts":     record.Attempts - 1,
+			})
+	case record.Attempts >= q.opts.MaxAttempts:
+		outcome = q.db.Model(&models.QueuedJob{}).Where("id = ?", record.ID).
+			Updates(map[string]interface{}{
+				"status":       models.QueuedJobFailed,
+				"locked_until": nil,
+				"last_error":   err.Error(),
+			})
+		fields["error"] = err.Error()
+		logger.WithFields(fields).Error("Job failed after its last attempt")
+	default:
+		retryAt := q.now().Add(q.backoff(record.Attempts))
+		outcome = q.db.Model(&models.QueuedJob{}).Where("id = ?", record.ID).
+			Updates(map[string]interface{}{
+				"status":       models.QueuedJobPending,
+				"run_at":       retryAt,
+				"locked_until": nil,
+				"last_error":   err.Error(),
+			})
+		fields["error"] = err.Error()
+		fields["retry_at"] = retryAt
+		logger.WithFields(fields).Warn("Job failed; it will be retried")
+	}
+
+	if outcome.Error != nil {
+		fields["error"] = outcome.Error.Error()
+		logger.WithFields(fields).Error("Failed to record the outcome of a queued job")
+	}
+}
+
+// backoff returns the wait before retrying a job that failed its attempt-th attempt.
+func (q *DurableQueue) backoff(attempt int) time.Duration {
+	wait := q.opts.RetryBackoff
+	for i := 1; i < attempt && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxRetryBackoff)
+}
+
+// callHandler runs handler, turning a panic into an error so that the job is retried.
+func callHandler(ctx context.Context, handler Handler, payload []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, payload)
+}
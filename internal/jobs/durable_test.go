@@ -0,0 +1,191 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func newTestDurableQueue(t *testing.T, db *gorm.DB, opts DurableOptions) *DurableQueue {
+	t.Helper()
+	opts.PollInterval = 5 * time.Millisecond
+	q := NewDurableQueue(db, opts)
+	t.Cleanup(func() { _ = q.Shutdown(context.Background()) })
+	return q
+}
+
+// waitUntil polls cond until it holds.
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func countJobs(db *gorm.DB, status string) int64 {
+	var n int64
+	query := db.Model(&models.QueuedJob{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&n)
+	return n
+}
+
+func TestDurableQueueRunsPersistedJobs(t *testing.T) {
+	db := testutil.NewDB(t)
+	q := newTestDurableQueue(t, db, DurableOptions{Workers: 2})
+	got := make(chan string, 1)
+	q.Handle("greet", func(_ context.Context, payload []byte) error {
+		got <- string(payload)
+		return nil
+	})
+
+	if err := q.Enqueue(Job{ID: "1", Name: "greet", Payload: []byte("hello")}); err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+	select {
+	case payload := <-got:
+		if payload != "hello" {
+			t.Errorf("payload = %q, want hello", payload)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the job did not run")
+	}
+	waitUntil(t, "the completed job to be deleted", func() bool { return countJobs(db, "") == 0 })
+}
+
+func TestDurableQueueRetriesFailedJobs(t *testing.T) {
+	db := testutil.NewDB(t)
+	q := newTestDurableQueue(t, db, DurableOptions{MaxAttempts: 3, RetryBackoff: time.Millisecond})
+	var flaky, broken atomic.Int32
+	q.Handle("flaky", func(context.Context, []byte) error {
+		if flaky.Add(1) < 3 {
+			return errors.New("try again")
+		}
+		return nil
+	})
+	q.Handle("broken", func(context.Context, []byte) error {
+		broken.Add(1)
+		panic("always")
+	})
+
+	_ = q.Enqueue(Job{Name: "flaky"})
+	_ = q.Enqueue(Job{Name: "broken"})
+
+	waitUntil(t, "the broken job to fail", func() bool { return countJobs(db, models.QueuedJobFailed) == 1 })
+	waitUntil(t, "the flaky job to succeed", func() bool { return countJobs(db, "") == 1 })
+	if flaky.Load() != 3 || broken.Load() != 3 {
+		t.Errorf("runs = %d flaky, %d broken; want 3 each", flaky.Load(), broken.Load())
+	}
+	var failed models.QueuedJob
+	db.First(&failed, "name = ?", "broken")
+	if failed.Attempts != 3 || failed.LastError != "panic: always" || failed.LockedUntil != nil {
+		t.Errorf("unexpected failed job: %+v", failed)
+	}
+}
+
+func TestDurableQueueRunsJobsLeftByAPreviousProcess(t *testing.T) {
+	db := testutil.NewDB(t)
+	past := time.Now().Add(-time.Minute)
+	db.Create(&models.QueuedJob{Name: "greet", Status: models.QueuedJobPending, RunAt: past})
+	// Claimed by a worker that died
+	db.Create(&models.QueuedJob{Name: "greet", Status: models.QueuedJobRunning, RunAt: past, LockedUntil: &past, Attempts: 1})
+	// Still running elsewhere
+	future := time.Now().Add(time.Hour)
+	db.Create(&models.QueuedJob{Name: "greet", Status: models.QueuedJobRunning, RunAt: past, LockedUntil: &future, Attempts: 1})
+	// Without a handler in this version
+	db.Create(&models.QueuedJob{Name: "unknown", Status: models.QueuedJobPending, RunAt: past})
+
+	q := newTestDurableQueue(t, db, DurableOptions{})
+	var ran atomic.Int32
+	q.Handle("greet", func(context.Context, []byte) error {
+		ran.Add(1)
+		return nil
+	})
+
+	waitUntil(t, "the jobs to run", func() bool { return ran.Load() == 2 })
+	waitUntil(t, "the jobs to be deleted", func() bool { return countJobs(db, "") == 2 })
+	time.Sleep(20 * time.Millisecond)
+	if ran.Load() != 2 {
+		t.Errorf("ran %d jobs, want the job still leased left alone", ran.Load())
+	}
+}
+
+func TestDurableQueueRunsOtherJobsInMemory(t *testing.T) {
+	db := testutil.NewDB(t)
+	q := newTestDurableQueue(t, db, DurableOptions{QueueSize: 1})
+	ran := make(chan struct{})
+
+	if err := q.Enqueue(Job{Name: "closure", Run: func(context.Context) error {
+		close(ran)
+		return nil
+	}}); err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the job did not run")
+	}
+	if n := countJobs(db, ""); n != 0 {
+		t.Errorf("%d jobs persisted, want none", n)
+	}
+	if err := q.Enqueue(Job{Name: "nothing"}); !errors.Is(err, ErrNoHandler) {
+		t.Errorf("Enqueue() error = %v, want ErrNoHandler", err)
+	}
+}
+
+func TestDurableQueueShutdownReleasesRunningJobs(t *testing.T) {
+	db := testutil.NewDB(t)
+	q := NewDurableQueue(db, DurableOptions{PollInterval: 5 * time.Millisecond})
+	started := make(chan struct{})
+	q.Handle("slow", func(ctx context.Context, _ []byte) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	_ = q.Enqueue(Job{Name: "slow"})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want deadline exceeded", err)
+	}
+	waitUntil(t, "the job to be released", func() bool { return countJobs(db, models.QueuedJobPending) == 1 })
+	var released models.QueuedJob
+	db.First(&released)
+	if released.Attempts != 0 || released.LockedUntil != nil {
+		t.Errorf("unexpected released job: %+v", released)
+	}
+	if err := q.Enqueue(Job{Name: "slow"}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Enqueue() error = %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestDurableQueueBackoff(t *testing.T) {
+	q := &DurableQueue{opts: DurableOptions{RetryBackoff: 10 * time.Second}}
+	for attempt, want := range map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		4:  80 * time.Second,
+		30: maxRetryBackoff,
+	} {
+		if got := q.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
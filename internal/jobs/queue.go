@@ -0,0 +1,155 @@
+// Package jobs provides a background job queue for work that outlives an HTTP request.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+var (
+	// ErrQueueFull is returned when a job is enqueued while the buffer is full.
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrQueueClosed is returned when a job is enqueued after Shutdown.
+	ErrQueueClosed = errors.New("job queue is closed")
+)
+
+// Job is a unit of background work.
+type Job struct {
+	// ID identifies the job in logs.
+	ID string
+	// Name describes the kind of job, e.g. "users.batch".
+	Name string
+	// Run executes the job. The context is canceled when the queue shuts down.
+	Run func(ctx context.Context) error
+	// Payload is the input of the handler registered for Name on a DurableQueue, which
+	// persists the job and runs it with that handler instead of Run.
+	Payload []byte
+}
+
+// Handler runs a persisted job from its payload.
+type Handler func(ctx context.Context, payload []byte) error
+
+// HandlerRegistry is implemented by the queues that persist jobs, which need a handler to run
+// them after a restart.
+type HandlerRegistry interface {
+	// Handle registers handler for the jobs named name.
+	Handle(name string, handler Handler)
+}
+
+// Queue accepts jobs for asynchronous execution.
+type Queue interface {
+	// Enqueue schedules a job. It never blocks; ErrQueueFull is returned when there is no capacity.
+	Enqueue(job Job) error
+	// Shutdown stops accepting jobs and waits for running jobs until ctx expires.
+	Shutdown(ctx context.Context) error
+}
+
+// MemoryQueue runs jobs on a fixed pool of goroutines in the current process.
+// Jobs that are still buffered when the process exits are lost.
+type MemoryQueue struct {
+	jobs   chan Job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewMemoryQueue starts workers goroutines consuming a buffer of size jobs.
+func NewMemoryQueue(workers, size int) *MemoryQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if size < 0 {
+		size = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &MemoryQueue{
+		jobs:   make(chan Job, size),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// Enqueue schedules a job for execution.
+func (q *MemoryQueue) Enqueue(job Job) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting jobs, lets the workers drain the buffer, and waits for them.
+// When ctx expires first, running jobs are canceled and ctx.Err() is returned.
+func (q *MemoryQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		return ctx.Err()
+	}
+}
+
+func (q *MemoryQueue) work() {
+	defer q.wg.Done()
+	for job := range q.jobs {
+		q.run(job)
+	}
+}
+
+// run executes a job, recovering from panics so a single job cannot stop a worker.
+func (q *MemoryQueue) run(job Job) {
+	fields := map[string]interface{}{
+		"job_id":   job.ID,
+		"job_name": job.Name,
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			fields["panic"] = fmt.Sprint(r)
+			logger.WithFields(fields).Error("Job panicked")
+		}
+	}()
+
+	if err := job.Run(q.ctx); err != nil {
+		fields["error"] = err.Error()
+		logger.WithFields(fields).Error("Job failed")
+		return
+	}
+	logger.WithFields(fields).Debug("Job completed")
+}
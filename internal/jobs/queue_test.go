@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryQueueRunsJobs(t *testing.T) {
+	q := NewMemoryQueue(2, 10)
+
+	var ran int32
+	for i := 0; i < 5; i++ {
+		err := q.Enqueue(Job{Name: "test", Run: func(context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			return nil
+		}})
+		if err != nil {
+			t.Fatalf("Enqueue returned error: %v", err)
+		}
+	}
+
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+	if ran != 5 {
+		t.Fatalf("expected 5 jobs to run, got %d", ran)
+	}
+	if err := q.Enqueue(Job{Run: func(context.Context) error { return nil }}); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expected ErrQueueClosed after shutdown, got %v", err)
+	}
+}
+
+func TestMemoryQueueRejectsWhenFull(t *testing.T) {
+	q := NewMemoryQueue(1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	if err := q.Enqueue(Job{Run: func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}}); err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+	<-started
+
+	// The worker is busy, so the next job fills the buffer and the one after is rejected.
+	if err := q.Enqueue(Job{Run: func(context.Context) error { return nil }}); err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+	if err := q.Enqueue(Job{Run: func(context.Context) error { return nil }}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	close(release)
+	_ = q.Shutdown(context.Background())
+}
+
+func TestMemoryQueueShutdownCancelsRunningJobs(t *testing.T) {
+	q := NewMemoryQueue(1, 1)
+	canceled := make(chan struct{})
+
+	_ = q.Enqueue(Job{Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := q.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("running job was not canceled")
+	}
+}
@@ -0,0 +1,8 @@
+package metering
+
+import "time"
+
+// SetNow replaces the clock of m, so that tests can move between days and months.
+func SetNow(m *Meter, now func() time.Time) {
+	m.now = now
+}
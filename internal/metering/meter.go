@@ -0,0 +1,205 @@
+// Package metering counts the requests and bytes of each user per API key and day, and
+// enforces the monthly request quota of API keys. Usage is counted in memory and written to
+// the database every flush interval, so with several instances a key may exceed its quota by
+// up to one interval of traffic.
+package metering
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// dayLayout is the format of models.APIUsage.Day.
+const dayLayout = "2006-01-02"
+
+type usageKey struct {
+	userID   uint
+	apiKeyID uint
+	day      string
+}
+
+type usage struct {
+	requests int64
+	bytes    int64
+}
+
+// Meter records usage and reports it against the monthly quota.
+type Meter struct {
+	db    *gorm.DB
+	quota int64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	pending map[usageKey]usage
+}
+
+// NewMeter creates a meter storing usage in db. Each API key may make monthlyQuota requests per
+// calendar month (UTC); 0 leaves keys unlimited.
+func NewMeter(db *gorm.DB, monthlyQuota int64) *Meter {
+	return &Meter{
+		db:      db,
+		quota:   monthlyQuota,
+		now:     time.Now,
+		pending: make(map[usageKey]usage),
+	}
+}
+
+// Now returns the time of the meter's clock, which decides the day and month usage counts in.
+func (m *Meter) Now() time.Time {
+	return m.now()
+}
+
+// Quota returns the monthly request quota of API keys, 0 meaning unlimited.
+func (m *Meter) Quota() int64 {
+	return m.quota
+}
+
+// Period returns the start of the calendar month (UTC) containing t and the start of the next
+// one, when quotas reset.
+func Period(t time.Time) (start, reset time.Time) {
+	t = t.UTC()
+	start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Record counts a request of userID of the given size, with apiKeyID 0 for requests
+// authenticated without an API key.
+func (m *Meter) Record(userID, apiKeyID uint, bytes int64) {
+	key := usageKey{userID: userID, apiKeyID: apiKeyID, day: m.now().UTC().Format(dayLayout)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u := m.pending[key]
+	u.requests++
+	u.bytes += bytes
+	m.pending[key] = u
+}
+
+// Flush writes the usage counted since the last flush. Usage that could not be written is
+// kept for the next flush.
+func (m *Meter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[usageKey]usage)
+	m.mu.Unlock()
+
+	for key, u := range pending {
+		err := m.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "api_key_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"requests": gorm.Expr("api_usage.requests + ?", u.requests),
+				"bytes":    gorm.Expr("api_usage.bytes + ?", u.bytes),
+			}),
+		}).Create(&models.APIUsage{
+			UserID:   key.userID,
+			APIKeyID: key.apiKeyID,
+			Day:      key.day,
+			Requests: u.requests,
+			Bytes:    u.bytes,
+		}).Error
+		if err != nil {
+			m.restore(pending)
+			return err
+		}
+		delete(pending, key)
+	}
+	return nil
+}
+
+// restore adds usage that was not written back to the pending usage.
+func (m *Meter) restore(unwritten map[usageKey]usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, u := range unwritten {
+		p := m.pending[key]
+		p.requests += u.requests
+		p.bytes += u.bytes
+		m.pending[key] = p
+	}
+}
+
+// Run flushes the usage every interval until ctx is canceled, then flushes it a last time.
+func (m *Meter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := m.Flush(context.Background()); err != nil {
+				logger.WithField("error", err.Error()).Error("Failed to flush API usage")
+			}
+			return
+		case <-ticker.C:
+			if err := m.Flush(ctx); err != nil {
+				logger.WithField("error", err.Error()).Error("Failed to flush API usage")
+			}
+		}
+	}
+}
+
+// Usage returns the daily usage of an API key of userID in the current month, oldest first,
+// including the usage not flushed yet.
+func (m *Meter) Usage(ctx context.Context, userID, apiKeyID uint) ([]models.APIUsage, error) {
+	start, reset := Period(m.now())
+	var days []models.APIUsage
+	err := m.db.WithContext(ctx).
+		Where("user_id = ? AND api_key_id = ? AND day >= ? AND day < ?",
+			userID, apiKeyID, start.Format(dayLayout), reset.Format(dayLayout)).
+		Order("day").
+		Find(&days).Error
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, u := range m.pending {
+		if key.userID != userID || key.apiKeyID != apiKeyID || key.day < start.Format(dayLayout) {
+			continue
+		}
+		found := false
+		for i := range days {
+			if days[i].Day == key.day {
+				days[i].Requests += u.requests
+				days[i].Bytes += u.bytes
+				found = true
+				break
+			}
+		}
+		if !found {
+			days = append(days, models.APIUsage{UserID: userID, APIKeyID: apiKeyID, Day: key.day, Requests: u.requests, Bytes: u.bytes})
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+	return days, nil
+}
+
+// Used returns the requests made with an API key of userID in the current month.
+func (m *Meter) Used(ctx context.Context, userID, apiKeyID uint) (int64, error) {
+	start, _ := Period(m.now())
+	var stored int64
+	err := m.db.WithContext(ctx).Model(&models.APIUsage{}).
+		Where("user_id = ? AND api_key_id = ? AND day >= ?", userID, apiKeyID, start.Format(dayLayout)).
+		Select("COALESCE(SUM(requests), 0)").
+		Scan(&stored).Error
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, u := range m.pending {
+		if key.userID == userID && key.apiKeyID == apiKeyID && key.day >= start.Format(dayLayout) {
+			stored += u.requests
+		}
+	}
+	return stored, nil
+}
@@ -0,0 +1,68 @@
+package metering_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/metering"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func setupMeter(t *testing.T, quota int64) *metering.Meter {
+	t.Helper()
+	return metering.NewMeter(testutil.NewDB(t), quota)
+}
+
+func TestPeriod(t *testing.T) {
+	start, reset := metering.Period(time.Date(2026, time.December, 31, 23, 0, 0, 0, time.FixedZone("UTC-3", -3*3600)))
+	if want := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("start = %v, want %v (the month in UTC)", start, want)
+	}
+	if want := time.Date(2027, time.February, 1, 0, 0, 0, 0, time.UTC); !reset.Equal(want) {
+		t.Errorf("reset = %v, want %v", reset, want)
+	}
+}
+
+func TestMeterFlushAccumulatesUsage(t *testing.T) {
+	ctx := context.Background()
+	meter := setupMeter(t, 100)
+	now := time.Date(2026, time.March, 31, 12, 0, 0, 0, time.UTC)
+	metering.SetNow(meter, func() time.Time { return now })
+
+	meter.Record(1, 7, 100)
+	meter.Record(1, 7, 50)
+	meter.Record(1, 0, 10)
+	meter.Record(2, 8, 10)
+	if used, err := meter.Used(ctx, 1, 7); err != nil || used != 2 {
+		t.Fatalf("Used() before flush = %d, %v; want 2", used, err)
+	}
+	if err := meter.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second flush of the same day adds to the stored row
+	meter.Record(1, 7, 25)
+	if err := meter.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	meter.Record(1, 7, 5)
+	days, err := meter.Usage(ctx, 1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 || days[0].Day != "2026-03-31" || days[0].Requests != 4 || days[0].Bytes != 180 {
+		t.Errorf("Usage() = %+v, want 4 requests and 180 bytes on 2026-03-31", days)
+	}
+
+	// Usage of the previous month does not count
+	now = now.Add(24 * time.Hour)
+	meter.Record(1, 7, 1)
+	if used, err := meter.Used(ctx, 1, 7); err != nil || used != 1 {
+		t.Errorf("Used() in the next month = %d, %v; want 1", used, err)
+	}
+	days, err = meter.Usage(ctx, 1, 7)
+	if err != nil || len(days) != 1 || days[0].Day != "2026-04-01" {
+		t.Errorf("Usage() in the next month = %+v, %v", days, err)
+	}
+}
@@ -4,21 +4,32 @@ package middlewares
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yeferson59/gin-template/internal/auth"
-	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/security"
 	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
 	"github.com/yeferson59/gin-template/pkg/response"
 	"gorm.io/gorm"
 )
 
+// apiKeyTouchInterval is how often the last use of an API key is written, at most.
+const apiKeyTouchInterval = time.Minute
+
 // AuthRequired is a middleware that validates the JWT and checks if the user exists in the database.
+// Requests without an Authorization header may authenticate with an API key in X-API-Key instead.
 func AuthRequired(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
+			if key := c.GetHeader(auth.APIKeyHeader); key != "" {
+				authenticateAPIKey(c, db, key)
+				return
+			}
 			logger.WithField("ip", c.ClientIP()).Warn("Request to protected endpoint without authorization header")
+			security.RecordTokenFailure(c, security.TokenMissing)
 			response.UnauthorizedError(c, "Authorization required", "Authorization header is missing")
 			c.Abort()
 			return
@@ -26,7 +37,11 @@ func AuthRequired(db *gorm.DB) gin.HandlerFunc {
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			logger.WithField("auth_header", authHeader).Warn("Invalid authorization header format")
+			logger.WithFields(map[string]interface{}{
+				"ip":     c.ClientIP(),
+				"scheme": parts[0],
+			}).Warn("Invalid authorization header format")
+			security.RecordTokenFailure(c, security.TokenMalformed)
 			response.UnauthorizedError(c, "Invalid authorization format", "Authorization header must be in format 'Bearer <token>'")
 			c.Abort()
 			return
@@ -36,31 +51,34 @@ func AuthRequired(db *gorm.DB) gin.HandlerFunc {
 		claims, err := auth.ValidateJWT(tokenString)
 		if err != nil {
 			logger.WithField("error", err.Error()).Warn("Invalid or expired JWT token")
+			security.RecordTokenFailure(c, security.TokenInvalid)
 			response.UnauthorizedError(c, "Invalid or expired token", err.Error())
 			c.Abort()
 			return
 		}
 
-		// Check if the user exists in the database
-		var user models.User
-		if err := db.First(&user, claims.UserID).Error; err != nil {
+		// Check if the user exists (served from the user cache when one is attached to db)
+		user, err := auth.LoadUser(db, claims.UserID)
+		if err != nil {
 			logger.WithFields(map[string]interface{}{
 				"user_id": claims.UserID,
 				"error":   err.Error(),
 			}).Warn("JWT token refers to non-existent user")
+			security.RecordTokenFailure(c, security.TokenUnknownUser)
 			response.UnauthorizedError(c, "Invalid token", "User associated with token not found")
 			c.Abort()
 			return
 		}
 
 		// Set user information in context
-		c.Set("user_id", user.ID)
-		c.Set("user", user)
-		c.Set("email", user.Email)
-		c.Set("username", user.Username)
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time
+		}
+		requestctx.SetUser(c, &user, issuedAt)
+		requestctx.SetLoginRisk(c, claims.Risk)
 
-		logger.WithFields(map[string]interface{}{
-			"user_id":  user.ID,
+		requestctx.Logger(c).WithFields(map[string]interface{}{
 			"username": user.Username,
 			"endpoint": c.Request.URL.Path,
 		}).Debug("User authenticated successfully")
@@ -69,17 +87,48 @@ func AuthRequired(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// authenticateAPIKey authenticates the request as the owner of an API key.
+func authenticateAPIKey(c *gin.Context, db *gorm.DB, key string) {
+	apiKey, err := auth.LoadAPIKey(db, key)
+	if err != nil {
+		logger.WithField("ip", c.ClientIP()).Warn("Request with unknown API key")
+		security.RecordTokenFailure(c, security.TokenInvalid)
+		response.UnauthorizedError(c, "Invalid API key", "The API key is invalid or was revoked")
+		c.Abort()
+		return
+	}
+
+	user, err := auth.LoadUser(db, apiKey.UserID)
+	if err != nil {
+		logger.WithField("api_key_id", apiKey.ID).Warn("API key refers to non-existent user")
+		security.RecordTokenFailure(c, security.TokenUnknownUser)
+		response.UnauthorizedError(c, "Invalid API key", "User associated with API key not found")
+		c.Abort()
+		return
+	}
+
+	usedAt := now()
+	if apiKey.LastUsedAt == nil || usedAt.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		db.Model(&apiKey).Update("last_used_at", usedAt)
+	}
+	requestctx.SetUser(c, &user, time.Time{})
+	requestctx.SetAPIKey(c, &apiKey)
+	c.Next()
+}
+
 // ProtectedHandler is an example of a JWT-protected endpoint.
 func ProtectedHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, _ := c.Get("user_id")
-		email, _ := c.Get("email")
-		username, _ := c.Get("username")
+		user, ok := requestctx.CurrentUser(c)
+		if !ok {
+			response.UnauthorizedError(c, "Authorization required", "No authenticated user")
+			return
+		}
 
 		data := gin.H{
-			"user_id":  userID,
-			"email":    email,
-			"username": username,
+			"user_id":  user.ID,
+			"email":    user.Email,
+			"username": user.Username,
 			"message":  "You have successfully accessed a protected resource",
 		}
 
@@ -0,0 +1,125 @@
+package middlewares_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+// benchRouter returns a router with the same global middlewares as the server.
+func benchRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger.Init().SetOutput(io.Discard)
+
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler())
+	r.Use(middlewares.RequestLogger())
+	r.Use(middlewares.SecurityHeaders())
+	r.Use(middlewares.RequestID(true))
+	r.Use(middlewares.CORS())
+	return r
+}
+
+func serve(b *testing.B, h http.Handler, req *http.Request, want int) {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != want {
+		b.Fatalf("status = %d, want %d: %s", w.Code, want, w.Body.String())
+	}
+}
+
+func BenchmarkGlobalMiddlewareChain(b *testing.B) {
+	r := benchRouter()
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		serve(b, r, req, http.StatusNoContent)
+	}
+}
+
+func BenchmarkAuthRequired(b *testing.B) {
+	benchmarkAuthRequired(b, false)
+}
+
+func BenchmarkAuthRequired_UserCache(b *testing.B) {
+	benchmarkAuthRequired(b, true)
+}
+
+func benchmarkAuthRequired(b *testing.B, userCache bool) {
+	r := benchRouter()
+	testutil.SetJWTSecret(b)
+	db := testutil.NewDB(b)
+	if userCache {
+		if err := auth.UseUserCache(db, auth.NewUserCache(time.Minute, 100)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	user := testutil.CreateUser(b, db)
+	r.GET("/me", middlewares.AuthRequired(db), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	token, err := auth.GenerateJWT(user.ID, user.Email)
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		serve(b, r, req, http.StatusNoContent)
+	}
+}
+
+func BenchmarkRateLimiter_SingleIP(b *testing.B) {
+	rl := middlewares.NewIPRateLimiter(rate.Inf, 1)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		rl.GetLimiter("203.0.113.7").Allow()
+	}
+}
+
+func BenchmarkRateLimiter_ManyIPsParallel(b *testing.B) {
+	rl := middlewares.NewIPRateLimiter(rate.Inf, 1)
+	ips := make([]string, 1024)
+	for i := range ips {
+		ips[i] = fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			rl.GetLimiter(ips[i%len(ips)]).Allow()
+			i++
+		}
+	})
+}
+
+func BenchmarkRateLimitMiddleware(b *testing.B) {
+	r := benchRouter()
+	r.GET("/ping", middlewares.RateLimitWithConfig(rate.Inf, 1), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		serve(b, r, req, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,130 @@
+// Package middlewares provides bot detection and step-up authentication functionality.
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// maxHoneypotBodySize limits how much of the body is inspected for honeypot fields.
+const maxHoneypotBodySize = 1 << 20 // 1MB
+
+// BotDetection scores each request with user-agent and honeypot heuristics and stores the
+// resulting security.Assessment in the context. Requests reaching blockThreshold are rejected.
+func BotDetection(honeypotField string, blockThreshold int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		assessment := security.AssessRequest(c.Request)
+
+		if honeypotField != "" && honeypotFilled(c, honeypotField) {
+			assessment.Add(security.SignalHoneypot)
+		}
+
+		c.Set(security.ContextKey, assessment)
+
+		if blockThreshold > 0 && assessment.Score >= blockThreshold {
+			logger.WithFields(map[string]interface{}{
+				"ip":         c.ClientIP(),
+				"path":       c.Request.URL.Path,
+				"risk_score": assessment.Score,
+				"signals":    assessment.SignalNames(),
+			}).Warn("Request blocked by bot detection")
+			response.ForbiddenError(c, "Request blocked", "Automated traffic detected")
+			c.Abort()
+			return
+		}
+
+		if assessment.Score > 0 {
+			requestctx.Logger(c).WithFields(map[string]interface{}{
+				"ip":         c.ClientIP(),
+				"path":       c.Request.URL.Path,
+				"risk_score": assessment.Score,
+				"signals":    assessment.SignalNames(),
+			}).Debug("Request flagged by bot detection")
+		}
+
+		c.Next()
+	}
+}
+
+// RequireStepUp forces risky requests to present a recently issued token.
+// The risk of a request is its own score plus the score of the login that issued its token
+// (new device or country, impossible travel). When it reaches threshold and the token is older
+// than maxAge, the request is rejected with STEP_UP_REQUIRED so the client re-authenticates.
+// It must run after AuthRequired.
+func RequireStepUp(threshold int, maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		assessment := security.AssessmentFromContext(c)
+		score := assessment.Score + requestctx.LoginRisk(c)
+		if score > security.MaxScore {
+			score = security.MaxScore
+		}
+		if threshold <= 0 || score < threshold {
+			c.Next()
+			return
+		}
+
+		if issuedAt, ok := requestctx.TokenIssuedAt(c); ok && time.Since(issuedAt) <= maxAge {
+			c.Next()
+			return
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"user_id":    requestctx.UserID(c),
+			"risk_score": score,
+			"login_risk": requestctx.LoginRisk(c),
+			"signals":    assessment.SignalNames(),
+		}).Warn("Step-up authentication required")
+		response.ErrorResponse(c, response.CodeStepUpRequired, "Re-authentication required", "Please log in again to continue")
+		c.Abort()
+	}
+}
+
+// honeypotFilled reports whether the hidden honeypot field was submitted with a value.
+// Real users never see the field, so any value indicates an automated client.
+func honeypotFilled(c *gin.Context, field string) bool {
+	switch c.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return false
+	}
+
+	contentType := c.ContentType()
+	if contentType == "application/x-www-form-urlencoded" || contentType == "multipart/form-data" {
+		return strings.TrimSpace(c.PostForm(field)) != ""
+	}
+
+	if contentType != "application/json" || c.Request.Body == nil {
+		return false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxHoneypotBodySize))
+	if err != nil {
+		return false
+	}
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+
+	value, exists := payload[field]
+	if !exists || value == nil {
+		return false
+	}
+	if s, isString := value.(string); isString {
+		return strings.TrimSpace(s) != ""
+	}
+	return true
+}
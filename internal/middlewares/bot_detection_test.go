@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+const browserUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+func TestBotDetectionBlocksHoneypot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(BotDetection("website", 100))
+	r.POST("/register", func(c *gin.Context) {
+		var body map[string]string
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.String(http.StatusOK, body["name"])
+	})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", browserUserAgent)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(`{"name":"ana","website":"http://spam.example"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("filled honeypot: got %d, want 403", w.Code)
+	}
+	if w := post(`{"name":"ana","website":""}`); w.Code != http.StatusOK || w.Body.String() != "ana" {
+		t.Fatalf("empty honeypot: got %d %q, want 200 with the body still readable", w.Code, w.Body.String())
+	}
+}
+
+func TestRequireStepUp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		threshold int
+		userAgent string
+		loginRisk int
+		tokenAge  time.Duration
+		wantCode  int
+	}{
+		{"low risk", 70, browserUserAgent, 0, time.Hour, http.StatusOK},
+		{"risky login with old token", 70, browserUserAgent, 80, time.Hour, http.StatusUnauthorized},
+		{"risky login with fresh token", 70, browserUserAgent, 80, time.Minute, http.StatusOK},
+		{"new device from a script", 70, "curl/8.0", 50, time.Hour, http.StatusUnauthorized},
+		{"new device from a browser", 70, browserUserAgent, 50, time.Hour, http.StatusOK},
+		{"disabled", 0, "curl/8.0", 100, time.Hour, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(BotDetection("", 0))
+			// Stand-in for AuthRequired, which takes the login risk from the token
+			r.Use(func(c *gin.Context) {
+				requestctx.SetUser(c, &models.User{ID: 1}, time.Now().Add(-tt.tokenAge))
+				requestctx.SetLoginRisk(c, tt.loginRisk)
+			})
+			r.Use(RequireStepUp(tt.threshold, 5*time.Minute))
+			r.GET("/users/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("User-Agent", tt.userAgent)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body.String(), tt.wantCode)
+			}
+			if tt.wantCode != http.StatusUnauthorized {
+				return
+			}
+			var body response.APIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+			}
+			if body.Error == nil || body.Error.Code != response.CodeStepUpRequired.Code {
+				t.Fatalf("got %s, want STEP_UP_REQUIRED", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/cachecontrol"
+)
+
+// CacheControl sets the Cache-Control header of successful GET and HEAD responses from the
+// policy of their route group, and tags responses that shared caches may store with surrogate
+// keys in keyHeader: the group prefix, the keys of the policy, and those added by the handler
+// with cachecontrol.AddSurrogateKeys.
+//
+// A Cache-Control header set by the handler is left untouched, and the responses to requests
+// carrying credentials, an Authorization or X-API-Key header, are never public, so that a CDN
+// cannot serve one user's response to another.
+func CacheControl(rules cachecontrol.Rules, keyHeader string) gin.HandlerFunc {
+	if keyHeader == "" {
+		keyHeader = cachecontrol.DefaultSurrogateKeyHeader
+	}
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		rule, ok := rules.Match(c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		policy := rule.Policy
+		if policy.Public && rule.Prefix != "" {
+			policy.SurrogateKeys = append([]string{strings.TrimPrefix(rule.Prefix, "/")}, policy.SurrogateKeys...)
+		}
+		if _, ok := credentialHash(c); ok {
+			policy = policy.Private()
+		}
+		c.Writer = &cacheControlWriter{ResponseWriter: c.Writer, c: c, policy: policy, keyHeader: keyHeader}
+		c.Next()
+	}
+}
+
+// cacheControlWriter adds the caching headers just before the response headers are sent,
+// once the status is known.
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	c         *gin.Context
+	policy    cachecontrol.Policy
+	keyHeader string
+	applied   bool
+}
+
+func (w *cacheControlWriter) WriteHeaderNow() {
+	w.apply()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheControlWriter) Write(p []byte) (int, error) {
+	w.apply()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *cacheControlWriter) WriteString(s string) (int, error) {
+	w.apply()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *cacheControlWriter) apply() {
+	if w.applied || w.ResponseWriter.Written() {
+		return
+	}
+	w.applied = true
+
+	status := w.ResponseWriter.Status()
+	header := w.ResponseWriter.Header()
+	if status < 200 || status > 299 || header.Get("Cache-Control") != "" {
+		return
+	}
+	header.Set("Cache-Control", w.policy.Header())
+
+	if !w.policy.Public || w.policy.NoStore || header.Get(w.keyHeader) != "" {
+		return
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range append(append([]string(nil), w.policy.SurrogateKeys...), cachecontrol.SurrogateKeys(w.c)...) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) > 0 {
+		header.Set(w.keyHeader, strings.Join(keys, " "))
+	}
+}
@@ -0,0 +1,113 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/pkg/cachecontrol"
+)
+
+func cacheControlRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	rules, err := cachecontrol.ParseRules([]string{
+		"/catalog=public max-age=60 stale-while-revalidate=30 key=catalog",
+		"/account=private max-age=10",
+	})
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CacheControl(rules, ""))
+	r.GET("/catalog/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		cachecontrol.AddSurrogateKeys(c, "item-"+c.Param("id"))
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	r.POST("/catalog/:id", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	r.GET("/catalog/live", func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	r.GET("/account", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	return r
+}
+
+func serveCached(r *gin.Engine, method, path, auth string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCacheControl_AppliesGroupPolicy(t *testing.T) {
+	r := cacheControlRouter(t)
+
+	w := serveCached(r, http.MethodGet, "/catalog/1", "")
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=60, stale-while-revalidate=30" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	if got := w.Header().Get("Surrogate-Key"); got != "catalog item-1" {
+		t.Errorf("Surrogate-Key = %q", got)
+	}
+
+	w = serveCached(r, http.MethodGet, "/account", "")
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=10" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	if got := w.Header().Get("Surrogate-Key"); got != "" {
+		t.Errorf("private response has Surrogate-Key %q", got)
+	}
+}
+
+func TestCacheControl_AuthenticatedRequestsArePrivate(t *testing.T) {
+	w := serveCached(cacheControlRouter(t), http.MethodGet, "/catalog/1", "Bearer token")
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=60, stale-while-revalidate=30" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	if got := w.Header().Get("Surrogate-Key"); got != "" {
+		t.Errorf("Surrogate-Key = %q, want none", got)
+	}
+}
+
+func TestCacheControl_APIKeyRequestsArePrivate(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/catalog/1", nil)
+	req.Header.Set(auth.APIKeyHeader, "key")
+	w := httptest.NewRecorder()
+	cacheControlRouter(t).ServeHTTP(w, req)
+
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=60, stale-while-revalidate=30" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	if got := w.Header().Get("Surrogate-Key"); got != "" {
+		t.Errorf("Surrogate-Key = %q, want none", got)
+	}
+}
+
+func TestCacheControl_SkipsOtherResponses(t *testing.T) {
+	r := cacheControlRouter(t)
+
+	for _, tc := range []struct {
+		method, path, want string
+	}{
+		{http.MethodGet, "/catalog/missing", ""},
+		{http.MethodPost, "/catalog/1", ""},
+		{http.MethodGet, "/catalog/live", "no-store"},
+	} {
+		w := serveCached(r, tc.method, tc.path, "")
+		if got := w.Header().Get("Cache-Control"); got != tc.want {
+			t.Errorf("%s %s: Cache-Control = %q, want %q", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/canary"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+)
+
+// CanaryRolloutHeader names the rollout whose canary served the response.
+const CanaryRolloutHeader = "X-Canary-Rollout"
+
+// Implementations of a rollout, as labeled in metrics.
+const (
+	variantStable = "stable"
+	variantCanary = "canary"
+)
+
+// canaryRequestsTotal compares the status codes of both implementations of each rollout.
+var canaryRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "canary_requests_total",
+	Help: "Requests of canary rollouts by rollout, implementation (stable or canary) and status code.",
+}, []string{"rollout", "variant", "code"})
+
+func init() {
+	metrics.Registry.MustRegister(canaryRequestsTotal)
+}
+
+// Canary serves the requests router assigns to the canary of rollout with canaryHandler, and
+// passes the others on to the stable handler registered after it on the same route:
+//
+//	users.GET("/me", middlewares.Canary(svc.Canary, "profile-v2", handlers.GetProfileV2(db)), getUserProfile())
+//
+// Behind AuthRequired requests are assigned by user, otherwise by client IP. Canary responses
+// carry X-Canary-Rollout. A nil router always uses the stable handler.
+func Canary(router *canary.Router, rollout string, canaryHandler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if router == nil {
+			c.Next()
+			return
+		}
+
+		req := canary.Request{UserID: requestctx.UserID(c), ClientIP: c.ClientIP()}
+		if header := router.Header(); header != "" {
+			req.Override = c.GetHeader(header)
+		}
+		variant := variantStable
+		if router.UseCanary(rollout, req) {
+			variant = variantCanary
+		}
+
+		// Panics are counted as 500s and left to the recovery middleware
+		completed := false
+		defer func() {
+			status := http.StatusInternalServerError
+			if completed {
+				status = handlerStatus(c)
+			}
+			canaryRequestsTotal.WithLabelValues(rollout, variant, strconv.Itoa(status)).Inc()
+		}()
+
+		if variant == variantCanary {
+			c.Header(CanaryRolloutHeader, rollout)
+			canaryHandler(c)
+			c.Abort()
+		} else {
+			c.Next()
+		}
+		completed = true
+	}
+}
+
+// handlerStatus returns the status of the response, including the error response ErrorHandler
+// writes later for errors added with c.Error.
+func handlerStatus(c *gin.Context) int {
+	if len(c.Errors) > 0 && !c.Writer.Written() {
+		return toAppError(c.Errors.Last().Err).Status()
+	}
+	return c.Writer.Status()
+}
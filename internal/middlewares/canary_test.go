@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/canary"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+)
+
+func TestCanary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := canary.NewRouter(map[string]float64{"items-v2": 0}, []uint{7}, "X-Canary")
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/items", func(c *gin.Context) {
+		if c.GetHeader("X-User") == "7" {
+			requestctx.SetUser(c, &models.User{ID: 7}, time.Time{})
+		}
+		c.Next()
+	}, Canary(router, "items-v2", func(c *gin.Context) {
+		_ = c.Error(apperrors.NotFound("Item not found", "v2"))
+	}), func(c *gin.Context) {
+		c.String(http.StatusOK, "stable")
+	})
+	r.GET("/plain", Canary(nil, "items-v2", func(c *gin.Context) {
+		c.String(http.StatusOK, "canary")
+	}), func(c *gin.Context) {
+		c.String(http.StatusOK, "stable")
+	})
+
+	perform := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	stable := testutil.ToFloat64(canaryRequestsTotal.WithLabelValues("items-v2", variantStable, "200"))
+	canaries := testutil.ToFloat64(canaryRequestsTotal.WithLabelValues("items-v2", variantCanary, "404"))
+
+	if w := perform("/items", nil); w.Code != http.StatusOK || w.Body.String() != "stable" || w.Header().Get(CanaryRolloutHeader) != "" {
+		t.Errorf("default request: %d %q, want the stable handler", w.Code, w.Body.String())
+	}
+	for _, headers := range []map[string]string{{"X-User": "7"}, {"X-Canary": "always"}} {
+		w := perform("/items", headers)
+		if w.Code != http.StatusNotFound || w.Header().Get(CanaryRolloutHeader) != "items-v2" {
+			t.Errorf("request with %v: %d (%s=%q), want the canary", headers, w.Code, CanaryRolloutHeader, w.Header().Get(CanaryRolloutHeader))
+		}
+	}
+	if w := perform("/items", map[string]string{"X-User": "7", "X-Canary": "never"}); w.Body.String() != "stable" {
+		t.Errorf("forced stable request got %d %q", w.Code, w.Body.String())
+	}
+	if w := perform("/plain", map[string]string{"X-Canary": "always"}); w.Body.String() != "stable" {
+		t.Errorf("nil router served %q, want the stable handler", w.Body.String())
+	}
+
+	if got := testutil.ToFloat64(canaryRequestsTotal.WithLabelValues("items-v2", variantStable, "200")) - stable; got != 2 {
+		t.Errorf("stable counted %v requests, want 2", got)
+	}
+	// The status of errors is counted before ErrorHandler writes them
+	if got := testutil.ToFloat64(canaryRequestsTotal.WithLabelValues("items-v2", variantCanary, "404")) - canaries; got != 2 {
+		t.Errorf("canary counted %v 404s, want 2", got)
+	}
+}
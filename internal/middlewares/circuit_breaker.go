@@ -0,0 +1,49 @@
+// Package middlewares provides circuit breaker functionality.
+package middlewares
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/circuitbreaker"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// CircuitBreaker fails fast with 503 Service Unavailable while the breaker of any of the
+// named dependencies is open, instead of letting requests time out against it.
+// With no names, every breaker in the registry is checked.
+func CircuitBreaker(registry *circuitbreaker.Registry, names ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		breakers := registry.All()
+		if len(names) > 0 {
+			breakers = breakers[:0:0]
+			for _, name := range names {
+				if b, ok := registry.Lookup(name); ok {
+					breakers = append(breakers, b)
+				}
+			}
+		}
+
+		for _, b := range breakers {
+			if b.State() != circuitbreaker.StateOpen {
+				continue
+			}
+
+			if retryAfter := b.RetryAfter(); retryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			}
+			logger.WithFields(map[string]interface{}{
+				"dependency": b.Name(),
+				"endpoint":   c.Request.URL.Path,
+			}).Warn("Request rejected: dependency circuit breaker is open")
+			response.ErrorResponse(c, response.CodeServiceUnavailable, "Service temporarily unavailable", "A required dependency is unavailable, please retry later")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
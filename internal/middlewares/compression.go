@@ -0,0 +1,130 @@
+package middlewares
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Compression gzips response bodies for clients that accept it. level is a compress/gzip level
+// (gzip.DefaultCompression when out of range). Responses that already set a Content-Encoding,
+// event streams, byte ranges (206), and bodiless responses are sent unchanged.
+func Compression(level int) gin.HandlerFunc {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	pool := &sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, level)
+		return w
+	}}
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || !acceptsGzip(c.Request) {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Accept-Encoding")
+		w := &gzipWriter{ResponseWriter: c.Writer, pool: pool}
+		c.Writer = w
+		// On panic the buffered output is dropped and the writer restored, so the recovery
+		// middleware can still write its error response when nothing reached the client
+		completed := false
+		defer func() {
+			w.close(completed)
+			c.Writer = w.ResponseWriter
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header lists gzip without q=0.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
+
+// gzipWriter decides on the first body write whether to compress, so that handlers can still
+// set Content-Encoding or Content-Type before writing.
+type gzipWriter struct {
+	gin.ResponseWriter
+	pool    *sync.Pool
+	gz      *gzip.Writer
+	decided bool
+}
+
+func (w *gzipWriter) start() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	header := w.ResponseWriter.Header()
+	status := w.ResponseWriter.Status()
+	if header.Get("Content-Encoding") != "" ||
+		strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") ||
+		status == http.StatusNoContent || status == http.StatusNotModified ||
+		status == http.StatusPartialContent {
+		return
+	}
+
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	w.gz = w.pool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+}
+
+// Write implements io.Writer.
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	w.start()
+	if w.gz == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.gz.Write(data)
+}
+
+// WriteString implements io.StringWriter.
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends the data compressed so far, for streaming handlers.
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack hands the raw connection over, as for WebSocket upgrades; nothing is compressed then.
+func (w *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// close writes the gzip footer, unless the handler did not complete, and returns the
+// compressor to the pool.
+func (w *gzipWriter) close(completed bool) {
+	if w.gz == nil {
+		return
+	}
+	if completed {
+		_ = w.gz.Close()
+	}
+	w.gz.Reset(nil)
+	w.pool.Put(w.gz)
+	w.gz = nil
+}
@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func compressionRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.Use(Compression(gzip.BestSpeed))
+	r.GET("/json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": strings.Repeat("hello ", 100)})
+	})
+	r.GET("/empty", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.String(http.StatusOK, "already compressed")
+	})
+	r.GET("/partial", func(c *gin.Context) {
+		// Content-Range counts bytes of the uncompressed file
+		c.Header("Content-Range", "bytes 0-9/100")
+		c.String(http.StatusPartialContent, "0123456789")
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+	return r
+}
+
+func getWithEncoding(r http.Handler, path, acceptEncoding string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCompressionGzipsWhenAccepted(t *testing.T) {
+	w := getWithEncoding(compressionRouter(), "/json", "br;q=1.0, gzip")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("headers = %v, want gzip encoding", w.Header())
+	}
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil || !strings.Contains(string(body), "hello hello") {
+		t.Fatalf("decompressed body = %q, %v", body, err)
+	}
+}
+
+func TestCompressionSkipsWhenNotApplicable(t *testing.T) {
+	r := compressionRouter()
+	cases := []struct{ path, acceptEncoding string }{
+		{"/json", ""},
+		{"/json", "gzip;q=0"},
+		{"/empty", "gzip"},
+		{"/encoded", "gzip"},
+		{"/partial", "gzip"},
+	}
+	for _, tc := range cases {
+		w := getWithEncoding(r, tc.path, tc.acceptEncoding)
+		if got := w.Header().Get("Content-Encoding"); got == "gzip" {
+			t.Errorf("%s with Accept-Encoding %q was gzipped", tc.path, tc.acceptEncoding)
+		}
+	}
+}
+
+func TestCompressionPanicStillReturnsJSONError(t *testing.T) {
+	w := getWithEncoding(compressionRouter(), "/panic", "gzip")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	if w.Header().Get("Content-Encoding") != "" || !strings.Contains(w.Body.String(), "INTERNAL_SERVER_ERROR") {
+		t.Fatalf("expected a plain JSON error, got %v %q", w.Header(), w.Body.String())
+	}
+}
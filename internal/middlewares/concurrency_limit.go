@@ -0,0 +1,83 @@
+// Package middlewares provides load shedding functionality.
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/metrics"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+var (
+	inFlightRequests = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Requests currently counted by the concurrency limiter.",
+	})
+	shedRequestsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "http_requests_shed_total",
+		Help: "Requests rejected because the concurrency limit was reached or the runtime watchdog reported the server overloaded.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(inFlightRequests, shedRequestsTotal)
+}
+
+// ConcurrencyLimit sheds load by answering 503 while max requests are already being served,
+// instead of queueing them until every request times out. Requests for which exempt returns
+// true, such as health probes, are neither limited nor counted, so an orchestrator keeps seeing
+// a busy instance as healthy. A max of zero or less disables the limit.
+func ConcurrencyLimit(max int, exempt func(path string) bool) gin.HandlerFunc {
+	return LoadShedding(max, nil, exempt)
+}
+
+// LoadShedding is ConcurrencyLimit also answering 503 while overloaded, which may be nil,
+// returns true, as the runtime watchdog does while the heap or the goroutine count is above its
+// limit.
+func LoadShedding(max int, overloaded func() bool, exempt func(path string) bool) gin.HandlerFunc {
+	if max <= 0 && overloaded == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	var slots chan struct{}
+	if max > 0 {
+		slots = make(chan struct{}, max)
+	}
+	return func(c *gin.Context) {
+		if exempt != nil && exempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		if overloaded != nil && overloaded() {
+			shed(c, "The server is low on resources, please retry later")
+			return
+		}
+		if slots == nil {
+			c.Next()
+			return
+		}
+		select {
+		case slots <- struct{}{}:
+		default:
+			shed(c, "Too many concurrent requests, please retry later")
+			return
+		}
+		inFlightRequests.Inc()
+		defer func() {
+			<-slots
+			inFlightRequests.Dec()
+		}()
+
+		c.Next()
+	}
+}
+
+// shed rejects the request with a 503 the client may retry.
+func shed(c *gin.Context, details string) {
+	shedRequestsTotal.Inc()
+	c.Header("Retry-After", "1")
+	response.ErrorResponse(c, response.CodeServerOverloaded, "Server is overloaded", details)
+	c.Abort()
+}
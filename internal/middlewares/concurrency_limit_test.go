@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConcurrencyLimitShedsExceptExemptPaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	r := gin.New()
+	r.Use(ConcurrencyLimit(1, func(path string) bool { return strings.HasPrefix(path, "/health") }))
+	r.GET("/slow", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.Status(http.StatusOK)
+	})
+	r.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/health/live", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	perform := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		perform("/slow")
+	}()
+	<-entered
+
+	w := perform("/fast")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After at the limit, got %d", w.Code)
+	}
+	if w := perform("/health/live"); w.Code != http.StatusOK {
+		t.Fatalf("expected health checks to bypass the limit, got %d", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+	if w := perform("/fast"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 once a slot is free, got %d", w.Code)
+	}
+}
+
+func TestLoadSheddingWhileOverloaded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var overloaded atomic.Bool
+	overloaded.Store(true)
+
+	r := gin.New()
+	r.Use(LoadShedding(0, overloaded.Load, func(path string) bool { return path == "/health" }))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	perform := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	if w := perform("/"); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "SERVER_OVERLOADED") {
+		t.Fatalf("expected 503 SERVER_OVERLOADED while overloaded, got %d %s", w.Code, w.Body.String())
+	}
+	if w := perform("/health"); w.Code != http.StatusOK {
+		t.Fatalf("expected health checks to bypass shedding, got %d", w.Code)
+	}
+	overloaded.Store(false)
+	if w := perform("/"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 once recovered, got %d", w.Code)
+	}
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// RequireConsent rejects the requests of users who have not accepted the current version of
+// the terms of service with 451 TERMS_NOT_ACCEPTED. An empty version disables the check.
+// It must run after AuthRequired.
+func RequireConsent(db *gorm.DB, version, termsURL string) gin.HandlerFunc {
+	details := "Accept version " + version + " of the terms of service to continue"
+	if termsURL != "" {
+		details += " (" + termsURL + ")"
+	}
+
+	return func(c *gin.Context) {
+		if version == "" {
+			c.Next()
+			return
+		}
+
+		var accepted int64
+		err := db.WithContext(c.Request.Context()).Model(&models.Consent{}).
+			Where("user_id = ? AND kind = ? AND version = ?", requestctx.UserID(c), models.ConsentTerms, version).
+			Count(&accepted).Error
+		if err != nil {
+			requestctx.Logger(c).WithField("error", err.Error()).Error("Failed to check terms consent")
+			response.InternalServerError(c, "Could not verify consent", "Database error occurred")
+			c.Abort()
+			return
+		}
+		if accepted == 0 {
+			response.ErrorResponse(c, response.CodeTermsNotAccepted, "Terms not accepted", details)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
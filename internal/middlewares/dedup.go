@@ -0,0 +1,126 @@
+// Package middlewares provides request deduplication functionality.
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+var dedupedRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_deduplicated_requests_total",
+	Help: "GET requests answered with the response of an identical concurrent request.",
+}, []string{"route"})
+
+func init() {
+	metrics.Registry.MustRegister(dedupedRequestsTotal)
+}
+
+// replayExcludedHeaders are set by the outer middlewares of each request, not by the handler,
+// so they are never copied from the shared response.
+var replayExcludedHeaders = map[string]bool{
+	"Content-Encoding": true,
+	"Content-Length":   true,
+	"Vary":             true,
+}
+
+// sharedResponse is the response of the handler run that identical requests waited for.
+type sharedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// Deduplicate runs the handler once for simultaneous identical GET requests to the given route
+// templates (as in c.FullPath, e.g. "/api/admin/users"), and answers the requests that arrived
+// while it ran with a copy of its response. Requests are identical when they have the same URL,
+// query string, Accept and Accept-Encoding headers, and Authorization and X-API-Key headers, so
+// a response is only ever shared between clients presenting the same credentials. Requests
+// without credentials are never deduplicated.
+//
+// Only responses written by the handler with a status below 500 are shared. When the first
+// request fails, panics, reports an error for ErrorHandler, or writes more than 1 MiB, each
+// waiting request runs the handler itself.
+func Deduplicate(routes []string) gin.HandlerFunc {
+	enabled := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		enabled[route] = true
+	}
+	var group singleflight.Group
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		key, hasCredentials := dedupKey(c)
+		if c.Request.Method != http.MethodGet || !enabled[route] || !hasCredentials {
+			c.Next()
+			return
+		}
+
+		leader := false
+		var recovered interface{}
+		v, _, _ := group.Do(key, func() (result interface{}, err error) {
+			leader = true
+			defer func() {
+				// Re-panicked below with the original value, so that waiting requests are not
+				// failed by singleflight and ErrorHandler sees the same value
+				recovered = recover()
+			}()
+			return runShared(c), nil
+		})
+		if recovered != nil {
+			panic(recovered)
+		}
+		if leader {
+			return
+		}
+
+		shared, _ := v.(*sharedResponse)
+		if shared == nil {
+			c.Next()
+			return
+		}
+		dedupedRequestsTotal.WithLabelValues(route).Inc()
+		for name, values := range shared.header {
+			c.Writer.Header()[name] = append([]string(nil), values...)
+		}
+		c.Status(shared.status)
+		_, _ = c.Writer.Write(shared.body)
+		c.Abort()
+	}
+}
+
+// runShared runs the handler chain and returns its response, or nil if it cannot be shared.
+func runShared(c *gin.Context) *sharedResponse {
+	before := c.Writer.Header().Clone()
+	writer := &captureWriter{ResponseWriter: c.Writer, limit: maxCachedResponseSize}
+	c.Writer = writer
+	defer func() { c.Writer = writer.ResponseWriter }()
+
+	c.Next()
+
+	if !writer.Written() || writer.overflow || len(c.Errors) > 0 || writer.Status() >= http.StatusInternalServerError {
+		return nil
+	}
+
+	// Only the headers the handler set: the outer middlewares set theirs on every request
+	header := make(http.Header)
+	for name, values := range writer.Header() {
+		if replayExcludedHeaders[name] || slices.Equal(before[name], values) {
+			continue
+		}
+		header[name] = append([]string(nil), values...)
+	}
+	return &sharedResponse{status: writer.Status(), header: header, body: writer.body.Bytes()}
+}
+
+// dedupKey returns the key identical requests share, or false when the request carries no
+// credentials.
+func dedupKey(c *gin.Context) (string, bool) {
+	credentials, ok := credentialHash(c)
+	return c.Request.URL.RequestURI() + "|" + c.GetHeader("Accept") + "|" + c.GetHeader("Accept-Encoding") + "|" + credentials, ok
+}
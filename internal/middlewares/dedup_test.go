@@ -0,0 +1,120 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dedupRouter serves /items/:id, which blocks until release is closed.
+func dedupRouter(calls *int32, release chan struct{}, status int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Header("X-Request-ID", c.GetHeader("X-Test-ID"))
+		c.Next()
+	})
+	r.Use(Deduplicate([]string{"/items/:id"}))
+	r.GET("/items/:id", func(c *gin.Context) {
+		atomic.AddInt32(calls, 1)
+		<-release
+		c.Header("X-Item", c.Param("id"))
+		c.JSON(status, gin.H{"id": c.Param("id")})
+	})
+	r.GET("/other", func(c *gin.Context) {
+		atomic.AddInt32(calls, 1)
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+// concurrentGets sends n requests for path with the given Authorization headers, or other
+// headers as "Name: value", at the same time and releases the handler once they have all
+// arrived.
+func concurrentGets(r *gin.Engine, release chan struct{}, path string, auths ...string) []*httptest.ResponseRecorder {
+	recorders := make([]*httptest.ResponseRecorder, len(auths))
+	var wg sync.WaitGroup
+	for i, auth := range auths {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(i int, auth string) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if name, value, ok := strings.Cut(auth, ": "); ok {
+				req.Header.Set(name, value)
+			} else if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			req.Header.Set("X-Test-ID", string(rune('a'+i)))
+			r.ServeHTTP(recorders[i], req)
+		}(i, auth)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return recorders
+}
+
+func TestDeduplicate_SharesOneHandlerRun(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	r := dedupRouter(&calls, release, http.StatusOK)
+
+	recorders := concurrentGets(r, release, "/items/1", "Bearer a", "Bearer a", "Bearer a", "Bearer b")
+
+	// One run for the three identical requests, one for the other credentials
+	if calls != 2 {
+		t.Fatalf("handler ran %d times, want 2", calls)
+	}
+	for i, w := range recorders {
+		if w.Code != http.StatusOK || w.Body.String() != `{"id":"1"}` || w.Header().Get("X-Item") != "1" {
+			t.Errorf("request %d: %d %q X-Item=%q", i, w.Code, w.Body.String(), w.Header().Get("X-Item"))
+		}
+		// Headers of the outer middlewares are not copied from the shared response
+		if got, want := w.Header().Get("X-Request-ID"), string(rune('a'+i)); got != want {
+			t.Errorf("request %d: X-Request-ID = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestDeduplicate_FailedResponsesAreNotShared(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	r := dedupRouter(&calls, release, http.StatusServiceUnavailable)
+
+	concurrentGets(r, release, "/items/1", "Bearer a", "Bearer a", "Bearer a")
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want each waiting request to run it", calls)
+	}
+}
+
+func TestDeduplicate_OnlyConfiguredRoutes(t *testing.T) {
+	var calls int32
+	r := dedupRouter(&calls, make(chan struct{}), http.StatusOK)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestDeduplicate_CredentialsAreNotShared(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	r := dedupRouter(&calls, release, http.StatusOK)
+
+	concurrentGets(r, release, "/items/1", "X-API-Key: k1", "X-API-Key: k1", "X-API-Key: k2", "", "")
+	// One run for the two identical API keys, one for the other, one per anonymous request
+	if calls != 4 {
+		t.Errorf("handler ran %d times, want 4", calls)
+	}
+}
@@ -0,0 +1,170 @@
+// Package middlewares provides graceful degradation functionality.
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/pkg/cache"
+	"github.com/yeferson59/gin-template/pkg/cachecontrol"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// maxCachedResponseSize bounds the body size kept for degraded mode.
+const maxCachedResponseSize = 1 << 20
+
+// cachedResponse is a successful GET response saved for degraded mode, with the user it was
+// served to.
+type cachedResponse struct {
+	UserID      uint      `json:"user_id"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	StoredAt    time.Time `json:"stored_at"`
+}
+
+// captureWriter copies up to limit bytes of the response body while it is written to the
+// client.
+type captureWriter struct {
+	gin.ResponseWriter
+	limit    int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.capture(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *captureWriter) capture(p []byte) {
+	if w.overflow || w.body.Len()+len(p) > w.limit {
+		w.overflow = true
+		return
+	}
+	w.body.Write(p)
+}
+
+// DegradedMode keeps the API partially available while the database is down.
+// While available reports true, successful GET responses are copied into store for ttl.
+// While it reports false, GET requests are answered from that copy (marked stale with a
+// Warning header) and all other requests get 503 instead of failing with database errors.
+//
+// Only responses to requests authenticated with a bearer token are cached, keyed by URL,
+// Accept, and a hash of the credentials, so a stale response is only ever returned to a client
+// presenting the same token. As the database cannot be checked, the token is verified before
+// replaying: its signature must be valid, it must not have expired, and it must belong to the
+// user the response was served to.
+func DegradedMode(store cache.Cache, ttl time.Duration, available func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		isGET := c.Request.Method == http.MethodGet
+		key, hasCredentials := degradedCacheKey(c)
+		token := bearerToken(c)
+
+		if !available() {
+			if isGET && hasCredentials && token != "" && serveStale(c, store, key, token) {
+				c.Abort()
+				return
+			}
+
+			response.ErrorResponse(c, response.CodeServiceUnavailable, "Service degraded", "The database is temporarily unavailable, please retry later")
+			c.Abort()
+			return
+		}
+
+		if !isGET || !hasCredentials || token == "" {
+			c.Next()
+			return
+		}
+
+		writer := &captureWriter{ResponseWriter: c.Writer, limit: maxCachedResponseSize}
+		c.Writer = writer
+		c.Next()
+
+		// Only responses the token was authenticated for, not public routes sent a token
+		userID := requestctx.UserID(c)
+		if userID == 0 || writer.Status() != http.StatusOK || writer.overflow ||
+			cachecontrol.HasDirective(writer.Header().Get("Cache-Control"), "no-store") {
+			return
+		}
+
+		encoded, err := json.Marshal(cachedResponse{
+			UserID:      userID,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+			StoredAt:    time.Now(),
+		})
+		if err == nil {
+			err = store.Set(c.Request.Context(), key, encoded, ttl)
+		}
+		if err != nil {
+			logger.WithField("error", err.Error()).Warn("Failed to cache response for degraded mode")
+		}
+	}
+}
+
+// serveStale writes the cached copy of the response, reporting whether there was one served
+// to the user of the still valid token.
+func serveStale(c *gin.Context, store cache.Cache, key, token string) bool {
+	data, ok, err := store.Get(c.Request.Context(), key)
+	if err != nil || !ok {
+		return false
+	}
+
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return false
+	}
+	claims, err := auth.ValidateJWT(token)
+	if err != nil || claims.UserID != cached.UserID {
+		return false
+	}
+
+	c.Header("Warning", `110 - "Response is Stale"`)
+	c.Header("X-Cache", "STALE")
+	c.Header("Age", strconv.Itoa(int(time.Since(cached.StoredAt).Seconds())))
+	c.Data(http.StatusOK, cached.ContentType, cached.Body)
+	return true
+}
+
+// bearerToken returns the token of a bearer Authorization header, or "".
+func bearerToken(c *gin.Context) string {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+	return token
+}
+
+// degradedCacheKey returns the cache key of the request, or false when it carries no
+// credentials.
+func degradedCacheKey(c *gin.Context) (string, bool) {
+	credentials, ok := credentialHash(c)
+	return "degraded:" + c.Request.URL.RequestURI() + "|" + c.GetHeader("Accept") + "|" + credentials, ok
+}
+
+// credentialHash returns a hash of the credentials of the request, its Authorization and
+// X-API-Key headers, or false when it carries neither.
+func credentialHash(c *gin.Context) (string, bool) {
+	authorization, apiKey := c.GetHeader("Authorization"), c.GetHeader(auth.APIKeyHeader)
+	if authorization == "" && apiKey == "" {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(authorization + "\x00" + apiKey))
+	return hex.EncodeToString(sum[:]), true
+}
@@ -0,0 +1,124 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/cache"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+)
+
+// degradedRouter serves /items behind DegradedMode and a stand-in for AuthRequired that
+// authenticates valid bearer tokens without a database.
+func degradedRouter(available *bool, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(DegradedMode(cache.NewMemory(10), time.Minute, func() bool { return *available }))
+	authenticate := func(c *gin.Context) {
+		if claims, err := auth.ValidateJWT(bearerToken(c)); err == nil {
+			requestctx.SetUser(c, &models.User{ID: claims.UserID}, time.Time{})
+		}
+	}
+	r.GET("/items", authenticate, func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusOK, gin.H{"items": []int{1, 2, 3}})
+	})
+	r.GET("/export", authenticate, func(c *gin.Context) {
+		c.Header("Cache-Control", "private, no-store")
+		c.String(http.StatusOK, "export")
+	})
+	r.GET("/public", func(c *gin.Context) { c.String(http.StatusOK, "public") })
+	r.POST("/items", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	return r
+}
+
+func degradedRequest(r *gin.Engine, method, path, header, value string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func newToken(t *testing.T, userID uint) string {
+	t.Helper()
+	token, err := auth.GenerateJWT(userID, "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func TestDegradedModeServesStaleResponses(t *testing.T) {
+	t.Setenv("JWT_SECRET", "degraded-mode-test-secret-0123456789")
+	available, calls := true, 0
+	r := degradedRouter(&available, &calls)
+	tokenA, tokenB := newToken(t, 1), newToken(t, 2)
+
+	if w := degradedRequest(r, http.MethodGet, "/items", "Authorization", tokenA); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 while available, got %d", w.Code)
+	}
+
+	available = false
+
+	w := degradedRequest(r, http.MethodGet, "/items", "Authorization", tokenA)
+	if w.Code != http.StatusOK || w.Header().Get("X-Cache") != "STALE" || w.Body.String() != `{"items":[1,2,3]}` {
+		t.Fatalf("expected stale cached response, got %d %q (X-Cache=%q)", w.Code, w.Body.String(), w.Header().Get("X-Cache"))
+	}
+	if calls != 1 {
+		t.Fatalf("expected the handler not to run in degraded mode, ran %d times", calls)
+	}
+
+	if w := degradedRequest(r, http.MethodGet, "/items", "Authorization", tokenB); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for other credentials, got %d", w.Code)
+	}
+	if w := degradedRequest(r, http.MethodPost, "/items", "Authorization", tokenA); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for writes, got %d", w.Code)
+	}
+}
+
+func TestDegradedModeVerifiesTokenBeforeReplaying(t *testing.T) {
+	t.Setenv("JWT_SECRET", "degraded-mode-test-secret-0123456789")
+	available, calls := true, 0
+	r := degradedRouter(&available, &calls)
+	token := newToken(t, 1)
+	degradedRequest(r, http.MethodGet, "/items", "Authorization", token)
+
+	// The token no longer verifies, as when it has expired or the secret was rotated
+	t.Setenv("JWT_SECRET", "another-secret-0123456789-abcdefghij")
+	available = false
+	if w := degradedRequest(r, http.MethodGet, "/items", "Authorization", token); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for a token that no longer verifies, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestDegradedModeCachesOnlyAuthenticatedResponses(t *testing.T) {
+	t.Setenv("JWT_SECRET", "degraded-mode-test-secret-0123456789")
+	available, calls := true, 0
+	r := degradedRouter(&available, &calls)
+	token := newToken(t, 1)
+
+	degradedRequest(r, http.MethodGet, "/items", "X-API-Key", "k1")
+	degradedRequest(r, http.MethodGet, "/public", "Authorization", token)
+	degradedRequest(r, http.MethodGet, "/export", "Authorization", token)
+	available = false
+
+	for _, tc := range []struct{ path, header, value string }{
+		{"/items", "X-API-Key", "k1"},       // API keys cannot be verified without the database
+		{"/public", "Authorization", token}, // not authenticated by the route
+		{"/export", "Authorization", token}, // marked no-store
+		{"/items", "", ""},                  // no credentials
+	} {
+		if w := degradedRequest(r, http.MethodGet, tc.path, tc.header, tc.value); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s with %s: expected 503, got %d %q", tc.path, tc.header, w.Code, w.Body.String())
+		}
+	}
+}
@@ -2,49 +2,182 @@
 package middlewares
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"math"
+	"mime"
+	"net/http"
 	"runtime/debug"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/circuitbreaker"
+	"github.com/yeferson59/gin-template/pkg/crashreport"
 	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/patch"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/requestid"
 	"github.com/yeferson59/gin-template/pkg/response"
 )
 
 // ErrorHandler returns a middleware that handles panics and errors gracefully.
+//
+// Errors that handlers add with c.Error without writing a response are converted to one:
+// apperrors.Error values use their status and code, validation errors become 400s, missing
+// records 404s, duplicate keys and foreign key violations 409s, deadlocks 409s with a
+// Retry-After, an unreachable database 503, and anything else a 500 whose cause is logged but
+// not returned.
+//
+// Panics are only reported through the logger, which redacts them; Gin's own dump is disabled
+// because it writes the panic value and request headers such as Cookie unfiltered.
 func ErrorHandler() gin.HandlerFunc {
-	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		// Log the panic with stack trace
-		if recovered != nil {
-			stack := debug.Stack()
-			logger.WithField("panic", recovered).WithField("stack", string(stack)).Error("Panic recovered")
-
-			// Convert the recovered value to a string
-			var errStr string
-			switch v := recovered.(type) {
-			case error:
-				errStr = v.Error()
-			case string:
-				errStr = v
-			default:
-				errStr = fmt.Sprintf("%v", v)
-			}
+	return ErrorHandlerWithReports(nil)
+}
 
-			// Log the error string for debugging
-			logger.WithField("error_details", errStr).Error("Panic details")
+// ErrorHandlerWithReports is ErrorHandler saving a crash report of each recovered panic to
+// reports, whose ID is returned in the details of the error so that users can quote it. A nil
+// reports only logs panics.
+func ErrorHandlerWithReports(reports *crashreport.Store) gin.HandlerFunc {
+	recovery := gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered interface{}) {
+		recoverPanic(c, recovered, reports)
+	})
+	return func(c *gin.Context) {
+		recovery(c)
+		writeHandlerError(c)
+	}
+}
 
-			// Return a generic error response to the client
-			response.InternalServerError(c, "Internal server error", "An unexpected error occurred")
+// writeHandlerError writes the response for the last error added to c, unless one was written.
+func writeHandlerError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	appErr := toAppError(c.Errors.Last().Err)
+	if appErr.Status() >= http.StatusInternalServerError {
+		entry := logger.WithFields(map[string]interface{}{
+			"status": appErr.Status(),
+			"code":   appErr.Code.Code,
+			"path":   c.FullPath(),
+		})
+		if appErr.Err != nil {
+			entry = entry.WithField("error", appErr.Err.Error())
 		}
-	})
+		entry.Error(appErr.Message)
+	}
+	if appErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+	response.WriteError(c, appErr.Code, appErr.Message, appErr.Details, appErr.Fields)
+}
+
+// toAppError maps database errors to their kind before falling back to apperrors.As.
+func toAppError(err error) *apperrors.Error {
+	var appErr *apperrors.Error
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apperrors.NotFound("Resource not found", "").Wrap(err)
+	case database.IsDuplicateKeyError(err):
+		return apperrors.Conflict("Resource already exists", "").Wrap(err)
+	case database.IsForeignKeyError(err):
+		return apperrors.Conflict("Resource conflicts with related records", "").Wrap(err)
+	case database.IsDeadlockError(err):
+		return apperrors.New(response.CodeConcurrentUpdate, "Concurrent update").WithRetryAfter(time.Second).Wrap(err)
+	case errors.Is(err, circuitbreaker.ErrOpen) || database.IsConnectionError(err):
+		return apperrors.Unavailable("Service temporarily unavailable", "A required dependency is unavailable, please retry later").Wrap(err)
+	default:
+		return apperrors.As(err)
+	}
+}
+
+// bodyHeaders describe a body the handler was about to write and must not be sent with the
+// JSON error that replaces it.
+var bodyHeaders = []string{"Content-Type", "Content-Length", "Content-Disposition", "Content-Encoding"}
+
+// crashReportTimeout bounds the time spent saving a crash report before responding.
+const crashReportTimeout = 5 * time.Second
+
+// recoverPanic logs a recovered panic, saves its crash report when reports is not nil and
+// returns a generic 500 that includes the request ID and the report ID.
+// When the handler had already started the response, as streaming handlers do, a JSON body
+// would be appended to it, so the connection is aborted instead and the client sees a
+// truncated response rather than one that looks complete.
+func recoverPanic(c *gin.Context, recovered interface{}, reports *crashreport.Store) {
+	// A deliberate abort, as used by httputil.ReverseProxy, is handled by net/http
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
+
+	requestID := requestctx.RequestID(c)
+	if requestID == "" {
+		// The panic happened before the RequestID middleware ran
+		requestID = requestid.New()
+		requestctx.SetRequestID(c, requestID)
+	}
+
+	stack := debug.Stack()
+	fields := map[string]interface{}{
+		"panic":      recovered,
+		"stack":      string(stack),
+		"request_id": requestID,
+		"method":     c.Request.Method,
+		"path":       c.Request.URL.Path,
+		"written":    c.Writer.Written(),
+	}
+	details := "An unexpected error occurred"
+	if reports != nil {
+		report := crashreport.New(recovered, stack, c.Request)
+		report.Request.RequestID = requestID
+		report.Request.Route = c.FullPath()
+		report.Request.ClientIP = c.ClientIP()
+		report.Request.UserID = requestctx.UserID(c)
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), crashReportTimeout)
+		err := reports.Save(ctx, report)
+		cancel()
+		if err != nil {
+			fields["crash_report_error"] = err.Error()
+		} else {
+			fields["crash_report"] = report.ID
+			details = "An unexpected error occurred (crash report " + report.ID + ")"
+		}
+	}
+	logger.WithFields(fields).Error("Panic recovered")
+
+	c.Abort()
+	if c.Writer.Written() {
+		panic(http.ErrAbortHandler)
+	}
+
+	header := c.Writer.Header()
+	for _, name := range bodyHeaders {
+		header.Del(name)
+	}
+	header.Set(requestid.Header, requestID)
+
+	// Return a generic error response to the client
+	response.InternalServerError(c, "Internal server error", details)
 }
 
 // RequestLogger returns a middleware that logs HTTP requests with structured logging.
 func RequestLogger() gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+	return RequestLoggerWithDebug(nil)
+}
+
+// RequestLoggerWithDebug is RequestLogger that also marks the requests of the routes debugged
+// by routes, which may be nil, so that requestctx.Logger logs their debug entries.
+func RequestLoggerWithDebug(routes *logger.RouteDebug) gin.HandlerFunc {
+	log := gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		// Custom log format using structured logging
-		logger.WithFields(map[string]interface{}{
+		fields := map[string]interface{}{
 			"client_ip":   param.ClientIP,
 			"timestamp":   param.TimeStamp.Format("2006-01-02 15:04:05"),
 			"method":      param.Method,
@@ -54,10 +187,26 @@ func RequestLogger() gin.HandlerFunc {
 			"latency":     param.Latency.String(),
 			"user_agent":  param.Request.UserAgent(),
 			"error":       param.ErrorMessage,
-		}).Info("HTTP Request")
+		}
+		if loc, ok := param.Keys[security.LocationKey].(*security.Location); ok && loc != nil {
+			fields["country"] = loc.Country
+			if loc.ASN != 0 {
+				fields["asn"] = loc.ASN
+			}
+		}
+		logger.WithFields(fields).Info("HTTP Request")
 
 		return ""
 	})
+	if routes == nil {
+		return log
+	}
+	return func(c *gin.Context) {
+		if routes.Enabled(c.FullPath()) {
+			requestctx.SetDebugging(c)
+		}
+		log(c)
+	}
 }
 
 // SecurityHeaders adds security headers to responses.
@@ -75,27 +224,45 @@ func SecurityHeaders() gin.HandlerFunc {
 	}
 }
 
-// RequestID adds a unique request ID to each request.
-func RequestID() gin.HandlerFunc {
+// RequestID assigns every request an ID, returned in X-Request-ID and stored in both the
+// Gin context ("request_id") and the request context for outbound propagation.
+// When trustInbound is true, a valid client-supplied X-Request-ID is kept; otherwise the trace ID
+// of a valid W3C traceparent header is used, and as a last resort a new UUIDv7 is generated.
+func RequestID(trustInbound bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
+		requestID := ""
+		traceparent := c.GetHeader(requestid.TraceparentHeader)
+		if !requestid.ValidTraceparent(traceparent) {
+			traceparent = ""
+		}
+
+		if inbound := c.GetHeader(requestid.Header); trustInbound && inbound != "" {
+			if requestid.Valid(inbound) {
+				requestID = inbound
+			} else {
+				requestctx.Logger(c).WithField("client_ip", c.ClientIP()).Debug("Ignoring invalid inbound X-Request-ID")
+			}
+		}
+		if requestID == "" && trustInbound {
+			requestID, _ = requestid.FromTraceparent(traceparent)
+		}
 		if requestID == "" {
-			requestID = generateRequestID()
+			requestID = requestid.New()
 		}
 
-		c.Header("X-Request-ID", requestID)
-		c.Set("request_id", requestID)
+		ctx := requestid.WithContext(c.Request.Context(), requestID)
+		if traceparent != "" {
+			ctx = requestid.WithTraceparent(ctx, traceparent)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Header(requestid.Header, requestID)
+		requestctx.SetRequestID(c, requestID)
 
 		c.Next()
 	}
 }
 
-// generateRequestID generates a simple request ID.
-// In production, you might want to use a more sophisticated ID generation
-func generateRequestID() string {
-	return fmt.Sprintf("req_%d", time.Now().UnixNano())
-}
-
 // Timeout adds a timeout to requests.
 func Timeout() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -107,11 +274,26 @@ func Timeout() gin.HandlerFunc {
 }
 
 // ValidateContentType validates the Content-Type header for specific endpoints.
-func ValidateContentType() gin.HandlerFunc {
+// PATCH requests may also use the JSON Merge Patch and JSON Patch media types, and the
+// formRoutes (route templates as in c.FullPath) HTML form posts, such as the SAML ACS.
+func ValidateContentType(formRoutes ...string) gin.HandlerFunc {
+	forms := make(map[string]bool, len(formRoutes))
+	for _, route := range formRoutes {
+		forms[route] = true
+	}
 	return func(c *gin.Context) {
 		if c.Request.Method == "POST" || c.Request.Method == "PUT" || c.Request.Method == "PATCH" {
 			contentType := c.GetHeader("Content-Type")
-			if contentType != "application/json" && contentType != "application/json; charset=utf-8" {
+			accepted := contentType == "application/json" || contentType == "application/json; charset=utf-8"
+			if c.Request.Method == "PATCH" && !accepted {
+				mediaType, _, _ := mime.ParseMediaType(contentType)
+				accepted = mediaType == patch.MergePatchType || mediaType == patch.JSONPatchType
+			}
+			if !accepted && forms[c.FullPath()] {
+				mediaType, _, _ := mime.ParseMediaType(contentType)
+				accepted = mediaType == "application/x-www-form-urlencoded"
+			}
+			if !accepted {
 				response.BadRequestError(c, "Invalid Content-Type", "Content-Type must be application/json")
 				c.Abort()
 				return
@@ -0,0 +1,179 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/requestid"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+func TestErrorHandlerMapsHandlerErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantRetry  string
+	}{
+		{"app error", apperrors.NotFound("User not found", "No user exists with the given id"), http.StatusNotFound, "NOT_FOUND", ""},
+		{"wrapped app error", fmt.Errorf("service: %w", apperrors.Conflict("User already exists", "")), http.StatusConflict, "CONFLICT", ""},
+		{"record not found", fmt.Errorf("find: %w", gorm.ErrRecordNotFound), http.StatusNotFound, "NOT_FOUND", ""},
+		{"duplicate key", gorm.ErrDuplicatedKey, http.StatusConflict, "CONFLICT", ""},
+		{"foreign key", gorm.ErrForeignKeyViolated, http.StatusConflict, "CONFLICT", ""},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, http.StatusConflict, "CONCURRENT_UPDATE", "1"},
+		{"retry hint", apperrors.Unavailable("Down", "").WithRetryAfter(1500 * time.Millisecond), http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "2"},
+		{"unknown", errors.New("secret internal detail"), http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(ErrorHandler())
+			r.GET("/", func(c *gin.Context) { _ = c.Error(tt.err) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			var body response.APIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+			}
+			if w.Code != tt.wantStatus || body.Error == nil || body.Error.Code != tt.wantCode {
+				t.Fatalf("got %d %s, want %d %s", w.Code, w.Body.String(), tt.wantStatus, tt.wantCode)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetry {
+				t.Fatalf("expected Retry-After %q, got %q", tt.wantRetry, got)
+			}
+			if body.Error.Details == "secret internal detail" || body.Error.Message == "secret internal detail" {
+				t.Fatal("internal error leaked to the client")
+			}
+		})
+	}
+}
+
+func TestErrorHandlerKeepsWrittenResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/", func(c *gin.Context) {
+		_ = c.Error(errors.New("logged only"))
+		c.Status(http.StatusAccepted)
+		c.Writer.WriteHeaderNow()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+		t.Fatalf("got %d %q, want the handler's response untouched", w.Code, w.Body.String())
+	}
+}
+
+func TestErrorHandlerRecoversPanicWithRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/", func(c *gin.Context) {
+		// Headers for a download that never started must not describe the JSON error.
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", `attachment; filename="users.csv"`)
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body response.APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusInternalServerError || body.Error == nil || body.Error.Code != "INTERNAL_SERVER_ERROR" {
+		t.Fatalf("got %d %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("Content-Type = %q, want JSON", ct)
+	}
+	if w.Header().Get("Content-Disposition") != "" {
+		t.Fatal("Content-Disposition of the failed download was kept")
+	}
+	if id := w.Header().Get(requestid.Header); id == "" || body.Error.RequestID != id {
+		t.Fatalf("request ID header %q, body %q", id, body.Error.RequestID)
+	}
+}
+
+func TestErrorHandlerAbortsPartiallyWrittenResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+		_, _ = c.Writer.WriteString("id,username\n1,alice\n")
+		c.Writer.Flush()
+		panic("database connection lost mid-export")
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want the 200 already sent", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err == nil {
+		t.Fatalf("expected a truncated body, read %q", body)
+	}
+	if strings.Contains(string(body), "INTERNAL_SERVER_ERROR") || string(body) != "id,username\n1,alice\n" {
+		t.Fatalf("a JSON error was appended to the stream: %q", body)
+	}
+}
+
+func TestValidateContentType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateContentType("/form"))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.POST("/json", ok)
+	r.POST("/form", ok)
+	r.PATCH("/json", ok)
+
+	tests := []struct {
+		method, path, contentType string
+		want                      int
+	}{
+		{http.MethodPost, "/json", "application/json", http.StatusNoContent},
+		{http.MethodPost, "/json", "application/x-www-form-urlencoded", http.StatusBadRequest},
+		{http.MethodPost, "/form", "application/x-www-form-urlencoded; charset=utf-8", http.StatusNoContent},
+		{http.MethodPost, "/form", "multipart/form-data", http.StatusBadRequest},
+		{http.MethodPatch, "/json", "application/merge-patch+json", http.StatusNoContent},
+		{http.MethodPatch, "/json", "text/plain", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s %s (%s) = %d, want %d", tt.method, tt.path, tt.contentType, w.Code, tt.want)
+		}
+	}
+}
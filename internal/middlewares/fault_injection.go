@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/faults"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// FaultInjectedHeader lists the faults injected into a response, such as "latency,error".
+const FaultInjectedHeader = "X-Fault-Injected"
+
+var faultInjectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "fault_injections_total",
+	Help: "Faults injected into requests, by route template and fault (latency, error or drop).",
+}, []string{"route", "fault"})
+
+func init() {
+	metrics.Registry.MustRegister(faultInjectionsTotal)
+}
+
+// faultErrorCodes are the codes of injected errors, those of the real failures they imitate.
+var faultErrorCodes = map[int]*response.ErrorCode{
+	http.StatusTooManyRequests:     response.CodeRateLimitExceeded,
+	http.StatusInternalServerError: response.CodeInternal,
+	http.StatusServiceUnavailable:  response.CodeServiceUnavailable,
+}
+
+// FaultInjection injects the faults of the rule matching each route, and with fromHeaders those
+// requested by the X-Fault-* headers, which replace the rule's. Latency delays the request,
+// errors are answered instead of calling the handler (with Retry-After for 429 and 503), and
+// dropped connections are closed without a response. It is meant for staging and load tests
+// only, never production.
+func FaultInjection(rules faults.Rules, fromHeaders bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		var fault faults.Fault
+		if rule, ok := rules.Match(route); ok {
+			fault = rule.Fault
+		}
+		if fromHeaders {
+			requested, err := faults.FromHeader(c.Request.Header)
+			if err != nil {
+				response.ErrorResponse(c, response.CodeBadRequest, "Invalid fault injection header", err.Error())
+				c.Abort()
+				return
+			}
+			fault = fault.Merge(requested)
+		}
+
+		outcome := fault.Roll(rand.Float64)
+		if route == "" {
+			route = unmatchedRoute
+		}
+		var injected []string
+		if outcome.Latency > 0 {
+			faultInjectionsTotal.WithLabelValues(route, "latency").Inc()
+			injected = append(injected, "latency")
+			timer := time.NewTimer(outcome.Latency)
+			select {
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+				// The client gave up waiting
+				timer.Stop()
+				c.Abort()
+				return
+			}
+		}
+		if outcome.Drop {
+			faultInjectionsTotal.WithLabelValues(route, "drop").Inc()
+			// net/http closes the connection without writing a response
+			panic(http.ErrAbortHandler)
+		}
+		if outcome.Status != 0 {
+			faultInjectionsTotal.WithLabelValues(route, "error").Inc()
+			c.Header(FaultInjectedHeader, strings.Join(append(injected, "error"), ","))
+			if outcome.Status != http.StatusInternalServerError {
+				c.Header("Retry-After", "1")
+			}
+			response.ErrorResponse(c, faultErrorCodes[outcome.Status], "Injected fault", "This error was injected to test resilience")
+			c.Abort()
+			return
+		}
+		if len(injected) > 0 {
+			c.Header(FaultInjectedHeader, strings.Join(injected, ","))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/faults"
+)
+
+func TestFaultInjection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rules, err := faults.ParseRules([]string{"/broken=error:503", "/slow=latency:20ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(FaultInjection(rules, true))
+	for _, path := range []string{"/broken", "/slow", "/ok"} {
+		r.GET(path, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	}
+
+	perform := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := perform("/broken", nil)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" || w.Header().Get(FaultInjectedHeader) != "error" {
+		t.Errorf("/broken: %d (Retry-After=%q, %s=%q), want an injected 503", w.Code,
+			w.Header().Get("Retry-After"), FaultInjectedHeader, w.Header().Get(FaultInjectedHeader))
+	}
+
+	start := time.Now()
+	w = perform("/slow", nil)
+	if w.Code != http.StatusNoContent || time.Since(start) < 20*time.Millisecond || w.Header().Get(FaultInjectedHeader) != "latency" {
+		t.Errorf("/slow: %d after %s, want the handler's 204 after the latency", w.Code, time.Since(start))
+	}
+
+	if w := perform("/ok", nil); w.Code != http.StatusNoContent || w.Header().Get(FaultInjectedHeader) != "" {
+		t.Errorf("/ok: %d, want no faults", w.Code)
+	}
+	if w := perform("/ok", map[string]string{faults.ErrorHeader: "500"}); w.Code != http.StatusInternalServerError {
+		t.Errorf("/ok with %s: %d, want 500", faults.ErrorHeader, w.Code)
+	}
+	if w := perform("/ok", map[string]string{faults.ErrorHeader: "418"}); w.Code != http.StatusBadRequest {
+		t.Errorf("/ok with an invalid %s: %d, want 400", faults.ErrorHeader, w.Code)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("dropped request panicked with %v, want http.ErrAbortHandler", recovered)
+		}
+	}()
+	perform("/ok", map[string]string{faults.DropHeader: "true"})
+}
+
+func TestFaultInjection_IgnoresHeadersUnlessEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(FaultInjection(nil, false))
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set(faults.DropHeader, "true")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want the handler's 204", w.Code)
+	}
+}
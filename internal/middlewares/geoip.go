@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/pkg/geoip"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// GeoIP resolves the client address with the configured security.GeoLocator and attaches the
+// location to the request (see security.LocationFromContext), where the geo rules, the login
+// risk checks and the request log read it. Addresses the locator does not know, such as private
+// addresses, get no location.
+func GeoIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if loc, ok := security.Locate(c.ClientIP()); ok {
+			security.SetLocation(c, loc)
+		}
+		c.Next()
+	}
+}
+
+// GeoBlock rejects requests from the countries and networks the rule of their route group
+// blocks with 403 REGION_BLOCKED. Requests without a location are let through.
+func GeoBlock(rules geoip.BlockRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := rules.Match(c.FullPath())
+		loc, located := security.LocationFromContext(c)
+		if !ok || !located || !rule.Blocks(loc.Country, loc.ASN) {
+			c.Next()
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"client_ip": c.ClientIP(),
+			"country":   loc.Country,
+			"asn":       loc.ASN,
+			"route":     c.FullPath(),
+		}).Warn("Request blocked by geo rule")
+		response.ErrorResponse(c, response.CodeRegionBlocked, "Region not allowed", "Requests from your country or network are not accepted on this endpoint")
+		c.Abort()
+	}
+}
+
+// GeoRateLimit limits the requests to each route group per country or per network, as set by
+// its rule, so that a distributed burst from one network is throttled even when every IP
+// stays under its own limit. Requests without a location are not counted.
+func GeoRateLimit(rules geoip.RateLimitRules) gin.HandlerFunc {
+	limiters := make(map[string]*IPRateLimiter, len(rules))
+	for _, rule := range rules {
+		limiters[rule.Prefix] = NewIPRateLimiter(rate.Limit(rule.RPS), rule.Burst)
+	}
+
+	return func(c *gin.Context) {
+		rule, ok := rules.Match(c.FullPath())
+		loc, located := security.LocationFromContext(c)
+		if !ok || !located {
+			c.Next()
+			return
+		}
+		key := rule.Key(loc.Country, loc.ASN)
+		if key == "" || limiters[rule.Prefix].Allow(key) {
+			c.Next()
+			return
+		}
+
+		requestctx.Logger(c).WithFields(map[string]interface{}{
+			"client_ip": c.ClientIP(),
+			"bucket":    key,
+			"route":     c.FullPath(),
+		}).Warn("Geo rate limit exceeded")
+		security.RecordRateLimited(c, security.LimiterGeo)
+		response.ErrorResponse(c, response.CodeRateLimitExceeded, "Rate limit exceeded", "Too many requests from your country or network")
+		c.Abort()
+	}
+}
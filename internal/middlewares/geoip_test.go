@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/pkg/geoip"
+)
+
+// fakeLocator resolves the addresses in its map.
+type fakeLocator map[string]*security.Location
+
+func (l fakeLocator) Locate(ip string) (*security.Location, error) {
+	return l[ip], nil
+}
+
+func geoRouter(t *testing.T, middlewares ...gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	security.SetGeoLocator(fakeLocator{
+		"203.0.113.1": {Country: "KP", CountryOnly: true},
+		"203.0.113.2": {Country: "CO", ASN: 64500, CountryOnly: true},
+		"203.0.113.3": {Country: "CO", ASN: 64500, CountryOnly: true},
+	})
+	t.Cleanup(func() { security.SetGeoLocator(nil) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(GeoIP())
+	r.Use(middlewares...)
+	r.GET("/api/items", func(c *gin.Context) {
+		loc, _ := security.LocationFromContext(c)
+		if loc == nil {
+			c.String(http.StatusOK, "")
+			return
+		}
+		c.String(http.StatusOK, loc.Country)
+	})
+	return r
+}
+
+func geoGet(r *gin.Engine, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGeoIP_AttachesLocation(t *testing.T) {
+	r := geoRouter(t)
+	if w := geoGet(r, "203.0.113.2"); w.Body.String() != "CO" {
+		t.Errorf("location = %q, want CO", w.Body.String())
+	}
+	if w := geoGet(r, "192.168.1.1"); w.Body.String() != "" {
+		t.Errorf("unknown address got location %q", w.Body.String())
+	}
+}
+
+func TestGeoBlock(t *testing.T) {
+	rules, err := geoip.ParseBlockRules([]string{"/api=deny:KP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := geoRouter(t, GeoBlock(rules))
+
+	if w := geoGet(r, "203.0.113.1"); w.Code != http.StatusForbidden {
+		t.Errorf("blocked country got %d, want 403", w.Code)
+	}
+	for _, ip := range []string{"203.0.113.2", "192.168.1.1"} {
+		if w := geoGet(r, ip); w.Code != http.StatusOK {
+			t.Errorf("%s got %d, want 200", ip, w.Code)
+		}
+	}
+}
+
+func TestGeoRateLimit_PerNetwork(t *testing.T) {
+	rules, err := geoip.ParseRateLimitRules([]string{"/api=asn:0.001:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := geoRouter(t, GeoRateLimit(rules))
+
+	if w := geoGet(r, "203.0.113.2"); w.Code != http.StatusOK {
+		t.Fatalf("first request got %d", w.Code)
+	}
+	// Another address of the same network shares its bucket
+	if w := geoGet(r, "203.0.113.3"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("same network got %d, want 429", w.Code)
+	}
+	// Without a known network the rule does not apply
+	if w := geoGet(r, "203.0.113.1"); w.Code != http.StatusOK {
+		t.Errorf("unknown network got %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/metering"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// Quota headers of responses to API key requests.
+const (
+	QuotaLimitHeader     = "X-Quota-Limit"
+	QuotaRemainingHeader = "X-Quota-Remaining"
+	QuotaResetHeader     = "X-Quota-Reset"
+)
+
+// Metering records the requests and bytes (request and response bodies) of authenticated
+// users per API key and day. Requests made with an API key carry the X-Quota-* headers, and
+// are rejected with 429 QUOTA_EXCEEDED once the key has used its monthly quota. A nil meter
+// disables it. It must run after AuthRequired.
+func Metering(meter *metering.Meter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := requestctx.UserID(c)
+		if meter == nil || userID == 0 {
+			c.Next()
+			return
+		}
+
+		var apiKeyID uint
+		if key, ok := requestctx.APIKey(c); ok {
+			apiKeyID = key.ID
+			if quota := meter.Quota(); quota > 0 {
+				used, err := meter.Used(c.Request.Context(), userID, apiKeyID)
+				if err != nil {
+					// Metering never takes the API down with it
+					requestctx.Logger(c).WithField("error", err.Error()).Error("Failed to check API key quota")
+				} else {
+					now := meter.Now()
+					_, reset := metering.Period(now)
+					remaining := quota - used - 1
+					if remaining < 0 {
+						remaining = 0
+					}
+					c.Header(QuotaLimitHeader, strconv.FormatInt(quota, 10))
+					c.Header(QuotaRemainingHeader, strconv.FormatInt(remaining, 10))
+					c.Header(QuotaResetHeader, strconv.FormatInt(reset.Unix(), 10))
+					if used >= quota {
+						c.Header("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())+1))
+						response.ErrorResponse(c, response.CodeQuotaExceeded, "Quota exceeded",
+							"The API key used its monthly quota of "+strconv.FormatInt(quota, 10)+" requests")
+						c.Abort()
+						return
+					}
+				}
+			}
+		}
+
+		c.Next()
+
+		bytes := int64(c.Writer.Size())
+		if bytes < 0 {
+			bytes = 0
+		}
+		if c.Request.ContentLength > 0 {
+			bytes += c.Request.ContentLength
+		}
+		meter.Record(userID, apiKeyID, bytes)
+	}
+}
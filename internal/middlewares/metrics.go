@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/metrics"
+	"github.com/yeferson59/gin-template/pkg/response"
+	"github.com/yeferson59/gin-template/pkg/slo"
+)
+
+// unmatchedRoute labels requests that matched no route, so that scanners probing random paths
+// cannot create unbounded label values.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template, and status code.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route template.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route"})
+	sloBadRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_slo_bad_requests_total",
+		Help: "Requests that consumed error budget, by route and objective (availability or latency).",
+	}, []string{"method", "route", "objective"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(httpRequestsTotal, httpRequestDuration, sloBadRequestsTotal)
+}
+
+// Metrics records the count and latency of every request by route template (c.FullPath), so
+// /api/users/1 and /api/users/2 share one series, and feeds tracker with each outcome when it
+// is not nil. Requests for which exempt returns true, such as health probes and metric scrapes,
+// are not recorded. It must run before ErrorHandler to see the status of recovered panics.
+func Metrics(tracker *slo.Tracker, exempt func(path string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if exempt != nil && exempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		status := c.Writer.Status()
+
+		httpRequestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		httpRequestDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+		if tracker == nil {
+			return
+		}
+
+		failed, slow := tracker.Observe(slo.RouteKey(method, route), status, elapsed)
+		if failed {
+			sloBadRequestsTotal.WithLabelValues(method, route, "availability").Inc()
+		}
+		if slow {
+			sloBadRequestsTotal.WithLabelValues(method, route, "latency").Inc()
+		}
+	}
+}
+
+// MetricsToken lets through only the requests bearing token in their Authorization header,
+// so that a scraper can read the metrics on the public listener. An empty token lets every
+// request through.
+func MetricsToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		presented, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			response.UnauthorizedError(c, "Authorization required", "A valid metrics token is required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
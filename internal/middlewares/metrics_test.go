@@ -0,0 +1,130 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/yeferson59/gin-template/pkg/metrics"
+	"github.com/yeferson59/gin-template/pkg/slo"
+)
+
+func TestMetrics_LabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracker, err := slo.NewTracker(slo.Objective{Availability: 0.99, Latency: time.Second, LatencyTarget: 0.9}, 10)
+	if err != nil {
+		t.Fatalf("NewTracker() error = %v", err)
+	}
+	r := gin.New()
+	r.Use(Metrics(tracker, func(path string) bool { return path == "/metrics" }))
+	r.GET("/metrics-test/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.GET("/metrics", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	before := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, "/metrics-test/:id", "204"))
+	unmatched := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, unmatchedRoute, "404"))
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics", "/no-such-path"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, "/metrics-test/:id", "204")) - before; got != 2 {
+		t.Errorf("route template counted %v requests, want 2", got)
+	}
+	if got := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, unmatchedRoute, "404")) - unmatched; got != 1 {
+		t.Errorf("unmatched counted %v requests, want 1", got)
+	}
+	// The exempt metrics path is not tracked
+	if routes := tracker.Report().Routes; len(routes) != 2 {
+		t.Errorf("tracked routes = %+v, want the template and unmatched", routes)
+	}
+}
+
+func TestMetricsToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/metrics", MetricsToken("s3cret"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer other", http.StatusUnauthorized},
+		{"wrong scheme", "Basic s3cret", http.StatusUnauthorized},
+		{"valid token", "Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+// counterValue returns the value of the counter name with labels in the metrics registry.
+func counterValue(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	series:
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue series
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestAuthSecurityMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/auth-metrics-test", AuthRequired(nil), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.POST("/auth-metrics-test/login", AuthRateLimit(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	missing := map[string]string{"route": "/auth-metrics-test", "reason": "missing"}
+	malformed := map[string]string{"route": "/auth-metrics-test", "reason": "malformed"}
+	limited := map[string]string{"route": "/auth-metrics-test/login", "limiter": "auth"}
+	beforeMissing := counterValue(t, "auth_token_failures_total", missing)
+	beforeMalformed := counterValue(t, "auth_token_failures_total", malformed)
+	beforeLimited := counterValue(t, "auth_rate_limited_total", limited)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/auth-metrics-test", nil))
+	req := httptest.NewRequest(http.MethodGet, "/auth-metrics-test", nil)
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	// AuthRateLimit lets 5 attempts per minute through
+	for i := 0; i < 7; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/auth-metrics-test/login", nil))
+	}
+
+	if got := counterValue(t, "auth_token_failures_total", missing) - beforeMissing; got != 1 {
+		t.Errorf("missing token failures = %v, want 1", got)
+	}
+	if got := counterValue(t, "auth_token_failures_total", malformed) - beforeMalformed; got != 1 {
+		t.Errorf("malformed token failures = %v, want 1", got)
+	}
+	if got := counterValue(t, "auth_rate_limited_total", limited) - beforeLimited; got != 2 {
+		t.Errorf("auth rate limit rejections = %v, want 2", got)
+	}
+}
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/apimock"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// MockResponseHeader names the operation of the specification whose example answered a request.
+const MockResponseHeader = "X-Mock-Response"
+
+// Mock answers requests that match no route with the example responses of the operation of
+// server's specification matching them, so that endpoints can be used before their handlers
+// exist. Registered ahead of NoRoute and NoMethod, it leaves requests matching no operation to
+// them. A Prefer header asking for a response or example the operation lacks is answered with
+// 400 BAD_REQUEST.
+func Mock(server *apimock.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, ok, err := server.Respond(c.Request)
+		if !ok {
+			return
+		}
+		defer c.Abort()
+		if err != nil {
+			response.ErrorResponse(c, response.CodeBadRequest, "Cannot mock the response", err.Error())
+			return
+		}
+		// Set by Gin when the path exists for other methods
+		c.Writer.Header().Del("Allow")
+		c.Header(MockResponseHeader, resp.Operation)
+		if resp.ContentType == "" {
+			c.Status(resp.Status)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+		c.Data(resp.Status, resp.ContentType, resp.Body)
+	}
+}
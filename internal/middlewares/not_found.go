@@ -0,0 +1,122 @@
+package middlewares
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// maxRouteSuggestions bounds the "did you mean" suggestions of a 404.
+const maxRouteSuggestions = 3
+
+// NoRoute answers requests that match no route with 404 NOT_FOUND in the standard error
+// envelope, instead of Gin's plain-text "404 page not found". With routes, typically the
+// engine's Routes method, the details suggest the routes of the same method whose path is
+// closest to the requested one; meant for development, as it reveals the route table.
+func NoRoute(routes func() gin.RoutesInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		details := "No route matches " + c.Request.Method + " " + c.Request.URL.Path
+		if routes != nil {
+			if suggestions := suggestRoutes(routes(), c.Request.Method, c.Request.URL.Path); len(suggestions) > 0 {
+				details += "; did you mean " + strings.Join(suggestions, ", ") + "?"
+			}
+		}
+		response.ErrorResponse(c, response.CodeNotFound, "Route not found", details)
+	}
+}
+
+// NoMethod answers requests for a path that exists only for other methods with
+// 405 METHOD_NOT_ALLOWED. Gin has already set the Allow header listing those methods.
+func NoMethod() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		details := c.Request.Method + " is not allowed on " + c.Request.URL.Path
+		if allow := c.Writer.Header().Get("Allow"); allow != "" {
+			details += "; allowed methods: " + allow
+		}
+		response.ErrorResponse(c, response.CodeMethodNotAllowed, "Method not allowed", details)
+	}
+}
+
+// suggestRoutes returns the paths of the routes for method closest to path, if they are at
+// most a fifth of its length (and at least 2) edits away. Parameters match any segment.
+func suggestRoutes(routes gin.RoutesInfo, method, path string) []string {
+	type candidate struct {
+		path     string
+		distance int
+	}
+	limit := max(2, len(path)/5)
+	var candidates []candidate
+	seen := make(map[string]bool)
+	for _, r := range routes {
+		if r.Method != method && !(method == http.MethodHead && r.Method == http.MethodGet) {
+			continue
+		}
+		if seen[r.Path] {
+			continue
+		}
+		seen[r.Path] = true
+		if d := editDistance(path, fillParams(r.Path, path)); d <= limit {
+			candidates = append(candidates, candidate{r.Path, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].path < candidates[j].path
+	})
+
+	suggestions := make([]string, 0, maxRouteSuggestions)
+	for _, c := range candidates {
+		if len(suggestions) == maxRouteSuggestions || c.distance > candidates[0].distance {
+			break
+		}
+		suggestions = append(suggestions, c.path)
+	}
+	return suggestions
+}
+
+// fillParams replaces the parameters of template with the segments of path at their position,
+// so that /api/users/:id compares equal to /api/users/42.
+func fillParams(template, path string) string {
+	if !strings.ContainsAny(template, ":*") {
+		return template
+	}
+	tmpl := strings.Split(template, "/")
+	segments := strings.Split(path, "/")
+	for i, s := range tmpl {
+		switch {
+		case strings.HasPrefix(s, "*") && i < len(segments):
+			// Catch-all parameters take the rest of the path
+			return strings.Join(append(tmpl[:i], segments[i:]...), "/")
+		case strings.HasPrefix(s, ":") && i < len(segments):
+			tmpl[i] = segments[i]
+		}
+	}
+	return strings.Join(tmpl, "/")
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSuggestRoutes(t *testing.T) {
+	routes := gin.RoutesInfo{
+		{Method: http.MethodGet, Path: "/api/users"},
+		{Method: http.MethodGet, Path: "/api/users/:id"},
+		{Method: http.MethodGet, Path: "/api/orgs/:org/members"},
+		{Method: http.MethodPost, Path: "/api/user"},
+		{Method: http.MethodGet, Path: "/static/*filepath"},
+	}
+	for _, tt := range []struct {
+		method, path string
+		want         []string
+	}{
+		{http.MethodGet, "/api/user", []string{"/api/users"}},
+		{http.MethodHead, "/api/userz", []string{"/api/users"}},
+		{http.MethodGet, "/api/user/42", []string{"/api/users/:id"}},
+		{http.MethodGet, "/api/orgs/acme/member", []string{"/api/orgs/:org/members"}},
+		{http.MethodGet, "/statik/css/app.css", []string{"/static/*filepath"}},
+		{http.MethodGet, "/api/invoices", []string{}},
+		{http.MethodDelete, "/api/users", []string{}},
+	} {
+		if got := suggestRoutes(routes, tt.method, tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("suggestRoutes(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0}, {"abc", "", 3}, {"kitten", "sitting", 3}, {"/api/user", "/api/users", 1},
+	} {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNoRouteAndNoMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(NoRoute(r.Routes))
+	r.NoMethod(NoMethod())
+	r.GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/itemz", nil))
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `did you mean /items?`) {
+		t.Errorf("404: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/items", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET" ||
+		!strings.Contains(w.Body.String(), `"METHOD_NOT_ALLOWED"`) || !strings.Contains(w.Body.String(), "allowed methods: GET") {
+		t.Errorf("405: %d %s (Allow %q)", w.Code, w.Body.String(), w.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,125 @@
+// Package middlewares provides request validation against the OpenAPI specification.
+package middlewares
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// OpenAPIPath converts a Gin route template such as /api/users/:id or /static/*filepath to
+// an OpenAPI path such as /api/users/{id}.
+func OpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// OpenAPIValidation validates the path parameters, query, and body of each request against the
+// operation of its route in the specification returned by spec, answering 400 VALIDATION_ERROR
+// with one entry per schema violation. Authentication is left to the route's middlewares, and
+// routes missing from the specification are not validated. spec is called on every request,
+// so that it can be built lazily once every route is registered; when it fails, requests are
+// let through. It is meant for development and staging, to catch drift between the handlers
+// and the documented schemas.
+func OpenAPIValidation(spec func() (*openapi3.T, error)) gin.HandlerFunc {
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+	var logOnce sync.Once
+
+	return func(c *gin.Context) {
+		doc, err := spec()
+		if err != nil {
+			logOnce.Do(func() {
+				logger.WithField("error", err.Error()).Error("OpenAPI specification unavailable, requests are not validated")
+			})
+			c.Next()
+			return
+		}
+
+		path := OpenAPIPath(c.FullPath())
+		item := doc.Paths.Value(path)
+		if item == nil || item.GetOperation(c.Request.Method) == nil {
+			c.Next()
+			return
+		}
+		params := make(map[string]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = p.Value
+		}
+
+		err = openapi3filter.ValidateRequest(c.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: params,
+			Route: &routers.Route{
+				Spec:      doc,
+				Path:      path,
+				PathItem:  item,
+				Method:    c.Request.Method,
+				Operation: item.GetOperation(c.Request.Method),
+			},
+			Options: options,
+		})
+		if err != nil {
+			fields := openAPIFieldErrors(err, nil)
+			details := make([]string, len(fields))
+			for i, f := range fields {
+				details[i] = f.Field + ": " + f.Message
+			}
+			response.ValidationErrors(c, strings.Join(details, "; "), fields)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// openAPIFieldErrors flattens the errors of openapi3filter.ValidateRequest into field errors.
+// Body fields are dotted paths such as "operations.0.op", like those of the handlers'
+// validation; parameters are prefixed by their name, and errors of the whole body use "body".
+func openAPIFieldErrors(err error, prefix []string) []response.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var fields []response.FieldError
+		for _, err := range e {
+			fields = append(fields, openAPIFieldErrors(err, prefix)...)
+		}
+		return fields
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			prefix = []string{e.Parameter.Name}
+		}
+		if e.Err != nil {
+			return openAPIFieldErrors(e.Err, prefix)
+		}
+		return []response.FieldError{{Field: fieldName(prefix), Code: "invalid_format", Message: e.Reason}}
+	case *openapi3.SchemaError:
+		code := "invalid_format"
+		if e.SchemaField == "required" {
+			code = "required"
+		}
+		return []response.FieldError{{Field: fieldName(append(prefix, e.JSONPointer()...)), Code: code, Message: e.Reason}}
+	default:
+		return []response.FieldError{{Field: fieldName(prefix), Code: "invalid_format", Message: err.Error()}}
+	}
+}
+
+func fieldName(path []string) string {
+	if len(path) == 0 {
+		return "body"
+	}
+	return strings.Join(path, ".")
+}
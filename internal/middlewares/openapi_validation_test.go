@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+func TestOpenAPIPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/api/users":                      "/api/users",
+		"/api/orgs/:org/members/:user_id": "/api/orgs/{org}/members/{user_id}",
+		"/admin/*filepath":                "/admin/{filepath}",
+	} {
+		if got := OpenAPIPath(path); got != want {
+			t.Errorf("OpenAPIPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestOpenAPIValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := openapi3.NewObjectSchema().WithProperty("name", openapi3.NewStringSchema())
+	body.Required = []string{"name"}
+	op := openapi3.NewOperation()
+	op.AddParameter(openapi3.NewPathParameter("id").WithSchema(openapi3.NewIntegerSchema()))
+	op.AddParameter(openapi3.NewQueryParameter("limit").WithSchema(openapi3.NewIntegerSchema()))
+	op.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchema(body)}
+	op.Responses = openapi3.NewResponses(openapi3.WithName("default", openapi3.NewResponse().WithDescription("ok")))
+	spec := &openapi3.T{OpenAPI: "3.0.3", Info: &openapi3.Info{Title: "Test", Version: "1"}, Paths: openapi3.NewPaths()}
+	spec.AddOperation("/items/{id}", http.MethodPut, op)
+
+	var specErr error
+	r := gin.New()
+	r.Use(OpenAPIValidation(func() (*openapi3.T, error) { return spec, specErr }))
+	r.PUT("/items/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.POST("/undocumented", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	put := func(path, payload string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := put("/items/1?limit=10", `{"name":"a"}`); w.Code != http.StatusNoContent {
+		t.Fatalf("valid request: %d %s", w.Code, w.Body.String())
+	}
+	for _, tt := range []struct{ path, payload, field string }{
+		{"/items/abc", `{"name":"a"}`, `"field":"id"`},
+		{"/items/1?limit=ten", `{"name":"a"}`, `"field":"limit"`},
+		{"/items/1", `{"name":1}`, `"field":"name","code":"invalid_format"`},
+		{"/items/1", `{}`, `"field":"name","code":"required"`},
+		{"/items/1", `{"name":`, `"field":"body"`},
+	} {
+		w := put(tt.path, tt.payload)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.field) {
+			t.Errorf("PUT %s %s: %d %s, want a 400 with %s", tt.path, tt.payload, w.Code, w.Body.String(), tt.field)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/undocumented", strings.NewReader("anything")))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("undocumented route: status %d, want it not validated", w.Code)
+	}
+
+	specErr = errors.New("broken spec")
+	if w := put("/items/abc", `{}`); w.Code != http.StatusNoContent {
+		t.Errorf("without a specification: status %d, want requests let through", w.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// OrgParam is the route parameter OrgScope reads: the ID or the slug of an organization.
+const OrgParam = "org"
+
+// OrgScope loads the organization named by the :org route parameter and the authenticated
+// user's membership in it (see requestctx.Organization and requestctx.Membership). Users who
+// are not members get 404, so the existence of other organizations is not revealed.
+// It must run after AuthRequired.
+func OrgScope(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		param := c.Param(OrgParam)
+		query := db.WithContext(c.Request.Context())
+		var org models.Organization
+		var err error
+		if id, convErr := strconv.ParseUint(param, 10, 64); convErr == nil {
+			err = query.First(&org, id).Error
+		} else {
+			err = query.Where("slug = ?", param).First(&org).Error
+		}
+
+		var membership models.Membership
+		if err == nil {
+			err = query.Where("organization_id = ? AND user_id = ?", org.ID, requestctx.UserID(c)).First(&membership).Error
+		}
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.NotFoundError(c, "Organization not found", "No organization exists with the given id or slug")
+			c.Abort()
+			return
+		case err != nil:
+			logger.WithField("error", err.Error()).Error("Failed to load organization")
+			response.InternalServerError(c, "Failed to load organization", "Database error occurred")
+			c.Abort()
+			return
+		}
+
+		requestctx.SetOrganization(c, &org, &membership)
+		c.Next()
+	}
+}
+
+// RequireOrgRole allows the request only when the user's role in the organization has at least
+// the privileges of min (owner > admin > member). It must run after OrgScope.
+func RequireOrgRole(min string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		membership, ok := requestctx.Membership(c)
+		if ok && models.OrgRoleAtLeast(membership.Role, min) {
+			c.Next()
+			return
+		}
+
+		fields := map[string]interface{}{
+			"user_id":  requestctx.UserID(c),
+			"endpoint": c.Request.URL.Path,
+		}
+		if ok {
+			fields["organization_id"] = membership.OrganizationID
+			fields["org_role"] = membership.Role
+		}
+		logger.WithFields(fields).Warn("Access denied: insufficient organization role")
+		response.ForbiddenError(c, "Access denied", "You need the "+min+" role in this organization")
+		c.Abort()
+	}
+}
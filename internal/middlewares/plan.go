@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// RequirePlan rejects with 402 PLAN_REQUIRED the requests of users without an active
+// subscription (active, trialing or past_due) to one of plans. Subscriptions are kept up to
+// date by the Stripe webhook. It must run after AuthRequired.
+func RequirePlan(db *gorm.DB, plans ...string) gin.HandlerFunc {
+	details := "This endpoint requires the " + strings.Join(plans, " or ") + " plan"
+
+	return func(c *gin.Context) {
+		var subscribed int64
+		err := db.WithContext(c.Request.Context()).Model(&models.Subscription{}).
+			Where("user_id = ? AND plan IN ? AND status IN ?", requestctx.UserID(c), plans, models.ActiveSubscriptionStatuses).
+			Count(&subscribed).Error
+		if err != nil {
+			requestctx.Logger(c).WithField("error", err.Error()).Error("Failed to check subscription plan")
+			response.InternalServerError(c, "Could not verify plan", "Database error occurred")
+			c.Abort()
+			return
+		}
+		if subscribed == 0 {
+			response.ErrorResponse(c, response.CodePlanRequired, "Plan required", details)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/profiler"
+)
+
+// Profiler feeds p with the status and latency of every request, so that it captures profiles
+// when they degrade. Like Metrics, it must run before ErrorHandler to see recovered panics.
+func Profiler(p *profiler.Profiler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		p.Observe(c.Writer.Status(), time.Since(start))
+	}
+}
@@ -2,17 +2,41 @@
 package middlewares
 
 import (
-	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 
+	"github.com/yeferson59/gin-template/internal/security"
+	"github.com/yeferson59/gin-template/pkg/clock"
 	"github.com/yeferson59/gin-template/pkg/logger"
 	"github.com/yeferson59/gin-template/pkg/response"
 )
 
+var (
+	middlewareClock   = clock.Real
+	middlewareClockMu sync.RWMutex
+)
+
+// SetClock replaces the clock with which the middlewares refill rate limits and record the use
+// of API keys; nil restores the system clock. Tests use it to exhaust and refill limits
+// without waiting.
+func SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.Real
+	}
+	middlewareClockMu.Lock()
+	defer middlewareClockMu.Unlock()
+	middlewareClock = c
+}
+
+func now() time.Time {
+	middlewareClockMu.RLock()
+	defer middlewareClockMu.RUnlock()
+	return middlewareClock.Now()
+}
+
 // IPRateLimiter contains the rate limiters for each IP address.
 type IPRateLimiter struct {
 	limiters map[string]*rate.Limiter
@@ -44,6 +68,12 @@ func (rl *IPRateLimiter) GetLimiter(ip string) *rate.Limiter {
 	return limiter
 }
 
+// Allow reports whether a request of the given IP address may happen now, by the clock set
+// with SetClock.
+func (rl *IPRateLimiter) Allow(ip string) bool {
+	return rl.GetLimiter(ip).AllowN(now(), 1)
+}
+
 // CleanupOldLimiters removes limiters for IPs that haven't been used recently.
 func (rl *IPRateLimiter) CleanupOldLimiters() {
 	rl.mu.Lock()
@@ -70,11 +100,10 @@ var globalRateLimiter = NewIPRateLimiter(rate.Every(time.Second), 10) // 10 requ
 func RateLimit() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
-		limiter := globalRateLimiter.GetLimiter(ip)
-
-		if !limiter.Allow() {
+		if !globalRateLimiter.Allow(ip) {
 			logger.WithField("ip", ip).Warn("Rate limit exceeded")
-			response.ErrorResponse(c, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED", "Rate limit exceeded", "Too many requests from your IP address")
+			security.RecordRateLimited(c, security.LimiterIP)
+			response.ErrorResponse(c, response.CodeRateLimitExceeded, "Rate limit exceeded", "Too many requests from your IP address")
 			c.Abort()
 			return
 		}
@@ -89,11 +118,10 @@ func RateLimitWithConfig(rps rate.Limit, burst int) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
-		limiter := rateLimiter.GetLimiter(ip)
-
-		if !limiter.Allow() {
+		if !rateLimiter.Allow(ip) {
 			logger.WithField("ip", ip).Warn("Rate limit exceeded")
-			response.ErrorResponse(c, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED", "Rate limit exceeded", "Too many requests from your IP address")
+			security.RecordRateLimited(c, security.LimiterIP)
+			response.ErrorResponse(c, response.CodeRateLimitExceeded, "Rate limit exceeded", "Too many requests from your IP address")
 			c.Abort()
 			return
 		}
@@ -108,11 +136,10 @@ func AuthRateLimit() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
-		limiter := authLimiter.GetLimiter(ip)
-
-		if !limiter.Allow() {
+		if !authLimiter.Allow(ip) {
 			logger.WithField("ip", ip).Warn("Auth rate limit exceeded")
-			response.ErrorResponse(c, http.StatusTooManyRequests, "AUTH_RATE_LIMIT_EXCEEDED", "Authentication rate limit exceeded", "Too many authentication attempts from your IP address")
+			security.RecordRateLimited(c, security.LimiterAuth)
+			response.ErrorResponse(c, response.CodeAuthRateLimitExceeded, "Authentication rate limit exceeded", "Too many authentication attempts from your IP address")
 			c.Abort()
 			return
 		}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/clock"
+)
+
+func TestAuthRateLimitRefillsWithClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC))
+	SetClock(clk)
+	t.Cleanup(func() { SetClock(nil) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", AuthRateLimit(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	login := func() int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = "203.0.113.9:1234"
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 5; i++ {
+		if code := login(); code != http.StatusNoContent {
+			t.Fatalf("attempt %d: status = %d, want 204", i+1, code)
+		}
+	}
+	if code := login(); code != http.StatusTooManyRequests {
+		t.Fatalf("sixth attempt: status = %d, want 429", code)
+	}
+
+	// One attempt is refilled per minute
+	clk.Advance(59 * time.Second)
+	if code := login(); code != http.StatusTooManyRequests {
+		t.Fatalf("after 59s: status = %d, want 429", code)
+	}
+	clk.Advance(time.Second)
+	if code := login(); code != http.StatusNoContent {
+		t.Fatalf("after a minute: status = %d, want 204", code)
+	}
+	if code := login(); code != http.StatusTooManyRequests {
+		t.Fatalf("second attempt after a minute: status = %d, want 429", code)
+	}
+}
@@ -0,0 +1,32 @@
+// Package middlewares provides role-based access control functionality.
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// RequireRole allows the request only when the authenticated user has one of the given roles.
+// It must run after AuthRequired.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := requestctx.Role(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"user_id":  requestctx.UserID(c),
+			"role":     role,
+			"endpoint": c.Request.URL.Path,
+		}).Warn("Access denied: insufficient role")
+		response.ForbiddenError(c, "Access denied", "You do not have permission to access this resource")
+		c.Abort()
+	}
+}
@@ -0,0 +1,32 @@
+// Package middlewares provides read-only mode functionality.
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/readonly"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// ReadOnly rejects mutating requests (any method but GET, HEAD, and OPTIONS) with
+// 503 READ_ONLY while mode is enabled, so the database is not written during a migration or a
+// failover. Routes whose template is in exempt, such as token issuance and the endpoint that
+// turns the mode off, are always served.
+func ReadOnly(mode *readonly.Switch, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mode.Enabled() || isSafeMethod(c.Request.Method) || slices.Contains(exempt, c.FullPath()) {
+			c.Next()
+			return
+		}
+		response.ErrorResponse(c, response.CodeReadOnly, "Read-only mode",
+			"The API is temporarily read-only for maintenance, please retry later")
+		c.Abort()
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/readonly"
+)
+
+func TestReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mode := readonly.NewSwitch(false)
+
+	r := gin.New()
+	r.Use(ReadOnly(mode, "/token"))
+	for _, path := range []string{"/items", "/token"} {
+		r.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+		r.POST(path, func(c *gin.Context) { c.Status(http.StatusCreated) })
+	}
+
+	perform := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := perform(http.MethodPost, "/items"); w.Code != http.StatusCreated {
+		t.Fatalf("expected writes to pass while disabled, got %d", w.Code)
+	}
+
+	mode.Set(true)
+	w := perform(http.MethodPost, "/items")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"READ_ONLY"`) {
+		t.Fatalf("expected 503 READ_ONLY for writes, got %d %s", w.Code, w.Body.String())
+	}
+	if w := perform(http.MethodGet, "/items"); w.Code != http.StatusOK {
+		t.Errorf("expected reads to pass, got %d", w.Code)
+	}
+	if w := perform(http.MethodPost, "/token"); w.Code != http.StatusCreated {
+		t.Errorf("expected exempt routes to pass, got %d", w.Code)
+	}
+
+	mode.Set(false)
+	if w := perform(http.MethodPost, "/items"); w.Code != http.StatusCreated {
+		t.Errorf("expected writes to pass once disabled again, got %d", w.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/recorder"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+)
+
+// Record saves a sanitized copy of each request under the paths of rec, and of its response,
+// for `api replay`. It must run before ErrorHandler to record the error responses it writes.
+func Record(rec *recorder.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rec.Records(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		limit := rec.MaxBodyBytes()
+		var body []byte
+		truncated := false
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			// Keep the first bytes and hand the handler the whole body
+			body, _ = io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+			if len(body) > limit {
+				body, truncated = body[:limit], true
+			}
+		}
+		header := c.Request.Header.Clone()
+
+		writer := &captureWriter{ResponseWriter: c.Writer, limit: limit}
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+
+		c.Next()
+
+		exchange := recorder.Exchange{
+			Time:       start,
+			RequestID:  requestctx.RequestID(c),
+			Method:     c.Request.Method,
+			URL:        rec.URL(c.Request.URL),
+			Route:      c.FullPath(),
+			Status:     writer.Status(),
+			DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+			Request:    rec.RequestMessage(header, body, truncated),
+			Response:   rec.Message(writer.Header(), writer.body.Bytes(), writer.overflow),
+		}
+		if err := rec.Save(&exchange); err != nil {
+			logger.WithField("error", err.Error()).Warn("Failed to record request")
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/recorder"
+)
+
+func TestRecord(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	rec, err := recorder.New(recorder.Options{Dir: dir, Paths: []string{"/api"}, MaxBodyBytes: 512, Redactor: logger.NewRedactor(nil, false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(Record(rec), ErrorHandler())
+	r.POST("/api/login", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		if !strings.Contains(string(body), "hunter22") {
+			_ = c.Error(apperrors.BadRequest("Body lost", string(body)))
+			return
+		}
+		_ = c.Error(apperrors.Unauthorized("Invalid credentials", ""))
+	})
+	r.POST("/api/upload", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%d", len(body))
+	})
+	r.GET("/other", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	perform := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := perform(http.MethodPost, "/api/login", `{"username":"alice","password":"hunter22"}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("login: %d %s, want the handler to read the whole body", w.Code, w.Body.String())
+	}
+	large := `{"data":"` + strings.Repeat("x", 1000) + `"}`
+	if w := perform(http.MethodPost, "/api/upload", large); w.Body.String() != "1011" {
+		t.Fatalf("upload read %s bytes, want the whole body past the recording limit", w.Body.String())
+	}
+	perform(http.MethodGet, "/other", "")
+
+	exchanges, err := recorder.Load(dir)
+	if err != nil || len(exchanges) != 2 {
+		t.Fatalf("Load() = %d exchanges, %v, want the two /api requests", len(exchanges), err)
+	}
+	login := exchanges[0]
+	if login.Route != "/api/login" || login.Status != http.StatusUnauthorized {
+		t.Errorf("login exchange = %+v", login)
+	}
+	if strings.Contains(string(login.Request.Body), "hunter22") || !strings.Contains(string(login.Request.Body), "alice") {
+		t.Errorf("recorded request body = %s, want the password redacted", login.Request.Body)
+	}
+	if !strings.Contains(string(login.Response.Body), "UNAUTHORIZED") {
+		t.Errorf("recorded response = %+v, want the error written by ErrorHandler", login.Response)
+	}
+	if upload := exchanges[1]; !upload.Request.Truncated || !upload.Request.BodyOmitted {
+		t.Errorf("upload exchange request = %+v, want it truncated", upload.Request)
+	}
+}
@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/cache"
+)
+
+// Cache is a mock cache.Cache. Without the XxxFunc fields it behaves as a simple map that
+// ignores expiry, which is enough for most handler tests.
+type Cache struct {
+	GetFunc    func(ctx context.Context, key string) ([]byte, bool, error)
+	SetFunc    func(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	DeleteFunc func(ctx context.Context, key string) error
+
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+var _ cache.Cache = (*Cache)(nil)
+
+// Get implements cache.Cache.
+func (m *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, key)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.entries[key]
+	return value, ok, nil
+}
+
+// Set implements cache.Cache.
+func (m *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if m.SetFunc != nil {
+		return m.SetFunc(ctx, key, value, ttl)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries == nil {
+		m.entries = make(map[string][]byte)
+	}
+	m.entries[key] = value
+	return nil
+}
+
+// Delete implements cache.Cache.
+func (m *Cache) Delete(ctx context.Context, key string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, key)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
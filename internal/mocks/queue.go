@@ -0,0 +1,64 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yeferson59/gin-template/internal/jobs"
+)
+
+// Queue is a mock jobs.Queue. By default Enqueue records the job without running it;
+// call RunAll to execute the recorded jobs synchronously.
+type Queue struct {
+	EnqueueFunc  func(job jobs.Job) error
+	ShutdownFunc func(ctx context.Context) error
+
+	mu   sync.Mutex
+	jobs []jobs.Job
+}
+
+var _ jobs.Queue = (*Queue)(nil)
+
+// Enqueue implements jobs.Queue.
+func (m *Queue) Enqueue(job jobs.Job) error {
+	if m.EnqueueFunc != nil {
+		if err := m.EnqueueFunc(job); err != nil {
+			return err
+		}
+	}
+	m.mu.Lock()
+	m.jobs = append(m.jobs, job)
+	m.mu.Unlock()
+	return nil
+}
+
+// Shutdown implements jobs.Queue.
+func (m *Queue) Shutdown(ctx context.Context) error {
+	if m.ShutdownFunc == nil {
+		return nil
+	}
+	return m.ShutdownFunc(ctx)
+}
+
+// Jobs returns the enqueued jobs that have not been run yet.
+func (m *Queue) Jobs() []jobs.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]jobs.Job(nil), m.jobs...)
+}
+
+// RunAll runs and removes every enqueued job in order, returning the first error.
+func (m *Queue) RunAll(ctx context.Context) error {
+	m.mu.Lock()
+	pending := m.jobs
+	m.jobs = nil
+	m.mu.Unlock()
+
+	var first error
+	for _, job := range pending {
+		if err := job.Run(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,111 @@
+// Package mocks provides hand-written test doubles for the template's core interfaces, so
+// handlers and services can be unit tested without a database or background workers.
+//
+// Each mock has a XxxFunc field per method. A nil field returns zero values. Every call is
+// recorded so tests can assert on the arguments.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/pagination"
+)
+
+// UserRepository is a mock repository.UserRepository.
+type UserRepository struct {
+	ListFunc      func(ctx context.Context, filter repository.UserFilter, page pagination.Params) ([]models.User, int64, error)
+	FindByIDsFunc func(ctx context.Context, ids []uint) ([]models.User, error)
+	EachFunc      func(ctx context.Context, filter repository.UserFilter, batchSize int, fn func(users []models.User) error) error
+
+	mu             sync.Mutex
+	listCalls      []UserListCall
+	findByIDsCalls [][]uint
+	eachCalls      []UserEachCall
+}
+
+// UserListCall records the arguments of a List call.
+type UserListCall struct {
+	Filter repository.UserFilter
+	Page   pagination.Params
+}
+
+// UserEachCall records the arguments of an Each call.
+type UserEachCall struct {
+	Filter    repository.UserFilter
+	BatchSize int
+}
+
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// List implements repository.UserRepository.
+func (m *UserRepository) List(ctx context.Context, filter repository.UserFilter, page pagination.Params) ([]models.User, int64, error) {
+	m.mu.Lock()
+	m.listCalls = append(m.listCalls, UserListCall{Filter: filter, Page: page})
+	m.mu.Unlock()
+
+	if m.ListFunc == nil {
+		return nil, 0, nil
+	}
+	return m.ListFunc(ctx, filter, page)
+}
+
+// FindByIDs implements repository.UserRepository.
+func (m *UserRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.User, error) {
+	m.mu.Lock()
+	m.findByIDsCalls = append(m.findByIDsCalls, append([]uint(nil), ids...))
+	m.mu.Unlock()
+
+	if m.FindByIDsFunc == nil {
+		return nil, nil
+	}
+	return m.FindByIDsFunc(ctx, ids)
+}
+
+// Each implements repository.UserRepository.
+func (m *UserRepository) Each(ctx context.Context, filter repository.UserFilter, batchSize int, fn func(users []models.User) error) error {
+	m.mu.Lock()
+	m.eachCalls = append(m.eachCalls, UserEachCall{Filter: filter, BatchSize: batchSize})
+	m.mu.Unlock()
+
+	if m.EachFunc == nil {
+		return nil
+	}
+	return m.EachFunc(ctx, filter, batchSize, fn)
+}
+
+// ListCalls returns the recorded List calls.
+func (m *UserRepository) ListCalls() []UserListCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]UserListCall(nil), m.listCalls...)
+}
+
+// FindByIDsCalls returns the IDs of the recorded FindByIDs calls.
+func (m *UserRepository) FindByIDsCalls() [][]uint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([][]uint(nil), m.findByIDsCalls...)
+}
+
+// EachCalls returns the recorded Each calls.
+func (m *UserRepository) EachCalls() []UserEachCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]UserEachCall(nil), m.eachCalls...)
+}
+
+// UsersInBatches returns an EachFunc that yields users in batches of the requested size.
+func UsersInBatches(users []models.User) func(ctx context.Context, filter repository.UserFilter, batchSize int, fn func(users []models.User) error) error {
+	return func(_ context.Context, _ repository.UserFilter, batchSize int, fn func(users []models.User) error) error {
+		for start := 0; start < len(users); start += batchSize {
+			end := min(start+batchSize, len(users))
+			if err := fn(users[start:end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
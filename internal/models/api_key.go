@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// APIKey es una clave con la que un usuario autentica sus integraciones mediante la cabecera
+// X-API-Key. Solo se guarda el hash SHA-256 de la clave; Prefix identifica la clave al
+// listarla sin revelarla.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`
+	KeyHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de claves de API.
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// APIUsage acumula las peticiones y los bytes de un usuario en un día (UTC), por clave de
+// API. APIKeyID es 0 para las peticiones autenticadas con un token de sesión.
+type APIUsage struct {
+	ID       uint `gorm:"primaryKey" json:"-"`
+	UserID   uint `gorm:"not null;uniqueIndex:idx_api_usage" json:"-"`
+	APIKeyID uint `gorm:"not null;uniqueIndex:idx_api_usage" json:"-"`
+	// Day es la fecha en formato 2006-01-02, que se ordena como texto.
+	Day      string `gorm:"size:10;not null;uniqueIndex:idx_api_usage" json:"day"`
+	Requests int64  `gorm:"not null;default:0" json:"requests"`
+	Bytes    int64  `gorm:"not null;default:0" json:"bytes"`
+}
+
+// TableName define el nombre de la tabla de uso de la API.
+func (APIUsage) TableName() string {
+	return "api_usage"
+}
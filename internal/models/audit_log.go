@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AuditLog registra un evento del ciclo de vida de un modelo auditado: qué cambió, quién lo
+// cambió y en qué petición (ver hooks.Registry).
+type AuditLog struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Event    string `gorm:"size:100;not null;index" json:"event"`
+	Entity   string `gorm:"size:100;not null;index:idx_audit_logs_entity" json:"entity"`
+	EntityID string `gorm:"size:64;index:idx_audit_logs_entity" json:"entity_id,omitempty"`
+	// ActorID es el usuario autenticado que hizo el cambio; nil si no se conoce.
+	ActorID   *uint  `gorm:"index" json:"actor_id,omitempty"`
+	RequestID string `gorm:"size:64" json:"request_id,omitempty"`
+	// Changes guarda en JSON los campos que fijó el evento con sus nuevos valores.
+	Changes   string    `gorm:"type:text" json:"changes,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName define el nombre de la tabla del registro de auditoría.
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Tipos de consentimiento que registra la API.
+const (
+	// ConsentTerms es la aceptación de los términos del servicio.
+	ConsentTerms = "terms"
+)
+
+// Consent registra que un usuario aceptó una versión de un documento legal, con el origen de
+// la petición como prueba. Los registros nunca se modifican.
+type Consent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_consent" json:"user_id"`
+	Kind       string    `gorm:"size:20;not null;uniqueIndex:idx_consent" json:"kind"`
+	Version    string    `gorm:"size:50;not null;uniqueIndex:idx_consent" json:"version"`
+	IP         string    `gorm:"size:64" json:"ip"`
+	UserAgent  string    `gorm:"size:512" json:"user_agent"`
+	AcceptedAt time.Time `gorm:"not null" json:"accepted_at"`
+}
+
+// TableName define el nombre de la tabla de consentimientos.
+func (Consent) TableName() string {
+	return "consents"
+}
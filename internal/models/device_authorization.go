@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Estados de una autorización de dispositivo.
+const (
+	DeviceAuthPending  = "pending"
+	DeviceAuthApproved = "approved"
+	DeviceAuthDenied   = "denied"
+)
+
+// DeviceAuthorization es una solicitud de token de un cliente sin navegador, como una CLI
+// (OAuth device authorization grant, RFC 8628). El cliente consulta el token con el device
+// code mientras el usuario aprueba el user code desde el navegador.
+type DeviceAuthorization struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	// DeviceCodeHash es el SHA-256 del device code; el código nunca se guarda en claro.
+	DeviceCodeHash string `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	UserCode       string `gorm:"size:16;uniqueIndex;not null" json:"user_code"`
+	ClientID       string `gorm:"size:255;not null" json:"client_id"`
+	Scope          string `gorm:"size:255" json:"scope,omitempty"`
+	Status         string `gorm:"size:16;not null" json:"status"`
+	// UserID es el usuario que aprobó o rechazó la solicitud.
+	UserID *uint `gorm:"index" json:"user_id,omitempty"`
+	// Interval es el tiempo mínimo entre consultas en segundos, que crece con cada consulta
+	// demasiado rápida.
+	Interval     int        `json:"interval"`
+	LastPolledAt *time.Time `json:"last_polled_at,omitempty"`
+	ExpiresAt    time.Time  `gorm:"index" json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de autorizaciones de dispositivo.
+func (DeviceAuthorization) TableName() string {
+	return "device_authorizations"
+}
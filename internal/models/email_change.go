@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// EmailChange es un cambio de email pendiente o hecho. El email anterior sigue activo hasta
+// que se confirma el nuevo con su token, y el token de reversión enviado al email anterior
+// permite deshacer el cambio durante un tiempo, también después de confirmado.
+type EmailChange struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UserID   uint   `gorm:"not null;index" json:"user_id"`
+	OldEmail string `gorm:"size:255;not null" json:"old_email"`
+	NewEmail string `gorm:"size:255;not null" json:"new_email"`
+	// Solo se guardan los hashes SHA-256 de los tokens enviados por email.
+	ConfirmTokenHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	RevertTokenHash  string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	// ExpiresAt limita la confirmación y RevertExpiresAt la reversión.
+	ExpiresAt       time.Time  `gorm:"not null" json:"expires_at"`
+	RevertExpiresAt time.Time  `gorm:"not null" json:"revert_expires_at"`
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty"`
+	RevertedAt      *time.Time `json:"reverted_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de cambios de email.
+func (EmailChange) TableName() string {
+	return "email_changes"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ExternalIdentity vincula un usuario con su identidad en un proveedor externo, como un IdP
+// SAML. Provider es el entity ID del proveedor y Subject el identificador del usuario en él.
+type ExternalIdentity struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"index;not null" json:"user_id"`
+	Provider    string    `gorm:"size:255;not null;uniqueIndex:idx_external_identity" json:"provider"`
+	Subject     string    `gorm:"size:255;not null;uniqueIndex:idx_external_identity" json:"subject"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+// TableName define el nombre de la tabla de identidades externas.
+func (ExternalIdentity) TableName() string {
+	return "external_identities"
+}
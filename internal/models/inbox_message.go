@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// InboxMessage registra una entrega de webhook ya procesada, para descartar las repeticiones
+// del mismo evento (ver inbox.Inbox). Se guarda en la misma transacción que los efectos del
+// evento, así que solo existe si se aplicaron.
+type InboxMessage struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	// Provider y EventID identifican el evento: el proveedor (stripe, github...) y su ID de
+	// evento, único para ese proveedor.
+	Provider  string    `gorm:"size:32;not null;uniqueIndex:idx_inbox_messages_event" json:"provider"`
+	EventID   string    `gorm:"size:255;not null;uniqueIndex:idx_inbox_messages_event" json:"event_id"`
+	EventType string    `gorm:"size:100" json:"event_type,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de entregas de webhooks procesadas.
+func (InboxMessage) TableName() string {
+	return "inbox_messages"
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Eventos del ciclo de vida de una instancia del servidor.
+const (
+	InstanceStarted = "started"
+	InstanceStopped = "stopped"
+)
+
+// InstanceEvent registra el arranque o la parada de una instancia del servidor, para saber en
+// el siguiente arranque por qué se reinició (ver app.Instance).
+type InstanceEvent struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	// InstanceID identifica el proceso; sus eventos started y stopped lo comparten.
+	InstanceID string `gorm:"size:36;not null;index" json:"instance_id"`
+	Host       string `gorm:"size:255;index" json:"host"`
+	Event      string `gorm:"size:20;not null" json:"event"`
+	Version    string `gorm:"size:64" json:"version"`
+	// ConfigFingerprint es el hash de la configuración sin secretos.
+	ConfigFingerprint string `gorm:"size:64" json:"config_fingerprint"`
+	// Migrations lista, separadas por comas, las migraciones versionadas aplicadas al arrancar.
+	Migrations string `gorm:"type:text" json:"migrations,omitempty"`
+	// Reason es la causa de la parada: la señal recibida, una actualización o un error.
+	Reason    string    `gorm:"size:512" json:"reason,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de eventos de instancia.
+func (InstanceEvent) TableName() string {
+	return "instance_events"
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// LoginEvent registra cada inicio de sesión exitoso junto con su origen y puntaje de riesgo.
+type LoginEvent struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `gorm:"index;not null" json:"user_id"`
+	IP        string `gorm:"size:64" json:"ip"`
+	UserAgent string `gorm:"size:512" json:"user_agent"`
+	// DeviceFingerprint identifica el dispositivo (ver security.DeviceFingerprint).
+	DeviceFingerprint string    `gorm:"size:64;index" json:"device_fingerprint,omitempty"`
+	Country           string    `gorm:"size:2" json:"country,omitempty"`
+	ASN               uint      `json:"asn,omitempty"`
+	Latitude          *float64  `json:"latitude,omitempty"`
+	Longitude         *float64  `json:"longitude,omitempty"`
+	RiskScore         int       `json:"risk_score"`
+	Signals           string    `gorm:"size:255" json:"signals,omitempty"`
+	CreatedAt         time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de eventos de inicio de sesión.
+func (LoginEvent) TableName() string {
+	return "login_events"
+}
+
+// HasLocation indica si el evento tiene coordenadas geográficas.
+func (e LoginEvent) HasLocation() bool {
+	return e.Latitude != nil && e.Longitude != nil
+}
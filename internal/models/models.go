@@ -0,0 +1,35 @@
+package models
+
+// All devuelve todos los modelos que deben migrarse a la base de datos.
+func All() []interface{} {
+	return []interface{}{
+		&User{},
+		&LoginEvent{},
+		&Operation{},
+		&Notification{},
+		&LoginChallenge{},
+		&ExternalIdentity{},
+		&DeviceAuthorization{},
+		&Organization{},
+		&Membership{},
+		&Invitation{},
+		&RegistrationCode{},
+		&Consent{},
+		&Preference{},
+		&UsernameChange{},
+		&EmailChange{},
+		&APIKey{},
+		&APIUsage{},
+		&BillingCustomer{},
+		&Subscription{},
+		&Plan{},
+		&Entitlement{},
+		&UserPlan{},
+		&AuditLog{},
+		&InboxMessage{},
+		&Saga{},
+		&QueuedJob{},
+		&InstanceEvent{},
+		// gen:models
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Notification es un aviso dentro de la aplicación para un usuario, como una alerta de seguridad.
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	Kind      string     `gorm:"size:64;not null" json:"kind"`
+	Title     string     `gorm:"size:255;not null" json:"title"`
+	Body      string     `gorm:"type:text" json:"body"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de notificaciones.
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// LoginChallenge es una verificación pendiente de un inicio de sesión desde un dispositivo o
+// país nuevo: el token se emite cuando el usuario confirma el código enviado por correo.
+type LoginChallenge struct {
+	ID     string `gorm:"primaryKey;size:64" json:"id"`
+	UserID uint   `gorm:"index;not null" json:"user_id"`
+	// CodeHash es el SHA-256 del código; el código nunca se guarda en claro.
+	CodeHash          string    `gorm:"size:64;not null" json:"-"`
+	DeviceFingerprint string    `gorm:"size:64" json:"-"`
+	Attempts          int       `json:"attempts"`
+	ExpiresAt         time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de verificaciones de inicio de sesión.
+func (LoginChallenge) TableName() string {
+	return "login_challenges"
+}
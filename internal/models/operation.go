@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Estados posibles de una operación asíncrona.
+const (
+	OperationPending   = "pending"
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+	OperationCanceled  = "canceled"
+)
+
+// Operation representa una tarea de larga duración ejecutada en segundo plano.
+type Operation struct {
+	ID         string     `gorm:"primaryKey;size:36" json:"id"`
+	Type       string     `gorm:"size:100;not null" json:"type"`
+	Status     string     `gorm:"size:20;not null;index" json:"status"`
+	Progress   int        `json:"progress"`
+	Message    string     `gorm:"size:255" json:"message,omitempty"`
+	Result     string     `gorm:"type:text" json:"-"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `gorm:"index" json:"finished_at,omitempty"`
+}
+
+// TableName define el nombre de la tabla de operaciones.
+func (Operation) TableName() string {
+	return "operations"
+}
+
+// IsFinished indica si la operación alcanzó un estado final.
+func (o Operation) IsFinished() bool {
+	return o.Status == OperationSucceeded || o.Status == OperationFailed || o.Status == OperationCanceled
+}
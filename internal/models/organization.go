@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Roles de un usuario dentro de una organización, de mayor a menor privilegio.
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// orgRoleRanks ordena los roles de organización por privilegio.
+var orgRoleRanks = map[string]int{
+	OrgRoleMember: 1,
+	OrgRoleAdmin:  2,
+	OrgRoleOwner:  3,
+}
+
+// IsValidOrgRole indica si role es uno de los roles de organización.
+func IsValidOrgRole(role string) bool {
+	return orgRoleRanks[role] > 0
+}
+
+// OrgRoleAtLeast indica si role tiene al menos los privilegios de min.
+func OrgRoleAtLeast(role, min string) bool {
+	return IsValidOrgRole(role) && orgRoleRanks[role] >= orgRoleRanks[min]
+}
+
+// Organization es un equipo de usuarios que comparten recursos, como un cliente de una app SaaS.
+type Organization struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `gorm:"size:100;not null" json:"name"`
+	Slug      string         `gorm:"size:50;uniqueIndex;not null" json:"slug"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName define el nombre de la tabla de organizaciones.
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// Membership vincula un usuario con una organización y su rol en ella.
+type Membership struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;uniqueIndex:idx_membership" json:"organization_id"`
+	UserID         uint      `gorm:"not null;uniqueIndex:idx_membership;index" json:"user_id"`
+	Role           string    `gorm:"size:20;not null" json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName define el nombre de la tabla de membresías.
+func (Membership) TableName() string {
+	return "memberships"
+}
+
+// Invitation es una invitación por correo para unirse a una organización. Solo el usuario con
+// ese email puede aceptarla, una vez y antes de que expire.
+type Invitation struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	OrganizationID uint   `gorm:"not null;index" json:"organization_id"`
+	Email          string `gorm:"size:255;not null;index" json:"email"`
+	Role           string `gorm:"size:20;not null" json:"role"`
+	// TokenHash es el SHA-256 del token de la invitación; el token nunca se guarda en claro.
+	TokenHash   string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	InvitedByID uint       `gorm:"not null" json:"invited_by_id"`
+	ExpiresAt   time.Time  `gorm:"index" json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de invitaciones.
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+// BeforeSave normaliza el email para compararlo con el del usuario que acepta.
+func (i *Invitation) BeforeSave(_ *gorm.DB) error {
+	i.Email = NormalizeEmail(i.Email)
+	return nil
+}
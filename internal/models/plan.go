@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Plan es un plan con sus derechos, independiente del proveedor de pagos. Name coincide con
+// el plan de las suscripciones de Stripe, así que una suscripción da los derechos del plan del
+// mismo nombre.
+type Plan struct {
+	ID           uint          `gorm:"primaryKey" json:"id"`
+	Name         string        `gorm:"size:50;not null;uniqueIndex" json:"name"`
+	DisplayName  string        `gorm:"size:100" json:"display_name"`
+	Entitlements []Entitlement `gorm:"constraint:OnDelete:CASCADE" json:"entitlements"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// Entitlement es un derecho de un plan: una funcionalidad cuando Limit es nil, o un límite
+// como el número máximo de organizaciones.
+type Entitlement struct {
+	ID     uint   `gorm:"primaryKey" json:"-"`
+	PlanID uint   `gorm:"not null;uniqueIndex:idx_plan_entitlement" json:"-"`
+	Key    string `gorm:"size:100;not null;uniqueIndex:idx_plan_entitlement" json:"key"`
+	Limit  *int64 `gorm:"column:limit_value" json:"limit"`
+}
+
+// UserPlan asigna manualmente un plan a un usuario, con prioridad sobre su suscripción.
+// ExpiresAt nil mantiene la asignación hasta que un administrador la retira.
+type UserPlan struct {
+	ID         uint       `gorm:"primaryKey" json:"-"`
+	UserID     uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	PlanID     uint       `gorm:"not null;index" json:"-"`
+	Plan       Plan       `json:"plan"`
+	AssignedBy uint       `json:"assigned_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
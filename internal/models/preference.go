@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Preference guarda un ajuste que el usuario cambió respecto de su valor por defecto (ver
+// preferences.Schema). Los ajustes sin fila usan el valor por defecto.
+type Preference struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;uniqueIndex:idx_preference" json:"user_id"`
+	// Setting es "categoría.ajuste", por ejemplo "theme.mode".
+	Setting string `gorm:"size:100;not null;uniqueIndex:idx_preference" json:"setting"`
+	// Value es el valor codificado en JSON.
+	Value     string    `gorm:"size:1024;not null" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName define el nombre de la tabla de preferencias.
+func (Preference) TableName() string {
+	return "preferences"
+}
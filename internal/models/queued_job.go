@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Estados posibles de un trabajo de la cola persistente.
+const (
+	QueuedJobPending = "pending"
+	QueuedJobRunning = "running"
+	QueuedJobFailed  = "failed"
+)
+
+// QueuedJob representa un trabajo en segundo plano guardado en la base de datos por
+// jobs.DurableQueue, para que sobreviva a un reinicio. Se borra al completarse; los que agotan
+// sus intentos quedan como fallidos.
+type QueuedJob struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	// Name elige el handler que ejecuta el trabajo; JobID lo identifica en los logs.
+	Name    string `gorm:"size:100;not null;index" json:"name"`
+	JobID   string `gorm:"size:100" json:"job_id,omitempty"`
+	Payload []byte `json:"-"`
+	Status  string `gorm:"size:20;not null;index:idx_queued_jobs_runnable,priority:1" json:"status"`
+	// RunAt es cuándo puede ejecutarse, más tarde tras un fallo; LockedUntil es cuándo vence
+	// el trabajo en curso, tras lo cual otro worker lo retoma.
+	RunAt       time.Time  `gorm:"not null;index:idx_queued_jobs_runnable,priority:2" json:"run_at"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName define el nombre de la tabla de trabajos en cola.
+func (QueuedJob) TableName() string {
+	return "queued_jobs"
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// RegistrationCode es un código de invitación para registrarse cuando el registro abierto está
+// desactivado. Lo crea un administrador o el owner de una organización, a la que se une el
+// usuario registrado con él.
+type RegistrationCode struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	// CodeHash es el SHA-256 del código; el código nunca se guarda en claro.
+	CodeHash       string    `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Note           string    `gorm:"size:200" json:"note,omitempty"`
+	OrganizationID *uint     `gorm:"index" json:"organization_id,omitempty"`
+	CreatedByID    uint      `gorm:"not null" json:"created_by_id"`
+	MaxUses        int       `gorm:"not null" json:"max_uses"`
+	Uses           int       `gorm:"not null;default:0" json:"uses"`
+	ExpiresAt      time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName define el nombre de la tabla de códigos de registro.
+func (RegistrationCode) TableName() string {
+	return "registration_codes"
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Estados posibles de una saga.
+const (
+	SagaRunning      = "running"
+	SagaCompensating = "compensating"
+	SagaSucceeded    = "succeeded"
+	SagaCompensated  = "compensated"
+	SagaFailed       = "failed"
+)
+
+// Saga representa un proceso de negocio de varios pasos ejecutado en segundo plano (ver
+// saga.Runner). Se guarda tras cada paso, así que tras un reinicio continúa donde quedó.
+type Saga struct {
+	ID     string `gorm:"primaryKey;size:36" json:"id"`
+	Name   string `gorm:"size:100;not null;index" json:"name"`
+	Status string `gorm:"size:20;not null;index" json:"status"`
+	// Step es el número de pasos completados, y al compensar el de pasos aún por deshacer.
+	Step int `gorm:"not null" json:"step"`
+	// State guarda en JSON los valores que los pasos se pasan entre sí.
+	State string `gorm:"type:text" json:"-"`
+	Error string `gorm:"type:text" json:"error,omitempty"`
+	// Attempts cuenta las veces que se empezó a ejecutar; cada ejecución lo incrementa para
+	// reclamar la saga, de modo que dos instancias no la ejecuten a la vez.
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"index" json:"updated_at"`
+	FinishedAt *time.Time `gorm:"index" json:"finished_at,omitempty"`
+}
+
+// TableName define el nombre de la tabla de sagas.
+func (Saga) TableName() string {
+	return "sagas"
+}
+
+// IsFinished indica si la saga alcanzó un estado final.
+func (s Saga) IsFinished() bool {
+	return s.Status == SagaSucceeded || s.Status == SagaCompensated || s.Status == SagaFailed
+}
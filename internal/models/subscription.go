@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Estados de una suscripción de Stripe que dan acceso a su plan. past_due mantiene el acceso
+// mientras Stripe reintenta el cobro.
+const (
+	SubscriptionActive   = "active"
+	SubscriptionTrialing = "trialing"
+	SubscriptionPastDue  = "past_due"
+)
+
+// ActiveSubscriptionStatuses son los estados que dan acceso al plan de la suscripción.
+var ActiveSubscriptionStatuses = []string{SubscriptionActive, SubscriptionTrialing, SubscriptionPastDue}
+
+// BillingCustomer enlaza a un usuario con su cliente de Stripe.
+type BillingCustomer struct {
+	ID         uint      `gorm:"primaryKey" json:"-"`
+	UserID     uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+	CustomerID string    `gorm:"size:255;not null;uniqueIndex" json:"customer_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Subscription es una suscripción de Stripe de un usuario, actualizada por el webhook.
+// EventCreatedAt es la fecha del último evento aplicado, para ignorar los que llegan
+// desordenados.
+type Subscription struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	UserID            uint       `gorm:"not null;index" json:"user_id"`
+	CustomerID        string     `gorm:"size:255;not null;index" json:"customer_id"`
+	SubscriptionID    string     `gorm:"size:255;not null;uniqueIndex" json:"subscription_id"`
+	PriceID           string     `gorm:"size:255" json:"price_id"`
+	Plan              string     `gorm:"size:50;index" json:"plan"`
+	Status            string     `gorm:"size:30;not null" json:"status"`
+	CurrentPeriodEnd  *time.Time `json:"current_period_end,omitempty"`
+	CancelAtPeriodEnd bool       `json:"cancel_at_period_end"`
+	EventCreatedAt    time.Time  `json:"-"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// Active indica si la suscripción da acceso a su plan.
+func (s Subscription) Active() bool {
+	for _, status := range ActiveSubscriptionStatuses {
+		if s.Status == status {
+			return true
+		}
+	}
+	return false
+}
@@ -2,20 +2,37 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Roles disponibles para los usuarios.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsValidRole indica si role es uno de los roles disponibles.
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
 // User representa el modelo de usuario para autenticación y ejemplo.
 type User struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	Username  string         `gorm:"unique;not null" json:"username"`
 	Email     string         `gorm:"unique;not null" json:"email"`
 	Password  string         `gorm:"not null" json:"-"`
+	Role      string         `gorm:"size:20;not null;default:user;index" json:"role"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	// UsernameNormalized es NormalizeUsername(Username), con un índice único creado por una
+	// migración: LOWER() de SQLite solo convierte ASCII, así que las búsquedas sin distinguir
+	// mayúsculas usan esta columna.
+	UsernameNormalized string `gorm:"size:255" json:"-"`
 }
 
 // TableName permite personalizar el nombre de la tabla si se desea.
@@ -23,3 +40,29 @@ type User struct {
 func (User) TableName() string {
 	return "users"
 }
+
+// BeforeSave normaliza el email a minúsculas y guarda el nombre de usuario normalizado para
+// que la unicidad no dependa de mayúsculas, también en las actualizaciones con mapas.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.Email = NormalizeEmail(u.Email)
+	u.UsernameNormalized = NormalizeUsername(u.Username)
+	if updates, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		if username, ok := updates["username"].(string); ok {
+			updates["username_normalized"] = NormalizeUsername(username)
+		}
+		if email, ok := updates["email"].(string); ok {
+			updates["email"] = NormalizeEmail(email)
+		}
+	}
+	return nil
+}
+
+// NormalizeEmail devuelve el email sin espacios y en minúsculas, tal como se almacena.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeUsername devuelve el nombre de usuario en la forma usada para búsquedas sin distinguir mayúsculas.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
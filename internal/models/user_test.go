@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -46,3 +47,41 @@ func TestUserFields(t *testing.T) {
 		t.Errorf("UpdatedAt = %v; want %v", user.UpdatedAt, now)
 	}
 }
+
+func TestUserBeforeSaveNormalizesMapUpdates(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&User{}); err != nil {
+		t.Fatal(err)
+	}
+	user := User{Username: "alice", Email: "Alice@Example.com", Password: "hash"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "alice@example.com" {
+		t.Errorf("created email = %q, want alice@example.com", user.Email)
+	}
+
+	if err := db.Model(&User{ID: user.ID}).Updates(map[string]interface{}{"username": "Alice_B", "email": " New@Bar.COM "}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var saved User
+	if err := db.First(&saved, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.Email != "new@bar.com" || saved.UsernameNormalized != "alice_b" {
+		t.Errorf("after Updates: email = %q, username_normalized = %q", saved.Email, saved.UsernameNormalized)
+	}
+
+	if err := db.Model(&User{ID: user.ID}).Update("email", "Other@Bar.COM").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(&saved, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.Email != "other@bar.com" {
+		t.Errorf("after Update: email = %q, want other@bar.com", saved.Email)
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UsernameChange registra un cambio de nombre de usuario. El nombre anterior queda reservado
+// para su dueño durante un tiempo, para que nadie más pueda suplantarlo.
+type UsernameChange struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	UserID      uint   `gorm:"not null;index" json:"user_id"`
+	OldUsername string `gorm:"size:120;not null;index" json:"old_username"`
+	NewUsername string `gorm:"size:120;not null" json:"new_username"`
+	// OldUsernameNormalized es NormalizeUsername(OldUsername), usado para buscar los nombres
+	// reservados.
+	OldUsernameNormalized string `gorm:"size:120;index" json:"-"`
+	// ChangedByID es el usuario que hizo el cambio: el propio usuario o un administrador.
+	ChangedByID uint      `gorm:"not null" json:"changed_by_id"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName define el nombre de la tabla del historial de nombres de usuario.
+func (UsernameChange) TableName() string {
+	return "username_changes"
+}
+
+// BeforeSave guarda el nombre anterior normalizado.
+func (c *UsernameChange) BeforeSave(_ *gorm.DB) error {
+	c.OldUsernameNormalized = NormalizeUsername(c.OldUsername)
+	return nil
+}
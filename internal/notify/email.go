@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig is the mail server of the email channel.
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password enable PLAIN authentication, which net/smtp only sends over TLS
+	// or to localhost.
+	Username string
+	Password string
+	// From is the sender address, such as "Security <security@example.com>".
+	From string
+}
+
+// Email sends messages by SMTP.
+type Email struct {
+	cfg  SMTPConfig
+	auth smtp.Auth
+	// send is smtp.SendMail, replaced in tests.
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail creates an email notifier for the given server.
+func NewEmail(cfg SMTPConfig) (*Email, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, errors.New("notify: the email channel needs SMTP_HOST and SMTP_FROM")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	e := &Email{cfg: cfg, send: smtp.SendMail}
+	if cfg.Username != "" {
+		e.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return e, nil
+}
+
+// Notify emails msg to msg.Email. Messages without a recipient are skipped.
+func (e *Email) Notify(ctx context.Context, msg Message) error {
+	if msg.Email == "" {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	addr := net.JoinHostPort(e.cfg.Host, fmt.Sprint(e.cfg.Port))
+	if err := e.send(addr, e.auth, envelopeAddress(e.cfg.From), []string{msg.Email}, e.compose(msg)); err != nil {
+		return fmt.Errorf("notify: send email: %w", err)
+	}
+	return nil
+}
+
+// compose builds a plain text RFC 5322 message.
+func (e *Email) compose(msg Message) []byte {
+	var b strings.Builder
+	header := func(name, value string) {
+		// Header values never span lines, which would allow header injection
+		value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+		b.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", e.cfg.From)
+	header("To", msg.Email)
+	header("Subject", msg.Subject)
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// envelopeAddress extracts the address of "Name <address>".
+func envelopeAddress(from string) string {
+	if start := strings.LastIndex(from, "<"); start >= 0 {
+		if end := strings.LastIndex(from, ">"); end > start {
+			return from[start+1 : end]
+		}
+	}
+	return from
+}
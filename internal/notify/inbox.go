@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CapturedEmail is an email kept by an Inbox instead of being sent.
+type CapturedEmail struct {
+	ID         uint64    `json:"id"`
+	To         string    `json:"to"`
+	Kind       string    `json:"kind"`
+	Subject    string    `json:"subject"`
+	Body       string    `json:"body"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// Inbox replaces the email channel in development: it keeps the last emails in memory, where
+// they are read with GET /dev/emails/inbox, so email flows work without an SMTP server.
+type Inbox struct {
+	mu     sync.Mutex
+	size   int
+	lastID uint64
+	emails []CapturedEmail
+}
+
+// NewInbox creates an inbox keeping the last size emails (100 when size is not positive).
+func NewInbox(size int) *Inbox {
+	if size <= 0 {
+		size = 100
+	}
+	return &Inbox{size: size}
+}
+
+// Notify keeps msg, dropping the oldest email when the inbox is full. Messages without a
+// recipient are skipped, as by Email.
+func (i *Inbox) Notify(ctx context.Context, msg Message) error {
+	if msg.Email == "" {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastID++
+	i.emails = append(i.emails, CapturedEmail{
+		ID:         i.lastID,
+		To:         msg.Email,
+		Kind:       msg.Kind,
+		Subject:    msg.Subject,
+		Body:       msg.Body,
+		CapturedAt: time.Now(),
+	})
+	if len(i.emails) > i.size {
+		i.emails = append(i.emails[:0], i.emails[len(i.emails)-i.size:]...)
+	}
+	return nil
+}
+
+// Emails returns the kept emails, newest first.
+func (i *Inbox) Emails() []CapturedEmail {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	emails := make([]CapturedEmail, len(i.emails))
+	for j, email := range i.emails {
+		emails[len(emails)-1-j] = email
+	}
+	return emails
+}
+
+// Clear empties the inbox.
+func (i *Inbox) Clear() {
+	i.mu.Lock()
+	i.emails = nil
+	i.mu.Unlock()
+}
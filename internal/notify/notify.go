@@ -0,0 +1,113 @@
+// Package notify delivers notifications to users, such as security alerts, through the
+// channels a deployment enables: in the app, by email, or to the log.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// Notification channels (NOTIFY_CHANNELS).
+const (
+	ChannelInApp = "inapp"
+	ChannelEmail = "email"
+	ChannelLog   = "log"
+)
+
+// Message is a notification for a single user.
+type Message struct {
+	UserID uint
+	// Email is the recipient address of the email channel.
+	Email string
+	// Kind classifies the message, such as "login.new_device".
+	Kind    string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages through one or more channels.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Multi delivers each message through all of its notifiers, even when some fail.
+type Multi []Notifier
+
+// Notify sends msg through every notifier and joins their errors.
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InApp stores messages as notifications the user lists in the app.
+type InApp struct {
+	db *gorm.DB
+}
+
+// NewInApp creates an in-app notifier storing notifications in db.
+func NewInApp(db *gorm.DB) *InApp {
+	return &InApp{db: db}
+}
+
+// Notify stores msg as an unread notification.
+func (n *InApp) Notify(ctx context.Context, msg Message) error {
+	notification := models.Notification{
+		UserID: msg.UserID,
+		Kind:   msg.Kind,
+		Title:  msg.Subject,
+		Body:   msg.Body,
+	}
+	if err := n.db.WithContext(ctx).Create(&notification).Error; err != nil {
+		return fmt.Errorf("notify: store notification: %w", err)
+	}
+	return nil
+}
+
+// Log writes messages to the application log, for development and auditing.
+type Log struct{}
+
+// Notify logs msg.
+func (Log) Notify(_ context.Context, msg Message) error {
+	logger.WithFields(map[string]interface{}{
+		"user_id": msg.UserID,
+		"kind":    msg.Kind,
+		"subject": msg.Subject,
+	}).Info("Notification")
+	return nil
+}
+
+// Async delivers messages on a background job queue so slow channels, such as SMTP, never
+// delay the request that triggered them. Delivery errors are logged by the queue.
+type Async struct {
+	next  Notifier
+	queue jobs.Queue
+}
+
+// NewAsync wraps next so its messages are delivered on queue.
+func NewAsync(next Notifier, queue jobs.Queue) *Async {
+	return &Async{next: next, queue: queue}
+}
+
+// Notify enqueues the delivery of msg; it only fails when the queue rejects the job.
+func (a *Async) Notify(_ context.Context, msg Message) error {
+	return a.queue.Enqueue(jobs.Job{
+		ID:   uuid.NewString(),
+		Name: "notify." + msg.Kind,
+		Run: func(ctx context.Context) error {
+			return a.next.Notify(ctx, msg)
+		},
+	})
+}
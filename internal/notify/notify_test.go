@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+type recorder struct {
+	messages []Message
+	err      error
+}
+
+func (r *recorder) Notify(_ context.Context, msg Message) error {
+	r.messages = append(r.messages, msg)
+	return r.err
+}
+
+func TestMulti_DeliversDespiteFailures(t *testing.T) {
+	failing := &recorder{err: errors.New("down")}
+	ok := &recorder{}
+	err := Multi{failing, ok}.Notify(context.Background(), Message{UserID: 1, Kind: "test"})
+	if err == nil || len(ok.messages) != 1 {
+		t.Fatalf("Notify() error = %v, delivered %d", err, len(ok.messages))
+	}
+}
+
+func TestInApp(t *testing.T) {
+	db := testutil.NewDB(t)
+	msg := Message{UserID: 7, Kind: "login.new_device", Subject: "New sign-in", Body: "From Firefox"}
+	if err := NewInApp(db).Notify(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored models.Notification
+	if err := db.First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.UserID != 7 || stored.Kind != msg.Kind || stored.Title != msg.Subject || stored.ReadAt != nil {
+		t.Errorf("stored %+v", stored)
+	}
+}
+
+func TestEmail(t *testing.T) {
+	if _, err := NewEmail(SMTPConfig{Host: "smtp.example.com"}); err == nil {
+		t.Error("NewEmail() accepted a config without a sender")
+	}
+
+	e, err := NewEmail(SMTPConfig{Host: "smtp.example.com", Username: "u", Password: "p", From: "Security <security@example.com>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addr, from string
+	var to []string
+	var body []byte
+	e.send = func(a string, _ smtp.Auth, f string, t []string, msg []byte) error {
+		addr, from, to, body = a, f, t, msg
+		return nil
+	}
+
+	msg := Message{Email: "user@example.com", Subject: "New sign-in\r\nBcc: evil@example.com", Body: "line 1\nline 2"}
+	if err := e.Notify(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if addr != "smtp.example.com:587" || from != "security@example.com" || len(to) != 1 || to[0] != "user@example.com" {
+		t.Errorf("sent to %s from %s to %v", addr, from, to)
+	}
+	if strings.Contains(string(body), "\r\nBcc:") {
+		t.Errorf("subject injected a header:\n%s", body)
+	}
+	if !strings.HasSuffix(string(body), "\r\n\r\nline 1\r\nline 2") {
+		t.Errorf("body = %q", body)
+	}
+
+	// Messages without a recipient are skipped
+	body = nil
+	if err := e.Notify(context.Background(), Message{Subject: "x"}); err != nil || body != nil {
+		t.Errorf("Notify() without recipient = %v, sent %q", err, body)
+	}
+}
+
+func TestAsync(t *testing.T) {
+	queue := jobs.NewMemoryQueue(1, 1)
+	next := &recorder{}
+	if err := NewAsync(next, queue).Notify(context.Background(), Message{Kind: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(next.messages) != 1 {
+		t.Errorf("delivered %d messages, want 1", len(next.messages))
+	}
+}
+
+func TestInbox(t *testing.T) {
+	inbox := NewInbox(2)
+	for _, subject := range []string{"first", "second", "third"} {
+		if err := inbox.Notify(context.Background(), Message{Email: "user@example.com", Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := inbox.Notify(context.Background(), Message{Subject: "no recipient"}); err != nil {
+		t.Fatal(err)
+	}
+
+	emails := inbox.Emails()
+	if len(emails) != 2 || emails[0].Subject != "third" || emails[1].Subject != "second" {
+		t.Fatalf("Emails() = %+v, want the last 2 newest first", emails)
+	}
+	if emails[0].ID != 3 || emails[0].To != "user@example.com" || emails[0].CapturedAt.IsZero() {
+		t.Errorf("unexpected email: %+v", emails[0])
+	}
+
+	inbox.Clear()
+	if emails := inbox.Emails(); len(emails) != 0 {
+		t.Errorf("Emails() after Clear = %+v", emails)
+	}
+}
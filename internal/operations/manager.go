@@ -0,0 +1,256 @@
+// Package operations tracks long-running tasks started by API requests.
+// A task is persisted as a models.Operation, executed on the job queue, and
+// polled by clients through GET /api/operations/:id.
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+var (
+	// ErrNotFound is returned when an operation does not exist or has expired.
+	ErrNotFound = errors.New("operation not found")
+	// ErrFinished is returned when canceling an operation that already finished.
+	ErrFinished = errors.New("operation already finished")
+)
+
+// Task is the work performed by an operation. It should return promptly when ctx is
+// canceled and may report progress through p. The returned result is stored as JSON.
+type Task func(ctx context.Context, p *Progress) (interface{}, error)
+
+// Manager starts, tracks, and cancels operations.
+type Manager struct {
+	db        *gorm.DB
+	queue     jobs.Queue
+	retention time.Duration
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// NewManager creates a Manager that runs tasks on queue and keeps finished operations for retention.
+func NewManager(db *gorm.DB, queue jobs.Queue, retention time.Duration) *Manager {
+	return &Manager{
+		db:        db,
+		queue:     queue,
+		retention: retention,
+		cancels:   make(map[string]context.CancelFunc),
+	}
+}
+
+// Start persists a pending operation owned by userID and enqueues task.
+func (m *Manager) Start(ctx context.Context, userID uint, opType string, task Task) (*models.Operation, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate operation id: %w", err)
+	}
+
+	op := &models.Operation{
+		ID:     id.String(),
+		Type:   opType,
+		Status: models.OperationPending,
+		UserID: userID,
+	}
+	if err := m.db.WithContext(ctx).Create(op).Error; err != nil {
+		return nil, fmt.Errorf("failed to create operation: %w", err)
+	}
+
+	taskCtx, cancel := context.WithCancel(context.Background())
+	m.mu.Lock()
+	m.cancels[op.ID] = cancel
+	m.mu.Unlock()
+
+	err = m.queue.Enqueue(jobs.Job{
+		ID:   op.ID,
+		Name: opType,
+		Run: func(queueCtx context.Context) error {
+			// Stop the task when either the queue shuts down or the operation is canceled.
+			stop := context.AfterFunc(queueCtx, cancel)
+			defer stop()
+			return m.run(taskCtx, op.ID, task)
+		},
+	})
+	if err != nil {
+		m.forget(op.ID)
+		m.finish(op.ID, models.OperationFailed, "", err.Error())
+		return nil, fmt.Errorf("failed to enqueue operation: %w", err)
+	}
+
+	return op, nil
+}
+
+// Get returns an operation by ID.
+func (m *Manager) Get(ctx context.Context, id string) (*models.Operation, error) {
+	var op models.Operation
+	if err := m.db.WithContext(ctx).First(&op, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &op, nil
+}
+
+// Cancel requests cancellation of a pending or running operation. Pending operations are
+// marked canceled immediately; running ones are canceled once the task observes its context.
+func (m *Manager) Cancel(ctx context.Context, id string) (*models.Operation, error) {
+	op, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if op.IsFinished() {
+		return op, ErrFinished
+	}
+
+	m.mu.Lock()
+	cancel, ok := m.cancels[id]
+	m.mu.Unlock()
+	if ok {
+		cancel()
+	}
+
+	// Only a pending operation can be finalized here; a running one is finalized by its worker.
+	result := m.db.WithContext(ctx).Model(&models.Operation{}).
+		Where("id = ? AND status = ?", id, models.OperationPending).
+		Updates(map[string]interface{}{
+			"status":      models.OperationCanceled,
+			"finished_at": time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return m.Get(ctx, id)
+}
+
+// PurgeExpired deletes operations that finished longer ago than the retention period.
+func (m *Manager) PurgeExpired(ctx context.Context) (int64, error) {
+	cutoff := time.Now().Add(-m.retention)
+	result := m.db.WithContext(ctx).
+		Where("finished_at IS NOT NULL AND finished_at < ?", cutoff).
+		Delete(&models.Operation{})
+	return result.RowsAffected, result.Error
+}
+
+// RunJanitor purges expired operations every interval until ctx is canceled.
+func (m *Manager) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := m.PurgeExpired(ctx)
+			if err != nil {
+				logger.WithField("error", err.Error()).Error("Failed to purge expired operations")
+				continue
+			}
+			if purged > 0 {
+				logger.WithField("purged", purged).Info("Purged expired operations")
+			}
+		}
+	}
+}
+
+// run executes a task and records its outcome.
+func (m *Manager) run(ctx context.Context, id string, task Task) error {
+	defer m.forget(id)
+
+	// Claim the operation; it may have been canceled while waiting in the queue.
+	claimed := m.db.Model(&models.Operation{}).
+		Where("id = ? AND status = ?", id, models.OperationPending).
+		Update("status", models.OperationRunning)
+	if claimed.Error != nil {
+		return claimed.Error
+	}
+	if claimed.RowsAffected == 0 {
+		return nil
+	}
+
+	result, err := task(ctx, &Progress{db: m.db, id: id})
+
+	switch {
+	case ctx.Err() != nil:
+		m.finish(id, models.OperationCanceled, "", "")
+		return nil
+	case err != nil:
+		m.finish(id, models.OperationFailed, "", err.Error())
+		return err
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		m.finish(id, models.OperationFailed, "", fmt.Sprintf("failed to encode result: %v", err))
+		return err
+	}
+	m.finish(id, models.OperationSucceeded, string(encoded), "")
+	return nil
+}
+
+// finish stores the final state of an operation.
+func (m *Manager) finish(id, status, result, errMsg string) {
+	updates := map[string]interface{}{
+		"status":      status,
+		"result":      result,
+		"error":       errMsg,
+		"finished_at": time.Now(),
+	}
+	if status == models.OperationSucceeded {
+		updates["progress"] = 100
+	}
+
+	if err := m.db.Model(&models.Operation{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"operation_id": id,
+			"error":        err.Error(),
+		}).Error("Failed to record operation result")
+	}
+}
+
+func (m *Manager) forget(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cancel, ok := m.cancels[id]; ok {
+		cancel()
+		delete(m.cancels, id)
+	}
+}
+
+// Progress reports the progress of a running operation.
+type Progress struct {
+	db *gorm.DB
+	id string
+}
+
+// Update records the completion percentage (0-100) and a short status message.
+func (p *Progress) Update(percent int, message string) {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+
+	err := p.db.Model(&models.Operation{}).Where("id = ?", p.id).
+		Updates(map[string]interface{}{"progress": percent, "message": message}).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"operation_id": p.id,
+			"error":        err.Error(),
+		}).Warn("Failed to record operation progress")
+	}
+}
@@ -0,0 +1,114 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func setupManager(t *testing.T) *Manager {
+	t.Helper()
+	db := testutil.NewDB(t)
+
+	queue := jobs.NewMemoryQueue(1, 10)
+	t.Cleanup(func() { _ = queue.Shutdown(context.Background()) })
+	return NewManager(db, queue, time.Hour)
+}
+
+// waitFor polls an operation until it reaches a final state.
+func waitFor(t *testing.T, m *Manager, id string) *models.Operation {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		op, err := m.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+		if op.IsFinished() {
+			return op
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("operation %s did not finish", id)
+	return nil
+}
+
+func TestManagerRecordsResult(t *testing.T) {
+	m := setupManager(t)
+
+	op, err := m.Start(context.Background(), 1, "test", func(_ context.Context, p *Progress) (interface{}, error) {
+		p.Update(50, "halfway")
+		return map[string]int{"answer": 42}, nil
+	})
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if op.Status != models.OperationPending {
+		t.Fatalf("expected pending operation, got %s", op.Status)
+	}
+
+	done := waitFor(t, m, op.ID)
+	if done.Status != models.OperationSucceeded || done.Progress != 100 || done.Result != `{"answer":42}` {
+		t.Fatalf("unexpected finished operation: %+v", done)
+	}
+}
+
+func TestManagerRecordsFailure(t *testing.T) {
+	m := setupManager(t)
+
+	op, _ := m.Start(context.Background(), 1, "test", func(context.Context, *Progress) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	done := waitFor(t, m, op.ID)
+	if done.Status != models.OperationFailed || done.Error != "boom" {
+		t.Fatalf("unexpected finished operation: %+v", done)
+	}
+}
+
+func TestManagerCancelsRunningOperation(t *testing.T) {
+	m := setupManager(t)
+	started := make(chan struct{})
+
+	op, _ := m.Start(context.Background(), 1, "test", func(ctx context.Context, _ *Progress) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+
+	if _, err := m.Cancel(context.Background(), op.ID); err != nil {
+		t.Fatalf("Cancel returned error: %v", err)
+	}
+
+	done := waitFor(t, m, op.ID)
+	if done.Status != models.OperationCanceled {
+		t.Fatalf("expected canceled operation, got %s", done.Status)
+	}
+	if _, err := m.Cancel(context.Background(), op.ID); !errors.Is(err, ErrFinished) {
+		t.Fatalf("expected ErrFinished when canceling twice, got %v", err)
+	}
+}
+
+func TestManagerPurgesExpiredOperations(t *testing.T) {
+	m := setupManager(t)
+	m.retention = 0
+
+	op, _ := m.Start(context.Background(), 1, "test", func(context.Context, *Progress) (interface{}, error) {
+		return nil, nil
+	})
+	waitFor(t, m, op.ID)
+
+	purged, err := m.PurgeExpired(context.Background())
+	if err != nil || purged != 1 {
+		t.Fatalf("expected 1 purged operation, got %d (%v)", purged, err)
+	}
+	if _, err := m.Get(context.Background(), op.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after purge, got %v", err)
+	}
+}
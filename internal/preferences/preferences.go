@@ -0,0 +1,216 @@
+// Package preferences defines the user preferences: typed settings grouped in categories,
+// with their defaults in code. Only the settings a user changes are stored, as JSON values
+// keyed by "category.setting", so new settings and new defaults apply to everyone else.
+package preferences
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	// Time zones are validated without relying on the zoneinfo of the host
+	_ "time/tzdata"
+
+	"golang.org/x/text/language"
+)
+
+// Type is the JSON type of a setting.
+type Type string
+
+// Setting types, named as in JSON Schema.
+const (
+	Boolean Type = "boolean"
+	String  Type = "string"
+	Integer Type = "integer"
+)
+
+// Setting is a single preference with its type, default, and constraints.
+type Setting struct {
+	Type        Type
+	Default     interface{}
+	Description string
+	// Enum lists the allowed values of a String setting; empty allows any string that
+	// passes Check.
+	Enum []string
+	// Min and Max bound an Integer setting.
+	Min, Max int
+	// Parse validates a String value of the given Format and returns its canonical form.
+	Parse  func(string) (string, bool)
+	Format string
+}
+
+// Values holds preference values by category and setting.
+type Values map[string]map[string]interface{}
+
+// Schema lists the preference categories and their settings. Add settings here; stored values
+// of settings removed from it are ignored.
+var Schema = map[string]map[string]Setting{
+	"notifications": {
+		"email":        {Type: Boolean, Default: true, Description: "Receive notifications by email"},
+		"in_app":       {Type: Boolean, Default: true, Description: "Receive in-app notifications"},
+		"login_alerts": {Type: Boolean, Default: true, Description: "Be alerted of logins from a new device or country"},
+		"digest": {Type: String, Default: "off", Enum: []string{"off", "daily", "weekly"},
+			Description: "Summary email of the account activity"},
+	},
+	"locale": {
+		"language": {Type: String, Default: "en", Parse: parseLanguageTag, Format: "BCP 47 language tag",
+			Description: "Language of the interface and emails"},
+		"timezone": {Type: String, Default: "UTC", Parse: parseTimeZone, Format: "IANA time zone",
+			Description: "Time zone dates are shown in"},
+		"time_format": {Type: String, Default: "24h", Enum: []string{"12h", "24h"}, Description: "Clock format"},
+		"first_day_of_week": {Type: Integer, Default: 1, Min: 0, Max: 6,
+			Description: "First day of the week in calendars, 0 being Sunday"},
+	},
+	"theme": {
+		"mode": {Type: String, Default: "system", Enum: []string{"system", "light", "dark"}, Description: "Color scheme"},
+		"density": {Type: String, Default: "comfortable", Enum: []string{"comfortable", "compact"},
+			Description: "Spacing of lists and tables"},
+		"font_scale": {Type: Integer, Default: 100, Min: 75, Max: 200, Description: "Text size, in percent"},
+	},
+}
+
+// Key returns the storage key of a setting.
+func Key(category, name string) string {
+	return category + "." + name
+}
+
+// Lookup returns the setting of a category.
+func Lookup(category, name string) (Setting, bool) {
+	s, ok := Schema[category][name]
+	return s, ok
+}
+
+// Defaults returns the default value of every setting.
+func Defaults() Values {
+	values := make(Values, len(Schema))
+	for category, settings := range Schema {
+		values[category] = make(map[string]interface{}, len(settings))
+		for name, s := range settings {
+			values[category][name] = s.Default
+		}
+	}
+	return values
+}
+
+// Resolve returns the defaults overridden by the stored values, keyed as by Key. Stored
+// values that no longer match the schema are ignored.
+func Resolve(stored map[string]string) Values {
+	values := Defaults()
+	for category, settings := range Schema {
+		for name, s := range settings {
+			raw, ok := stored[Key(category, name)]
+			if !ok {
+				continue
+			}
+			if v, err := s.Decode(json.RawMessage(raw)); err == nil {
+				values[category][name] = v
+			}
+		}
+	}
+	return values
+}
+
+// Decode parses a JSON value of the setting and checks its constraints; errors describe the
+// expected value. Integers decode as int and the other types as their Go equivalent.
+func (s Setting) Decode(raw json.RawMessage) (interface{}, error) {
+	switch s.Type {
+	case Boolean:
+		var v bool
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, errors.New("must be a boolean")
+		}
+		return v, nil
+	case Integer:
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		if v < s.Min || v > s.Max {
+			return nil, fmt.Errorf("must be between %d and %d", s.Min, s.Max)
+		}
+		return v, nil
+	default:
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, errors.New("must be a string")
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, v) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(s.Enum, ", "))
+		}
+		if s.Parse != nil {
+			canonical, ok := s.Parse(v)
+			if !ok {
+				return nil, fmt.Errorf("must be a valid %s", s.Format)
+			}
+			v = canonical
+		}
+		return v, nil
+	}
+}
+
+// JSONSchema describes the preferences document as a JSON Schema, for clients that build
+// settings forms from it.
+func JSONSchema() map[string]interface{} {
+	categories := make(map[string]interface{}, len(Schema))
+	for category, settings := range Schema {
+		properties := make(map[string]interface{}, len(settings))
+		for name, s := range settings {
+			property := map[string]interface{}{
+				"type":        string(s.Type),
+				"default":     s.Default,
+				"description": s.Description,
+			}
+			if len(s.Enum) > 0 {
+				property["enum"] = s.Enum
+			}
+			if s.Format != "" {
+				property["format"] = s.Format
+			}
+			if s.Type == Integer {
+				property["minimum"] = s.Min
+				property["maximum"] = s.Max
+			}
+			properties[name] = property
+		}
+		categories[category] = map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	}
+	return map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"type":                 "object",
+		"properties":           categories,
+		"additionalProperties": false,
+	}
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func parseLanguageTag(v string) (string, bool) {
+	tag, err := language.Parse(v)
+	if err != nil {
+		return "", false
+	}
+	return tag.String(), true
+}
+
+func parseTimeZone(v string) (string, bool) {
+	if v == "" || v == "Local" {
+		return "", false
+	}
+	loc, err := time.LoadLocation(v)
+	if err != nil {
+		return "", false
+	}
+	return loc.String(), true
+}
@@ -0,0 +1,63 @@
+package preferences
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSchema_DefaultsAreValid(t *testing.T) {
+	for category, settings := range Schema {
+		for name, s := range settings {
+			raw, _ := json.Marshal(s.Default)
+			v, err := s.Decode(raw)
+			if err != nil || v != s.Default {
+				t.Errorf("%s: default %v decodes to %v, %v", Key(category, name), s.Default, v, err)
+			}
+		}
+	}
+}
+
+func TestSetting_Decode(t *testing.T) {
+	tests := []struct {
+		category, name, raw string
+		want                interface{}
+		wantErr             bool
+	}{
+		{"theme", "mode", `"dark"`, "dark", false},
+		{"theme", "mode", `"neon"`, nil, true},
+		{"theme", "font_scale", `150`, 150, false},
+		{"theme", "font_scale", `1.5`, nil, true},
+		{"theme", "font_scale", `20`, nil, true},
+		{"notifications", "email", `false`, false, false},
+		{"notifications", "email", `"false"`, nil, true},
+		{"locale", "language", `"es-co"`, "es-CO", false},
+		{"locale", "language", `"not a tag"`, nil, true},
+		{"locale", "timezone", `"Europe/Madrid"`, "Europe/Madrid", false},
+		{"locale", "timezone", `"Local"`, nil, true},
+	}
+	for _, tt := range tests {
+		s, _ := Lookup(tt.category, tt.name)
+		got, err := s.Decode(json.RawMessage(tt.raw))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s.Decode(%s) = %v, %v; want %v", Key(tt.category, tt.name), tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	values := Resolve(map[string]string{
+		"theme.mode":      `"light"`,
+		"theme.density":   `"huge"`,
+		"theme.removed":   `true`,
+		"locale.timezone": `not json`,
+	})
+	if values["theme"]["mode"] != "light" {
+		t.Errorf("theme.mode = %v, want light", values["theme"]["mode"])
+	}
+	if values["theme"]["density"] != "comfortable" || values["locale"]["timezone"] != "UTC" {
+		t.Errorf("invalid stored values were not ignored: %+v", values)
+	}
+	if _, ok := values["theme"]["removed"]; ok {
+		t.Error("settings missing from the schema were resolved")
+	}
+}
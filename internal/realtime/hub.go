@@ -0,0 +1,308 @@
+// Package realtime provides an in-process hub of WebSocket clients grouped in channels, with
+// the presence of users in each channel and typing indicators.
+package realtime
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Message types. Clients send heartbeat and typing; the hub sends the others.
+const (
+	TypeHeartbeat     = "heartbeat"
+	TypeTyping        = "typing"
+	TypePresenceState = "presence.state"
+	TypePresenceJoin  = "presence.join"
+	TypePresenceLeave = "presence.leave"
+)
+
+// DefaultTTL is how long clients stay online when NewHub is given no TTL.
+const DefaultTTL = time.Minute
+
+// sendBuffer is how many messages may wait for a client before it is dropped as too slow.
+const sendBuffer = 32
+
+// ErrFull is returned by Join when the hub holds its maximum number of clients.
+var ErrFull = errors.New("realtime: too many connections")
+
+// channelRegex matches the channel names clients may join.
+var channelRegex = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,100}$`)
+
+// ValidChannel reports whether name is a valid channel name: 1 to 100 letters, digits, and
+// "_", ".", ":" or "-".
+func ValidChannel(name string) bool {
+	return channelRegex.MatchString(name)
+}
+
+// Message is a message exchanged with the clients of a channel.
+type Message struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+	// UserID is the user who joined, left or is typing.
+	UserID uint `json:"user_id,omitempty"`
+	// Members are the users online in the channel, in presence.state messages.
+	Members []Member  `json:"members,omitempty"`
+	At      time.Time `json:"at,omitempty"`
+}
+
+// Member is a user online in a channel.
+type Member struct {
+	UserID   uint      `json:"user_id"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Client is a connection of a user to a channel.
+type Client struct {
+	channel string
+	userID  uint
+	send    chan Message
+	done    chan struct{}
+	// lastSeen is guarded by the hub's mutex.
+	lastSeen time.Time
+	dropOnce sync.Once
+}
+
+// Channel returns the channel of the client.
+func (c *Client) Channel() string {
+	return c.channel
+}
+
+// UserID returns the user of the client.
+func (c *Client) UserID() uint {
+	return c.userID
+}
+
+// Messages returns the messages to send to the client.
+func (c *Client) Messages() <-chan Message {
+	return c.send
+}
+
+// Done is closed when the hub drops the client: its presence expired, it did not keep up with
+// its messages, or the hub was closed. The connection should then be closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Client) drop() {
+	c.dropOnce.Do(func() { close(c.done) })
+}
+
+// Hub holds the clients of every channel. A user is online in a channel while one of their
+// clients there has been seen within the TTL; clients send heartbeats to stay online, and Run
+// drops those that stop. Presence is per instance: clients of other instances are not seen. It
+// is safe for concurrent use.
+type Hub struct {
+	ttl        time.Duration
+	maxClients int
+	now        func() time.Time
+
+	mu       sync.Mutex
+	channels map[string]map[*Client]struct{}
+	clients  int
+}
+
+// NewHub creates a hub in which clients stay online for ttl (DefaultTTL when ttl <= 0) after
+// their last message, holding at most maxClients clients; maxClients <= 0 means unbounded.
+func NewHub(ttl time.Duration, maxClients int) *Hub {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Hub{
+		ttl:        ttl,
+		maxClients: maxClients,
+		now:        time.Now,
+		channels:   make(map[string]map[*Client]struct{}),
+	}
+}
+
+// TTL returns how long clients stay online without sending messages.
+func (h *Hub) TTL() time.Duration {
+	return h.ttl
+}
+
+// Join adds a client of userID to channel. The client first receives the presence.state of the
+// channel, and the other clients a presence.join unless the user was already online there.
+func (h *Hub) Join(channel string, userID uint) (*Client, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.maxClients > 0 && h.clients >= h.maxClients {
+		return nil, ErrFull
+	}
+	now := h.now()
+	c := &Client{
+		channel:  channel,
+		userID:   userID,
+		send:     make(chan Message, sendBuffer),
+		done:     make(chan struct{}),
+		lastSeen: now,
+	}
+	online := h.online(channel, userID)
+	clients := h.channels[channel]
+	if clients == nil {
+		clients = make(map[*Client]struct{})
+		h.channels[channel] = clients
+	}
+	clients[c] = struct{}{}
+	h.clients++
+
+	deliver(c, Message{Type: TypePresenceState, Channel: channel, Members: h.members(channel, now), At: now})
+	if !online {
+		h.broadcast(channel, Message{Type: TypePresenceJoin, Channel: channel, UserID: userID, At: now}, c)
+	}
+	return c, nil
+}
+
+// Leave removes c from its channel and drops it. The other clients receive a presence.leave
+// when it was the last client of its user there. Leaving again does nothing.
+func (h *Hub) Leave(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(c)
+}
+
+// Heartbeat keeps the user of c online.
+func (h *Hub) Heartbeat(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c.lastSeen = h.now()
+}
+
+// Typing tells the other clients of the channel of c that its user is typing, and keeps the
+// user online.
+func (h *Hub) Typing(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.channels[c.channel][c]; !ok {
+		return
+	}
+	c.lastSeen = h.now()
+	h.broadcast(c.channel, Message{Type: TypeTyping, Channel: c.channel, UserID: c.userID, At: c.lastSeen}, c)
+}
+
+// Online returns the users online in channel, by user ID.
+func (h *Hub) Online(channel string) []Member {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.members(channel, h.now())
+}
+
+// Sweep removes the clients not seen within the TTL, as Leave does, and returns how many were
+// removed.
+func (h *Hub) Sweep() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := h.now().Add(-h.ttl)
+	var expired []*Client
+	for _, clients := range h.channels {
+		for c := range clients {
+			if c.lastSeen.Before(cutoff) {
+				expired = append(expired, c)
+			}
+		}
+	}
+	for _, c := range expired {
+		h.leave(c)
+	}
+	return len(expired)
+}
+
+// Run sweeps the hub every interval until ctx is done.
+func (h *Hub) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.Sweep()
+		}
+	}
+}
+
+// Close drops every client, so that their connections are closed on shutdown.
+func (h *Hub) Close(context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, clients := range h.channels {
+		for c := range clients {
+			c.drop()
+		}
+	}
+	h.channels = make(map[string]map[*Client]struct{})
+	h.clients = 0
+	return nil
+}
+
+func (h *Hub) leave(c *Client) {
+	clients := h.channels[c.channel]
+	if _, ok := clients[c]; !ok {
+		c.drop()
+		return
+	}
+	delete(clients, c)
+	h.clients--
+	c.drop()
+	if len(clients) == 0 {
+		delete(h.channels, c.channel)
+	}
+	if !h.online(c.channel, c.userID) {
+		h.broadcast(c.channel, Message{Type: TypePresenceLeave, Channel: c.channel, UserID: c.userID, At: h.now()}, nil)
+	}
+}
+
+// online reports whether userID has a client in channel.
+func (h *Hub) online(channel string, userID uint) bool {
+	for c := range h.channels[channel] {
+		if c.userID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// members returns the users of channel seen within the TTL, with the last time any of their
+// clients was seen.
+func (h *Hub) members(channel string, now time.Time) []Member {
+	cutoff := now.Add(-h.ttl)
+	seen := make(map[uint]time.Time)
+	for c := range h.channels[channel] {
+		if c.lastSeen.Before(cutoff) {
+			continue
+		}
+		if last, ok := seen[c.userID]; !ok || c.lastSeen.After(last) {
+			seen[c.userID] = c.lastSeen
+		}
+	}
+	members := make([]Member, 0, len(seen))
+	for userID, last := range seen {
+		members = append(members, Member{UserID: userID, LastSeen: last})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+	return members
+}
+
+// broadcast delivers msg to the clients of channel other than except.
+func (h *Hub) broadcast(channel string, msg Message, except *Client) {
+	for c := range h.channels[channel] {
+		if c != except {
+			deliver(c, msg)
+		}
+	}
+}
+
+// deliver queues msg for c, dropping c when its queue is full so that a slow client never
+// blocks the hub. The connection then closes and the client leaves.
+func deliver(c *Client, msg Message) {
+	select {
+	case c.send <- msg:
+	default:
+		c.drop()
+	}
+}
@@ -0,0 +1,183 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestHub(ttl time.Duration, maxClients int) (*Hub, *time.Time) {
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+	h := NewHub(ttl, maxClients)
+	h.now = func() time.Time { return now }
+	return h, &now
+}
+
+// next returns the next message queued for c, failing when there is none.
+func next(t *testing.T, c *Client) Message {
+	t.Helper()
+	select {
+	case msg := <-c.Messages():
+		return msg
+	default:
+		t.Fatalf("no message for user %d", c.UserID())
+		return Message{}
+	}
+}
+
+func assertNoMessage(t *testing.T, c *Client) {
+	t.Helper()
+	select {
+	case msg := <-c.Messages():
+		t.Fatalf("unexpected message for user %d: %+v", c.UserID(), msg)
+	default:
+	}
+}
+
+func TestValidChannel(t *testing.T) {
+	for name, want := range map[string]bool{
+		"room-1":        true,
+		"doc:42.cursor": true,
+		"":              false,
+		"room 1":        false,
+		"room/1":        false,
+	} {
+		if got := ValidChannel(name); got != want {
+			t.Errorf("ValidChannel(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestHubPresence(t *testing.T) {
+	h, _ := newTestHub(time.Minute, 0)
+
+	alice, err := h.Join("room", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := next(t, alice); msg.Type != TypePresenceState || len(msg.Members) != 1 || msg.Members[0].UserID != 1 {
+		t.Fatalf("first message = %+v, want the presence state with alice", msg)
+	}
+
+	bob, _ := h.Join("room", 2)
+	if msg := next(t, bob); msg.Type != TypePresenceState || len(msg.Members) != 2 {
+		t.Fatalf("bob's state = %+v", msg)
+	}
+	if msg := next(t, alice); msg.Type != TypePresenceJoin || msg.UserID != 2 {
+		t.Fatalf("alice received %+v, want bob's join", msg)
+	}
+
+	// A second tab of bob neither joins nor leaves him again
+	tab, _ := h.Join("room", 2)
+	next(t, tab)
+	assertNoMessage(t, alice)
+	h.Leave(tab)
+	assertNoMessage(t, alice)
+
+	// Other channels are not told
+	other, _ := h.Join("other", 3)
+	next(t, other)
+	assertNoMessage(t, alice)
+
+	h.Typing(bob)
+	if msg := next(t, alice); msg.Type != TypeTyping || msg.UserID != 2 {
+		t.Fatalf("alice received %+v, want bob typing", msg)
+	}
+	assertNoMessage(t, bob)
+
+	h.Leave(bob)
+	if msg := next(t, alice); msg.Type != TypePresenceLeave || msg.UserID != 2 {
+		t.Fatalf("alice received %+v, want bob's leave", msg)
+	}
+	select {
+	case <-bob.Done():
+	default:
+		t.Error("a client that left was not dropped")
+	}
+	h.Leave(bob)
+	assertNoMessage(t, alice)
+
+	if members := h.Online("room"); len(members) != 1 || members[0].UserID != 1 {
+		t.Errorf("Online() = %+v, want alice", members)
+	}
+}
+
+func TestHubSweepsExpiredClients(t *testing.T) {
+	h, now := newTestHub(time.Minute, 0)
+	alice, _ := h.Join("room", 1)
+	bob, _ := h.Join("room", 2)
+	next(t, alice)
+	next(t, alice)
+	next(t, bob)
+
+	*now = now.Add(40 * time.Second)
+	h.Heartbeat(alice)
+	*now = now.Add(40 * time.Second)
+
+	// Bob is no longer listed once his TTL passed, even before the sweep
+	if members := h.Online("room"); len(members) != 1 || members[0].UserID != 1 || !members[0].LastSeen.Equal(now.Add(-40*time.Second)) {
+		t.Fatalf("Online() = %+v, want alice seen 40s ago", members)
+	}
+	if n := h.Sweep(); n != 1 {
+		t.Fatalf("Sweep() = %d, want 1", n)
+	}
+	if msg := next(t, alice); msg.Type != TypePresenceLeave || msg.UserID != 2 {
+		t.Fatalf("alice received %+v, want bob's leave", msg)
+	}
+	select {
+	case <-bob.Done():
+	default:
+		t.Error("an expired client was not dropped")
+	}
+	select {
+	case <-alice.Done():
+		t.Error("a client sending heartbeats was dropped")
+	default:
+	}
+}
+
+func TestHubLimitsClients(t *testing.T) {
+	h, _ := newTestHub(time.Minute, 1)
+	c, err := h.Join("room", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Join("room", 2); !errors.Is(err, ErrFull) {
+		t.Fatalf("Join() over the limit error = %v, want ErrFull", err)
+	}
+	h.Leave(c)
+	if _, err := h.Join("room", 2); err != nil {
+		t.Errorf("Join() after a leave error = %v", err)
+	}
+}
+
+func TestHubDropsSlowClients(t *testing.T) {
+	h, _ := newTestHub(time.Minute, 0)
+	slow, _ := h.Join("room", 1)
+	typist, _ := h.Join("room", 2)
+	for i := 0; i < sendBuffer; i++ {
+		h.Typing(typist)
+	}
+	select {
+	case <-slow.Done():
+	default:
+		t.Error("a client with a full queue was not dropped")
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	h, _ := newTestHub(time.Minute, 0)
+	c, _ := h.Join("room", 1)
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Error("Close() did not drop the clients")
+	}
+	if members := h.Online("room"); len(members) != 0 {
+		t.Errorf("Online() after Close() = %+v", members)
+	}
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+// CountMode selects how pagination totals are computed.
+type CountMode string
+
+// Count modes (DB_COUNT_MODE).
+const (
+	// CountExact runs COUNT(*) for every page.
+	CountExact CountMode = "exact"
+	// CountCached reuses totals for up to the staleness TTL.
+	CountCached CountMode = "cached"
+	// CountEstimated also answers large totals from the planner statistics on PostgreSQL.
+	CountEstimated CountMode = "estimated"
+)
+
+// ParseCountMode validates a DB_COUNT_MODE value; empty means CountExact.
+func ParseCountMode(s string) (CountMode, error) {
+	switch mode := CountMode(s); mode {
+	case "":
+		return CountExact, nil
+	case CountExact, CountCached, CountEstimated:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown count mode %q (want %s, %s or %s)", s, CountExact, CountCached, CountEstimated)
+	}
+}
+
+// countsName is the name the counter is registered under as a GORM plugin.
+const countsName = "repository:counts"
+
+var paginationCounts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "db_pagination_counts_total",
+	Help: "Pagination totals by table and source: query (COUNT), cache, or estimate (planner statistics).",
+}, []string{"table", "source"})
+
+func init() {
+	metrics.Registry.MustRegister(paginationCounts)
+}
+
+// Counter computes the totals of paginated listings, which on large tables cost more than the
+// page itself. In CountCached mode a total is reused for up to the TTL. The unfiltered total of
+// a table is kept exact by adding the rows created and subtracting the rows deleted through the
+// database the counter is attached to; filtered totals are dropped on any write to their table,
+// as the counter cannot tell whether the rows match. Writes inside an explicit transaction drop
+// the totals instead of adjusting them, since the transaction may roll back.
+//
+// In CountEstimated mode, on PostgreSQL, totals of at least the estimate threshold are taken
+// from the planner's row estimate (EXPLAIN) instead of counted; smaller ones, and every total
+// on other databases, are counted as in CountCached mode. Estimates are reported through
+// EstimatedCount.
+type Counter struct {
+	mode      CountMode
+	ttl       time.Duration
+	threshold int64
+	now       func() time.Time
+
+	mu     sync.Mutex
+	tables map[string]map[string]countEntry
+}
+
+type countEntry struct {
+	total     int64
+	estimated bool
+	at        time.Time
+}
+
+// NewCounter creates a counter in mode, reusing totals for ttl and estimating totals of at
+// least threshold rows.
+func NewCounter(mode CountMode, ttl time.Duration, threshold int64) *Counter {
+	return &Counter{
+		mode:      mode,
+		ttl:       ttl,
+		threshold: threshold,
+		now:       time.Now,
+		tables:    make(map[string]map[string]countEntry),
+	}
+}
+
+// UseCounter attaches c to db. Repositories created with db then compute their totals with it,
+// and writes through db adjust or drop them.
+func UseCounter(db *gorm.DB, c *Counter) error {
+	return db.Use(c)
+}
+
+// Name implements gorm.Plugin.
+func (c *Counter) Name() string {
+	return countsName
+}
+
+// Initialize implements gorm.Plugin by registering the callbacks that keep totals current.
+func (c *Counter) Initialize(db *gorm.DB) error {
+	adjust := func(sign int64) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			table := tx.Statement.Table
+			_, inTx := tx.Statement.ConnPool.(gorm.TxCommitter)
+			// Unscoped deletes may remove rows that were already soft-deleted, and so not counted,
+			// and upserts report updated rows as affected too
+			_, upsert := tx.Statement.Clauses["ON CONFLICT"]
+			switch {
+			case table == "":
+				c.Drop()
+			case tx.Error != nil || inTx || tx.Statement.Unscoped || upsert:
+				c.Drop(table)
+			default:
+				c.adjust(table, sign*tx.RowsAffected)
+			}
+		}
+	}
+	update := func(tx *gorm.DB) {
+		// An unscoped update may restore soft-deleted rows
+		c.dropFiltered(tx.Statement.Table, tx.Statement.Unscoped)
+	}
+	raw := func(*gorm.DB) {
+		c.Drop()
+	}
+
+	// After the implicit transaction of single writes, if any, has committed or rolled back
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:commit_or_rollback_transaction").Register("repository:counts_create", adjust(1)); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:commit_or_rollback_transaction").Register("repository:counts_delete", adjust(-1)); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:commit_or_rollback_transaction").Register("repository:counts_update", update); err != nil {
+		return err
+	}
+	// Raw statements cannot be attributed to a table
+	return cb.Raw().After("gorm:raw").Register("repository:counts_raw", raw)
+}
+
+// Drop forgets the totals of tables, or of every table when none is given.
+func (c *Counter) Drop(tables ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(tables) == 0 {
+		clear(c.tables)
+		return
+	}
+	for _, table := range tables {
+		delete(c.tables, table)
+	}
+}
+
+// dropFiltered forgets the filtered totals of table, and its unfiltered total too with all.
+func (c *Counter) dropFiltered(table string, all bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if table == "" {
+		clear(c.tables)
+		return
+	}
+	if all {
+		delete(c.tables, table)
+		return
+	}
+	if entry, ok := c.tables[table][""]; ok {
+		c.tables[table] = map[string]countEntry{"": entry}
+	} else {
+		delete(c.tables, table)
+	}
+}
+
+// adjust adds delta to the unfiltered total of table and drops its filtered totals.
+func (c *Counter) adjust(table string, delta int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.tables[table][""]
+	delete(c.tables, table)
+	if ok && !entry.estimated && entry.total+delta >= 0 {
+		entry.total += delta
+		c.tables[table] = map[string]countEntry{"": entry}
+	}
+}
+
+func (c *Counter) get(table, filter string) (countEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.tables[table][filter]
+	if !ok || c.now().Sub(entry.at) >= c.ttl {
+		return countEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *Counter) set(table, filter string, entry countEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tables[table] == nil {
+		c.tables[table] = make(map[string]countEntry)
+	}
+	c.tables[table][filter] = entry
+}
+
+// count returns the number of rows of query, a query on table, from the counter attached to
+// db, if any. filter identifies the query's conditions and must be empty for an unfiltered
+// count. Inside a transaction rows are always counted.
+func count(ctx context.Context, db, query *gorm.DB, table, filter string) (int64, error) {
+	c, _ := db.Config.Plugins[countsName].(*Counter)
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); c == nil || c.mode == CountExact || inTx {
+		var total int64
+		err := query.Count(&total).Error
+		return total, err
+	}
+
+	if entry, ok := c.get(table, filter); ok {
+		paginationCounts.WithLabelValues(table, "cache").Inc()
+		reportEstimate(ctx, entry.estimated)
+		return entry.total, nil
+	}
+
+	// Taken before counting, so that the TTL bounds how stale the total can be
+	entry := countEntry{at: c.now()}
+	if c.mode == CountEstimated && db.Dialector.Name() == "postgres" {
+		estimate, err := explainRows(ctx, query)
+		if err != nil {
+			logger.WithFields(map[string]interface{}{"table": table, "error": err.Error()}).
+				Warn("Failed to estimate a row count, counting instead")
+		} else if estimate >= c.threshold {
+			entry.total, entry.estimated = estimate, true
+		}
+	}
+	if entry.estimated {
+		paginationCounts.WithLabelValues(table, "estimate").Inc()
+	} else {
+		if err := query.Count(&entry.total).Error; err != nil {
+			return 0, err
+		}
+		paginationCounts.WithLabelValues(table, "query").Inc()
+	}
+	c.set(table, filter, entry)
+	reportEstimate(ctx, entry.estimated)
+	return entry.total, nil
+}
+
+// explainRows returns the planner's estimate of the rows of query on PostgreSQL.
+func explainRows(ctx context.Context, query *gorm.DB) (int64, error) {
+	stmt := query.Session(&gorm.Session{DryRun: true}).Select("1").Find(&[]map[string]interface{}{}).Statement
+	var plan string
+	if err := stmt.ConnPool.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).Scan(&plan); err != nil {
+		return 0, err
+	}
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &plans); err != nil {
+		return 0, err
+	}
+	if len(plans) == 0 {
+		return 0, fmt.Errorf("empty query plan")
+	}
+	return int64(plans[0].Plan.Rows), nil
+}
+
+type countReportKey struct{}
+
+// countReport records whether a total returned in a context was estimated.
+type countReport struct {
+	mu        sync.Mutex
+	estimated bool
+}
+
+// WithCountReport returns a context in which repositories record whether the totals they
+// return are estimates, read with EstimatedCount.
+func WithCountReport(ctx context.Context) context.Context {
+	return context.WithValue(ctx, countReportKey{}, &countReport{})
+}
+
+// EstimatedCount reports whether a total returned in ctx, created with WithCountReport, was
+// an estimate rather than an exact count.
+func EstimatedCount(ctx context.Context) bool {
+	report, _ := ctx.Value(countReportKey{}).(*countReport)
+	if report == nil {
+		return false
+	}
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	return report.estimated
+}
+
+func reportEstimate(ctx context.Context, estimated bool) {
+	report, _ := ctx.Value(countReportKey{}).(*countReport)
+	if report == nil || !estimated {
+		return
+	}
+	report.mu.Lock()
+	report.estimated = true
+	report.mu.Unlock()
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/pagination"
+)
+
+func openCountedDB(t *testing.T, c *Counter) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := UseCounter(db, c); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func countQueries(t *testing.T) func() float64 {
+	t.Helper()
+	start := testutil.ToFloat64(paginationCounts.WithLabelValues(usersTable, "query"))
+	return func() float64 {
+		return testutil.ToFloat64(paginationCounts.WithLabelValues(usersTable, "query")) - start
+	}
+}
+
+func createUsers(t *testing.T, db *gorm.DB, names ...string) []models.User {
+	t.Helper()
+	users := make([]models.User, len(names))
+	for i, name := range names {
+		users[i] = models.User{Username: name, Email: name + "@example.com", Password: "hash"}
+		if err := db.Create(&users[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return users
+}
+
+func TestCounterAdjustsTotals(t *testing.T) {
+	counter := NewCounter(CountCached, time.Minute, 0)
+	db := openCountedDB(t, counter)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	page := pagination.Params{Page: 1, PerPage: 1}
+	queries := countQueries(t)
+
+	users := createUsers(t, db, "alice", "bob")
+	total := func(filter UserFilter) int64 {
+		t.Helper()
+		_, total, err := repo.List(ctx, filter, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total
+	}
+
+	if total(UserFilter{}) != 2 || total(UserFilter{}) != 2 || queries() != 1 {
+		t.Fatalf("expected the total to be counted once, got %v queries", queries())
+	}
+
+	// Creating and deleting adjust the unfiltered total without counting again
+	createUsers(t, db, "carol")
+	if got := total(UserFilter{}); got != 3 {
+		t.Fatalf("expected 3 users after a create, got %d", got)
+	}
+	if err := db.Delete(&users[0]).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := total(UserFilter{}); got != 2 || queries() != 1 {
+		t.Fatalf("expected 2 users after a delete from 1 query, got %d from %v", got, queries())
+	}
+
+	// Filtered totals are dropped on any write to the table
+	if total(UserFilter{Role: models.RoleAdmin}) != 0 || queries() != 2 {
+		t.Fatalf("expected the filtered total to be counted, got %v queries", queries())
+	}
+	if err := db.Model(&users[1]).Update("role", models.RoleAdmin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := total(UserFilter{Role: models.RoleAdmin}); got != 1 || queries() != 3 {
+		t.Fatalf("expected the filtered total to be counted again, got %d from %v queries", got, queries())
+	}
+	if total(UserFilter{}) != 2 || queries() != 3 {
+		t.Fatal("expected the unfiltered total to survive updates")
+	}
+
+	// Writes in a transaction may roll back, so they drop the total
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		createUsers(t, tx, "dave")
+		return gorm.ErrInvalidTransaction
+	})
+	if got := total(UserFilter{}); got != 2 || queries() != 4 {
+		t.Fatalf("expected the total to be counted again after a rollback, got %d from %v queries", got, queries())
+	}
+
+	// Totals expire after the TTL
+	now := time.Now()
+	counter.now = func() time.Time { return now.Add(time.Minute) }
+	total(UserFilter{})
+	if queries() != 5 {
+		t.Fatalf("expected the total to expire, got %v queries", queries())
+	}
+}
+
+func TestCounterExactCountsEveryTime(t *testing.T) {
+	db := openCountedDB(t, NewCounter(CountExact, time.Minute, 0))
+	repo := NewUserRepository(db)
+	createUsers(t, db, "alice")
+	queries := countQueries(t)
+
+	for range 2 {
+		if _, _, err := repo.List(context.Background(), UserFilter{}, pagination.Params{Page: 1, PerPage: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Exact counts are not recorded as cache misses
+	if queries() != 0 {
+		t.Fatalf("expected exact counts to bypass the counter, got %v queries", queries())
+	}
+}
+
+func TestCounterEstimatesOnlyOnPostgres(t *testing.T) {
+	db := openCountedDB(t, NewCounter(CountEstimated, time.Minute, 0))
+	createUsers(t, db, "alice")
+
+	ctx := WithCountReport(context.Background())
+	_, total, err := NewUserRepository(db).List(ctx, UserFilter{}, pagination.Params{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || EstimatedCount(ctx) {
+		t.Fatalf("expected an exact total on SQLite, got %d (estimated %v)", total, EstimatedCount(ctx))
+	}
+}
+
+func TestCountReport(t *testing.T) {
+	if EstimatedCount(context.Background()) {
+		t.Fatal("expected no estimate without a report")
+	}
+	ctx := WithCountReport(context.Background())
+	reportEstimate(ctx, false)
+	if EstimatedCount(ctx) {
+		t.Fatal("expected no estimate")
+	}
+	reportEstimate(ctx, true)
+	reportEstimate(ctx, false)
+	if !EstimatedCount(ctx) {
+		t.Fatal("expected the estimate to be reported")
+	}
+}
+
+func TestParseCountMode(t *testing.T) {
+	for _, s := range []string{"exact", "cached", "estimated"} {
+		if mode, err := ParseCountMode(s); err != nil || string(mode) != s {
+			t.Errorf("ParseCountMode(%q) = %q, %v", s, mode, err)
+		}
+	}
+	if _, err := ParseCountMode("approximate"); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// Retry hints of the database errors worth retrying.
+const (
+	deadlockRetryAfter    = time.Second
+	unavailableRetryAfter = 5 * time.Second
+)
+
+// TranslateError maps a failed query or transaction to the application error it means, with
+// message describing what failed, such as "Could not create API key":
+//
+//   - a missing record is a 404
+//   - a duplicate key or a foreign key violation is a 409
+//   - a deadlock or serialization failure is a 409 CONCURRENT_UPDATE with a Retry-After, as the
+//     request usually succeeds when retried
+//   - an unreachable database is a 503 with a Retry-After
+//
+// Application errors, such as those returned from a transaction, are returned as they are, and
+// any other error becomes a 500 whose cause is only logged.
+func TranslateError(err error, message string) *apperrors.Error {
+	var appErr *apperrors.Error
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apperrors.NotFound(message, "The record does not exist").Wrap(err)
+	case database.IsDuplicateKeyError(err):
+		return apperrors.Conflict(message, "A record with the same unique value already exists").Wrap(err)
+	case database.IsForeignKeyError(err):
+		return apperrors.Conflict(message, "The record references one that does not exist, or is still referenced by others").Wrap(err)
+	case database.IsDeadlockError(err):
+		return apperrors.New(response.CodeConcurrentUpdate, message).
+			WithDetails("The request conflicted with a concurrent one and nothing was changed; retry it").
+			WithRetryAfter(deadlockRetryAfter).
+			Wrap(err)
+	case database.IsConnectionError(err):
+		return apperrors.Unavailable(message, "The database is unavailable, please retry later").
+			WithRetryAfter(unavailableRetryAfter).
+			Wrap(err)
+	default:
+		return apperrors.Internal(message, "Database error occurred", err)
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+func TestTranslateError(t *testing.T) {
+	rejected := apperrors.Conflict("API key not created", "You have too many API keys")
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   *response.ErrorCode
+		wantStatus int
+		wantRetry  time.Duration
+	}{
+		{"application error", fmt.Errorf("transaction: %w", rejected), response.CodeConflict, http.StatusConflict, 0},
+		{"record not found", gorm.ErrRecordNotFound, response.CodeNotFound, http.StatusNotFound, 0},
+		{"duplicate key", gorm.ErrDuplicatedKey, response.CodeConflict, http.StatusConflict, 0},
+		{"foreign key", &mysql.MySQLError{Number: 1452}, response.CodeConflict, http.StatusConflict, 0},
+		{"deadlock", &mysql.MySQLError{Number: 1213}, response.CodeConcurrentUpdate, http.StatusConflict, time.Second},
+		{"unreachable", fmt.Errorf("query: %w", driver.ErrBadConn), response.CodeServiceUnavailable, http.StatusServiceUnavailable, 5 * time.Second},
+		{"other error", errors.New("syntax error"), response.CodeInternal, http.StatusInternalServerError, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TranslateError(tt.err, "Could not save")
+			if got.Code != tt.wantCode || got.Status() != tt.wantStatus || got.RetryAfter != tt.wantRetry {
+				t.Fatalf("got %s %d retry %s, want %s %d retry %s", got.Code, got.Status(), got.RetryAfter, tt.wantCode, tt.wantStatus, tt.wantRetry)
+			}
+			if !errors.Is(got, tt.err) && got != rejected {
+				t.Fatalf("expected %v to wrap %v", got, tt.err)
+			}
+		})
+	}
+}
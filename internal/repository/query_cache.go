@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/pkg/cache"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+const (
+	// queryCacheName is the name the cache is registered under as a GORM plugin.
+	queryCacheName = "repository:query_cache"
+
+	// allTables is the tag every cached query carries, invalidated by raw statements that cannot
+	// be attributed to a table.
+	allTables = "*"
+
+	queryKeyPrefix = "query:"
+	tagKeyPrefix   = "query-tag:"
+)
+
+var (
+	queryCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_cache_requests_total",
+		Help: "Cached repository queries by table and result: hit, miss, or error when the cache failed and the database was queried.",
+	}, []string{"table", "result"})
+	queryCacheInvalidations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_cache_invalidations_total",
+		Help: "Query cache invalidations by table; * counts raw statements, which invalidate every table.",
+	}, []string{"table"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(queryCacheRequests, queryCacheInvalidations)
+}
+
+// QueryCache caches the results of repository reads, keyed by the query and its arguments and
+// tagged with the tables they read. Creating, updating, or deleting rows of a table through the
+// database the cache is attached to invalidates every result tagged with it, and a raw
+// statement invalidates them all.
+//
+// Invalidation stores a new version for the tag rather than finding and deleting its entries,
+// so it works on any cache.Cache: entries of the old version are no longer looked up and expire
+// after the TTL. With a shared cache such as Redis, a write through one instance invalidates
+// the results cached by all of them.
+//
+// Writes in an explicit transaction are invalidated when they run, not when it commits, so a
+// read racing the transaction may cache the previous result until it expires; keep the TTL
+// short.
+// Reads inside a transaction are never cached.
+type QueryCache struct {
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewQueryCache creates a query cache storing results in c for ttl.
+func NewQueryCache(c cache.Cache, ttl time.Duration) *QueryCache {
+	return &QueryCache{cache: c, ttl: ttl}
+}
+
+// UseQueryCache attaches qc to db. Repositories created with db then read through it, and
+// writes through db invalidate it.
+func UseQueryCache(db *gorm.DB, qc *QueryCache) error {
+	return db.Use(qc)
+}
+
+// Name implements gorm.Plugin.
+func (q *QueryCache) Name() string {
+	return queryCacheName
+}
+
+// Initialize implements gorm.Plugin by registering the invalidation callbacks.
+func (q *QueryCache) Initialize(db *gorm.DB) error {
+	invalidate := func(tx *gorm.DB) {
+		table := tx.Statement.Table
+		if table == "" {
+			table = allTables
+		}
+		if err := q.Invalidate(tx.Statement.Context, table); err != nil {
+			logger.WithFields(map[string]interface{}{"table": table, "error": err.Error()}).
+				Warn("Failed to invalidate the query cache")
+		}
+	}
+
+	// After the implicit transaction of single writes, if any, has committed
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:commit_or_rollback_transaction").Register("repository:query_cache_create", invalidate); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:commit_or_rollback_transaction").Register("repository:query_cache_update", invalidate); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:commit_or_rollback_transaction").Register("repository:query_cache_delete", invalidate); err != nil {
+		return err
+	}
+	// Raw statements cannot be attributed to a table; their Table is always empty.
+	return cb.Raw().After("gorm:raw").Register("repository:query_cache_raw", invalidate)
+}
+
+// Close closes the connections of the cache backend, such as those of Redis.
+func (q *QueryCache) Close() error {
+	if closer, ok := q.cache.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Invalidate drops every cached result tagged with one of tables.
+func (q *QueryCache) Invalidate(ctx context.Context, tables ...string) error {
+	for _, table := range tables {
+		queryCacheInvalidations.WithLabelValues(table).Inc()
+		if _, err := q.newVersion(ctx, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fetch stores in dest the result of the query identified by signature, which reads tables,
+// from the cache or, on a miss, by calling load, which must fill dest. dest must be encodable
+// with encoding/gob. When the cache fails the query runs as if it were not cached.
+func (q *QueryCache) Fetch(ctx context.Context, tables []string, signature string, dest interface{}, load func() error) error {
+	table := allTables
+	if len(tables) > 0 {
+		table = tables[0]
+	}
+
+	key, err := q.key(ctx, tables, signature)
+	if err != nil {
+		return q.bypass(table, err, load)
+	}
+	value, found, err := q.cache.Get(ctx, key)
+	if err != nil {
+		return q.bypass(table, err, load)
+	}
+	if found && gob.NewDecoder(bytes.NewReader(value)).Decode(dest) == nil {
+		queryCacheRequests.WithLabelValues(table, "hit").Inc()
+		return nil
+	}
+
+	queryCacheRequests.WithLabelValues(table, "miss").Inc()
+	if err := load(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(dest); err != nil {
+		logger.WithFields(map[string]interface{}{"table": table, "error": err.Error()}).
+			Warn("Failed to encode a query result for the cache")
+		return nil
+	}
+	if err := q.cache.Set(ctx, key, buf.Bytes(), q.ttl); err != nil {
+		logger.WithFields(map[string]interface{}{"table": table, "error": err.Error()}).
+			Warn("Failed to store a query result in the cache")
+	}
+	return nil
+}
+
+// bypass runs load after the cache failed.
+func (q *QueryCache) bypass(table string, err error, load func() error) error {
+	queryCacheRequests.WithLabelValues(table, "error").Inc()
+	logger.WithFields(map[string]interface{}{"table": table, "error": err.Error()}).
+		Warn("Query cache unavailable, querying the database")
+	return load()
+}
+
+// key hashes the signature with the current version of every tag of the query, so that
+// invalidating any of them changes the key.
+func (q *QueryCache) key(ctx context.Context, tables []string, signature string) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(signature))
+	for _, tag := range append([]string{allTables}, tables...) {
+		version, found, err := q.cache.Get(ctx, tagKeyPrefix+tag)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			// Never written, or evicted: entries of any earlier version must not be served
+			if version, err = q.newVersion(ctx, tag); err != nil {
+				return "", err
+			}
+		}
+		h.Write([]byte{0})
+		h.Write(version)
+	}
+	return queryKeyPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// newVersion stores a random version for tag.
+func (q *QueryCache) newVersion(ctx context.Context, tag string) ([]byte, error) {
+	version := []byte(hex.EncodeToString(randomBytes(8)))
+	if err := q.cache.Set(ctx, tagKeyPrefix+tag, version, 0); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return b
+}
+
+// cachedQuery runs load through the query cache attached to db, if any, outside transactions.
+// The signature is built from name and args, which must be JSON-encodable.
+func cachedQuery(ctx context.Context, db *gorm.DB, tables []string, dest interface{}, load func() error, name string, args ...interface{}) error {
+	qc, _ := db.Config.Plugins[queryCacheName].(*QueryCache)
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); qc == nil || inTx {
+		return load()
+	}
+	signature, err := json.Marshal(append([]interface{}{name}, args...))
+	if err != nil {
+		return load()
+	}
+	return qc.Fetch(ctx, tables, string(signature), dest, load)
+}
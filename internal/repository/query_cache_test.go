@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/cache"
+	"github.com/yeferson59/gin-template/pkg/pagination"
+)
+
+func openCachedDB(t *testing.T, c cache.Cache) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := UseQueryCache(db, NewQueryCache(c, time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func cacheResults(t *testing.T) func(result string) float64 {
+	t.Helper()
+	start := map[string]float64{}
+	for _, result := range []string{"hit", "miss", "error"} {
+		start[result] = testutil.ToFloat64(queryCacheRequests.WithLabelValues(usersTable, result))
+	}
+	return func(result string) float64 {
+		return testutil.ToFloat64(queryCacheRequests.WithLabelValues(usersTable, result)) - start[result]
+	}
+}
+
+func TestQueryCacheServesAndInvalidates(t *testing.T) {
+	db := openCachedDB(t, cache.NewMemory(0))
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	page := pagination.Params{Page: 1, PerPage: 10}
+	count := cacheResults(t)
+
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "hash"}
+	if err := db.Create(&alice).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		users, total, err := repo.List(ctx, UserFilter{}, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Fields hidden from JSON survive the cache
+		if total != 1 || len(users) != 1 || users[0].Password != "hash" {
+			t.Fatalf("unexpected users %+v (total %d)", users, total)
+		}
+	}
+	if count("miss") != 1 || count("hit") != 1 {
+		t.Fatalf("expected 1 miss and 1 hit, got %v and %v", count("miss"), count("hit"))
+	}
+
+	// A write to the table invalidates the cached listing
+	if err := db.Create(&models.User{Username: "bob", Email: "bob@example.com", Password: "hash"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := repo.List(ctx, UserFilter{}, page); total != 2 {
+		t.Fatalf("expected the new user to be listed, got %d users", total)
+	}
+
+	// So does a raw statement
+	if err := db.Exec("UPDATE users SET role = ?", "admin").Error; err != nil {
+		t.Fatal(err)
+	}
+	users, err := repo.FindByIDs(ctx, []uint{alice.ID})
+	if err != nil || len(users) != 1 || users[0].Role != "admin" {
+		t.Fatalf("expected the updated user, got %+v (%v)", users, err)
+	}
+	if err := db.Exec("UPDATE users SET role = ?", "user").Error; err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := repo.FindByIDs(ctx, []uint{alice.ID}); users[0].Role != "user" {
+		t.Fatalf("expected the role to be reverted, got %q", users[0].Role)
+	}
+
+	// Empty results are cached as empty, not nil, slices
+	for range 2 {
+		if users, err := repo.FindByIDs(ctx, []uint{999}); err != nil || users == nil || len(users) != 0 {
+			t.Fatalf("expected an empty slice, got %#v (%v)", users, err)
+		}
+	}
+}
+
+func TestQueryCacheSkipsTransactions(t *testing.T) {
+	db := openCachedDB(t, cache.NewMemory(0))
+	count := cacheResults(t)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, _, err := NewUserRepository(tx).List(context.Background(), UserFilter{}, pagination.Params{Page: 1, PerPage: 10})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count("miss")+count("hit") != 0 {
+		t.Fatal("expected reads in a transaction to bypass the cache")
+	}
+}
+
+// failingCache fails every operation.
+type failingCache struct{}
+
+var errCacheDown = errors.New("cache down")
+
+func (failingCache) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errCacheDown
+}
+
+func (failingCache) Set(context.Context, string, []byte, time.Duration) error {
+	return errCacheDown
+}
+
+func (failingCache) Delete(context.Context, string) error {
+	return errCacheDown
+}
+
+func TestQueryCacheFallsBackToTheDatabase(t *testing.T) {
+	db := openCachedDB(t, failingCache{})
+	count := cacheResults(t)
+
+	if err := db.Create(&models.User{Username: "alice", Email: "alice@example.com", Password: "hash"}).Error; err != nil {
+		t.Fatalf("expected writes to succeed without the cache, got %v", err)
+	}
+	users, total, err := NewUserRepository(db).List(context.Background(), UserFilter{}, pagination.Params{Page: 1, PerPage: 10})
+	if err != nil || total != 1 || len(users) != 1 {
+		t.Fatalf("expected the user from the database, got %+v (%v)", users, err)
+	}
+	if count("error") != 1 {
+		t.Fatalf("expected 1 cache error, got %v", count("error"))
+	}
+}
@@ -0,0 +1,156 @@
+// Package repository provides data access abstractions over the database.
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/pagination"
+)
+
+// UserFilter narrows user listings. Zero values are ignored.
+type UserFilter struct {
+	Search        string
+	Role          string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	// Columns limits the columns loaded by List, for partial responses. Empty loads them all.
+	Columns []string
+}
+
+// UserRepository defines data access operations for users.
+type UserRepository interface {
+	// List returns one page of users matching the filter along with the total match count.
+	List(ctx context.Context, filter UserFilter, page pagination.Params) ([]models.User, int64, error)
+	// FindByIDs returns the users with the given IDs in one query, in no particular order.
+	// IDs that do not exist are skipped.
+	FindByIDs(ctx context.Context, ids []uint) ([]models.User, error)
+	// Each calls fn with consecutive batches of users matching the filter, ordered by ID.
+	Each(ctx context.Context, filter UserFilter, batchSize int, fn func(users []models.User) error) error
+}
+
+// usersTable tags the cached user queries.
+var usersTable = models.User{}.TableName()
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository creates a GORM-backed UserRepository.
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &gormUserRepository{db: db}
+}
+
+// userPage is a cached page of List.
+type userPage struct {
+	Users     []models.User
+	Total     int64
+	Estimated bool
+}
+
+func (r *gormUserRepository) List(ctx context.Context, filter UserFilter, page pagination.Params) ([]models.User, int64, error) {
+	var result userPage
+	err := cachedQuery(ctx, r.db, []string{usersTable}, &result, func() error {
+		var err error
+		// The report of this query alone, to cache it with the page
+		listCtx := WithCountReport(ctx)
+		result.Users, result.Total, err = r.list(listCtx, filter, page)
+		result.Estimated = EstimatedCount(listCtx)
+		return err
+	}, "users.List", filter, page.Offset(), page.Limit())
+	if err != nil {
+		return nil, 0, err
+	}
+	reportEstimate(ctx, result.Estimated)
+	return nonNilUsers(result.Users), result.Total, nil
+}
+
+func (r *gormUserRepository) list(ctx context.Context, filter UserFilter, page pagination.Params) ([]models.User, int64, error) {
+	query := applyUserFilter(r.db.WithContext(ctx).Model(&models.User{}), filter)
+
+	total, err := count(ctx, r.db, query, usersTable, userFilterKey(filter))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(filter.Columns) > 0 {
+		query = query.Select(filter.Columns)
+	}
+
+	var users []models.User
+	err = query.Order("id ASC").Offset(page.Offset()).Limit(page.Limit()).Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (r *gormUserRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.User, error) {
+	var users []models.User
+	err := cachedQuery(ctx, r.db, []string{usersTable}, &users, func() error {
+		return r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error
+	}, "users.FindByIDs", ids)
+	if err != nil {
+		return nil, err
+	}
+	return nonNilUsers(users), nil
+}
+
+func (r *gormUserRepository) Each(ctx context.Context, filter UserFilter, batchSize int, fn func(users []models.User) error) error {
+	var batch []models.User
+	result := applyUserFilter(r.db.WithContext(ctx).Model(&models.User{}), filter).
+		FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+			return fn(batch)
+		})
+	return result.Error
+}
+
+// userFilterKey identifies the rows matched by filter for the counter, "" meaning all of them.
+func userFilterKey(filter UserFilter) string {
+	filter.Columns = nil
+	if filter.Search == "" && filter.Role == "" && filter.CreatedAfter == nil && filter.CreatedBefore == nil {
+		return ""
+	}
+	key, _ := json.Marshal(filter)
+	return string(key)
+}
+
+// nonNilUsers returns an empty slice for nil, as a cached empty result decodes to nil while
+// GORM returns an empty slice.
+func nonNilUsers(users []models.User) []models.User {
+	if users == nil {
+		return []models.User{}
+	}
+	return users
+}
+
+// applyUserFilter adds the WHERE clauses for a UserFilter.
+func applyUserFilter(query *gorm.DB, filter UserFilter) *gorm.DB {
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := "%" + escapeLike(models.NormalizeUsername(search)) + "%"
+		query = query.Where("(username_normalized LIKE ? ESCAPE '!' OR LOWER(email) LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	return query
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally. "!" is used as the
+// escape character because backslashes are themselves escapes in MySQL string literals.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+}
@@ -0,0 +1,309 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Formatos de exportación de la tabla de rutas.
+const (
+	FormatPostman  = "postman"
+	FormatInsomnia = "insomnia"
+)
+
+// postmanSchema es el esquema de las colecciones de Postman v2.1.
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// Collection exporta routes como una colección de Postman o un workspace de Insomnia, con una
+// carpeta por grupo de rutas (/api/users, /health...), el token bearer en las rutas
+// autenticadas y los cuerpos de ejemplo conocidos. La URL base y el token son variables de la
+// colección.
+func Collection(format, name, baseURL string, routes []RouteInfo) (interface{}, error) {
+	switch format {
+	case FormatPostman:
+		return postmanCollection(name, baseURL, routes), nil
+	case FormatInsomnia:
+		return insomniaExport(name, baseURL, routes, time.Now()), nil
+	default:
+		return nil, fmt.Errorf("unknown collection format %q, want %s or %s", format, FormatPostman, FormatInsomnia)
+	}
+}
+
+// exportable indica si la ruta se exporta: las de HEAD y OPTIONS solo repiten otras.
+func exportable(r RouteInfo) bool {
+	return r.Method != "HEAD" && r.Method != "OPTIONS"
+}
+
+// folderName devuelve la carpeta de una ruta: el segmento tras /api, o el primero fuera de él.
+func folderName(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if segments[0] == "api" && len(segments) > 1 {
+		return segments[1]
+	}
+	if segments[0] == "" {
+		return "root"
+	}
+	return segments[0]
+}
+
+// requestName describe una ruta en la colección.
+func requestName(r RouteInfo) string {
+	return r.Method + " " + r.Path
+}
+
+// description resume el handler y la autenticación que requiere una ruta.
+func description(r RouteInfo) string {
+	switch {
+	case len(r.Roles) > 0:
+		return fmt.Sprintf("%s. Requires a bearer token with role: %s.", r.Handler, strings.Join(r.Roles, ", "))
+	case r.AuthRequired:
+		return r.Handler + ". Requires a bearer token."
+	default:
+		return r.Handler + ". Public."
+	}
+}
+
+// example devuelve el cuerpo de ejemplo de una ruta: JSON indentado, o los campos de un
+// formulario.
+func example(r RouteInfo) (body string, form url.Values) {
+	value, ok := examples[r.Method+" "+r.Path]
+	if !ok {
+		return "", nil
+	}
+	if form, ok := value.(url.Values); ok {
+		return "", form
+	}
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", nil
+	}
+	return string(encoded), nil
+}
+
+// pathParams devuelve los parámetros de un path de gin (:id, *path).
+func pathParams(path string) []string {
+	var params []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+		}
+	}
+	return params
+}
+
+type postmanCollectionFile struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanFolder   `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+type postmanFolder struct {
+	Name string        `json:"name"`
+	Item []postmanItem `json:"item"`
+}
+
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+type postmanRequest struct {
+	Method      string          `json:"method"`
+	Description string          `json:"description"`
+	Header      []postmanHeader `json:"header"`
+	URL         postmanURL      `json:"url"`
+	Body        *postmanBody    `json:"body,omitempty"`
+	Auth        postmanAuth     `json:"auth"`
+}
+
+type postmanHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw,omitempty"`
+	URLEncoded []postmanVariable `json:"urlencoded,omitempty"`
+	Options    interface{}       `json:"options,omitempty"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanVariable `json:"bearer,omitempty"`
+}
+
+func postmanCollection(name, baseURL string, routes []RouteInfo) postmanCollectionFile {
+	collection := postmanCollectionFile{
+		Info: postmanInfo{Name: name, Schema: postmanSchema},
+		Item: []postmanFolder{},
+		Variable: []postmanVariable{
+			{Key: "baseUrl", Value: baseURL, Type: "string"},
+			{Key: "token", Value: "", Type: "string"},
+		},
+	}
+	folders := make(map[string]int)
+	for _, r := range routes {
+		if !exportable(r) {
+			continue
+		}
+		req := postmanRequest{
+			Method:      r.Method,
+			Description: description(r),
+			Header:      []postmanHeader{},
+			URL: postmanURL{
+				Raw:  "{{baseUrl}}" + r.Path,
+				Host: []string{"{{baseUrl}}"},
+				Path: strings.Split(strings.Trim(r.Path, "/"), "/"),
+			},
+			Auth: postmanAuth{Type: "noauth"},
+		}
+		for _, param := range pathParams(r.Path) {
+			req.URL.Variable = append(req.URL.Variable, postmanVariable{Key: param, Value: ""})
+		}
+		if r.AuthRequired {
+			req.Auth = postmanAuth{Type: "bearer", Bearer: []postmanVariable{{Key: "token", Value: "{{token}}", Type: "string"}}}
+		}
+		switch body, form := example(r); {
+		case form != nil:
+			req.Header = append(req.Header, postmanHeader{Key: "Content-Type", Value: "application/x-www-form-urlencoded"})
+			req.Body = &postmanBody{Mode: "urlencoded"}
+			for _, key := range sortedKeys(form) {
+				req.Body.URLEncoded = append(req.Body.URLEncoded, postmanVariable{Key: key, Value: form.Get(key)})
+			}
+		case body != "":
+			req.Header = append(req.Header, postmanHeader{Key: "Content-Type", Value: "application/json"})
+			req.Body = &postmanBody{Mode: "raw", Raw: body, Options: map[string]interface{}{"raw": map[string]string{"language": "json"}}}
+		}
+
+		folder := folderName(r.Path)
+		i, ok := folders[folder]
+		if !ok {
+			i = len(collection.Item)
+			folders[folder] = i
+			collection.Item = append(collection.Item, postmanFolder{Name: folder})
+		}
+		collection.Item[i].Item = append(collection.Item[i].Item, postmanItem{Name: requestName(r), Request: req})
+	}
+	return collection
+}
+
+type insomniaExportFile struct {
+	Type         string             `json:"_type"`
+	ExportFormat int                `json:"__export_format"`
+	ExportDate   time.Time          `json:"__export_date"`
+	ExportSource string             `json:"__export_source"`
+	Resources    []insomniaResource `json:"resources"`
+}
+
+// insomniaResource es un recurso de una exportación de Insomnia v4; cada tipo usa sus campos.
+type insomniaResource struct {
+	ID             string            `json:"_id"`
+	Type           string            `json:"_type"`
+	ParentID       *string           `json:"parentId"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	Method         string            `json:"method,omitempty"`
+	URL            string            `json:"url,omitempty"`
+	Body           *insomniaBody     `json:"body,omitempty"`
+	Headers        []insomniaHeader  `json:"headers,omitempty"`
+	Authentication map[string]string `json:"authentication,omitempty"`
+	Data           map[string]string `json:"data,omitempty"`
+}
+
+type insomniaBody struct {
+	MimeType string           `json:"mimeType"`
+	Text     string           `json:"text,omitempty"`
+	Params   []insomniaHeader `json:"params,omitempty"`
+}
+
+type insomniaHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func insomniaExport(name, baseURL string, routes []RouteInfo, now time.Time) insomniaExportFile {
+	workspace := "wrk_api"
+	export := insomniaExportFile{
+		Type:         "export",
+		ExportFormat: 4,
+		ExportDate:   now.UTC().Truncate(time.Second),
+		ExportSource: "gin-template",
+		Resources: []insomniaResource{
+			{ID: workspace, Type: "workspace", Name: name},
+			{ID: "env_base", Type: "environment", ParentID: &workspace, Name: "Base Environment",
+				Data: map[string]string{"base_url": baseURL, "token": ""}},
+		},
+	}
+	folders := make(map[string]string)
+	for i, r := range routes {
+		if !exportable(r) {
+			continue
+		}
+		folder := folderName(r.Path)
+		folderID, ok := folders[folder]
+		if !ok {
+			folderID = "fld_" + folder
+			folders[folder] = folderID
+			export.Resources = append(export.Resources, insomniaResource{ID: folderID, Type: "request_group", ParentID: &workspace, Name: folder})
+		}
+
+		parent := folderID
+		req := insomniaResource{
+			ID:          fmt.Sprintf("req_%d", i+1),
+			Type:        "request",
+			ParentID:    &parent,
+			Name:        requestName(r),
+			Description: description(r),
+			Method:      r.Method,
+			URL:         "{{ _.base_url }}" + r.Path,
+		}
+		if r.AuthRequired {
+			req.Authentication = map[string]string{"type": "bearer", "token": "{{ _.token }}"}
+		}
+		switch body, form := example(r); {
+		case form != nil:
+			req.Headers = []insomniaHeader{{Name: "Content-Type", Value: "application/x-www-form-urlencoded"}}
+			req.Body = &insomniaBody{MimeType: "application/x-www-form-urlencoded"}
+			for _, key := range sortedKeys(form) {
+				req.Body.Params = append(req.Body.Params, insomniaHeader{Name: key, Value: form.Get(key)})
+			}
+		case body != "":
+			req.Headers = []insomniaHeader{{Name: "Content-Type", Value: "application/json"}}
+			req.Body = &insomniaBody{MimeType: "application/json", Text: body}
+		}
+		export.Resources = append(export.Resources, req)
+	}
+	return export
+}
+
+func sortedKeys(form url.Values) []string {
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func collectionRoutes(t *testing.T) []RouteInfo {
+	t.Helper()
+	var table *Table
+	testutil.NewApp(t, func(a *testutil.App) {
+		table = RegisterAPIRoutes(a.Router, a.DB, a.Config, Services{})
+	})
+	return table.Routes()
+}
+
+func TestPostmanCollection(t *testing.T) {
+	routes := collectionRoutes(t)
+	value, err := Collection(FormatPostman, "Test API", "http://localhost:8080", routes)
+	if err != nil {
+		t.Fatalf("Collection() error = %v", err)
+	}
+	collection := value.(postmanCollectionFile)
+
+	items := make(map[string]postmanItem)
+	for _, folder := range collection.Item {
+		for _, item := range folder.Item {
+			if folderName(item.Request.URL.Raw[len("{{baseUrl}}"):]) != folder.Name {
+				t.Errorf("%s is in folder %s", item.Name, folder.Name)
+			}
+			items[item.Name] = item
+		}
+	}
+
+	register, ok := items["POST /api/auth/register"]
+	if !ok || register.Request.Auth.Type != "noauth" || register.Request.Body == nil ||
+		!strings.Contains(register.Request.Body.Raw, `"email": "jane@example.com"`) {
+		t.Errorf("register request = %+v", register.Request)
+	}
+	revoke, ok := items["DELETE /api/keys/:id"]
+	if !ok || revoke.Request.Auth.Type != "bearer" || len(revoke.Request.URL.Variable) != 1 || revoke.Request.URL.Variable[0].Key != "id" {
+		t.Errorf("revoke request = %+v", revoke.Request)
+	}
+	if users, ok := items["GET /api/admin/users"]; !ok || !strings.Contains(users.Request.Description, "role: admin") {
+		t.Errorf("admin users request = %+v", users.Request)
+	}
+	if _, ok := items["HEAD /health/live"]; ok {
+		t.Error("HEAD routes are exported")
+	}
+	if _, err := json.Marshal(collection); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInsomniaExport(t *testing.T) {
+	value, err := Collection(FormatInsomnia, "Test API", "http://localhost:8080", collectionRoutes(t))
+	if err != nil {
+		t.Fatalf("Collection() error = %v", err)
+	}
+	export := value.(insomniaExportFile)
+	if export.Type != "export" || export.ExportFormat != 4 || export.Resources[0].Type != "workspace" {
+		t.Fatalf("export header = %+v", export.Resources[0])
+	}
+
+	groups := make(map[string]bool)
+	found := false
+	for _, r := range export.Resources {
+		switch r.Type {
+		case "request_group":
+			groups[r.ID] = true
+		case "request":
+			if !groups[*r.ParentID] {
+				t.Errorf("%s is outside a folder", r.Name)
+			}
+			if r.Name == "POST /api/keys" {
+				found = true
+				if r.Authentication["type"] != "bearer" || r.Body == nil || !strings.Contains(r.Body.Text, "CI pipeline") {
+					t.Errorf("create key request = %+v", r)
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("POST /api/keys not exported")
+	}
+
+	if _, err := Collection("har", "Test API", "", nil); err == nil {
+		t.Error("Collection() accepted an unknown format")
+	}
+}
+
+func TestExamplesAreValidRoutes(t *testing.T) {
+	methods := map[string]bool{http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true}
+	for key := range examples {
+		method, path, ok := strings.Cut(key, " ")
+		if !ok || !methods[method] || !strings.HasPrefix(path, "/api/") {
+			t.Errorf("example %q is not a \"METHOD /api/path\" key of a route with a body", key)
+		}
+	}
+}
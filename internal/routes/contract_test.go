@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/auth"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/contract"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+// providerStates load the fixtures of the provider states consumers may use in their pacts.
+var providerStates = map[string]testutil.ProviderState{
+	// params: username, email, password
+	"a user exists": func(t testing.TB, app *testutil.App, params map[string]interface{}) http.Header {
+		createStateUser(t, app, params)
+		return nil
+	},
+	// params: username, email
+	"the user is signed in": func(t testing.TB, app *testutil.App, params map[string]interface{}) http.Header {
+		user := createStateUser(t, app, params)
+		token, err := auth.GenerateJWT(user.ID, user.Email)
+		if err != nil {
+			t.Fatalf("failed to sign JWT: %v", err)
+		}
+		return http.Header{"Authorization": {"Bearer " + token}}
+	},
+}
+
+func createStateUser(t testing.TB, app *testutil.App, params map[string]interface{}) *models.User {
+	t.Helper()
+	var opts []testutil.UserOption
+	if username, ok := params["username"].(string); ok {
+		opts = append(opts, testutil.WithUsername(username), testutil.WithEmail(username+"@example.com"))
+	}
+	if email, ok := params["email"].(string); ok {
+		opts = append(opts, testutil.WithEmail(email))
+	}
+	if password, ok := params["password"].(string); ok {
+		opts = append(opts, testutil.WithPassword(password))
+	}
+	return testutil.CreateUser(t, app.DB, opts...)
+}
+
+// TestProviderContracts verifies the API against the consumer pacts in testdata/pacts, those in
+// PACT_DIR, and, with PACT_BROKER_URL, the latest pacts of PACT_PROVIDER (gin-template by
+// default) in the broker. Results are published to the broker when PACT_PROVIDER_VERSION is
+// set.
+func TestProviderContracts(t *testing.T) {
+	pacts, err := contract.LoadDir("testdata/pacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir := os.Getenv("PACT_DIR"); dir != "" {
+		local, err := contract.LoadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pacts = append(pacts, local...)
+	}
+
+	var broker *contract.Broker
+	var fetched []*contract.Pact
+	if url := os.Getenv("PACT_BROKER_URL"); url != "" {
+		provider := os.Getenv("PACT_PROVIDER")
+		if provider == "" {
+			provider = "gin-template"
+		}
+		broker = &contract.Broker{URL: url, Token: os.Getenv("PACT_BROKER_TOKEN")}
+		fetched, err = broker.Fetch(context.Background(), provider)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	register := func(a *testutil.App) {
+		RegisterAPIRoutes(a.Router, a.DB, a.Config, Services{})
+	}
+	for _, p := range pacts {
+		testutil.VerifyPact(t, p, register, providerStates)
+	}
+	version := os.Getenv("PACT_PROVIDER_VERSION")
+	for _, p := range fetched {
+		success := testutil.VerifyPact(t, p, register, providerStates)
+		if version == "" {
+			continue
+		}
+		if err := broker.Publish(context.Background(), p, success, version); err != nil {
+			t.Errorf("failed to publish the verification of %s: %v", p.Name(), err)
+		}
+	}
+}
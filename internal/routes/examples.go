@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/handlers"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/validators"
+)
+
+// examples son los cuerpos de ejemplo de las rutas que reciben uno, por método y path, para
+// las colecciones de Postman e Insomnia. Las rutas de formulario usan url.Values.
+var examples = map[string]interface{}{
+	"POST /api/auth/register": validators.AuthRequest{Username: "jane", Email: "jane@example.com", Password: "Str0ng!Passw0rd"},
+	"POST /api/register":      validators.AuthRequest{Username: "jane", Email: "jane@example.com", Password: "Str0ng!Passw0rd"},
+	"POST /api/auth/login":    validators.LoginRequest{Username: "jane", Password: "Str0ng!Passw0rd"},
+	"POST /api/login":         validators.LoginRequest{Username: "jane", Password: "Str0ng!Passw0rd"},
+	"POST /api/auth/login/verify": handlers.LoginVerifyRequest{
+		ChallengeID: "challenge-id-from-login", Code: "123456",
+	},
+	"POST /api/auth/email/confirm": handlers.EmailTokenRequest{Token: "token-from-the-email"},
+	"POST /api/auth/email/revert":  handlers.EmailTokenRequest{Token: "token-from-the-email"},
+	"POST /api/oauth/device/code":  url.Values{"client_id": {"cli"}, "scope": {""}},
+	"POST /api/oauth/token": url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {"device-code-from-device-code"},
+		"client_id":   {"cli"},
+	},
+	"POST /api/oauth/device/verify": handlers.DeviceVerifyRequest{UserCode: "BCDF-GHJK", Approve: true},
+
+	"PATCH /api/users/me":                    validators.ProfileUpdate{Username: "jane.doe"},
+	"PUT /api/users/me/email":                validators.EmailChangeRequest{Email: "jane.doe@example.com", Password: "Str0ng!Passw0rd"},
+	"POST /api/users/me/consents":            validators.ConsentRequest{Kind: models.ConsentTerms, Version: "2026-01"},
+	"POST /api/keys":                         validators.APIKeyRequest{Name: "CI pipeline"},
+	"POST /api/orgs":                         validators.OrganizationRequest{Name: "Acme", Slug: "acme"},
+	"POST /api/orgs/:org/invitations":        validators.InvitationRequest{Email: "teammate@example.com", Role: models.OrgRoleMember},
+	"PATCH /api/orgs/:org/members/:user_id":  validators.MemberUpdate{Role: models.OrgRoleAdmin},
+	"POST /api/orgs/:org/registration-codes": validators.RegistrationCodeRequest{Note: "Onboarding", MaxUses: 10},
+	"POST /api/invitations/accept":           handlers.AcceptInvitationRequest{Token: "token-from-the-invitation"},
+
+	"POST /api/admin/users/batch": handlers.BatchUserRequest{
+		Operations: []handlers.BatchUserOperation{{Op: handlers.BatchOpCreate, Data: &handlers.BatchUserData{
+			Username: ptr("john"), Email: ptr("john@example.com"), Password: ptr("Str0ng!Passw0rd"),
+		}}},
+	},
+	"POST /api/admin/registration-codes": validators.RegistrationCodeRequest{Note: "Beta testers", MaxUses: 100, ExpiresAt: ptr(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))},
+	"POST /api/admin/cache/purge":        handlers.PurgeCacheRequest{Keys: []string{"api/errors"}},
+	"PUT /api/admin/plans/:name": validators.PlanRequest{DisplayName: "Pro", Entitlements: []validators.EntitlementRequest{
+		{Key: "max_organizations", Limit: ptr(int64(10))}, {Key: "export"},
+	}},
+	"PUT /api/admin/users/:id/plan": validators.UserPlanRequest{Plan: "pro"},
+	"PUT /api/admin/read-only":      handlers.ReadOnlyStatus{Enabled: ptr(true)},
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestMetricsRequireToken(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"without a token", "", http.StatusNotFound},
+		{"with a token", "s3cret", http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutil.NewApp(t, func(a *testutil.App) {
+				a.Config.Metrics.Enabled, a.Config.Metrics.Path, a.Config.Metrics.Token = true, "/metrics", tt.token
+				RegisterAPIRoutes(a.Router, a.DB, a.Config, Services{})
+			})
+			testutil.AssertStatus(t, testutil.Get("/metrics").Do(t, app.Router), tt.want)
+			if tt.token != "" {
+				w := testutil.Get("/metrics").WithHeader("Authorization", "Bearer "+tt.token).Do(t, app.Router)
+				testutil.AssertStatus(t, w, http.StatusOK)
+			}
+		})
+	}
+}
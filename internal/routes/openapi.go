@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/pkg/patch"
+)
+
+// bearerScheme es el esquema de seguridad de las rutas autenticadas.
+const bearerScheme = "bearerAuth"
+
+// OpenAPI genera la especificación OpenAPI 3 de routes: un path por ruta con sus parámetros, el
+// token bearer en las rutas autenticadas y el esquema del cuerpo de las que tienen un ejemplo,
+// derivado de su tipo Go (tags json y binding:"required", "oneof").
+func OpenAPI(title, version string, routes []RouteInfo) (*openapi3.T, error) {
+	if version == "" {
+		version = "1.0.0"
+	}
+	spec := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info:    &openapi3.Info{Title: title, Version: version},
+		Paths:   openapi3.NewPaths(),
+		Components: &openapi3.Components{
+			SecuritySchemes: openapi3.SecuritySchemes{
+				bearerScheme: &openapi3.SecuritySchemeRef{Value: openapi3.NewJWTSecurityScheme()},
+			},
+		},
+	}
+
+	for _, r := range routes {
+		if !exportable(r) {
+			continue
+		}
+		op, err := operation(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", r.Method, r.Path, err)
+		}
+		spec.AddOperation(middlewares.OpenAPIPath(r.Path), r.Method, op)
+	}
+	return spec, nil
+}
+
+// operation describe una ruta como una operación OpenAPI.
+func operation(r RouteInfo) (*openapi3.Operation, error) {
+	op := openapi3.NewOperation()
+	op.OperationID = r.Method + " " + r.Path
+	op.Summary = r.Handler
+	op.Description = description(r)
+	op.Tags = []string{folderName(r.Path)}
+	op.Responses = openapi3.NewResponses(openapi3.WithName("default", openapi3.NewResponse().
+		WithDescription("Response envelope; error codes are listed by GET /api/errors")))
+	if r.AuthRequired {
+		op.Security = openapi3.NewSecurityRequirements().With(openapi3.NewSecurityRequirement().Authenticate(bearerScheme))
+	}
+	for _, name := range pathParams(r.Path) {
+		op.AddParameter(openapi3.NewPathParameter(name).WithSchema(openapi3.NewStringSchema()))
+	}
+
+	value, ok := examples[r.Method+" "+r.Path]
+	if !ok {
+		return op, nil
+	}
+	if form, ok := value.(url.Values); ok {
+		op.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+			WithRequired(true).
+			WithFormDataSchema(formSchema(form))}
+		return op, nil
+	}
+	schema, err := openapi3gen.NewSchemaRefForValue(value, nil, openapi3gen.SchemaCustomizer(bindingRules))
+	if err != nil {
+		return nil, err
+	}
+	body := openapi3.NewRequestBody().WithRequired(true)
+	if r.Method == http.MethodPatch {
+		body.WithContent(patchContent(schema.Value))
+	} else {
+		body.WithJSONSchemaRef(schema)
+	}
+	op.RequestBody = &openapi3.RequestBodyRef{Value: body}
+	return op, nil
+}
+
+// patchContent describe los cuerpos de PATCH que acepta patch.Bind: un JSON Merge Patch del
+// recurso, en el que cualquier campo puede faltar o ser null, o un JSON Patch.
+func patchContent(resource *openapi3.Schema) openapi3.Content {
+	merge := *resource
+	merge.Required = nil
+	merge.Properties = make(openapi3.Schemas, len(resource.Properties))
+	for name, prop := range resource.Properties {
+		nullable := *prop.Value
+		nullable.Nullable = true
+		merge.Properties[name] = openapi3.NewSchemaRef("", &nullable)
+	}
+
+	operation := openapi3.NewObjectSchema().
+		WithProperty("op", openapi3.NewStringSchema().WithEnum("add", "remove", "replace", "move", "copy", "test")).
+		WithProperty("path", openapi3.NewStringSchema()).
+		WithProperty("from", openapi3.NewStringSchema()).
+		WithAnyAdditionalProperties()
+	operation.Required = []string{"op", "path"}
+
+	return openapi3.Content{
+		"application/json":   openapi3.NewMediaType().WithSchema(&merge),
+		patch.MergePatchType: openapi3.NewMediaType().WithSchema(&merge),
+		patch.JSONPatchType:  openapi3.NewMediaType().WithSchema(openapi3.NewArraySchema().WithItems(operation)),
+	}
+}
+
+// formSchema describe un formulario con los campos de su ejemplo, todos de texto.
+func formSchema(form url.Values) *openapi3.Schema {
+	schema := openapi3.NewObjectSchema()
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		schema.WithProperty(key, openapi3.NewStringSchema())
+	}
+	return schema
+}
+
+// bindingRules traslada al esquema de cada struct las reglas de validación de gin que OpenAPI
+// puede expresar: los campos binding:"required" y los valores de "oneof".
+func bindingRules(_ string, t reflect.Type, _ reflect.StructTag, schema *openapi3.Schema) error {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" || schema.Properties[name] == nil {
+			continue
+		}
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			switch {
+			case rule == "required":
+				schema.Required = append(schema.Required, name)
+			case strings.HasPrefix(rule, "oneof="):
+				if prop := schema.Properties[name].Value; prop != nil && prop.Type.Is(openapi3.TypeString) {
+					for _, v := range strings.Fields(strings.TrimPrefix(rule, "oneof=")) {
+						prop.Enum = append(prop.Enum, v)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
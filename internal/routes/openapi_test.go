@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/handlers"
+	"github.com/yeferson59/gin-template/pkg/patch"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestOpenAPI(t *testing.T) {
+	var table *Table
+	testutil.NewApp(t, func(a *testutil.App) {
+		table = RegisterAPIRoutes(a.Router, a.DB, a.Config, Services{})
+	})
+	spec, err := OpenAPI("Test API", "", table.Routes())
+	if err != nil {
+		t.Fatalf("OpenAPI() error = %v", err)
+	}
+	if err := spec.Validate(t.Context()); err != nil {
+		t.Fatalf("invalid specification: %v", err)
+	}
+	if spec.Info.Version != "1.0.0" {
+		t.Errorf("version = %q, want the 1.0.0 default", spec.Info.Version)
+	}
+
+	verify := spec.Paths.Value("/api/auth/login/verify").Post
+	schema := verify.RequestBody.Value.Content.Get("application/json").Schema.Value
+	if len(schema.Required) != 2 || schema.Properties["challenge_id"] == nil || verify.Security != nil {
+		t.Errorf("unexpected login verification operation: required %v, security %v", schema.Required, verify.Security)
+	}
+
+	profile := spec.Paths.Value("/api/users/me").Patch
+	if profile.Security == nil || len(*profile.Security) != 1 {
+		t.Errorf("authenticated route without bearer security: %v", profile.Security)
+	}
+	content := profile.RequestBody.Value.Content
+	if merge := content.Get(patch.MergePatchType); merge == nil || !merge.Schema.Value.Properties["username"].Value.Nullable {
+		t.Error("PATCH body is not described as a merge patch with nullable fields")
+	}
+	if content.Get(patch.JSONPatchType) == nil {
+		t.Error("PATCH body does not accept JSON Patch")
+	}
+
+	members := spec.Paths.Value("/api/orgs/{org}/members/{user_id}")
+	if members == nil || len(members.Patch.Parameters) != 2 {
+		t.Fatalf("path parameters not converted: %+v", members)
+	}
+	if form := spec.Paths.Value("/api/oauth/token"); form != nil {
+		if form.Post.RequestBody.Value.Content.Get("application/x-www-form-urlencoded") == nil {
+			t.Error("form route not described as a form")
+		}
+	}
+}
+
+func TestOpenAPIValidation(t *testing.T) {
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		a.Config.Middleware.OpenAPIValidation = true
+		RegisterAPIRoutes(a.Router, a.DB, a.Config, Services{})
+	})
+
+	w := testutil.Post("/api/auth/login/verify").
+		WithJSON(map[string]interface{}{"challenge_id": 42}).
+		Do(t, app.Router)
+	apiErr := testutil.AssertError(t, w, http.StatusBadRequest, "VALIDATION_ERROR")
+	codes := map[string]string{}
+	for _, f := range apiErr.Fields {
+		codes[f.Field] = f.Code
+	}
+	if codes["challenge_id"] != "invalid_format" || codes["code"] != "required" {
+		t.Errorf("unexpected field errors %+v", apiErr.Fields)
+	}
+
+	// Valid requests reach the handler, which rejects the unknown challenge
+	w = testutil.Post("/api/auth/login/verify").
+		WithJSON(handlers.LoginVerifyRequest{ChallengeID: "unknown", Code: "123456"}).
+		Do(t, app.Router)
+	if w.Code == http.StatusBadRequest {
+		if apiErr := testutil.DecodeError(t, w); apiErr.Code == "VALIDATION_ERROR" && len(apiErr.Fields) > 0 {
+			t.Errorf("valid request rejected by the schema: %+v", apiErr)
+		}
+	}
+}
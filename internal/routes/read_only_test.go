@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yeferson59/gin-template/pkg/readonly"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	mode := readonly.NewSwitch(true)
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		RegisterAPIRoutes(a.Router, a.DB, a.Config, Services{ReadOnly: mode})
+	})
+	admin := testutil.CreateAdmin(t, app.DB)
+
+	w := testutil.Patch("/api/users/me").WithJWT(admin).WithJSON(map[string]string{"username": "renamed"}).Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusServiceUnavailable, "READ_ONLY")
+	w = testutil.Get("/api/users/me").WithJWT(admin).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	w = testutil.NewRequest(http.MethodPut, "/api/admin/read-only").WithJWT(admin).
+		WithJSON(map[string]bool{"enabled": false}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if mode.Enabled() {
+		t.Fatal("PUT /api/admin/read-only did not turn read-only mode off")
+	}
+
+	w = testutil.Patch("/api/users/me").WithJWT(admin).WithJSON(map[string]string{"username": "renamed"}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+}
@@ -2,73 +2,549 @@
 package routes
 
 import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/yeferson59/gin-template/internal/adminui"
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/internal/assets"
+	"github.com/yeferson59/gin-template/internal/billing"
 	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/deviceui"
+	"github.com/yeferson59/gin-template/internal/entitlements"
 	"github.com/yeferson59/gin-template/internal/handlers"
+	"github.com/yeferson59/gin-template/internal/metering"
 	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/notify"
+	"github.com/yeferson59/gin-template/internal/operations"
+	"github.com/yeferson59/gin-template/internal/realtime"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/internal/saga"
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+	"github.com/yeferson59/gin-template/pkg/cache"
+	"github.com/yeferson59/gin-template/pkg/cachecontrol"
+	"github.com/yeferson59/gin-template/pkg/canary"
+	"github.com/yeferson59/gin-template/pkg/circuitbreaker"
+	"github.com/yeferson59/gin-template/pkg/crashreport"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+	"github.com/yeferson59/gin-template/pkg/profiler"
+	"github.com/yeferson59/gin-template/pkg/projection"
+	"github.com/yeferson59/gin-template/pkg/readonly"
+	"github.com/yeferson59/gin-template/pkg/requestctx"
 	"github.com/yeferson59/gin-template/pkg/response"
+	"github.com/yeferson59/gin-template/pkg/saml"
+	"github.com/yeferson59/gin-template/pkg/slo"
+	"github.com/yeferson59/gin-template/pkg/watchdog"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// RegisterAPIRoutes registra las rutas main de la API.
-func RegisterAPIRoutes(router *gin.Engine, db *gorm.DB, _ *config.Config) {
+// Services agrupa los componentes compartidos que usan los handlers.
+type Services struct {
+	Operations *operations.Manager
+	// Sagas ejecuta en la cola los procesos de varios pasos con compensaciones; los handlers
+	// registran sus definiciones con Register y los inician con Start.
+	Sagas    *saga.Runner
+	Breakers *circuitbreaker.Registry
+	// DBMonitor y Cache habilitan el modo degradado cuando ambos están presentes.
+	DBMonitor *database.Monitor
+	Cache     cache.Cache
+	// Lifecycle alimenta los probes de arranque y readiness; nil los deja siempre listos.
+	Lifecycle *app.Lifecycle
+	// RouteDebug activa por un tiempo los logs de depuración de algunas rutas y habilita
+	// GET y PUT /api/admin/logging para cambiar el nivel y el formato de los logs; nil no
+	// registra esas rutas.
+	RouteDebug *logger.RouteDebug
+	// Instance registra los arranques y paradas del proceso y habilita GET /api/admin/instance
+	// con su uptime, build y la causa del último reinicio; nil no registra la ruta.
+	Instance *app.Instance
+	// HealthProbes comprueba en GET /health otras dependencias, como el almacenamiento de
+	// objetos, por nombre de servicio; si alguna falla el estado es degraded.
+	HealthProbes map[string]func(context.Context) error
+	// Assets sirve los archivos estáticos bajo STATIC_PATH; nil no registra la ruta.
+	Assets *assets.Server
+	// SLO registra latencias y presupuestos de error por ruta; nil omite GET /api/admin/slo.
+	SLO *slo.Tracker
+	// CachePolicies fija el Cache-Control de cada grupo de rutas; Purger habilita
+	// POST /api/admin/cache/purge cuando no es nil.
+	CachePolicies cachecontrol.Rules
+	Purger        cachecontrol.Purger
+	// Geo bloquea regiones y limita peticiones por país o red en cada grupo de rutas.
+	Geo app.GeoRules
+	// Notifier envía las alertas de inicio de sesión desde un dispositivo o país nuevo; Mailer
+	// envía los códigos de verificación. nil desactiva cada uno.
+	Notifier notify.Notifier
+	Mailer   notify.Notifier
+	// InviteMailer envía las invitaciones a organizaciones y los emails de cambio de email; nil
+	// devuelve el token a quien invita y deja cambiar el email sin confirmarlo.
+	InviteMailer notify.Notifier
+	// EmailInbox guarda los emails en lugar de enviarlos (EMAIL_CAPTURE) y habilita /dev/emails
+	// para verlos junto con la vista previa de cada email; nil no registra esas rutas.
+	EmailInbox *notify.Inbox
+	// Meter registra el uso de la API por clave y usuario y aplica la cuota mensual de las
+	// claves; nil desactiva la medición.
+	Meter *metering.Meter
+	// Realtime reúne las conexiones WebSocket por canal con la presencia de sus usuarios y
+	// habilita GET /api/realtime y GET /api/realtime/channels/:channel/presence; nil no
+	// registra esas rutas.
+	Realtime *realtime.Hub
+	// Billing recibe el webhook de Stripe y crea los clientes de los usuarios nuevos; nil
+	// desactiva la facturación y las rutas bajo /api/billing.
+	Billing *billing.Service
+	// Entitlements limita lo que cada usuario puede hacer según su plan y habilita la gestión de
+	// planes bajo /api/admin/plans; nil deja todo sin límites.
+	Entitlements *entitlements.Service
+	// Canary reparte el tráfico entre las versiones estable y canary de los endpoints
+	// reescritos, registrados con middlewares.Canary; nil sirve siempre la estable.
+	Canary *canary.Router
+	// SAML habilita el inicio de sesión único bajo /api/auth/saml cuando no es nil.
+	SAML *saml.ServiceProvider
+	// ReadOnly rechaza las peticiones que escriben mientras está activo y habilita
+	// GET y PUT /api/admin/read-only para cambiarlo; nil desactiva el modo de solo lectura.
+	ReadOnly *readonly.Switch
+	// Watchdog vigila el heap y las goroutines; GET /health pasa a degraded mientras descarta
+	// carga y muestra sus cifras con ?detail=true. nil muestra las cifras sin límites.
+	Watchdog *watchdog.Watchdog
+	// Profiler captura perfiles de CPU y heap cuando la latencia o la tasa de errores se degradan
+	// y habilita GET /api/admin/profiles para listarlos; nil no los captura.
+	Profiler *profiler.Profiler
+	// CrashReports guarda un informe de cada panic recuperado y habilita
+	// GET /api/admin/crash-reports para consultarlos; nil solo registra los panics en el log.
+	CrashReports *crashreport.Store
+}
+
+// readOnlyExempt son las rutas que se sirven en modo de solo lectura: la emisión de tokens y
+// la que desactiva el modo.
+var readOnlyExempt = []string{"/api/oauth/token", "/api/admin/read-only"}
+
+// formRoutes son las rutas que reciben formularios en lugar de JSON: el ACS de SAML y los
+// endpoints OAuth del flujo de dispositivo.
+var formRoutes = []string{"/api/auth/saml/acs", "/api/oauth/device/code", "/api/oauth/token"}
+
+// RegisterAPIRoutes registra las rutas main de la API y devuelve su tabla de rutas.
+func RegisterAPIRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config, svc Services) *Table {
+	table := NewTable(router)
+	root := table.Root()
+
 	// Health check endpoints (no rate limiting for monitoring)
-	health := router.Group("/health")
-	{
-		health.GET("/", handlers.HealthCheck(db))
-		health.GET("/live", handlers.LivenessCheck())
-		health.GET("/ready", handlers.ReadinessCheck(db))
+	registerHealthRoutes(root, db, cfg, svc)
+
+	// Prometheus metrics, behind the metrics token unless served on the internal listeners
+	if cfg.Metrics.Enabled && cfg.Metrics.Token != "" && len(cfg.Server.InternalAddrs) == 0 {
+		root.GET(cfg.Metrics.Path, middlewares.MetricsToken(cfg.Metrics.Token), gin.WrapH(metrics.Handler()))
+	}
+
+	// Embedded admin dashboard (a static client of the admin API)
+	if cfg.AdminUI.Enabled {
+		adminui.Register(router, cfg.AdminUI.Path)
 	}
 
-	// API routes with rate limiting
-	api := router.Group("/api")
-	api.Use(middlewares.RateLimit())
-	api.Use(middlewares.ValidateContentType())
+	// Verification page of the device flow, where users approve the code shown by a CLI
+	if cfg.DeviceAuth.Enabled {
+		deviceui.Register(router, cfg.DeviceAuth.VerificationPath)
+	}
+
+	// Static assets, under their own name and under a name carrying a hash of their content
+	if svc.Assets != nil {
+		svc.Assets.Register(router)
+	}
+
+	// API routes; per-IP rate limiting is part of the global pipeline (see app.Builder)
+	api := root.Group("/api")
+	if len(svc.Geo.Block) > 0 {
+		api.Use(middlewares.GeoBlock(svc.Geo.Block))
+	}
+	if len(svc.Geo.RateLimits) > 0 {
+		api.Use(middlewares.GeoRateLimit(svc.Geo.RateLimits))
+	}
+	api.Use(middlewares.ValidateContentType(formRoutes...))
+	if svc.ReadOnly != nil {
+		api.Use(middlewares.ReadOnly(svc.ReadOnly, readOnlyExempt...))
+	}
+	if cfg.Middleware.OpenAPIValidation {
+		// La especificación se genera en la primera petición, con todas las rutas ya registradas
+		api.Use(middlewares.OpenAPIValidation(sync.OnceValues(func() (*openapi3.T, error) {
+			return OpenAPI(cfg.Server.AppName, cfg.Response.APIVersion, table.Routes())
+		})))
+	}
+	if cfg.Database.DegradedMode && svc.DBMonitor != nil && svc.Cache != nil {
+		api.Use(middlewares.DegradedMode(svc.Cache, cfg.Database.DegradedCacheTTL, databaseAvailable(svc)))
+	}
+	if svc.Breakers != nil {
+		api.Use(middlewares.CircuitBreaker(svc.Breakers, database.BreakerName))
+	}
+	if cfg.Security.BotDetectionEnabled {
+		api.Use(middlewares.BotDetection(cfg.Security.BotHoneypotField, cfg.Security.BotBlockThreshold))
+	}
+	if len(svc.CachePolicies) > 0 {
+		api.Use(middlewares.CacheControl(svc.CachePolicies, cfg.Cache.SurrogateKeyHeader))
+	}
+	if len(cfg.Middleware.DedupRoutes) > 0 {
+		api.Use(middlewares.Deduplicate(cfg.Middleware.DedupRoutes))
+	}
+	userRepo := repository.NewUserRepository(db)
+	alerts := &handlers.LoginAlerts{
+		Notifier:        svc.Notifier,
+		NewDevice:       cfg.Security.LoginAlertNewDevice,
+		NewCountry:      cfg.Security.LoginAlertNewCountry,
+		Verification:    cfg.Security.LoginVerification,
+		Mailer:          svc.Mailer,
+		VerificationTTL: cfg.Security.LoginVerificationTTL,
+	}
+	usernames := handlers.UsernamePolicy{
+		ChangeCooldown: cfg.Account.UsernameChangeCooldown,
+		Reservation:    cfg.Account.UsernameReservation,
+	}
+	registrationOpts := handlers.RegistrationOptions{
+		InviteOnly:   cfg.Security.RegistrationInviteOnly,
+		TermsVersion: cfg.Terms.Version,
+		Usernames:    usernames,
+	}
+	if cfg.Billing.CreateCustomers {
+		registrationOpts.Billing = svc.Billing
+	}
+	emailOpts := handlers.EmailChangeOptions{
+		Mailer:     svc.InviteMailer,
+		ConfirmTTL: cfg.Account.EmailChangeTTL,
+		RevertTTL:  cfg.Account.EmailRevertTTL,
+		ConfirmURL: cfg.Account.EmailConfirmURL,
+		RevertURL:  cfg.Account.EmailRevertURL,
+	}
+	profileOpts := handlers.ProfileOptions{
+		Usernames:           usernames,
+		ConfirmEmailChanges: emailOpts.Mailer != nil,
+	}
+	// Authenticated routes require the current terms of service, when there are any
+	consent := middlewares.RequireConsent(db, cfg.Terms.Version, cfg.Terms.URL)
+	// Authenticated requests are metered per API key, which are limited by their monthly quota
+	metered := middlewares.Metering(svc.Meter)
+	inviteOpts := handlers.InvitationOptions{
+		Mailer:    svc.InviteMailer,
+		TTL:       cfg.Orgs.InvitationTTL,
+		AcceptURL: cfg.Orgs.InvitationURL,
+	}
+	// Development tools: previews of the emails and the inbox capturing them
+	if svc.EmailInbox != nil {
+		previewOpts := handlers.EmailPreviewOptions{
+			Invitations:          inviteOpts,
+			EmailChanges:         emailOpts,
+			LoginVerificationTTL: cfg.Security.LoginVerificationTTL,
+		}
+		dev := root.Group("/dev/emails")
+		{
+			dev.GET("", handlers.ListEmailPreviews(previewOpts))
+			dev.GET("/inbox", handlers.ListCapturedEmails(svc.EmailInbox))
+			dev.DELETE("/inbox", handlers.ClearCapturedEmails(svc.EmailInbox))
+		}
+	}
 	{
+		// Error code catalog for clients
+		api.GET("/errors", handlers.ListErrorCodes())
+
 		// Authentication endpoints with stricter rate limiting
 		auth := api.Group("/auth")
 		auth.Use(middlewares.AuthRateLimit())
 		{
-			auth.POST("/register", handlers.Register(db))
-			auth.POST("/login", handlers.Login(db))
+			auth.POST("/register", handlers.Register(db, registrationOpts))
+			auth.POST("/login", handlers.Login(db, alerts))
+			auth.POST("/login/verify", handlers.VerifyLogin(db))
+			auth.POST("/email/confirm", handlers.ConfirmEmailChange(db))
+			auth.POST("/email/revert", handlers.RevertEmailChange(db))
+		}
+
+		// SAML single sign-on: the IdP posts the response form to the ACS
+		if svc.SAML != nil {
+			samlOpts := handlers.SAMLOptions{
+				JITProvisioning:   cfg.SAML.JITProvisioning,
+				TrustedDomains:    cfg.SAML.TrustedDomains,
+				EmailAttribute:    cfg.SAML.EmailAttribute,
+				UsernameAttribute: cfg.SAML.UsernameAttribute,
+				GroupsAttribute:   cfg.SAML.GroupsAttribute,
+				AdminGroups:       cfg.SAML.AdminGroups,
+				RedirectURL:       cfg.SAML.RedirectURL,
+			}
+			sso := auth.Group("/saml")
+			{
+				sso.GET("/metadata", handlers.SAMLMetadata(svc.SAML))
+				sso.GET("/login", handlers.SAMLLogin(svc.SAML))
+				sso.POST("/acs", handlers.SAMLACS(db, svc.SAML, samlOpts))
+			}
+		}
+
+		// OAuth device authorization grant for CLI clients: the token endpoint is polled, so
+		// it is paced by slow_down rather than the auth rate limit
+		if cfg.DeviceAuth.Enabled {
+			deviceOpts := handlers.DeviceAuthOptions{
+				ClientIDs:        cfg.DeviceAuth.ClientIDs,
+				VerificationPath: cfg.DeviceAuth.VerificationPath,
+				VerificationURL:  cfg.DeviceAuth.VerificationURL,
+				CodeTTL:          cfg.DeviceAuth.CodeTTL,
+				PollInterval:     cfg.DeviceAuth.PollInterval,
+			}
+			oauth := api.Group("/oauth")
+			{
+				oauth.POST("/device/code", middlewares.AuthRateLimit(), handlers.DeviceAuthorize(db, deviceOpts))
+				oauth.POST("/token", handlers.DeviceToken(db, deviceOpts))
+
+				verify := oauth.Group("/device/verify")
+				verify.Use(middlewares.AuthRequired(db), metered, consent, middlewares.AuthRateLimit())
+				{
+					verify.GET("", handlers.DeviceVerification(db))
+					verify.POST("", handlers.VerifyDevice(db))
+				}
+			}
 		}
 
 		// Legacy endpoints (for backward compatibility)
-		api.POST("/register", middlewares.AuthRateLimit(), handlers.Register(db))
-		api.POST("/login", middlewares.AuthRateLimit(), handlers.Login(db))
+		api.POST("/register", middlewares.AuthRateLimit(), handlers.Register(db, registrationOpts))
+		api.POST("/login", middlewares.AuthRateLimit(), handlers.Login(db, alerts))
 
 		// Protected endpoints
 		protected := api.Group("/protected")
-		protected.Use(middlewares.AuthRequired(db))
+		protected.Use(middlewares.AuthRequired(db), metered, consent)
 		{
 			protected.GET("/", middlewares.ProtectedHandler())
 			protected.GET("/profile", getUserProfile())
+			if svc.Billing != nil {
+				// Example of an endpoint gated by plan
+				protected.GET("/pro", middlewares.RequirePlan(db, "pro"), middlewares.ProtectedHandler())
+			}
+		}
+
+		// Billing: Stripe authenticates the webhook with its signature
+		if svc.Billing != nil {
+			api.POST("/billing/webhook", handlers.StripeWebhook(svc.Billing))
+			api.GET("/billing/subscription", middlewares.AuthRequired(db), metered, consent, handlers.GetSubscription(svc.Billing))
 		}
 
 		// User endpoints
 		users := api.Group("/users")
-		users.Use(middlewares.AuthRequired(db))
+		users.Use(middlewares.AuthRequired(db), metered, consent)
+		users.Use(middlewares.RequireStepUp(cfg.Security.StepUpRiskThreshold, cfg.Security.StepUpMaxAge))
 		{
+			users.GET("", handlers.GetUsersByIDs(userRepo))
 			users.GET("/me", getUserProfile())
+			users.PATCH("/me", handlers.UpdateProfile(db, profileOpts))
+			users.PUT("/me/email", handlers.RequestEmailChange(db, emailOpts))
+			users.GET("/me/notifications", handlers.ListNotifications(db))
+			users.POST("/me/notifications/:id/read", handlers.MarkNotificationRead(db))
+			users.GET("/me/preferences", handlers.GetPreferences(db))
+			users.PATCH("/me/preferences", handlers.UpdatePreferences(db))
+			users.GET("/me/preferences/schema", handlers.PreferencesSchema())
+			if svc.Entitlements != nil {
+				users.GET("/me/entitlements", handlers.GetEntitlements(svc.Entitlements))
+			}
 			// Add more user endpoints as needed
 		}
+
+		// API keys authenticate integrations with X-API-Key; each one has a monthly quota
+		keys := api.Group("/keys")
+		keys.Use(middlewares.AuthRequired(db), metered, consent)
+		{
+			keys.POST("", handlers.CreateAPIKey(db))
+			keys.GET("", handlers.ListAPIKeys(db))
+			keys.DELETE("/:id", handlers.RevokeAPIKey(db))
+			keys.GET("/:id/usage", handlers.APIKeyUsage(db, svc.Meter))
+		}
+
+		// Realtime: a WebSocket per channel with the presence of its users and typing indicators
+		if svc.Realtime != nil {
+			rt := api.Group("/realtime")
+			rt.Use(middlewares.AuthRequired(db), metered, consent)
+			{
+				rt.GET("", handlers.RealtimeSocket(svc.Realtime))
+				rt.GET("/channels/:channel/presence", handlers.ChannelPresence(svc.Realtime))
+			}
+		}
+
+		// Consents are recorded without the current ones, which RequireConsent asks for
+		consents := api.Group("/users/me/consents")
+		consents.Use(middlewares.AuthRequired(db), metered)
+		{
+			consents.GET("", handlers.ListConsents(db))
+			consents.POST("", handlers.RecordConsent(db, cfg.Terms.Version))
+		}
+
+		// Organizations: members act within the organization of :org (ID or slug)
+		orgs := api.Group("/orgs")
+		orgs.Use(middlewares.AuthRequired(db), metered, consent)
+		{
+			orgs.POST("", handlers.CreateOrganization(db, svc.Entitlements))
+			orgs.GET("", handlers.ListOrganizations(db))
+
+			org := orgs.Group("/:"+middlewares.OrgParam, middlewares.OrgScope(db))
+			{
+				org.GET("", handlers.GetOrganization())
+				org.DELETE("", middlewares.RequireOrgRole(models.OrgRoleOwner), handlers.DeleteOrganization(db))
+				org.GET("/members", handlers.ListMembers(db))
+				org.PATCH("/members/:user_id", middlewares.RequireOrgRole(models.OrgRoleAdmin), handlers.UpdateMember(db))
+				org.DELETE("/members/:user_id", handlers.RemoveMember(db))
+
+				invitations := org.Group("/invitations", middlewares.RequireOrgRole(models.OrgRoleAdmin))
+				{
+					invitations.POST("", handlers.CreateInvitation(db, inviteOpts))
+					invitations.GET("", handlers.ListInvitations(db))
+					invitations.DELETE("/:id", handlers.RevokeInvitation(db))
+				}
+
+				// Registration codes that also join the organization
+				codes := org.Group("/registration-codes", middlewares.RequireOrgRole(models.OrgRoleOwner))
+				{
+					codes.POST("", handlers.CreateRegistrationCode(db, cfg.Security.RegistrationCodeTTL))
+					codes.GET("", handlers.ListRegistrationCodes(db))
+					codes.DELETE("/:id", handlers.RevokeRegistrationCode(db))
+				}
+			}
+		}
+		api.POST("/invitations/accept", middlewares.AuthRequired(db), metered, consent, handlers.AcceptInvitation(db))
+
+		// Long-running operation endpoints
+		operationsGroup := api.Group("/operations")
+		operationsGroup.Use(middlewares.AuthRequired(db), metered, consent)
+		{
+			operationsGroup.GET("/:id", handlers.GetOperation(svc.Operations))
+			operationsGroup.POST("/:id/cancel", handlers.CancelOperation(svc.Operations))
+		}
+
+		// Admin endpoints
+		admin := api.Group("/admin")
+		admin.Use(middlewares.AuthRequired(db), metered, consent)
+		admin.RequireRole(models.RoleAdmin)
+		{
+			admin.GET("/users", handlers.ListUsers(userRepo))
+			admin.POST("/users/batch", handlers.BatchUsers(db, svc.Operations))
+			admin.GET("/users/:id/username-history", handlers.UsernameHistory(db))
+			admin.GET("/routes", listRoutes(table))
+			admin.POST("/registration-codes", handlers.CreateRegistrationCode(db, cfg.Security.RegistrationCodeTTL))
+			admin.GET("/registration-codes", handlers.ListRegistrationCodes(db))
+			admin.DELETE("/registration-codes/:id", handlers.RevokeRegistrationCode(db))
+			if svc.Entitlements != nil {
+				// Plans and their manual assignment, independent of the payment provider
+				admin.GET("/plans", handlers.ListPlans(db))
+				admin.PUT("/plans/:name", handlers.PutPlan(db))
+				admin.DELETE("/plans/:name", handlers.DeletePlan(db))
+				admin.PUT("/users/:id/plan", handlers.AssignPlan(db))
+				admin.DELETE("/users/:id/plan", handlers.UnassignPlan(db))
+			}
+			if svc.SLO != nil {
+				admin.GET("/slo", handlers.SLOSummary(svc.SLO))
+			}
+			if svc.Purger != nil {
+				admin.POST("/cache/purge", handlers.PurgeCache(svc.Purger))
+			}
+			if svc.ReadOnly != nil {
+				admin.GET("/read-only", handlers.GetReadOnly(svc.ReadOnly))
+				admin.PUT("/read-only", handlers.SetReadOnly(svc.ReadOnly))
+			}
+			if svc.Profiler != nil {
+				admin.GET("/profiles", handlers.ListProfiles(svc.Profiler))
+			}
+			if svc.RouteDebug != nil {
+				admin.GET("/logging", handlers.GetLogging(svc.RouteDebug))
+				admin.PUT("/logging", handlers.SetLogging(svc.RouteDebug, registeredRoute(router)))
+			}
+			if svc.Instance != nil {
+				admin.GET("/instance", handlers.InstanceInfo(svc.Instance))
+			}
+			if svc.CrashReports != nil {
+				admin.GET("/crash-reports", handlers.ListCrashReports(svc.CrashReports))
+				admin.GET("/crash-reports/:id", handlers.GetCrashReport(svc.CrashReports))
+			}
+		}
+
+		// Recursos generados con "api gen resource"
+		// gen:routes
+	}
+	return table
+}
+
+// RegisterInternalRoutes registra las rutas de los listeners internos: métricas y health checks.
+func RegisterInternalRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config, svc Services) {
+	registerHealthRoutes(NewTable(router).Root(), db, cfg, svc)
+	if cfg.Metrics.Enabled {
+		router.GET(cfg.Metrics.Path, gin.WrapH(metrics.Handler()))
 	}
 }
 
+// IsHealthPath indica si path es un probe de salud, que nunca se limita ni se descarta por carga.
+func IsHealthPath(path string) bool {
+	return path == "/health" || strings.HasPrefix(path, "/health/") || path == handlers.LivezPath
+}
+
+// registerHealthRoutes registra los probes de salud.
+func registerHealthRoutes(router *Group, db *gorm.DB, cfg *config.Config, svc Services) {
+	health := router.Group("/health")
+	{
+		health.GET("/", handlers.HealthCheck(db, svc.Breakers, svc.HealthProbes, svc.Watchdog))
+		health.GET("/live", handlers.LivenessCheck())
+		health.GET("/ready", handlers.ReadinessCheck(db, cfg.Database.DegradedMode, svc.Lifecycle))
+		health.GET("/startup", handlers.StartupCheck(svc.Lifecycle))
+	}
+}
+
+// databaseAvailable combina el monitor de la base de datos y su circuit breaker.
+func databaseAvailable(svc Services) func() bool {
+	return func() bool {
+		if !svc.DBMonitor.Available() {
+			return false
+		}
+		if svc.Breakers != nil {
+			if b, ok := svc.Breakers.Lookup(database.BreakerName); ok && b.State() == circuitbreaker.StateOpen {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// registeredRoute indica si route es el patrón de una ruta registrada en router, como
+// /api/users/:id.
+func registeredRoute(router *gin.Engine) func(route string) bool {
+	return func(route string) bool {
+		for _, r := range router.Routes() {
+			if r.Path == route {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// profileFields are the profile fields that can be selected with ?fields=
+var profileFields = []string{"id", "username", "email"}
+
 // getUserProfile returns the current user's profile
 func getUserProfile() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, _ := c.Get("user_id")
-		email, _ := c.Get("email")
-		username, _ := c.Get("username")
+		user, ok := requestctx.CurrentUser(c)
+		if !ok {
+			response.UnauthorizedError(c, "Authorization required", "No authenticated user")
+			return
+		}
+
+		fields, err := projection.FromContext(c, profileFields)
+		if err != nil {
+			_ = c.Error(apperrors.BadRequest("Invalid fields", err.Error()))
+			return
+		}
 
 		profile := gin.H{
-			"id":       userID,
-			"username": username,
-			"email":    email,
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
 		}
 
-		response.SuccessResponse(c, 200, "User profile retrieved successfully", profile)
+		data, err := fields.Apply(profile)
+		if err != nil {
+			_ = c.Error(apperrors.Internal("Could not retrieve profile", "Failed to encode the response", err))
+			return
+		}
+		response.SuccessResponse(c, 200, "User profile retrieved successfully", data)
 	}
 }
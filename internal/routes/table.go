@@ -0,0 +1,205 @@
+package routes
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// RouteInfo describe una ruta registrada.
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+	// Middlewares son los middlewares propios de la ruta, sin el pipeline global.
+	Middlewares  []string `json:"middlewares"`
+	AuthRequired bool     `json:"auth_required"`
+	Roles        []string `json:"roles,omitempty"`
+}
+
+// RouteTableResponse es la respuesta de GET /api/admin/routes.
+type RouteTableResponse struct {
+	Routes []RouteInfo `json:"routes"`
+	Total  int         `json:"total"`
+}
+
+// Table registra las rutas a medida que se declaran a través de Group, con sus middlewares y
+// requisitos de autenticación, que gin no expone después del registro.
+type Table struct {
+	engine *gin.Engine
+	global int
+
+	mu     sync.RWMutex
+	routes []RouteInfo
+}
+
+// NewTable crea la tabla de rutas de engine. Los middlewares añadidos al engine hasta ahora se
+// consideran globales y no se listan por ruta.
+func NewTable(engine *gin.Engine) *Table {
+	return &Table{engine: engine, global: len(engine.Handlers)}
+}
+
+// Root devuelve el grupo raíz del engine.
+func (t *Table) Root() *Group {
+	return &Group{RouterGroup: &t.engine.RouterGroup, table: t}
+}
+
+// Routes devuelve todas las rutas ordenadas por path y método. Las registradas directamente en
+// gin, sin pasar por Group, se incluyen sin middlewares.
+func (t *Table) Routes() []RouteInfo {
+	t.mu.RLock()
+	routes := append([]RouteInfo(nil), t.routes...)
+	t.mu.RUnlock()
+
+	recorded := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		recorded[r.Method+" "+r.Path] = true
+	}
+	for _, r := range t.engine.Routes() {
+		if !recorded[r.Method+" "+r.Path] {
+			routes = append(routes, RouteInfo{Method: r.Method, Path: r.Path, Handler: shortFuncName(r.Handler), Middlewares: []string{}})
+		}
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+func (t *Table) record(g *Group, method, relativePath string, handlers []gin.HandlerFunc) {
+	chain := append(append([]gin.HandlerFunc(nil), g.Handlers[min(t.global, len(g.Handlers)):]...), handlers...)
+	info := RouteInfo{
+		Method:      method,
+		Path:        joinPaths(g.BasePath(), relativePath),
+		Middlewares: []string{},
+		Roles:       g.roles,
+	}
+	for i, h := range chain {
+		name := handlerName(h)
+		if i == len(chain)-1 {
+			info.Handler = name
+			break
+		}
+		info.Middlewares = append(info.Middlewares, name)
+		if name == authMiddlewareName {
+			info.AuthRequired = true
+		}
+	}
+
+	t.mu.Lock()
+	t.routes = append(t.routes, info)
+	t.mu.Unlock()
+}
+
+// Group envuelve gin.RouterGroup para registrar en la tabla cada ruta que declara.
+type Group struct {
+	*gin.RouterGroup
+	table *Table
+	roles []string
+}
+
+// Group crea un subgrupo que hereda los middlewares y roles de g.
+func (g *Group) Group(relativePath string, handlers ...gin.HandlerFunc) *Group {
+	return &Group{RouterGroup: g.RouterGroup.Group(relativePath, handlers...), table: g.table, roles: g.roles}
+}
+
+// RequireRole añade middlewares.RequireRole al grupo y anota los roles en sus rutas.
+func (g *Group) RequireRole(roles ...string) {
+	g.Use(middlewares.RequireRole(roles...))
+	g.roles = append(append([]string(nil), g.roles...), roles...)
+}
+
+// Handle registra una ruta con cualquier método.
+func (g *Group) Handle(method, relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	g.table.record(g, method, relativePath, handlers)
+	return g.RouterGroup.Handle(method, relativePath, handlers...)
+}
+
+// GET registra una ruta GET.
+func (g *Group) GET(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodGet, relativePath, handlers...)
+}
+
+// POST registra una ruta POST.
+func (g *Group) POST(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodPost, relativePath, handlers...)
+}
+
+// PUT registra una ruta PUT.
+func (g *Group) PUT(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodPut, relativePath, handlers...)
+}
+
+// PATCH registra una ruta PATCH.
+func (g *Group) PATCH(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodPatch, relativePath, handlers...)
+}
+
+// DELETE registra una ruta DELETE.
+func (g *Group) DELETE(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodDelete, relativePath, handlers...)
+}
+
+// HEAD registra una ruta HEAD.
+func (g *Group) HEAD(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodHead, relativePath, handlers...)
+}
+
+// OPTIONS registra una ruta OPTIONS.
+func (g *Group) OPTIONS(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodOptions, relativePath, handlers...)
+}
+
+// listRoutes devuelve la tabla de rutas.
+func listRoutes(table *Table) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		routes := table.Routes()
+		response.SuccessResponse(c, http.StatusOK, "Routes retrieved successfully", RouteTableResponse{
+			Routes: routes,
+			Total:  len(routes),
+		})
+	}
+}
+
+var (
+	authMiddlewareName = handlerName(middlewares.AuthRequired(nil))
+
+	// closureSuffix matches the suffixes the compiler adds to closures and method values.
+	closureSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+)
+
+// handlerName devuelve el nombre corto de la función que crea h, como "middlewares.AuthRequired".
+func handlerName(h gin.HandlerFunc) string {
+	return shortFuncName(runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name())
+}
+
+func shortFuncName(name string) string {
+	name = name[strings.LastIndex(name, "/")+1:]
+	return closureSuffix.ReplaceAllString(name, "")
+}
+
+// joinPaths une el path base de un grupo con el relativo de una ruta, como hace gin.
+func joinPaths(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		joined += "/"
+	}
+	return joined
+}
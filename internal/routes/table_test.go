@@ -0,0 +1,92 @@
+package routes
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/middlewares"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func findRoute(t *testing.T, routes []RouteInfo, method, path string) RouteInfo {
+	t.Helper()
+	for _, r := range routes {
+		if r.Method == method && r.Path == path {
+			return r
+		}
+	}
+	t.Fatalf("route %s %s not in table %+v", method, path, routes)
+	return RouteInfo{}
+}
+
+func TestTableRecordsMiddlewaresAndAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.SecurityHeaders())
+	table := NewTable(router)
+
+	api := table.Root().Group("/api", middlewares.ValidateContentType())
+	api.GET("/public", middlewares.ProtectedHandler())
+
+	admin := api.Group("/admin")
+	admin.Use(middlewares.AuthRequired(nil))
+	admin.RequireRole(models.RoleAdmin)
+	admin.POST("/things/", middlewares.ProtectedHandler())
+
+	// Registered without the wrapper
+	router.GET("/raw", middlewares.ProtectedHandler())
+
+	routes := table.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("expected 3 routes, got %+v", routes)
+	}
+
+	public := findRoute(t, routes, http.MethodGet, "/api/public")
+	if public.AuthRequired || len(public.Roles) != 0 || public.Handler != "middlewares.ProtectedHandler" {
+		t.Errorf("unexpected public route %+v", public)
+	}
+	// Global middlewares are not listed per route
+	if !reflect.DeepEqual(public.Middlewares, []string{"middlewares.ValidateContentType"}) {
+		t.Errorf("public middlewares = %v", public.Middlewares)
+	}
+
+	adminRoute := findRoute(t, routes, http.MethodPost, "/api/admin/things/")
+	wantMiddlewares := []string{"middlewares.ValidateContentType", "middlewares.AuthRequired", "middlewares.RequireRole"}
+	if !adminRoute.AuthRequired || !reflect.DeepEqual(adminRoute.Roles, []string{models.RoleAdmin}) ||
+		!reflect.DeepEqual(adminRoute.Middlewares, wantMiddlewares) {
+		t.Errorf("unexpected admin route %+v", adminRoute)
+	}
+
+	raw := findRoute(t, routes, http.MethodGet, "/raw")
+	if raw.Handler != "middlewares.ProtectedHandler" || raw.AuthRequired {
+		t.Errorf("unexpected raw route %+v", raw)
+	}
+}
+
+func TestListRoutesRequiresAdmin(t *testing.T) {
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		RegisterAPIRoutes(a.Router, a.DB, a.Config, Services{})
+	})
+	user := testutil.CreateUser(t, app.DB)
+	admin := testutil.CreateAdmin(t, app.DB)
+
+	w := testutil.Get("/api/admin/routes").WithJWT(user).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusForbidden)
+
+	w = testutil.Get("/api/admin/routes").WithJWT(admin).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var table RouteTableResponse
+	testutil.DecodeData(t, w, &table)
+	if table.Total != len(table.Routes) {
+		t.Errorf("total %d does not match %d routes", table.Total, len(table.Routes))
+	}
+	self := findRoute(t, table.Routes, http.MethodGet, "/api/admin/routes")
+	if !self.AuthRequired || !reflect.DeepEqual(self.Roles, []string{models.RoleAdmin}) {
+		t.Errorf("unexpected route entry %+v", self)
+	}
+}
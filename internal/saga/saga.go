@@ -0,0 +1,436 @@
+// Package saga runs business processes made of several steps, such as "register a user, create
+// their organization, send the invitations", on the job queue. Each step has a compensation
+// undoing it: when a step fails, the steps already completed are compensated in reverse order.
+// The progress of a saga is persisted as a models.Saga after every step, so a saga interrupted
+// by a restart is resumed where it stopped.
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+var (
+	// ErrNotFound is returned when a saga does not exist or has expired.
+	ErrNotFound = errors.New("saga not found")
+	// ErrUnknown is returned when starting a saga whose definition was not registered.
+	ErrUnknown = errors.New("unknown saga")
+	// ErrNotSet is returned by State.Get for a key that was never set.
+	ErrNotSet = errors.New("state value not set")
+)
+
+// jobName names the jobs running sagas, whose payload is the saga ID.
+const jobName = "saga"
+
+var finishedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "sagas_finished_total",
+	Help: "Sagas that finished, by name and status (succeeded, compensated or failed).",
+}, []string{"saga", "status"})
+
+func init() {
+	metrics.Registry.MustRegister(finishedTotal)
+}
+
+// Step is one step of a saga.
+type Step struct {
+	// Name identifies the step in errors, e.g. "create_organization".
+	Name string
+	// Do performs the step. It may run again after a restart, so it must be idempotent,
+	// for instance by looking up what it creates before creating it. A failed Do must leave
+	// nothing to undo: it is not compensated itself.
+	Do func(ctx context.Context, state *State) error
+	// Compensate undoes a completed Do; optional for steps with nothing to undo. It must be
+	// idempotent too.
+	Compensate func(ctx context.Context, state *State) error
+}
+
+// Definition is a named sequence of steps.
+type Definition struct {
+	// Name identifies the saga, e.g. "users.onboard". It is stored with each saga, so it must
+	// not change while sagas of the definition are unfinished.
+	Name  string
+	Steps []Step
+}
+
+// Runner registers saga definitions, starts sagas on the job queue and resumes the ones
+// interrupted by a restart.
+type Runner struct {
+	db         *gorm.DB
+	queue      jobs.Queue
+	staleAfter time.Duration
+	now        func() time.Time
+
+	mu          sync.Mutex
+	definitions map[string]Definition
+	active      map[string]bool
+}
+
+// NewRunner creates a runner storing sagas in db and running them on queue. Unfinished sagas
+// that made no progress for staleAfter are considered interrupted and resumed by Resume, so it
+// must be longer than the slowest step. On a queue persisting its jobs, such as a
+// jobs.DurableQueue, the runner registers the handler of its jobs.
+func NewRunner(db *gorm.DB, queue jobs.Queue, staleAfter time.Duration) *Runner {
+	r := &Runner{
+		db:          db,
+		queue:       queue,
+		staleAfter:  staleAfter,
+		now:         time.Now,
+		definitions: make(map[string]Definition),
+		active:      make(map[string]bool),
+	}
+	if registry, ok := queue.(jobs.HandlerRegistry); ok {
+		registry.Handle(jobName, func(ctx context.Context, payload []byte) error {
+			return r.execute(ctx, string(payload))
+		})
+	}
+	return r
+}
+
+// Register adds a definition, which must have a name not registered yet and at least one step
+// with a Do function.
+func (r *Runner) Register(def Definition) error {
+	if def.Name == "" {
+		return errors.New("saga definition without a name")
+	}
+	if len(def.Steps) == 0 {
+		return fmt.Errorf("saga %s has no steps", def.Name)
+	}
+	for i, step := range def.Steps {
+		if step.Do == nil {
+			return fmt.Errorf("step %d of saga %s has no Do function", i, def.Name)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.definitions[def.Name]; ok {
+		return fmt.Errorf("saga %s is already registered", def.Name)
+	}
+	r.definitions[def.Name] = def
+	return nil
+}
+
+// Start persists a saga of the definition name with the initial state values and enqueues it.
+// When the queue is full the saga is still returned, and runs once Resume finds it stale.
+func (r *Runner) Start(ctx context.Context, name string, values map[string]any) (*models.Saga, error) {
+	if _, ok := r.definition(name); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknown, name)
+	}
+	state := newState()
+	for key, value := range values {
+		if err := state.Set(key, value); err != nil {
+			return nil, err
+		}
+	}
+	encoded, err := state.encode()
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate saga id: %w", err)
+	}
+
+	saga := &models.Saga{ID: id.String(), Name: name, Status: models.SagaRunning, State: encoded}
+	if err := r.db.WithContext(ctx).Create(saga).Error; err != nil {
+		return nil, fmt.Errorf("failed to create saga: %w", err)
+	}
+	if err := r.enqueue(saga.ID); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"saga_id": saga.ID,
+			"error":   err.Error(),
+		}).Warn("Failed to enqueue saga; it will be resumed later")
+	}
+	return saga, nil
+}
+
+// Get returns a saga by ID.
+func (r *Runner) Get(ctx context.Context, id string) (*models.Saga, error) {
+	var saga models.Saga
+	if err := r.db.WithContext(ctx).First(&saga, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &saga, nil
+}
+
+// Resume enqueues the unfinished sagas that made no progress for the stale period and are not
+// running in this process, and returns how many it enqueued.
+func (r *Runner) Resume(ctx context.Context) (int, error) {
+	var stale []models.Saga
+	err := r.db.WithContext(ctx).Select("id").
+		Where("status IN ? AND updated_at < ?", []string{models.SagaRunning, models.SagaCompensating}, r.now().Add(-r.staleAfter)).
+		Order("created_at").Find(&stale).Error
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for _, saga := range stale {
+		if r.isActive(saga.ID) {
+			continue
+		}
+		if err := r.enqueue(saga.ID); err != nil {
+			return resumed, err
+		}
+		resumed++
+	}
+	return resumed, nil
+}
+
+// Run resumes interrupted sagas every interval until ctx is canceled.
+func (r *Runner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			resumed, err := r.Resume(ctx)
+			if err != nil {
+				logger.WithField("error", err.Error()).Error("Failed to resume sagas")
+			}
+			if resumed > 0 {
+				logger.WithField("resumed", resumed).Info("Resumed interrupted sagas")
+			}
+		}
+	}
+}
+
+func (r *Runner) definition(name string) (Definition, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	def, ok := r.definitions[name]
+	return def, ok
+}
+
+func (r *Runner) enqueue(id string) error {
+	return r.queue.Enqueue(jobs.Job{
+		ID:      id,
+		Name:    jobName,
+		Payload: []byte(id),
+		Run: func(ctx context.Context) error {
+			return r.execute(ctx, id)
+		},
+	})
+}
+
+// setActive marks a saga as running in this process, returning false when it already was.
+func (r *Runner) setActive(id string, active bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if active && r.active[id] {
+		return false
+	}
+	if active {
+		r.active[id] = true
+	} else {
+		delete(r.active, id)
+	}
+	return true
+}
+
+func (r *Runner) isActive(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.active[id]
+}
+
+// execute claims a saga and runs its remaining steps, or its remaining compensations.
+func (r *Runner) execute(ctx context.Context, id string) error {
+	if !r.setActive(id, true) {
+		return nil
+	}
+	defer r.setActive(id, false)
+
+	saga, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if saga.IsFinished() {
+		return nil
+	}
+	// Claim the saga, which another instance may have resumed in the meantime
+	claimed := r.db.WithContext(ctx).Model(&models.Saga{}).
+		Where("id = ? AND attempts = ?", id, saga.Attempts).
+		Update("attempts", saga.Attempts+1)
+	if claimed.Error != nil {
+		return claimed.Error
+	}
+	if claimed.RowsAffected == 0 {
+		return nil
+	}
+	saga.Attempts++
+
+	def, ok := r.definition(saga.Name)
+	if !ok {
+		return r.finish(saga, models.SagaFailed, fmt.Sprintf("%s: %s", ErrUnknown, saga.Name))
+	}
+	state, err := decodeState(saga.State)
+	if err != nil {
+		return r.finish(saga, models.SagaFailed, fmt.Sprintf("invalid state: %v", err))
+	}
+
+	for saga.Status == models.SagaRunning && saga.Step < len(def.Steps) {
+		step := def.Steps[saga.Step]
+		if err := call(ctx, step.Do, state); err != nil {
+			if ctx.Err() != nil {
+				// Shutting down: the step runs again when the saga is resumed
+				return ctx.Err()
+			}
+			saga.Status = models.SagaCompensating
+			saga.Error = fmt.Sprintf("%s: %v", step.Name, err)
+		} else {
+			saga.Step++
+		}
+		if err := r.save(ctx, saga, state); err != nil {
+			return err
+		}
+	}
+	if saga.Status == models.SagaRunning {
+		return r.finish(saga, models.SagaSucceeded, "")
+	}
+
+	for saga.Step > 0 {
+		step := def.Steps[saga.Step-1]
+		if step.Compensate != nil {
+			if err := call(ctx, step.Compensate, state); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// The saga is left half undone for an operator to fix
+				msg := fmt.Sprintf("%s; compensating %s: %v", saga.Error, step.Name, err)
+				return errors.Join(err, r.finish(saga, models.SagaFailed, msg))
+			}
+		}
+		saga.Step--
+		if err := r.save(ctx, saga, state); err != nil {
+			return err
+		}
+	}
+	return r.finish(saga, models.SagaCompensated, saga.Error)
+}
+
+// call runs fn, turning a panic into an error so that it is compensated like a failure.
+func call(ctx context.Context, fn func(context.Context, *State) error, state *State) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx, state)
+}
+
+// save persists the progress and state of a saga, which also marks it as not stale.
+func (r *Runner) save(ctx context.Context, saga *models.Saga, state *State) error {
+	encoded, err := state.encode()
+	if err != nil {
+		return err
+	}
+	saga.State = encoded
+	return r.db.WithContext(ctx).Model(&models.Saga{}).Where("id = ?", saga.ID).
+		Updates(map[string]interface{}{
+			"status": saga.Status,
+			"step":   saga.Step,
+			"state":  saga.State,
+			"error":  saga.Error,
+		}).Error
+}
+
+// finish stores the final status of a saga. It is not bound to the job's context, so that a
+// saga that finished is recorded as such during a shutdown.
+func (r *Runner) finish(saga *models.Saga, status, errMsg string) error {
+	err := r.db.Model(&models.Saga{}).Where("id = ?", saga.ID).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"step":        saga.Step,
+			"error":       errMsg,
+			"finished_at": r.now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record the result of saga %s: %w", saga.ID, err)
+	}
+	finishedTotal.WithLabelValues(saga.Name, status).Inc()
+
+	fields := map[string]interface{}{"saga_id": saga.ID, "saga": saga.Name, "status": status}
+	switch status {
+	case models.SagaFailed:
+		fields["error"] = errMsg
+		logger.WithFields(fields).Error("Saga failed and was not fully compensated")
+	case models.SagaCompensated:
+		fields["error"] = errMsg
+		logger.WithFields(fields).Warn("Saga compensated after a failed step")
+	default:
+		logger.WithFields(fields).Debug("Saga completed")
+	}
+	return nil
+}
+
+// State holds the values that the steps of a saga pass to each other, such as the ID of a
+// record created by one step and deleted by its compensation. It is persisted as JSON after
+// every step.
+type State struct {
+	values map[string]json.RawMessage
+}
+
+func newState() *State {
+	return &State{values: make(map[string]json.RawMessage)}
+}
+
+func decodeState(encoded string) (*State, error) {
+	state := newState()
+	if encoded == "" {
+		return state, nil
+	}
+	if err := json.Unmarshal([]byte(encoded), &state.values); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *State) encode() (string, error) {
+	encoded, err := json.Marshal(s.values)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode saga state: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// Set stores value under key, encoded as JSON.
+func (s *State) Set(key string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode saga state %q: %w", key, err)
+	}
+	s.values[key] = encoded
+	return nil
+}
+
+// Get decodes the value stored under key into v, or returns ErrNotSet.
+func (s *State) Get(key string, v any) error {
+	encoded, ok := s.values[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotSet, key)
+	}
+	if err := json.Unmarshal(encoded, v); err != nil {
+		return fmt.Errorf("failed to decode saga state %q: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/jobs"
+	"github.com/yeferson59/gin-template/internal/mocks"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func setupRunner(t *testing.T) (*Runner, *mocks.Queue) {
+	t.Helper()
+	db := testutil.NewDB(t)
+	queue := &mocks.Queue{}
+	return NewRunner(db, queue, time.Minute), queue
+}
+
+// recorder builds steps that append what they do to a log.
+type recorder struct {
+	log  []string
+	fail map[string]error
+}
+
+func (rec *recorder) step(name string) Step {
+	return Step{
+		Name: name,
+		Do: func(_ context.Context, state *State) error {
+			rec.log = append(rec.log, "do "+name)
+			if err := rec.fail["do "+name]; err != nil {
+				return err
+			}
+			return state.Set(name, "done")
+		},
+		Compensate: func(_ context.Context, state *State) error {
+			var value string
+			if err := state.Get(name, &value); err != nil {
+				return err
+			}
+			rec.log = append(rec.log, "undo "+name)
+			return rec.fail["undo "+name]
+		},
+	}
+}
+
+func runSaga(t *testing.T, r *Runner, queue *mocks.Queue, def Definition) *models.Saga {
+	t.Helper()
+	if err := r.Register(def); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+	saga, err := r.Start(context.Background(), def.Name, map[string]any{"user_id": 7})
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	_ = queue.RunAll(context.Background())
+	saga, err = r.Get(context.Background(), saga.ID)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	return saga
+}
+
+func TestRunnerCompletesSteps(t *testing.T) {
+	r, queue := setupRunner(t)
+	rec := &recorder{}
+	var userID int
+	first := rec.step("user")
+	do := first.Do
+	first.Do = func(ctx context.Context, state *State) error {
+		if err := state.Get("user_id", &userID); err != nil {
+			return err
+		}
+		return do(ctx, state)
+	}
+
+	saga := runSaga(t, r, queue, Definition{Name: "onboard", Steps: []Step{first, rec.step("org"), rec.step("invites")}})
+
+	if saga.Status != models.SagaSucceeded || saga.Step != 3 || saga.FinishedAt == nil || saga.Attempts != 1 {
+		t.Fatalf("unexpected saga: %+v", saga)
+	}
+	if got := strings.Join(rec.log, ", "); got != "do user, do org, do invites" {
+		t.Errorf("log = %q", got)
+	}
+	if userID != 7 {
+		t.Errorf("user_id = %d, want the initial state value 7", userID)
+	}
+	if !strings.Contains(saga.State, `"org":"done"`) {
+		t.Errorf("state = %s, want the values set by the steps", saga.State)
+	}
+}
+
+func TestRunnerCompensatesCompletedStepsInReverse(t *testing.T) {
+	r, queue := setupRunner(t)
+	rec := &recorder{fail: map[string]error{"do invites": errors.New("mail server down")}}
+
+	saga := runSaga(t, r, queue, Definition{Name: "onboard", Steps: []Step{rec.step("user"), rec.step("org"), rec.step("invites")}})
+
+	if saga.Status != models.SagaCompensated || saga.Step != 0 || saga.Error != "invites: mail server down" {
+		t.Fatalf("unexpected saga: %+v", saga)
+	}
+	if got := strings.Join(rec.log, ", "); got != "do user, do org, do invites, undo org, undo user" {
+		t.Errorf("log = %q", got)
+	}
+}
+
+func TestRunnerFailsWhenCompensationFails(t *testing.T) {
+	r, queue := setupRunner(t)
+	rec := &recorder{fail: map[string]error{
+		"do invites": errors.New("mail server down"),
+		"undo org":   errors.New("organization locked"),
+	}}
+
+	saga := runSaga(t, r, queue, Definition{Name: "onboard", Steps: []Step{rec.step("user"), rec.step("org"), rec.step("invites")}})
+
+	if saga.Status != models.SagaFailed || saga.Step != 2 {
+		t.Fatalf("unexpected saga: %+v", saga)
+	}
+	if saga.Error != "invites: mail server down; compensating org: organization locked" {
+		t.Errorf("error = %q", saga.Error)
+	}
+	if got := strings.Join(rec.log, ", "); got != "do user, do org, do invites, undo org" {
+		t.Errorf("log = %q, want the compensation to stop at the failure", got)
+	}
+}
+
+func TestRunnerCompensatesPanics(t *testing.T) {
+	r, queue := setupRunner(t)
+	rec := &recorder{}
+	boom := Step{Name: "boom", Do: func(context.Context, *State) error { panic("nil map") }}
+
+	saga := runSaga(t, r, queue, Definition{Name: "onboard", Steps: []Step{rec.step("user"), boom}})
+
+	if saga.Status != models.SagaCompensated || saga.Error != "boom: panic: nil map" {
+		t.Fatalf("unexpected saga: %+v", saga)
+	}
+	if got := strings.Join(rec.log, ", "); got != "do user, undo user" {
+		t.Errorf("log = %q", got)
+	}
+}
+
+func TestRunnerResumesInterruptedSagas(t *testing.T) {
+	r, queue := setupRunner(t)
+	rec := &recorder{}
+	if err := r.Register(Definition{Name: "onboard", Steps: []Step{rec.step("user"), rec.step("org")}}); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+	ctx := context.Background()
+
+	// A saga whose process stopped after its first step
+	interrupted := &models.Saga{ID: "interrupted", Name: "onboard", Status: models.SagaRunning, Step: 1, State: `{"user":"done"}`, Attempts: 1}
+	if err := r.db.Create(interrupted).Error; err != nil {
+		t.Fatalf("failed to create saga: %v", err)
+	}
+
+	if resumed, err := r.Resume(ctx); err != nil || resumed != 0 {
+		t.Fatalf("Resume() = %d, %v; want the recently updated saga left alone", resumed, err)
+	}
+
+	r.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if resumed, err := r.Resume(ctx); err != nil || resumed != 1 {
+		t.Fatalf("Resume() = %d, %v; want the stale saga resumed", resumed, err)
+	}
+	if err := queue.RunAll(ctx); err != nil {
+		t.Fatalf("resumed saga failed: %v", err)
+	}
+
+	saga, _ := r.Get(ctx, "interrupted")
+	if saga.Status != models.SagaSucceeded || saga.Step != 2 || saga.Attempts != 2 {
+		t.Fatalf("unexpected saga: %+v", saga)
+	}
+	if got := strings.Join(rec.log, ", "); got != "do org" {
+		t.Errorf("log = %q, want only the remaining step run", got)
+	}
+	if resumed, _ := r.Resume(ctx); resumed != 0 {
+		t.Errorf("Resume() = %d, want finished sagas left alone", resumed)
+	}
+}
+
+func TestRunnerSkipsSagasClaimedElsewhere(t *testing.T) {
+	r, queue := setupRunner(t)
+	rec := &recorder{}
+	saga := &models.Saga{ID: "claimed", Name: "onboard", Status: models.SagaRunning}
+	if err := r.Register(Definition{Name: "onboard", Steps: []Step{rec.step("user")}}); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+	if err := r.db.Create(saga).Error; err != nil {
+		t.Fatalf("failed to create saga: %v", err)
+	}
+	// Another instance claims the saga between the load and the claim of this one
+	r.db.Callback().Query().After("gorm:query").Register("claim_elsewhere", func(db *gorm.DB) {
+		db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Exec("UPDATE sagas SET attempts = attempts + 1 WHERE id = ?", "claimed")
+	})
+
+	if err := r.enqueue("claimed"); err != nil {
+		t.Fatalf("enqueue returned error: %v", err)
+	}
+	if err := queue.RunAll(context.Background()); err != nil {
+		t.Fatalf("execute returned error: %v", err)
+	}
+	if len(rec.log) != 0 {
+		t.Errorf("log = %v, want no step run by this instance", rec.log)
+	}
+}
+
+func TestRunnerRegisterAndStartErrors(t *testing.T) {
+	r, _ := setupRunner(t)
+	step := Step{Name: "noop", Do: func(context.Context, *State) error { return nil }}
+
+	for _, def := range []Definition{
+		{Steps: []Step{step}},
+		{Name: "empty"},
+		{Name: "no_do", Steps: []Step{{Name: "noop"}}},
+	} {
+		if err := r.Register(def); err == nil {
+			t.Errorf("Register(%+v) accepted an invalid definition", def)
+		}
+	}
+	if err := r.Register(Definition{Name: "ok", Steps: []Step{step}}); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+	if err := r.Register(Definition{Name: "ok", Steps: []Step{step}}); err == nil {
+		t.Error("Register accepted a duplicate name")
+	}
+
+	if _, err := r.Start(context.Background(), "missing", nil); !errors.Is(err, ErrUnknown) {
+		t.Errorf("Start() error = %v, want ErrUnknown", err)
+	}
+	if _, err := r.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRunnerRunsOnDurableQueue(t *testing.T) {
+	r, _ := setupRunner(t)
+	queue := jobs.NewDurableQueue(r.db, jobs.DurableOptions{PollInterval: 5 * time.Millisecond})
+	t.Cleanup(func() { _ = queue.Shutdown(context.Background()) })
+	r = NewRunner(r.db, queue, time.Minute)
+	step := Step{Name: "noop", Do: func(context.Context, *State) error { return nil }}
+	if err := r.Register(Definition{Name: "onboard", Steps: []Step{step}}); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+
+	started, err := r.Start(context.Background(), "onboard", nil)
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if saga, _ := r.Get(context.Background(), started.ID); saga.IsFinished() {
+			if saga.Status != models.SagaSucceeded {
+				t.Fatalf("unexpected saga: %+v", saga)
+			}
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("the saga did not finish")
+}
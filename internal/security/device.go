@@ -0,0 +1,38 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// DeviceIDHeader lets clients that keep a stable installation id, such as mobile apps, send it
+// so their fingerprint survives browser and OS updates.
+const DeviceIDHeader = "X-Device-ID"
+
+// fingerprintHeaders are the request headers that describe the client software. They are stable
+// across the requests of a device and say nothing about its network, so a device keeps its
+// fingerprint when it moves.
+var fingerprintHeaders = []string{
+	"User-Agent",
+	"Accept-Language",
+	"Sec-CH-UA",
+	"Sec-CH-UA-Mobile",
+	"Sec-CH-UA-Platform",
+}
+
+// DeviceFingerprint returns a stable hex digest identifying the client device of r. With an
+// X-Device-ID header only that id is used; otherwise the digest covers the client headers.
+// It is a heuristic: devices with identical software share a fingerprint.
+func DeviceFingerprint(r *http.Request) string {
+	h := sha256.New()
+	if id := strings.TrimSpace(r.Header.Get(DeviceIDHeader)); id != "" {
+		h.Write([]byte("id:" + id))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	for _, name := range fingerprintHeaders {
+		h.Write([]byte(name + ":" + strings.TrimSpace(r.Header.Get(name)) + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,48 @@
+package security
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/geoip"
+)
+
+// LocationKey is the gin context key under which the location of the client is stored.
+const LocationKey = "geo_location"
+
+// geoIPLocator resolves locations with MaxMind databases.
+type geoIPLocator struct {
+	db *geoip.DB
+}
+
+// NewGeoIPLocator returns a GeoLocator backed by MaxMind databases.
+func NewGeoIPLocator(db *geoip.DB) GeoLocator {
+	return geoIPLocator{db: db}
+}
+
+// Locate returns nil for addresses the databases do not know.
+func (l geoIPLocator) Locate(ip string) (*Location, error) {
+	record, found, err := l.db.Lookup(ip)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &Location{
+		Country:      record.Country,
+		Latitude:     record.Latitude,
+		Longitude:    record.Longitude,
+		CountryOnly:  !record.HasCoordinates,
+		ASN:          record.ASN,
+		Organization: record.Organization,
+	}, nil
+}
+
+// SetLocation attaches the location of the client to the request.
+func SetLocation(c *gin.Context, loc *Location) {
+	c.Set(LocationKey, loc)
+}
+
+// LocationFromContext returns the location attached by the GeoIP middleware.
+func LocationFromContext(c *gin.Context) (*Location, bool) {
+	value, _ := c.Get(LocationKey)
+	loc, ok := value.(*Location)
+	return loc, ok && loc != nil
+}
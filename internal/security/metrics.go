@@ -0,0 +1,89 @@
+package security
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+// Reasons of failed logins.
+const (
+	LoginUnknownUser      = "unknown_user"
+	LoginWrongPassword    = "wrong_password"
+	LoginWrongCode        = "wrong_code"
+	LoginInvalidChallenge = "invalid_challenge"
+	LoginSSORejected      = "sso_rejected"
+)
+
+// Reasons of bearer token validation failures.
+const (
+	TokenMissing     = "missing"
+	TokenMalformed   = "malformed"
+	TokenInvalid     = "invalid"
+	TokenUnknownUser = "unknown_user"
+)
+
+// Limiters that reject requests.
+const (
+	LimiterIP   = "ip"
+	LimiterAuth = "auth"
+	LimiterGeo  = "geo"
+)
+
+// unmatchedRoute labels requests that matched no route, as in the HTTP metrics.
+const unmatchedRoute = "unmatched"
+
+// Authentication security counters, labeled by route template so dashboards can alert on
+// brute force against a given endpoint.
+var (
+	failedLoginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_failed_logins_total",
+		Help: "Failed login attempts by route and reason.",
+	}, []string{"route", "reason"})
+	lockoutsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_lockouts_total",
+		Help: "Login challenges discarded after too many wrong verification codes, by route.",
+	}, []string{"route"})
+	rateLimitedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_rate_limited_total",
+		Help: "Requests rejected by a rate limiter, by route and limiter.",
+	}, []string{"route", "limiter"})
+	tokenFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_failures_total",
+		Help: "Requests to authenticated routes with a missing or invalid bearer token, by route and reason.",
+	}, []string{"route", "reason"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(failedLoginsTotal, lockoutsTotal, rateLimitedTotal, tokenFailuresTotal)
+}
+
+// RecordFailedLogin counts a failed login on the route of c.
+func RecordFailedLogin(c *gin.Context, reason string) {
+	failedLoginsTotal.WithLabelValues(route(c), reason).Inc()
+}
+
+// RecordLockout counts a login challenge discarded after too many wrong codes.
+func RecordLockout(c *gin.Context) {
+	lockoutsTotal.WithLabelValues(route(c)).Inc()
+}
+
+// RecordRateLimited counts a request rejected by limiter.
+func RecordRateLimited(c *gin.Context, limiter string) {
+	rateLimitedTotal.WithLabelValues(route(c), limiter).Inc()
+}
+
+// RecordTokenFailure counts a request rejected for its bearer token.
+func RecordTokenFailure(c *gin.Context, reason string) {
+	tokenFailuresTotal.WithLabelValues(route(c), reason).Inc()
+}
+
+// route returns the route template of c, so that probing random paths cannot create
+// unbounded label values.
+func route(c *gin.Context) string {
+	if path := c.FullPath(); path != "" {
+		return path
+	}
+	return unmatchedRoute
+}
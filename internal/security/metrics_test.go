@@ -0,0 +1,39 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSecurityMetricsLabelRouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/security-test/:id/login", func(c *gin.Context) {
+		RecordFailedLogin(c, LoginWrongPassword)
+		RecordLockout(c)
+	})
+	r.NoRoute(func(c *gin.Context) { RecordTokenFailure(c, TokenMissing) })
+
+	wrongPassword := failedLoginsTotal.WithLabelValues("/security-test/:id/login", LoginWrongPassword)
+	lockouts := lockoutsTotal.WithLabelValues("/security-test/:id/login")
+	unmatched := tokenFailuresTotal.WithLabelValues(unmatchedRoute, TokenMissing)
+	beforeLogins, beforeLockouts, beforeUnmatched := testutil.ToFloat64(wrongPassword), testutil.ToFloat64(lockouts), testutil.ToFloat64(unmatched)
+
+	for _, path := range []string{"/security-test/1/login", "/security-test/2/login", "/no-such-path"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	if got := testutil.ToFloat64(wrongPassword) - beforeLogins; got != 2 {
+		t.Errorf("failed logins = %v, want 2 under the route template", got)
+	}
+	if got := testutil.ToFloat64(lockouts) - beforeLockouts; got != 2 {
+		t.Errorf("lockouts = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(unmatched) - beforeUnmatched; got != 1 {
+		t.Errorf("unmatched token failures = %v, want 1", got)
+	}
+}
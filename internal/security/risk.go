@@ -0,0 +1,134 @@
+// Package security provides request risk scoring and bot detection heuristics.
+package security
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey is the gin context key under which the request risk assessment is stored.
+const ContextKey = "risk_assessment"
+
+// MaxScore is the highest risk score a request can reach.
+const MaxScore = 100
+
+// Signal identifies a single heuristic that contributed to a risk score.
+type Signal string
+
+// Signals raised by the bot detection heuristics.
+const (
+	SignalEmptyUserAgent   Signal = "empty_user_agent"
+	SignalScriptedClient   Signal = "scripted_client"
+	SignalHeadlessBrowser  Signal = "headless_browser"
+	SignalMissingAccept    Signal = "missing_accept_header"
+	SignalHoneypot         Signal = "honeypot_field"
+	SignalImpossibleTravel Signal = "impossible_travel"
+	SignalNewDevice        Signal = "new_device"
+	SignalNewCountry       Signal = "new_country"
+)
+
+// signalWeights defines how much each signal adds to the risk score.
+var signalWeights = map[Signal]int{
+	SignalEmptyUserAgent:   40,
+	SignalScriptedClient:   30,
+	SignalHeadlessBrowser:  50,
+	SignalMissingAccept:    10,
+	SignalHoneypot:         100,
+	SignalImpossibleTravel: 60,
+	SignalNewDevice:        20,
+	SignalNewCountry:       30,
+}
+
+var (
+	// scriptedClientTokens are user-agent fragments of common HTTP libraries and CLI tools
+	scriptedClientTokens = []string{
+		"curl", "wget", "python-requests", "python-urllib", "go-http-client",
+		"java/", "okhttp", "libwww-perl", "scrapy", "httpclient", "aiohttp",
+	}
+
+	// headlessTokens are user-agent fragments of automated browsers
+	headlessTokens = []string{"headlesschrome", "phantomjs", "selenium", "puppeteer", "playwright"}
+)
+
+// Assessment holds the risk score of a request and the signals that produced it.
+type Assessment struct {
+	Score   int      `json:"score"`
+	Signals []Signal `json:"signals,omitempty"`
+}
+
+// Add records a signal and increases the score by its weight, capped at MaxScore.
+func (a *Assessment) Add(signal Signal) {
+	for _, s := range a.Signals {
+		if s == signal {
+			return
+		}
+	}
+
+	a.Signals = append(a.Signals, signal)
+	a.Score += signalWeights[signal]
+	if a.Score > MaxScore {
+		a.Score = MaxScore
+	}
+}
+
+// Has reports whether the given signal was raised.
+func (a *Assessment) Has(signal Signal) bool {
+	for _, s := range a.Signals {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// SignalNames returns the raised signals as a comma-separated string, suitable for storage and logs.
+func (a *Assessment) SignalNames() string {
+	names := make([]string, len(a.Signals))
+	for i, s := range a.Signals {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ",")
+}
+
+// AssessRequest scores a request using user-agent and header heuristics.
+func AssessRequest(r *http.Request) *Assessment {
+	assessment := &Assessment{}
+
+	userAgent := strings.ToLower(strings.TrimSpace(r.UserAgent()))
+	switch {
+	case userAgent == "":
+		assessment.Add(SignalEmptyUserAgent)
+	case containsAny(userAgent, headlessTokens):
+		assessment.Add(SignalHeadlessBrowser)
+	case containsAny(userAgent, scriptedClientTokens):
+		assessment.Add(SignalScriptedClient)
+	}
+
+	if r.Header.Get("Accept") == "" {
+		assessment.Add(SignalMissingAccept)
+	}
+
+	return assessment
+}
+
+// AssessmentFromContext returns the risk assessment attached to the request.
+// An empty assessment is returned when bot detection is disabled.
+func AssessmentFromContext(c *gin.Context) *Assessment {
+	if value, exists := c.Get(ContextKey); exists {
+		if assessment, ok := value.(*Assessment); ok {
+			return assessment
+		}
+	}
+	return &Assessment{}
+}
+
+func containsAny(s string, tokens []string) bool {
+	for _, token := range tokens {
+		if strings.Contains(s, token) {
+			return true
+		}
+	}
+	return false
+}
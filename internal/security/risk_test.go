@@ -0,0 +1,112 @@
+package security
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAssessRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		accept    string
+		wantScore int
+		wantSig   Signal
+	}{
+		{"Browser", "Mozilla/5.0 (X11; Linux x86_64) Firefox/120.0", "text/html", 0, ""},
+		{"Empty user agent", "", "*/*", 40, SignalEmptyUserAgent},
+		{"Curl", "curl/8.4.0", "*/*", 30, SignalScriptedClient},
+		{"Headless browser", "Mozilla/5.0 HeadlessChrome/120.0", "*/*", 50, SignalHeadlessBrowser},
+		{"Missing accept", "Mozilla/5.0 Firefox/120.0", "", 10, SignalMissingAccept},
+		{"Scripted without accept", "python-requests/2.31", "", 40, SignalScriptedClient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			assessment := AssessRequest(req)
+			if assessment.Score != tt.wantScore {
+				t.Errorf("Score = %d; want %d (signals: %v)", assessment.Score, tt.wantScore, assessment.Signals)
+			}
+			if tt.wantSig != "" && !assessment.Has(tt.wantSig) {
+				t.Errorf("expected signal %s, got %v", tt.wantSig, assessment.Signals)
+			}
+		})
+	}
+}
+
+func TestAssessmentScoreIsCapped(t *testing.T) {
+	assessment := &Assessment{}
+	assessment.Add(SignalHoneypot)
+	assessment.Add(SignalImpossibleTravel)
+	assessment.Add(SignalHoneypot)
+
+	if assessment.Score != MaxScore {
+		t.Errorf("Score = %d; want %d", assessment.Score, MaxScore)
+	}
+	if len(assessment.Signals) != 2 {
+		t.Errorf("expected duplicate signals to be ignored, got %v", assessment.Signals)
+	}
+}
+
+func TestIsImpossibleTravel(t *testing.T) {
+	bogota := Location{Country: "CO", Latitude: 4.711, Longitude: -74.0721}
+	medellin := Location{Country: "CO", Latitude: 6.2442, Longitude: -75.5812}
+	madrid := Location{Country: "ES", Latitude: 40.4168, Longitude: -3.7038}
+
+	tests := []struct {
+		name    string
+		from    Location
+		to      Location
+		elapsed time.Duration
+		want    bool
+	}{
+		{"Nearby city within minutes", bogota, medellin, 10 * time.Minute, false},
+		{"Other continent within an hour", bogota, madrid, time.Hour, true},
+		{"Other continent after a day", bogota, madrid, 24 * time.Hour, false},
+		{"Other continent at the same instant", bogota, madrid, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsImpossibleTravel(tt.from, tt.to, tt.elapsed); got != tt.want {
+				t.Errorf("IsImpossibleTravel() = %v; want %v (distance %.0f km)", got, tt.want, DistanceKm(tt.from, tt.to))
+			}
+		})
+	}
+}
+
+func TestDeviceFingerprint(t *testing.T) {
+	newRequest := func(headers map[string]string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/login", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return req
+	}
+
+	browser := map[string]string{"User-Agent": "Mozilla/5.0 Firefox/120.0", "Accept-Language": "es-CO"}
+	fp := DeviceFingerprint(newRequest(browser))
+	if len(fp) != 64 {
+		t.Fatalf("fingerprint %q is not a sha256 hex digest", fp)
+	}
+	if again := DeviceFingerprint(newRequest(browser)); again != fp {
+		t.Error("fingerprint is not stable across requests")
+	}
+	if other := DeviceFingerprint(newRequest(map[string]string{"User-Agent": "curl/8.4.0"})); other == fp {
+		t.Error("different clients share a fingerprint")
+	}
+
+	// An installation id takes precedence over the headers
+	app := DeviceFingerprint(newRequest(map[string]string{"User-Agent": "App/1.0", DeviceIDHeader: "abc"}))
+	updated := DeviceFingerprint(newRequest(map[string]string{"User-Agent": "App/2.0", DeviceIDHeader: "abc"}))
+	if app != updated {
+		t.Error("X-Device-ID fingerprint changed with the user agent")
+	}
+}
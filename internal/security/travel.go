@@ -0,0 +1,92 @@
+package security
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// MaxTravelSpeedKmh is the fastest plausible travel speed between two logins (roughly a commercial flight).
+const MaxTravelSpeedKmh = 1000.0
+
+// minTravelDistanceKm ignores small jumps caused by imprecise IP geolocation.
+const minTravelDistanceKm = 500.0
+
+const earthRadiusKm = 6371.0
+
+// Location is the geographic position resolved for an IP address.
+type Location struct {
+	Country   string
+	Latitude  float64
+	Longitude float64
+	// CountryOnly is set when only the country is known, so the coordinates are meaningless.
+	CountryOnly bool
+	// ASN and Organization identify the network of the address when known.
+	ASN          uint
+	Organization string
+}
+
+// GeoLocator resolves IP addresses to geographic locations.
+type GeoLocator interface {
+	Locate(ip string) (*Location, error)
+}
+
+var (
+	locator   GeoLocator
+	locatorMu sync.RWMutex
+)
+
+// SetGeoLocator configures the locator used for impossible-travel detection.
+// Passing nil disables location lookups.
+func SetGeoLocator(l GeoLocator) {
+	locatorMu.Lock()
+	defer locatorMu.Unlock()
+	locator = l
+}
+
+// Locate resolves an IP address with the configured locator.
+// It returns false when no locator is configured or the lookup fails.
+func Locate(ip string) (*Location, bool) {
+	locatorMu.RLock()
+	l := locator
+	locatorMu.RUnlock()
+
+	if l == nil {
+		return nil, false
+	}
+
+	loc, err := l.Locate(ip)
+	if err != nil || loc == nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+// IsImpossibleTravel reports whether moving between two locations in the elapsed time
+// would require travelling faster than MaxTravelSpeedKmh.
+func IsImpossibleTravel(from, to Location, elapsed time.Duration) bool {
+	distance := DistanceKm(from, to)
+	if distance < minTravelDistanceKm {
+		return false
+	}
+
+	hours := elapsed.Hours()
+	if hours <= 0 {
+		return true
+	}
+
+	return distance/hours > MaxTravelSpeedKmh
+}
+
+// DistanceKm returns the great-circle distance between two locations using the haversine formula.
+func DistanceKm(from, to Location) float64 {
+	lat1 := from.Latitude * math.Pi / 180
+	lat2 := to.Latitude * math.Pi / 180
+	dLat := (to.Latitude - from.Latitude) * math.Pi / 180
+	dLon := (to.Longitude - from.Longitude) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,243 @@
+// Package smoke runs a scripted sequence of requests against a deployed instance: readiness,
+// registration, login, an authenticated request and a request without credentials. It checks
+// that a deployment serves its main flow end to end, for post-deploy verification in
+// pipelines.
+package smoke
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxBodySize bounds the response bodies read.
+const maxBodySize = 1 << 20
+
+// Options configures a run.
+type Options struct {
+	// BaseURL is the URL of the instance, such as "https://api.example.com".
+	BaseURL string
+	// Username and Password sign in as an existing user instead of registering one, for
+	// invite-only deployments and to avoid creating a user per run.
+	Username string
+	Password string
+	// EmailDomain is the domain of the email of registered users (example.com by default).
+	EmailDomain string
+	// Client performs the requests; http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// Step is the result of a step of a run.
+type Step struct {
+	Name     string
+	Duration time.Duration
+	// Skipped is set for steps not needed by the options, such as the registration when
+	// signing in as an existing user.
+	Skipped bool
+	Err     error
+}
+
+// Run performs the steps in order. Each step needs the previous ones, so a run stops at the
+// first failure; Failed reports it.
+func Run(ctx context.Context, opts Options) []Step {
+	r := &runner{opts: opts, client: opts.Client}
+	if r.client == nil {
+		r.client = http.DefaultClient
+	}
+	r.opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	if r.opts.EmailDomain == "" {
+		r.opts.EmailDomain = "example.com"
+	}
+
+	steps := []struct {
+		name string
+		skip bool
+		run  func(ctx context.Context) error
+	}{
+		{"ready", false, r.ready},
+		{"register", opts.Username != "", r.register},
+		{"login", false, r.login},
+		{"profile", false, r.profile},
+		{"anonymous", false, r.anonymous},
+	}
+
+	results := make([]Step, 0, len(steps))
+	for _, s := range steps {
+		if s.skip {
+			results = append(results, Step{Name: s.name, Skipped: true})
+			continue
+		}
+		start := time.Now()
+		err := s.run(ctx)
+		results = append(results, Step{Name: s.name, Duration: time.Since(start), Err: err})
+		if err != nil {
+			break
+		}
+	}
+	return results
+}
+
+// Failed returns the error of the failed step of steps, or nil when every step passed.
+func Failed(steps []Step) error {
+	for _, s := range steps {
+		if s.Err != nil {
+			return fmt.Errorf("smoke step %s failed: %w", s.Name, s.Err)
+		}
+	}
+	return nil
+}
+
+type runner struct {
+	opts   Options
+	client *http.Client
+	// username and password are those of the user of the run, and token its JWT.
+	username string
+	password string
+	token    string
+}
+
+// ready checks that the instance is ready to serve traffic.
+func (r *runner) ready(ctx context.Context) error {
+	_, err := r.do(ctx, http.MethodGet, "/health/ready", nil, "", http.StatusOK)
+	return err
+}
+
+// register creates a user with a random name and a password that meets the default policy.
+func (r *runner) register(ctx context.Context) error {
+	suffix, err := randomHex(6)
+	if err != nil {
+		return err
+	}
+	secret, err := randomHex(12)
+	if err != nil {
+		return err
+	}
+	r.username = "smoke_" + suffix
+	r.password = "Sm0ke!" + secret
+	_, err = r.do(ctx, http.MethodPost, "/api/auth/register", map[string]interface{}{
+		"username":     r.username,
+		"email":        r.username + "@" + r.opts.EmailDomain,
+		"password":     r.password,
+		"accept_terms": true,
+	}, "", http.StatusCreated)
+	return err
+}
+
+// login signs in and keeps the token.
+func (r *runner) login(ctx context.Context) error {
+	if r.opts.Username != "" {
+		r.username, r.password = r.opts.Username, r.opts.Password
+	}
+	body, err := r.do(ctx, http.MethodPost, "/api/auth/login", map[string]string{
+		"username": r.username,
+		"password": r.password,
+	}, "", http.StatusOK)
+	if err != nil {
+		return err
+	}
+	var envelope struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Data.Token == "" {
+		return errors.New("the login response has no token")
+	}
+	r.token = envelope.Data.Token
+	return nil
+}
+
+// profile calls a protected endpoint with the token.
+func (r *runner) profile(ctx context.Context) error {
+	body, err := r.do(ctx, http.MethodGet, "/api/users/me", nil, r.token, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	var envelope struct {
+		Data struct {
+			Username string `json:"username"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("invalid profile response: %w", err)
+	}
+	if !strings.EqualFold(envelope.Data.Username, r.username) {
+		return fmt.Errorf("the profile is of %q, want %q", envelope.Data.Username, r.username)
+	}
+	return nil
+}
+
+// anonymous checks that the protected endpoint rejects requests without a token. The API has
+// no logout endpoint, as tokens are stateless and expire on their own.
+func (r *runner) anonymous(ctx context.Context) error {
+	_, err := r.do(ctx, http.MethodGet, "/api/users/me", nil, "", http.StatusUnauthorized)
+	return err
+}
+
+// do sends a request with payload encoded as JSON and token as bearer token, when set, and
+// returns the body of the response, unless its status is not want.
+func (r *runner) do(ctx context.Context, method, path string, payload interface{}, token string, want int) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.opts.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != want {
+		return nil, fmt.Errorf("%s %s: status %d, want %d%s", method, path, resp.StatusCode, want, errorMessage(body))
+	}
+	return body, nil
+}
+
+// errorMessage returns the error of an error envelope, to explain an unexpected status.
+func errorMessage(body []byte) string {
+	var envelope struct {
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) != nil || envelope.Error == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (%s: %s)", envelope.Error.Code, envelope.Error.Message)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
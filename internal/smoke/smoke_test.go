@@ -0,0 +1,94 @@
+package smoke_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/routes"
+	"github.com/yeferson59/gin-template/internal/smoke"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+)
+
+func newServer(t *testing.T) (*httptest.Server, *testutil.App) {
+	t.Helper()
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		routes.RegisterAPIRoutes(a.Router, a.DB, a.Config, routes.Services{})
+	})
+	srv := httptest.NewServer(app.Router)
+	t.Cleanup(srv.Close)
+	return srv, app
+}
+
+func TestRun_Passes(t *testing.T) {
+	srv, _ := newServer(t)
+
+	steps := smoke.Run(context.Background(), smoke.Options{BaseURL: srv.URL + "/", Client: srv.Client()})
+	if err := smoke.Failed(steps); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range steps {
+		if s.Skipped {
+			t.Errorf("step %s was skipped", s.Name)
+		}
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "ready,register,login,profile,anonymous" {
+		t.Errorf("steps = %s", got)
+	}
+}
+
+func TestRun_ExistingUser(t *testing.T) {
+	srv, app := newServer(t)
+	user := testutil.CreateUser(t, app.DB)
+
+	steps := smoke.Run(context.Background(), smoke.Options{
+		BaseURL:  srv.URL,
+		Username: user.Username,
+		Password: testutil.DefaultPassword,
+		Client:   srv.Client(),
+	})
+	if err := smoke.Failed(steps); err != nil {
+		t.Fatal(err)
+	}
+	if !steps[1].Skipped {
+		t.Errorf("register step = %+v, want it skipped", steps[1])
+	}
+	var count int64
+	app.DB.Table("users").Count(&count)
+	if count != 1 {
+		t.Errorf("%d users after the run, want no new user", count)
+	}
+}
+
+func TestRun_StopsAtFirstFailure(t *testing.T) {
+	srv, app := newServer(t)
+	user := testutil.CreateUser(t, app.DB)
+
+	steps := smoke.Run(context.Background(), smoke.Options{
+		BaseURL:  srv.URL,
+		Username: user.Username,
+		Password: "wrong-password",
+		Client:   srv.Client(),
+	})
+	err := smoke.Failed(steps)
+	if err == nil || !strings.Contains(err.Error(), "login") || !strings.Contains(err.Error(), "status 401") {
+		t.Fatalf("Failed() = %v, want the login step to fail with 401", err)
+	}
+	if last := steps[len(steps)-1]; last.Name != "login" {
+		t.Errorf("the run continued after the failure, up to %s", last.Name)
+	}
+}
+
+func TestRun_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	steps := smoke.Run(context.Background(), smoke.Options{BaseURL: srv.URL})
+	if len(steps) != 1 || steps[0].Err == nil {
+		t.Fatalf("steps = %+v, want the ready step to fail", steps)
+	}
+}
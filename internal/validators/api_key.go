@@ -0,0 +1,31 @@
+package validators
+
+import "unicode/utf8"
+
+// APIKeyRequest represents the structure of API key creation requests.
+type APIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// maxAPIKeyName is the maximum length of API key names.
+const maxAPIKeyName = 100
+
+// Normalize converts the request fields to NFC and trims them.
+func (r *APIKeyRequest) Normalize() {
+	r.Name = Normalize(r.Name)
+}
+
+// ValidateAPIKey validates API key data.
+// All invalid fields are reported together as ValidationErrors.
+func ValidateAPIKey(req *APIKeyRequest) error {
+	var errs ValidationErrors
+	switch {
+	case req.Name == "":
+		errs.Add("name", newFieldError("name", CodeRequired, "name is required"))
+	case utf8.RuneCountInString(req.Name) > maxAPIKeyName:
+		errs.Add("name", newFieldError("name", CodeTooLong, "name must be no more than 100 characters long"))
+	case hasInvisibleCharacters(req.Name):
+		errs.Add("name", newFieldError("name", CodeInvalidCharacters, "name contains invisible characters"))
+	}
+	return errs.Err()
+}
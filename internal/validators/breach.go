@@ -0,0 +1,52 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// BreachChecker reports how many times a password appears in known data breaches.
+type BreachChecker interface {
+	Count(ctx context.Context, password string) (int, error)
+}
+
+var (
+	breachChecker   BreachChecker
+	breachCheckerMu sync.RWMutex
+)
+
+// SetBreachChecker enables breach checking in ValidatePassword. Passing nil disables it.
+func SetBreachChecker(checker BreachChecker) {
+	breachCheckerMu.Lock()
+	defer breachCheckerMu.Unlock()
+	breachChecker = checker
+}
+
+// checkPasswordBreach rejects passwords found in known breaches. The lookup is canceled with
+// ctx, so a client that goes away does not keep it waiting. Lookup failures are logged and
+// the password is accepted, so an outage of the breach service never blocks registrations.
+func checkPasswordBreach(ctx context.Context, password string) error {
+	breachCheckerMu.RLock()
+	checker := breachChecker
+	breachCheckerMu.RUnlock()
+
+	if checker == nil {
+		return nil
+	}
+
+	count, err := checker.Count(ctx, password)
+	if err != nil {
+		logger.WithField("error", err.Error()).Warn("Password breach check failed, skipping")
+		return nil
+	}
+
+	if count > 0 {
+		return newFieldError("password", CodeBreached,
+			fmt.Sprintf("password has appeared in %d known data breaches, please choose a different password", count))
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package validators
+
+import (
+	"strings"
+
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+// ConsentRequest represents the structure of consent records. The kind defaults to terms.
+type ConsentRequest struct {
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+}
+
+// Normalize converts the request fields to NFC, trims them and lowercases the kind.
+func (r *ConsentRequest) Normalize() {
+	r.Kind = strings.ToLower(Normalize(r.Kind))
+	r.Version = Normalize(r.Version)
+}
+
+// ValidateConsent validates a consent to the current version of a document: only that version
+// can be accepted. All invalid fields are reported together as ValidationErrors.
+func ValidateConsent(req *ConsentRequest, current string) error {
+	var errs ValidationErrors
+	if req.Kind != models.ConsentTerms {
+		errs.Add("kind", newFieldError("kind", CodeInvalidValue, "kind must be terms"))
+	}
+	switch {
+	case req.Version == "":
+		errs.Add("version", newFieldError("version", CodeRequired, "version is required"))
+	case req.Version != current:
+		errs.Add("version", newFieldError("version", CodeInvalidValue, "version must be the current version of the terms"))
+	}
+	return errs.Err()
+}
@@ -0,0 +1,101 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// Validation error codes returned to clients alongside each invalid field.
+const (
+	CodeRequired          = "required"
+	CodeTooShort          = "too_short"
+	CodeTooLong           = "too_long"
+	CodeInvalidFormat     = "invalid_format"
+	CodeInvalidCharacters = "invalid_characters"
+	CodeConfusable        = "confusable"
+	CodeMissingUppercase  = "missing_uppercase"
+	CodeMissingLowercase  = "missing_lowercase"
+	CodeMissingNumber     = "missing_number"
+	CodeMissingSpecial    = "missing_special"
+	CodeTooManyRepeats    = "too_many_repeats"
+	CodeDictionaryWord    = "dictionary_word"
+	CodeContainsUserInfo  = "contains_user_info"
+	CodeBreached          = "breached"
+	CodeInvalidValue      = "invalid_value"
+)
+
+// FieldError describes why a single field failed validation.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// Error returns the human readable message.
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func newFieldError(field, code, message string) error {
+	return &FieldError{Field: field, Code: code, Message: message}
+}
+
+// ValidationErrors collects every field that failed validation in a request.
+type ValidationErrors []*FieldError
+
+// Error joins all field messages.
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add appends err to the collection. Plain errors are attributed to field with an invalid_format code.
+func (e *ValidationErrors) Add(field string, err error) {
+	if err == nil {
+		return
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		fieldErr = &FieldError{Field: field, Code: CodeInvalidFormat, Message: err.Error()}
+	}
+	*e = append(*e, fieldErr)
+}
+
+// Err returns the collection as an error, or nil when it is empty.
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ResponseFields converts the errors to the API response representation.
+func (e ValidationErrors) ResponseFields() []response.FieldError {
+	fields := make([]response.FieldError, len(e))
+	for i, fieldErr := range e {
+		fields[i] = response.FieldError{
+			Field:   fieldErr.Field,
+			Code:    fieldErr.Code,
+			Message: fieldErr.Message,
+		}
+	}
+	return fields
+}
+
+// FieldErrorsOf extracts the field errors from err, wrapping plain errors as a single entry.
+func FieldErrorsOf(err error) []response.FieldError {
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return validationErrs.ResponseFields()
+	}
+
+	var errs ValidationErrors
+	errs.Add("", err)
+	return errs.ResponseFields()
+}
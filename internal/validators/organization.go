@@ -0,0 +1,124 @@
+package validators
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+// OrganizationRequest represents the structure of organization creation requests.
+// The slug is derived from the name when empty.
+type OrganizationRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// InvitationRequest represents the structure of organization invitation requests.
+// The role defaults to member.
+type InvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// MemberUpdate represents the structure of membership role changes.
+type MemberUpdate struct {
+	Role string `json:"role"`
+}
+
+// slugRegex allows lowercase ASCII letters and digits separated by single hyphens, so slugs
+// never look like numeric organization IDs in URLs.
+var slugRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+const maxOrgNameLength = 100
+
+// Slug length limits.
+const (
+	minSlugLength = 3
+	maxSlugLength = 50
+)
+
+// Normalize converts the request fields to NFC, trims them and lowercases the slug.
+func (r *OrganizationRequest) Normalize() {
+	r.Name = Normalize(r.Name)
+	r.Slug = strings.ToLower(Normalize(r.Slug))
+}
+
+// Normalize converts the request fields to NFC and trims them.
+func (r *InvitationRequest) Normalize() {
+	r.Email = Normalize(r.Email)
+	r.Role = strings.ToLower(Normalize(r.Role))
+}
+
+// ValidateOrganization validates organization data.
+// All invalid fields are reported together as ValidationErrors.
+func ValidateOrganization(req *OrganizationRequest) error {
+	var errs ValidationErrors
+	switch length := utf8.RuneCountInString(req.Name); {
+	case length == 0:
+		errs.Add("name", newFieldError("name", CodeRequired, "name is required"))
+	case length > maxOrgNameLength:
+		errs.Add("name", newFieldError("name", CodeTooLong, "name must be no more than 100 characters long"))
+	case hasInvisibleCharacters(req.Name):
+		errs.Add("name", newFieldError("name", CodeInvalidCharacters, "name contains invisible characters"))
+	}
+	errs.Add("slug", ValidateSlug(req.Slug))
+	return errs.Err()
+}
+
+// ValidateSlug validates an organization slug.
+func ValidateSlug(slug string) error {
+	switch {
+	case slug == "":
+		return newFieldError("slug", CodeRequired, "slug is required")
+	case len(slug) < minSlugLength:
+		return newFieldError("slug", CodeTooShort, "slug must be at least 3 characters long")
+	case len(slug) > maxSlugLength:
+		return newFieldError("slug", CodeTooLong, "slug must be no more than 50 characters long")
+	case !slugRegex.MatchString(slug) || strings.Trim(slug, "0123456789") == "":
+		return newFieldError("slug", CodeInvalidFormat, "slug can only contain lowercase letters, numbers, and single hyphens, and must not be a number")
+	}
+	return nil
+}
+
+// ValidateInvitation validates invitation data.
+// All invalid fields are reported together as ValidationErrors.
+func ValidateInvitation(req *InvitationRequest) error {
+	var errs ValidationErrors
+	errs.Add("email", ValidateEmail(req.Email))
+	errs.Add("role", ValidateOrgRole(req.Role))
+	return errs.Err()
+}
+
+// ValidateOrgRole validates a role within an organization.
+func ValidateOrgRole(role string) error {
+	if !models.IsValidOrgRole(role) {
+		return newFieldError("role", CodeInvalidFormat, "role must be owner, admin, or member")
+	}
+	return nil
+}
+
+// Slugify derives a slug from an organization name: ASCII letters and digits are kept,
+// lowercased, and everything else becomes a single hyphen. The result may still be invalid,
+// for example too short for a name in another script.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
@@ -0,0 +1,41 @@
+package validators
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Acme Corp", "acme-corp"},
+		{"  Acme -- Corp!! ", "acme-corp"},
+		{"Café Ñandú 2024", "caf-and-2024"},
+		{"東京", ""},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.name); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSlug(t *testing.T) {
+	tests := []struct {
+		slug    string
+		wantErr bool
+	}{
+		{"acme", false},
+		{"acme-corp-2", false},
+		{"", true},
+		{"ab", true},
+		{"123", true},
+		{"Acme", true},
+		{"acme--corp", true},
+		{"-acme", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateSlug(tt.slug); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateSlug(%q) error = %v, wantErr %v", tt.slug, err, tt.wantErr)
+		}
+	}
+}
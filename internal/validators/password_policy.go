@@ -0,0 +1,156 @@
+package validators
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minIdentifierLength is the shortest username/email fragment checked against passwords,
+// so very short identifiers don't reject unrelated passwords.
+const minIdentifierLength = 3
+
+// PasswordPolicy describes the complexity rules a password must satisfy.
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	RequireUpper     bool
+	RequireLower     bool
+	RequireNumber    bool
+	RequireSpecial   bool
+	MaxRepeats       int      // maximum consecutive identical characters, 0 disables the check
+	DictionaryWords  []string // words that may not appear in the password (case-insensitive)
+	DisallowUserInfo bool     // reject passwords containing the username or email local part
+}
+
+// DefaultPasswordPolicy returns the built-in policy used when no configuration is provided.
+// It matches the defaults of the PASSWORD_* settings.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:        8,
+		MaxLength:        128,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireNumber:    true,
+		RequireSpecial:   true,
+		DisallowUserInfo: true,
+	}
+}
+
+var (
+	passwordPolicy   = DefaultPasswordPolicy()
+	passwordPolicyMu sync.RWMutex
+)
+
+// SetPasswordPolicy replaces the policy used by ValidatePassword.
+func SetPasswordPolicy(policy PasswordPolicy) {
+	passwordPolicyMu.Lock()
+	defer passwordPolicyMu.Unlock()
+	passwordPolicy = policy
+}
+
+// CurrentPasswordPolicy returns the policy used by ValidatePassword.
+func CurrentPasswordPolicy() PasswordPolicy {
+	passwordPolicyMu.RLock()
+	defer passwordPolicyMu.RUnlock()
+	return passwordPolicy
+}
+
+// Validate checks a password against the policy. The optional identifiers (username, email)
+// are rejected as substrings when DisallowUserInfo is enabled.
+func (p PasswordPolicy) Validate(password string, identifiers ...string) error {
+	if password == "" {
+		return newFieldError("password", CodeRequired, "password is required")
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return newFieldError("password", CodeTooShort, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return newFieldError("password", CodeTooLong, "password is too long")
+	}
+
+	var (
+		hasUpper   = false
+		hasLower   = false
+		hasNumber  = false
+		hasSpecial = false
+	)
+
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsNumber(char):
+			hasNumber = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSpecial = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		return newFieldError("password", CodeMissingUppercase, "password must contain at least one uppercase letter")
+	}
+
+	if p.RequireLower && !hasLower {
+		return newFieldError("password", CodeMissingLowercase, "password must contain at least one lowercase letter")
+	}
+
+	if p.RequireNumber && !hasNumber {
+		return newFieldError("password", CodeMissingNumber, "password must contain at least one number")
+	}
+
+	if p.RequireSpecial && !hasSpecial {
+		return newFieldError("password", CodeMissingSpecial, "password must contain at least one special character")
+	}
+
+	if p.MaxRepeats > 0 && maxConsecutiveRepeats(password) > p.MaxRepeats {
+		return newFieldError("password", CodeTooManyRepeats, fmt.Sprintf("password must not repeat the same character more than %d times in a row", p.MaxRepeats))
+	}
+
+	lowered := strings.ToLower(password)
+	for _, word := range p.DictionaryWords {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(lowered, word) {
+			return newFieldError("password", CodeDictionaryWord, "password must not contain common dictionary words")
+		}
+	}
+
+	if p.DisallowUserInfo {
+		for _, identifier := range identifiers {
+			identifier = strings.ToLower(strings.TrimSpace(identifier))
+			if local, _, found := strings.Cut(identifier, "@"); found {
+				identifier = local
+			}
+			if utf8.RuneCountInString(identifier) >= minIdentifierLength && strings.Contains(lowered, identifier) {
+				return newFieldError("password", CodeContainsUserInfo, "password must not contain your username or email")
+			}
+		}
+	}
+
+	return nil
+}
+
+// maxConsecutiveRepeats returns the longest run of the same character in s.
+func maxConsecutiveRepeats(s string) int {
+	longest, current := 0, 0
+	var previous rune
+	for i, char := range s {
+		if i > 0 && char == previous {
+			current++
+		} else {
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+		previous = char
+	}
+	return longest
+}
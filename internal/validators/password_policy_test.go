@@ -0,0 +1,57 @@
+package validators
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:        10,
+		MaxLength:        64,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireNumber:    true,
+		MaxRepeats:       2,
+		DictionaryWords:  []string{"qwerty", "dragon"},
+		DisallowUserInfo: true,
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"Valid without special chars", "Tr0ubadorHorse", false},
+		{"Too short for policy", "Short1Aa", true},
+		{"Too many repeats", "Baaad1Horse", true},
+		{"Dictionary word", "MyQwerty123X", true},
+		{"Contains username", "JohnDoe2024X", true},
+		{"Contains email local part", "JDoeMail2024", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, "johndoe", "jdoemail@example.com")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetPasswordPolicy(t *testing.T) {
+	SetPasswordPolicy(PasswordPolicy{MinLength: 4})
+	defer SetPasswordPolicy(DefaultPasswordPolicy())
+
+	if err := ValidatePassword(context.Background(), "simple"); err != nil {
+		t.Errorf("expected relaxed policy to accept password, got %v", err)
+	}
+}
+
+func TestDefaultPasswordPolicyDisallowsUserInfo(t *testing.T) {
+	// PASSWORD_DISALLOW_USER_INFO defaults to true, and so does the built-in policy
+	if err := DefaultPasswordPolicy().Validate("Johndoe#2024", "johndoe", "jd@example.com"); err == nil {
+		t.Error("expected the default policy to reject a password containing the username")
+	}
+}
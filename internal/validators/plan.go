@@ -0,0 +1,113 @@
+package validators
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PlanRequest represents the structure of plan create or replace requests. The entitlements
+// replace those of the plan.
+type PlanRequest struct {
+	DisplayName  string               `json:"display_name"`
+	Entitlements []EntitlementRequest `json:"entitlements"`
+}
+
+// EntitlementRequest is an entitlement of a plan: a feature when Limit is null, or a limit.
+type EntitlementRequest struct {
+	Key   string `json:"key"`
+	Limit *int64 `json:"limit"`
+}
+
+// UserPlanRequest represents the structure of plan assignment requests.
+type UserPlanRequest struct {
+	Plan      string     `json:"plan"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+var (
+	// planNameRegex allows lowercase ASCII letters and digits separated by single hyphens or
+	// underscores, as in Stripe lookup keys.
+	planNameRegex = regexp.MustCompile(`^[a-z0-9]+([_-][a-z0-9]+)*$`)
+	// entitlementKeyRegex also allows dots, to namespace keys such as "feature.export".
+	entitlementKeyRegex = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*$`)
+)
+
+// Plan field limits.
+const (
+	maxPlanNameLength       = 50
+	maxPlanDisplayName      = 100
+	maxEntitlementKeyLength = 100
+	maxPlanEntitlements     = 100
+)
+
+// Normalize converts the request fields to NFC and trims them.
+func (r *PlanRequest) Normalize() {
+	r.DisplayName = Normalize(r.DisplayName)
+	for i := range r.Entitlements {
+		r.Entitlements[i].Key = strings.ToLower(Normalize(r.Entitlements[i].Key))
+	}
+}
+
+// Normalize converts the request fields to NFC, trims them and lowercases the plan.
+func (r *UserPlanRequest) Normalize() {
+	r.Plan = strings.ToLower(Normalize(r.Plan))
+}
+
+// ValidatePlanName validates the name of a plan, reported as field.
+func ValidatePlanName(field, name string) error {
+	switch {
+	case name == "":
+		return newFieldError(field, CodeRequired, field+" is required")
+	case len(name) > maxPlanNameLength:
+		return newFieldError(field, CodeTooLong, field+" must be no more than 50 characters long")
+	case !planNameRegex.MatchString(name):
+		return newFieldError(field, CodeInvalidFormat, field+" can only contain lowercase letters, numbers, and single hyphens or underscores")
+	}
+	return nil
+}
+
+// ValidatePlan validates plan data.
+// All invalid fields are reported together as ValidationErrors.
+func ValidatePlan(req *PlanRequest) error {
+	var errs ValidationErrors
+	switch {
+	case utf8.RuneCountInString(req.DisplayName) > maxPlanDisplayName:
+		errs.Add("display_name", newFieldError("display_name", CodeTooLong, "display_name must be no more than 100 characters long"))
+	case hasInvisibleCharacters(req.DisplayName):
+		errs.Add("display_name", newFieldError("display_name", CodeInvalidCharacters, "display_name contains invisible characters"))
+	}
+	if len(req.Entitlements) > maxPlanEntitlements {
+		errs.Add("entitlements", newFieldError("entitlements", CodeTooLong, "a plan can have at most 100 entitlements"))
+		return errs.Err()
+	}
+
+	seen := make(map[string]bool, len(req.Entitlements))
+	for _, e := range req.Entitlements {
+		switch {
+		case e.Key == "":
+			errs.Add("entitlements", newFieldError("entitlements", CodeRequired, "every entitlement needs a key"))
+		case len(e.Key) > maxEntitlementKeyLength || !entitlementKeyRegex.MatchString(e.Key):
+			errs.Add("entitlements", newFieldError("entitlements", CodeInvalidFormat,
+				"entitlement key "+e.Key+" can only contain lowercase letters, numbers, and single dots, hyphens or underscores"))
+		case seen[e.Key]:
+			errs.Add("entitlements", newFieldError("entitlements", CodeInvalidValue, "entitlement "+e.Key+" is listed twice"))
+		case e.Limit != nil && *e.Limit < 0:
+			errs.Add("entitlements", newFieldError("entitlements", CodeInvalidValue, "the limit of "+e.Key+" must not be negative"))
+		}
+		seen[e.Key] = true
+	}
+	return errs.Err()
+}
+
+// ValidateUserPlan validates plan assignment data.
+// All invalid fields are reported together as ValidationErrors.
+func ValidateUserPlan(req *UserPlanRequest) error {
+	var errs ValidationErrors
+	errs.Add("plan", ValidatePlanName("plan", req.Plan))
+	if req.ExpiresAt != nil && time.Until(*req.ExpiresAt) <= 0 {
+		errs.Add("expires_at", newFieldError("expires_at", CodeInvalidValue, "expires_at must be in the future"))
+	}
+	return errs.Err()
+}
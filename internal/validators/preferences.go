@@ -0,0 +1,51 @@
+package validators
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/yeferson59/gin-template/internal/preferences"
+)
+
+// PreferencesDocument is a preferences document as sent by clients: settings by category.
+type PreferencesDocument map[string]map[string]json.RawMessage
+
+// ValidatePreferences checks a preferences document against preferences.Schema and returns
+// its typed values, with settings missing from the document at their default. Fields are
+// named category.setting. All invalid fields are reported together as ValidationErrors.
+func ValidatePreferences(doc PreferencesDocument) (preferences.Values, error) {
+	var errs ValidationErrors
+	values := preferences.Defaults()
+
+	categories := make([]string, 0, len(doc))
+	for category := range doc {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		if _, ok := preferences.Schema[category]; !ok {
+			errs.Add(category, newFieldError(category, CodeInvalidValue, "unknown preference category "+category))
+			continue
+		}
+		names := make([]string, 0, len(doc[category]))
+		for name := range doc[category] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			field := preferences.Key(category, name)
+			setting, ok := preferences.Lookup(category, name)
+			if !ok {
+				errs.Add(field, newFieldError(field, CodeInvalidValue, "unknown preference "+field))
+				continue
+			}
+			v, err := setting.Decode(doc[category][name])
+			if err != nil {
+				errs.Add(field, newFieldError(field, CodeInvalidValue, field+" "+err.Error()))
+				continue
+			}
+			values[category][name] = v
+		}
+	}
+	return values, errs.Err()
+}
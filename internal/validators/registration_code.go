@@ -0,0 +1,47 @@
+package validators
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// RegistrationCodeRequest represents the structure of registration code creation requests.
+// MaxUses defaults to 1 and ExpiresAt to the configured code lifetime.
+type RegistrationCodeRequest struct {
+	Note      string     `json:"note"`
+	MaxUses   int        `json:"max_uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Registration code limits.
+const (
+	maxRegistrationCodeUses     = 10000
+	maxRegistrationCodeNote     = 200
+	maxRegistrationCodeLifetime = 365 * 24 * time.Hour
+)
+
+// Normalize converts the request fields to NFC and trims them.
+func (r *RegistrationCodeRequest) Normalize() {
+	r.Note = Normalize(r.Note)
+}
+
+// ValidateRegistrationCode validates registration code data.
+// All invalid fields are reported together as ValidationErrors.
+func ValidateRegistrationCode(req *RegistrationCodeRequest) error {
+	var errs ValidationErrors
+	switch {
+	case utf8.RuneCountInString(req.Note) > maxRegistrationCodeNote:
+		errs.Add("note", newFieldError("note", CodeTooLong, "note must be no more than 200 characters long"))
+	case hasInvisibleCharacters(req.Note):
+		errs.Add("note", newFieldError("note", CodeInvalidCharacters, "note contains invisible characters"))
+	}
+	if req.MaxUses < 1 || req.MaxUses > maxRegistrationCodeUses {
+		errs.Add("max_uses", newFieldError("max_uses", CodeInvalidValue, "max_uses must be between 1 and 10000"))
+	}
+	if req.ExpiresAt != nil {
+		if until := time.Until(*req.ExpiresAt); until <= 0 || until > maxRegistrationCodeLifetime {
+			errs.Add("expires_at", newFieldError("expires_at", CodeInvalidValue, "expires_at must be in the future and within a year"))
+		}
+	}
+	return errs.Err()
+}
@@ -0,0 +1,128 @@
+package validators
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize trims surrounding whitespace and converts s to Unicode NFC, so visually
+// identical input (precomposed vs. combining characters) is stored and compared the same way.
+func Normalize(s string) string {
+	return norm.NFC.String(strings.TrimSpace(s))
+}
+
+// NormalizePassword converts a password to NFC without trimming, since whitespace is significant.
+func NormalizePassword(s string) string {
+	return norm.NFC.String(s)
+}
+
+// scripts lists the writing systems recognised when checking usernames for mixed scripts.
+var scripts = map[string]*unicode.RangeTable{
+	"Latin":    unicode.Latin,
+	"Cyrillic": unicode.Cyrillic,
+	"Greek":    unicode.Greek,
+	"Armenian": unicode.Armenian,
+	"Hebrew":   unicode.Hebrew,
+	"Arabic":   unicode.Arabic,
+	"Han":      unicode.Han,
+	"Hiragana": unicode.Hiragana,
+	"Katakana": unicode.Katakana,
+	"Hangul":   unicode.Hangul,
+	"Thai":     unicode.Thai,
+	"Cherokee": unicode.Cherokee,
+}
+
+// compatibleScripts are combinations that legitimately appear together (e.g. Japanese).
+var compatibleScripts = map[string]string{
+	"Hiragana": "Japanese",
+	"Katakana": "Japanese",
+	"Han":      "Japanese",
+}
+
+// latinConfusables maps non-Latin letters to the ASCII letter they are commonly mistaken for.
+var latinConfusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's', 'і': 'i', 'ј': 'j', 'ԁ': 'd',
+	'ԛ': 'q', 'ԝ': 'w', 'ү': 'y', 'һ': 'h', 'ӏ': 'l',
+	'А': 'a', 'В': 'b', 'Е': 'e', 'К': 'k', 'М': 'm', 'Н': 'h', 'О': 'o', 'Р': 'p',
+	'С': 'c', 'Т': 't', 'У': 'y', 'Х': 'x', 'Ѕ': 's', 'І': 'i', 'Ј': 'j',
+	// Greek
+	'α': 'a', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'ν': 'v', 'ι': 'i', 'κ': 'k',
+	'Α': 'a', 'Β': 'b', 'Ε': 'e', 'Ζ': 'z', 'Η': 'h', 'Ι': 'i', 'Κ': 'k', 'Μ': 'm',
+	'Ν': 'n', 'Ο': 'o', 'Ρ': 'p', 'Τ': 't', 'Υ': 'y', 'Χ': 'x',
+}
+
+// scriptOf returns the script name of a letter, or "" for digits, symbols and unknown scripts.
+func scriptOf(r rune) string {
+	if !unicode.IsLetter(r) {
+		return ""
+	}
+	for name, table := range scripts {
+		if unicode.Is(table, r) {
+			if group, ok := compatibleScripts[name]; ok {
+				return group
+			}
+			return name
+		}
+	}
+	return ""
+}
+
+// isMixedScript reports whether s contains letters from more than one script.
+func isMixedScript(s string) bool {
+	seen := ""
+	for _, r := range s {
+		script := scriptOf(r)
+		if script == "" {
+			continue
+		}
+		if seen != "" && script != seen {
+			return true
+		}
+		seen = script
+	}
+	return false
+}
+
+// isWholeScriptConfusable reports whether every letter of s is a non-Latin lookalike of a
+// Latin letter, e.g. "раураl" written entirely in Cyrillic to impersonate "paypal".
+func isWholeScriptConfusable(s string) bool {
+	letters := 0
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if _, ok := latinConfusables[r]; !ok {
+			return false
+		}
+	}
+	return letters > 0
+}
+
+// hasInvisibleCharacters reports whether s contains format or control characters
+// (zero-width joiners, bidi overrides, etc.) that render invisibly.
+func hasInvisibleCharacters(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cf, r) || unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Skeleton maps a username to a canonical form where confusable letters are replaced by
+// their Latin lookalike and case is folded, so "Pаypal" and "paypal" share a skeleton.
+func Skeleton(s string) string {
+	var b strings.Builder
+	for _, r := range Normalize(s) {
+		if latin, ok := latinConfusables[r]; ok {
+			r = latin
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -2,154 +2,259 @@
 package validators
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+
+	"github.com/yeferson59/gin-template/internal/models"
 )
 
 // AuthRequest represents the structure for user authentication requests.
+// Required fields are checked by the validators so every missing field is reported at once.
+// InvitationCode is the registration code required in invite-only mode, and AcceptTerms
+// accepts the current terms of service when the service has them.
 type AuthRequest struct {
-	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Username       string `json:"username"`
+	Email          string `json:"email"`
+	Password       string `json:"password"`
+	InvitationCode string `json:"invitation_code,omitempty"`
+	AcceptTerms    bool   `json:"accept_terms,omitempty"`
 }
 
 // LoginRequest represents the structure for user login requests.
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ProfileUpdate is the part of a user profile the user can change, as patched by
+// PATCH /api/users/me.
+type ProfileUpdate struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// EmailChangeRequest is the body of PUT /api/users/me/email: the new address and the current
+// password.
+type EmailChangeRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Normalize converts the profile fields to NFC and trims them.
+func (r *ProfileUpdate) Normalize() {
+	r.Username = Normalize(r.Username)
+	r.Email = Normalize(r.Email)
+}
+
+// Normalize converts the request fields to NFC and trims the username and email.
+func (r *AuthRequest) Normalize() {
+	r.Username = Normalize(r.Username)
+	r.Email = Normalize(r.Email)
+	r.Password = NormalizePassword(r.Password)
+	r.InvitationCode = strings.TrimSpace(r.InvitationCode)
+}
+
+// Normalize converts the request fields to NFC and trims the username.
+func (r *LoginRequest) Normalize() {
+	r.Username = Normalize(r.Username)
+	r.Password = NormalizePassword(r.Password)
 }
 
 var (
-	// emailRegex is a simple email validation regex
-	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+	// emailDomainRegex validates the ASCII (punycode) form of an email domain
+	emailDomainRegex = regexp.MustCompile(`^(?i)([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+([a-z]{2,}|xn--[a-z0-9-]+)$`)
 
-	// usernameRegex allows alphanumeric characters, underscores, and hyphens
-	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	// usernameRegex allows letters and numbers of any script, underscores, and hyphens
+	usernameRegex = regexp.MustCompile(`^[\p{L}\p{M}\p{N}_-]+$`)
 )
 
-// ValidateUserRegistration validates user registration data.
-func ValidateUserRegistration(req *AuthRequest) error {
-	if err := ValidateUsername(req.Username); err != nil {
-		return err
-	}
+const (
+	maxEmailLength      = 254
+	maxEmailLocalLength = 64
+)
 
-	if err := ValidateEmail(req.Email); err != nil {
-		return err
+// ValidateTermsAcceptance validates that the current terms of service are accepted.
+func ValidateTermsAcceptance(accepted bool) error {
+	if !accepted {
+		return newFieldError("accept_terms", CodeRequired, "the terms of service must be accepted")
 	}
+	return nil
+}
 
-	if err := ValidatePassword(req.Password); err != nil {
-		return err
-	}
+// ValidateUserRegistration validates user registration data; ctx bounds the breach check of
+// the password. All invalid fields are reported together as ValidationErrors.
+func ValidateUserRegistration(ctx context.Context, req *AuthRequest) error {
+	var errs ValidationErrors
+	errs.Add("username", ValidateUsername(req.Username))
+	errs.Add("email", ValidateEmail(req.Email))
+	errs.Add("password", ValidatePasswordFor(ctx, req.Password, req.Username, req.Email))
+	return errs.Err()
+}
 
-	return nil
+// ValidateProfileUpdate validates a patched profile.
+// All invalid fields are reported together as ValidationErrors.
+func ValidateProfileUpdate(req *ProfileUpdate) error {
+	var errs ValidationErrors
+	errs.Add("username", ValidateUsername(req.Username))
+	errs.Add("email", ValidateEmail(req.Email))
+	return errs.Err()
 }
 
-// ValidateUserLogin validates user login data.
-func ValidateUserLogin(req *LoginRequest) error {
-	if err := ValidateUsername(req.Username); err != nil {
-		return err
-	}
+// Normalize converts the request fields to NFC and trims the email.
+func (r *EmailChangeRequest) Normalize() {
+	r.Email = Normalize(r.Email)
+	r.Password = NormalizePassword(r.Password)
+}
 
+// ValidateEmailChange validates an email change request.
+// All invalid fields are reported together as ValidationErrors.
+func ValidateEmailChange(req *EmailChangeRequest) error {
+	var errs ValidationErrors
+	errs.Add("email", ValidateEmail(req.Email))
 	if strings.TrimSpace(req.Password) == "" {
-		return errors.New("password is required")
+		errs.Add("password", newFieldError("password", CodeRequired, "password is required"))
 	}
+	return errs.Err()
+}
 
-	return nil
+// ValidateUserLogin validates user login data.
+// All invalid fields are reported together as ValidationErrors.
+func ValidateUserLogin(req *LoginRequest) error {
+	var errs ValidationErrors
+	errs.Add("username", ValidateUsername(req.Username))
+	if strings.TrimSpace(req.Password) == "" {
+		errs.Add("password", newFieldError("password", CodeRequired, "password is required"))
+	}
+	return errs.Err()
 }
 
 // ValidateUsername validates username format and requirements.
+// Usernames may use any script, but mixing scripts or impersonating Latin names with
+// lookalike characters is rejected.
 func ValidateUsername(username string) error {
-	username = strings.TrimSpace(username)
+	username = Normalize(username)
 
 	if username == "" {
-		return errors.New("username is required")
+		return newFieldError("username", CodeRequired, "username is required")
 	}
 
-	if len(username) < 3 {
-		return errors.New("username must be at least 3 characters long")
+	length := utf8.RuneCountInString(username)
+	if length < 3 {
+		return newFieldError("username", CodeTooShort, "username must be at least 3 characters long")
 	}
 
-	if len(username) > 30 {
-		return errors.New("username must be no more than 30 characters long")
+	if length > 30 {
+		return newFieldError("username", CodeTooLong, "username must be no more than 30 characters long")
 	}
 
-	if !usernameRegex.MatchString(username) {
-		return errors.New("username can only contain letters, numbers, underscores, and hyphens")
+	if hasInvisibleCharacters(username) || !usernameRegex.MatchString(username) {
+		return newFieldError("username", CodeInvalidCharacters, "username can only contain letters, numbers, underscores, and hyphens")
+	}
+
+	if isMixedScript(username) || isWholeScriptConfusable(username) {
+		return newFieldError("username", CodeConfusable, "username contains characters that can be confused with other letters")
 	}
 
 	return nil
 }
 
-// ValidateEmail validates email format.
+// ValidateEmail validates email format, accepting internationalized addresses (RFC 6531)
+// with UTF-8 local parts and IDN domains.
 func ValidateEmail(email string) error {
-	email = strings.TrimSpace(email)
+	email = Normalize(email)
 
 	if email == "" {
-		return errors.New("email is required")
+		return newFieldError("email", CodeRequired, "email is required")
+	}
+
+	if utf8.RuneCountInString(email) > maxEmailLength {
+		return newFieldError("email", CodeTooLong, "email is too long")
 	}
 
-	if len(email) > 254 {
-		return errors.New("email is too long")
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return newFieldError("email", CodeInvalidFormat, "invalid email format")
 	}
 
-	if !emailRegex.MatchString(email) {
-		return errors.New("invalid email format")
+	if !isValidEmailLocalPart(email[:at]) {
+		return newFieldError("email", CodeInvalidFormat, "invalid email format")
+	}
+
+	if _, err := EmailDomainToASCII(email[at+1:]); err != nil {
+		return newFieldError("email", CodeInvalidFormat, "invalid email format")
 	}
 
 	return nil
 }
 
-// ValidatePassword validates password strength and requirements.
-func ValidatePassword(password string) error {
-	if password == "" {
-		return errors.New("password is required")
+// EmailDomainToASCII converts an (optionally internationalized) email domain to its
+// punycode form and validates it.
+func EmailDomainToASCII(domain string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", err
 	}
-
-	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters long")
+	if !emailDomainRegex.MatchString(ascii) {
+		return "", errors.New("invalid email domain")
 	}
+	return ascii, nil
+}
 
-	if len(password) > 128 {
-		return errors.New("password is too long")
+// isValidEmailLocalPart checks the part before "@", allowing non-ASCII letters and digits.
+func isValidEmailLocalPart(local string) bool {
+	if local == "" || utf8.RuneCountInString(local) > maxEmailLocalLength {
+		return false
+	}
+	if strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return false
 	}
 
-	var (
-		hasUpper   = false
-		hasLower   = false
-		hasNumber  = false
-		hasSpecial = false
-	)
-
-	for _, char := range password {
+	for _, r := range local {
 		switch {
-		case unicode.IsUpper(char):
-			hasUpper = true
-		case unicode.IsLower(char):
-			hasLower = true
-		case unicode.IsNumber(char):
-			hasNumber = true
-		case unicode.IsPunct(char) || unicode.IsSymbol(char):
-			hasSpecial = true
+		case r < utf8.RuneSelf:
+			if !isASCIIAlphaNumeric(r) && !strings.ContainsRune("._%+-", r) {
+				return false
+			}
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r):
+		default:
+			return false
 		}
 	}
+	return true
+}
 
-	if !hasUpper {
-		return errors.New("password must contain at least one uppercase letter")
-	}
+func isASCIIAlphaNumeric(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
 
-	if !hasLower {
-		return errors.New("password must contain at least one lowercase letter")
+// ValidateRole validates that role is one of the roles defined in the models package.
+func ValidateRole(role string) error {
+	if role == "" {
+		return newFieldError("role", CodeRequired, "role is required")
 	}
-
-	if !hasNumber {
-		return errors.New("password must contain at least one number")
+	if !models.IsValidRole(role) {
+		return newFieldError("role", CodeInvalidValue, "role must be one of: "+models.RoleUser+", "+models.RoleAdmin)
 	}
+	return nil
+}
+
+// ValidatePassword validates password strength against the configured password policy.
+func ValidatePassword(ctx context.Context, password string) error {
+	return ValidatePasswordFor(ctx, password)
+}
 
-	if !hasSpecial {
-		return errors.New("password must contain at least one special character")
+// ValidatePasswordFor validates a password against the configured policy, rejecting it
+// when it contains any of the given identifiers (username, email) if the policy requires so.
+func ValidatePasswordFor(ctx context.Context, password string, identifiers ...string) error {
+	if err := CurrentPasswordPolicy().Validate(password, identifiers...); err != nil {
+		return err
 	}
 
-	return nil
+	return checkPasswordBreach(ctx, password)
 }
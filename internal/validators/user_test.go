@@ -1,6 +1,7 @@
 package validators
 
 import (
+	"context"
 	"testing"
 )
 
@@ -19,6 +20,14 @@ func TestValidateUsername(t *testing.T) {
 		{"Too long", "averylongusernamethatisgreaterthan30characters", true},
 		{"With spaces", "test user", true},
 		{"With special chars", "test@user", true},
+		{"Valid accented", "josé_99", false},
+		{"Valid decomposed accent", "jose\u0301_99", false},
+		{"Valid Japanese", "やまだ太郎", false},
+		{"Valid Cyrillic", "иван_петров", false},
+		{"Too short in runes", "жя", true},
+		{"Mixed script", "p\u0430ypal", true},
+		{"Whole-script confusable", "\u0440\u0430\u0443\u0440\u0430\u04cf", true},
+		{"Zero-width joiner", "test\u200duser", true},
 	}
 
 	for _, tt := range tests {
@@ -46,6 +55,12 @@ func TestValidateEmail(t *testing.T) {
 		{"Missing domain", "test@", true},
 		{"Missing local part", "@example.com", true},
 		{"Invalid format", "test@@example.com", true},
+		{"Internationalized local part", "josé@ejemplo.com", false},
+		{"Internationalized domain", "user@münchen.de", false},
+		{"Fully internationalized", "用户@例子.广告", false},
+		{"Leading dot", ".test@example.com", true},
+		{"Double dot", "te..st@example.com", true},
+		{"Invalid domain label", "test@-example.com", true},
 		{"Too long", func() string {
 			// Create an email longer than 254 characters
 			// "@example.com" = 12 characters, so we need 243+ for local part
@@ -77,6 +92,8 @@ func TestValidatePassword(t *testing.T) {
 		{"Valid complex", "MySecure@Pass1", false},
 		{"Empty password", "", true},
 		{"Too short", "Pass1!", true},
+		{"Too short in runes", "Ñañ1!Aé", true},
+		{"Valid with multibyte", "Ñandú123!", false},
 		{"No uppercase", "password123!", true},
 		{"No lowercase", "PASSWORD123!", true},
 		{"No numbers", "Password!", true},
@@ -95,7 +112,7 @@ func TestValidatePassword(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := ValidatePassword(tt.password)
+			err := ValidatePassword(context.Background(), tt.password)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidatePassword() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -149,7 +166,7 @@ func TestValidateUserRegistration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := ValidateUserRegistration(tt.req)
+			err := ValidateUserRegistration(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateUserRegistration() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -206,3 +223,57 @@ func TestValidateUserLogin(t *testing.T) {
 		})
 	}
 }
+
+type fakeBreachChecker map[string]int
+
+func (f fakeBreachChecker) Count(ctx context.Context, password string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f[password], nil
+}
+
+func TestValidatePasswordBreached(t *testing.T) {
+	SetBreachChecker(fakeBreachChecker{"Password123!": 42})
+	defer SetBreachChecker(nil)
+
+	if err := ValidatePassword(context.Background(), "Password123!"); err == nil {
+		t.Error("expected breached password to be rejected")
+	}
+	if err := ValidatePassword(context.Background(), "MySecure@Pass1"); err != nil {
+		t.Errorf("unexpected error for non-breached password: %v", err)
+	}
+
+	// The lookup is canceled with the request, and failed lookups accept the password
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ValidatePassword(ctx, "Password123!"); err != nil {
+		t.Errorf("expected a canceled breach check to be skipped, got %v", err)
+	}
+}
+
+func TestValidateUserRegistrationCollectsAllErrors(t *testing.T) {
+	err := ValidateUserRegistration(context.Background(), &AuthRequest{
+		Username: "ab",
+		Email:    "invalid-email",
+		Password: "weak",
+	})
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	fields := FieldErrorsOf(err)
+	want := map[string]string{
+		"username": CodeTooShort,
+		"email":    CodeInvalidFormat,
+		"password": CodeTooShort,
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("got %d field errors, want %d: %+v", len(fields), len(want), fields)
+	}
+	for _, field := range fields {
+		if want[field.Field] != field.Code {
+			t.Errorf("field %s: code = %s; want %s", field.Field, field.Code, want[field.Field])
+		}
+	}
+}
@@ -0,0 +1,363 @@
+// Package apimock answers requests with the example responses of an OpenAPI specification, so
+// that clients can be developed against endpoints that are designed but not implemented yet.
+package apimock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// PreferHeader chooses the mocked response, as in "Prefer: code=404, example=banned": code
+// selects the response by status (or "default") and example a named example of it.
+const PreferHeader = "Prefer"
+
+// maxSampleDepth bounds the schemas followed when a response has no example, so that
+// recursive schemas end.
+const maxSampleDepth = 8
+
+// Response is a mocked response.
+type Response struct {
+	// Operation is the operationId of the matched operation, or "METHOD /path" without one.
+	Operation   string
+	Status      int
+	ContentType string
+	// Body is empty when the response has no content.
+	Body []byte
+}
+
+// route is one operation of the specification with the pattern matching its URLs.
+type route struct {
+	method    string
+	template  string
+	pattern   *regexp.Regexp
+	operation *openapi3.Operation
+}
+
+// Server finds the example responses of a specification's operations.
+type Server struct {
+	routes []route
+}
+
+// Load reads and validates the specification at path (JSON or YAML) and creates a server for it.
+func Load(path string) (*Server, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid specification %s: %w", path, err)
+	}
+	return New(doc), nil
+}
+
+// New creates a server for doc. Paths are matched under the base path of each server URL
+// that has one, such as "/v1" in "https://api.example.com/v1", and as written otherwise.
+func New(doc *openapi3.T) *Server {
+	s := &Server{}
+	bases := basePaths(doc.Servers)
+	for _, template := range doc.Paths.InMatchingOrder() {
+		item := doc.Paths.Value(template)
+		methods := make([]string, 0, len(item.Operations()))
+		for method := range item.Operations() {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, base := range bases {
+			pattern := pathPattern(base + template)
+			for _, method := range methods {
+				s.routes = append(s.routes, route{
+					method:    method,
+					template:  base + template,
+					pattern:   pattern,
+					operation: item.GetOperation(method),
+				})
+			}
+		}
+	}
+	return s
+}
+
+// basePaths returns the path prefixes of servers, or just "" when none has one. URLs with
+// variables are skipped.
+func basePaths(servers openapi3.Servers) []string {
+	seen := map[string]bool{}
+	var bases []string
+	for _, server := range servers {
+		if strings.Contains(server.URL, "{") {
+			continue
+		}
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			continue
+		}
+		base := strings.TrimSuffix(u.Path, "/")
+		if !seen[base] {
+			seen[base] = true
+			bases = append(bases, base)
+		}
+	}
+	if !seen[""] {
+		bases = append(bases, "")
+	}
+	return bases
+}
+
+var pathParam = regexp.MustCompile(`\{[^}/]+\}`)
+
+// pathPattern matches the URLs of a path template, each {param} matching one segment.
+func pathPattern(template string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range pathParam.FindAllStringIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		b.WriteString("[^/]+")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("/?$")
+	return regexp.MustCompile(b.String())
+}
+
+// Respond returns the mocked response to r, or false when no operation of the specification
+// matches its method and path. HEAD requests are answered like GET ones.
+func (s *Server) Respond(r *http.Request) (Response, bool, error) {
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	for _, rt := range s.routes {
+		if rt.method != method || !rt.pattern.MatchString(r.URL.Path) {
+			continue
+		}
+		code, example := parsePrefer(r.Header.Get(PreferHeader))
+		resp, err := respond(rt.operation, code, example)
+		resp.Operation = rt.operation.OperationID
+		if resp.Operation == "" {
+			resp.Operation = method + " " + rt.template
+		}
+		return resp, true, err
+	}
+	return Response{}, false, nil
+}
+
+// parsePrefer reads the code and example preferences of a Prefer header.
+func parsePrefer(header string) (code, example string) {
+	for _, pref := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(pref), "=")
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "code":
+			code = value
+		case "example":
+			example = value
+		}
+	}
+	return code, example
+}
+
+// respond builds the response of op with the status code, or the first 2xx one (200 for a
+// default response alone), and its example named example, or the first one.
+func respond(op *openapi3.Operation, code, example string) (Response, error) {
+	if op.Responses == nil || op.Responses.Len() == 0 {
+		return Response{Status: http.StatusNoContent}, nil
+	}
+	if code == "" {
+		code = defaultCode(op.Responses)
+	}
+	ref := op.Responses.Value(code)
+	if ref == nil || ref.Value == nil {
+		return Response{}, fmt.Errorf("the operation has no %s response", code)
+	}
+	status := statusOf(code)
+
+	contentType, media := chooseMedia(ref.Value.Content)
+	if media == nil {
+		return Response{Status: status}, nil
+	}
+	value, err := exampleValue(media, example)
+	if err != nil {
+		return Response{}, err
+	}
+	resp := Response{Status: status, ContentType: contentType}
+	if s, ok := value.(string); ok && !isJSON(contentType) {
+		resp.Body = []byte(s)
+		return resp, nil
+	}
+	if resp.Body, err = json.Marshal(value); err != nil {
+		return Response{}, fmt.Errorf("invalid example: %w", err)
+	}
+	return resp, nil
+}
+
+// defaultCode returns the lowest 2xx status of responses, then the lowest other one, with
+// "default" last.
+func defaultCode(responses *openapi3.Responses) string {
+	codes := make([]string, 0, responses.Len())
+	for code := range responses.Map() {
+		codes = append(codes, code)
+	}
+	rank := func(code string) string {
+		switch {
+		case code == "default":
+			return "3" + code
+		case strings.HasPrefix(code, "2"):
+			return "1" + code
+		default:
+			return "2" + code
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool { return rank(codes[i]) < rank(codes[j]) })
+	return codes[0]
+}
+
+// statusOf returns the status of a response code: 200 for "default", and the lowest status of
+// a range such as "4XX".
+func statusOf(code string) int {
+	if status, err := strconv.Atoi(code); err == nil {
+		return status
+	}
+	if len(code) == 3 && code[0] >= '1' && code[0] <= '5' && strings.EqualFold(code[1:], "XX") {
+		return int(code[0]-'0') * 100
+	}
+	return http.StatusOK
+}
+
+// chooseMedia returns application/json content, then other JSON content, then the first
+// content type in alphabetical order.
+func chooseMedia(content openapi3.Content) (string, *openapi3.MediaType) {
+	if len(content) == 0 {
+		return "", nil
+	}
+	if media := content["application/json"]; media != nil {
+		return "application/json", media
+	}
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if isJSON(t) {
+			return t, content[t]
+		}
+	}
+	return types[0], content[types[0]]
+}
+
+func isJSON(contentType string) bool {
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+// exampleValue returns the example named name, or the media type's example, or its first
+// named example, or a sample built from its schema.
+func exampleValue(media *openapi3.MediaType, name string) (any, error) {
+	if name != "" {
+		ref := media.Examples[name]
+		if ref == nil || ref.Value == nil {
+			return nil, fmt.Errorf("the response has no example %q", name)
+		}
+		return ref.Value.Value, nil
+	}
+	if media.Example != nil {
+		return media.Example, nil
+	}
+	if len(media.Examples) > 0 {
+		names := make([]string, 0, len(media.Examples))
+		for n := range media.Examples {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if ref := media.Examples[names[0]]; ref != nil && ref.Value != nil {
+			return ref.Value.Value, nil
+		}
+	}
+	return sample(media.Schema, 0), nil
+}
+
+// sample builds a value matching schema from its examples, defaults and enums, with
+// placeholders for the rest.
+func sample(ref *openapi3.SchemaRef, depth int) any {
+	if ref == nil || ref.Value == nil || depth > maxSampleDepth {
+		return nil
+	}
+	schema := ref.Value
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		merged := map[string]any{}
+		for _, part := range schema.AllOf {
+			if obj, ok := sample(part, depth+1).(map[string]any); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return sample(schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return sample(schema.AnyOf[0], depth+1)
+	}
+
+	switch {
+	case schema.Type.Is(openapi3.TypeObject) || len(schema.Properties) > 0:
+		obj := make(map[string]any, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			obj[name] = sample(prop, depth+1)
+		}
+		return obj
+	case schema.Type.Is(openapi3.TypeArray):
+		if item := sample(schema.Items, depth+1); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case schema.Type.Is(openapi3.TypeString):
+		return sampleString(schema.Format)
+	case schema.Type.Is(openapi3.TypeInteger):
+		if schema.Min != nil {
+			return int64(*schema.Min)
+		}
+		return 0
+	case schema.Type.Is(openapi3.TypeNumber):
+		if schema.Min != nil {
+			return *schema.Min
+		}
+		return 0.0
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return true
+	}
+	return nil
+}
+
+// sampleString returns a placeholder in format.
+func sampleString(format string) string {
+	switch format {
+	case "date-time":
+		return "2026-01-01T00:00:00Z"
+	case "date":
+		return "2026-01-01"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,150 @@
+package apimock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const spec = `
+openapi: 3.0.3
+info: {title: Pets, version: "1"}
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: "#/components/schemas/Pet"}
+    post:
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              examples:
+                rex: {value: {id: 2, name: Rex}}
+                bella: {value: {id: 3, name: Bella}}
+        "422":
+          description: invalid
+          content:
+            application/problem+json:
+              example: {title: Invalid pet}
+  /pets/{id}:
+    delete:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        "204": {description: deleted}
+  /pets/mine:
+    get:
+      responses:
+        default:
+          description: my pet
+          content:
+            text/plain:
+              example: Rex
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id: {type: integer, minimum: 1}
+        name: {type: string, example: Rex}
+        status: {type: string, enum: [available, sold]}
+        born: {type: string, format: date}
+`
+
+func newServer(t *testing.T) *Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(path, []byte(spec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return s
+}
+
+func TestServer_Respond(t *testing.T) {
+	s := newServer(t)
+	tests := []struct {
+		name, method, path, prefer string
+		operation                  string
+		status                     int
+		contentType, body          string
+	}{
+		{"sample from schema", "GET", "/pets", "", "listPets", 200, "application/json",
+			`[{"born":"2026-01-01","id":1,"name":"Rex","status":"available"}]`},
+		{"under the server base path", "GET", "/v1/pets/", "", "listPets", 200, "application/json",
+			`[{"born":"2026-01-01","id":1,"name":"Rex","status":"available"}]`},
+		{"first named example", "POST", "/pets", "", "POST /pets", 201, "application/json", `{"id":3,"name":"Bella"}`},
+		{"preferred example", "POST", "/pets", "example=rex", "POST /pets", 201, "application/json", `{"id":2,"name":"Rex"}`},
+		{"preferred status", "POST", "/pets", "code=422", "POST /pets", 422, "application/problem+json", `{"title":"Invalid pet"}`},
+		{"literal path before template", "GET", "/pets/mine", "", "GET /pets/mine", 200, "text/plain", "Rex"},
+		{"no content", "DELETE", "/pets/7", "", "DELETE /pets/{id}", 204, "", ""},
+		{"head as get", "HEAD", "/pets", "", "listPets", 200, "application/json",
+			`[{"born":"2026-01-01","id":1,"name":"Rex","status":"available"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.prefer != "" {
+				r.Header.Set(PreferHeader, tt.prefer)
+			}
+			resp, ok, err := s.Respond(r)
+			if !ok || err != nil {
+				t.Fatalf("Respond() = %v, %v", ok, err)
+			}
+			if resp.Operation != tt.operation || resp.Status != tt.status || resp.ContentType != tt.contentType || string(resp.Body) != tt.body {
+				t.Errorf("Respond() = %s %d %s %s, want %s %d %s %s", resp.Operation, resp.Status, resp.ContentType, resp.Body,
+					tt.operation, tt.status, tt.contentType, tt.body)
+			}
+		})
+	}
+}
+
+func TestServer_RespondUnmatched(t *testing.T) {
+	s := newServer(t)
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/owners", nil),
+		httptest.NewRequest(http.MethodPut, "/pets", nil),
+		httptest.NewRequest(http.MethodDelete, "/pets/7/toys", nil),
+	} {
+		if _, ok, _ := s.Respond(r); ok {
+			t.Errorf("Respond(%s %s) matched", r.Method, r.URL.Path)
+		}
+	}
+
+	for _, prefer := range []string{"code=500", "example=missing"} {
+		r := httptest.NewRequest(http.MethodPost, "/pets", nil)
+		r.Header.Set(PreferHeader, prefer)
+		if _, ok, err := s.Respond(r); !ok || err == nil {
+			t.Errorf("Respond() with Prefer %q = %v, %v, want an error", prefer, ok, err)
+		}
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Load() accepted a missing file")
+	}
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(path, []byte("openapi: 3.0.3\npaths: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() accepted a specification without info")
+	}
+}
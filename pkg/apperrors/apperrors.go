@@ -0,0 +1,161 @@
+// Package apperrors defines typed application errors that carry their HTTP status, error code,
+// and client-facing message. Handlers and services return them, handlers pass them to
+// c.Error, and middlewares.ErrorHandler writes the matching response:
+//
+//	if errors.Is(err, gorm.ErrRecordNotFound) {
+//		_ = c.Error(apperrors.NotFound("User not found", "No user exists with the given id").Wrap(err))
+//		return
+//	}
+//
+// Kinds are compared with errors.Is against the sentinel errors (ErrNotFound, ErrConflict...),
+// which match any error of the same code, wrapped or not. Codes come from the catalog in
+// pkg/response; register new ones with response.NewErrorCode.
+package apperrors
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// Sentinel errors of each kind, for use with errors.Is.
+var (
+	ErrBadRequest   = New(response.CodeBadRequest, "Bad request")
+	ErrValidation   = New(response.CodeValidation, "Validation failed")
+	ErrUnauthorized = New(response.CodeUnauthorized, "Unauthorized")
+	ErrForbidden    = New(response.CodeForbidden, "Forbidden")
+	ErrNotFound     = New(response.CodeNotFound, "Resource not found")
+	ErrConflict     = New(response.CodeConflict, "Conflict")
+	ErrUnavailable  = New(response.CodeServiceUnavailable, "Service temporarily unavailable")
+	ErrInternal     = New(response.CodeInternal, "Internal server error")
+)
+
+// Error is an application error. Its code from the response catalog sets the HTTP status;
+// Message and Details are shown to clients, and the wrapped error is only logged. RetryAfter,
+// when set, is sent as the Retry-After header of errors worth retrying.
+type Error struct {
+	Code       *response.ErrorCode
+	Message    string
+	Details    string
+	Fields     []response.FieldError
+	RetryAfter time.Duration
+	Err        error
+}
+
+// New creates an error with any catalog code, for kinds without a constructor.
+func New(code *response.ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// BadRequest creates a 400 error for malformed requests.
+func BadRequest(message, details string) *Error {
+	return &Error{Code: response.CodeBadRequest, Message: message, Details: details}
+}
+
+// Validation creates a 400 error listing the invalid fields.
+func Validation(details string, fields ...response.FieldError) *Error {
+	return &Error{Code: response.CodeValidation, Message: "Validation failed", Details: details, Fields: fields}
+}
+
+// Unauthorized creates a 401 error.
+func Unauthorized(message, details string) *Error {
+	return &Error{Code: response.CodeUnauthorized, Message: message, Details: details}
+}
+
+// Forbidden creates a 403 error.
+func Forbidden(message, details string) *Error {
+	return &Error{Code: response.CodeForbidden, Message: message, Details: details}
+}
+
+// NotFound creates a 404 error.
+func NotFound(message, details string) *Error {
+	return &Error{Code: response.CodeNotFound, Message: message, Details: details}
+}
+
+// Conflict creates a 409 error.
+func Conflict(message, details string) *Error {
+	return &Error{Code: response.CodeConflict, Message: message, Details: details}
+}
+
+// Unavailable creates a 503 error.
+func Unavailable(message, details string) *Error {
+	return &Error{Code: response.CodeServiceUnavailable, Message: message, Details: details}
+}
+
+// Internal creates a 500 error. err is logged but never sent to the client.
+func Internal(message, details string, err error) *Error {
+	return &Error{Code: response.CodeInternal, Message: message, Details: details, Err: err}
+}
+
+// Error returns the message, followed by the wrapped error if any.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status of the error's code.
+func (e *Error) Status() int {
+	return e.Code.Status
+}
+
+// Is reports whether target is an *Error with the same code, so errors.Is(err, ErrNotFound)
+// matches every not-found error.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e wrapping err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	cp := *e
+	cp.Err = err
+	return &cp
+}
+
+// WithDetails returns a copy of e with details shown to the client.
+func (e *Error) WithDetails(details string) *Error {
+	cp := *e
+	cp.Details = details
+	return &cp
+}
+
+// WithRetryAfter returns a copy of e that tells clients to retry after d.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	cp := *e
+	cp.RetryAfter = d
+	return &cp
+}
+
+// APIError returns the response body representation of e.
+func (e *Error) APIError() *response.APIError {
+	return &response.APIError{Code: e.Code.Code, Message: e.Message, Details: e.Details, Fields: e.Fields}
+}
+
+// fieldErrors is implemented by validation error collections such as
+// validators.ValidationErrors.
+type fieldErrors interface {
+	error
+	ResponseFields() []response.FieldError
+}
+
+// As returns err as an *Error. Validation error collections become validation errors, and
+// any other error becomes an internal error wrapping it.
+func As(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	var fieldsErr fieldErrors
+	if errors.As(err, &fieldsErr) {
+		return Validation(fieldsErr.Error(), fieldsErr.ResponseFields()...).Wrap(err)
+	}
+	return Internal("Internal server error", "An unexpected error occurred", err)
+}
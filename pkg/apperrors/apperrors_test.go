@@ -0,0 +1,62 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+type testFieldErrors []response.FieldError
+
+func (e testFieldErrors) Error() string                         { return "email is required" }
+func (e testFieldErrors) ResponseFields() []response.FieldError { return e }
+
+func TestError_IsMatchesKindThroughWrapping(t *testing.T) {
+	cause := errors.New("record not found")
+	err := fmt.Errorf("loading user: %w", NotFound("User not found", "").Wrap(cause))
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal("expected errors.Is to match ErrNotFound")
+	}
+	if errors.Is(err, ErrConflict) {
+		t.Fatal("did not expect a not-found error to match ErrConflict")
+	}
+	if !errors.Is(err, cause) {
+		t.Fatal("expected the cause to stay reachable")
+	}
+	if got := err.Error(); got != "loading user: User not found: record not found" {
+		t.Fatalf("Error() = %q", got)
+	}
+}
+
+func TestError_CopiesDoNotModifySentinels(t *testing.T) {
+	_ = ErrNotFound.WithDetails("changed").WithRetryAfter(time.Second).Wrap(errors.New("cause"))
+	if ErrNotFound.Details != "" || ErrNotFound.RetryAfter != 0 || ErrNotFound.Err != nil {
+		t.Fatalf("sentinel was modified: %+v", ErrNotFound)
+	}
+}
+
+func TestAs(t *testing.T) {
+	conflict := Conflict("User already exists", "Username or email already exists")
+	if got := As(fmt.Errorf("wrapped: %w", conflict)); got != conflict {
+		t.Fatalf("As returned %+v, want the wrapped error", got)
+	}
+
+	validation := As(testFieldErrors{{Field: "email", Code: "required", Message: "email is required"}})
+	if validation.Status() != http.StatusBadRequest || validation.Code != response.CodeValidation || len(validation.Fields) != 1 {
+		t.Fatalf("validation errors mapped to %+v", validation)
+	}
+
+	cause := errors.New("disk full")
+	internal := As(cause)
+	if internal.Status() != http.StatusInternalServerError || !errors.Is(internal, cause) {
+		t.Fatalf("plain error mapped to %+v", internal)
+	}
+	if api := internal.APIError(); api.Details == cause.Error() || api.Message == cause.Error() {
+		t.Fatalf("internal cause leaked to the client: %+v", api)
+	}
+}
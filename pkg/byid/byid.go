@@ -0,0 +1,78 @@
+// Package byid implements the batch GET convention: ?ids=1,2,3 fetches several records in one
+// request and one query, and the response keys them by ID, so clients do not issue one request
+// per record.
+package byid
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// QueryParam is the query parameter that lists the IDs.
+	QueryParam = "ids"
+	// MaxIDs is the largest number of IDs a client can request at once.
+	MaxIDs = 100
+)
+
+// ErrNoIDs is returned when the ID list is empty.
+var ErrNoIDs = errors.New("at least one id is required")
+
+// Parse parses a comma-separated list of positive IDs, dropping duplicates and keeping the
+// order of first appearance. More than max distinct IDs is an error.
+func Parse(raw string, max int) ([]uint, error) {
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 0)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		if seen[uint(id)] {
+			continue
+		}
+		seen[uint(id)] = true
+		ids = append(ids, uint(id))
+		if len(ids) > max {
+			return nil, fmt.Errorf("at most %d ids can be requested at once", max)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, ErrNoIDs
+	}
+	return ids, nil
+}
+
+// FromContext parses the ?ids= query parameter with the MaxIDs cap.
+func FromContext(c *gin.Context) ([]uint, error) {
+	return Parse(c.Query(QueryParam), MaxIDs)
+}
+
+// Key returns items keyed by the ID that id returns for each of them.
+func Key[T any](items []T, id func(T) uint) map[uint]T {
+	keyed := make(map[uint]T, len(items))
+	for _, item := range items {
+		keyed[id(item)] = item
+	}
+	return keyed
+}
+
+// Missing returns the requested IDs that are not in found, in request order. It never returns
+// nil, so the list is serialized as [] rather than null.
+func Missing[T any](ids []uint, found map[uint]T) []uint {
+	missing := []uint{}
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
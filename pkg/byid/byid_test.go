@@ -0,0 +1,40 @@
+package byid
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	ids, err := Parse(" 3, 1,,3 ,2", 3)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(ids, []uint{3, 1, 2}) {
+		t.Errorf("Parse() = %v, want [3 1 2]", ids)
+	}
+
+	if _, err := Parse("", 3); !errors.Is(err, ErrNoIDs) {
+		t.Errorf("empty list: error = %v, want ErrNoIDs", err)
+	}
+	for _, raw := range []string{"1,x", "-1", "0", "1,2,3,4"} {
+		if _, err := Parse(raw, 3); err == nil {
+			t.Errorf("Parse(%q): expected an error", raw)
+		}
+	}
+}
+
+func TestKeyAndMissing(t *testing.T) {
+	type record struct{ id uint }
+	found := Key([]record{{id: 2}, {id: 5}}, func(r record) uint { return r.id })
+	if len(found) != 2 || found[5].id != 5 {
+		t.Fatalf("Key() = %v", found)
+	}
+	if missing := Missing([]uint{1, 2, 5, 7}, found); !reflect.DeepEqual(missing, []uint{1, 7}) {
+		t.Errorf("Missing() = %v, want [1 7]", missing)
+	}
+	if missing := Missing([]uint{2}, found); missing == nil || len(missing) != 0 {
+		t.Errorf("Missing() = %#v, want an empty non-nil slice", missing)
+	}
+}
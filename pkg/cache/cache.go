@@ -0,0 +1,115 @@
+// Package cache provides a key-value cache abstraction with in-memory and Redis implementations.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache stores byte values with an expiry.
+type Cache interface {
+	// Get returns the value for key and whether it was found and not expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl. A ttl <= 0 means the entry does not expire.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Memory is an in-process LRU cache. It is safe for concurrent use.
+type Memory struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemory creates an in-memory cache holding at most maxEntries; the least recently
+// used entry is evicted when it is full. maxEntries <= 0 means unbounded.
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get implements Cache.
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && m.now().After(entry.expiresAt) {
+		m.remove(elem)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set implements Cache.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = m.now().Add(ttl)
+	}
+
+	if elem, ok := m.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(elem)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	if m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+	return nil
+}
+
+// Delete implements Cache.
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+	return nil
+}
+
+// Len returns the number of stored entries, including expired ones not yet evicted.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// remove deletes an element. Must hold m.mu.
+func (m *Memory) remove(elem *list.Element) {
+	m.order.Remove(elem)
+	delete(m.entries, elem.Value.(*memoryEntry).key)
+}
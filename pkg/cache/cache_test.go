@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	m := NewMemory(0)
+	m.now = func() time.Time { return now }
+
+	_ = m.Set(ctx, "a", []byte("1"), time.Minute)
+	if v, ok, _ := m.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Fatalf("expected cached value, got %q (found=%v)", v, ok)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Fatal("expected entry to expire")
+	}
+	if m.Len() != 0 {
+		t.Fatal("expected expired entry to be removed")
+	}
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	_ = m.Set(ctx, "a", []byte("1"), 0)
+	_ = m.Set(ctx, "b", []byte("2"), 0)
+	_, _, _ = m.Get(ctx, "a")
+	_ = m.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := m.Get(ctx, "b"); ok {
+		t.Fatal("expected least recently used entry to be evicted")
+	}
+	if _, ok, _ := m.Get(ctx, "a"); !ok {
+		t.Fatal("expected recently used entry to be kept")
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(0)
+
+	_ = m.Set(ctx, "a", []byte("1"), 0)
+	_ = m.Delete(ctx, "a")
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Fatal("expected entry to be deleted")
+	}
+}
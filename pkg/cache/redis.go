@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisTimeout bounds dialing and each command whose context has no deadline.
+const redisTimeout = 5 * time.Second
+
+// redisMaxIdle is the number of idle connections kept for reuse.
+const redisMaxIdle = 8
+
+// RedisError is an error reply from the Redis server.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// Redis is a Cache stored in a Redis server, shared by every instance pointed at it. It speaks
+// the RESP protocol over a small pool of connections and is safe for concurrent use.
+type Redis struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      *tls.Config
+
+	idle chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// NewRedis creates a Redis cache from a redis://[[username]:password@]host[:port][/db] URL,
+// or rediss:// for TLS. Connections are opened on first use.
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid redis URL: scheme must be redis or rediss, got %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("invalid redis URL: missing host")
+	}
+
+	r := &Redis{addr: u.Host, idle: make(chan *redisConn, redisMaxIdle)}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil || r.db < 0 {
+			return nil, fmt.Errorf("invalid redis URL: database must be a number, got %q", db)
+		}
+	}
+	if u.Scheme == "rediss" {
+		r.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+	return r, nil
+}
+
+// Get implements Cache.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", []byte(key))
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+// Set implements Cache.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := [][]byte{[]byte(key), value}
+	if ttl > 0 {
+		args = append(args, []byte("PX"), []byte(strconv.FormatInt(max(ttl.Milliseconds(), 1), 10)))
+	}
+	_, err := r.do(ctx, "SET", args...)
+	return err
+}
+
+// Delete implements Cache.
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	_, err := r.do(ctx, "DEL", []byte(key))
+	return err
+}
+
+// Ping checks that the server is reachable and accepts the credentials.
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections. Connections in use are closed when they are returned.
+func (r *Redis) Close() error {
+	for {
+		select {
+		case conn := <-r.idle:
+			_ = conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends one command and reads its reply: nil, a string for status replies, an int64, a
+// []byte, or a []interface{} of those.
+func (r *Redis) do(ctx context.Context, cmd string, args ...[]byte) (interface{}, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(ctx, cmd, args...)
+	var redisErr RedisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// The connection is in an unknown state
+		_ = conn.Close()
+		return nil, err
+	}
+	select {
+	case r.idle <- conn:
+	default:
+		_ = conn.Close()
+	}
+	return reply, err
+}
+
+// conn returns an idle connection, or dials and authenticates a new one.
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var nc net.Conn
+	var err error
+	if r.tls != nil {
+		nc, err = (&tls.Dialer{NetDialer: dialer, Config: r.tls}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+
+	if r.password != "" {
+		args := [][]byte{[]byte(r.password)}
+		if r.username != "" {
+			args = [][]byte{[]byte(r.username), []byte(r.password)}
+		}
+		if _, err := conn.do(ctx, "AUTH", args...); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := conn.do(ctx, "SELECT", []byte(strconv.Itoa(r.db))); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *redisConn) do(ctx context.Context, cmd string, args ...[]byte) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisTimeout)
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	b = append(b, '*')
+	b = strconv.AppendInt(b, int64(len(args)+1), 10)
+	b = append(b, "\r\n"...)
+	b = appendBulk(b, []byte(cmd))
+	for _, arg := range args {
+		b = appendBulk(b, arg)
+	}
+	if _, err := c.Write(b); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
+
+func appendBulk(b, value []byte) []byte {
+	b = append(b, '$')
+	b = strconv.AppendInt(b, int64(len(value)), 10)
+	b = append(b, "\r\n"...)
+	b = append(b, value...)
+	return append(b, "\r\n"...)
+}
+
+// readReply reads one RESP2 reply.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, RedisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		value := make([]byte, n+2)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+		return value[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readReply(r); err != nil {
+				// Keep reading the rest so the connection stays usable
+				var redisErr RedisError
+				if !errors.As(err, &redisErr) {
+					return nil, err
+				}
+				values[i] = redisErr
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a Redis server supporting the commands used by Redis, with password "secret".
+type fakeRedis struct {
+	mu      sync.Mutex
+	values  map[int]map[string]string
+	expires map[string]time.Duration
+	dials   int
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	s := &fakeRedis{values: map[int]map[string]string{}, expires: map[string]time.Duration{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.dials++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s, ln.Addr().String()
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed, db := false, 0
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, string(arg.([]byte)))
+		}
+
+		s.mu.Lock()
+		if s.values[db] == nil {
+			s.values[db] = map[string]string{}
+		}
+		var out string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			authed = args[len(args)-1] == "secret"
+			out = "+OK\r\n"
+			if !authed {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			out = "-NOAUTH Authentication required.\r\n"
+		case cmd == "SELECT":
+			db, _ = strconv.Atoi(args[1])
+			out = "+OK\r\n"
+		case cmd == "PING":
+			out = "+PONG\r\n"
+		case cmd == "GET":
+			if v, ok := s.values[db][args[1]]; ok {
+				out = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				out = "$-1\r\n"
+			}
+		case cmd == "SET":
+			s.values[db][args[1]] = args[2]
+			if len(args) == 5 && args[3] == "PX" {
+				ms, _ := strconv.Atoi(args[4])
+				s.expires[args[1]] = time.Duration(ms) * time.Millisecond
+			}
+			out = "+OK\r\n"
+		case cmd == "DEL":
+			delete(s.values[db], args[1])
+			out = ":1\r\n"
+		default:
+			out = "-ERR unknown command\r\n"
+		}
+		s.mu.Unlock()
+
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRedisGetSetDelete(t *testing.T) {
+	server, addr := startFakeRedis(t)
+	r, err := NewRedis("redis://:secret@" + addr + "/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ctx := context.Background()
+
+	if err := r.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := r.Get(ctx, "a"); ok || err != nil {
+		t.Fatalf("expected a miss, got found=%v err=%v", ok, err)
+	}
+	value := []byte("binary\r\n\x00value")
+	if err := r.Set(ctx, "a", value, 1500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, err := r.Get(ctx, "a"); !ok || err != nil || string(v) != string(value) {
+		t.Fatalf("expected %q, got %q (found=%v err=%v)", value, v, ok, err)
+	}
+	if err := r.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := r.Get(ctx, "a"); ok {
+		t.Fatal("expected the key to be deleted")
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.expires["a"] != 1500*time.Millisecond {
+		t.Fatalf("expected a 1500ms expiry, got %v", server.expires["a"])
+	}
+	if _, ok := server.values[2]; !ok {
+		t.Fatal("expected database 2 to be selected")
+	}
+	if server.dials != 1 {
+		t.Fatalf("expected the connection to be reused, got %d dials", server.dials)
+	}
+}
+
+func TestRedisReportsErrors(t *testing.T) {
+	_, addr := startFakeRedis(t)
+	r, err := NewRedis("redis://:wrong@" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var redisErr RedisError
+	if err := r.Ping(context.Background()); !errors.As(err, &redisErr) || !strings.HasPrefix(string(redisErr), "WRONGPASS") {
+		t.Fatalf("expected WRONGPASS, got %v", err)
+	}
+
+	for _, rawURL := range []string{"http://localhost", "redis://", "redis://localhost/x"} {
+		if _, err := NewRedis(rawURL); err == nil {
+			t.Errorf("expected %q to be rejected", rawURL)
+		}
+	}
+}
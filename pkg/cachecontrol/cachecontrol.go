@@ -0,0 +1,222 @@
+// Package cachecontrol describes the HTTP caching policy of route groups: the Cache-Control
+// header of their successful responses and the surrogate keys a CDN purges them by.
+package cachecontrol
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultSurrogateKeyHeader is the response header that carries surrogate keys to the CDN.
+const DefaultSurrogateKeyHeader = "Surrogate-Key"
+
+const surrogateKeysKey = "surrogate_keys"
+
+// Policy is the caching policy of a route group.
+type Policy struct {
+	// Public lets shared caches such as a CDN store the response; otherwise only the client
+	// may. NoStore forbids caching altogether and ignores the other fields.
+	Public  bool
+	NoStore bool
+
+	MaxAge time.Duration
+	// SharedMaxAge overrides MaxAge for shared caches (s-maxage).
+	SharedMaxAge time.Duration
+	// StaleWhileRevalidate lets caches serve the response this long after it expires while
+	// they fetch a fresh copy in the background; StaleIfError this long when that fails.
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+
+	// SurrogateKeys tag every response of the group, in addition to the keys set by handlers.
+	SurrogateKeys []string
+}
+
+// ParsePolicy parses space-separated directives, such as
+// "public max-age=60 stale-while-revalidate=30 key=catalog". Durations are seconds, as in
+// Cache-Control, or Go durations such as "5m". Besides the Cache-Control directives public,
+// private, no-store, max-age, s-maxage, stale-while-revalidate and stale-if-error, key=
+// adds a surrogate key.
+func ParsePolicy(s string) (Policy, error) {
+	var p Policy
+	directives := strings.Fields(s)
+	if len(directives) == 0 {
+		return p, errors.New("empty policy")
+	}
+	for _, directive := range directives {
+		name, value, hasValue := strings.Cut(strings.ToLower(directive), "=")
+		var target *time.Duration
+		switch name {
+		case "public":
+			p.Public = true
+		case "private":
+			p.Public = false
+		case "no-store":
+			p.NoStore = true
+		case "key":
+			// Keys keep their case
+			_, key, _ := strings.Cut(directive, "=")
+			if err := ValidateKey(key); err != nil {
+				return Policy{}, err
+			}
+			p.SurrogateKeys = append(p.SurrogateKeys, key)
+		case "max-age":
+			target = &p.MaxAge
+		case "s-maxage":
+			target = &p.SharedMaxAge
+		case "stale-while-revalidate":
+			target = &p.StaleWhileRevalidate
+		case "stale-if-error":
+			target = &p.StaleIfError
+		default:
+			return Policy{}, fmt.Errorf("unknown directive %q", directive)
+		}
+		if target == nil {
+			continue
+		}
+		if !hasValue {
+			return Policy{}, fmt.Errorf("%s needs a duration", name)
+		}
+		d, err := parseSeconds(value)
+		if err != nil {
+			return Policy{}, fmt.Errorf("%s: %w", name, err)
+		}
+		*target = d
+	}
+	return p, nil
+}
+
+func parseSeconds(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("negative duration %q", value)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
+
+// HasDirective reports whether the Cache-Control header value contains directive, such as
+// "no-store" in "private, no-store".
+func HasDirective(header, directive string) bool {
+	for _, d := range strings.Split(header, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// Header returns the Cache-Control header value of the policy.
+func (p Policy) Header() string {
+	if p.NoStore {
+		return "no-store"
+	}
+	directives := []string{"private"}
+	if p.Public {
+		directives[0] = "public"
+	}
+	directives = append(directives, "max-age="+seconds(p.MaxAge))
+	if p.Public && p.SharedMaxAge > 0 {
+		directives = append(directives, "s-maxage="+seconds(p.SharedMaxAge))
+	}
+	if p.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+seconds(p.StaleWhileRevalidate))
+	}
+	if p.StaleIfError > 0 {
+		directives = append(directives, "stale-if-error="+seconds(p.StaleIfError))
+	}
+	return strings.Join(directives, ", ")
+}
+
+// Private returns the policy for a response only the client may cache: public and s-maxage
+// are dropped, as are the surrogate keys, since no CDN stores it.
+func (p Policy) Private() Policy {
+	p.Public = false
+	p.SharedMaxAge = 0
+	p.SurrogateKeys = nil
+	return p
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// Rule applies a policy to the routes under a path prefix.
+type Rule struct {
+	Prefix string
+	Policy Policy
+}
+
+// Rules are the policies of the route groups.
+type Rules []Rule
+
+// ParseRules parses "/route/prefix=directives" entries (see ParsePolicy).
+func ParseRules(entries []string) (Rules, error) {
+	rules := make(Rules, 0, len(entries))
+	for _, entry := range entries {
+		prefix, directives, ok := strings.Cut(entry, "=")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid cache policy %q: want /route/prefix=directives", entry)
+		}
+		policy, err := ParsePolicy(directives)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache policy %q: %w", entry, err)
+		}
+		rules = append(rules, Rule{Prefix: strings.TrimSuffix(prefix, "/"), Policy: policy})
+	}
+	// Longest prefix first, so that Match finds the most specific rule
+	sort.SliceStable(rules, func(i, j int) bool { return len(rules[i].Prefix) > len(rules[j].Prefix) })
+	return rules, nil
+}
+
+// Match returns the rule of the most specific prefix of route, a route template as in
+// c.FullPath. Prefixes match whole path segments: "/api/user" does not match "/api/users".
+func (r Rules) Match(route string) (Rule, bool) {
+	for _, rule := range r {
+		if route == rule.Prefix || strings.HasPrefix(route, rule.Prefix+"/") || rule.Prefix == "" {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// AddSurrogateKeys tags the response of the request with keys, such as "user-42" for a
+// response that shows user 42, so that it can be purged when that record changes.
+func AddSurrogateKeys(c *gin.Context, keys ...string) {
+	c.Set(surrogateKeysKey, append(SurrogateKeys(c), keys...))
+}
+
+// SurrogateKeys returns the keys added with AddSurrogateKeys.
+func SurrogateKeys(c *gin.Context) []string {
+	keys, _ := c.Get(surrogateKeysKey)
+	list, _ := keys.([]string)
+	return list
+}
+
+// ValidateKey reports whether key can be sent in a space-separated surrogate key header.
+func ValidateKey(key string) error {
+	if key == "" {
+		return errors.New("empty surrogate key")
+	}
+	if len(key) > 1024 {
+		return fmt.Errorf("surrogate key %.16q... is longer than 1024 bytes", key)
+	}
+	for _, r := range key {
+		if r <= ' ' || r == 0x7f || r > '~' {
+			return fmt.Errorf("surrogate key %q contains a space or a non-ASCII character", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package cachecontrol
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/testutil/vcr"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("public max-age=60 s-maxage=5m stale-while-revalidate=30 stale-if-error=1h key=Catalog")
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	if !p.Public || p.MaxAge != time.Minute || p.SharedMaxAge != 5*time.Minute ||
+		p.StaleWhileRevalidate != 30*time.Second || p.StaleIfError != time.Hour {
+		t.Errorf("unexpected policy %+v", p)
+	}
+	if len(p.SurrogateKeys) != 1 || p.SurrogateKeys[0] != "Catalog" {
+		t.Errorf("SurrogateKeys = %v, want [Catalog]", p.SurrogateKeys)
+	}
+	want := "public, max-age=60, s-maxage=300, stale-while-revalidate=30, stale-if-error=3600"
+	if got := p.Header(); got != want {
+		t.Errorf("Header() = %q, want %q", got, want)
+	}
+	if got := p.Private().Header(); got != "private, max-age=60, stale-while-revalidate=30, stale-if-error=3600" {
+		t.Errorf("Private().Header() = %q", got)
+	}
+}
+
+func TestParsePolicy_Errors(t *testing.T) {
+	for _, s := range []string{"", "public max-age", "max-age=-1", "max-age=soon", "immutable", "key=", "public,max-age=60"} {
+		if _, err := ParsePolicy(s); err == nil {
+			t.Errorf("ParsePolicy(%q) succeeded", s)
+		}
+	}
+}
+
+func TestRulesMatch(t *testing.T) {
+	rules, err := ParseRules([]string{"/api=private max-age=0", "/api/errors=public max-age=3600"})
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	tests := map[string]string{
+		"/api/errors":     "/api/errors",
+		"/api/errors/:id": "/api/errors",
+		"/api/errorsx":    "/api",
+		"/api/users":      "/api",
+	}
+	for route, want := range tests {
+		rule, ok := rules.Match(route)
+		if !ok || rule.Prefix != want {
+			t.Errorf("Match(%q) = %q, %v; want %q", route, rule.Prefix, ok, want)
+		}
+	}
+	if _, ok := rules.Match("/health"); ok {
+		t.Error("Match(/health) matched a rule")
+	}
+
+	if _, err := ParseRules([]string{"api=public max-age=1"}); err == nil {
+		t.Error("ParseRules() accepted a prefix without a leading slash")
+	}
+}
+
+func TestFastlyPurge(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	purger := NewFastly("svc1", "token", true, srv.Client()).WithBaseURL(srv.URL)
+	if err := purger.Purge(context.Background(), []string{"users", "user-1"}); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if got.Method != http.MethodPost || got.URL.Path != "/service/svc1/purge" {
+		t.Errorf("request = %s %s", got.Method, got.URL.Path)
+	}
+	if got.Header.Get("Surrogate-Key") != "users user-1" || got.Header.Get("Fastly-Key") != "token" || got.Header.Get("Fastly-Soft-Purge") != "1" {
+		t.Errorf("unexpected headers %v", got.Header)
+	}
+}
+
+func TestWebhookPurge(t *testing.T) {
+	var body struct {
+		Keys []string `json:"keys"`
+		Soft bool     `json:"soft"`
+	}
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhook(srv.URL, "secret", false, srv.Client()).Purge(context.Background(), []string{"errors"}); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if auth != "Bearer secret" || len(body.Keys) != 1 || body.Keys[0] != "errors" || body.Soft {
+		t.Errorf("webhook received %q %+v", auth, body)
+	}
+}
+
+func TestWebhookPurge_Cassette(t *testing.T) {
+	webhook := NewWebhook("https://purge.example.com/purge", "purge-token", true, vcr.New(t, "webhook_purge"))
+
+	if err := webhook.Purge(context.Background(), []string{"user:1", "users"}); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	// Other keys are not in the cassette, so the request fails instead of reaching the network
+	if err := webhook.Purge(context.Background(), []string{"errors"}); err == nil {
+		t.Error("Purge() succeeded for a request missing from the cassette")
+	}
+}
+
+func TestPurge_FailedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhook(srv.URL, "", true, srv.Client()).Purge(context.Background(), []string{"k"}); err == nil {
+		t.Error("Purge() succeeded on a 403 response")
+	}
+}
+
+func TestHasDirective(t *testing.T) {
+	for header, want := range map[string]bool{
+		"no-store":                    true,
+		"private, No-Store":           true,
+		"private,no-store, max-age=0": true,
+		"private, max-age=60":         false,
+		"":                            false,
+	} {
+		if got := HasDirective(header, "no-store"); got != want {
+			t.Errorf("HasDirective(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
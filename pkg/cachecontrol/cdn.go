@@ -0,0 +1,111 @@
+package cachecontrol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultFastlyURL is the base URL of the Fastly API.
+const DefaultFastlyURL = "https://api.fastly.com"
+
+// MaxPurgeKeys is the largest number of surrogate keys purged in one request, Fastly's limit.
+const MaxPurgeKeys = 256
+
+// Purger invalidates the responses a CDN cached under any of the given surrogate keys.
+type Purger interface {
+	Purge(ctx context.Context, keys []string) error
+}
+
+// Fastly purges by surrogate key with the Fastly API. A soft purge marks the responses stale
+// instead of removing them, so they can still be served under stale-while-revalidate and
+// stale-if-error while the CDN fetches fresh copies.
+type Fastly struct {
+	baseURL    string
+	serviceID  string
+	token      string
+	soft       bool
+	httpClient *http.Client
+}
+
+// NewFastly creates a purger for the Fastly service serviceID, authenticated with an API token.
+func NewFastly(serviceID, token string, soft bool, httpClient *http.Client) *Fastly {
+	return &Fastly{
+		baseURL:    DefaultFastlyURL,
+		serviceID:  serviceID,
+		token:      token,
+		soft:       soft,
+		httpClient: httpClient,
+	}
+}
+
+// WithBaseURL overrides the API base URL, for tests.
+func (f *Fastly) WithBaseURL(baseURL string) *Fastly {
+	f.baseURL = strings.TrimSuffix(baseURL, "/")
+	return f
+}
+
+// Purge purges keys with a single batch purge request.
+func (f *Fastly) Purge(ctx context.Context, keys []string) error {
+	url := f.baseURL + "/service/" + f.serviceID + "/purge"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Fastly-Key", f.token)
+	req.Header.Set("Surrogate-Key", strings.Join(keys, " "))
+	req.Header.Set("Accept", "application/json")
+	if f.soft {
+		req.Header.Set("Fastly-Soft-Purge", "1")
+	}
+	return send(f.httpClient, req)
+}
+
+// Webhook purges by posting {"keys": [...], "soft": true|false} to a URL, for CDNs reached
+// through a small adapter or an internal purge service.
+type Webhook struct {
+	url        string
+	token      string
+	soft       bool
+	httpClient *http.Client
+}
+
+// NewWebhook creates a purger that posts to url, with token as a bearer token when set.
+func NewWebhook(url, token string, soft bool, httpClient *http.Client) *Webhook {
+	return &Webhook{url: url, token: token, soft: soft, httpClient: httpClient}
+}
+
+// Purge posts keys to the webhook.
+func (w *Webhook) Purge(ctx context.Context, keys []string) error {
+	body, err := json.Marshal(map[string]interface{}{"keys": keys, "soft": w.soft})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+	return send(w.httpClient, req)
+}
+
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("purge request failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
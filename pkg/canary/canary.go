@@ -0,0 +1,129 @@
+// Package canary decides which requests a canary implementation of an endpoint serves, so a
+// rewritten handler can take a share of the traffic next to the stable one in the same
+// process. Each rollout has a name and a percentage; requests are assigned by hashing the
+// rollout name with the user ID, or the client address of anonymous requests, so a user keeps
+// seeing the same implementation while the percentage stays the same.
+package canary
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Values of the override header.
+const (
+	ForceCanary = "always"
+	ForceStable = "never"
+)
+
+// buckets is the resolution of percentages: 0.01%.
+const buckets = 10000
+
+// Router assigns requests to the canary or the stable implementation of each rollout.
+type Router struct {
+	percents map[string]float64
+	users    map[uint]bool
+	header   string
+}
+
+// NewRouter creates a router for rollouts (name to percentage of traffic). users always get
+// every canary, such as staff accounts. header, when not empty, is a request header that
+// forces the canary ("always") or the stable implementation ("never").
+func NewRouter(rollouts map[string]float64, users []uint, header string) *Router {
+	r := &Router{percents: rollouts, users: make(map[uint]bool, len(users)), header: header}
+	for _, id := range users {
+		r.users[id] = true
+	}
+	return r
+}
+
+// Header returns the override header, empty when overrides are disabled.
+func (r *Router) Header() string {
+	return r.header
+}
+
+// Percent returns the share of traffic of a rollout, 0 for unknown rollouts.
+func (r *Router) Percent(name string) float64 {
+	return r.percents[name]
+}
+
+// Request describes the request being routed.
+type Request struct {
+	// UserID is the authenticated user, 0 for anonymous requests.
+	UserID uint
+	// ClientIP keys anonymous requests.
+	ClientIP string
+	// Override is the value of the override header.
+	Override string
+}
+
+// UseCanary reports whether the canary of the rollout name serves req.
+func (r *Router) UseCanary(name string, req Request) bool {
+	if r.header != "" {
+		switch strings.ToLower(strings.TrimSpace(req.Override)) {
+		case ForceCanary:
+			return true
+		case ForceStable:
+			return false
+		}
+	}
+	if req.UserID != 0 && r.users[req.UserID] {
+		return true
+	}
+
+	percent := r.percents[name]
+	if percent <= 0 {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+	key := req.ClientIP
+	if req.UserID != 0 {
+		key = "user:" + strconv.FormatUint(uint64(req.UserID), 10)
+	}
+	return bucket(name, key) < uint32(percent*buckets/100)
+}
+
+// bucket maps a rollout and key to [0, buckets).
+func bucket(name, key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return h.Sum32() % buckets
+}
+
+// ParseRollouts parses "name=percent" entries (CANARY_ROLLOUTS), such as "profile-v2=5" or
+// "search-v2=0.5".
+func ParseRollouts(entries []string) (map[string]float64, error) {
+	rollouts := make(map[string]float64, len(entries))
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid canary rollout %q (want name=percent)", entry)
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%")), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid canary rollout %q: the percentage must be between 0 and 100", entry)
+		}
+		rollouts[name] = percent
+	}
+	return rollouts, nil
+}
+
+// ParseUsers parses user IDs (CANARY_USERS).
+func ParseUsers(entries []string) ([]uint, error) {
+	users := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		id, err := strconv.ParseUint(strings.TrimSpace(entry), 10, 64)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid canary user ID %q", entry)
+		}
+		users = append(users, uint(id))
+	}
+	return users, nil
+}
@@ -0,0 +1,93 @@
+package canary
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestUseCanary(t *testing.T) {
+	r := NewRouter(map[string]float64{"profile-v2": 20, "search-v2": 0, "export-v2": 100}, []uint{7}, "X-Canary")
+
+	canaries := 0
+	for id := uint(1000); id < 11000; id++ {
+		req := Request{UserID: id}
+		got := r.UseCanary("profile-v2", req)
+		if got {
+			canaries++
+		}
+		// Assignments are sticky
+		if r.UseCanary("profile-v2", req) != got {
+			t.Fatalf("user %d changed implementation", id)
+		}
+	}
+	if canaries < 1800 || canaries > 2200 {
+		t.Errorf("canary served %d of 10000 users, want about 20%%", canaries)
+	}
+
+	tests := []struct {
+		name    string
+		rollout string
+		req     Request
+		want    bool
+	}{
+		{"disabled rollout", "search-v2", Request{UserID: 1}, false},
+		{"unknown rollout", "missing", Request{UserID: 1}, false},
+		{"full rollout", "export-v2", Request{ClientIP: "203.0.113.1"}, true},
+		{"canary user", "search-v2", Request{UserID: 7}, true},
+		{"forced canary", "search-v2", Request{UserID: 1, Override: "Always"}, true},
+		{"forced stable", "export-v2", Request{UserID: 7, Override: "never"}, false},
+	}
+	for _, tt := range tests {
+		if got := r.UseCanary(tt.rollout, tt.req); got != tt.want {
+			t.Errorf("%s: UseCanary() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUseCanary_AnonymousByClientIP(t *testing.T) {
+	r := NewRouter(map[string]float64{"profile-v2": 50}, nil, "")
+
+	canaries := 0
+	for i := 0; i < 1000; i++ {
+		req := Request{ClientIP: "198.51.100." + strconv.Itoa(i%250) + ":" + strconv.Itoa(i)}
+		if r.UseCanary("profile-v2", req) {
+			canaries++
+		}
+	}
+	if canaries == 0 || canaries == 1000 {
+		t.Errorf("canary served %d of 1000 clients, want a share", canaries)
+	}
+
+	// Overrides are ignored without a header
+	if r.UseCanary("missing", Request{ClientIP: "198.51.100.1", Override: ForceCanary}) {
+		t.Error("override applied without a configured header")
+	}
+}
+
+func TestParseRollouts(t *testing.T) {
+	rollouts, err := ParseRollouts([]string{"profile-v2=5", " search-v2 = 0.5% "})
+	if err != nil {
+		t.Fatalf("ParseRollouts() error = %v", err)
+	}
+	if rollouts["profile-v2"] != 5 || rollouts["search-v2"] != 0.5 {
+		t.Errorf("ParseRollouts() = %v", rollouts)
+	}
+
+	for _, entry := range []string{"profile-v2", "=5", "profile-v2=101", "profile-v2=-1", "profile-v2=half"} {
+		if _, err := ParseRollouts([]string{entry}); err == nil {
+			t.Errorf("ParseRollouts(%q) succeeded", entry)
+		}
+	}
+}
+
+func TestParseUsers(t *testing.T) {
+	users, err := ParseUsers([]string{"1", " 42 "})
+	if err != nil || len(users) != 2 || users[1] != 42 {
+		t.Fatalf("ParseUsers() = %v, %v", users, err)
+	}
+	for _, entry := range []string{"0", "-1", "admin"} {
+		if _, err := ParseUsers([]string{entry}); err == nil {
+			t.Errorf("ParseUsers(%q) succeeded", entry)
+		}
+	}
+}
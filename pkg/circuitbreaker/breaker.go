@@ -0,0 +1,229 @@
+// Package circuitbreaker stops calling a failing dependency for a while so that it can
+// recover and callers fail fast instead of piling up on timeouts.
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned when a call is rejected because the breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a breaker.
+type State int
+
+// Breaker states.
+const (
+	// StateClosed lets every call through and counts consecutive failures.
+	StateClosed State = iota
+	// StateOpen rejects every call until the open timeout elapses.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through to test recovery.
+	StateHalfOpen
+)
+
+// String returns the lower-case state name.
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Settings configures a breaker. Zero values are replaced by the defaults.
+type Settings struct {
+	// FailureThreshold is the number of consecutive failures that opens the breaker. Default 5.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before probing. Default 30s.
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests is the number of successful probes needed to close again. Default 1.
+	HalfOpenMaxRequests int
+	// OnStateChange is called after every state transition, outside the breaker lock.
+	OnStateChange func(name string, from, to State)
+}
+
+// Breaker is a consecutive-failure circuit breaker. It is safe for concurrent use.
+type Breaker struct {
+	name     string
+	settings Settings
+	now      func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	inFlight  int
+	successes int
+}
+
+// New creates a closed breaker.
+func New(name string, settings Settings) *Breaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenMaxRequests <= 0 {
+		settings.HalfOpenMaxRequests = 1
+	}
+	return &Breaker{name: name, settings: settings, now: time.Now}
+}
+
+// Name returns the breaker name.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state, moving from open to half-open once the timeout elapsed.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	from, to := b.refresh()
+	state := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return state
+}
+
+// RetryAfter returns how long an open breaker will keep rejecting calls, or 0 when it is not open.
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != StateOpen {
+		return 0
+	}
+	if remaining := b.settings.OpenTimeout - b.now().Sub(b.openedAt); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be followed by
+// exactly one call to Success, Failure or Ignore.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	from, to := b.refresh()
+
+	var err error
+	switch b.state {
+	case StateOpen:
+		err = ErrOpen
+	case StateHalfOpen:
+		if b.inFlight >= b.settings.HalfOpenMaxRequests {
+			err = ErrOpen
+		} else {
+			b.inFlight++
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return err
+}
+
+// Success records a successful call.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	from, to := b.state, b.state
+	switch b.state {
+	case StateClosed:
+		b.failures = 0
+	case StateHalfOpen:
+		b.inFlight--
+		b.successes++
+		if b.successes >= b.settings.HalfOpenMaxRequests {
+			to = b.transition(StateClosed)
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// Failure records a failed call.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	from, to := b.state, b.state
+	switch b.state {
+	case StateClosed:
+		b.failures++
+		if b.failures >= b.settings.FailureThreshold {
+			to = b.transition(StateOpen)
+		}
+	case StateHalfOpen:
+		// A failed probe means the dependency has not recovered yet.
+		to = b.transition(StateOpen)
+	}
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// Ignore records an allowed call that ended without telling whether the dependency works,
+// such as one canceled by its caller. It neither resets nor adds to the failure count.
+func (b *Breaker) Ignore() {
+	b.mu.Lock()
+	if b.state == StateHalfOpen && b.inFlight > 0 {
+		b.inFlight--
+	}
+	b.mu.Unlock()
+}
+
+// Execute runs fn when the breaker allows it and records the outcome.
+func (b *Breaker) Execute(fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	if err != nil {
+		b.Failure()
+	} else {
+		b.Success()
+	}
+	return err
+}
+
+// Reset closes the breaker, e.g. when a health check confirms the dependency recovered.
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	from := b.state
+	to := b.transition(StateClosed)
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// refresh moves an open breaker to half-open once its timeout elapsed. Must hold b.mu.
+func (b *Breaker) refresh() (State, State) {
+	from := b.state
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.settings.OpenTimeout {
+		return from, b.transition(StateHalfOpen)
+	}
+	return from, from
+}
+
+// transition switches state and resets the counters. Must hold b.mu.
+func (b *Breaker) transition(to State) State {
+	b.state = to
+	b.failures = 0
+	b.inFlight = 0
+	b.successes = 0
+	if to == StateOpen {
+		b.openedAt = b.now()
+	}
+	return to
+}
+
+func (b *Breaker) notify(from, to State) {
+	if from != to && b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(b.name, from, to)
+	}
+}
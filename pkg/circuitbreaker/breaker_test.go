@@ -0,0 +1,130 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b := New("test", Settings{FailureThreshold: 3})
+	failing := func() error { return errors.New("down") }
+
+	for i := 0; i < 3; i++ {
+		_ = b.Execute(failing)
+	}
+
+	if b.State() != StateOpen {
+		t.Fatalf("expected open breaker, got %s", b.State())
+	}
+	if err := b.Execute(func() error { return nil }); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen, got %v", err)
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	b := New("test", Settings{FailureThreshold: 2})
+
+	_ = b.Execute(func() error { return errors.New("down") })
+	_ = b.Execute(func() error { return nil })
+	_ = b.Execute(func() error { return errors.New("down") })
+
+	if b.State() != StateClosed {
+		t.Fatalf("expected closed breaker, got %s", b.State())
+	}
+}
+
+func TestBreakerHalfOpenProbing(t *testing.T) {
+	now := time.Now()
+	var transitions []string
+	b := New("test", Settings{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+		OnStateChange: func(_ string, from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	now = now.Add(time.Minute)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected concurrent probe to be rejected, got %v", err)
+	}
+	b.Failure()
+	if b.State() != StateOpen {
+		t.Fatalf("expected failed probe to reopen the breaker, got %s", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("expected successful probe to close the breaker, got %s", b.State())
+	}
+
+	want := []string{"closed->open", "open->half_open", "half_open->open", "open->half_open", "half_open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("unexpected transitions %v", transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("unexpected transitions %v", transitions)
+		}
+	}
+}
+
+func TestBreakerIgnore(t *testing.T) {
+	now := time.Now()
+	b := New("test", Settings{FailureThreshold: 2, OpenTimeout: time.Minute})
+	b.now = func() time.Time { return now }
+
+	// An ignored call neither resets nor adds to the failures
+	b.Failure()
+	b.Ignore()
+	b.Ignore()
+	if b.State() != StateClosed {
+		t.Fatalf("expected ignored calls not to open the breaker, got %s", b.State())
+	}
+	b.Failure()
+	if b.State() != StateOpen {
+		t.Fatalf("expected the second failure to open the breaker, got %s", b.State())
+	}
+
+	// An ignored probe frees its slot for another one
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	b.Ignore()
+	if b.State() != StateHalfOpen {
+		t.Fatalf("expected the breaker to stay half-open, got %s", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected another probe to be allowed, got %v", err)
+	}
+}
+
+func TestRegistrySharesBreakersByName(t *testing.T) {
+	r := NewRegistry(Settings{FailureThreshold: 1})
+
+	r.Get("payments").Failure()
+
+	if r.Get("payments").State() != StateOpen {
+		t.Fatal("expected the same breaker to be returned for the same name")
+	}
+	if r.Get("email").State() != StateClosed {
+		t.Fatal("expected breakers to be independent per name")
+	}
+	if names := []string{r.All()[0].Name(), r.All()[1].Name()}; names[0] != "email" || names[1] != "payments" {
+		t.Fatalf("expected breakers sorted by name, got %v", names)
+	}
+	if r.Get("payments").RetryAfter() <= 0 {
+		t.Fatal("expected an open breaker to report a retry delay")
+	}
+}
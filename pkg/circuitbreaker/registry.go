@@ -0,0 +1,91 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+var stateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "circuit_breaker_state",
+	Help: "Circuit breaker state by dependency (0 closed, 1 open, 2 half-open).",
+}, []string{"name"})
+
+func init() {
+	metrics.Registry.MustRegister(stateGauge)
+}
+
+// Registry holds one breaker per dependency name so that every caller of a dependency
+// shares its state, and exposes the states to health checks and metrics.
+type Registry struct {
+	defaults Settings
+
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}
+
+// NewRegistry creates a registry whose lazily created breakers use defaults.
+func NewRegistry(defaults Settings) *Registry {
+	return &Registry{
+		defaults: defaults,
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+// Get returns the breaker for name, creating it with the default settings if needed.
+func (r *Registry) Get(name string) *Breaker {
+	r.mu.RLock()
+	b, ok := r.breakers[name]
+	r.mu.RUnlock()
+	if ok {
+		return b
+	}
+	return r.Register(name, r.defaults)
+}
+
+// Register returns the breaker for name, creating it with settings if it does not exist yet.
+func (r *Registry) Register(name string, settings Settings) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b, ok := r.breakers[name]; ok {
+		return b
+	}
+
+	onChange := settings.OnStateChange
+	settings.OnStateChange = func(name string, from, to State) {
+		stateGauge.WithLabelValues(name).Set(float64(to))
+		if onChange != nil {
+			onChange(name, from, to)
+		}
+	}
+
+	b := New(name, settings)
+	stateGauge.WithLabelValues(name).Set(float64(StateClosed))
+	r.breakers[name] = b
+	return b
+}
+
+// Lookup returns the breaker for name without creating it.
+func (r *Registry) Lookup(name string) (*Breaker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.breakers[name]
+	return b, ok
+}
+
+// All returns every registered breaker ordered by name.
+func (r *Registry) All() []*Breaker {
+	r.mu.RLock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(breakers, func(i, j int) bool { return breakers[i].Name() < breakers[j].Name() })
+	return breakers
+}
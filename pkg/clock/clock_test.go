@@ -0,0 +1,33 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	if got := c.Now(); !got.Equal(start) {
+		t.Fatalf("Now() = %v, want %v", got, start)
+	}
+	if got := c.Advance(90 * time.Second); !got.Equal(start.Add(90 * time.Second)) {
+		t.Errorf("Advance returned %v", got)
+	}
+	if got := c.Now(); !got.Equal(start.Add(90 * time.Second)) {
+		t.Errorf("Now() after Advance = %v", got)
+	}
+	c.Set(start)
+	if got := c.Now(); !got.Equal(start) {
+		t.Errorf("Now() after Set = %v, want %v", got, start)
+	}
+}
+
+func TestReal(t *testing.T) {
+	before := time.Now()
+	got := Real.Now()
+	if got.Before(before) || got.After(time.Now()) {
+		t.Errorf("Real.Now() = %v, want the current time", got)
+	}
+}
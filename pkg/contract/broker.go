@@ -0,0 +1,115 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// publishLink is the link of a broker pact that verification results are posted to.
+const publishLink = "pb:publish-verification-results"
+
+// Broker is a Pact Broker that consumers publish their pacts to.
+type Broker struct {
+	// URL is the base URL of the broker, such as https://pacts.example.com.
+	URL string
+	// Token authenticates requests as a bearer token, when set.
+	Token string
+	// Client sends the requests; http.DefaultClient when nil.
+	Client *http.Client
+}
+
+type link struct {
+	Href string `json:"href"`
+	Name string `json:"name,omitempty"`
+}
+
+// Fetch returns the latest pact of each consumer of provider.
+func (b *Broker) Fetch(ctx context.Context, provider string) ([]*Pact, error) {
+	var index struct {
+		Links struct {
+			Pacts []link `json:"pb:pacts"`
+		} `json:"_links"`
+	}
+	latest := strings.TrimSuffix(b.URL, "/") + "/pacts/provider/" + url.PathEscape(provider) + "/latest"
+	if err := b.do(ctx, http.MethodGet, latest, nil, &index); err != nil {
+		return nil, err
+	}
+
+	pacts := make([]*Pact, 0, len(index.Links.Pacts))
+	for _, l := range index.Links.Pacts {
+		var raw json.RawMessage
+		if err := b.do(ctx, http.MethodGet, l.Href, nil, &raw); err != nil {
+			return nil, err
+		}
+		p, err := Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pact %s: %w", l.Href, err)
+		}
+		pacts = append(pacts, p)
+	}
+	return pacts, nil
+}
+
+// Publish records the verification result of p, a pact fetched from the broker, against
+// version, the version of the provider that was verified (such as the commit SHA).
+func (b *Broker) Publish(ctx context.Context, p *Pact, success bool, version string) error {
+	var l link
+	if raw, ok := p.Links[publishLink]; !ok || json.Unmarshal(raw, &l) != nil || l.Href == "" {
+		return fmt.Errorf("pact %s has no %s link; was it fetched from the broker?", p.Name(), publishLink)
+	}
+	result := map[string]interface{}{
+		"success":                    success,
+		"providerApplicationVersion": version,
+	}
+	return b.do(ctx, http.MethodPost, l.Href, result, nil)
+}
+
+// do sends a request to the broker and decodes its JSON response into out, unless nil.
+func (b *Broker) do(ctx context.Context, method, target string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/hal+json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.Token)
+	}
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pact broker request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("pact broker %s %s: status %d", method, target, resp.StatusCode)
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid pact broker response from %s: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// provider answers like the API under test.
+var provider = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/items":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if r.URL.Query().Get("page") != "2" || r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":[{"id":7,"name":"a","tags":["x"]},{"id":8,"name":"b","tags":[]}],"total":2,"extra":true}`)
+	case "/echo":
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write(body)
+	}
+})
+
+func TestParseV2(t *testing.T) {
+	p, err := Parse([]byte(`{
+		"consumer": {"name": "web"}, "provider": {"name": "api"},
+		"interactions": [{
+			"description": "list", "providerState": "items exist",
+			"request": {"method": "get", "path": "/items", "query": "page=2", "headers": {"authorization": "Bearer t"}},
+			"response": {"status": 200, "body": {"data": [{"id": 1, "name": "z"}]},
+				"matchingRules": {"$.body.data": {"min": 1}, "$.body.data[*].tags": {"match": "type"}}}
+		}],
+		"metadata": {"pactSpecification": {"version": "2.0.0"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := p.Interactions[0]
+	if states := i.States(); len(states) != 1 || states[0].Name != "items exist" {
+		t.Fatalf("unexpected states %+v", states)
+	}
+	if i.Request.Query["page"][0] != "2" || i.Request.Headers["Authorization"][0] != "Bearer t" {
+		t.Fatalf("unexpected request %+v", i.Request)
+	}
+
+	mismatches, err := Verify(provider, i, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Fatalf("expected no mismatches, got %v", mismatches)
+	}
+}
+
+func TestParseRejectsV4(t *testing.T) {
+	_, err := Parse([]byte(`{"consumer": {"name": "web"}, "provider": {"name": "api"}, "metadata": {"pactSpecification": {"version": "4.0"}}}`))
+	if err == nil || !strings.Contains(err.Error(), "v4") {
+		t.Fatalf("expected an unsupported version error, got %v", err)
+	}
+}
+
+func TestVerifyReportsMismatches(t *testing.T) {
+	i := Interaction{
+		Request: Request{Method: http.MethodGet, Path: "/items", Query: Query{"page": {"2"}}},
+		Response: Response{
+			Status:  http.StatusOK,
+			Headers: Headers{"Content-Type": {"application/json;charset=utf-8"}, "X-Total": {"2"}},
+			Body:    json.RawMessage(`{"data": [{"id": "7", "name": "a", "missing": 1}], "total": 2.0}`),
+			MatchingRules: json.RawMessage(`{"body": {
+				"$.data[0].name": {"matchers": [{"match": "regex", "regex": "[0-9]+"}]}
+			}}`),
+		},
+	}
+	// Credentials come from the provider state
+	mismatches, err := Verify(provider, i, http.Header{"Authorization": {"Bearer t"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range mismatches {
+		got = append(got, m.String())
+	}
+	want := []string{
+		"header X-Total: missing",
+		"$.data: expected 1 elements, got 2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	// By type, every element is compared with the example
+	i.Response.Headers = nil
+	i.Response.MatchingRules = json.RawMessage(`{"body": {
+		"$.data": {"matchers": [{"match": "type", "min": 1}]},
+		"$.data[*].name": {"matchers": [{"match": "regex", "regex": "[0-9]+"}]}
+	}}`)
+	mismatches, err = Verify(provider, i, http.Header{"Authorization": {"Bearer t"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, m := range mismatches {
+		got = append(got, m.String())
+	}
+	want = []string{
+		`$.data[0].id: expected string, got number`,
+		`$.data[0].missing: missing`,
+		`$.data[0].name: "a" does not match "[0-9]+"`,
+		`$.data[1].id: expected string, got number`,
+		`$.data[1].missing: missing`,
+		`$.data[1].name: "b" does not match "[0-9]+"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestVerifySendsTextBodies(t *testing.T) {
+	i := Interaction{
+		Request: Request{
+			Method:  http.MethodPost,
+			Path:    "/echo",
+			Headers: Headers{"Content-Type": {"application/x-www-form-urlencoded"}},
+			Body:    json.RawMessage(`"a=1&b=2"`),
+		},
+		Response: Response{Status: http.StatusOK, Body: json.RawMessage(`"a=1&b=2"`)},
+	}
+	mismatches, err := Verify(provider, i, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Fatalf("expected no mismatches, got %v", mismatches)
+	}
+}
+
+func TestVerifyRejectsInvalidRules(t *testing.T) {
+	i := Interaction{
+		Request:  Request{Method: http.MethodGet, Path: "/items"},
+		Response: Response{Status: http.StatusOK, MatchingRules: json.RawMessage(`{"body": {"data": {"matchers": []}}}`)},
+	}
+	if _, err := Verify(provider, i, nil); err == nil {
+		t.Fatal("expected an error for a path without $")
+	}
+}
+
+func TestBrokerFetchAndPublish(t *testing.T) {
+	var published map[string]interface{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /pacts/provider/api/latest":
+			_, _ = io.WriteString(w, `{"_links": {"pb:pacts": [{"href": "`+server.URL+`/pacts/web"}]}}`)
+		case "GET /pacts/web":
+			_, _ = io.WriteString(w, `{"consumer": {"name": "web"}, "provider": {"name": "api"}, "interactions": [],
+				"_links": {"pb:publish-verification-results": {"href": "`+server.URL+`/results"}, "pb:consumer-versions": [{"href": "x"}]}}`)
+		case "POST /results":
+			_ = json.NewDecoder(r.Body).Decode(&published)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	broker := &Broker{URL: server.URL, Token: "secret"}
+	pacts, err := broker.Fetch(context.Background(), "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pacts) != 1 || pacts[0].Name() != "web-api" {
+		t.Fatalf("unexpected pacts %+v", pacts)
+	}
+	if err := broker.Publish(context.Background(), pacts[0], true, "abc123"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"success": true, "providerApplicationVersion": "abc123"}
+	if !reflect.DeepEqual(published, want) {
+		t.Fatalf("expected %v to be published, got %v", want, published)
+	}
+
+	if err := broker.Publish(context.Background(), &Pact{}, true, "abc123"); err == nil {
+		t.Fatal("expected an error for a pact without a publish link")
+	}
+}
@@ -0,0 +1,439 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Matcher is a matching rule: type, regex, integer, decimal, number, boolean, include or
+// equality.
+type Matcher struct {
+	Match string `json:"match"`
+	Regex string `json:"regex,omitempty"`
+	Value string `json:"value,omitempty"`
+	Min   *int   `json:"min,omitempty"`
+	Max   *int   `json:"max,omitempty"`
+}
+
+// rule is the matchers of a body path or header; combine is AND or OR.
+type rule struct {
+	path     []string
+	matchers []Matcher
+	combine  string
+}
+
+// rules are the matching rules of a response.
+type rules struct {
+	body   []rule
+	header map[string]rule
+}
+
+// parseRules reads the matching rules of a response, keyed by "$.body..." and "$.headers..."
+// paths in v2 pacts and grouped in body and header categories in v3 pacts.
+func parseRules(raw json.RawMessage) (rules, error) {
+	r := rules{header: map[string]rule{}}
+	if len(raw) == 0 {
+		return r, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return r, fmt.Errorf("invalid matching rules: %w", err)
+	}
+
+	for key, value := range fields {
+		switch {
+		case key == "$.body" || strings.HasPrefix(key, "$.body.") || strings.HasPrefix(key, "$.body["):
+			var m Matcher
+			if err := json.Unmarshal(value, &m); err != nil {
+				return r, fmt.Errorf("invalid matching rule %s: %w", key, err)
+			}
+			if err := r.addBody("$"+strings.TrimPrefix(key, "$.body"), []Matcher{m}, "AND"); err != nil {
+				return r, err
+			}
+		case strings.HasPrefix(key, "$.headers."):
+			var m Matcher
+			if err := json.Unmarshal(value, &m); err != nil {
+				return r, fmt.Errorf("invalid matching rule %s: %w", key, err)
+			}
+			r.addHeader(strings.TrimPrefix(key, "$.headers."), []Matcher{m}, "AND")
+		case key == "body" || key == "header":
+			var category map[string]struct {
+				Matchers []Matcher `json:"matchers"`
+				Combine  string    `json:"combine"`
+			}
+			if err := json.Unmarshal(value, &category); err != nil {
+				return r, fmt.Errorf("invalid %s matching rules: %w", key, err)
+			}
+			for path, c := range category {
+				if key == "header" {
+					r.addHeader(path, c.Matchers, c.Combine)
+				} else if err := r.addBody(path, c.Matchers, c.Combine); err != nil {
+					return r, err
+				}
+			}
+		}
+	}
+	// The most specific rule of a path wins: the one with the fewest wildcards
+	sort.SliceStable(r.body, func(i, j int) bool {
+		wi, wj := wildcards(r.body[i].path), wildcards(r.body[j].path)
+		if wi != wj {
+			return wi < wj
+		}
+		return strings.Join(r.body[i].path, "") < strings.Join(r.body[j].path, "")
+	})
+	return r, nil
+}
+
+func (r *rules) addBody(path string, matchers []Matcher, combine string) error {
+	tokens, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	r.body = append(r.body, rule{path: tokens, matchers: normalize(matchers), combine: combine})
+	return nil
+}
+
+func (r *rules) addHeader(name string, matchers []Matcher, combine string) {
+	r.header[http.CanonicalHeaderKey(name)] = rule{matchers: normalize(matchers), combine: combine}
+}
+
+// normalize fills in the match of v2 rules, which may only have a regex or bounds.
+func normalize(matchers []Matcher) []Matcher {
+	for i, m := range matchers {
+		if m.Match == "" {
+			if m.Regex != "" {
+				matchers[i].Match = "regex"
+			} else {
+				matchers[i].Match = "type"
+			}
+		}
+	}
+	return matchers
+}
+
+// find returns the rule of a body path, nil when there is none.
+func (r *rules) find(path []string) *rule {
+	for i, candidate := range r.body {
+		if len(candidate.path) != len(path) {
+			continue
+		}
+		matches := true
+		for j, token := range candidate.path {
+			if token != path[j] && !(token == ".*" && path[j][0] == '.') && !(token == "[*]" && path[j][0] == '[') {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return &r.body[i]
+		}
+	}
+	return nil
+}
+
+// parsePath splits a JSONPath such as $.data.items[*].id or $['a-b'] into tokens: ".name"
+// for object keys and "[n]" for array indexes, with ".*" and "[*]" as wildcards.
+func parsePath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid matching rule path %q: want $ first", path)
+	}
+	var tokens []string
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid matching rule path %q: empty key", path)
+			}
+			tokens = append(tokens, rest[:end+1])
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid matching rule path %q: unclosed [", path)
+			}
+			inner := rest[1:end]
+			if len(inner) >= 2 && inner[0] == '\'' && inner[len(inner)-1] == '\'' {
+				tokens = append(tokens, "."+inner[1:len(inner)-1])
+			} else if _, err := strconv.Atoi(inner); err == nil || inner == "*" {
+				tokens = append(tokens, "["+inner+"]")
+			} else {
+				return nil, fmt.Errorf("invalid matching rule path %q: bad index %q", path, inner)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid matching rule path %q", path)
+		}
+	}
+	return tokens, nil
+}
+
+func wildcards(path []string) int {
+	n := 0
+	for _, token := range path {
+		if token == ".*" || token == "[*]" {
+			n++
+		}
+	}
+	return n
+}
+
+// Mismatch is a difference between the response and the expected one.
+type Mismatch struct {
+	// Path is the JSONPath of the body value, "status", or "header NAME".
+	Path    string
+	Message string
+}
+
+func (m Mismatch) String() string {
+	return m.Path + ": " + m.Message
+}
+
+// comparison compares a response body with the expected one.
+type comparison struct {
+	rules      rules
+	mismatches []Mismatch
+}
+
+func (c *comparison) fail(path []string, format string, args ...interface{}) {
+	c.mismatches = append(c.mismatches, Mismatch{Path: "$" + strings.Join(path, ""), Message: fmt.Sprintf(format, args...)})
+}
+
+// compare checks actual against expected at path. Objects may have keys that are not
+// expected, as the consumer ignores them. A type rule applies to the values under its path
+// too, unless they have rules of their own.
+func (c *comparison) compare(path []string, expected, actual interface{}, cascaded *rule) {
+	r := c.rules.find(path)
+	if r == nil {
+		r = cascaded
+	}
+	byType := r != nil && r.has("type")
+
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			c.fail(path, "expected an object, got %s", typeName(actual))
+			return
+		}
+		keys := make([]string, 0, len(e))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := a[key]
+			if !ok {
+				c.fail(append(path, "."+key), "missing")
+				continue
+			}
+			c.compare(append(path, "."+key), e[key], value, cascade(r, byType))
+		}
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			c.fail(path, "expected an array, got %s", typeName(actual))
+			return
+		}
+		if !byType {
+			if len(a) != len(e) {
+				c.fail(path, "expected %d elements, got %d", len(e), len(a))
+				return
+			}
+			for i := range e {
+				c.compare(append(path, "["+strconv.Itoa(i)+"]"), e[i], a[i], nil)
+			}
+			return
+		}
+		for _, m := range r.matchers {
+			if m.Min != nil && len(a) < *m.Min {
+				c.fail(path, "expected at least %d elements, got %d", *m.Min, len(a))
+			}
+			if m.Max != nil && len(a) > *m.Max {
+				c.fail(path, "expected at most %d elements, got %d", *m.Max, len(a))
+			}
+		}
+		if len(e) == 0 {
+			return
+		}
+		// Every element matches the example
+		for i := range a {
+			c.compare(append(path, "["+strconv.Itoa(i)+"]"), e[0], a[i], r)
+		}
+	default:
+		if r == nil {
+			if !equal(expected, actual) {
+				c.fail(path, "expected %s, got %s", encode(expected), encode(actual))
+			}
+			return
+		}
+		if err := r.check(expected, actual); err != "" {
+			c.fail(path, "%s", err)
+		}
+	}
+}
+
+// cascade returns the rule that applies to the values under a path with rule r.
+func cascade(r *rule, byType bool) *rule {
+	if byType {
+		return r
+	}
+	return nil
+}
+
+func (r *rule) has(match string) bool {
+	for _, m := range r.matchers {
+		if m.Match == match {
+			return true
+		}
+	}
+	return false
+}
+
+// check applies the matchers of r to a value, returning why it does not match.
+func (r *rule) check(expected, actual interface{}) string {
+	var failures []string
+	for _, m := range r.matchers {
+		failure := m.check(expected, actual)
+		if failure == "" && strings.EqualFold(r.combine, "OR") {
+			return ""
+		}
+		if failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+	return strings.Join(failures, "; ")
+}
+
+func (m Matcher) check(expected, actual interface{}) string {
+	switch m.Match {
+	case "type":
+		if typeName(expected) != typeName(actual) {
+			return fmt.Sprintf("expected %s, got %s", typeName(expected), typeName(actual))
+		}
+	case "equality":
+		if !equal(expected, actual) {
+			return fmt.Sprintf("expected %s, got %s", encode(expected), encode(actual))
+		}
+	case "regex":
+		re, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return fmt.Sprintf("invalid regex %q", m.Regex)
+		}
+		value, ok := scalar(actual)
+		if !ok || !fullMatch(re, value) {
+			return fmt.Sprintf("%s does not match %q", encode(actual), m.Regex)
+		}
+	case "include":
+		value, ok := actual.(string)
+		if !ok || !strings.Contains(value, m.Value) {
+			return fmt.Sprintf("%s does not include %q", encode(actual), m.Value)
+		}
+	case "integer", "decimal", "number":
+		n, ok := actual.(json.Number)
+		isInteger := ok && !strings.ContainsAny(n.String(), ".eE")
+		if !ok || (m.Match == "integer" && !isInteger) || (m.Match == "decimal" && isInteger) {
+			return fmt.Sprintf("expected %s, got %s", article(m.Match), encode(actual))
+		}
+	case "boolean":
+		if _, ok := actual.(bool); !ok {
+			return fmt.Sprintf("expected a boolean, got %s", encode(actual))
+		}
+	default:
+		return fmt.Sprintf("unsupported matcher %q", m.Match)
+	}
+	return ""
+}
+
+// checkHeader compares a response header with the expected value, ignoring whitespace after
+// the separators of lists and parameters.
+func checkHeader(r *rule, expected string, actual []string) string {
+	value := strings.Join(actual, ", ")
+	if len(actual) == 0 {
+		return "missing"
+	}
+	if r != nil {
+		return r.check(expected, value)
+	}
+	if headerValue(expected) != headerValue(value) {
+		return fmt.Sprintf("expected %q, got %q", expected, value)
+	}
+	return ""
+}
+
+func headerValue(value string) string {
+	return strings.NewReplacer(", ", ",", "; ", ";").Replace(strings.TrimSpace(value))
+}
+
+func fullMatch(re *regexp.Regexp, value string) bool {
+	loc := re.FindStringIndex(value)
+	return loc != nil && loc[0] == 0 && loc[1] == len(value)
+}
+
+func scalar(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+func equal(expected, actual interface{}) bool {
+	if e, ok := expected.(json.Number); ok {
+		a, ok := actual.(json.Number)
+		if !ok {
+			return false
+		}
+		ef, err1 := e.Float64()
+		af, err2 := a.Float64()
+		return err1 == nil && err2 == nil && ef == af
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func article(match string) string {
+	if match == "integer" {
+		return "an integer"
+	}
+	return "a " + match
+}
+
+func encode(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,203 @@
+// Package contract verifies this service against the consumer contracts of its clients,
+// written as Pact files (specification v2 and v3, HTTP interactions): each interaction's
+// request is sent to the router and its response is checked against the expected one and
+// its matching rules. Pacts are read from files or fetched from a Pact Broker, which the
+// verification results can be published back to.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Pact is the contract between a consumer and a provider.
+type Pact struct {
+	Consumer     Pacticipant                `json:"consumer"`
+	Provider     Pacticipant                `json:"provider"`
+	Interactions []Interaction              `json:"interactions"`
+	Metadata     map[string]json.RawMessage `json:"metadata,omitempty"`
+	// Links are the links of a pact fetched from a Pact Broker.
+	Links map[string]json.RawMessage `json:"_links,omitempty"`
+}
+
+// Pacticipant is a consumer or provider.
+type Pacticipant struct {
+	Name string `json:"name"`
+}
+
+// ProviderState is a state the provider must be in before an interaction, such as "a user
+// exists", set up by loading fixtures.
+type ProviderState struct {
+	Name   string                 `json:"name"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// Interaction is a request a consumer sends and the response it expects.
+type Interaction struct {
+	Description string `json:"description"`
+	// ProviderState is the state of v2 pacts, ProviderStates those of v3 pacts.
+	ProviderState  string          `json:"providerState,omitempty"`
+	ProviderStates []ProviderState `json:"providerStates,omitempty"`
+	Request        Request         `json:"request"`
+	Response       Response        `json:"response"`
+}
+
+// States returns the provider states of the interaction, of either specification.
+func (i Interaction) States() []ProviderState {
+	if i.ProviderState != "" {
+		return append([]ProviderState{{Name: i.ProviderState}}, i.ProviderStates...)
+	}
+	return i.ProviderStates
+}
+
+// Request is the request of an interaction.
+type Request struct {
+	Method  string          `json:"method"`
+	Path    string          `json:"path"`
+	Query   Query           `json:"query,omitempty"`
+	Headers Headers         `json:"headers,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// Response is the expected response of an interaction. MatchingRules relax the comparison of
+// the body and headers, such as matching a token by type rather than value.
+type Response struct {
+	Status        int             `json:"status"`
+	Headers       Headers         `json:"headers,omitempty"`
+	Body          json.RawMessage `json:"body,omitempty"`
+	MatchingRules json.RawMessage `json:"matchingRules,omitempty"`
+}
+
+// Query is the query of a request: a string in v2 pacts, an object of values in v3 pacts.
+type Query url.Values
+
+// UnmarshalJSON accepts both forms of the query.
+func (q *Query) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		values, err := url.ParseQuery(raw)
+		if err != nil {
+			return fmt.Errorf("invalid query %q: %w", raw, err)
+		}
+		*q = Query(values)
+		return nil
+	}
+	var fields map[string]stringOrList
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("invalid query: want a string or an object")
+	}
+	*q = make(Query, len(fields))
+	for name, values := range fields {
+		(*q)[name] = values
+	}
+	return nil
+}
+
+// Headers are the headers of a request or response, each a string or a list of strings.
+type Headers http.Header
+
+// UnmarshalJSON accepts headers with a string or a list of values.
+func (h *Headers) UnmarshalJSON(data []byte) error {
+	var fields map[string]stringOrList
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("invalid headers: want an object")
+	}
+	*h = make(Headers, len(fields))
+	for name, values := range fields {
+		(*h)[http.CanonicalHeaderKey(name)] = values
+	}
+	return nil
+}
+
+type stringOrList []string
+
+func (s *stringOrList) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*s = []string{value}
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("want a string or a list of strings")
+	}
+	*s = values
+	return nil
+}
+
+// Parse decodes a pact, rejecting specifications other than v1 to v3: v4 pacts describe bodies
+// differently.
+func Parse(data []byte) (*Pact, error) {
+	var p Pact
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if version := p.SpecificationVersion(); version > 3 {
+		return nil, fmt.Errorf("pact specification v%d is not supported, want v2 or v3", version)
+	}
+	if p.Consumer.Name == "" || p.Provider.Name == "" {
+		return nil, fmt.Errorf("pact has no consumer or provider name")
+	}
+	return &p, nil
+}
+
+// SpecificationVersion returns the major version of the Pact specification of p, 2 when the
+// metadata does not say.
+func (p *Pact) SpecificationVersion() int {
+	var spec struct {
+		Version string `json:"version"`
+	}
+	for _, key := range []string{"pactSpecification", "pact-specification"} {
+		if raw, ok := p.Metadata[key]; ok && json.Unmarshal(raw, &spec) == nil && spec.Version != "" {
+			major, _, _ := strings.Cut(spec.Version, ".")
+			if version, err := strconv.Atoi(major); err == nil {
+				return version
+			}
+		}
+	}
+	return 2
+}
+
+// Name identifies the pact in test names and errors.
+func (p *Pact) Name() string {
+	return p.Consumer.Name + "-" + p.Provider.Name
+}
+
+// Load reads a pact file.
+func Load(path string) (*Pact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pact %s: %w", filepath.Base(path), err)
+	}
+	return p, nil
+}
+
+// LoadDir reads the pact files (*.json) of dir in name order.
+func LoadDir(dir string) ([]*Pact, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	pacts := make([]*Pact, 0, len(files))
+	for _, file := range files {
+		p, err := Load(file)
+		if err != nil {
+			return nil, err
+		}
+		pacts = append(pacts, p)
+	}
+	return pacts, nil
+}
@@ -0,0 +1,138 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Verify sends the request of i to h and returns how the response differs from the expected
+// one; none means the provider honors the interaction. header is added to the request,
+// replacing the pact's headers, such as the credentials of a user created for a provider
+// state. An error means the interaction itself is invalid.
+func Verify(h http.Handler, i Interaction, header http.Header) ([]Mismatch, error) {
+	req, err := newRequest(i.Request)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	r, err := parseRules(i.Response.MatchingRules)
+	if err != nil {
+		return nil, err
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	c := &comparison{rules: r}
+	if i.Response.Status != 0 && w.Code != i.Response.Status {
+		c.mismatches = append(c.mismatches, Mismatch{Path: "status", Message: fmt.Sprintf("expected %d, got %d", i.Response.Status, w.Code)})
+	}
+	names := make([]string, 0, len(i.Response.Headers))
+	for name := range i.Response.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var headerRule *rule
+		if hr, ok := r.header[name]; ok {
+			headerRule = &hr
+		}
+		expected := strings.Join(i.Response.Headers[name], ", ")
+		if failure := checkHeader(headerRule, expected, w.Header().Values(name)); failure != "" {
+			c.mismatches = append(c.mismatches, Mismatch{Path: "header " + name, Message: failure})
+		}
+	}
+	if len(i.Response.Body) > 0 {
+		c.compareBody(i.Response.Body, w.Body.Bytes(), w.Header().Get("Content-Type"))
+	}
+	return c.mismatches, nil
+}
+
+// compareBody compares a JSON body, or the text of a non-JSON one written as a string.
+func (c *comparison) compareBody(expectedBody, actualBody []byte, contentType string) {
+	expected, err := decode(expectedBody)
+	if err != nil {
+		c.fail(nil, "invalid expected body: %v", err)
+		return
+	}
+	if text, ok := expected.(string); ok && !isJSON(contentType) {
+		if text != string(actualBody) {
+			c.fail(nil, "expected body %q, got %q", text, actualBody)
+		}
+		return
+	}
+	actual, err := decode(actualBody)
+	if err != nil {
+		c.fail(nil, "response body is not JSON: %v", err)
+		return
+	}
+	c.compare(nil, expected, actual, nil)
+}
+
+// newRequest builds the request of an interaction. JSON bodies are sent as JSON; string bodies
+// of other content types, such as forms, as they are.
+func newRequest(r Request) (*http.Request, error) {
+	target := r.Path
+	if target == "" {
+		target = "/"
+	}
+	if len(r.Query) > 0 {
+		target += "?" + url.Values(r.Query).Encode()
+	}
+
+	var body io.Reader
+	contentType := http.Header(r.Headers).Get("Content-Type")
+	if len(r.Body) > 0 {
+		var text string
+		if !isJSON(contentType) && json.Unmarshal(r.Body, &text) == nil {
+			body = strings.NewReader(text)
+		} else {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, r.Body); err != nil {
+				return nil, fmt.Errorf("invalid request body: %w", err)
+			}
+			body = &compact
+			if contentType == "" {
+				contentType = "application/json"
+			}
+		}
+	}
+
+	method := strings.ToUpper(r.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	req := httptest.NewRequest(method, target, body)
+	for name, values := range r.Headers {
+		req.Header[name] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+func decode(data []byte) (interface{}, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
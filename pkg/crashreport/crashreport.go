@@ -0,0 +1,252 @@
+// Package crashreport persists reports of recovered panics to a storage.Storage, with the stack
+// of the panic, the request being served and a dump of every goroutine, so that crashes can be
+// investigated after the fact from the admin API rather than from scattered log lines.
+// Credentials and sensitive fields are redacted with the same rules as the logs.
+package crashreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/requestid"
+	"github.com/yeferson59/gin-template/pkg/storage"
+)
+
+// ErrNotFound is returned by Get when no report has the requested ID.
+var ErrNotFound = errors.New("crash report not found")
+
+// ErrBusy is returned by Save while another report is being written.
+var ErrBusy = errors.New("another crash report is being written")
+
+// maxGoroutineDump bounds the goroutine dump of a report.
+const maxGoroutineDump = 1 << 20
+
+// Request describes the request being served when the panic happened.
+type Request struct {
+	RequestID string      `json:"request_id,omitempty"`
+	Method    string      `json:"method"`
+	URL       string      `json:"url"`
+	Route     string      `json:"route,omitempty"`
+	ClientIP  string      `json:"client_ip,omitempty"`
+	UserID    uint        `json:"user_id,omitempty"`
+	Header    http.Header `json:"header,omitempty"`
+}
+
+// Summary identifies a report in listings.
+type Summary struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Panic     string    `json:"panic"`
+	Host      string    `json:"host,omitempty"`
+	GoVersion string    `json:"go_version"`
+	Request   Request   `json:"request"`
+}
+
+// Report is a recovered panic.
+type Report struct {
+	Summary
+	// Stack is the stack of the goroutine that panicked and Goroutines those of every
+	// goroutine, truncated to 1 MiB.
+	Stack      string `json:"stack"`
+	Goroutines string `json:"goroutines"`
+}
+
+// New creates a report of the panic recovered, with the stack of the current goroutine and a
+// dump of every goroutine. req may be nil for panics outside requests.
+func New(recovered interface{}, stack []byte, req *http.Request) *Report {
+	host, _ := os.Hostname()
+	r := &Report{
+		Summary: Summary{
+			ID:        requestid.New(),
+			Time:      time.Now().UTC(),
+			Panic:     fmt.Sprint(recovered),
+			Host:      host,
+			GoVersion: runtime.Version(),
+		},
+		Stack:      string(stack),
+		Goroutines: goroutines(),
+	}
+	if req != nil {
+		r.Request = Request{Method: req.Method, URL: req.URL.RequestURI(), Header: req.Header.Clone()}
+	}
+	return r
+}
+
+// goroutines returns the stacks of every goroutine.
+func goroutines() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxGoroutineDump {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// Store keeps reports under keys starting with a prefix, named by their ID, which sorts by
+// time.
+type Store struct {
+	store    storage.Storage
+	prefix   string
+	max      int
+	redactor *logger.Redactor
+	// saving lets a single report be written at a time, so that a handler panicking on every
+	// request does not turn into a flood of goroutine dumps.
+	saving atomic.Bool
+}
+
+// NewStore creates a store keeping the max newest reports (0 for no limit) in store. Reports
+// are sanitized with redactor, or the default log redaction when nil.
+func NewStore(store storage.Storage, prefix string, max int, redactor *logger.Redactor) *Store {
+	if redactor == nil {
+		redactor = logger.NewRedactor(nil, true)
+	}
+	return &Store{store: store, prefix: prefix, max: max, redactor: redactor}
+}
+
+// Save redacts r, writes it and deletes the reports beyond the limit. It returns ErrBusy,
+// without writing r, while another report is being saved.
+func (s *Store) Save(ctx context.Context, r *Report) error {
+	if !s.saving.CompareAndSwap(false, true) {
+		return ErrBusy
+	}
+	defer s.saving.Store(false)
+
+	s.redact(r)
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Put(ctx, s.key(r.ID), bytes.NewReader(body)); err != nil {
+		return fmt.Errorf("failed to store the crash report: %w", err)
+	}
+	return s.prune(ctx)
+}
+
+// redact removes credentials from the panic value, the URL and the headers.
+func (s *Store) redact(r *Report) {
+	r.Panic = s.redactor.String(r.Panic)
+	if u, err := url.ParseRequestURI(r.Request.URL); err == nil && u.RawQuery != "" {
+		query := u.Query()
+		for name, values := range query {
+			for i, value := range values {
+				if s.redactor.SensitiveField(name) {
+					values[i] = logger.Redacted
+				} else {
+					values[i] = s.redactor.String(value)
+				}
+			}
+		}
+		r.Request.URL = u.Path + "?" + query.Encode()
+	}
+	for name, values := range r.Request.Header {
+		for i, value := range values {
+			if s.redactor.SensitiveField(name) {
+				values[i] = logger.Redacted
+			} else {
+				values[i] = s.redactor.String(value)
+			}
+		}
+	}
+}
+
+// prune deletes the oldest reports beyond the limit.
+func (s *Store) prune(ctx context.Context) error {
+	if s.max <= 0 {
+		return nil
+	}
+	keys, err := s.keys(ctx)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(keys)-s.max; i++ {
+		if err := s.store.Delete(ctx, keys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the summaries of the limit newest reports (every report when limit is 0),
+// newest first.
+func (s *Store) List(ctx context.Context, limit int) ([]Summary, error) {
+	keys, err := s.keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(keys) > limit {
+		keys = keys[len(keys)-limit:]
+	}
+
+	summaries := make([]Summary, 0, len(keys))
+	for i := len(keys) - 1; i >= 0; i-- {
+		r, err := s.get(ctx, keys[i])
+		if errors.Is(err, ErrNotFound) {
+			// Pruned by another instance since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, r.Summary)
+	}
+	return summaries, nil
+}
+
+// Get returns the report with id, or ErrNotFound.
+func (s *Store) Get(ctx context.Context, id string) (*Report, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrNotFound
+	}
+	return s.get(ctx, s.key(id))
+}
+
+func (s *Store) get(ctx context.Context, key string) (*Report, error) {
+	rc, err := s.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	var r Report
+	if err := json.NewDecoder(rc).Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid crash report %s: %w", key, err)
+	}
+	return &r, nil
+}
+
+// keys returns the keys of the reports, oldest first.
+func (s *Store) keys(ctx context.Context) ([]string, error) {
+	objects, err := s.store.List(ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		if strings.HasSuffix(o.Key, ".json") && !strings.Contains(strings.TrimPrefix(o.Key, s.prefix), "/") {
+			keys = append(keys, o.Key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *Store) key(id string) string {
+	return s.prefix + id + ".json"
+}
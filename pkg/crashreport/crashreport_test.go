@@ -0,0 +1,95 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/storage"
+)
+
+func newReport(t *testing.T, recovered interface{}) *Report {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/users/me?token=abc&page=2", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Accept", "application/json")
+	return New(recovered, []byte("goroutine 1 [running]:"), req)
+}
+
+func TestSaveRedactsAndGet(t *testing.T) {
+	store := NewStore(storage.NewLocal(t.TempDir()), "crashes/", 0, nil)
+	report := newReport(t, "boom for jane@example.com")
+	if err := store.Save(context.Background(), report); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Get(context.Background(), report.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Panic != "boom for j***@example.com" {
+		t.Errorf("panic = %q, want the email masked", got.Panic)
+	}
+	if v := got.Request.Header.Get("Authorization"); v != logger.Redacted {
+		t.Errorf("Authorization = %q, want it redacted", v)
+	}
+	if got.Request.Header.Get("Accept") != "application/json" {
+		t.Errorf("headers = %v, want the others kept", got.Request.Header)
+	}
+	if strings.Contains(got.Request.URL, "abc") || !strings.Contains(got.Request.URL, "page=2") {
+		t.Errorf("url = %q, want the token redacted", got.Request.URL)
+	}
+	if got.Stack == "" || !strings.Contains(got.Goroutines, "goroutine") {
+		t.Errorf("report has no stacks: %+v", got)
+	}
+
+	for _, id := range []string{"missing", "../../etc/passwd", "0192d5a8-0000-7000-8000-000000000000"} {
+		if _, err := store.Get(context.Background(), id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestListNewestFirstAndPrune(t *testing.T) {
+	store := NewStore(storage.NewLocal(t.TempDir()), "", 3, nil)
+	var ids []string
+	for _, value := range []string{"first", "second", "third", "fourth"} {
+		report := newReport(t, value)
+		if err := store.Save(context.Background(), report); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, report.ID)
+	}
+
+	summaries, err := store.List(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var panics []string
+	for _, s := range summaries {
+		panics = append(panics, s.Panic)
+	}
+	if got := strings.Join(panics, ","); got != "fourth,third,second" {
+		t.Errorf("List = %s, want the 3 newest, newest first", got)
+	}
+	if _, err := store.Get(context.Background(), ids[0]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("the oldest report was not pruned: %v", err)
+	}
+
+	summaries, err = store.List(context.Background(), 1)
+	if err != nil || len(summaries) != 1 || summaries[0].ID != ids[3] {
+		t.Errorf("List(1) = %+v, %v; want the newest", summaries, err)
+	}
+}
+
+func TestSaveIsBusyWhileSaving(t *testing.T) {
+	store := NewStore(storage.NewLocal(t.TempDir()), "", 0, nil)
+	store.saving.Store(true)
+	if err := store.Save(context.Background(), newReport(t, "boom")); !errors.Is(err, ErrBusy) {
+		t.Fatalf("Save = %v, want ErrBusy", err)
+	}
+}
@@ -0,0 +1,135 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestKeyring_EncryptDecryptAndRotate(t *testing.T) {
+	old, err := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := old.Encrypt([]byte("+57 300 000 0000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(value) || bytes.Contains([]byte(value), []byte("300")) {
+		t.Fatalf("value does not look encrypted: %s", value)
+	}
+
+	// k2 becomes primary; k1 stays for decryption.
+	kr, err := NewKeyring("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := kr.Decrypt(value); err != nil || string(got) != "+57 300 000 0000" {
+		t.Fatalf("Decrypt = %q, %v", got, err)
+	}
+	if !kr.NeedsRotation(value) {
+		t.Fatal("expected a value under k1 to need rotation")
+	}
+
+	rotated, err := kr.Rotate(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.NeedsRotation(rotated) {
+		t.Fatal("expected the rotated value to use the primary key")
+	}
+	onlyNew, _ := NewKeyring("k2", map[string][]byte{"k2": testKey(2)})
+	if got, err := onlyNew.Decrypt(rotated); err != nil || string(got) != "+57 300 000 0000" {
+		t.Fatalf("Decrypt after rotation = %q, %v", got, err)
+	}
+	if _, err := onlyNew.Decrypt(value); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey without k1, got %v", err)
+	}
+}
+
+func TestKeyring_RejectsTampering(t *testing.T) {
+	kr, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	value, _ := kr.Encrypt([]byte("secret"))
+
+	tampered := []byte(value)
+	tampered[len(tampered)-2] ^= 1
+	if _, err := kr.Decrypt(string(tampered)); err == nil {
+		t.Fatal("expected tampered ciphertext to fail")
+	}
+	if _, err := kr.Decrypt("plain text"); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("expected ErrMalformed, got %v", err)
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(testKey(3))
+	kr, err := ParseKeyring("2026:" + key + ", 2025:" + key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.PrimaryKeyID() != "2026" {
+		t.Fatalf("primary = %q, want the first key", kr.PrimaryKeyID())
+	}
+
+	for _, spec := range []string{"", "nokey", "k1:not-base64!", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), "k1:" + key + ",k1:" + key} {
+		if _, err := ParseKeyring(spec); err == nil {
+			t.Errorf("ParseKeyring(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+type contact struct {
+	ID    uint
+	Phone string  `gorm:"serializer:encrypted"`
+	Notes *string `gorm:"serializer:encrypted"`
+}
+
+func TestSerializer(t *testing.T) {
+	kr, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	SetKeyring(kr)
+	t.Cleanup(func() { SetKeyring(nil) })
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&contact{}); err != nil {
+		t.Fatal(err)
+	}
+
+	notes := "call after 5pm"
+	if err := db.Create(&contact{Phone: "+57 300 000 0000", Notes: &notes}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// A row written before the column was encrypted is still readable.
+	if err := db.Exec("INSERT INTO contacts (phone) VALUES (?)", "+1 555 0100").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var raw string
+	if err := db.Raw("SELECT phone FROM contacts WHERE id = 1").Scan(&raw).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(raw) {
+		t.Fatalf("stored value is not encrypted: %q", raw)
+	}
+
+	var got []contact
+	if err := db.Order("id").Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Phone != "+57 300 000 0000" || got[0].Notes == nil || *got[0].Notes != notes {
+		t.Fatalf("decrypted row = %+v", got[0])
+	}
+	if got[1].Phone != "+1 555 0100" || got[1].Notes != nil {
+		t.Fatalf("plaintext row = %+v", got[1])
+	}
+}
@@ -0,0 +1,246 @@
+// Package crypto provides envelope encryption for sensitive values stored in the database.
+//
+// Every value is encrypted with its own random data key (AES-256-GCM), and the data key is
+// encrypted ("wrapped") with a key encryption key from a Keyring. The stored value records the
+// ID of that key, so the keyring can hold old keys for decryption while new values use the
+// primary key, and rotating a value only re-wraps its data key.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the size in bytes of key encryption keys and data keys (AES-256).
+const KeySize = 32
+
+// prefix marks values produced by Encrypt, followed by the format version.
+const prefix = "enc:v1:"
+
+var (
+	// ErrUnknownKey is returned when a value was encrypted with a key missing from the keyring.
+	ErrUnknownKey = errors.New("crypto: unknown key ID")
+	// ErrMalformed is returned for values that were not produced by Encrypt.
+	ErrMalformed = errors.New("crypto: malformed encrypted value")
+)
+
+// Keyring holds the key encryption keys. The primary key encrypts new values; every key can
+// decrypt.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring from key IDs mapped to 32-byte keys. primary must be one of them.
+// Key IDs must not contain ':'.
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	kr := &Keyring{primary: primary, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("crypto: invalid key ID %q", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %q: %w", id, err)
+		}
+		kr.keys[id] = aead
+	}
+	if _, ok := kr.keys[primary]; !ok {
+		return nil, fmt.Errorf("crypto: primary key %q is not in the keyring", primary)
+	}
+	return kr, nil
+}
+
+// ParseKeyring parses a comma-separated list of id:base64key pairs, as found in the
+// ENCRYPTION_KEYS variable. The first key is the primary one.
+func ParseKeyring(spec string) (*Keyring, error) {
+	var primary string
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("crypto: key %q must be written as id:base64key", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %q is not valid base64: %w", id, err)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("crypto: duplicate key ID %q", id)
+		}
+		if primary == "" {
+			primary = id
+		}
+		keys[id] = key
+	}
+	if primary == "" {
+		return nil, errors.New("crypto: no keys configured")
+	}
+	return NewKeyring(primary, keys)
+}
+
+// GenerateKey returns a new random key, base64 encoded for ENCRYPTION_KEYS.
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// PrimaryKeyID returns the ID of the key used for new values.
+func (kr *Keyring) PrimaryKeyID() string {
+	return kr.primary
+}
+
+// Encrypt encrypts plaintext under a new data key wrapped with the primary key. The result is
+// printable text of the form enc:v1:<key id>:<wrapped data key>:<ciphertext>.
+func (kr *Keyring) Encrypt(plaintext []byte) (string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(kr.keys[kr.primary], dataKey, []byte(kr.primary))
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataAEAD, plaintext, nil)
+	if err != nil {
+		return "", err
+	}
+	return format(kr.primary, wrapped, ciphertext), nil
+}
+
+// Decrypt returns the plaintext of a value produced by Encrypt with any key in the keyring.
+func (kr *Keyring) Decrypt(value string) ([]byte, error) {
+	keyID, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := kr.unwrap(keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(dataAEAD, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: decrypt value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// NeedsRotation reports whether value was encrypted with a key other than the primary one.
+func (kr *Keyring) NeedsRotation(value string) bool {
+	keyID, _, _, err := parse(value)
+	return err == nil && keyID != kr.primary
+}
+
+// Rotate re-wraps the data key of value with the primary key. The ciphertext itself is kept,
+// so rotating is cheap regardless of the value size.
+func (kr *Keyring) Rotate(value string) (string, error) {
+	keyID, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	if keyID == kr.primary {
+		return value, nil
+	}
+
+	dataKey, err := kr.unwrap(keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+	rewrapped, err := seal(kr.keys[kr.primary], dataKey, []byte(kr.primary))
+	if err != nil {
+		return "", err
+	}
+	return format(kr.primary, rewrapped, ciphertext), nil
+}
+
+// IsEncrypted reports whether value looks like the output of Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// unwrap decrypts a data key with the key encryption key keyID.
+func (kr *Keyring) unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := kr.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	// The key ID is authenticated data, so a wrapped key cannot be moved to another key ID.
+	dataKey, err := open(aead, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("crypto: unwrap data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce prepended to the result.
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
+
+func format(keyID string, wrapped, ciphertext []byte) string {
+	enc := base64.RawStdEncoding
+	return prefix + keyID + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(ciphertext)
+}
+
+func parse(value string) (keyID string, wrapped, ciphertext []byte, err error) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return "", nil, nil, ErrMalformed
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+	enc := base64.RawStdEncoding
+	if wrapped, err = enc.DecodeString(parts[1]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	if ciphertext, err = enc.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	return parts[0], wrapped, ciphertext, nil
+}
@@ -0,0 +1,121 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the name of the GORM serializer that encrypts a field with the keyring
+// set by SetKeyring:
+//
+//	Phone string `gorm:"serializer:encrypted" json:"phone"`
+//
+// Supported field types are string, *string, and []byte. Encrypted columns cannot be searched
+// or indexed by value, and need a text type large enough for the envelope (about 130 bytes
+// plus 4/3 of the plaintext).
+const SerializerName = "encrypted"
+
+// ErrNoKeyring is returned by the serializer when no keyring has been configured.
+var ErrNoKeyring = errors.New("crypto: no encryption keys configured (set ENCRYPTION_KEYS)")
+
+var (
+	keyring   *Keyring
+	keyringMu sync.RWMutex
+)
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// SetKeyring sets the keyring used by the encrypted GORM serializer. Passing nil disables it.
+func SetKeyring(kr *Keyring) {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	keyring = kr
+}
+
+// CurrentKeyring returns the keyring set by SetKeyring, or nil.
+func CurrentKeyring() *Keyring {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+	return keyring
+}
+
+// Serializer implements schema.SerializerInterface. Values that are not encrypted yet are read
+// as plaintext, so an existing column can be switched to the serializer and is encrypted as
+// rows are written again.
+type Serializer struct{}
+
+// Scan implements schema.SerializerInterface.
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+		return nil
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("crypto: cannot decrypt %T into field %s", dbValue, field.Name)
+	}
+
+	plaintext := []byte(stored)
+	if IsEncrypted(stored) {
+		kr := CurrentKeyring()
+		if kr == nil {
+			return ErrNoKeyring
+		}
+		var err error
+		if plaintext, err = kr.Decrypt(stored); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+	}
+
+	fieldValue := reflect.New(field.FieldType)
+	switch target := fieldValue.Interface().(type) {
+	case *string:
+		*target = string(plaintext)
+	case **string:
+		s := string(plaintext)
+		*target = &s
+	case *[]byte:
+		*target = plaintext
+	default:
+		return fmt.Errorf("crypto: unsupported type %s for encrypted field %s", field.FieldType, field.Name)
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerInterface.
+func (Serializer) Value(_ context.Context, field *schema.Field, _ reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext []byte
+	switch v := fieldValue.(type) {
+	case string:
+		plaintext = []byte(v)
+	case *string:
+		if v == nil {
+			return nil, nil
+		}
+		plaintext = []byte(*v)
+	case []byte:
+		if v == nil {
+			return nil, nil
+		}
+		plaintext = v
+	default:
+		return nil, fmt.Errorf("crypto: unsupported type %T for encrypted field %s", fieldValue, field.Name)
+	}
+
+	kr := CurrentKeyring()
+	if kr == nil {
+		return nil, ErrNoKeyring
+	}
+	return kr.Encrypt(plaintext)
+}
@@ -0,0 +1,178 @@
+// Package events provides an in-process bus for domain events such as "user.created".
+package events
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// Event is something that happened to an entity.
+type Event struct {
+	// Name is the entity and what happened to it, such as "user.created".
+	Name string `json:"name"`
+	// Entity is the kind of entity, such as the table "users", and EntityID its ID, or "" when
+	// the event covers every row matching a condition.
+	Entity   string `json:"entity"`
+	EntityID string `json:"entity_id,omitempty"`
+	// ActorID is the user who caused the event, or 0 when unknown (see WithActor).
+	ActorID   uint   `json:"actor_id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Changes maps the fields set by the event to their new values.
+	Changes    map[string]interface{} `json:"changes,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// Handler handles a published event. It runs synchronously in the publisher's goroutine, so it
+// must be quick; hand slow work to a job queue.
+type Handler func(ctx context.Context, e Event)
+
+// Bus delivers events to the handlers subscribed to them. It is safe for concurrent use.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []subscription
+}
+
+type subscription struct {
+	pattern string
+	handler Handler
+}
+
+// NewBus creates a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls h for every event matching pattern: an event name such as "user.created",
+// every event of an entity with "user.*", or every event with "*".
+func (b *Bus) Subscribe(pattern string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, subscription{pattern: pattern, handler: h})
+}
+
+// Publish delivers e to its subscribers, in the order they subscribed. A panicking handler is
+// logged and does not stop the others.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, s := range handlers {
+		if match(s.pattern, e.Name) {
+			deliver(ctx, s.handler, e)
+		}
+	}
+}
+
+// PublishAfter publishes e once the work deferred in ctx is done (see Defer), or right away
+// when nothing is deferred.
+func (b *Bus) PublishAfter(ctx context.Context, e Event) {
+	q, _ := ctx.Value(queueKey{}).(*queue)
+	if q == nil {
+		b.Publish(ctx, e)
+		return
+	}
+	q.mu.Lock()
+	q.pending = append(q.pending, pending{bus: b, event: e})
+	q.mu.Unlock()
+}
+
+// After runs fn once the work deferred in ctx is done successfully (see Defer), or right away
+// when nothing is deferred. It lets code outside the bus, such as caches, act on commits.
+func After(ctx context.Context, fn func()) {
+	q, _ := ctx.Value(queueKey{}).(*queue)
+	if q == nil {
+		fn()
+		return
+	}
+	q.mu.Lock()
+	q.pending = append(q.pending, pending{fn: fn})
+	q.mu.Unlock()
+}
+
+func deliver(ctx context.Context, h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.WithFields(map[string]interface{}{"event": e.Name, "panic": r}).
+				Error("Event handler panicked")
+		}
+	}()
+	h(ctx, e)
+}
+
+func match(pattern, name string) bool {
+	if pattern == "*" || pattern == name {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, ".*")
+	return ok && strings.HasPrefix(name, prefix+".")
+}
+
+type queueKey struct{}
+
+// queue holds the events published with PublishAfter until the deferred work is done.
+type queue struct {
+	mu      sync.Mutex
+	pending []pending
+}
+
+// pending is an event to publish on bus, or a function registered with After.
+type pending struct {
+	bus   *Bus
+	event Event
+	fn    func()
+}
+
+// Defer returns a context in which PublishAfter holds events back, and a function that
+// publishes them (done(true)), such as after a transaction commits, or discards them
+// (done(false)). When ctx already defers events, they stay with the outer work and done does
+// nothing.
+func Defer(ctx context.Context) (context.Context, func(publish bool)) {
+	if q, _ := ctx.Value(queueKey{}).(*queue); q != nil {
+		return ctx, func(bool) {}
+	}
+	q := &queue{}
+	parent := ctx
+	return context.WithValue(ctx, queueKey{}, q), func(publish bool) {
+		q.mu.Lock()
+		held := q.pending
+		q.pending = nil
+		q.mu.Unlock()
+		if !publish {
+			return
+		}
+		for _, p := range held {
+			if p.fn != nil {
+				p.fn()
+				continue
+			}
+			p.bus.Publish(parent, p.event)
+		}
+	}
+}
+
+// Join returns ctx with the events deferred in from, if any, held back with them.
+func Join(ctx, from context.Context) context.Context {
+	if q, _ := from.Value(queueKey{}).(*queue); q != nil {
+		return context.WithValue(ctx, queueKey{}, q)
+	}
+	return ctx
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx naming the user whose request causes the events published
+// with it.
+func WithActor(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// ActorFromContext returns the user stored with WithActor, or 0 when there is none.
+func ActorFromContext(ctx context.Context) uint {
+	id, _ := ctx.Value(actorKey{}).(uint)
+	return id
+}
@@ -0,0 +1,125 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func record(b *Bus, pattern string) *[]string {
+	var got []string
+	b.Subscribe(pattern, func(_ context.Context, e Event) {
+		got = append(got, e.Name)
+	})
+	return &got
+}
+
+func TestPublishMatchesPatterns(t *testing.T) {
+	b := NewBus()
+	exact := record(b, "user.created")
+	entity := record(b, "user.*")
+	all := record(b, "*")
+
+	for _, name := range []string{"user.created", "user.deleted", "users.created", "organization.created"} {
+		b.Publish(context.Background(), Event{Name: name})
+	}
+
+	if !slices.Equal(*exact, []string{"user.created"}) {
+		t.Errorf("exact subscriber got %v", *exact)
+	}
+	if !slices.Equal(*entity, []string{"user.created", "user.deleted"}) {
+		t.Errorf("entity subscriber got %v", *entity)
+	}
+	if len(*all) != 4 {
+		t.Errorf("wildcard subscriber got %v", *all)
+	}
+}
+
+func TestPublishRecoversPanics(t *testing.T) {
+	b := NewBus()
+	b.Subscribe("*", func(context.Context, Event) { panic("boom") })
+	got := record(b, "*")
+
+	b.Publish(context.Background(), Event{Name: "user.created"})
+	if len(*got) != 1 {
+		t.Fatal("expected the handler after a panicking one to run")
+	}
+}
+
+func TestDefer(t *testing.T) {
+	b := NewBus()
+	got := record(b, "*")
+
+	ctx, done := Defer(context.Background())
+	b.PublishAfter(ctx, Event{Name: "user.created"})
+	if len(*got) != 0 {
+		t.Fatal("expected the event to be held back")
+	}
+	done(true)
+	if !slices.Equal(*got, []string{"user.created"}) {
+		t.Fatalf("expected the event once done, got %v", *got)
+	}
+
+	ctx, done = Defer(context.Background())
+	b.PublishAfter(ctx, Event{Name: "user.deleted"})
+	done(false)
+	if len(*got) != 1 {
+		t.Fatalf("expected the event to be discarded, got %v", *got)
+	}
+
+	// Without deferral events are published right away
+	b.PublishAfter(context.Background(), Event{Name: "user.updated"})
+	if len(*got) != 2 {
+		t.Fatalf("expected the event to be published, got %v", *got)
+	}
+}
+
+func TestDeferNestedAndJoin(t *testing.T) {
+	b := NewBus()
+	got := record(b, "*")
+
+	outer, outerDone := Defer(context.Background())
+	inner, innerDone := Defer(outer)
+	b.PublishAfter(inner, Event{Name: "user.created"})
+	innerDone(true)
+	if len(*got) != 0 {
+		t.Fatal("expected the inner work to leave the event to the outer one")
+	}
+
+	joined := Join(context.Background(), outer)
+	b.PublishAfter(joined, Event{Name: "user.updated"})
+	outerDone(true)
+	if !slices.Equal(*got, []string{"user.created", "user.updated"}) {
+		t.Fatalf("expected both events once the outer work is done, got %v", *got)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	var ran int
+	ctx, done := Defer(context.Background())
+	After(ctx, func() { ran++ })
+	if ran != 0 {
+		t.Fatal("expected the function to wait for the deferred work")
+	}
+	done(true)
+	if ran != 1 {
+		t.Fatalf("expected the function to run once done, ran %d times", ran)
+	}
+
+	ctx, done = Defer(context.Background())
+	After(ctx, func() { ran++ })
+	done(false)
+	After(context.Background(), func() { ran++ })
+	if ran != 2 {
+		t.Fatalf("expected the function to be dropped on failure and run right away otherwise, ran %d times", ran)
+	}
+}
+
+func TestActor(t *testing.T) {
+	if ActorFromContext(context.Background()) != 0 {
+		t.Fatal("expected no actor")
+	}
+	if got := ActorFromContext(WithActor(context.Background(), 9)); got != 9 {
+		t.Fatalf("ActorFromContext() = %d, want 9", got)
+	}
+}
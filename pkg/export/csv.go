@@ -0,0 +1,41 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// CSVWriter streams rows as RFC 4180 CSV.
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter creates a CSV writer.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+// WriteRow writes a single row. Cells that spreadsheet applications would evaluate as
+// formulas are prefixed with a quote to prevent CSV injection.
+func (cw *CSVWriter) WriteRow(cells []string) error {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = escapeFormula(cell)
+	}
+	return cw.w.Write(escaped)
+}
+
+// Close flushes buffered rows.
+func (cw *CSVWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// escapeFormula neutralises values starting with formula trigger characters.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
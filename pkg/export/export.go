@@ -0,0 +1,61 @@
+// Package export provides streaming tabular writers (CSV and XLSX) for data exports.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format identifies an export file format.
+type Format string
+
+// Supported export formats.
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// RowWriter writes rows of string cells to an export file.
+// Close must be called to flush buffered data and finish the file.
+type RowWriter interface {
+	WriteRow(cells []string) error
+	Close() error
+}
+
+// ParseFormat validates an export format name.
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", name)
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension returns the file extension of the format, without the dot.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// NewWriter creates a RowWriter for the format that streams to w.
+func NewWriter(f Format, w io.Writer) (RowWriter, error) {
+	switch f {
+	case FormatCSV:
+		return NewCSVWriter(w), nil
+	case FormatXLSX:
+		return NewXLSXWriter(w, "Export")
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", f)
+	}
+}
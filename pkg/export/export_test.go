@@ -0,0 +1,76 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCSVWriterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter returned error: %v", err)
+	}
+	if err := w.WriteRow([]string{"=SUM(A1)", "plain"}); err != nil {
+		t.Fatalf("WriteRow returned error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	if got := buf.String(); got != "'=SUM(A1),plain\n" {
+		t.Fatalf("unexpected CSV output %q", got)
+	}
+}
+
+func TestXLSXWriterProducesWorkbook(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter returned error: %v", err)
+	}
+	if err := w.WriteRow([]string{"name", "note"}); err != nil {
+		t.Fatalf("WriteRow returned error: %v", err)
+	}
+	if err := w.WriteRow([]string{"alice", "<b>&"}); err != nil {
+		t.Fatalf("WriteRow returned error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("output is not a zip archive: %v", err)
+	}
+
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open worksheet: %v", err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		sheet = string(data)
+	}
+
+	if !strings.Contains(sheet, `<c r="B2" t="inlineStr"><is><t xml:space="preserve">&lt;b&gt;&amp;</t></is></c>`) {
+		t.Fatalf("worksheet does not contain escaped cell: %s", sheet)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"}
+	for index, want := range cases {
+		if got := columnName(index); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}
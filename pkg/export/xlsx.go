@@ -0,0 +1,127 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XLSXWriter streams rows into a single-sheet Office Open XML workbook.
+// Cells are written as inline strings, so no shared string table has to be kept in memory.
+type XLSXWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+const xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const xlsxSheetFooter = `</sheetData></worksheet>`
+
+// NewXLSXWriter writes the workbook skeleton and opens the worksheet for streaming rows.
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	zw := zip.NewWriter(w)
+
+	var escapedName strings.Builder
+	if err := xml.EscapeText(&escapedName, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, escapedName.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		fw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(fw, part.content); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worksheet: %w", err)
+	}
+	if _, err := io.WriteString(sheet, xlsxSheetHeader); err != nil {
+		return nil, err
+	}
+
+	return &XLSXWriter{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow appends a row to the worksheet.
+func (xw *XLSXWriter) WriteRow(cells []string) error {
+	xw.row++
+
+	var b strings.Builder
+	b.WriteString(`<row r="`)
+	b.WriteString(strconv.Itoa(xw.row))
+	b.WriteString(`">`)
+	for i, cell := range cells {
+		b.WriteString(`<c r="`)
+		b.WriteString(columnName(i))
+		b.WriteString(strconv.Itoa(xw.row))
+		b.WriteString(`" t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(&b, []byte(cell)); err != nil {
+			return err
+		}
+		b.WriteString(`</t></is></c>`)
+	}
+	b.WriteString(`</row>`)
+
+	_, err := io.WriteString(xw.sheet, b.String())
+	return err
+}
+
+// Close finishes the worksheet and the zip archive.
+func (xw *XLSXWriter) Close() error {
+	if _, err := io.WriteString(xw.sheet, xlsxSheetFooter); err != nil {
+		return err
+	}
+	return xw.zw.Close()
+}
+
+// columnName converts a zero-based column index to its spreadsheet name (0 -> A, 26 -> AA).
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,208 @@
+// Package faults describes the faults injected into requests to test the resilience of
+// clients and the alerting of the service: added latency, error responses and dropped
+// connections, each with a probability, per route group or per request through headers.
+package faults
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request headers that inject faults into a single request, when enabled.
+const (
+	LatencyHeader = "X-Fault-Latency" // e.g. 500ms
+	ErrorHeader   = "X-Fault-Error"   // a status of Statuses, e.g. 503
+	DropHeader    = "X-Fault-Drop"    // true
+)
+
+// Statuses are the statuses of injected errors, those a client should retry or back off from.
+var Statuses = []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable}
+
+// Fault is the faults injected into a request, each with the probability (0 to 1) that it
+// happens.
+type Fault struct {
+	Latency            time.Duration
+	LatencyProbability float64
+	Status             int
+	StatusProbability  float64
+	DropProbability    float64
+}
+
+// Outcome is the faults that happen to a request.
+type Outcome struct {
+	Latency time.Duration
+	Status  int
+	Drop    bool
+}
+
+// Roll decides which faults happen, drawing each probability from random, a source of numbers
+// in [0, 1) such as rand.Float64.
+func (f Fault) Roll(random func() float64) Outcome {
+	var o Outcome
+	if f.Latency > 0 && random() < f.LatencyProbability {
+		o.Latency = f.Latency
+	}
+	if f.DropProbability > 0 && random() < f.DropProbability {
+		o.Drop = true
+		return o
+	}
+	if f.Status != 0 && random() < f.StatusProbability {
+		o.Status = f.Status
+	}
+	return o
+}
+
+// Merge returns f with the faults set in other replacing its own.
+func (f Fault) Merge(other Fault) Fault {
+	if other.Latency > 0 {
+		f.Latency, f.LatencyProbability = other.Latency, other.LatencyProbability
+	}
+	if other.Status != 0 {
+		f.Status, f.StatusProbability = other.Status, other.StatusProbability
+	}
+	if other.DropProbability > 0 {
+		f.DropProbability = other.DropProbability
+	}
+	return f
+}
+
+// Rule injects Fault into the routes under Prefix.
+type Rule struct {
+	Prefix string
+	Fault
+}
+
+// Rules are the fault rules of the route groups.
+type Rules []Rule
+
+// ParseRules parses "/route/prefix=fault fault..." entries (FAULT_INJECTION_RULES), where each
+// fault is latency:DURATION, error:STATUS or drop, optionally followed by @PROBABILITY (1 by
+// default), such as "/api/users=latency:500ms@0.2 error:503@0.05 drop@0.01". Each route
+// matches the rule of its longest prefix.
+func ParseRules(entries []string) (Rules, error) {
+	rules := make(Rules, 0, len(entries))
+	for _, entry := range entries {
+		prefix, spec, ok := strings.Cut(entry, "=")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid fault rule %q: want /route/prefix=faults", entry)
+		}
+		rule := Rule{Prefix: strings.TrimSuffix(prefix, "/")}
+		specs := strings.Fields(spec)
+		if len(specs) == 0 {
+			return nil, fmt.Errorf("invalid fault rule %q: no faults", entry)
+		}
+		for _, s := range specs {
+			if err := rule.parse(s); err != nil {
+				return nil, fmt.Errorf("invalid fault rule %q: %w", entry, err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return len(rules[i].Prefix) > len(rules[j].Prefix) })
+	return rules, nil
+}
+
+// parse adds one fault of a rule to f.
+func (f *Fault) parse(spec string) error {
+	spec, p, hasProbability := strings.Cut(spec, "@")
+	probability := 1.0
+	if hasProbability {
+		var err error
+		probability, err = strconv.ParseFloat(p, 64)
+		if err != nil || probability <= 0 || probability > 1 {
+			return fmt.Errorf("probability %q must be greater than 0 and at most 1", p)
+		}
+	}
+
+	kind, value, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "latency":
+		latency, err := parseLatency(value)
+		if err != nil {
+			return err
+		}
+		f.Latency, f.LatencyProbability = latency, probability
+	case "error":
+		status, err := parseStatus(value)
+		if err != nil {
+			return err
+		}
+		f.Status, f.StatusProbability = status, probability
+	case "drop":
+		if value != "" {
+			return fmt.Errorf("drop takes no value")
+		}
+		f.DropProbability = probability
+	default:
+		return fmt.Errorf("unknown fault %q, want latency, error or drop", kind)
+	}
+	return nil
+}
+
+// Match returns the rule of the most specific prefix of route, a route template as in c.FullPath.
+func (r Rules) Match(route string) (Rule, bool) {
+	for _, rule := range r {
+		if rule.Prefix == "" || route == rule.Prefix || strings.HasPrefix(route, rule.Prefix+"/") {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// FromHeader returns the faults requested by the X-Fault-* headers of a request, which always
+// happen.
+func FromHeader(h http.Header) (Fault, error) {
+	var f Fault
+	if value := h.Get(LatencyHeader); value != "" {
+		latency, err := parseLatency(value)
+		if err != nil {
+			return Fault{}, fmt.Errorf("invalid %s: %w", LatencyHeader, err)
+		}
+		f.Latency, f.LatencyProbability = latency, 1
+	}
+	if value := h.Get(ErrorHeader); value != "" {
+		status, err := parseStatus(value)
+		if err != nil {
+			return Fault{}, fmt.Errorf("invalid %s: %w", ErrorHeader, err)
+		}
+		f.Status, f.StatusProbability = status, 1
+	}
+	if value := h.Get(DropHeader); value != "" {
+		drop, err := strconv.ParseBool(value)
+		if err != nil {
+			return Fault{}, fmt.Errorf("invalid %s: want true or false", DropHeader)
+		}
+		if drop {
+			f.DropProbability = 1
+		}
+	}
+	return f, nil
+}
+
+// maxLatency bounds injected latency, so a typo cannot hold requests for hours.
+const maxLatency = time.Minute
+
+func parseLatency(value string) (time.Duration, error) {
+	latency, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || latency <= 0 || latency > maxLatency {
+		return 0, fmt.Errorf("latency %q must be a duration up to %s, such as 500ms", value, maxLatency)
+	}
+	return latency, nil
+}
+
+func parseStatus(value string) (int, error) {
+	status, err := strconv.Atoi(strings.TrimSpace(value))
+	if err == nil {
+		for _, s := range Statuses {
+			if s == status {
+				return status, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("error status %q must be one of %v", value, Statuses)
+}
@@ -0,0 +1,78 @@
+package faults
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]string{"/api=error:500@0.01", "/api/users/=latency:500ms@0.2 error:503@0.05 drop@0.01"})
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+
+	rule, ok := rules.Match("/api/users/:id")
+	want := Fault{Latency: 500 * time.Millisecond, LatencyProbability: 0.2, Status: 503, StatusProbability: 0.05, DropProbability: 0.01}
+	if !ok || rule.Fault != want {
+		t.Errorf("Match(/api/users/:id) = %+v, want %+v", rule, want)
+	}
+	if rule, ok := rules.Match("/api/usersettings"); !ok || rule.Prefix != "/api" {
+		t.Errorf("Match(/api/usersettings) = %+v, want the /api rule", rule)
+	}
+	if _, ok := rules.Match("/health/live"); ok {
+		t.Error("Match(/health/live) matched a rule under /api")
+	}
+}
+
+func TestParseRules_Errors(t *testing.T) {
+	for _, entry := range []string{
+		"api=drop", "/api=", "/api=timeout:1s", "/api=error:404", "/api=latency:2h",
+		"/api=latency:fast", "/api=drop@0", "/api=drop@1.5", "/api=drop:1",
+	} {
+		if _, err := ParseRules([]string{entry}); err == nil {
+			t.Errorf("ParseRules(%q) succeeded", entry)
+		}
+	}
+}
+
+func TestRoll(t *testing.T) {
+	always := func() float64 { return 0 }
+	never := func() float64 { return 0.999 }
+	f := Fault{Latency: time.Second, LatencyProbability: 0.5, Status: 500, StatusProbability: 0.5}
+
+	if got := f.Roll(always); got != (Outcome{Latency: time.Second, Status: 500}) {
+		t.Errorf("Roll() = %+v, want latency and error", got)
+	}
+	if got := f.Roll(never); got != (Outcome{}) {
+		t.Errorf("Roll() = %+v, want no faults", got)
+	}
+	// A dropped connection gets no error response
+	f.DropProbability = 1
+	if got := f.Roll(always); !got.Drop || got.Status != 0 {
+		t.Errorf("Roll() = %+v, want a drop without error", got)
+	}
+}
+
+func TestFromHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set(LatencyHeader, "250ms")
+	h.Set(ErrorHeader, "429")
+	f, err := FromHeader(h)
+	if err != nil {
+		t.Fatalf("FromHeader() error = %v", err)
+	}
+	rule := Fault{Status: 500, StatusProbability: 0.1, DropProbability: 0.2}
+	want := Fault{Latency: 250 * time.Millisecond, LatencyProbability: 1, Status: 429, StatusProbability: 1, DropProbability: 0.2}
+	if got := rule.Merge(f); got != want {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+
+	for header, value := range map[string]string{LatencyHeader: "-1s", ErrorHeader: "200", DropHeader: "maybe"} {
+		h := http.Header{}
+		h.Set(header, value)
+		if _, err := FromHeader(h); err == nil {
+			t.Errorf("FromHeader(%s: %s) succeeded", header, value)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Package geoip resolves the country, coordinates and network of IP addresses from MaxMind
+// GeoLite2 or GeoIP2 databases in the .mmdb format: a Country or City database, and an ASN
+// database.
+package geoip
+
+import (
+	"fmt"
+	"net"
+)
+
+// Record is what the databases know about an IP address.
+type Record struct {
+	// Country is the ISO 3166-1 alpha-2 code, such as "CO"; empty when unknown.
+	Country string
+	// Latitude and Longitude are set when HasCoordinates is, which needs a City database.
+	Latitude       float64
+	Longitude      float64
+	HasCoordinates bool
+	// ASN is the autonomous system number of the network and Organization its owner, from
+	// the ASN database; 0 when unknown.
+	ASN          uint
+	Organization string
+}
+
+// DB looks addresses up in a location (Country or City) database and an ASN database, either
+// of which may be missing.
+type DB struct {
+	location *Reader
+	asn      *Reader
+}
+
+// NewDB combines the given readers; either may be nil.
+func NewDB(location, asn *Reader) *DB {
+	return &DB{location: location, asn: asn}
+}
+
+// OpenDB opens the location and ASN databases at the given paths; an empty path is skipped.
+func OpenDB(locationPath, asnPath string) (*DB, error) {
+	db := &DB{}
+	var err error
+	if locationPath != "" {
+		if db.location, err = Open(locationPath); err != nil {
+			return nil, err
+		}
+	}
+	if asnPath != "" {
+		if db.asn, err = Open(asnPath); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// Lookup returns the record of ip. found is false when no database knows the address, as for
+// private and loopback addresses.
+func (db *DB) Lookup(ip string) (record Record, found bool, err error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return Record{}, false, fmt.Errorf("geoip: invalid IP address %q", ip)
+	}
+
+	if db.location != nil {
+		values, err := db.location.Lookup(addr)
+		if err != nil {
+			return Record{}, false, err
+		}
+		if values != nil {
+			found = true
+			record.Country = isoCode(values, "country")
+			if record.Country == "" {
+				record.Country = isoCode(values, "registered_country")
+			}
+			if location, ok := values["location"].(map[string]interface{}); ok {
+				record.Latitude, record.HasCoordinates = location["latitude"].(float64)
+				longitude, ok := location["longitude"].(float64)
+				record.Longitude = longitude
+				record.HasCoordinates = record.HasCoordinates && ok
+			}
+		}
+	}
+
+	if db.asn != nil {
+		values, err := db.asn.Lookup(addr)
+		if err != nil {
+			return Record{}, false, err
+		}
+		if values != nil {
+			found = true
+			record.ASN = toUint(values["autonomous_system_number"])
+			record.Organization, _ = values["autonomous_system_organization"].(string)
+		}
+	}
+	return record, found, nil
+}
+
+func isoCode(values map[string]interface{}, key string) string {
+	country, _ := values[key].(map[string]interface{})
+	code, _ := country["iso_code"].(string)
+	return code
+}
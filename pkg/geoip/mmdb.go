@@ -0,0 +1,344 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+// ErrInvalidDatabase is returned for files that are not valid MaxMind DB files.
+var ErrInvalidDatabase = errors.New("geoip: invalid MaxMind DB file")
+
+// metadataMarker precedes the metadata map at the end of the file.
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// Data section types of the MaxMind DB format.
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEndMarker = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// unsignedSizes are the largest sizes in bytes of the unsigned integer types.
+var unsignedSizes = map[uint]uint{typeUint16: 2, typeUint32: 4, typeUint64: 8}
+
+// dataSectionSeparator is the size of the zeroed gap between the search tree and the data.
+const dataSectionSeparator = 16
+
+// Reader reads a MaxMind DB (.mmdb) file held in memory. It is safe for concurrent use.
+type Reader struct {
+	// DatabaseType is the type in the metadata, such as "GeoLite2-City" or "GeoLite2-ASN".
+	DatabaseType string
+
+	tree       []byte
+	data       decoder
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+}
+
+// Open reads the database at path.
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := FromBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// FromBytes reads a database from its contents.
+func FromBytes(buf []byte) (*Reader, error) {
+	markerAt := bytes.LastIndex(buf, metadataMarker)
+	if markerAt < 0 {
+		return nil, ErrInvalidDatabase
+	}
+	meta, _, err := decoder{buf: buf[markerAt+len(metadataMarker):]}.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: metadata: %v", ErrInvalidDatabase, err)
+	}
+	metadata, ok := meta.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", ErrInvalidDatabase)
+	}
+
+	r := &Reader{
+		nodeCount:  toUint(metadata["node_count"]),
+		recordSize: toUint(metadata["record_size"]),
+		ipVersion:  toUint(metadata["ip_version"]),
+	}
+	r.DatabaseType, _ = metadata["database_type"].(string)
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("%w: unsupported record size %d", ErrInvalidDatabase, r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported IP version %d", ErrInvalidDatabase, r.ipVersion)
+	}
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+dataSectionSeparator > uint(markerAt) {
+		return nil, fmt.Errorf("%w: search tree is larger than the file", ErrInvalidDatabase)
+	}
+	r.tree = buf[:treeSize]
+	r.data = decoder{buf: buf[treeSize+dataSectionSeparator : markerAt]}
+
+	// IPv4 addresses live under ::/96 of IPv6 databases
+	if r.ipVersion == 6 {
+		for i := 0; i < 96 && r.ipv4Start < r.nodeCount; i++ {
+			r.ipv4Start = r.readNode(r.ipv4Start, 0)
+		}
+	}
+	return r, nil
+}
+
+// Lookup returns the record of ip, decoded into maps, slices, strings, float64, int64, uint64,
+// *big.Int, []byte and bool values, or nil when the database has no record for it.
+func (r *Reader) Lookup(ip net.IP) (map[string]interface{}, error) {
+	var node uint
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		if r.ipVersion == 6 {
+			node = r.ipv4Start
+		}
+	} else if ip = ip.To16(); ip == nil {
+		return nil, fmt.Errorf("geoip: invalid IP address")
+	} else if r.ipVersion == 4 {
+		return nil, nil
+	}
+
+	for i := 0; i < len(ip)*8 && node < r.nodeCount; i++ {
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+		node = r.readNode(node, bit)
+	}
+	switch {
+	case node == r.nodeCount:
+		return nil, nil
+	case node < r.nodeCount:
+		return nil, fmt.Errorf("%w: search tree is deeper than the address", ErrInvalidDatabase)
+	}
+
+	value, _, err := r.data.decode(node - r.nodeCount - dataSectionSeparator)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDatabase, err)
+	}
+	record, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: record is not a map", ErrInvalidDatabase)
+	}
+	return record, nil
+}
+
+// readNode returns the left (bit 0) or right (bit 1) record of node.
+func (r *Reader) readNode(node uint, bit byte) uint {
+	switch r.recordSize {
+	case 24:
+		b := r.tree[node*6+uint(bit)*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(r.tree[node*8+uint(bit)*4:]))
+	}
+}
+
+// decoder decodes values of the data section (or the metadata), where pointers are offsets
+// from the start of buf.
+type decoder struct {
+	buf []byte
+}
+
+// decode returns the value at offset and the offset that follows it.
+func (d decoder) decode(offset uint) (interface{}, uint, error) {
+	return d.decodeAt(offset, true)
+}
+
+func (d decoder) decodeAt(offset uint, followPointers bool) (interface{}, uint, error) {
+	ctrl, err := d.bytes(offset, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset++
+	kind := uint(ctrl[0] >> 5)
+
+	if kind == typePointer {
+		if !followPointers {
+			return nil, 0, errors.New("pointer to a pointer")
+		}
+		target, next, err := d.pointer(ctrl[0], offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decodeAt(target, false)
+		return value, next, err
+	}
+	if kind == typeExtended {
+		extended, err := d.bytes(offset, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		kind = 7 + uint(extended[0])
+		offset++
+	}
+
+	size := uint(ctrl[0] & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		b, err := d.bytes(offset, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += n
+		switch n {
+		case 1:
+			size = 29 + uint(b[0])
+		case 2:
+			size = 285 + (uint(b[0])<<8 | uint(b[1]))
+		default:
+			size = 65821 + (uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]))
+		}
+	}
+	return d.value(kind, size, offset)
+}
+
+func (d decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	n := uint(ctrl>>3&0x3) + 1
+	b, err := d.bytes(offset, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	high := uint(ctrl & 0x7)
+	var target uint
+	switch n {
+	case 1:
+		target = high<<8 | uint(b[0])
+	case 2:
+		target = (high<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+	case 3:
+		target = (high<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+	default:
+		target = uint(binary.BigEndian.Uint32(b))
+	}
+	return target, offset + n, nil
+}
+
+func (d decoder) value(kind, size, offset uint) (interface{}, uint, error) {
+	switch kind {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			if m[name], offset, err = d.decode(next); err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			item, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, item)
+			offset = next
+		}
+		return a, offset, nil
+	case typeBool:
+		if size > 1 {
+			return nil, 0, fmt.Errorf("invalid boolean %d", size)
+		}
+		return size == 1, offset, nil
+	}
+
+	b, err := d.bytes(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	next := offset + size
+	switch kind {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte(nil), b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid double size %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid float size %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > unsignedSizes[kind] {
+			return nil, 0, fmt.Errorf("invalid unsigned integer size %d", size)
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("invalid int32 size %d", size)
+		}
+		var v uint32
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+		return int64(int32(v)), next, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, fmt.Errorf("invalid uint128 size %d", size)
+		}
+		return new(big.Int).SetBytes(b), next, nil
+	default:
+		return nil, 0, fmt.Errorf("unexpected data type %d", kind)
+	}
+}
+
+func (d decoder) bytes(offset, n uint) ([]byte, error) {
+	if offset+n > uint(len(d.buf)) || offset+n < offset {
+		return nil, errors.New("value extends past the end of the data")
+	}
+	return d.buf[offset : offset+n], nil
+}
+
+// toUint converts a decoded unsigned integer, or returns 0.
+func toUint(v interface{}) uint {
+	n, _ := v.(uint64)
+	return uint(n)
+}
@@ -0,0 +1,288 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testNetwork is a network of a test database and its record; a string record is a pointer
+// to the record of the network with that CIDR.
+type testNetwork struct {
+	cidr   string
+	record interface{}
+}
+
+type trieNode struct {
+	child [2]*trieNode
+	data  [2]int // data section offset + 1, or 0
+}
+
+// buildDB writes a MaxMind DB file with the given networks.
+func buildDB(t *testing.T, ipVersion, recordSize int, dbType string, networks []testNetwork) []byte {
+	t.Helper()
+	var data bytes.Buffer
+	offsets := make(map[string]int)
+	root := &trieNode{}
+	for _, n := range networks {
+		offset := data.Len()
+		if target, ok := n.record.(string); ok {
+			encodePointer(&data, offsets[target])
+		} else {
+			encodeValue(&data, n.record)
+		}
+		offsets[n.cidr] = offset
+
+		_, network, err := net.ParseCIDR(n.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ip := network.IP
+		ones, _ := network.Mask.Size()
+		if ip4 := ip.To4(); ip4 != nil && ipVersion == 6 {
+			// IPv4 networks live under ::/96
+			ip, ones = append(make(net.IP, 12), ip4...), ones+96
+		}
+		node := root
+		for i := 0; i < ones; i++ {
+			bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+			if i == ones-1 {
+				node.data[bit] = offset + 1
+				break
+			}
+			if node.child[bit] == nil {
+				node.child[bit] = &trieNode{}
+			}
+			node = node.child[bit]
+		}
+	}
+
+	// Number the nodes breadth first
+	var nodes []*trieNode
+	index := map[*trieNode]int{}
+	for queue := []*trieNode{root}; len(queue) > 0; queue = queue[1:] {
+		index[queue[0]] = len(nodes)
+		nodes = append(nodes, queue[0])
+		for _, child := range queue[0].child {
+			if child != nil {
+				queue = append(queue, child)
+			}
+		}
+	}
+	nodeCount := len(nodes)
+	record := func(n *trieNode, bit int) uint32 {
+		switch {
+		case n.child[bit] != nil:
+			return uint32(index[n.child[bit]])
+		case n.data[bit] != 0:
+			return uint32(nodeCount + dataSectionSeparator + n.data[bit] - 1)
+		default:
+			return uint32(nodeCount)
+		}
+	}
+
+	var file bytes.Buffer
+	for _, n := range nodes {
+		left, right := record(n, 0), record(n, 1)
+		switch recordSize {
+		case 24:
+			file.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)})
+		case 28:
+			file.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left),
+				byte(left>>24)<<4 | byte(right>>24)&0x0F, byte(right >> 16), byte(right >> 8), byte(right)})
+		default:
+			_ = binary.Write(&file, binary.BigEndian, [2]uint32{left, right})
+		}
+	}
+	file.Write(make([]byte, dataSectionSeparator))
+	file.Write(data.Bytes())
+	file.Write(metadataMarker)
+	encodeValue(&file, map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+		"ip_version":                  uint16(ipVersion),
+		"database_type":               dbType,
+		"binary_format_major_version": uint16(2),
+	})
+	return file.Bytes()
+}
+
+func encodeControl(buf *bytes.Buffer, kind, size int) {
+	first := byte(kind) << 5
+	if kind > 7 {
+		first = 0
+	}
+	var extra []byte
+	switch {
+	case size < 29:
+		first |= byte(size)
+	case size < 285:
+		first |= 29
+		extra = []byte{byte(size - 29)}
+	default:
+		first |= 30
+		extra = []byte{byte((size - 285) >> 8), byte(size - 285)}
+	}
+	buf.WriteByte(first)
+	if kind > 7 {
+		buf.WriteByte(byte(kind - 7))
+	}
+	buf.Write(extra)
+}
+
+func encodeValue(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		encodeControl(buf, typeString, len(v))
+		buf.WriteString(v)
+	case float64:
+		encodeControl(buf, typeDouble, 8)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case uint16:
+		encodeControl(buf, typeUint16, 2)
+		_ = binary.Write(buf, binary.BigEndian, v)
+	case uint32:
+		encodeControl(buf, typeUint32, 4)
+		_ = binary.Write(buf, binary.BigEndian, v)
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		encodeControl(buf, typeBool, size)
+	case []interface{}:
+		encodeControl(buf, typeArray, len(v))
+		for _, item := range v {
+			encodeValue(buf, item)
+		}
+	case map[string]interface{}:
+		encodeControl(buf, typeMap, len(v))
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			encodeValue(buf, key)
+			encodeValue(buf, v[key])
+		}
+	default:
+		panic("unsupported test value")
+	}
+}
+
+func encodePointer(buf *bytes.Buffer, offset int) {
+	buf.Write([]byte{typePointer<<5 | byte(offset>>8)&0x7, byte(offset)})
+}
+
+func cityRecord(country string, lat, lon float64) map[string]interface{} {
+	return map[string]interface{}{
+		"country":  map[string]interface{}{"iso_code": country, "names": map[string]interface{}{"en": "Somewhere"}},
+		"location": map[string]interface{}{"latitude": lat, "longitude": lon, "accuracy_radius": uint16(100)},
+		"subdivisions": []interface{}{
+			map[string]interface{}{"iso_code": "ENG"},
+		},
+		"is_in_european_union": false,
+	}
+}
+
+func TestReader_Lookup(t *testing.T) {
+	for _, recordSize := range []int{24, 28, 32} {
+		buf := buildDB(t, 6, recordSize, "GeoLite2-City", []testNetwork{
+			{cidr: "81.2.69.0/24", record: cityRecord("GB", 51.5142, -0.0931)},
+			{cidr: "89.160.20.0/24", record: "81.2.69.0/24"},
+			{cidr: "2001:db8::/32", record: map[string]interface{}{
+				"registered_country": map[string]interface{}{"iso_code": "CO"},
+			}},
+		})
+		r, err := FromBytes(buf)
+		if err != nil {
+			t.Fatalf("record size %d: FromBytes() error = %v", recordSize, err)
+		}
+		if r.DatabaseType != "GeoLite2-City" {
+			t.Errorf("DatabaseType = %q", r.DatabaseType)
+		}
+
+		record, err := r.Lookup(net.ParseIP("81.2.69.142"))
+		if err != nil {
+			t.Fatalf("record size %d: Lookup() error = %v", recordSize, err)
+		}
+		if got := record["location"].(map[string]interface{})["latitude"]; got != 51.5142 {
+			t.Errorf("record size %d: latitude = %v", recordSize, got)
+		}
+		if got := record["subdivisions"].([]interface{})[0].(map[string]interface{})["iso_code"]; got != "ENG" {
+			t.Errorf("record size %d: subdivision = %v", recordSize, got)
+		}
+
+		// Stored as a pointer to the record above
+		if record, _ := r.Lookup(net.ParseIP("89.160.20.1")); record["country"] == nil {
+			t.Errorf("record size %d: pointer record = %v", recordSize, record)
+		}
+		if record, err := r.Lookup(net.ParseIP("10.0.0.1")); record != nil || err != nil {
+			t.Errorf("record size %d: private address = %v, %v", recordSize, record, err)
+		}
+		if record, _ := r.Lookup(net.ParseIP("2001:db8::1")); record["registered_country"] == nil {
+			t.Errorf("record size %d: IPv6 record = %v", recordSize, record)
+		}
+	}
+}
+
+func TestDBLookup(t *testing.T) {
+	location, err := FromBytes(buildDB(t, 6, 24, "GeoLite2-City", []testNetwork{
+		{cidr: "81.2.69.0/24", record: cityRecord("GB", 51.5142, -0.0931)},
+		{cidr: "2001:db8::/32", record: map[string]interface{}{
+			"registered_country": map[string]interface{}{"iso_code": "CO"},
+		}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	organization := strings.Repeat("Example Networks ", 3)
+	asn, err := FromBytes(buildDB(t, 4, 24, "GeoLite2-ASN", []testNetwork{
+		{cidr: "81.2.69.0/24", record: map[string]interface{}{
+			"autonomous_system_number":       uint32(64500),
+			"autonomous_system_organization": organization,
+		}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := NewDB(location, asn)
+
+	record, found, err := db.Lookup("81.2.69.142")
+	want := Record{Country: "GB", Latitude: 51.5142, Longitude: -0.0931, HasCoordinates: true, ASN: 64500, Organization: organization}
+	if err != nil || !found || record != want {
+		t.Errorf("Lookup() = %+v, %v, %v; want %+v", record, found, err, want)
+	}
+
+	// Country only, from registered_country; the IPv4 ASN database has no IPv6 networks
+	record, found, _ = db.Lookup("2001:db8::1")
+	if !found || record != (Record{Country: "CO"}) {
+		t.Errorf("Lookup(IPv6) = %+v, %v", record, found)
+	}
+
+	if _, found, err := db.Lookup("127.0.0.1"); found || err != nil {
+		t.Errorf("Lookup(loopback) found = %v, err = %v", found, err)
+	}
+	if _, _, err := db.Lookup("not-an-ip"); err == nil {
+		t.Error("Lookup() accepted an invalid address")
+	}
+}
+
+func TestFromBytes_Invalid(t *testing.T) {
+	if _, err := FromBytes([]byte("not a database")); !errors.Is(err, ErrInvalidDatabase) {
+		t.Errorf("FromBytes() error = %v, want ErrInvalidDatabase", err)
+	}
+
+	valid := buildDB(t, 4, 24, "Test", []testNetwork{{cidr: "1.0.0.0/8", record: map[string]interface{}{"a": "b"}}})
+	markerAt := bytes.LastIndex(valid, metadataMarker)
+	truncated := append(append([]byte(nil), valid[:10]...), valid[markerAt:]...)
+	if _, err := FromBytes(truncated); !errors.Is(err, ErrInvalidDatabase) {
+		t.Errorf("FromBytes(truncated) error = %v, want ErrInvalidDatabase", err)
+	}
+}
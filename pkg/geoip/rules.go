@@ -0,0 +1,167 @@
+package geoip
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rate limit keys of a RateLimitRule.
+const (
+	ByCountry = "country"
+	ByASN     = "asn"
+)
+
+// BlockRule restricts which countries and networks may call the routes under Prefix. With
+// Allow, only the listed ones may; otherwise the listed ones may not. Addresses the databases
+// do not know, such as private addresses, are never blocked.
+type BlockRule struct {
+	Prefix string
+	Allow  bool
+	// Origins are ISO country codes such as "CO" and networks written as "AS64500".
+	Origins map[string]bool
+}
+
+// Blocks reports whether the rule rejects a request from the given country and network.
+func (b BlockRule) Blocks(country string, asn uint) bool {
+	if country == "" && asn == 0 {
+		return false
+	}
+	listed := b.Origins[country] || (asn != 0 && b.Origins[asName(asn)])
+	return listed != b.Allow
+}
+
+// BlockRules are the block rules of the route groups.
+type BlockRules []BlockRule
+
+// ParseBlockRules parses "/route/prefix=allow:CO US" and "/route/prefix=deny:KP AS64500"
+// entries. Each route matches the rule of its longest prefix.
+func ParseBlockRules(entries []string) (BlockRules, error) {
+	rules := make(BlockRules, 0, len(entries))
+	for _, entry := range entries {
+		prefix, spec, err := splitRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		action, list, _ := strings.Cut(spec, ":")
+		rule := BlockRule{Prefix: prefix, Origins: make(map[string]bool)}
+		switch strings.TrimSpace(action) {
+		case "allow":
+			rule.Allow = true
+		case "deny":
+		default:
+			return nil, fmt.Errorf("invalid geo block rule %q: want allow: or deny: before the list", entry)
+		}
+		for _, origin := range strings.Fields(list) {
+			origin = strings.ToUpper(origin)
+			if !validOrigin(origin) {
+				return nil, fmt.Errorf("invalid geo block rule %q: %q is not a country code or AS number", entry, origin)
+			}
+			rule.Origins[origin] = true
+		}
+		if len(rule.Origins) == 0 {
+			return nil, fmt.Errorf("invalid geo block rule %q: no countries or networks", entry)
+		}
+		rules = append(rules, rule)
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return len(rules[i].Prefix) > len(rules[j].Prefix) })
+	return rules, nil
+}
+
+// Match returns the rule of the most specific prefix of route, a route template as in c.FullPath.
+func (r BlockRules) Match(route string) (BlockRule, bool) {
+	for _, rule := range r {
+		if matchPrefix(route, rule.Prefix) {
+			return rule, true
+		}
+	}
+	return BlockRule{}, false
+}
+
+// RateLimitRule limits the requests to the routes under Prefix per country or per network
+// (ByCountry or ByASN), on top of the per-IP limits.
+type RateLimitRule struct {
+	Prefix string
+	By     string
+	RPS    float64
+	Burst  int
+}
+
+// RateLimitRules are the rate limit rules of the route groups.
+type RateLimitRules []RateLimitRule
+
+// ParseRateLimitRules parses "/route/prefix=asn:RPS:BURST" and "/route/prefix=country:RPS:BURST"
+// entries, such as "/api/auth=asn:5:20".
+func ParseRateLimitRules(entries []string) (RateLimitRules, error) {
+	rules := make(RateLimitRules, 0, len(entries))
+	for _, entry := range entries {
+		prefix, spec, err := splitRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		parts := strings.Split(spec, ":")
+		if len(parts) != 3 || (parts[0] != ByCountry && parts[0] != ByASN) {
+			return nil, fmt.Errorf("invalid geo rate limit %q: want country:RPS:BURST or asn:RPS:BURST", entry)
+		}
+		rps, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || rps <= 0 {
+			return nil, fmt.Errorf("invalid geo rate limit %q: RPS must be a positive number", entry)
+		}
+		burst, err := strconv.Atoi(parts[2])
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid geo rate limit %q: BURST must be a positive integer", entry)
+		}
+		rules = append(rules, RateLimitRule{Prefix: prefix, By: parts[0], RPS: rps, Burst: burst})
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return len(rules[i].Prefix) > len(rules[j].Prefix) })
+	return rules, nil
+}
+
+// Match returns the rule of the most specific prefix of route.
+func (r RateLimitRules) Match(route string) (RateLimitRule, bool) {
+	for _, rule := range r {
+		if matchPrefix(route, rule.Prefix) {
+			return rule, true
+		}
+	}
+	return RateLimitRule{}, false
+}
+
+// Key returns the rate limit bucket of a request from the given country and network, or ""
+// when the rule does not apply because that is unknown.
+func (r RateLimitRule) Key(country string, asn uint) string {
+	if r.By == ByASN {
+		if asn == 0 {
+			return ""
+		}
+		return asName(asn)
+	}
+	return country
+}
+
+func asName(asn uint) string {
+	return "AS" + strconv.FormatUint(uint64(asn), 10)
+}
+
+func splitRule(entry string) (prefix, spec string, err error) {
+	prefix, spec, ok := strings.Cut(entry, "=")
+	prefix = strings.TrimSpace(prefix)
+	if !ok || !strings.HasPrefix(prefix, "/") {
+		return "", "", fmt.Errorf("invalid geo rule %q: want /route/prefix=...", entry)
+	}
+	return strings.TrimSuffix(prefix, "/"), strings.TrimSpace(spec), nil
+}
+
+// matchPrefix reports whether prefix covers route, matching whole path segments.
+func matchPrefix(route, prefix string) bool {
+	return prefix == "" || route == prefix || strings.HasPrefix(route, prefix+"/")
+}
+
+func validOrigin(origin string) bool {
+	if strings.HasPrefix(origin, "AS") && len(origin) > 2 {
+		_, err := strconv.ParseUint(origin[2:], 10, 32)
+		return err == nil
+	}
+	return len(origin) == 2 && origin[0] >= 'A' && origin[0] <= 'Z' && origin[1] >= 'A' && origin[1] <= 'Z'
+}
@@ -0,0 +1,66 @@
+package geoip
+
+import "testing"
+
+func TestBlockRules(t *testing.T) {
+	rules, err := ParseBlockRules([]string{"/api=deny:kp AS64500", "/api/admin=allow:CO US"})
+	if err != nil {
+		t.Fatalf("ParseBlockRules() error = %v", err)
+	}
+
+	tests := []struct {
+		route   string
+		country string
+		asn     uint
+		blocked bool
+	}{
+		{"/api/auth/login", "KP", 0, true},
+		{"/api/auth/login", "US", 64500, true},
+		{"/api/auth/login", "US", 64501, false},
+		{"/api/admin/users", "CO", 0, false},
+		{"/api/admin/users", "KP", 0, true},
+		{"/api/admin/users", "", 0, false}, // unknown origin
+		{"/api/administrators", "GB", 0, false},
+	}
+	for _, tt := range tests {
+		rule, ok := rules.Match(tt.route)
+		if got := ok && rule.Blocks(tt.country, tt.asn); got != tt.blocked {
+			t.Errorf("%s from %s/AS%d: blocked = %v, want %v", tt.route, tt.country, tt.asn, got, tt.blocked)
+		}
+	}
+}
+
+func TestParseBlockRules_Errors(t *testing.T) {
+	for _, entry := range []string{"api=deny:KP", "/api=block:KP", "/api=deny:", "/api=deny:Korea", "/api=deny:ASX"} {
+		if _, err := ParseBlockRules([]string{entry}); err == nil {
+			t.Errorf("ParseBlockRules(%q) succeeded", entry)
+		}
+	}
+}
+
+func TestRateLimitRules(t *testing.T) {
+	rules, err := ParseRateLimitRules([]string{"/api/auth=asn:5:20", "/api=country:100:200"})
+	if err != nil {
+		t.Fatalf("ParseRateLimitRules() error = %v", err)
+	}
+
+	rule, ok := rules.Match("/api/auth/login")
+	if !ok || rule.By != ByASN || rule.RPS != 5 || rule.Burst != 20 {
+		t.Fatalf("Match() = %+v, %v", rule, ok)
+	}
+	if got := rule.Key("US", 64500); got != "AS64500" {
+		t.Errorf("Key() = %q, want AS64500", got)
+	}
+	if got := rule.Key("US", 0); got != "" {
+		t.Errorf("Key() without ASN = %q, want none", got)
+	}
+	if rule, _ := rules.Match("/api/users"); rule.Key("CO", 64500) != "CO" {
+		t.Errorf("country rule Key() = %q, want CO", rule.Key("CO", 64500))
+	}
+
+	for _, entry := range []string{"/api=asn:5", "/api=city:1:1", "/api=asn:0:1", "/api=asn:1:0"} {
+		if _, err := ParseRateLimitRules([]string{entry}); err == nil {
+			t.Errorf("ParseRateLimitRules(%q) succeeded", entry)
+		}
+	}
+}
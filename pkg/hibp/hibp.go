@@ -0,0 +1,95 @@
+// Package hibp provides a client for the Have I Been Pwned "Pwned Passwords" range API.
+// Passwords are checked with k-anonymity: only the first five characters of the SHA-1
+// hash are sent, and the match is done locally against the returned suffixes.
+package hibp
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1" //nolint:gosec // SHA-1 is mandated by the Pwned Passwords API, not used for security
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the public Pwned Passwords range endpoint.
+const DefaultBaseURL = "https://api.pwnedpasswords.com/range/"
+
+// Client queries the Pwned Passwords range API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+}
+
+// NewClient creates a new Pwned Passwords client with the given request timeout.
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{Timeout: timeout},
+		userAgent:  "gin-template-password-check",
+	}
+}
+
+// WithBaseURL overrides the API endpoint, mainly for testing.
+func (c *Client) WithBaseURL(baseURL string) *Client {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	c.baseURL = baseURL
+	return c
+}
+
+// WithHTTPClient overrides the HTTP client used for requests.
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c.httpClient = httpClient
+	return c
+}
+
+// Count returns how many times the password appears in known breaches.
+// A count of zero means the password was not found.
+func (c *Client) Count(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password)) //nolint:gosec // see import comment
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+prefix, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build breach check request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	// Padding hides the real number of suffixes returned for the prefix
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("breach check request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("breach check returned status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, countStr, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found || !strings.EqualFold(candidate, suffix) {
+			continue
+		}
+
+		count, err := strconv.Atoi(countStr)
+		if err != nil {
+			return 0, fmt.Errorf("invalid breach count %q: %w", countStr, err)
+		}
+		return count, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read breach check response: %w", err)
+	}
+	return 0, nil
+}
@@ -0,0 +1,75 @@
+package hibp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/testutil/vcr"
+)
+
+func TestCount(t *testing.T) {
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Errorf("expected Add-Padding header")
+		}
+		_, _ = fmt.Fprint(w, "003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\r\n011053FD0102E94D6AE2F8B83D76FAF94F6:0\r\n")
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second).WithBaseURL(server.URL)
+
+	count, err := client.Count(context.Background(), "password")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requestedPath != "/5BAA6" {
+		t.Errorf("requested path = %s; want /5BAA6", requestedPath)
+	}
+	if count != 3861493 {
+		t.Errorf("count = %d; want 3861493", count)
+	}
+
+	count, err = client.Count(context.Background(), "a-password-that-is-not-in-the-list")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("count = %d; want 0", count)
+	}
+}
+
+func TestCountUpstreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second).WithBaseURL(server.URL)
+	if _, err := client.Count(context.Background(), "password"); err == nil {
+		t.Fatal("expected an error for non-200 responses")
+	}
+}
+
+func TestCount_Cassette(t *testing.T) {
+	client := NewClient(time.Second).WithHTTPClient(vcr.New(t, "hibp_range"))
+
+	count, err := client.Count(context.Background(), "password")
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if count != 10434004 {
+		t.Errorf("count = %d; want 10434004", count)
+	}
+
+	limited := NewClient(time.Second).WithHTTPClient(vcr.New(t, "hibp_range_rate_limited"))
+	if _, err := limited.Count(context.Background(), "password"); err == nil {
+		t.Error("Count() succeeded on a 429 response")
+	}
+}
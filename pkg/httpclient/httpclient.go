@@ -0,0 +1,215 @@
+// Package httpclient builds http.Clients for calling third-party APIs with consistent
+// timeouts, retries with exponential backoff, a circuit breaker, request-ID propagation,
+// and Prometheus metrics.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/circuitbreaker"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+	"github.com/yeferson59/gin-template/pkg/requestid"
+)
+
+// Config configures a client. Zero values are replaced by the defaults.
+type Config struct {
+	// Name identifies the dependency in metrics and the circuit breaker, e.g. "hibp".
+	Name string
+	// Timeout bounds the whole call including retries. Default 10s.
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt. Default 0 (no retries).
+	MaxRetries int
+	// RetryBackoff is the initial backoff, doubled on every retry with jitter. Default 100ms.
+	RetryBackoff time.Duration
+	// MaxBackoff caps the backoff and any Retry-After delay. Default 2s.
+	MaxBackoff time.Duration
+	// Breaker protects the dependency. A breaker with default settings is created when nil.
+	Breaker *circuitbreaker.Breaker
+	// Transport performs the requests. http.DefaultTransport is used when nil.
+	Transport http.RoundTripper
+}
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_requests_total",
+		Help: "Outbound HTTP request attempts by client, method, and status code.",
+	}, []string{"client", "method", "code"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_client_request_duration_seconds",
+		Help:    "Outbound HTTP request attempt latency.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"client", "method"})
+
+	retriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_retries_total",
+		Help: "Outbound HTTP request retries by client.",
+	}, []string{"client"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(requestsTotal, requestDuration, retriesTotal)
+}
+
+// New creates an http.Client configured with cfg.
+func New(cfg Config) *http.Client {
+	if cfg.Name == "" {
+		cfg.Name = "default"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 2 * time.Second
+	}
+	if cfg.Breaker == nil {
+		cfg.Breaker = circuitbreaker.New(cfg.Name, circuitbreaker.Settings{})
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = http.DefaultTransport
+	}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: &transport{cfg: cfg},
+	}
+}
+
+// transport implements retries, circuit breaking, propagation, and metrics.
+type transport struct {
+	cfg Config
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	requestid.Inject(out)
+
+	retryable := isIdempotent(out) && (out.Body == nil || out.GetBody != nil)
+	backoff := t.cfg.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && out.GetBody != nil {
+			body, err := out.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			out.Body = body
+		}
+
+		resp, err := t.attempt(out)
+		if errors.Is(err, circuitbreaker.ErrOpen) || !retryable || attempt >= t.cfg.MaxRetries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := jitter(backoff)
+		if after, ok := retryAfter(resp); ok {
+			delay = after
+		}
+		if delay > t.cfg.MaxBackoff {
+			delay = t.cfg.MaxBackoff
+		}
+		if resp != nil {
+			// Drain so the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+		}
+
+		retriesTotal.WithLabelValues(t.cfg.Name).Inc()
+		if err := sleep(out.Context(), delay); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// attempt sends a single request through the circuit breaker and records metrics.
+func (t *transport) attempt(req *http.Request) (*http.Response, error) {
+	if err := t.cfg.Breaker.Allow(); err != nil {
+		requestsTotal.WithLabelValues(t.cfg.Name, req.Method, "circuit_open").Inc()
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := t.cfg.Transport.RoundTrip(req)
+	requestDuration.WithLabelValues(t.cfg.Name, req.Method).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	requestsTotal.WithLabelValues(t.cfg.Name, req.Method, code).Inc()
+
+	// Only transport errors and server-side failures count against the dependency;
+	// 4xx responses are the caller's fault.
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		t.cfg.Breaker.Failure()
+	} else {
+		t.cfg.Breaker.Success()
+	}
+	return resp, err
+}
+
+// isIdempotent reports whether a request can be safely repeated.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// shouldRetry reports whether an attempt failed in a way that may succeed when repeated.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given in seconds.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// jitter returns a random duration in [d/2, d) so that clients do not retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
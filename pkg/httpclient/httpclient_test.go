@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/circuitbreaker"
+	"github.com/yeferson59/gin-template/pkg/requestid"
+)
+
+func TestClientRetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{Name: "test-retry", MaxRetries: 2, RetryBackoff: time.Millisecond})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Fatalf("expected success after 3 calls, got status %d after %d calls", resp.StatusCode, calls)
+	}
+}
+
+func TestClientDoesNotRetryNonIdempotentRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := New(Config{Name: "test-post", MaxRetries: 2, RetryBackoff: time.Millisecond})
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if calls != 1 {
+		t.Fatalf("expected a single call for POST, got %d", calls)
+	}
+}
+
+func TestClientFailsFastWhenBreakerOpens(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	breaker := circuitbreaker.New("test-breaker", circuitbreaker.Settings{FailureThreshold: 2, OpenTimeout: time.Hour})
+	client := New(Config{Name: "test-breaker", Breaker: breaker})
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, circuitbreaker.ErrOpen) {
+		t.Fatalf("expected ErrOpen, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the open breaker to stop calls, got %d calls", calls)
+	}
+}
+
+func TestClientPropagatesRequestID(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(requestid.Header)
+	}))
+	defer server.Close()
+
+	ctx := requestid.WithContext(context.Background(), "req-7")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := New(Config{Name: "test-propagation"}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if got != "req-7" {
+		t.Fatalf("expected request ID to be propagated, got %q", got)
+	}
+}
@@ -0,0 +1,214 @@
+// Package listener opens network listeners from address strings, including file descriptors
+// inherited from a parent process and sockets passed by systemd socket activation.
+//
+// Supported addresses:
+//
+//	:8080, 127.0.0.1:8080, tcp://[::1]:8080   TCP address
+//	fd://3                                      inherited file descriptor
+//	systemd                                     every socket passed by systemd
+//	systemd:api                                 sockets named "api" (FileDescriptorName= in the .socket unit)
+//
+// Unix domain sockets are opened with Unix, which also sets their permissions.
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd (SD_LISTEN_FDS_START).
+var listenFdsStart = 3
+
+// ErrNoSystemdSockets is returned for a systemd address when the process was not socket activated.
+var ErrNoSystemdSockets = errors.New("listener: no sockets passed by systemd (LISTEN_FDS is not set for this process)")
+
+// Open returns the listeners for addr. Every address yields one listener except "systemd"
+// addresses, which yield one per matching socket.
+func Open(addr string) ([]net.Listener, error) {
+	switch {
+	case addr == "systemd" || strings.HasPrefix(addr, "systemd:"):
+		return systemdListeners(strings.TrimPrefix(strings.TrimPrefix(addr, "systemd"), ":"))
+	case strings.HasPrefix(addr, "fd://"):
+		fd, err := strconv.Atoi(strings.TrimPrefix(addr, "fd://"))
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("listener: invalid file descriptor in %q", addr)
+		}
+		l, err := fileListener(uintptr(fd), addr)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	default:
+		l, err := net.Listen("tcp", strings.TrimPrefix(addr, "tcp://"))
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+}
+
+// OpenAll opens every address in addrs. On error the listeners opened so far are closed.
+func OpenAll(addrs []string) ([]net.Listener, error) {
+	var all []net.Listener
+	for _, addr := range addrs {
+		ls, err := Open(addr)
+		if err != nil {
+			CloseAll(all)
+			return nil, fmt.Errorf("listen on %s: %w", addr, err)
+		}
+		all = append(all, ls...)
+	}
+	return all, nil
+}
+
+// CloseAll closes every listener, ignoring errors.
+func CloseAll(listeners []net.Listener) {
+	for _, l := range listeners {
+		_ = l.Close()
+	}
+}
+
+// Unix listens on the unix domain socket at path and sets its permissions to mode. A stale
+// socket file left behind by a crashed process is removed first; a socket that still accepts
+// connections is an error. The file is removed again when the listener is closed.
+//
+// Accepted connections report a loopback remote address, so a reverse proxy on the same host
+// is trusted for X-Forwarded-For exactly like one connecting over 127.0.0.1.
+func Unix(path string, mode os.FileMode) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("listener: set permissions of %s: %w", path, err)
+	}
+	return unixListener{l}, nil
+}
+
+// loopback is the remote address reported for unix socket peers.
+var loopback = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+
+type unixListener struct {
+	net.Listener
+}
+
+func (l unixListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return unixConn{conn}, nil
+}
+
+type unixConn struct {
+	net.Conn
+}
+
+func (unixConn) RemoteAddr() net.Addr { return loopback }
+
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("listener: %s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("listener: %s is in use by another process", path)
+	}
+	return os.Remove(path)
+}
+
+func fileListener(fd uintptr, name string) (net.Listener, error) {
+	f := os.NewFile(fd, name)
+	if f == nil {
+		return nil, fmt.Errorf("listener: invalid file descriptor %d", fd)
+	}
+	defer f.Close() // net.FileListener dups the descriptor
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("listener: file descriptor %d is not a listening socket: %w", fd, err)
+	}
+	return l, nil
+}
+
+var systemd struct {
+	once  sync.Once
+	names []string
+	err   error
+}
+
+// systemdListeners returns the activated sockets, optionally filtered by name. The sockets
+// are read from LISTEN_FDS/LISTEN_FDNAMES once, and each can be claimed by a single address.
+func systemdListeners(name string) ([]net.Listener, error) {
+	systemd.once.Do(func() {
+		systemd.names, systemd.err = systemdSockets(os.Getpid(), os.Getenv)
+		if systemd.err == nil {
+			// Do not pass the sockets on to child processes.
+			_ = os.Unsetenv("LISTEN_PID")
+			_ = os.Unsetenv("LISTEN_FDS")
+			_ = os.Unsetenv("LISTEN_FDNAMES")
+		}
+	})
+	if systemd.err != nil {
+		return nil, systemd.err
+	}
+
+	var listeners []net.Listener
+	for i, fdName := range systemd.names {
+		if fdName == "" || (name != "" && fdName != name) {
+			continue
+		}
+		l, err := fileListener(uintptr(listenFdsStart+i), fdName)
+		if err != nil {
+			CloseAll(listeners)
+			return nil, err
+		}
+		systemd.names[i] = "" // claimed
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("listener: no unclaimed systemd socket named %q", name)
+		}
+		return nil, fmt.Errorf("listener: every systemd socket is already in use")
+	}
+	return listeners, nil
+}
+
+// systemdSockets parses the socket activation environment (see sd_listen_fds(3)) and returns
+// one name per passed descriptor. Unnamed sockets are called "unknown", as systemd does.
+func systemdSockets(pid int, getenv func(string) string) ([]string, error) {
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) {
+		return nil, ErrNoSystemdSockets
+	}
+	n, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, ErrNoSystemdSockets
+	}
+
+	names := make([]string, n)
+	given := strings.Split(getenv("LISTEN_FDNAMES"), ":")
+	for i := range names {
+		names[i] = "unknown"
+		if i < len(given) && given[i] != "" {
+			names[i] = given[i]
+		}
+	}
+	return names, nil
+}
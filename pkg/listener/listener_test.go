@@ -0,0 +1,168 @@
+package listener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestOpen_TCP(t *testing.T) {
+	ls, err := Open("tcp://127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseAll(ls)
+	if len(ls) != 1 || ls[0].Addr().Network() != "tcp" {
+		t.Fatalf("unexpected listeners: %v", ls)
+	}
+}
+
+func TestUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+
+	l, err := Unix(path, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("mode = %o, want 600", info.Mode().Perm())
+	}
+
+	if _, err := Unix(path, 0o600); err == nil {
+		t.Fatal("expected an error for a socket that is still in use")
+	}
+
+	go func() {
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+		}
+	}()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conn.RemoteAddr().String(); got != "127.0.0.1:0" {
+		t.Fatalf("RemoteAddr = %q, want loopback", got)
+	}
+	_ = conn.Close()
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket file not removed on close: %v", err)
+	}
+}
+
+func TestUnix_RemovesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a crash: close the socket without removing the file.
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	l, err := Unix(path, 0o660)
+	if err != nil {
+		t.Fatalf("stale socket not replaced: %v", err)
+	}
+	_ = l.Close()
+
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unix(path, 0o660); err == nil {
+		t.Fatal("a regular file must never be removed")
+	}
+}
+
+func TestOpen_FileDescriptor(t *testing.T) {
+	parent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parent.Close()
+	f, err := parent.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ls, err := Open("fd://" + strconv.Itoa(int(f.Fd())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseAll(ls)
+	if ls[0].Addr().String() != parent.Addr().String() {
+		t.Fatalf("inherited listener is on %s, want %s", ls[0].Addr(), parent.Addr())
+	}
+
+	if _, err := Open("fd://nope"); err == nil {
+		t.Fatal("expected an error for an invalid descriptor")
+	}
+}
+
+func TestSystemdSockets(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	if _, err := systemdSockets(42, env(map[string]string{"LISTEN_PID": "7", "LISTEN_FDS": "1"})); !errors.Is(err, ErrNoSystemdSockets) {
+		t.Fatalf("sockets meant for another process must be ignored, got %v", err)
+	}
+
+	names, err := systemdSockets(42, env(map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "3", "LISTEN_FDNAMES": "api::metrics"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || names[0] != "api" || names[1] != "unknown" || names[2] != "metrics" {
+		t.Fatalf("unexpected names: %v", names)
+	}
+}
+
+func TestOpen_SystemdByName(t *testing.T) {
+	api, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+	f, err := api.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Pretend the duplicated descriptor is the first socket passed by systemd.
+	oldStart := listenFdsStart
+	listenFdsStart = int(f.Fd())
+	systemd.once = sync.Once{}
+	t.Cleanup(func() { listenFdsStart = oldStart; systemd.once = sync.Once{} })
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "api")
+
+	if _, err := Open("systemd:metrics"); err == nil {
+		t.Fatal("expected an error for an unknown socket name")
+	}
+	ls, err := Open("systemd:api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseAll(ls)
+	if len(ls) != 1 || ls[0].Addr().String() != api.Addr().String() {
+		t.Fatalf("unexpected listeners: %v", ls)
+	}
+	if _, err := Open("systemd"); err == nil {
+		t.Fatal("a socket must only be claimed once")
+	}
+}
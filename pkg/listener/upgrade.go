@@ -0,0 +1,264 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// upgradeEnv lists the names of the listeners passed to a new process, one per descriptor
+// starting at fd 4. Descriptor 3 is the pipe used to report readiness to the old process.
+const upgradeEnv = "LISTENER_UPGRADE_FDS"
+
+const (
+	readyFd          = 3
+	firstInheritedFd = 4
+)
+
+// ErrUpgradeInProgress is returned by Upgrade while another upgrade is running.
+var ErrUpgradeInProgress = errors.New("listener: an upgrade is already in progress")
+
+// Upgrader hands listening sockets over to a new process running an upgraded binary, so that
+// a deploy does not refuse connections: the new process inherits the sockets, reports that it
+// is ready, and only then does the old process stop accepting and drain its connections.
+//
+// Every process creates an Upgrader at startup, obtains its listeners through Listen, and
+// calls Ready once it is serving. Upgrade starts the new process.
+type Upgrader struct {
+	mu        sync.Mutex
+	inherited map[string][]net.Listener
+	named     []namedListener
+	parent    *os.File
+	pidFile   string
+	upgrading bool
+}
+
+type namedListener struct {
+	name string
+	net.Listener
+}
+
+// NewUpgrader returns an Upgrader. When the process was started by Upgrade, the inherited
+// listeners become available through Listen. pidFile, if not empty, is rewritten by Ready so
+// that a supervisor such as systemd can follow the process across upgrades.
+func NewUpgrader(pidFile string) (*Upgrader, error) {
+	u := &Upgrader{inherited: make(map[string][]net.Listener), pidFile: pidFile}
+
+	names := os.Getenv(upgradeEnv)
+	if names == "" {
+		return u, nil
+	}
+	// Do not pass the sockets on to unrelated child processes.
+	_ = os.Unsetenv(upgradeEnv)
+
+	u.parent = os.NewFile(readyFd, "upgrade-ready")
+	for i, name := range strings.Split(names, ",") {
+		l, err := inheritedListener(uintptr(firstInheritedFd + i))
+		if err != nil {
+			u.closeInherited()
+			return nil, err
+		}
+		u.inherited[name] = append(u.inherited[name], l)
+	}
+	return u, nil
+}
+
+// HasParent reports whether the process was started by Upgrade.
+func (u *Upgrader) HasParent() bool {
+	return u.parent != nil
+}
+
+// Listen returns the listeners inherited under name, or the result of open when there are none.
+// The listening addresses therefore cannot change across an upgrade; restart the service to
+// change them.
+func (u *Upgrader) Listen(name string, open func() ([]net.Listener, error)) ([]net.Listener, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	listeners, ok := u.inherited[name]
+	if ok {
+		delete(u.inherited, name)
+	} else {
+		var err error
+		if listeners, err = open(); err != nil {
+			return nil, err
+		}
+	}
+	for _, l := range listeners {
+		u.named = append(u.named, namedListener{name: name, Listener: l})
+	}
+	return listeners, nil
+}
+
+// Ready writes the PID file and tells the old process, if any, that it can drain and exit.
+// Inherited listeners that were not claimed through Listen are closed.
+func (u *Upgrader) Ready() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.closeInherited()
+	if u.pidFile != "" {
+		if err := writePIDFile(u.pidFile); err != nil {
+			return err
+		}
+	}
+	if u.parent == nil {
+		return nil
+	}
+	_, err := u.parent.Write([]byte{1})
+	_ = u.parent.Close()
+	u.parent = nil
+	return err
+}
+
+// Upgrade starts a new process from the current executable with the same arguments and
+// environment, passing it every listener obtained through Listen. It returns once the new
+// process has called Ready; the caller should then shut its servers down gracefully and exit.
+// If the new process exits or ctx ends first, the old process keeps serving and an error is
+// returned.
+func (u *Upgrader) Upgrade(ctx context.Context) error {
+	u.mu.Lock()
+	if u.upgrading {
+		u.mu.Unlock()
+		return ErrUpgradeInProgress
+	}
+	u.upgrading = true
+	named := append([]namedListener(nil), u.named...)
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+
+	fds := make([]uintptr, 0, len(named))
+	names := make([]string, 0, len(named))
+	defer func() {
+		for _, fd := range fds {
+			closeFd(fd)
+		}
+	}()
+	for _, l := range named {
+		fd, err := dupListener(l.Listener)
+		if err != nil {
+			_ = readyW.Close()
+			return fmt.Errorf("listener: pass %s listener %s: %w", l.name, l.Addr(), err)
+		}
+		fds = append(fds, fd)
+		names = append(names, l.name)
+	}
+
+	env := append(os.Environ(), upgradeEnv+"="+strings.Join(names, ","))
+	process, err := startProcess(env, readyW, fds)
+	_ = readyW.Close() // the new process holds the write end now
+	if err != nil {
+		return fmt.Errorf("listener: start new process: %w", err)
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if n, _ := readyR.Read(buf); n == 1 {
+			ready <- nil
+			return
+		}
+		ready <- errors.New("new process exited before it was ready")
+	}()
+	exited := make(chan error, 1)
+	go func() {
+		state, err := process.Wait()
+		if err == nil {
+			err = errors.New(state.String())
+		}
+		exited <- err
+	}()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			_ = process.Kill()
+			return fmt.Errorf("listener: upgrade failed: %w", err)
+		}
+	case err := <-exited:
+		return fmt.Errorf("listener: new process exited before it was ready: %v", err)
+	case <-ctx.Done():
+		_ = process.Kill()
+		return fmt.Errorf("listener: new process not ready: %w", ctx.Err())
+	}
+
+	// The new process owns the unix socket files now; do not remove them on shutdown.
+	for _, l := range named {
+		if ul, ok := l.Listener.(unixListener); ok {
+			if inner, ok := ul.Listener.(*net.UnixListener); ok {
+				inner.SetUnlinkOnClose(false)
+			}
+		}
+	}
+	return nil
+}
+
+func (u *Upgrader) closeInherited() {
+	for name, listeners := range u.inherited {
+		CloseAll(listeners)
+		delete(u.inherited, name)
+	}
+}
+
+// syscallConn returns the raw socket of a listener opened or inherited by this package.
+func syscallConn(l net.Listener) (syscall.RawConn, error) {
+	if ul, ok := l.(unixListener); ok {
+		l = ul.Listener
+	}
+	sc, ok := l.(syscall.Conn)
+	if !ok {
+		return nil, fmt.Errorf("unsupported listener type %T", l)
+	}
+	return sc.SyscallConn()
+}
+
+// inheritedListener rebuilds a listener passed by Upgrade. Unix sockets get the same loopback
+// peer address as listeners opened with Unix and remove their file when finally closed.
+func inheritedListener(fd uintptr) (net.Listener, error) {
+	l, err := fileListener(fd, "upgrade-"+strconv.Itoa(int(fd)))
+	if err != nil {
+		return nil, err
+	}
+	if ul, ok := l.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(true)
+		return unixListener{ul}, nil
+	}
+	return l, nil
+}
+
+// writePIDFile replaces path atomically so a supervisor never reads a partial PID.
+func writePIDFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".pid-*")
+	if err != nil {
+		return fmt.Errorf("listener: write PID file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("listener: write PID file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("listener: write PID file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("listener: write PID file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
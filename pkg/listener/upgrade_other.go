@@ -0,0 +1,21 @@
+//go:build !unix
+
+package listener
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+var errUpgradeUnsupported = errors.New("listener: binary upgrades are only supported on unix systems")
+
+func dupListener(net.Listener) (uintptr, error) {
+	return 0, errUpgradeUnsupported
+}
+
+func closeFd(uintptr) {}
+
+func startProcess([]string, *os.File, []uintptr) (*os.Process, error) {
+	return nil, errUpgradeUnsupported
+}
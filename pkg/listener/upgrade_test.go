@@ -0,0 +1,92 @@
+//go:build unix
+
+package listener
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUpgrader_WithoutParent(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "api.pid")
+	u, err := NewUpgrader(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.HasParent() {
+		t.Fatal("HasParent = true without an upgrade")
+	}
+
+	opened := false
+	ls, err := u.Listen("api", func() ([]net.Listener, error) {
+		opened = true
+		return Open("127.0.0.1:0")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseAll(ls)
+	if !opened || len(ls) != 1 {
+		t.Fatalf("Listen did not open the listener: opened=%v listeners=%v", opened, ls)
+	}
+
+	if err := u.Ready(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("PID file = %q, want %d", got, os.Getpid())
+	}
+}
+
+func TestInheritedListener_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	l, err := Unix(path, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fd, err := dupListener(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inherited, err := inheritedListener(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeFd(fd)
+
+	// The original owner hands the socket file over, as Upgrade does.
+	l.(unixListener).Listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+		}
+	}()
+	conn, err := inherited.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conn.RemoteAddr().String(); got != "127.0.0.1:0" {
+		t.Fatalf("RemoteAddr = %q, want loopback", got)
+	}
+	_ = conn.Close()
+
+	if err := inherited.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket file not removed by the new owner: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build unix
+
+package listener
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// dupListener duplicates the listener's socket descriptor. Unlike File, it leaves the socket
+// in non-blocking mode; a blocking socket would make the listener's Accept, and therefore
+// http.Server.Shutdown, hang.
+func dupListener(l net.Listener) (uintptr, error) {
+	raw, err := syscallConn(l)
+	if err != nil {
+		return 0, err
+	}
+	var dup int
+	var dupErr error
+	if err := raw.Control(func(fd uintptr) {
+		dup, dupErr = syscall.Dup(int(fd))
+		if dupErr == nil {
+			syscall.CloseOnExec(dup)
+		}
+	}); err != nil {
+		return 0, err
+	}
+	return uintptr(dup), dupErr
+}
+
+func closeFd(fd uintptr) {
+	_ = syscall.Close(int(fd))
+}
+
+// startProcess starts the current executable with the same arguments and standard streams.
+// ready becomes descriptor 3 and fds follow from 4. syscall.ForkExec is used instead of
+// os/exec because os.File.Fd would switch the shared sockets to blocking mode.
+func startProcess(env []string, ready *os.File, fds []uintptr) (*os.Process, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	readyRaw, err := ready.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var pid int
+	var forkErr error
+	if err := readyRaw.Control(func(readyFd uintptr) {
+		files := append([]uintptr{os.Stdin.Fd(), os.Stdout.Fd(), os.Stderr.Fd(), readyFd}, fds...)
+		pid, forkErr = syscall.ForkExec(executable, os.Args, &syscall.ProcAttr{
+			Env:   env,
+			Files: files,
+		})
+	}); err != nil {
+		return nil, err
+	}
+	if forkErr != nil {
+		return nil, forkErr
+	}
+	return os.FindProcess(pid)
+}
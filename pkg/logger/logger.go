@@ -2,15 +2,46 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
 
+// Log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// timestampFormat is the format of the time of every entry.
+const timestampFormat = "2006-01-02 15:04:05"
+
 // Log is the global logger instance.
 var Log *logrus.Logger
 
+// debugLog writes like Log, at debug level, the entries of the requests being debugged (see
+// DebugFields).
+var debugLog *logrus.Logger
+
+var (
+	// formatMu guards format, the format of Log and debugLog.
+	formatMu sync.Mutex
+	format   string
+)
+
+// redactHook masks personal data and credentials in every entry of Log.
+var redactHook = NewRedactHook(NewRedactor(nil, true))
+
+// SetRedactor configures the redaction applied to every log entry. Init starts with
+// DefaultRedactFields and email masking.
+func SetRedactor(r *Redactor) {
+	redactHook.SetRedactor(r)
+}
+
 // Init initializes the global logger with configuration.
 func Init() *logrus.Logger {
 	Log = logrus.New()
@@ -36,25 +67,116 @@ func Init() *logrus.Logger {
 		Log.SetLevel(logrus.InfoLevel)
 	}
 
+	debugLog = logrus.New()
+	debugLog.SetLevel(logrus.DebugLevel)
+
 	// Set log format
-	logFormat := strings.ToUpper(os.Getenv("LOG_FORMAT"))
-	if logFormat == "JSON" {
-		Log.SetFormatter(&logrus.JSONFormatter{
-			TimestampFormat: "2006-01-02 15:04:05",
-		})
-	} else {
-		Log.SetFormatter(&logrus.TextFormatter{
-			FullTimestamp:   true,
-			TimestampFormat: "2006-01-02 15:04:05",
-		})
+	logFormat := FormatText
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), FormatJSON) {
+		logFormat = FormatJSON
 	}
+	_ = SetFormat(logFormat)
 
 	// Set output
-	Log.SetOutput(os.Stdout)
+	SetOutput(os.Stdout)
+
+	// Redact sensitive fields before entries are formatted
+	Log.AddHook(redactHook)
+	debugLog.AddHook(redactHook)
 
 	return Log
 }
 
+// ParseLevel returns the level named name: trace, debug, info, warn, error, fatal or panic.
+func ParseLevel(name string) (logrus.Level, error) {
+	level, err := logrus.ParseLevel(name)
+	if err != nil {
+		return 0, fmt.Errorf("unknown log level %q: use trace, debug, info, warn, error, fatal or panic", name)
+	}
+	return level, nil
+}
+
+// Level returns the level of Log.
+func Level() logrus.Level {
+	if Log == nil {
+		Init()
+	}
+	return Log.GetLevel()
+}
+
+// SetLevel changes the level of Log.
+func SetLevel(level logrus.Level) {
+	if Log == nil {
+		Init()
+	}
+	Log.SetLevel(level)
+}
+
+// ParseFormat returns the format named name, text or json, in any case.
+func ParseFormat(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unknown log format %q: use text or json", name)
+}
+
+// Format returns the format of Log, text or json.
+func Format() string {
+	if Log == nil {
+		Init()
+	}
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	return format
+}
+
+// SetFormat changes the format of Log to name, text or json.
+func SetFormat(name string) error {
+	name, err := ParseFormat(name)
+	if err != nil {
+		return err
+	}
+	if Log == nil {
+		Init()
+	}
+
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	format = name
+	for _, l := range []*logrus.Logger{Log, debugLog} {
+		if name == FormatJSON {
+			l.SetFormatter(&logrus.JSONFormatter{TimestampFormat: timestampFormat})
+		} else {
+			l.SetFormatter(&logrus.TextFormatter{FullTimestamp: true, TimestampFormat: timestampFormat})
+		}
+	}
+	return nil
+}
+
+// SetOutput changes where entries are written.
+func SetOutput(w io.Writer) {
+	if Log == nil {
+		Init()
+	}
+	Log.SetOutput(w)
+	debugLog.SetOutput(w)
+}
+
+// DebugFields creates an entry with fields that logs debug entries even when Log is set to a
+// higher level, for the requests of routes being debugged (see RouteDebug).
+func DebugFields(fields logrus.Fields) *logrus.Entry {
+	if Log == nil {
+		Init()
+	}
+	if Log.IsLevelEnabled(logrus.DebugLevel) {
+		return Log.WithFields(fields)
+	}
+	return debugLog.WithFields(fields)
+}
+
 // WithFields creates a new logger entry with the specified fields.
 func WithFields(fields logrus.Fields) *logrus.Entry {
 	if Log == nil {
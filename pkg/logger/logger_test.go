@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetLevelAndFormat(t *testing.T) {
+	Init()
+	t.Cleanup(func() { Init() })
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	level, err := ParseLevel("WARNING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetLevel(level)
+	if err := SetFormat("JSON"); err != nil {
+		t.Fatal(err)
+	}
+	if Level() != logrus.WarnLevel || Format() != FormatJSON {
+		t.Fatalf("level = %s, format = %s; want warning and json", Level(), Format())
+	}
+
+	WithField("n", 1).Info("hidden")
+	WithField("n", 2).Warn("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, `"msg":"shown"`) {
+		t.Errorf("output = %s, want only the warning, as JSON", out)
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel accepted an unknown level")
+	}
+	if err := SetFormat("xml"); err == nil || Format() != FormatJSON {
+		t.Errorf("SetFormat(xml) = %v, format %s; want an error and no change", err, Format())
+	}
+}
+
+func TestDebugFields(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "info")
+	Init()
+	t.Cleanup(func() { Init() })
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	WithField("route", "/other").Debug("hidden")
+	DebugFields(logrus.Fields{"route": "/debugged", "password": "hunter2"}).Debug("shown")
+	out := buf.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("output = %s, want only the debug entry of the debugged route", out)
+	}
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("output = %s, want debug entries redacted too", out)
+	}
+}
+
+func TestRouteDebug(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	d := NewRouteDebug()
+	d.now = func() time.Time { return now }
+
+	until := d.Enable("/api/users/:id", 10*time.Minute)
+	d.Enable("/api/orgs", time.Minute)
+	if !until.Equal(now.Add(10*time.Minute)) || !d.Enabled("/api/users/:id") || d.Enabled("/api/users") {
+		t.Fatalf("until = %v, want /api/users/:id debugged for 10 minutes", until)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if routes := d.Routes(); len(routes) != 1 || routes[0].Route != "/api/users/:id" {
+		t.Errorf("routes = %+v, want the expired route forgotten", routes)
+	}
+	d.Enable("/api/users/:id", 0)
+	if d.Enabled("/api/users/:id") || len(d.Routes()) != 0 {
+		t.Error("a zero duration did not stop debugging the route")
+	}
+
+	var none *RouteDebug
+	if none.Enabled("/api/users/:id") {
+		t.Error("a nil RouteDebug debugged a route")
+	}
+}
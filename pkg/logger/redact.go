@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces the values of sensitive fields in log entries.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactFields are the field names whose values are always redacted. A field matches
+// when its name, ignoring case, '-' and '_', contains one of them, so "new_password" and
+// "X-Auth-Token" are covered too.
+var DefaultRedactFields = []string{
+	"password", "passwd", "secret", "token", "authorization", "auth_header",
+	"cookie", "api_key", "apikey", "private_key", "credential",
+}
+
+var (
+	// emailRegex matches email addresses; the local part is masked.
+	emailRegex = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	// credentialRegex matches credentials after an HTTP authorization scheme.
+	credentialRegex = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+	// jwtRegex matches JSON Web Tokens anywhere in a value.
+	jwtRegex = regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+)
+
+// Redactor removes personal data and credentials from log entries: values of sensitive fields
+// are replaced entirely, and tokens and (optionally) email addresses are masked in every other
+// string value and in the message.
+type Redactor struct {
+	fields []string
+	emails bool
+}
+
+// NewRedactor creates a redactor for DefaultRedactFields plus extraFields. When maskEmails is
+// true, email addresses are logged as j***@example.com.
+func NewRedactor(extraFields []string, maskEmails bool) *Redactor {
+	r := &Redactor{emails: maskEmails}
+	for _, name := range append(append([]string(nil), DefaultRedactFields...), extraFields...) {
+		if name = normalizeFieldName(name); name != "" {
+			r.fields = append(r.fields, name)
+		}
+	}
+	return r
+}
+
+// SensitiveField reports whether the value of the field name is redacted entirely.
+func (r *Redactor) SensitiveField(name string) bool {
+	name = normalizeFieldName(name)
+	for _, field := range r.fields {
+		if strings.Contains(name, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// String masks tokens and email addresses in s.
+func (r *Redactor) String(s string) string {
+	s = credentialRegex.ReplaceAllString(s, "${1} "+Redacted)
+	s = jwtRegex.ReplaceAllString(s, Redacted)
+	if r.emails {
+		s = emailRegex.ReplaceAllString(s, "${1}***@${2}")
+	}
+	return s
+}
+
+// Value redacts a field value. Errors and other values printed through String or Error are
+// converted to redacted strings, and nested field maps are redacted recursively.
+func (r *Redactor) Value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return r.String(v)
+	case []byte:
+		return r.String(string(v))
+	case error:
+		return r.String(v.Error())
+	case fmt.Stringer:
+		return r.String(v.String())
+	case logrus.Fields:
+		return r.Fields(v)
+	case map[string]interface{}:
+		return r.Fields(v)
+	default:
+		return v
+	}
+}
+
+// Fields returns a redacted copy of fields.
+func (r *Redactor) Fields(fields map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if r.SensitiveField(key) {
+			out[key] = Redacted
+			continue
+		}
+		out[key] = r.Value(value)
+	}
+	return out
+}
+
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(strings.TrimSpace(name)))
+}
+
+// RedactHook is a logrus hook that redacts every entry before it is formatted.
+type RedactHook struct {
+	redactor atomic.Pointer[Redactor]
+}
+
+// NewRedactHook creates a hook using r.
+func NewRedactHook(r *Redactor) *RedactHook {
+	h := &RedactHook{}
+	h.redactor.Store(r)
+	return h
+}
+
+// SetRedactor replaces the redactor used by the hook.
+func (h *RedactHook) SetRedactor(r *Redactor) {
+	h.redactor.Store(r)
+}
+
+// Levels implements logrus.Hook.
+func (h *RedactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook. logrus passes hooks a copy of the entry's fields, so redacting
+// them does not affect entries derived from the same logger.
+func (h *RedactHook) Fire(entry *logrus.Entry) error {
+	r := h.redactor.Load()
+	entry.Message = r.String(entry.Message)
+	for key, value := range entry.Data {
+		if r.SensitiveField(key) {
+			entry.Data[key] = Redacted
+			continue
+		}
+		entry.Data[key] = r.Value(value)
+	}
+	return nil
+}
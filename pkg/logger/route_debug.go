@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DebugRoute is a route debugged until a deadline.
+type DebugRoute struct {
+	Route string    `json:"route"`
+	Until time.Time `json:"until"`
+}
+
+// RouteDebug turns on debug logging for the requests of some routes, each until a deadline,
+// so that an instance can be debugged in production without restarting it or raising the
+// level of every entry. It is safe for concurrent use, and a nil *RouteDebug debugs no route.
+type RouteDebug struct {
+	now func() time.Time
+
+	mu     sync.RWMutex
+	routes map[string]time.Time
+}
+
+// NewRouteDebug returns a RouteDebug debugging no route.
+func NewRouteDebug() *RouteDebug {
+	return &RouteDebug{now: time.Now, routes: make(map[string]time.Time)}
+}
+
+// Enable debugs route, a path pattern as registered such as /api/users/:id, for d, and
+// returns when it stops. A d of zero or less stops debugging route.
+func (r *RouteDebug) Enable(route string, d time.Duration) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d <= 0 {
+		delete(r.routes, route)
+		return time.Time{}
+	}
+	until := r.now().Add(d).UTC()
+	r.routes[route] = until
+	return until
+}
+
+// Enabled reports whether route is being debugged.
+func (r *RouteDebug) Enabled(route string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	until, ok := r.routes[route]
+	r.mu.RUnlock()
+	return ok && r.now().Before(until)
+}
+
+// Routes returns the routes being debugged, sorted, and forgets those that expired.
+func (r *RouteDebug) Routes() []DebugRoute {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	routes := make([]DebugRoute, 0, len(r.routes))
+	for route, until := range r.routes {
+		if !now.Before(until) {
+			delete(r.routes, route)
+			continue
+		}
+		routes = append(routes, DebugRoute{Route: route, Until: until})
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Route < routes[j].Route })
+	return routes
+}
@@ -0,0 +1,105 @@
+// Package pagination provides helpers to parse pagination parameters and build pagination metadata.
+package pagination
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultPerPage is the page size used when none is requested.
+	DefaultPerPage = 20
+	// MaxPerPage is the largest page size a client can request.
+	MaxPerPage = 100
+)
+
+// Params holds the requested page and page size.
+type Params struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+}
+
+// Offset returns the number of records to skip.
+func (p Params) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// Limit returns the number of records to fetch.
+func (p Params) Limit() int {
+	return p.PerPage
+}
+
+// Meta describes the current page of a list response.
+type Meta struct {
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+	// TotalEstimated is set when Total comes from database statistics rather than a count.
+	TotalEstimated bool `json:"total_estimated,omitempty"`
+}
+
+// FromContext reads ?page= and ?per_page= from the query string, applying defaults and limits.
+func FromContext(c *gin.Context) Params {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err := strconv.Atoi(c.Query("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = DefaultPerPage
+	}
+	if perPage > MaxPerPage {
+		perPage = MaxPerPage
+	}
+
+	return Params{Page: page, PerPage: perPage}
+}
+
+// NewMeta builds pagination metadata for the given params and total record count.
+func NewMeta(params Params, total int64) Meta {
+	totalPages := 0
+	if params.PerPage > 0 {
+		totalPages = int((total + int64(params.PerPage) - 1) / int64(params.PerPage))
+	}
+
+	return Meta{
+		Page:       params.Page,
+		PerPage:    params.PerPage,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
+
+// SetLinkHeader adds an RFC 8288 Link header with the first, prev, next, and last pages, so
+// clients can follow a list without reading the body. The links keep the request's path and
+// other query parameters; they are relative, so they stay correct behind proxies without
+// trusting the Host header.
+func SetLinkHeader(c *gin.Context, meta Meta) {
+	last := meta.TotalPages
+	if last < 1 {
+		last = 1
+	}
+
+	links := []string{pageLink(c.Request.URL, meta, 1, "first")}
+	if meta.Page > 1 {
+		links = append(links, pageLink(c.Request.URL, meta, min(meta.Page-1, last), "prev"))
+	}
+	if meta.Page < last {
+		links = append(links, pageLink(c.Request.URL, meta, meta.Page+1, "next"))
+	}
+	links = append(links, pageLink(c.Request.URL, meta, last, "last"))
+
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+func pageLink(u *url.URL, meta Meta, page int, rel string) string {
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(meta.PerPage))
+	return "<" + u.EscapedPath() + "?" + query.Encode() + `>; rel="` + rel + `"`
+}
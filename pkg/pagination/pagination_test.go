@@ -0,0 +1,58 @@
+package pagination
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func linkHeader(t *testing.T, target string, meta Meta) string {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	SetLinkHeader(c, meta)
+	return c.Writer.Header().Get("Link")
+}
+
+func TestSetLinkHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		meta   Meta
+		want   string
+	}{
+		{
+			name:   "middle page keeps other parameters",
+			target: "/api/items?q=a+b&page=2",
+			meta:   Meta{Page: 2, PerPage: 10, Total: 25, TotalPages: 3},
+			want: `</api/items?page=1&per_page=10&q=a+b>; rel="first", ` +
+				`</api/items?page=1&per_page=10&q=a+b>; rel="prev", ` +
+				`</api/items?page=3&per_page=10&q=a+b>; rel="next", ` +
+				`</api/items?page=3&per_page=10&q=a+b>; rel="last"`,
+		},
+		{
+			name:   "empty list",
+			target: "/api/items",
+			meta:   Meta{Page: 1, PerPage: 20},
+			want:   `</api/items?page=1&per_page=20>; rel="first", </api/items?page=1&per_page=20>; rel="last"`,
+		},
+		{
+			name:   "page past the end points back to the last page",
+			target: "/api/items?page=9",
+			meta:   Meta{Page: 9, PerPage: 20, Total: 30, TotalPages: 2},
+			want: `</api/items?page=1&per_page=20>; rel="first", ` +
+				`</api/items?page=2&per_page=20>; rel="prev", ` +
+				`</api/items?page=2&per_page=20>; rel="last"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linkHeader(t, tt.target, tt.meta); got != tt.want {
+				t.Errorf("Link = %s\nwant   %s", got, tt.want)
+			}
+		})
+	}
+}
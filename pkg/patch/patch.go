@@ -0,0 +1,429 @@
+// Package patch applies partial updates sent with PATCH: JSON Merge Patch (RFC 7386) and JSON
+// Patch (RFC 6902). Bind applies the request body to the current state of a resource, so the
+// handler validates and saves the complete result like it would for a PUT.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+)
+
+// Media types of the supported patch formats. Plain application/json bodies are applied as
+// merge patches.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned for a malformed patch document or operation.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPathNotFound is returned when an operation refers to a location that does not exist.
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed is returned when a JSON Patch "test" operation does not match.
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// Bind applies the patch in the request body to target, a pointer to a struct holding the
+// current state of the resource. Fields missing from the result are left at their zero value,
+// and fields that target does not have are rejected. It returns an apperrors error: 409 when a
+// JSON Patch test fails, 400 otherwise.
+func Bind(c *gin.Context, target interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return apperrors.BadRequest("Invalid request data", "Could not read the request body")
+	}
+	current, err := json.Marshal(target)
+	if err != nil {
+		return apperrors.Internal("Could not apply patch", "Failed to encode the resource", err)
+	}
+
+	var patched []byte
+	switch mediaType {
+	case MergePatchType, "application/json":
+		patched, err = MergePatch(current, body)
+	case JSONPatchType:
+		patched, err = JSONPatch(current, body)
+	default:
+		return apperrors.BadRequest("Invalid Content-Type",
+			fmt.Sprintf("PATCH requires %s or %s", MergePatchType, JSONPatchType))
+	}
+	if errors.Is(err, ErrTestFailed) {
+		return apperrors.Conflict("Patch test failed", err.Error())
+	}
+	if err != nil {
+		return apperrors.BadRequest("Invalid patch", err.Error())
+	}
+
+	// Decode into a zero value so that removed fields do not keep their current value
+	fresh := reflect.New(reflect.TypeOf(target).Elem())
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(fresh.Interface()); err != nil {
+		return apperrors.BadRequest("Invalid patch", err.Error())
+	}
+	reflect.ValueOf(target).Elem().Set(fresh.Elem())
+	return nil
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7386) to doc: objects are merged recursively, null
+// removes a member, and any other value replaces the target.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := decode(doc, &target); err != nil {
+		return nil, err
+	}
+	var p interface{}
+	if err := decode(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// Operation is a JSON Patch (RFC 6902) operation.
+type Operation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from,omitempty"`
+	Value *json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch applies a JSON Patch (RFC 6902) to doc. The operations are applied in order and
+// the patch fails as a whole when any of them fails.
+func JSONPatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := decode(doc, &target); err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch is an array of operations", ErrInvalidPatch)
+	}
+
+	for i, op := range ops {
+		var err error
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func (op Operation) apply(doc interface{}) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: missing path", ErrInvalidPatch)
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+		var value interface{}
+		if err := decode(*op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if _, err := get(doc, path); err != nil {
+				return nil, err
+			}
+			return set(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(current, value) {
+				return nil, fmt.Errorf("%w: value at %s differs", ErrTestFailed, *op.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: missing from", ErrInvalidPatch)
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return add(doc, path, deepCopy(value))
+		}
+		if len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// get returns the value at path.
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[i]
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+		}
+	}
+	return doc, nil
+}
+
+// set replaces the value at path, which must exist, and returns the updated document.
+func set(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			container[i] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+	}, value)
+}
+
+// add inserts value at path: members are created or replaced and array elements are inserted,
+// with "-" appending to the array.
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			i := len(container)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(container)); err != nil {
+					return nil, err
+				}
+			}
+			container = append(container, nil)
+			copy(container[i+1:], container[i:])
+			container[i] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+	}, value)
+}
+
+// remove deletes the value at path, which must exist.
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+			}
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(container[:i], container[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+	}, nil)
+}
+
+// update calls change with the parent of path and its last token, and stores the container it
+// returns in place of the parent, since arrays may be reallocated. An empty path replaces the
+// whole document with root.
+func update(doc interface{}, path []string, change func(parent interface{}, token string) (interface{}, error), root interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return root, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	changed, err := change(parent, path[len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 1 {
+		return changed, nil
+	}
+	grandparentPath, parentToken := path[:len(path)-2], path[len(path)-2]
+	grandparent, _ := get(doc, grandparentPath)
+	switch container := grandparent.(type) {
+	case map[string]interface{}:
+		container[parentToken] = changed
+	case []interface{}:
+		i, _ := strconv.Atoi(parentToken)
+		container[i] = changed
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an array index token, which must be between 0 and max.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("%w: array index %d out of range", ErrPathNotFound, i)
+	}
+	return i, nil
+}
+
+// equal compares JSON values, treating numbers by value so that 1 and 1.0 match.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// decode parses JSON keeping numbers as json.Number, so large integers keep their precision.
+func decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
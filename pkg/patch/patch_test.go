@@ -0,0 +1,150 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/apperrors"
+)
+
+// assertJSON compares JSON documents regardless of member order.
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid expectation %s: %v", want, err)
+	}
+	gb, _ := json.Marshal(g)
+	wb, _ := json.Marshal(w)
+	if string(gb) != string(wb) {
+		t.Errorf("got %s, want %s", gb, wb)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// Example from RFC 7386, section 3
+	doc := `{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"],"content":"This will be unchanged"}`
+	p := `{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`
+
+	got, err := MergePatch([]byte(doc), []byte(p))
+	if err != nil {
+		t.Fatalf("MergePatch() error = %v", err)
+	}
+	assertJSON(t, got, `{"title":"Hello!","author":{"givenName":"John"},"tags":["example"],"content":"This will be unchanged","phoneNumber":"+01-123-456-7890"}`)
+
+	if _, err := MergePatch([]byte(doc), []byte(`{`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("error = %v, want ErrInvalidPatch", err)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`, nil},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{"append", `{"foo":[1]}`, `[{"op":"add","path":"/foo/-","value":2}]`, `{"foo":[1,2]}`, nil},
+		{"remove", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, nil},
+		{"move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`, nil},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`, nil},
+		{"test passes by value", `{"n":1}`, `[{"op":"test","path":"/n","value":1.0},{"op":"replace","path":"/n","value":2}]`, `{"n":2}`, nil},
+		{"test fails", `{"foo":"bar"}`, `[{"op":"test","path":"/foo","value":"baz"}]`, "", ErrTestFailed},
+		{"replace missing member", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`, "", ErrPathNotFound},
+		{"index out of range", `{"foo":[1]}`, `[{"op":"add","path":"/foo/2","value":2}]`, "", ErrPathNotFound},
+		{"unknown op", `{}`, `[{"op":"merge","path":"/a","value":1}]`, "", ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, "", ErrInvalidPatch},
+		{"move into itself", `{"a":{"b":{}}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, "", ErrInvalidPatch},
+		{"not an array", `{}`, `{"op":"add"}`, "", ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONPatch() error = %v", err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+type profile struct {
+	Name  string   `json:"name"`
+	Email string   `json:"email"`
+	Tags  []string `json:"tags"`
+}
+
+func bind(t *testing.T, contentType, body string, target *profile) error {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", contentType)
+	return Bind(c, target)
+}
+
+func TestBind(t *testing.T) {
+	p := profile{Name: "ana", Email: "ana@example.com", Tags: []string{"a"}}
+	if err := bind(t, MergePatchType, `{"name":"bea","tags":null}`, &p); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if p.Name != "bea" || p.Email != "ana@example.com" || p.Tags != nil {
+		t.Errorf("unexpected merge result %+v", p)
+	}
+
+	if err := bind(t, JSONPatchType+"; charset=utf-8", `[{"op":"replace","path":"/email","value":"bea@example.com"}]`, &p); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if p.Email != "bea@example.com" {
+		t.Errorf("unexpected JSON Patch result %+v", p)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"unknown field", MergePatchType, `{"role":"admin"}`, http.StatusBadRequest},
+		{"wrong type", MergePatchType, `{"name":1}`, http.StatusBadRequest},
+		{"failed test", JSONPatchType, `[{"op":"test","path":"/name","value":"zoe"}]`, http.StatusConflict},
+		{"unsupported type", "text/plain", `{}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := profile{Name: "ana"}
+			err := bind(t, tt.contentType, tt.body, &p)
+			appErr := apperrors.As(err)
+			if appErr == nil || appErr.Status() != tt.status {
+				t.Fatalf("error = %v, want status %d", err, tt.status)
+			}
+			if p.Name != "ana" {
+				t.Errorf("target modified on error: %+v", p)
+			}
+		})
+	}
+}
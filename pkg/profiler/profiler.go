@@ -0,0 +1,308 @@
+// Package profiler captures CPU and heap profiles of the process when its latency or its error
+// rate degrades, and keeps them in a storage.Storage, so that a transient slowdown in
+// production can be diagnosed after it is over with `go tool pprof`.
+//
+// Requests are observed over a window; at the end of each window, a p99 latency or an error
+// rate above its threshold triggers a capture, unless a capture happened during the cooldown.
+package profiler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+	"github.com/yeferson59/gin-template/pkg/storage"
+)
+
+// timeFormat names snapshots by when they were captured, so that their keys sort
+// chronologically.
+const timeFormat = "20060102T150405Z"
+
+// maxSamples bounds the latencies kept per window; beyond it a uniform sample is kept.
+const maxSamples = 10000
+
+var snapshotsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "profiler_snapshots_total",
+	Help: "Profile snapshots captured because of high latency or errors, by result (success or failure).",
+}, []string{"result"})
+
+func init() {
+	metrics.Registry.MustRegister(snapshotsTotal)
+}
+
+// Options configures a Profiler.
+type Options struct {
+	// P99 and ErrorRate are the thresholds that trigger a capture; zero disables each. They
+	// only apply to windows with at least MinRequests requests.
+	P99         time.Duration
+	ErrorRate   float64
+	MinRequests int
+	// Window is the period requests are observed over before the thresholds are checked.
+	Window time.Duration
+	// CPUDuration is how long the CPU is profiled for.
+	CPUDuration time.Duration
+	// Cooldown is the minimum time between captures.
+	Cooldown time.Duration
+
+	// Store keeps the snapshots under keys starting with Prefix; only the MaxSnapshots newest
+	// are kept (0 keeps them all).
+	Store        storage.Storage
+	Prefix       string
+	MaxSnapshots int
+}
+
+// Snapshot describes a capture. Its profiles are stored under Keys, next to the snapshot
+// itself as JSON.
+type Snapshot struct {
+	Time      time.Time `json:"time"`
+	Reason    string    `json:"reason"`
+	Requests  int       `json:"requests"`
+	P99       float64   `json:"p99_ms"`
+	ErrorRate float64   `json:"error_rate"`
+	Keys      []string  `json:"keys"`
+}
+
+// Profiler observes requests and captures profiles when they degrade. It is safe for
+// concurrent use.
+type Profiler struct {
+	opts Options
+	now  func() time.Time
+
+	mu       sync.Mutex
+	requests int
+	errors   int
+	samples  []time.Duration
+
+	// captureMu serializes captures; lastCapture is guarded by it.
+	captureMu   sync.Mutex
+	lastCapture time.Time
+}
+
+// New creates a profiler with opts.
+func New(opts Options) (*Profiler, error) {
+	switch {
+	case opts.Store == nil:
+		return nil, errors.New("profiler: a storage is required")
+	case opts.Window <= 0 || opts.CPUDuration <= 0:
+		return nil, errors.New("profiler: the window and the CPU profile duration must be positive")
+	case opts.P99 < 0 || opts.ErrorRate < 0 || opts.ErrorRate > 1 || opts.Cooldown < 0 || opts.MinRequests < 0 || opts.MaxSnapshots < 0:
+		return nil, errors.New("profiler: thresholds, cooldown and limits must not be negative, and the error rate at most 1")
+	}
+	return &Profiler{opts: opts, now: time.Now, samples: make([]time.Duration, 0, 1024)}, nil
+}
+
+// Observe records a request that ended with status after d.
+func (p *Profiler) Observe(status int, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests++
+	if status >= http.StatusInternalServerError {
+		p.errors++
+	}
+	if len(p.samples) < maxSamples {
+		p.samples = append(p.samples, d)
+	} else if i := rand.IntN(p.requests); i < maxSamples {
+		p.samples[i] = d
+	}
+}
+
+// windowStats returns the figures of the current window and starts a new one.
+func (p *Profiler) windowStats() (requests int, p99 time.Duration, errorRate float64) {
+	p.mu.Lock()
+	samples := p.samples
+	requests, errs := p.requests, p.errors
+	p.samples = make([]time.Duration, 0, cap(samples))
+	p.requests, p.errors = 0, 0
+	p.mu.Unlock()
+
+	if requests == 0 {
+		return 0, 0, 0
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	rank := int(math.Ceil(0.99*float64(len(samples)))) - 1
+	return requests, samples[max(rank, 0)], float64(errs) / float64(requests)
+}
+
+// Evaluate closes the current window and captures profiles when it crossed a threshold and no
+// capture happened during the cooldown. It reports whether it captured.
+func (p *Profiler) Evaluate(ctx context.Context) (Snapshot, bool, error) {
+	requests, p99, errorRate := p.windowStats()
+	if requests == 0 || requests < p.opts.MinRequests {
+		return Snapshot{}, false, nil
+	}
+
+	var reasons []string
+	if p.opts.P99 > 0 && p99 > p.opts.P99 {
+		reasons = append(reasons, fmt.Sprintf("p99 latency %s above %s", p99, p.opts.P99))
+	}
+	if p.opts.ErrorRate > 0 && errorRate > p.opts.ErrorRate {
+		reasons = append(reasons, fmt.Sprintf("error rate %.2f%% above %.2f%%", errorRate*100, p.opts.ErrorRate*100))
+	}
+	if len(reasons) == 0 {
+		return Snapshot{}, false, nil
+	}
+
+	if !p.captureMu.TryLock() {
+		// Still capturing the previous snapshot
+		return Snapshot{}, false, nil
+	}
+	defer p.captureMu.Unlock()
+	if !p.lastCapture.IsZero() && p.now().Sub(p.lastCapture) < p.opts.Cooldown {
+		return Snapshot{}, false, nil
+	}
+	p.lastCapture = p.now()
+
+	snapshot := Snapshot{
+		Reason:    strings.Join(reasons, "; "),
+		Requests:  requests,
+		P99:       float64(p99.Microseconds()) / 1000,
+		ErrorRate: errorRate,
+	}
+	err := p.capture(ctx, &snapshot)
+	if err != nil {
+		snapshotsTotal.WithLabelValues("failure").Inc()
+		return snapshot, false, err
+	}
+	snapshotsTotal.WithLabelValues("success").Inc()
+	return snapshot, true, p.prune(ctx)
+}
+
+// capture profiles the CPU for CPUDuration, then writes the CPU and heap profiles and the
+// snapshot to the storage.
+func (p *Profiler) capture(ctx context.Context, snapshot *Snapshot) error {
+	snapshot.Time = p.now().UTC()
+	base := p.opts.Prefix + snapshot.Time.Format(timeFormat)
+
+	var cpu bytes.Buffer
+	if err := pprof.StartCPUProfile(&cpu); err != nil {
+		return fmt.Errorf("failed to start the CPU profile: %w", err)
+	}
+	timer := time.NewTimer(p.opts.CPUDuration)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+	pprof.StopCPUProfile()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var heap bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		return fmt.Errorf("failed to write the heap profile: %w", err)
+	}
+
+	snapshot.Keys = []string{base + ".cpu.pprof", base + ".heap.pprof"}
+	for i, profile := range []*bytes.Buffer{&cpu, &heap} {
+		if err := p.opts.Store.Put(ctx, snapshot.Keys[i], profile); err != nil {
+			return fmt.Errorf("failed to store the profile: %w", err)
+		}
+	}
+	meta, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return p.opts.Store.Put(ctx, base+".json", bytes.NewReader(meta))
+}
+
+// List returns the snapshots, newest first.
+func (p *Profiler) List(ctx context.Context) ([]Snapshot, error) {
+	objects, err := p.opts.Store.List(ctx, p.opts.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for i := len(objects) - 1; i >= 0; i-- {
+		if !p.isSnapshot(objects[i].Key) {
+			continue
+		}
+		r, err := p.opts.Store.Get(ctx, objects[i].Key)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var s Snapshot
+		err = json.NewDecoder(r).Decode(&s)
+		_ = r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot %s: %w", objects[i].Key, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+// prune deletes the snapshots beyond MaxSnapshots, with their profiles.
+func (p *Profiler) prune(ctx context.Context) error {
+	if p.opts.MaxSnapshots == 0 {
+		return nil
+	}
+	snapshots, err := p.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range snapshots[min(p.opts.MaxSnapshots, len(snapshots)):] {
+		keys := append(s.Keys, p.opts.Prefix+s.Time.Format(timeFormat)+".json")
+		for _, key := range keys {
+			if err := p.opts.Store.Delete(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isSnapshot reports whether key is the JSON description of a snapshot.
+func (p *Profiler) isSnapshot(key string) bool {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(key, p.opts.Prefix), ".json")
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(timeFormat, name)
+	return err == nil
+}
+
+// Run evaluates every window until ctx is canceled, logging the captures.
+func (p *Profiler) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.Window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snapshot, captured, err := p.Evaluate(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				logger.WithFields(map[string]interface{}{
+					"reason": snapshot.Reason,
+					"error":  err.Error(),
+				}).Error("Failed to capture profile snapshot")
+			case captured:
+				logger.WithFields(map[string]interface{}{
+					"reason": snapshot.Reason,
+					"keys":   strings.Join(snapshot.Keys, ","),
+				}).Warn("Captured profile snapshot")
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package profiler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/storage"
+)
+
+func newProfiler(t *testing.T, opts Options) (*Profiler, *storage.Local) {
+	t.Helper()
+	store := storage.NewLocal(t.TempDir())
+	opts.Store = store
+	opts.Window = time.Minute
+	opts.CPUDuration = 10 * time.Millisecond
+	p, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, store
+}
+
+func observe(p *Profiler, n int, status int, d time.Duration) {
+	for i := 0; i < n; i++ {
+		p.Observe(status, d)
+	}
+}
+
+func TestEvaluate_CapturesOnHighLatency(t *testing.T) {
+	p, store := newProfiler(t, Options{P99: 500 * time.Millisecond, MinRequests: 10})
+	observe(p, 95, http.StatusOK, 10*time.Millisecond)
+	observe(p, 5, http.StatusOK, 2*time.Second)
+
+	snapshot, captured, err := p.Evaluate(context.Background())
+	if err != nil || !captured {
+		t.Fatalf("Evaluate = %v, %v; want a capture", captured, err)
+	}
+	if !strings.Contains(snapshot.Reason, "p99 latency 2s") || snapshot.Requests != 100 {
+		t.Errorf("snapshot = %+v", snapshot)
+	}
+	objects, err := store.List(context.Background(), "")
+	if err != nil || len(objects) != 3 {
+		t.Fatalf("stored %v, %v; want the CPU and heap profiles and the snapshot", objects, err)
+	}
+	for _, o := range objects {
+		if o.Size == 0 {
+			t.Errorf("%s is empty", o.Key)
+		}
+	}
+
+	// The window was reset
+	if _, captured, _ := p.Evaluate(context.Background()); captured {
+		t.Error("captured again without new requests")
+	}
+}
+
+func TestEvaluate_ThresholdsAndMinRequests(t *testing.T) {
+	p, _ := newProfiler(t, Options{P99: time.Second, ErrorRate: 0.1, MinRequests: 50})
+
+	observe(p, 10, http.StatusInternalServerError, time.Millisecond)
+	if _, captured, _ := p.Evaluate(context.Background()); captured {
+		t.Error("captured below MinRequests")
+	}
+
+	observe(p, 95, http.StatusOK, time.Millisecond)
+	observe(p, 5, http.StatusServiceUnavailable, time.Millisecond)
+	if _, captured, _ := p.Evaluate(context.Background()); captured {
+		t.Error("captured within the thresholds")
+	}
+
+	observe(p, 80, http.StatusOK, time.Millisecond)
+	observe(p, 20, http.StatusInternalServerError, time.Millisecond)
+	snapshot, captured, err := p.Evaluate(context.Background())
+	if err != nil || !captured || !strings.Contains(snapshot.Reason, "error rate 20.00%") {
+		t.Errorf("Evaluate = %+v, %v, %v; want a capture for the error rate", snapshot, captured, err)
+	}
+}
+
+func TestEvaluate_CooldownAndRetention(t *testing.T) {
+	p, _ := newProfiler(t, Options{ErrorRate: 0.5, Cooldown: time.Hour, MaxSnapshots: 2})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	capture := func() bool {
+		observe(p, 10, http.StatusInternalServerError, time.Millisecond)
+		_, captured, err := p.Evaluate(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return captured
+	}
+
+	if !capture() {
+		t.Fatal("no first capture")
+	}
+	now = now.Add(30 * time.Minute)
+	if capture() {
+		t.Error("captured during the cooldown")
+	}
+	for i := 0; i < 2; i++ {
+		now = now.Add(time.Hour)
+		if !capture() {
+			t.Fatal("no capture after the cooldown")
+		}
+	}
+
+	snapshots, err := p.List(context.Background())
+	if err != nil || len(snapshots) != 2 {
+		t.Fatalf("List = %d snapshots, %v; want the 2 newest", len(snapshots), err)
+	}
+	if !snapshots[0].Time.Equal(now) {
+		t.Errorf("newest snapshot at %v, want %v", snapshots[0].Time, now)
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	store := storage.NewLocal(t.TempDir())
+	for name, opts := range map[string]Options{
+		"no storage":     {Window: time.Minute, CPUDuration: time.Second},
+		"no window":      {Store: store, CPUDuration: time.Second},
+		"error rate > 1": {Store: store, Window: time.Minute, CPUDuration: time.Second, ErrorRate: 2},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package projection implements partial responses: clients list the fields they need with
+// ?fields=id,username,email and every other field is pruned from the serialized response.
+package projection
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryParam is the query parameter that lists the selected fields.
+const QueryParam = "fields"
+
+// Fields is a field selection. The zero value selects every field.
+type Fields struct {
+	names map[string]bool
+}
+
+// Parse parses a comma-separated field list. Names not in allowed are an error, so that a typo
+// does not silently return an empty object; an empty list selects every field.
+func Parse(raw string, allowed []string) (Fields, error) {
+	known := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		known[name] = true
+	}
+
+	var f Fields
+	var unknown []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			unknown = append(unknown, name)
+			continue
+		}
+		if f.names == nil {
+			f.names = make(map[string]bool)
+		}
+		f.names[name] = true
+	}
+	if len(unknown) > 0 {
+		return Fields{}, fmt.Errorf("unknown fields %s; available fields are %s",
+			strings.Join(unknown, ", "), strings.Join(allowed, ", "))
+	}
+	return f, nil
+}
+
+// FromContext parses the ?fields= query parameter against allowed.
+func FromContext(c *gin.Context, allowed []string) (Fields, error) {
+	return Parse(c.Query(QueryParam), allowed)
+}
+
+// All reports whether every field is selected.
+func (f Fields) All() bool {
+	return len(f.names) == 0
+}
+
+// Has reports whether name is selected.
+func (f Fields) Has(name string) bool {
+	return f.All() || f.names[name]
+}
+
+// Columns returns the database columns of the selected fields, given the column of each JSON
+// field, so the query can load only what is returned. It returns nil when every field is
+// selected, meaning all columns.
+func (f Fields) Columns(columns map[string]string) []string {
+	if f.All() {
+		return nil
+	}
+	selected := make([]string, 0, len(f.names))
+	for name := range f.names {
+		if column, ok := columns[name]; ok {
+			selected = append(selected, column)
+		}
+	}
+	sort.Strings(selected)
+	return selected
+}
+
+// Apply returns data with only the selected fields. path names the object keys leading to
+// the projected value, such as "users" for {"users": [...], "pagination": {...}}; without it
+// data itself is projected. A projected object keeps the selected keys and a projected array
+// keeps them in each of its objects. When every field is selected, data is returned as is.
+func (f Fields) Apply(data interface{}, path ...string) (interface{}, error) {
+	if f.All() {
+		return data, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	parent, ok := tree.(map[string]interface{})
+	if len(path) == 0 {
+		return f.prune(tree), nil
+	}
+	for _, key := range path[:len(path)-1] {
+		if !ok {
+			return nil, fmt.Errorf("projection: %q is not an object", key)
+		}
+		parent, ok = parent[key].(map[string]interface{})
+	}
+	if !ok {
+		return nil, fmt.Errorf("projection: path %s not found", strings.Join(path, "."))
+	}
+	last := path[len(path)-1]
+	parent[last] = f.prune(parent[last])
+	return tree, nil
+}
+
+func (f Fields) prune(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key := range v {
+			if !f.names[key] {
+				delete(v, key)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			f.prune(item)
+		}
+	}
+	return value
+}
+
+// JSONFields returns the JSON names of the fields of struct v, including those of embedded
+// structs, in declaration order. Fields tagged json:"-" are left out.
+func JSONFields(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			// encoding/json promotes the fields of embedded structs, exported or not
+			names = append(names, JSONFields(reflect.Zero(field.Type).Interface())...)
+			continue
+		}
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
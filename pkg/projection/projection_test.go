@@ -0,0 +1,105 @@
+package projection
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type base struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type item struct {
+	base
+	Name   string `json:"name"`
+	Secret string `json:"-"`
+	Price  int64  `json:"price,omitempty"`
+}
+
+type page struct {
+	Items []item `json:"items"`
+	Total int    `json:"total"`
+}
+
+func encode(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestJSONFields(t *testing.T) {
+	want := []string{"id", "created_at", "name", "price"}
+	if got := JSONFields(item{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONFields() = %v, want %v", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	allowed := JSONFields(item{})
+	f, err := Parse(" id, name ,", allowed)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if f.All() || !f.Has("id") || !f.Has("name") || f.Has("price") {
+		t.Errorf("unexpected selection %+v", f)
+	}
+
+	if f, err := Parse("", allowed); err != nil || !f.All() {
+		t.Errorf("empty list: %+v, %v", f, err)
+	}
+	if _, err := Parse("id,secret", allowed); err == nil {
+		t.Error("expected an error for a field that is not serialized")
+	}
+}
+
+func TestApply(t *testing.T) {
+	f, _ := Parse("id,price", JSONFields(item{}))
+	p := page{Items: []item{{base: base{ID: 1}, Name: "a", Price: 9007199254740993}, {base: base{ID: 2}, Name: "b"}}, Total: 2}
+
+	got, err := f.Apply(p, "items")
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	// Large numbers keep their precision, and omitted fields stay omitted
+	want := `{"items":[{"id":1,"price":9007199254740993},{"id":2}],"total":2}`
+	if encoded := encode(t, got); encoded != want {
+		t.Errorf("Apply() = %s, want %s", encoded, want)
+	}
+
+	got, err = f.Apply(p.Items[0])
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if encoded := encode(t, got); encoded != `{"id":1,"price":9007199254740993}` {
+		t.Errorf("Apply() = %s", encoded)
+	}
+
+	if _, err := f.Apply(p, "missing", "items"); err == nil {
+		t.Error("expected an error for a path that does not exist")
+	}
+}
+
+func TestApplyAllFieldsReturnsData(t *testing.T) {
+	p := page{Total: 1}
+	got, err := Fields{}.Apply(p, "items")
+	if err != nil || !reflect.DeepEqual(got, p) {
+		t.Errorf("Apply() = %+v, %v; want the data unchanged", got, err)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	f, _ := Parse("name,id", JSONFields(item{}))
+	columns := map[string]string{"id": "id", "name": "name", "created_at": "created_at"}
+	if got := f.Columns(columns); !reflect.DeepEqual(got, []string{"id", "name"}) {
+		t.Errorf("Columns() = %v", got)
+	}
+	if got := (Fields{}).Columns(columns); got != nil {
+		t.Errorf("Columns() = %v, want nil for every field", got)
+	}
+}
@@ -0,0 +1,49 @@
+// Package readonly holds the switch of the API's read-only mode, in which mutating requests are
+// rejected while the database is being migrated or failed over. The switch is per process:
+// toggling it at runtime affects only the instance that handled the toggle.
+package readonly
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+var readOnlyMode = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "read_only_mode",
+	Help: "Whether the API rejects mutating requests (1) or not (0).",
+})
+
+func init() {
+	metrics.Registry.MustRegister(readOnlyMode)
+}
+
+// Switch turns read-only mode on and off. It is safe for concurrent use.
+type Switch struct {
+	enabled atomic.Bool
+}
+
+// NewSwitch creates a switch starting in the given state.
+func NewSwitch(enabled bool) *Switch {
+	s := &Switch{}
+	s.Set(enabled)
+	return s
+}
+
+// Enabled reports whether read-only mode is on.
+func (s *Switch) Enabled() bool {
+	return s.enabled.Load()
+}
+
+// Set turns read-only mode on or off and reports whether that changed its state.
+func (s *Switch) Set(enabled bool) bool {
+	changed := s.enabled.Swap(enabled) != enabled
+	if enabled {
+		readOnlyMode.Set(1)
+	} else {
+		readOnlyMode.Set(0)
+	}
+	return changed
+}
@@ -0,0 +1,26 @@
+package readonly
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSwitch(t *testing.T) {
+	s := NewSwitch(false)
+	if s.Enabled() || testutil.ToFloat64(readOnlyMode) != 0 {
+		t.Fatal("NewSwitch(false) is enabled")
+	}
+	if !s.Set(true) || !s.Enabled() || testutil.ToFloat64(readOnlyMode) != 1 {
+		t.Fatal("Set(true) did not enable the switch")
+	}
+	if s.Set(true) {
+		t.Error("Set(true) on an enabled switch reported a change")
+	}
+	if !s.Set(false) || s.Enabled() || testutil.ToFloat64(readOnlyMode) != 0 {
+		t.Error("Set(false) did not disable the switch")
+	}
+	if !NewSwitch(true).Enabled() {
+		t.Error("NewSwitch(true) is disabled")
+	}
+}
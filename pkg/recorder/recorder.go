@@ -0,0 +1,262 @@
+// Package recorder saves sanitized copies of API requests and their responses to files, and
+// re-issues them against another instance, to reproduce bugs reported against a deployment.
+// Credentials, fields with sensitive names and (optionally) email addresses are redacted with
+// the same rules as the logs before anything is written.
+package recorder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// fileTimeLayout orders recording files by the time of the request.
+const fileTimeLayout = "20060102T150405.000000000"
+
+// defaultMaxBodyBytes is the body size kept when Options.MaxBodyBytes is not set.
+const defaultMaxBodyBytes = 64 << 10
+
+// oneTimeCodes are the fields redacted in addition to those of the Redactor, matched ignoring
+// case, '-' and '_': the device flow codes, which log entries never carry. Unlike the Redactor
+// fields they must match the whole name, so that fields such as status_code are kept.
+var oneTimeCodes = map[string]bool{"devicecode": true, "usercode": true}
+
+// requestCode is a field redacted from requests and URLs only: the login verification code
+// there, and the error code of the response envelope in responses.
+const requestCode = "code"
+
+// Message is the sanitized headers and body of a request or response. Body holds JSON bodies;
+// other bodies are omitted, and only their size is kept.
+type Message struct {
+	Header      http.Header     `json:"header,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	BodySize    int             `json:"body_size"`
+	BodyOmitted bool            `json:"body_omitted,omitempty"`
+	Truncated   bool            `json:"truncated,omitempty"`
+}
+
+// Exchange is a recorded request and its response.
+type Exchange struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	Route      string    `json:"route,omitempty"`
+	Status     int       `json:"status"`
+	DurationMS float64   `json:"duration_ms"`
+	Request    Message   `json:"request"`
+	Response   Message   `json:"response"`
+}
+
+// Options configures a Recorder.
+type Options struct {
+	// Dir is the directory of the recording files, created if missing.
+	Dir string
+	// Paths are the path prefixes of the recorded requests; empty records every request.
+	Paths []string
+	// MaxBodyBytes bounds the body kept of each request and response, before and after
+	// decompression (64KB by default).
+	MaxBodyBytes int
+	// Redactor sanitizes headers, query parameters and JSON bodies.
+	Redactor *logger.Redactor
+}
+
+// Recorder writes one file per exchange.
+type Recorder struct {
+	opts Options
+	seq  atomic.Uint64
+}
+
+// New creates a recorder writing to opts.Dir.
+func New(opts Options) (*Recorder, error) {
+	if opts.Redactor == nil {
+		opts.Redactor = logger.NewRedactor(nil, true)
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if err := os.MkdirAll(opts.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	return &Recorder{opts: opts}, nil
+}
+
+// MaxBodyBytes returns the body size kept of each request and response.
+func (r *Recorder) MaxBodyBytes() int {
+	return r.opts.MaxBodyBytes
+}
+
+// Records reports whether requests to path are recorded.
+func (r *Recorder) Records(path string) bool {
+	if len(r.opts.Paths) == 0 {
+		return true
+	}
+	for _, prefix := range r.opts.Paths {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// URL returns the path and query of u with sensitive query parameters redacted.
+func (r *Recorder) URL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	query := u.Query()
+	for name, values := range query {
+		for i, value := range values {
+			if r.sensitive(name, true) {
+				values[i] = logger.Redacted
+			} else {
+				values[i] = r.opts.Redactor.String(value)
+			}
+		}
+		query[name] = values
+	}
+	return u.Path + "?" + query.Encode()
+}
+
+// Message sanitizes the headers and body of a response. truncated reports that body is only
+// the first MaxBodyBytes of the body.
+func (r *Recorder) Message(header http.Header, body []byte, truncated bool) Message {
+	return r.message(header, body, truncated, false)
+}
+
+// RequestMessage sanitizes the headers and body of a request like Message, and also redacts
+// verification codes.
+func (r *Recorder) RequestMessage(header http.Header, body []byte, truncated bool) Message {
+	return r.message(header, body, truncated, true)
+}
+
+func (r *Recorder) message(header http.Header, body []byte, truncated, request bool) Message {
+	m := Message{Header: make(http.Header, len(header)), BodySize: len(body), Truncated: truncated}
+	for name, values := range header {
+		sanitized := make([]string, len(values))
+		for i, value := range values {
+			if r.sensitive(name, request) {
+				sanitized[i] = logger.Redacted
+			} else {
+				sanitized[i] = r.opts.Redactor.String(value)
+			}
+		}
+		m.Header[name] = sanitized
+	}
+	if len(body) == 0 {
+		return m
+	}
+
+	if header.Get("Content-Encoding") == "gzip" && !truncated {
+		if decoded, err := gunzip(body, r.opts.MaxBodyBytes); err == nil {
+			body = decoded
+		}
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if truncated || decoder.Decode(&value) != nil || decoder.More() {
+		m.BodyOmitted = true
+		return m
+	}
+	encoded, err := json.Marshal(r.sanitize(value, request))
+	if err != nil {
+		m.BodyOmitted = true
+		return m
+	}
+	m.Body = encoded
+	return m
+}
+
+// sensitive reports whether the value of the field, header or query parameter name of a
+// request or response is redacted.
+func (r *Recorder) sensitive(name string, request bool) bool {
+	normalized := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+	return oneTimeCodes[normalized] || (request && normalized == requestCode) || r.opts.Redactor.SensitiveField(name)
+}
+
+// sanitize redacts the values of sensitive fields and the tokens in strings of a decoded JSON
+// value of a request or response.
+func (r *Recorder) sanitize(value interface{}, request bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.sensitive(key, request) {
+				v[key] = logger.Redacted
+			} else {
+				v[key] = r.sanitize(field, request)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = r.sanitize(v[i], request)
+		}
+	case string:
+		return r.opts.Redactor.String(v)
+	}
+	return value
+}
+
+// Save writes e to a new file in the recording directory, readable by the owner only.
+func (r *Recorder) Save(e *Exchange) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%06d.json", e.Time.UTC().Format(fileTimeLayout), r.seq.Add(1))
+	return os.WriteFile(filepath.Join(r.opts.Dir, name), data, 0o600)
+}
+
+// Load reads the exchanges recorded in dir, oldest first.
+func Load(dir string) ([]Exchange, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	exchanges := make([]Exchange, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var e Exchange
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("invalid recording %s: %w", filepath.Base(file), err)
+		}
+		exchanges = append(exchanges, e)
+	}
+	sort.SliceStable(exchanges, func(i, j int) bool { return exchanges[i].Time.Before(exchanges[j].Time) })
+	return exchanges, nil
+}
+
+// gunzip decompresses body, failing when it expands beyond limit bytes so that a small
+// compressed body cannot exhaust memory.
+func gunzip(body []byte, limit int) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+	decoded, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) > limit {
+		return nil, fmt.Errorf("decompressed body exceeds %d bytes", limit)
+	}
+	return decoded, nil
+}
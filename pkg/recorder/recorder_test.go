@@ -0,0 +1,171 @@
+package recorder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+func newRecorder(t *testing.T, maxBody int) *Recorder {
+	t.Helper()
+	rec, err := New(Options{Dir: t.TempDir(), Paths: []string{"/api"}, MaxBodyBytes: maxBody, Redactor: logger.NewRedactor(nil, true)})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return rec
+}
+
+func TestMessageSanitizes(t *testing.T) {
+	rec := newRecorder(t, 1024)
+	header := http.Header{}
+	header.Set("Authorization", "Bearer abc.def.ghi")
+	header.Set("X-API-Key", "gt_secret")
+	header.Set("Content-Type", "application/json")
+	body := `{"username":"alice","email":"alice@example.com","password":"hunter22","items":[{"refresh_token":"r"}]}`
+
+	m := rec.Message(header, []byte(body), false)
+	if got := m.Header.Get("Authorization"); got != logger.Redacted {
+		t.Errorf("Authorization = %q, want it redacted", got)
+	}
+	if got := m.Header.Get("X-API-Key"); got != logger.Redacted {
+		t.Errorf("X-API-Key = %q, want it redacted", got)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(m.Body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["password"] != logger.Redacted || got["email"] != "a***@example.com" || got["username"] != "alice" {
+		t.Errorf("body = %s", m.Body)
+	}
+	if item := got["items"].([]interface{})[0].(map[string]interface{}); item["refresh_token"] != logger.Redacted {
+		t.Errorf("nested token not redacted: %s", m.Body)
+	}
+
+	if m := rec.Message(http.Header{}, []byte("<html>"), false); !m.BodyOmitted || m.Body != nil || m.BodySize != 6 {
+		t.Errorf("non-JSON body = %+v, want it omitted", m)
+	}
+	if m := rec.Message(http.Header{}, []byte(`{"a":`), true); !m.BodyOmitted {
+		t.Errorf("truncated body = %+v, want it omitted", m)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(`{"access_token":"x"}`))
+	_ = zw.Close()
+	gzipped := http.Header{}
+	gzipped.Set("Content-Encoding", "gzip")
+	if m := rec.Message(gzipped, gz.Bytes(), false); string(m.Body) != `{"access_token":"[REDACTED]"}` {
+		t.Errorf("gzipped body = %s", m.Body)
+	}
+
+	// Bodies expanding beyond the limit are not decompressed
+	gz.Reset()
+	zw = gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(`{"padding":"` + strings.Repeat("a", 4096) + `"}`))
+	_ = zw.Close()
+	if m := rec.Message(gzipped, gz.Bytes(), false); !m.BodyOmitted || gz.Len() >= 1024 {
+		t.Errorf("gzip bomb = %+v, want it omitted", m)
+	}
+}
+
+func TestMessageRedactsOneTimeCodes(t *testing.T) {
+	rec := newRecorder(t, 1024)
+	body := `{"code":"123456","device_code":"dc","userCode":"ABCD-EFGH","status_code":404}`
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(rec.RequestMessage(http.Header{}, []byte(body), false).Body, &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"code", "device_code", "userCode"} {
+		if got[field] != logger.Redacted {
+			t.Errorf("%s = %v, want it redacted", field, got[field])
+		}
+	}
+	if got["status_code"] != float64(404) {
+		t.Errorf("status_code = %v, want it kept", got["status_code"])
+	}
+
+	// Responses keep the error code of the envelope
+	response := `{"error":{"code":"UNAUTHORIZED"},"device_code":"dc"}`
+	if m := rec.Message(http.Header{}, []byte(response), false); string(m.Body) != `{"device_code":"[REDACTED]","error":{"code":"UNAUTHORIZED"}}` {
+		t.Errorf("response body = %s", m.Body)
+	}
+
+	u, _ := url.Parse("/device?user_code=ABCD-EFGH")
+	if got := rec.URL(u); got != "/device?user_code=%5BREDACTED%5D" {
+		t.Errorf("URL() = %s", got)
+	}
+}
+
+func TestURLAndPaths(t *testing.T) {
+	rec := newRecorder(t, 1024)
+	u, _ := url.Parse("/api/auth/email/confirm?token=secret&page=2")
+	if got := rec.URL(u); got != "/api/auth/email/confirm?page=2&token=%5BREDACTED%5D" {
+		t.Errorf("URL() = %s", got)
+	}
+	for path, want := range map[string]bool{"/api": true, "/api/users": true, "/apis": false, "/health/live": false} {
+		if got := rec.Records(path); got != want {
+			t.Errorf("Records(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestSaveLoadAndReplay(t *testing.T) {
+	rec := newRecorder(t, 1024)
+	start := time.Now()
+	header := http.Header{}
+	header.Set("Authorization", "Bearer old")
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-Length", "13")
+	exchanges := []Exchange{
+		{Time: start.Add(time.Second), Method: http.MethodGet, URL: "/api/users/me", Status: http.StatusOK, Request: rec.Message(header, nil, false)},
+		{Time: start, Method: http.MethodPost, URL: "/api/items?x=1", Status: http.StatusCreated, Request: rec.Message(header, []byte(`{"name":"a"}`), false)},
+		{Time: start.Add(2 * time.Second), Method: http.MethodPost, URL: "/api/upload", Status: http.StatusOK, Request: rec.Message(header, []byte("data"), false)},
+	}
+	for i := range exchanges {
+		if err := rec.Save(&exchanges[i]); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	loaded, err := Load(rec.opts.Dir)
+	if err != nil || len(loaded) != 3 {
+		t.Fatalf("Load() = %d exchanges, %v", len(loaded), err)
+	}
+	if loaded[0].URL != "/api/items?x=1" {
+		t.Errorf("first exchange = %s, want the oldest", loaded[0].URL)
+	}
+
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.URL.RequestURI()+" "+r.Header.Get("Authorization")+" "+strings.TrimSpace(string(body)))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	fresh := http.Header{"authorization": {"Bearer new"}}
+	for _, e := range loaded[:2] {
+		status, err := Replay(context.Background(), server.Client(), server.URL+"/", e, fresh)
+		if err != nil || status != http.StatusCreated {
+			t.Errorf("Replay(%s) = %d, %v", e.URL, status, err)
+		}
+	}
+	want := []string{`POST /api/items?x=1 Bearer new {"name":"a"}`, "GET /api/users/me Bearer new "}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("replayed requests:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if _, err := Replay(context.Background(), server.Client(), server.URL, loaded[2], nil); err != ErrIncomplete {
+		t.Errorf("Replay() of an omitted body error = %v, want ErrIncomplete", err)
+	}
+}
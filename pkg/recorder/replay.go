@@ -0,0 +1,71 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// ErrIncomplete is returned by Replay for exchanges whose request body was not recorded in
+// full, which cannot be re-issued.
+var ErrIncomplete = errors.New("request body was not recorded in full")
+
+// skippedHeaders are recorded request headers that are not re-issued: the client sets them
+// for the new connection.
+var skippedHeaders = []string{"Content-Length", "Accept-Encoding", "Connection"}
+
+// Replay re-issues the request of e against target, a base URL such as http://localhost:8080,
+// and returns the status of the response. Redacted headers are left out; header adds headers
+// to the request, replacing recorded ones, such as a fresh Authorization.
+func Replay(ctx context.Context, client *http.Client, target string, e Exchange, header http.Header) (int, error) {
+	if e.Request.Truncated || e.Request.BodyOmitted {
+		return 0, ErrIncomplete
+	}
+
+	// Recording files are indented
+	var body bytes.Buffer
+	if len(e.Request.Body) > 0 {
+		if err := json.Compact(&body, e.Request.Body); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, e.Method, strings.TrimSuffix(target, "/")+e.URL, &body)
+	if err != nil {
+		return 0, err
+	}
+	for name, values := range e.Request.Header {
+		if redacted(values) {
+			continue
+		}
+		req.Header[name] = values
+	}
+	for _, name := range skippedHeaders {
+		req.Header.Del(name)
+	}
+	for name, values := range header {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func redacted(values []string) bool {
+	for _, value := range values {
+		if strings.Contains(value, logger.Redacted) {
+			return true
+		}
+	}
+	return false
+}
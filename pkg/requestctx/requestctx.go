@@ -0,0 +1,158 @@
+// Package requestctx provides typed accessors for the values middlewares store in the Gin
+// context: the request ID, the authenticated user and API key, the organization of org-scoped
+// routes, and a logger carrying the request ID and user.
+//
+// Values are stored under the same keys as before ("request_id", "user_id", "role"...), so
+// code reading them with c.Get keeps working, but new code should use these accessors.
+package requestctx
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/events"
+	"github.com/yeferson59/gin-template/pkg/logger"
+)
+
+// Context keys.
+const (
+	requestIDKey     = "request_id"
+	userKey          = "user"
+	userIDKey        = "user_id"
+	emailKey         = "email"
+	usernameKey      = "username"
+	roleKey          = "role"
+	tokenIssuedAtKey = "token_issued_at"
+	loginRiskKey     = "login_risk"
+	organizationKey  = "organization"
+	membershipKey    = "membership"
+	apiKeyKey        = "api_key"
+	debugKey         = "debug_logging"
+)
+
+// SetRequestID stores the request ID.
+func SetRequestID(c *gin.Context, id string) {
+	c.Set(requestIDKey, id)
+}
+
+// RequestID returns the request ID, or "" before the RequestID middleware has run.
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// SetUser stores the authenticated user. issuedAt is when the user's token was issued, or the
+// zero time if unknown. The user also becomes the actor of the domain events published with the
+// request's context.
+func SetUser(c *gin.Context, user *models.User, issuedAt time.Time) {
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(events.WithActor(c.Request.Context(), user.ID))
+	}
+	c.Set(userKey, user)
+	c.Set(userIDKey, user.ID)
+	c.Set(emailKey, user.Email)
+	c.Set(usernameKey, user.Username)
+	c.Set(roleKey, user.Role)
+	if !issuedAt.IsZero() {
+		c.Set(tokenIssuedAtKey, issuedAt)
+	}
+}
+
+// CurrentUser returns the authenticated user, or false on routes without AuthRequired.
+func CurrentUser(c *gin.Context) (*models.User, bool) {
+	value, exists := c.Get(userKey)
+	if !exists {
+		return nil, false
+	}
+	user, ok := value.(*models.User)
+	return user, ok && user != nil
+}
+
+// UserID returns the authenticated user's ID, or 0 when there is none.
+func UserID(c *gin.Context) uint {
+	return c.GetUint(userIDKey)
+}
+
+// Role returns the authenticated user's role, or "" when there is none.
+func Role(c *gin.Context) string {
+	return c.GetString(roleKey)
+}
+
+// TokenIssuedAt returns when the authenticated user's token was issued.
+func TokenIssuedAt(c *gin.Context) (time.Time, bool) {
+	value, exists := c.Get(tokenIssuedAtKey)
+	if !exists {
+		return time.Time{}, false
+	}
+	issuedAt, ok := value.(time.Time)
+	return issuedAt, ok
+}
+
+// SetLoginRisk stores the risk score of the login that issued the user's token.
+func SetLoginRisk(c *gin.Context, risk int) {
+	c.Set(loginRiskKey, risk)
+}
+
+// LoginRisk returns the risk score of the login that issued the user's token, or 0 when unknown.
+func LoginRisk(c *gin.Context) int {
+	return c.GetInt(loginRiskKey)
+}
+
+// Logger returns a log entry carrying the request ID and, once authenticated, the user ID. It
+// logs debug entries whatever the log level when the request is being debugged.
+func Logger(c *gin.Context) *logrus.Entry {
+	fields := logrus.Fields{}
+	if id := RequestID(c); id != "" {
+		fields[requestIDKey] = id
+	}
+	if id := UserID(c); id != 0 {
+		fields[userIDKey] = id
+	}
+	if Debugging(c) {
+		return logger.DebugFields(fields)
+	}
+	return logger.WithFields(fields)
+}
+
+// SetDebugging marks the request as being debugged, because debug logging is on for its route.
+func SetDebugging(c *gin.Context) {
+	c.Set(debugKey, true)
+}
+
+// Debugging reports whether the request is being debugged.
+func Debugging(c *gin.Context) bool {
+	return c.GetBool(debugKey)
+}
+
+// SetOrganization stores the organization of an org-scoped route and the authenticated
+// user's membership in it.
+func SetOrganization(c *gin.Context, org *models.Organization, membership *models.Membership) {
+	c.Set(organizationKey, org)
+	c.Set(membershipKey, membership)
+}
+
+// Organization returns the organization of the route, or false on routes without OrgScope.
+func Organization(c *gin.Context) (*models.Organization, bool) {
+	org, ok := c.Value(organizationKey).(*models.Organization)
+	return org, ok && org != nil
+}
+
+// Membership returns the authenticated user's membership in the organization of the route,
+// or false on routes without OrgScope.
+func Membership(c *gin.Context) (*models.Membership, bool) {
+	membership, ok := c.Value(membershipKey).(*models.Membership)
+	return membership, ok && membership != nil
+}
+
+// SetAPIKey stores the API key the request was authenticated with.
+func SetAPIKey(c *gin.Context, key *models.APIKey) {
+	c.Set(apiKeyKey, key)
+}
+
+// APIKey returns the API key of the request, or false when it was authenticated otherwise.
+func APIKey(c *gin.Context) (*models.APIKey, bool) {
+	key, ok := c.Value(apiKeyKey).(*models.APIKey)
+	return key, ok && key != nil
+}
@@ -0,0 +1,94 @@
+package requestctx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/pkg/events"
+)
+
+func newContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	return c
+}
+
+func TestEmptyContext(t *testing.T) {
+	c := newContext()
+
+	if user, ok := CurrentUser(c); ok || user != nil {
+		t.Errorf("CurrentUser() = %v, %v, want nil, false", user, ok)
+	}
+	if id := UserID(c); id != 0 {
+		t.Errorf("UserID() = %d, want 0", id)
+	}
+	if role := Role(c); role != "" {
+		t.Errorf("Role() = %q, want empty", role)
+	}
+	if _, ok := TokenIssuedAt(c); ok {
+		t.Error("TokenIssuedAt() reported a value on an empty context")
+	}
+	if id := RequestID(c); id != "" {
+		t.Errorf("RequestID() = %q, want empty", id)
+	}
+}
+
+func TestSetUser(t *testing.T) {
+	c := newContext()
+	issuedAt := time.Now().Add(-time.Minute)
+	SetUser(c, &models.User{ID: 7, Username: "alice", Email: "alice@example.com", Role: models.RoleAdmin}, issuedAt)
+
+	user, ok := CurrentUser(c)
+	if !ok || user.Username != "alice" {
+		t.Fatalf("CurrentUser() = %v, %v", user, ok)
+	}
+	if UserID(c) != 7 || Role(c) != models.RoleAdmin {
+		t.Errorf("UserID() = %d, Role() = %q", UserID(c), Role(c))
+	}
+	if got, ok := TokenIssuedAt(c); !ok || !got.Equal(issuedAt) {
+		t.Errorf("TokenIssuedAt() = %v, %v, want %v", got, ok, issuedAt)
+	}
+
+	// Code still reading the raw keys sees the same values
+	if c.GetUint("user_id") != 7 || c.GetString("email") != "alice@example.com" {
+		t.Error("SetUser did not set the legacy context keys")
+	}
+}
+
+func TestSetUserWithoutIssuedAt(t *testing.T) {
+	c := newContext()
+	SetUser(c, &models.User{ID: 1}, time.Time{})
+
+	if _, ok := TokenIssuedAt(c); ok {
+		t.Error("TokenIssuedAt() reported a value for a zero issue time")
+	}
+}
+
+func TestSetUserSetsEventActor(t *testing.T) {
+	c := newContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	SetUser(c, &models.User{ID: 5}, time.Time{})
+
+	if got := events.ActorFromContext(c.Request.Context()); got != 5 {
+		t.Errorf("ActorFromContext() = %d, want 5", got)
+	}
+}
+
+func TestLoggerFields(t *testing.T) {
+	c := newContext()
+	if entry := Logger(c); len(entry.Data) != 0 {
+		t.Errorf("Logger() fields = %v, want none", entry.Data)
+	}
+
+	SetRequestID(c, "req-1")
+	SetUser(c, &models.User{ID: 3}, time.Time{})
+	entry := Logger(c)
+	if entry.Data["request_id"] != "req-1" || entry.Data["user_id"] != uint(3) {
+		t.Errorf("Logger() fields = %v", entry.Data)
+	}
+}
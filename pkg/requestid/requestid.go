@@ -0,0 +1,171 @@
+// Package requestid generates, validates, and propagates request IDs across service boundaries.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Header is the HTTP header carrying the request ID.
+	Header = "X-Request-ID"
+	// TraceparentHeader is the W3C Trace Context header.
+	TraceparentHeader = "traceparent"
+
+	// maxLength bounds inbound IDs so clients cannot inflate logs and headers.
+	maxLength = 128
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	traceparentKey
+)
+
+// New returns a new request ID. UUIDv7 IDs are unique across instances and sort by creation time.
+func New() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		// NewV7 only fails when the system random source fails; fall back to a v4 UUID.
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// Valid reports whether an inbound request ID is safe to accept: 1 to 128 characters of
+// letters, digits, and ".", "_", ":", "-". This rules out log and header injection.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == ':', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// FromTraceparent extracts the trace ID from a W3C traceparent header
+// ("00-<32 hex trace-id>-<16 hex parent-id>-<2 hex flags>").
+func FromTraceparent(traceparent string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", false
+	}
+	if parts[0] == "ff" || !isLowerHex(parts[1]) || strings.Trim(parts[1], "0") == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// ValidTraceparent reports whether traceparent is a well-formed W3C traceparent header.
+func ValidTraceparent(traceparent string) bool {
+	_, ok := FromTraceparent(traceparent)
+	return ok
+}
+
+// ChildTraceparent returns the traceparent of an outbound call made while handling a request
+// that carried traceparent: the same trace ID and flags under a new random parent ID, so that
+// the downstream span is the child of a span of this service rather than a sibling of it. It
+// returns "" when traceparent is not valid.
+func ChildTraceparent(traceparent string) string {
+	traceparent = strings.TrimSpace(traceparent)
+	traceID, ok := FromTraceparent(traceparent)
+	if !ok {
+		return ""
+	}
+	flags := traceparent[len(traceparent)-2:]
+	var spanID [8]byte
+	for {
+		if _, err := rand.Read(spanID[:]); err != nil {
+			return ""
+		}
+		// An all-zero parent ID is invalid
+		if spanID != [8]byte{} {
+			break
+		}
+	}
+	return "00-" + traceID + "-" + hex.EncodeToString(spanID[:]) + "-" + flags
+}
+
+// WithContext returns a copy of ctx carrying the request ID.
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// FromContext returns the request ID stored in ctx, or "" when there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithTraceparent returns a copy of ctx carrying the inbound traceparent header.
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	return context.WithValue(ctx, traceparentKey, traceparent)
+}
+
+// TraceparentFromContext returns the traceparent stored in ctx, or "" when there is none.
+func TraceparentFromContext(ctx context.Context) string {
+	tp, _ := ctx.Value(traceparentKey).(string)
+	return tp
+}
+
+// Inject copies the request ID from the request context into its headers, along with a child
+// of the inbound traceparent (see ChildTraceparent), without overwriting headers the caller
+// already set. Every call gets its own parent ID.
+func Inject(req *http.Request) {
+	ctx := req.Context()
+	if id := FromContext(ctx); id != "" && req.Header.Get(Header) == "" {
+		req.Header.Set(Header, id)
+	}
+	if req.Header.Get(TraceparentHeader) != "" {
+		return
+	}
+	if tp := ChildTraceparent(TraceparentFromContext(ctx)); tp != "" {
+		req.Header.Set(TraceparentHeader, tp)
+	}
+}
+
+// Transport is an http.RoundTripper that propagates the request ID of the request context
+// to outbound calls.
+type Transport struct {
+	// Base is the underlying transport. http.DefaultTransport is used when nil.
+	Base http.RoundTripper
+}
+
+// NewTransport wraps base so outbound requests carry the request ID.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// RoundTrippers must not modify the caller's request.
+	out := req.Clone(req.Context())
+	Inject(out)
+	return base.RoundTrip(out)
+}
+
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewIsUniqueAndValid(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := New()
+		if !Valid(id) {
+			t.Fatalf("generated ID %q is not valid", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate ID %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestValid(t *testing.T) {
+	cases := map[string]bool{
+		"abc-123":                 true,
+		"svc:req_1.2":             true,
+		"":                        false,
+		"has space":               false,
+		"line\nbreak":             false,
+		"<script>":                false,
+		string(make([]byte, 129)): false,
+	}
+	for id, want := range cases {
+		if got := Valid(id); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestFromTraceparent(t *testing.T) {
+	id, ok := FromTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || id != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("unexpected trace id %q (ok=%v)", id, ok)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+	} {
+		if _, ok := FromTraceparent(invalid); ok {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestChildTraceparent(t *testing.T) {
+	const inbound = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	first, second := ChildTraceparent(inbound), ChildTraceparent(inbound)
+	for _, child := range []string{first, second} {
+		if traceID, ok := FromTraceparent(child); !ok || traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("child %q does not continue the trace", child)
+		}
+		if child[len(child)-3:] != "-01" {
+			t.Errorf("child %q lost the trace flags", child)
+		}
+	}
+	if first == inbound || first == second {
+		t.Errorf("children %q and %q must each get a new parent ID", first, second)
+	}
+	if got := ChildTraceparent("not-a-traceparent"); got != "" {
+		t.Errorf("ChildTraceparent(invalid) = %q, want empty", got)
+	}
+}
+
+func TestTransportPropagatesRequestID(t *testing.T) {
+	var gotID, gotTrace string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		gotID = r.Header.Get(Header)
+		gotTrace = r.Header.Get(TraceparentHeader)
+	}))
+	defer server.Close()
+
+	ctx := WithContext(context.Background(), "req-42")
+	ctx = WithTraceparent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if gotID != "req-42" || gotTrace == "" {
+		t.Fatalf("headers not propagated: id=%q traceparent=%q", gotID, gotTrace)
+	}
+	if traceID, _ := FromTraceparent(gotTrace); traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || gotTrace[36:52] == "00f067aa0ba902b7" {
+		t.Fatalf("traceparent = %q; want the inbound trace under a new parent ID", gotTrace)
+	}
+	if req.Header.Get(Header) != "" {
+		t.Fatal("transport modified the caller's request")
+	}
+}
@@ -0,0 +1,124 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// ErrorCode is a machine-readable error code from the catalog. Error responses can only be
+// written with a registered code, so every code a client may receive is documented and listed
+// by GET /api/errors.
+type ErrorCode struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// String returns the code.
+func (e *ErrorCode) String() string {
+	return e.Code
+}
+
+var (
+	errorCodes   = make(map[string]*ErrorCode)
+	errorCodesMu sync.RWMutex
+
+	errorCodeRegex = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// NewErrorCode registers an error code returned with the given HTTP status. It is meant for
+// package-level variables and panics if the code is malformed or already registered.
+func NewErrorCode(code string, status int, title, description string) *ErrorCode {
+	if !errorCodeRegex.MatchString(code) {
+		panic(fmt.Sprintf("response: invalid error code %q, use UPPER_SNAKE_CASE", code))
+	}
+	if http.StatusText(status) == "" || status < http.StatusBadRequest {
+		panic(fmt.Sprintf("response: invalid status %d for error code %s", status, code))
+	}
+
+	errorCodesMu.Lock()
+	defer errorCodesMu.Unlock()
+	if _, exists := errorCodes[code]; exists {
+		panic(fmt.Sprintf("response: error code %s registered twice", code))
+	}
+	e := &ErrorCode{Code: code, Status: status, Title: title, Description: description}
+	errorCodes[code] = e
+	return e
+}
+
+// LookupErrorCode returns the registered error code named code.
+func LookupErrorCode(code string) (*ErrorCode, bool) {
+	errorCodesMu.RLock()
+	defer errorCodesMu.RUnlock()
+	e, ok := errorCodes[code]
+	return e, ok
+}
+
+// ErrorCodes returns every registered error code, sorted by status and code.
+func ErrorCodes() []ErrorCode {
+	errorCodesMu.RLock()
+	codes := make([]ErrorCode, 0, len(errorCodes))
+	for _, e := range errorCodes {
+		codes = append(codes, *e)
+	}
+	errorCodesMu.RUnlock()
+
+	sort.Slice(codes, func(i, j int) bool {
+		if codes[i].Status != codes[j].Status {
+			return codes[i].Status < codes[j].Status
+		}
+		return codes[i].Code < codes[j].Code
+	})
+	return codes
+}
+
+// The error code catalog. Register codes for new error conditions here, or with NewErrorCode
+// in the package that returns them, instead of reusing a code with a different meaning.
+var (
+	CodeBadRequest = NewErrorCode("BAD_REQUEST", http.StatusBadRequest, "Bad request",
+		"The request body, query, or path could not be parsed or has invalid values.")
+	CodeValidation = NewErrorCode("VALIDATION_ERROR", http.StatusBadRequest, "Validation failed",
+		"One or more fields are invalid; error.fields lists each field with its own code.")
+	CodeUnauthorized = NewErrorCode("UNAUTHORIZED", http.StatusUnauthorized, "Unauthorized",
+		"Authentication is missing or invalid: no token, a malformed or expired token, or wrong credentials.")
+	CodeStepUpRequired = NewErrorCode("STEP_UP_REQUIRED", http.StatusUnauthorized, "Re-authentication required",
+		"The request looks risky and the token is too old; log in again and retry with the new token.")
+	CodePlanRequired = NewErrorCode("PLAN_REQUIRED", http.StatusPaymentRequired, "Plan required",
+		"The endpoint needs a paid plan the current user is not subscribed to.")
+	CodeForbidden = NewErrorCode("FORBIDDEN", http.StatusForbidden, "Forbidden",
+		"The authenticated user lacks the role or permission required.")
+	CodeRegionBlocked = NewErrorCode("REGION_BLOCKED", http.StatusForbidden, "Region not allowed",
+		"Requests from this country or network are not accepted on this endpoint.")
+	CodePlanLimitReached = NewErrorCode("PLAN_LIMIT_REACHED", http.StatusForbidden, "Plan limit reached",
+		"The current plan does not allow more of this resource; upgrade the plan or remove some first.")
+	CodeNotFound = NewErrorCode("NOT_FOUND", http.StatusNotFound, "Not found",
+		"The resource does not exist or is not visible to the current user.")
+	CodeMethodNotAllowed = NewErrorCode("METHOD_NOT_ALLOWED", http.StatusMethodNotAllowed, "Method not allowed",
+		"The path exists but does not accept this method; the Allow header lists the methods it accepts.")
+	CodeConflict = NewErrorCode("CONFLICT", http.StatusConflict, "Conflict",
+		"The request conflicts with the current state, such as a duplicate username or email.")
+	CodeConcurrentUpdate = NewErrorCode("CONCURRENT_UPDATE", http.StatusConflict, "Concurrent update",
+		"The request lost a race with a concurrent one and nothing was changed; retry it after the Retry-After delay.")
+	CodeRolledBack = NewErrorCode("ROLLED_BACK", http.StatusFailedDependency, "Rolled back",
+		"The item of an atomic batch was not applied because another item failed, so the whole batch was rolled back.")
+	CodeTermsNotAccepted = NewErrorCode("TERMS_NOT_ACCEPTED", http.StatusUnavailableForLegalReasons, "Terms not accepted",
+		"The current terms of service must be accepted with POST /api/users/me/consents before using this endpoint.")
+	CodeRateLimitExceeded = NewErrorCode("RATE_LIMIT_EXCEEDED", http.StatusTooManyRequests, "Rate limit exceeded",
+		"Too many requests from this client; wait before retrying.")
+	CodeAuthRateLimitExceeded = NewErrorCode("AUTH_RATE_LIMIT_EXCEEDED", http.StatusTooManyRequests, "Authentication rate limit exceeded",
+		"Too many login or registration attempts from this client; wait before retrying.")
+	CodeQuotaExceeded = NewErrorCode("QUOTA_EXCEEDED", http.StatusTooManyRequests, "Quota exceeded",
+		"The API key used its monthly request quota; the X-Quota-Reset header tells when it resets.")
+	CodeInternal = NewErrorCode("INTERNAL_SERVER_ERROR", http.StatusInternalServerError, "Internal server error",
+		"An unexpected error occurred. Report the request ID if it persists.")
+	CodeServiceUnavailable = NewErrorCode("SERVICE_UNAVAILABLE", http.StatusServiceUnavailable, "Service unavailable",
+		"A dependency such as the database is unavailable, or the server is starting or shutting down; retry later.")
+	CodeServerOverloaded = NewErrorCode("SERVER_OVERLOADED", http.StatusServiceUnavailable, "Server overloaded",
+		"The server is handling too many requests; retry after the Retry-After delay.")
+	CodeReadOnly = NewErrorCode("READ_ONLY", http.StatusServiceUnavailable, "Read-only mode",
+		"The API is temporarily read-only, such as during a database migration or failover; only reads are served, retry writes later.")
+)
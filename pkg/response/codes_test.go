@@ -0,0 +1,41 @@
+package response
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewErrorCode_RegistersOnce(t *testing.T) {
+	code := NewErrorCode("TEST_ONLY_CODE", http.StatusTeapot, "Teapot", "Used by tests.")
+	if got, ok := LookupErrorCode("TEST_ONLY_CODE"); !ok || got != code {
+		t.Fatalf("LookupErrorCode = %v, %v", got, ok)
+	}
+
+	for name, register := range map[string]func(){
+		"duplicate":  func() { NewErrorCode("TEST_ONLY_CODE", http.StatusTeapot, "", "") },
+		"lowercase":  func() { NewErrorCode("not_upper", http.StatusBadRequest, "", "") },
+		"success":    func() { NewErrorCode("TEST_SUCCESS", http.StatusOK, "", "") },
+		"bad status": func() { NewErrorCode("TEST_BAD_STATUS", 499, "", "") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected a panic")
+				}
+			}()
+			register()
+		})
+	}
+}
+
+func TestErrorCodes_SortedAndDocumented(t *testing.T) {
+	codes := ErrorCodes()
+	for i, code := range codes {
+		if code.Title == "" || code.Description == "" {
+			t.Errorf("%s is missing its documentation", code.Code)
+		}
+		if i > 0 && (codes[i-1].Status > code.Status || codes[i-1].Status == code.Status && codes[i-1].Code > code.Code) {
+			t.Errorf("codes are not sorted at %s", code.Code)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+)
+
+// ProblemContentType is the media type for RFC 9457 Problem Details.
+const ProblemContentType = "application/problem+json"
+
+// ErrorFormat selects how error responses are rendered.
+type ErrorFormat string
+
+// Supported error formats.
+const (
+	// FormatEnvelope renders errors inside the standard APIResponse envelope.
+	FormatEnvelope ErrorFormat = "envelope"
+	// FormatProblem renders errors as RFC 9457 Problem Details.
+	FormatProblem ErrorFormat = "problem"
+)
+
+// ProblemDetails is an RFC 9457 problem document. Code, RequestID and Errors are extension members.
+type ProblemDetails struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+var (
+	errorFormat     = FormatEnvelope
+	problemTypeBase string
+	problemMu       sync.RWMutex
+)
+
+// SetErrorFormat sets the default error format. Clients can still request Problem Details
+// by sending "Accept: application/problem+json".
+func SetErrorFormat(format ErrorFormat) {
+	problemMu.Lock()
+	defer problemMu.Unlock()
+	if format != FormatProblem {
+		format = FormatEnvelope
+	}
+	errorFormat = format
+}
+
+// SetProblemTypeBase sets the URI prefix used to build problem "type" members from error codes,
+// e.g. "https://api.example.com/problems/" turns VALIDATION_ERROR into ".../validation-error".
+// When empty, "about:blank" is used as RFC 9457 recommends.
+func SetProblemTypeBase(base string) {
+	problemMu.Lock()
+	defer problemMu.Unlock()
+	if base != "" && !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	problemTypeBase = base
+}
+
+// wantsProblem reports whether the error for this request should be rendered as Problem Details.
+func wantsProblem(c *gin.Context) bool {
+	if strings.Contains(c.GetHeader("Accept"), ProblemContentType) {
+		return true
+	}
+
+	problemMu.RLock()
+	defer problemMu.RUnlock()
+	return errorFormat == FormatProblem
+}
+
+// newProblem converts an APIError into a Problem Details document.
+func newProblem(c *gin.Context, statusCode int, apiErr *APIError) ProblemDetails {
+	problemMu.RLock()
+	base := problemTypeBase
+	problemMu.RUnlock()
+
+	problem := ProblemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    apiErr.Details,
+		Instance:  c.Request.URL.Path,
+		Code:      apiErr.Code,
+		RequestID: requestctx.RequestID(c),
+		Errors:    apiErr.Fields,
+	}
+
+	if base != "" {
+		problem.Type = base + strings.ReplaceAll(strings.ToLower(apiErr.Code), "_", "-")
+		problem.Title = apiErr.Message
+	}
+	if problem.Detail == "" {
+		problem.Detail = apiErr.Message
+	}
+
+	return problem
+}
+
+// writeError renders an error in the negotiated format. Problem Details bypass the
+// Serializer since their shape is fixed by RFC 9457.
+func writeError(c *gin.Context, statusCode int, apiErr *APIError) {
+	if wantsProblem(c) {
+		c.Header("Content-Type", ProblemContentType)
+		writeJSON(c, statusCode, newProblem(c, statusCode, apiErr))
+		return
+	}
+
+	if statusCode >= http.StatusInternalServerError && apiErr.RequestID == "" {
+		apiErr.RequestID = requestctx.RequestID(c)
+	}
+	writeJSON(c, statusCode, currentSerializer().Error(c, statusCode, apiErr))
+}
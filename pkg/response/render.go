@@ -0,0 +1,70 @@
+package response
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/codec/json"
+)
+
+// maxPooledBufferSize keeps unusually large responses from pinning memory in the pool.
+const maxPooledBufferSize = 64 << 10
+
+// jsonBuffer pairs a buffer with an encoder writing to it, so both are reused.
+type jsonBuffer struct {
+	buf bytes.Buffer
+	enc json.Encoder
+}
+
+var (
+	jsonContentType = []string{"application/json; charset=utf-8"}
+	bufferPool      = sync.Pool{New: func() interface{} {
+		b := new(jsonBuffer)
+		b.enc = json.API.NewEncoder(&b.buf)
+		return b
+	}}
+)
+
+// pooledJSON renders a body with gin's JSON codec into a pooled buffer and encoder, which
+// saves allocating and copying json.Marshal's result on every response. The codec is chosen
+// at build time: encoding/json by default, or goccy/go-json, json-iterator, or sonic with the
+// go_json, jsoniter, or sonic build tags. The output matches c.JSON.
+type pooledJSON struct {
+	data interface{}
+}
+
+// Render implements render.Render.
+func (r pooledJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	b := bufferPool.Get().(*jsonBuffer)
+	b.buf.Reset()
+	defer func() {
+		if b.buf.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(b)
+		}
+	}()
+
+	if err := b.enc.Encode(r.data); err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline, which c.JSON does not write.
+	_, err := w.Write(bytes.TrimSuffix(b.buf.Bytes(), []byte{'\n'}))
+	return err
+}
+
+// WriteContentType implements render.Render. A content type set beforehand, such as
+// application/problem+json, is kept.
+func (r pooledJSON) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = jsonContentType
+	}
+}
+
+// writeJSON writes data with the given status as JSON.
+func writeJSON(c *gin.Context, statusCode int, data interface{}) {
+	c.Render(statusCode, pooledJSON{data: data})
+}
@@ -0,0 +1,78 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// benchmarkPayload is a typical list response body.
+var benchmarkPayload = gin.H{
+	"users": []gin.H{
+		{"id": 1, "username": "alice", "email": "alice@example.com", "role": "admin"},
+		{"id": 2, "username": "bob", "email": "bob@example.com", "role": "user"},
+		{"id": 3, "username": "carol <c&o>", "email": "carol@example.com", "role": "user"},
+	},
+	"total": 3,
+}
+
+func TestWriteJSONMatchesGinJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := APIResponse{Success: true, Message: "Users retrieved", Data: benchmarkPayload}
+
+	render := func(handler gin.HandlerFunc) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/", handler)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	want := render(func(c *gin.Context) { c.JSON(http.StatusOK, body) })
+	got := render(func(c *gin.Context) { writeJSON(c, http.StatusOK, body) })
+
+	if got.Body.String() != want.Body.String() {
+		t.Fatalf("body = %s\nwant %s", got.Body.String(), want.Body.String())
+	}
+	if got.Header().Get("Content-Type") != want.Header().Get("Content-Type") {
+		t.Fatalf("Content-Type = %q, want %q", got.Header().Get("Content-Type"), want.Header().Get("Content-Type"))
+	}
+}
+
+// discardWriter is a ResponseWriter that keeps no output, so benchmarks measure rendering only.
+type discardWriter struct{ header http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func benchmarkRender(b *testing.B, handler gin.HandlerFunc) {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.GET("/", handler)
+	w := &discardWriter{header: make(http.Header)}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+// BenchmarkSuccessResponse renders the envelope through the pooled encoder. Compare with
+// BenchmarkSuccessResponse_GinJSON, and build with -tags go_json (or jsoniter, sonic) to
+// measure the alternative codecs.
+func BenchmarkSuccessResponse(b *testing.B) {
+	benchmarkRender(b, func(c *gin.Context) {
+		SuccessResponse(c, http.StatusOK, "Users retrieved", benchmarkPayload)
+	})
+}
+
+func BenchmarkSuccessResponse_GinJSON(b *testing.B) {
+	benchmarkRender(b, func(c *gin.Context) {
+		c.JSON(http.StatusOK, currentSerializer().Success(c, http.StatusOK, "Users retrieved", benchmarkPayload))
+	})
+}
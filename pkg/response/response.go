@@ -2,8 +2,6 @@
 package response
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,67 +11,84 @@ type APIResponse struct {
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   *APIError   `json:"error,omitempty"`
+	Meta    *Meta       `json:"meta,omitempty"`
 }
 
-// APIError defines the structure for error responses.
+// APIError defines the structure for error responses. Server errors (5xx) carry the request ID
+// so clients can report it.
 type APIError struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   string       `json:"details,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// FieldError describes a validation failure on a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
 	Code    string `json:"code"`
 	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
 }
 
-// SuccessResponse sends a successful response.
+// SuccessResponse sends a successful response using the configured Serializer.
 func SuccessResponse(c *gin.Context, statusCode int, message string, data interface{}) {
-	c.JSON(statusCode, APIResponse{
-		Success: true,
-		Message: message,
-		Data:    data,
-	})
+	writeJSON(c, statusCode, currentSerializer().Success(c, statusCode, message, data))
 }
 
-// ErrorResponse sends an error response.
-func ErrorResponse(c *gin.Context, statusCode int, code, message, details string) {
-	c.JSON(statusCode, APIResponse{
-		Success: false,
-		Error: &APIError{
-			Code:    code,
-			Message: message,
-			Details: details,
-		},
-	})
+// ErrorResponse sends an error response with the status of code in the negotiated format
+// (envelope or Problem Details).
+func ErrorResponse(c *gin.Context, code *ErrorCode, message, details string) {
+	WriteError(c, code, message, details, nil)
 }
 
 // BadRequestError sends a 400 Bad Request error.
 func BadRequestError(c *gin.Context, message, details string) {
-	ErrorResponse(c, http.StatusBadRequest, "BAD_REQUEST", message, details)
+	ErrorResponse(c, CodeBadRequest, message, details)
 }
 
 // UnauthorizedError sends a 401 Unauthorized error.
 func UnauthorizedError(c *gin.Context, message, details string) {
-	ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", message, details)
+	ErrorResponse(c, CodeUnauthorized, message, details)
 }
 
 // ForbiddenError sends a 403 Forbidden error.
 func ForbiddenError(c *gin.Context, message, details string) {
-	ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", message, details)
+	ErrorResponse(c, CodeForbidden, message, details)
 }
 
 // NotFoundError sends a 404 Not Found error.
 func NotFoundError(c *gin.Context, message, details string) {
-	ErrorResponse(c, http.StatusNotFound, "NOT_FOUND", message, details)
+	ErrorResponse(c, CodeNotFound, message, details)
 }
 
 // ConflictError sends a 409 Conflict error.
 func ConflictError(c *gin.Context, message, details string) {
-	ErrorResponse(c, http.StatusConflict, "CONFLICT", message, details)
+	ErrorResponse(c, CodeConflict, message, details)
 }
 
 // InternalServerError sends a 500 Internal Server Error.
 func InternalServerError(c *gin.Context, message, details string) {
-	ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", message, details)
+	ErrorResponse(c, CodeInternal, message, details)
 }
 
 // ValidationError sends a validation error response.
 func ValidationError(c *gin.Context, details string) {
-	ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Validation failed", details)
+	ErrorResponse(c, CodeValidation, "Validation failed", details)
+}
+
+// ValidationErrors sends a validation error response listing every invalid field.
+func ValidationErrors(c *gin.Context, details string, fields []FieldError) {
+	WriteError(c, CodeValidation, "Validation failed", details, fields)
+}
+
+// WriteError sends an error with the status of code in the negotiated format, listing fields
+// when given.
+func WriteError(c *gin.Context, code *ErrorCode, message, details string, fields []FieldError) {
+	writeError(c, code.Status, &APIError{
+		Code:    code.Code,
+		Message: message,
+		Details: details,
+		Fields:  fields,
+	})
 }
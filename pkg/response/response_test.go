@@ -0,0 +1,141 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+)
+
+func performError(t *testing.T, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/42", func(c *gin.Context) {
+		NotFoundError(c, "User not found", "No user with id 42")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users/42", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestErrorResponseEnvelope(t *testing.T) {
+	w := performError(t, "application/json")
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %s; want application/json", ct)
+	}
+
+	var resp APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Success || resp.Error == nil || resp.Error.Code != "NOT_FOUND" {
+		t.Errorf("unexpected envelope: %s", w.Body.String())
+	}
+}
+
+func TestErrorResponseProblemDetailsNegotiated(t *testing.T) {
+	w := performError(t, "application/problem+json")
+
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Content-Type = %s; want %s", ct, ProblemContentType)
+	}
+
+	var problem ProblemDetails
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to parse problem: %v", err)
+	}
+	if problem.Status != http.StatusNotFound || problem.Type != "about:blank" || problem.Title != "Not Found" {
+		t.Errorf("unexpected problem: %+v", problem)
+	}
+	if problem.Instance != "/users/42" || problem.Detail != "No user with id 42" || problem.Code != "NOT_FOUND" {
+		t.Errorf("unexpected problem members: %+v", problem)
+	}
+}
+
+func TestErrorResponseProblemDetailsConfigured(t *testing.T) {
+	SetErrorFormat(FormatProblem)
+	SetProblemTypeBase("https://api.example.com/problems")
+	defer func() {
+		SetErrorFormat(FormatEnvelope)
+		SetProblemTypeBase("")
+	}()
+
+	w := performError(t, "")
+
+	var problem ProblemDetails
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to parse problem: %v", err)
+	}
+	if problem.Type != "https://api.example.com/problems/not-found" || problem.Title != "User not found" {
+		t.Errorf("unexpected problem: %+v", problem)
+	}
+}
+
+type flatSerializer struct{}
+
+func (flatSerializer) Success(_ *gin.Context, _ int, _ string, data interface{}) interface{} {
+	return gin.H{"result": data}
+}
+
+func (flatSerializer) Error(_ *gin.Context, status int, apiErr *APIError) interface{} {
+	return gin.H{"status": status, "error": apiErr.Code}
+}
+
+func TestSetSerializer(t *testing.T) {
+	SetSerializer(flatSerializer{})
+	defer SetSerializer(nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ok", func(c *gin.Context) { SuccessResponse(c, http.StatusOK, "ignored", 42) })
+	r.GET("/fail", func(c *gin.Context) { ConflictError(c, "Conflict", "") })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/ok", nil)
+	r.ServeHTTP(w, req)
+	if w.Body.String() != `{"result":42}` {
+		t.Errorf("success body = %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/fail", nil)
+	r.ServeHTTP(w, req)
+	if w.Body.String() != `{"error":"CONFLICT","status":409}` {
+		t.Errorf("error body = %s", w.Body.String())
+	}
+}
+
+func TestEnvelopeSerializerMeta(t *testing.T) {
+	SetSerializer(EnvelopeSerializer{IncludeRequestID: true, IncludeTimestamp: true, APIVersion: "v1"})
+	defer SetSerializer(nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ok", func(c *gin.Context) {
+		requestctx.SetRequestID(c, "req-123")
+		SuccessResponse(c, http.StatusOK, "done", nil)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/ok", nil)
+	r.ServeHTTP(w, req)
+
+	var resp APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Meta == nil || resp.Meta.RequestID != "req-123" || resp.Meta.APIVersion != "v1" || resp.Meta.Timestamp == "" {
+		t.Errorf("unexpected meta: %+v", resp.Meta)
+	}
+}
@@ -0,0 +1,87 @@
+package response
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeferson59/gin-template/pkg/requestctx"
+)
+
+// Serializer builds the body written for success and error responses.
+// Applications built on the template can install their own with SetSerializer
+// to change the envelope without editing this package.
+type Serializer interface {
+	Success(c *gin.Context, statusCode int, message string, data interface{}) interface{}
+	Error(c *gin.Context, statusCode int, apiErr *APIError) interface{}
+}
+
+// Meta holds optional metadata attached to every envelope.
+type Meta struct {
+	RequestID  string `json:"request_id,omitempty"`
+	Timestamp  string `json:"timestamp,omitempty"`
+	APIVersion string `json:"api_version,omitempty"`
+}
+
+// EnvelopeSerializer renders the standard APIResponse envelope, optionally with metadata.
+type EnvelopeSerializer struct {
+	IncludeRequestID bool
+	IncludeTimestamp bool
+	APIVersion       string
+}
+
+// Success builds a successful envelope.
+func (s EnvelopeSerializer) Success(c *gin.Context, _ int, message string, data interface{}) interface{} {
+	return APIResponse{
+		Success: true,
+		Message: message,
+		Data:    data,
+		Meta:    s.meta(c),
+	}
+}
+
+// Error builds an error envelope.
+func (s EnvelopeSerializer) Error(c *gin.Context, _ int, apiErr *APIError) interface{} {
+	return APIResponse{
+		Success: false,
+		Error:   apiErr,
+		Meta:    s.meta(c),
+	}
+}
+
+func (s EnvelopeSerializer) meta(c *gin.Context) *Meta {
+	if !s.IncludeRequestID && !s.IncludeTimestamp && s.APIVersion == "" {
+		return nil
+	}
+
+	meta := &Meta{APIVersion: s.APIVersion}
+	if s.IncludeRequestID {
+		meta.RequestID = requestctx.RequestID(c)
+	}
+	if s.IncludeTimestamp {
+		meta.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+	return meta
+}
+
+var (
+	serializer   Serializer = EnvelopeSerializer{}
+	serializerMu sync.RWMutex
+)
+
+// SetSerializer replaces the serializer used for all responses. Passing nil restores the default envelope.
+func SetSerializer(s Serializer) {
+	serializerMu.Lock()
+	defer serializerMu.Unlock()
+	if s == nil {
+		s = EnvelopeSerializer{}
+	}
+	serializer = s
+}
+
+func currentSerializer() Serializer {
+	serializerMu.RLock()
+	defer serializerMu.RUnlock()
+	return serializer
+}
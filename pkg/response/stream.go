@@ -0,0 +1,33 @@
+package response
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// flushWriter flushes the underlying response after every write so large exports
+// reach the client progressively instead of being buffered in memory.
+type flushWriter struct {
+	w gin.ResponseWriter
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.w.Flush()
+	return n, err
+}
+
+// StreamAttachment streams a file download to the client. Headers are sent before write
+// is called, so an error returned by write can only be logged, not turned into an error response.
+func StreamAttachment(c *gin.Context, filename, contentType string, write func(w io.Writer) error) error {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+
+	return write(flushWriter{w: c.Writer})
+}
@@ -0,0 +1,142 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeFormatHeader is the request header with which a client picks the format of the
+// timestamps of responses.
+const TimeFormatHeader = "X-Time-Format"
+
+// Timestamp formats of TimeFormatHeader.
+const (
+	// TimeFormatISO8601 writes RFC 3339 strings, such as "2026-03-01T09:30:00+01:00" (default).
+	TimeFormatISO8601 = "iso8601"
+	// TimeFormatUnix writes seconds since the Unix epoch.
+	TimeFormatUnix = "unix"
+	// TimeFormatUnixMillis writes milliseconds since the Unix epoch.
+	TimeFormatUnixMillis = "unix_ms"
+)
+
+// timestampPattern matches the strings time.Time is encoded to in JSON.
+var timestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)
+
+// LocalizedSerializer rewrites the timestamps of the bodies built by Next: in the time zone of
+// the request, given by Location, or as Unix epoch numbers when the client asks for them with
+// TimeFormatHeader. Timestamps are found in the encoded data, so a string field whose value is
+// an RFC 3339 timestamp is rewritten too; meta.timestamp is left in UTC.
+type LocalizedSerializer struct {
+	Next Serializer
+	// Location returns the time zone of the request, such as the preference of the signed-in
+	// user, or nil to keep timestamps as they are. It is called at most once per response,
+	// and only when the body has timestamps to write in ISO 8601.
+	Location func(c *gin.Context) *time.Location
+}
+
+// Success builds the body of Next with localized timestamps.
+func (s LocalizedSerializer) Success(c *gin.Context, statusCode int, message string, data interface{}) interface{} {
+	return s.localize(c, s.next().Success(c, statusCode, message, data))
+}
+
+// Error builds the body of Next with localized timestamps.
+func (s LocalizedSerializer) Error(c *gin.Context, statusCode int, apiErr *APIError) interface{} {
+	return s.localize(c, s.next().Error(c, statusCode, apiErr))
+}
+
+func (s LocalizedSerializer) next() Serializer {
+	if s.Next == nil {
+		return EnvelopeSerializer{}
+	}
+	return s.Next
+}
+
+// localize rewrites the timestamps of body, or of its data when it is the standard envelope,
+// whose fields keep their order. Values that fail to encode are returned unchanged, for the
+// renderer to report the error.
+func (s LocalizedSerializer) localize(c *gin.Context, body interface{}) interface{} {
+	// Caches must not serve a response in the format another client asked for
+	c.Writer.Header().Add("Vary", TimeFormatHeader)
+
+	format := strings.ToLower(strings.TrimSpace(c.GetHeader(TimeFormatHeader)))
+	if format != TimeFormatUnix && format != TimeFormatUnixMillis {
+		format = TimeFormatISO8601
+		if s.Location == nil {
+			return body
+		}
+	}
+
+	var loc *time.Location
+	loaded := false
+	rewrite := func(t time.Time) interface{} {
+		switch format {
+		case TimeFormatUnix:
+			return json.Number(strconv.FormatInt(t.Unix(), 10))
+		case TimeFormatUnixMillis:
+			return json.Number(strconv.FormatInt(t.UnixMilli(), 10))
+		}
+		if !loaded {
+			loc, loaded = s.Location(c), true
+		}
+		if loc == nil {
+			return nil
+		}
+		return t.In(loc).Format(time.RFC3339Nano)
+	}
+
+	if envelope, ok := body.(APIResponse); ok {
+		if envelope.Data != nil {
+			envelope.Data = localizeValue(envelope.Data, rewrite)
+		}
+		return envelope
+	}
+	return localizeValue(body, rewrite)
+}
+
+// localizeValue encodes value to rewrite its timestamps.
+func localizeValue(value interface{}, rewrite func(time.Time) interface{}) interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return value
+	}
+	return rewriteTimestamps(tree, rewrite)
+}
+
+// rewriteTimestamps replaces the timestamps of a decoded JSON value with the result of rewrite,
+// unless it returns nil.
+func rewriteTimestamps(value interface{}, rewrite func(time.Time) interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = rewriteTimestamps(item, rewrite)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = rewriteTimestamps(item, rewrite)
+		}
+	case string:
+		if !timestampPattern.MatchString(v) {
+			return v
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return v
+		}
+		if rewritten := rewrite(t); rewritten != nil {
+			return rewritten
+		}
+	}
+	return value
+}
@@ -0,0 +1,90 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type event struct {
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+func performLocalized(t *testing.T, s LocalizedSerializer, format string) string {
+	t.Helper()
+	SetSerializer(s)
+	defer SetSerializer(nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	created := time.Date(2026, 3, 1, 8, 30, 0, 500_000_000, time.UTC)
+	r.GET("/events", func(c *gin.Context) {
+		SuccessResponse(c, http.StatusOK, "ok", []event{{Name: "2026", CreatedAt: created}})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/events", nil)
+	if format != "" {
+		req.Header.Set(TimeFormatHeader, format)
+	}
+	r.ServeHTTP(w, req)
+	if vary := w.Header().Get("Vary"); vary != TimeFormatHeader {
+		t.Errorf("Vary = %q, want %s", vary, TimeFormatHeader)
+	}
+	return w.Body.String()
+}
+
+func TestLocalizedSerializerTimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	s := LocalizedSerializer{Location: func(*gin.Context) *time.Location {
+		calls++
+		return berlin
+	}}
+
+	body := performLocalized(t, s, "")
+	want := `{"success":true,"message":"ok","data":[{"created_at":"2026-03-01T09:30:00.5+01:00","deleted_at":null,"name":"2026"}]}`
+	if body != want {
+		t.Errorf("body = %s\nwant   %s", body, want)
+	}
+	if calls != 1 {
+		t.Errorf("Location called %d times, want once", calls)
+	}
+}
+
+func TestLocalizedSerializerUnixEpoch(t *testing.T) {
+	s := LocalizedSerializer{Location: func(*gin.Context) *time.Location {
+		t.Error("Location called for a Unix epoch response")
+		return nil
+	}}
+
+	for format, want := range map[string]string{
+		"unix":    `{"success":true,"message":"ok","data":[{"created_at":1772353800,"deleted_at":null,"name":"2026"}]}`,
+		"UNIX_MS": `{"success":true,"message":"ok","data":[{"created_at":1772353800500,"deleted_at":null,"name":"2026"}]}`,
+	} {
+		if body := performLocalized(t, s, format); body != want {
+			t.Errorf("%s: body = %s\nwant       %s", format, body, want)
+		}
+	}
+}
+
+func TestLocalizedSerializerKeepsTimestamps(t *testing.T) {
+	// Without a time zone, or with an unknown format, the body is written as is
+	want := `{"success":true,"message":"ok","data":[{"name":"2026","created_at":"2026-03-01T08:30:00.5Z","deleted_at":null}]}`
+	if body := performLocalized(t, LocalizedSerializer{}, "rfc2822"); body != want {
+		t.Errorf("body = %s\nwant   %s", body, want)
+	}
+	s := LocalizedSerializer{Location: func(*gin.Context) *time.Location { return nil }}
+	want = `{"success":true,"message":"ok","data":[{"created_at":"2026-03-01T08:30:00.5Z","deleted_at":null,"name":"2026"}]}`
+	if body := performLocalized(t, s, ""); body != want {
+		t.Errorf("body = %s, want the timestamps unchanged", body)
+	}
+}
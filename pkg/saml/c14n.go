@@ -0,0 +1,124 @@
+package saml
+
+import (
+	"sort"
+	"strings"
+)
+
+// canonicalize serializes e with Exclusive XML Canonicalization without comments
+// (http://www.w3.org/2001/10/xml-exc-c14n#). skip, when not nil, is left out as the enveloped
+// signature transform requires; inclusive lists the prefixes of the InclusiveNamespaces
+// PrefixList ("#default" for the default namespace).
+func canonicalize(e, skip *element, inclusive []string) []byte {
+	var b strings.Builder
+	include := make(map[string]bool, len(inclusive))
+	for _, prefix := range inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		include[prefix] = true
+	}
+	writeCanonical(&b, e, skip, include, map[string]string{})
+	return []byte(b.String())
+}
+
+// writeCanonical writes e given the namespace declarations rendered by its output ancestors.
+func writeCanonical(b *strings.Builder, e, skip *element, include map[string]bool, rendered map[string]string) {
+	// Namespaces visibly utilized by the element and its attributes, plus the inclusive ones
+	used := map[string]bool{e.Prefix: true}
+	for _, a := range e.Attrs {
+		if a.Name.Space != "" && a.Name.Space != "xmlns" && a.Name.Space != "xml" {
+			used[a.Name.Space] = true
+		}
+	}
+	for prefix := range include {
+		if _, ok := e.lookupNamespace(prefix); ok {
+			used[prefix] = true
+		}
+	}
+
+	type nsDecl struct{ prefix, uri string }
+	var decls []nsDecl
+	scope := make(map[string]string, len(rendered)+len(used))
+	for prefix, uri := range rendered {
+		scope[prefix] = uri
+	}
+	for prefix := range used {
+		uri, ok := e.lookupNamespace(prefix)
+		if !ok || prefix == "xml" {
+			continue
+		}
+		previous, wasRendered := rendered[prefix]
+		if prefix == "" && uri == "" {
+			// xmlns="" only undoes a default namespace rendered by an ancestor
+			if wasRendered && previous != "" {
+				decls = append(decls, nsDecl{"", ""})
+				scope[""] = ""
+			}
+			continue
+		}
+		if !wasRendered || previous != uri {
+			decls = append(decls, nsDecl{prefix, uri})
+			scope[prefix] = uri
+		}
+	}
+	sort.Slice(decls, func(i, j int) bool { return decls[i].prefix < decls[j].prefix })
+
+	type attr struct{ space, qname, local, value string }
+	var attrs []attr
+	for _, a := range e.Attrs {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+			continue
+		}
+		qname, space := a.Name.Local, ""
+		if a.Name.Space != "" {
+			qname = a.Name.Space + ":" + a.Name.Local
+			space, _ = e.lookupNamespace(a.Name.Space)
+		}
+		attrs = append(attrs, attr{space, qname, a.Name.Local, a.Value})
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].space != attrs[j].space {
+			return attrs[i].space < attrs[j].space
+		}
+		return attrs[i].local < attrs[j].local
+	})
+
+	name := e.Local
+	if e.Prefix != "" {
+		name = e.Prefix + ":" + e.Local
+	}
+	b.WriteString("<" + name)
+	for _, d := range decls {
+		if d.prefix == "" {
+			b.WriteString(` xmlns="` + escapeAttr(d.uri) + `"`)
+		} else {
+			b.WriteString(" xmlns:" + d.prefix + `="` + escapeAttr(d.uri) + `"`)
+		}
+	}
+	for _, a := range attrs {
+		b.WriteString(" " + a.qname + `="` + escapeAttr(a.value) + `"`)
+	}
+	b.WriteString(">")
+
+	for _, child := range e.Children {
+		switch c := child.(type) {
+		case string:
+			b.WriteString(escapeText(c))
+		case *element:
+			if c != skip {
+				writeCanonical(b, c, skip, include, scope)
+			}
+		}
+	}
+	b.WriteString("</" + name + ">")
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+
+func escapeAttr(s string) string { return attrEscaper.Replace(s) }
@@ -0,0 +1,47 @@
+package saml
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	root, err := parseXML([]byte(`<?xml version="1.0"?>
+<root xmlns="http://example.com/default" xmlns:a="http://example.com/a" xmlns:unused="http://example.com/unused">
+  <a:item z="1" a:attr="&lt;x&gt;" b='say "hi"'>text &amp; more &gt;<!-- comment --><empty/><a:skip/></a:item>
+</root>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := root.Child("http://example.com/a", "item")
+	skip := item.Child("http://example.com/a", "skip")
+
+	tests := []struct {
+		name      string
+		skip      *element
+		inclusive []string
+		want      string
+	}{
+		{
+			name: "exclusive",
+			want: `<a:item xmlns:a="http://example.com/a" b="say &quot;hi&quot;" z="1" a:attr="&lt;x>">text &amp; more &gt;` +
+				`<empty xmlns="http://example.com/default"></empty><a:skip></a:skip></a:item>`,
+		},
+		{
+			name:      "inclusive prefixes and enveloped signature",
+			skip:      skip,
+			inclusive: []string{"unused", "#default"},
+			want: `<a:item xmlns="http://example.com/default" xmlns:a="http://example.com/a" xmlns:unused="http://example.com/unused" ` +
+				`b="say &quot;hi&quot;" z="1" a:attr="&lt;x>">text &amp; more &gt;<empty></empty></a:item>`,
+		},
+	}
+	for _, tt := range tests {
+		if got := string(canonicalize(item, tt.skip, tt.inclusive)); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseXML_RejectsDTD(t *testing.T) {
+	_, err := parseXML([]byte(`<!DOCTYPE r [<!ENTITY x "y">]><r>&x;</r>`))
+	if err == nil {
+		t.Error("parseXML() accepted a DTD")
+	}
+}
@@ -0,0 +1,165 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const nsXML = "http://www.w3.org/XML/1998/namespace"
+
+// element is a parsed XML element that keeps the namespace prefixes as written, which
+// encoding/xml drops when it resolves names but canonicalization needs.
+type element struct {
+	Prefix string
+	Local  string
+	// Attrs hold the raw attributes, namespace declarations included; Name.Space is the prefix.
+	Attrs []xml.Attr
+	// Children are *element and string (character data) nodes in document order.
+	Children []interface{}
+	Parent   *element
+}
+
+// parseXML parses a document. DTDs are rejected, which rules out entity expansion attacks,
+// and comments are dropped as exclusive canonicalization without comments does.
+func parseXML(data []byte) (*element, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = true
+
+	var root, current *element
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("saml: invalid XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &element{Prefix: t.Name.Space, Local: t.Name.Local, Attrs: append([]xml.Attr(nil), t.Attr...), Parent: current}
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("saml: invalid XML: more than one root element")
+				}
+				root = el
+			} else {
+				current.Children = append(current.Children, el)
+			}
+			current = el
+		case xml.EndElement:
+			// RawToken does not match end elements with start elements
+			if current == nil || t.Name.Space != current.Prefix || t.Name.Local != current.Local {
+				return nil, errors.New("saml: invalid XML: mismatched end element")
+			}
+			current = current.Parent
+		case xml.CharData:
+			if current == nil {
+				if len(bytes.TrimSpace(t)) > 0 {
+					return nil, errors.New("saml: invalid XML: text outside the root element")
+				}
+				continue
+			}
+			if n := len(current.Children); n > 0 {
+				if text, ok := current.Children[n-1].(string); ok {
+					current.Children[n-1] = text + string(t)
+					continue
+				}
+			}
+			current.Children = append(current.Children, string(t))
+		case xml.Directive:
+			return nil, errors.New("saml: invalid XML: DTDs are not allowed")
+		}
+	}
+	if root == nil || current != nil {
+		return nil, errors.New("saml: invalid XML: incomplete document")
+	}
+	return root, nil
+}
+
+// lookupNamespace resolves prefix ("" for the default namespace) in the scope of e.
+func (e *element) lookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return nsXML, true
+	}
+	for el := e; el != nil; el = el.Parent {
+		for _, a := range el.Attrs {
+			if (prefix == "" && a.Name.Space == "" && a.Name.Local == "xmlns") ||
+				(prefix != "" && a.Name.Space == "xmlns" && a.Name.Local == prefix) {
+				return a.Value, true
+			}
+		}
+	}
+	return "", prefix == ""
+}
+
+// Space returns the namespace URI of e.
+func (e *element) Space() string {
+	ns, _ := e.lookupNamespace(e.Prefix)
+	return ns
+}
+
+// Is reports whether e is the element local in namespace space.
+func (e *element) Is(space, local string) bool {
+	return e != nil && e.Local == local && e.Space() == space
+}
+
+// ChildElements returns the child elements named local in namespace space.
+func (e *element) ChildElements(space, local string) []*element {
+	var children []*element
+	if e == nil {
+		return nil
+	}
+	for _, child := range e.Children {
+		if el, ok := child.(*element); ok && el.Is(space, local) {
+			children = append(children, el)
+		}
+	}
+	return children
+}
+
+// Child returns the first child element named local in namespace space, or nil.
+func (e *element) Child(space, local string) *element {
+	if children := e.ChildElements(space, local); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+// Attr returns the value of the unprefixed attribute name.
+func (e *element) Attr(name string) string {
+	if e == nil {
+		return ""
+	}
+	for _, a := range e.Attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Text returns the character data of e and its descendants, trimmed.
+func (e *element) Text() string {
+	if e == nil {
+		return ""
+	}
+	var b strings.Builder
+	var walk func(*element)
+	walk = func(el *element) {
+		for _, child := range el.Children {
+			switch c := child.(type) {
+			case string:
+				b.WriteString(c)
+			case *element:
+				walk(c)
+			}
+		}
+	}
+	walk(e)
+	return strings.TrimSpace(b.String())
+}
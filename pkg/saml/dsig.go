@@ -0,0 +1,148 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	// Register the digests of the supported algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// XML signature namespaces and algorithms.
+const (
+	nsDSig       = "http://www.w3.org/2000/09/xmldsig#"
+	nsExcC14N    = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algExcC14N   = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+)
+
+// signatureMethods are the supported SignatureMethod algorithms. SHA-1 is not accepted.
+var signatureMethods = map[string]struct {
+	hash  crypto.Hash
+	ecdsa bool
+}{
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256":   {crypto.SHA256, false},
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512":   {crypto.SHA512, false},
+	"http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256": {crypto.SHA256, true},
+	"http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512": {crypto.SHA512, true},
+}
+
+// digestMethods are the supported DigestMethod algorithms.
+var digestMethods = map[string]crypto.Hash{
+	"http://www.w3.org/2001/04/xmlenc#sha256": crypto.SHA256,
+	"http://www.w3.org/2001/04/xmlenc#sha512": crypto.SHA512,
+}
+
+// errNotSigned is returned by verifySignature for elements without a signature.
+var errNotSigned = errors.New("saml: element is not signed")
+
+// verifySignature checks the enveloped signature of e against certs. The signature must cover
+// e as a whole, by its ID, so that nothing outside the verified element is trusted.
+func verifySignature(e *element, certs []*x509.Certificate) error {
+	signatures := e.ChildElements(nsDSig, "Signature")
+	switch {
+	case len(signatures) == 0:
+		return errNotSigned
+	case len(signatures) > 1:
+		return errors.New("saml: more than one signature")
+	}
+	signature := signatures[0]
+	signedInfo := signature.Child(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("saml: signature without SignedInfo")
+	}
+
+	c14nMethod := signedInfo.Child(nsDSig, "CanonicalizationMethod")
+	if c14nMethod.Attr("Algorithm") != algExcC14N {
+		return fmt.Errorf("saml: unsupported canonicalization %q", c14nMethod.Attr("Algorithm"))
+	}
+	method, ok := signatureMethods[signedInfo.Child(nsDSig, "SignatureMethod").Attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("saml: unsupported signature method %q", signedInfo.Child(nsDSig, "SignatureMethod").Attr("Algorithm"))
+	}
+
+	references := signedInfo.ChildElements(nsDSig, "Reference")
+	if len(references) != 1 {
+		return errors.New("saml: signature must have exactly one reference")
+	}
+	reference := references[0]
+	if id := e.Attr("ID"); id == "" || reference.Attr("URI") != "#"+id {
+		return errors.New("saml: signature does not reference the signed element")
+	}
+
+	var inclusive []string
+	enveloped := false
+	for _, transform := range reference.Child(nsDSig, "Transforms").ChildElements(nsDSig, "Transform") {
+		switch transform.Attr("Algorithm") {
+		case algEnveloped:
+			enveloped = true
+		case algExcC14N:
+			inclusive = prefixList(transform)
+		default:
+			return fmt.Errorf("saml: unsupported transform %q", transform.Attr("Algorithm"))
+		}
+	}
+	if !enveloped {
+		return errors.New("saml: signature is not enveloped")
+	}
+
+	digestMethod, ok := digestMethods[reference.Child(nsDSig, "DigestMethod").Attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("saml: unsupported digest method %q", reference.Child(nsDSig, "DigestMethod").Attr("Algorithm"))
+	}
+	wantDigest, err := decodeBase64(reference.Child(nsDSig, "DigestValue").Text())
+	if err != nil {
+		return fmt.Errorf("saml: invalid digest value: %w", err)
+	}
+	h := digestMethod.New()
+	h.Write(canonicalize(e, signature, inclusive))
+	if subtle.ConstantTimeCompare(h.Sum(nil), wantDigest) != 1 {
+		return errors.New("saml: digest mismatch; the signed element was modified")
+	}
+
+	signatureValue, err := decodeBase64(signature.Child(nsDSig, "SignatureValue").Text())
+	if err != nil {
+		return fmt.Errorf("saml: invalid signature value: %w", err)
+	}
+	h = method.hash.New()
+	h.Write(canonicalize(signedInfo, nil, prefixList(c14nMethod)))
+	hashed := h.Sum(nil)
+	for _, cert := range certs {
+		switch key := cert.PublicKey.(type) {
+		case *rsa.PublicKey:
+			if !method.ecdsa && rsa.VerifyPKCS1v15(key, method.hash, hashed, signatureValue) == nil {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			// XML signatures encode ECDSA as r || s
+			if method.ecdsa && len(signatureValue)%2 == 0 {
+				half := len(signatureValue) / 2
+				r := new(big.Int).SetBytes(signatureValue[:half])
+				s := new(big.Int).SetBytes(signatureValue[half:])
+				if ecdsa.Verify(key, hashed, r, s) {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.New("saml: signature does not match any IdP certificate")
+}
+
+// prefixList returns the InclusiveNamespaces PrefixList of a canonicalization element.
+func prefixList(e *element) []string {
+	return strings.Fields(e.Child(nsExcC14N, "InclusiveNamespaces").Attr("PrefixList"))
+}
+
+// decodeBase64 decodes base64 that may be wrapped across lines.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
@@ -0,0 +1,100 @@
+package saml
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SAML namespaces and bindings.
+const (
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+
+	BindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	BindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+)
+
+// IdPMetadata is what the service provider needs from the metadata of an identity provider.
+type IdPMetadata struct {
+	EntityID string
+	// SSOURL is the HTTP-Redirect SingleSignOnService location.
+	SSOURL string
+	// Certificates verify the signatures of responses and assertions; several are listed
+	// while the IdP rolls its signing key.
+	Certificates []*x509.Certificate
+}
+
+// ParseIdPMetadata parses an EntityDescriptor, or the first identity provider of an
+// EntitiesDescriptor, as published by Okta, Azure AD (Entra ID) and other IdPs.
+func ParseIdPMetadata(data []byte) (*IdPMetadata, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := []*element{root}
+	if root.Is(nsMetadata, "EntitiesDescriptor") {
+		entities = root.ChildElements(nsMetadata, "EntityDescriptor")
+	} else if !root.Is(nsMetadata, "EntityDescriptor") {
+		return nil, errors.New("saml: metadata is not an EntityDescriptor")
+	}
+
+	for _, entity := range entities {
+		idp := entity.Child(nsMetadata, "IDPSSODescriptor")
+		if idp == nil {
+			continue
+		}
+		md := &IdPMetadata{EntityID: entity.Attr("entityID")}
+		for _, sso := range idp.ChildElements(nsMetadata, "SingleSignOnService") {
+			if sso.Attr("Binding") == BindingHTTPRedirect {
+				md.SSOURL = sso.Attr("Location")
+				break
+			}
+		}
+		for _, key := range idp.ChildElements(nsMetadata, "KeyDescriptor") {
+			if use := key.Attr("use"); use != "" && use != "signing" {
+				continue
+			}
+			for _, data := range key.Child(nsDSig, "KeyInfo").ChildElements(nsDSig, "X509Data") {
+				for _, certElement := range data.ChildElements(nsDSig, "X509Certificate") {
+					der, err := decodeBase64(certElement.Text())
+					if err != nil {
+						return nil, fmt.Errorf("saml: invalid IdP certificate: %w", err)
+					}
+					cert, err := x509.ParseCertificate(der)
+					if err != nil {
+						return nil, fmt.Errorf("saml: invalid IdP certificate: %w", err)
+					}
+					md.Certificates = append(md.Certificates, cert)
+				}
+			}
+		}
+
+		switch {
+		case md.EntityID == "":
+			return nil, errors.New("saml: IdP metadata has no entityID")
+		case md.SSOURL == "":
+			return nil, errors.New("saml: IdP metadata has no HTTP-Redirect SingleSignOnService")
+		case len(md.Certificates) == 0:
+			return nil, errors.New("saml: IdP metadata has no signing certificate")
+		}
+		return md, nil
+	}
+	return nil, errors.New("saml: metadata has no IDPSSODescriptor")
+}
+
+// Metadata returns the SP metadata document to register the service provider with the IdP.
+func (sp *ServiceProvider) Metadata() []byte {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<md:EntityDescriptor xmlns:md="` + nsMetadata + `" entityID="` + escapeAttr(sp.EntityID) + `">` + "\n")
+	b.WriteString(`  <md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="` + nsProtocol + `">` + "\n")
+	b.WriteString(`    <md:NameIDFormat>urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress</md:NameIDFormat>` + "\n")
+	b.WriteString(`    <md:AssertionConsumerService Binding="` + BindingHTTPPost + `" Location="` + escapeAttr(sp.ACSURL) + `" index="0" isDefault="true"/>` + "\n")
+	b.WriteString(`  </md:SPSSODescriptor>` + "\n")
+	b.WriteString(`</md:EntityDescriptor>` + "\n")
+	return []byte(b.String())
+}
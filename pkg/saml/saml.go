@@ -0,0 +1,313 @@
+// Package saml implements a SAML 2.0 service provider for single sign-on with enterprise
+// identity providers such as Okta and Azure AD: SP metadata, AuthnRequests over the
+// HTTP-Redirect binding, and signed responses over the HTTP-POST binding.
+//
+// Responses must be signed, or carry a signed assertion, with RSA or ECDSA and SHA-256 or
+// SHA-512 using exclusive canonicalization. Encrypted assertions and signed AuthnRequests are
+// not supported.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusSuccess is the status code of successful responses.
+const StatusSuccess = "urn:oasis:names:tc:SAML:2.0:status:Success"
+
+// DefaultClockSkew is the clock difference tolerated with the IdP.
+const DefaultClockSkew = 3 * time.Minute
+
+// ErrIdPInitiated is returned for responses that answer no AuthnRequest when IdP-initiated
+// login is not allowed.
+var ErrIdPInitiated = errors.New("saml: IdP-initiated login is not allowed")
+
+// Assertion is the authenticated identity in a verified response.
+type Assertion struct {
+	// NameID identifies the user at the IdP, typically an email address or a stable ID.
+	NameID       string
+	NameIDFormat string
+	SessionIndex string
+	// Attributes are keyed by attribute Name, and by FriendlyName when the IdP sets one.
+	Attributes map[string][]string
+}
+
+// Attribute returns the first value of the named attribute, or "".
+func (a *Assertion) Attribute(name string) string {
+	if values := a.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ServiceProvider is this application as a SAML service provider of one identity provider.
+type ServiceProvider struct {
+	// EntityID identifies the SP to the IdP; by convention the metadata URL.
+	EntityID string
+	// ACSURL is the assertion consumer service that receives responses by HTTP-POST.
+	ACSURL string
+	IdP    *IdPMetadata
+	// AllowIdPInitiated accepts responses started from the IdP dashboard rather than by an
+	// AuthnRequest of the SP. They are more exposed to replay and login CSRF.
+	AllowIdPInitiated bool
+	ClockSkew         time.Duration
+	// Now is time.Now, replaced in tests.
+	Now func() time.Time
+
+	mu sync.Mutex
+	// seen holds the IDs of consumed assertions until they expire, so none is used twice.
+	seen map[string]time.Time
+}
+
+// AuthnRequestURL returns the IdP URL that starts a login and the ID of the AuthnRequest,
+// which the response must answer (see ParseResponse).
+func (sp *ServiceProvider) AuthnRequestURL(relayState string) (redirectURL, requestID string, err error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", err
+	}
+	// IDs must not start with a digit (xs:ID)
+	requestID = "id-" + hex.EncodeToString(random)
+
+	request := `<samlp:AuthnRequest xmlns:samlp="` + nsProtocol + `" xmlns:saml="` + nsAssertion + `"` +
+		` ID="` + requestID + `" Version="2.0" IssueInstant="` + sp.now().UTC().Format(time.RFC3339) + `"` +
+		` Destination="` + escapeAttr(sp.IdP.SSOURL) + `" ProtocolBinding="` + BindingHTTPPost + `"` +
+		` AssertionConsumerServiceURL="` + escapeAttr(sp.ACSURL) + `">` +
+		`<saml:Issuer>` + escapeText(sp.EntityID) + `</saml:Issuer>` +
+		`<samlp:NameIDPolicy AllowCreate="true"/>` +
+		`</samlp:AuthnRequest>`
+
+	// HTTP-Redirect binding: DEFLATE, base64, URL encoding
+	var deflated bytes.Buffer
+	w, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := w.Write([]byte(request)); err != nil {
+		return "", "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+
+	u, err := url.Parse(sp.IdP.SSOURL)
+	if err != nil {
+		return "", "", fmt.Errorf("saml: invalid IdP SSO URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		query.Set("RelayState", relayState)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), requestID, nil
+}
+
+// ParseResponse verifies the base64 SAMLResponse form value received at the ACS and returns
+// its assertion. requestID is the ID of the AuthnRequest that started the login, or "" for
+// IdP-initiated logins.
+func (sp *ServiceProvider) ParseResponse(samlResponse, requestID string) (*Assertion, error) {
+	raw, err := decodeBase64(samlResponse)
+	if err != nil {
+		return nil, fmt.Errorf("saml: invalid response encoding: %w", err)
+	}
+	response, err := parseXML(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !response.Is(nsProtocol, "Response") {
+		return nil, errors.New("saml: not a SAML response")
+	}
+
+	if requestID == "" && !sp.AllowIdPInitiated {
+		return nil, ErrIdPInitiated
+	}
+	if inResponseTo := response.Attr("InResponseTo"); inResponseTo != requestID {
+		return nil, errors.New("saml: response does not answer the login request")
+	}
+	if destination := response.Attr("Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, fmt.Errorf("saml: response destination %q is not the ACS URL", destination)
+	}
+	if issuer := response.Child(nsAssertion, "Issuer"); issuer != nil && issuer.Text() != sp.IdP.EntityID {
+		return nil, fmt.Errorf("saml: response issuer %q is not the IdP", issuer.Text())
+	}
+	if status := response.Child(nsProtocol, "Status").Child(nsProtocol, "StatusCode").Attr("Value"); status != StatusSuccess {
+		return nil, fmt.Errorf("saml: login failed at the IdP with status %q", status)
+	}
+
+	responseSigned := false
+	switch err := verifySignature(response, sp.IdP.Certificates); {
+	case err == nil:
+		responseSigned = true
+	case !errors.Is(err, errNotSigned):
+		return nil, err
+	}
+
+	if response.Child(nsAssertion, "EncryptedAssertion") != nil {
+		return nil, errors.New("saml: encrypted assertions are not supported")
+	}
+	assertions := response.ChildElements(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, errors.New("saml: response must have exactly one assertion")
+	}
+	assertion := assertions[0]
+	switch err := verifySignature(assertion, sp.IdP.Certificates); {
+	case errors.Is(err, errNotSigned):
+		if !responseSigned {
+			return nil, errors.New("saml: neither the response nor the assertion is signed")
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	return sp.readAssertion(assertion, requestID)
+}
+
+// readAssertion checks the conditions of a verified assertion and extracts the identity.
+func (sp *ServiceProvider) readAssertion(assertion *element, requestID string) (*Assertion, error) {
+	now := sp.now()
+	skew := sp.ClockSkew
+	if skew == 0 {
+		skew = DefaultClockSkew
+	}
+
+	if issuer := assertion.Child(nsAssertion, "Issuer").Text(); issuer != sp.IdP.EntityID {
+		return nil, fmt.Errorf("saml: assertion issuer %q is not the IdP", issuer)
+	}
+
+	conditions := assertion.Child(nsAssertion, "Conditions")
+	if err := checkWindow(conditions, now, skew); err != nil {
+		return nil, err
+	}
+	for _, restriction := range conditions.ChildElements(nsAssertion, "AudienceRestriction") {
+		allowed := false
+		for _, audience := range restriction.ChildElements(nsAssertion, "Audience") {
+			allowed = allowed || audience.Text() == sp.EntityID
+		}
+		if !allowed {
+			return nil, errors.New("saml: assertion is meant for another audience")
+		}
+	}
+
+	subject := assertion.Child(nsAssertion, "Subject")
+	confirmed := false
+	var expires time.Time
+	for _, confirmation := range subject.ChildElements(nsAssertion, "SubjectConfirmation") {
+		if confirmation.Attr("Method") != "urn:oasis:names:tc:SAML:2.0:cm:bearer" {
+			continue
+		}
+		data := confirmation.Child(nsAssertion, "SubjectConfirmationData")
+		notOnOrAfter, err := time.Parse(time.RFC3339Nano, data.Attr("NotOnOrAfter"))
+		if err != nil || !now.Before(notOnOrAfter.Add(skew)) {
+			continue
+		}
+		if data.Attr("Recipient") != sp.ACSURL || data.Attr("InResponseTo") != requestID {
+			continue
+		}
+		confirmed, expires = true, notOnOrAfter
+		break
+	}
+	if !confirmed {
+		return nil, errors.New("saml: assertion has no valid bearer subject confirmation")
+	}
+
+	nameID := subject.Child(nsAssertion, "NameID")
+	result := &Assertion{
+		NameID:       nameID.Text(),
+		NameIDFormat: nameID.Attr("Format"),
+		SessionIndex: assertion.Child(nsAssertion, "AuthnStatement").Attr("SessionIndex"),
+		Attributes:   make(map[string][]string),
+	}
+	if result.NameID == "" {
+		return nil, errors.New("saml: assertion has no NameID")
+	}
+	for _, statement := range assertion.ChildElements(nsAssertion, "AttributeStatement") {
+		for _, attribute := range statement.ChildElements(nsAssertion, "Attribute") {
+			var values []string
+			for _, value := range attribute.ChildElements(nsAssertion, "AttributeValue") {
+				values = append(values, value.Text())
+			}
+			for _, name := range []string{attribute.Attr("Name"), attribute.Attr("FriendlyName")} {
+				if name != "" {
+					result.Attributes[name] = append(result.Attributes[name], values...)
+				}
+			}
+		}
+	}
+
+	if err := sp.consume(assertion.Attr("ID"), expires.Add(skew), now); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkWindow checks the NotBefore and NotOnOrAfter attributes of conditions.
+func checkWindow(conditions *element, now time.Time, skew time.Duration) error {
+	if notBefore := conditions.Attr("NotBefore"); notBefore != "" {
+		t, err := time.Parse(time.RFC3339Nano, notBefore)
+		if err != nil || now.Add(skew).Before(t) {
+			return errors.New("saml: assertion is not valid yet")
+		}
+	}
+	if notOnOrAfter := conditions.Attr("NotOnOrAfter"); notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339Nano, notOnOrAfter)
+		if err != nil || !now.Before(t.Add(skew)) {
+			return errors.New("saml: assertion has expired")
+		}
+	}
+	return nil
+}
+
+// consume records an assertion ID until expires and fails when it was already used.
+func (sp *ServiceProvider) consume(id string, expires, now time.Time) error {
+	if id == "" {
+		return errors.New("saml: assertion has no ID")
+	}
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.seen == nil {
+		sp.seen = make(map[string]time.Time)
+	}
+	for seenID, until := range sp.seen {
+		if now.After(until) {
+			delete(sp.seen, seenID)
+		}
+	}
+	if _, ok := sp.seen[id]; ok {
+		return errors.New("saml: assertion was already used")
+	}
+	sp.seen[id] = expires
+	return nil
+}
+
+func (sp *ServiceProvider) now() time.Time {
+	if sp.Now != nil {
+		return sp.Now()
+	}
+	return time.Now()
+}
+
+// NewServiceProvider creates the service provider served at baseURL, such as
+// "https://api.example.com/api/auth/saml": its metadata is at baseURL + "/metadata", which is
+// also its entity ID, and its ACS at baseURL + "/acs".
+func NewServiceProvider(baseURL string, idp *IdPMetadata) (*ServiceProvider, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("saml: invalid base URL %q", baseURL)
+	}
+	base := strings.TrimSuffix(baseURL, "/")
+	return &ServiceProvider{
+		EntityID: base + "/metadata",
+		ACSURL:   base + "/acs",
+		IdP:      idp,
+	}, nil
+}
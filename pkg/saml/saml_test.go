@@ -0,0 +1,287 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIdP = "https://idp.example.com/metadata"
+	testSP  = "https://sp.example.com/api/auth/saml"
+)
+
+var testNow = time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)
+
+// testKey is an IdP signing key and its certificate.
+type testKey struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestKey(t *testing.T) testKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    testNow.Add(-time.Hour),
+		NotAfter:     testNow.Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{key: key, cert: cert}
+}
+
+// assertionTemplate is written in canonical form, so its digest is that of its own bytes.
+const assertionTemplate = `<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="assertion-1" IssueInstant="2026-10-15T10:00:00Z" Version="2.0">` +
+	`<saml:Issuer>ISSUER</saml:Issuer>` +
+	`<saml:Subject><saml:NameID Format="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress">alice@example.com</saml:NameID>` +
+	`<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">` +
+	`<saml:SubjectConfirmationData InResponseTo="REQUEST" NotOnOrAfter="2026-10-15T10:05:00Z" Recipient="` + testSP + `/acs"></saml:SubjectConfirmationData>` +
+	`</saml:SubjectConfirmation></saml:Subject>` +
+	`<saml:Conditions NotBefore="2026-10-15T09:59:00Z" NotOnOrAfter="2026-10-15T10:05:00Z">` +
+	`<saml:AudienceRestriction><saml:Audience>AUDIENCE</saml:Audience></saml:AudienceRestriction></saml:Conditions>` +
+	`<saml:AuthnStatement AuthnInstant="2026-10-15T10:00:00Z" SessionIndex="session-1"></saml:AuthnStatement>` +
+	`<saml:AttributeStatement>` +
+	`<saml:Attribute FriendlyName="mail" Name="urn:oid:0.9.2342.19200300.100.1.3"><saml:AttributeValue>alice@example.com</saml:AttributeValue></saml:Attribute>` +
+	`<saml:Attribute Name="groups"><saml:AttributeValue>admins</saml:AttributeValue><saml:AttributeValue>staff</saml:AttributeValue></saml:Attribute>` +
+	`</saml:AttributeStatement></saml:Assertion>`
+
+// responseOptions vary the test response.
+type responseOptions struct {
+	audience string
+	request  string
+	unsigned bool
+	// tamper edits the response after it was signed
+	tamper func(string) string
+}
+
+// signedResponse returns a base64 response whose assertion is signed by key.
+func signedResponse(t *testing.T, key testKey, opts responseOptions) string {
+	t.Helper()
+	if opts.audience == "" {
+		opts.audience = testSP + "/metadata"
+	}
+	if opts.request == "" {
+		opts.request = "id-request"
+	}
+	assertion := strings.NewReplacer("ISSUER", testIdP, "REQUEST", opts.request, "AUDIENCE", opts.audience).Replace(assertionTemplate)
+
+	if !opts.unsigned {
+		digest := sha256.Sum256([]byte(assertion))
+		signedInfo := `<ds:SignedInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">` +
+			`<ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"></ds:CanonicalizationMethod>` +
+			`<ds:SignatureMethod Algorithm="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"></ds:SignatureMethod>` +
+			`<ds:Reference URI="#assertion-1"><ds:Transforms>` +
+			`<ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"></ds:Transform>` +
+			`<ds:Transform Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"></ds:Transform></ds:Transforms>` +
+			`<ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"></ds:DigestMethod>` +
+			`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue></ds:Reference></ds:SignedInfo>`
+		hashed := sha256.Sum256([]byte(signedInfo))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key.key, crypto.SHA256, hashed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signatureXML := `<ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#">` + signedInfo +
+			"<ds:SignatureValue>\n" + base64.StdEncoding.EncodeToString(signature) + "\n</ds:SignatureValue></ds:Signature>"
+		assertion = strings.Replace(assertion, "</saml:Issuer>", "</saml:Issuer>"+signatureXML, 1)
+	}
+
+	response := `<?xml version="1.0" encoding="UTF-8"?>
+<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion"
+    ID="response-1" Version="2.0" IssueInstant="2026-10-15T10:00:00Z" Destination="` + testSP + `/acs" InResponseTo="` + opts.request + `">
+  <saml:Issuer>` + testIdP + `</saml:Issuer>
+  <samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>
+  ` + assertion + `
+</samlp:Response>`
+	if opts.tamper != nil {
+		response = opts.tamper(response)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(response))
+}
+
+func newTestSP(t *testing.T, key testKey) *ServiceProvider {
+	t.Helper()
+	sp, err := NewServiceProvider(testSP, &IdPMetadata{EntityID: testIdP, SSOURL: "https://idp.example.com/sso", Certificates: []*x509.Certificate{key.cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp.Now = func() time.Time { return testNow }
+	return sp
+}
+
+func TestParseResponse(t *testing.T) {
+	key := newTestKey(t)
+	sp := newTestSP(t, key)
+
+	assertion, err := sp.ParseResponse(signedResponse(t, key, responseOptions{}), "id-request")
+	if err != nil {
+		t.Fatalf("ParseResponse() error = %v", err)
+	}
+	if assertion.NameID != "alice@example.com" || assertion.SessionIndex != "session-1" {
+		t.Errorf("assertion = %+v", assertion)
+	}
+	if assertion.Attribute("mail") != "alice@example.com" || assertion.Attribute("urn:oid:0.9.2342.19200300.100.1.3") != "alice@example.com" {
+		t.Errorf("mail attribute = %v", assertion.Attributes)
+	}
+	if groups := assertion.Attributes["groups"]; len(groups) != 2 || groups[1] != "staff" {
+		t.Errorf("groups = %v", groups)
+	}
+
+	if _, err := sp.ParseResponse(signedResponse(t, key, responseOptions{}), "id-request"); err == nil {
+		t.Error("ParseResponse() accepted a replayed assertion")
+	}
+}
+
+func TestParseResponse_Rejects(t *testing.T) {
+	key := newTestKey(t)
+	other := newTestKey(t)
+
+	tests := []struct {
+		name      string
+		key       testKey
+		opts      responseOptions
+		requestID string
+		now       time.Time
+	}{
+		{name: "tampered NameID", key: key, opts: responseOptions{tamper: func(r string) string {
+			return strings.Replace(r, "alice@example.com</saml:NameID>", "admin@example.com</saml:NameID>", 1)
+		}}},
+		{name: "signed by another key", key: other},
+		{name: "unsigned", key: key, opts: responseOptions{unsigned: true}},
+		{name: "another audience", key: key, opts: responseOptions{audience: "https://other.example.com"}},
+		{name: "another request", key: key, requestID: "id-other"},
+		{name: "IdP-initiated", key: key, opts: responseOptions{request: ""}, requestID: ""},
+		{name: "expired", key: key, now: testNow.Add(time.Hour)},
+		{name: "not valid yet", key: key, now: testNow.Add(-time.Hour)},
+		{name: "two assertions", key: key, opts: responseOptions{tamper: func(r string) string {
+			start := strings.Index(r, "<saml:Assertion")
+			end := strings.Index(r, "</saml:Assertion>") + len("</saml:Assertion>")
+			return r[:end] + r[start:end] + r[end:]
+		}}},
+		{name: "failed status", key: key, opts: responseOptions{tamper: func(r string) string {
+			return strings.Replace(r, "status:Success", "status:Requester", 1)
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := newTestSP(t, key)
+			if !tt.now.IsZero() {
+				sp.Now = func() time.Time { return tt.now }
+			}
+			requestID := tt.requestID
+			if requestID == "" && tt.name != "IdP-initiated" {
+				requestID = "id-request"
+			}
+			if _, err := sp.ParseResponse(signedResponse(t, tt.key, tt.opts), requestID); err == nil {
+				t.Error("ParseResponse() succeeded")
+			}
+		})
+	}
+}
+
+func TestParseResponse_IdPInitiated(t *testing.T) {
+	key := newTestKey(t)
+	sp := newTestSP(t, key)
+
+	// InResponseTo="" makes the response answer no request
+	response := signedResponse(t, key, responseOptions{request: "x", tamper: func(r string) string {
+		return strings.Replace(r, ` InResponseTo="x"`, "", 1)
+	}})
+	if _, err := sp.ParseResponse(response, ""); !errors.Is(err, ErrIdPInitiated) {
+		t.Errorf("ParseResponse() error = %v, want ErrIdPInitiated", err)
+	}
+}
+
+func TestAuthnRequestURL(t *testing.T) {
+	sp := newTestSP(t, newTestKey(t))
+	redirect, requestID, err := sp.AuthnRequestURL("state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(redirect)
+	if err != nil || u.Host != "idp.example.com" || u.Query().Get("RelayState") != "state" {
+		t.Fatalf("redirect URL = %q", redirect)
+	}
+	deflated, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := parseXML(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.Is(nsProtocol, "AuthnRequest") || root.Attr("ID") != requestID || root.Attr("AssertionConsumerServiceURL") != sp.ACSURL {
+		t.Errorf("AuthnRequest = %s", request)
+	}
+	if root.Child(nsAssertion, "Issuer").Text() != sp.EntityID {
+		t.Errorf("issuer = %q", root.Child(nsAssertion, "Issuer").Text())
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	key := newTestKey(t)
+	idpMetadata := `<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="` + testIdP + `">
+  <IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <KeyDescriptor use="encryption"><KeyInfo xmlns="http://www.w3.org/2000/09/xmldsig#"><X509Data><X509Certificate>bm90IGEgY2VydA==</X509Certificate></X509Data></KeyInfo></KeyDescriptor>
+    <KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>
+        ` + base64.StdEncoding.EncodeToString(key.cert.Raw) + `
+      </ds:X509Certificate></ds:X509Data></ds:KeyInfo>
+    </KeyDescriptor>
+    <SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://idp.example.com/sso/post"/>
+    <SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso"/>
+  </IDPSSODescriptor>
+</EntityDescriptor>`
+	md, err := ParseIdPMetadata([]byte(idpMetadata))
+	if err != nil {
+		t.Fatalf("ParseIdPMetadata() error = %v", err)
+	}
+	if md.EntityID != testIdP || md.SSOURL != "https://idp.example.com/sso" || len(md.Certificates) != 1 || !md.Certificates[0].Equal(key.cert) {
+		t.Errorf("metadata = %+v", md)
+	}
+
+	if _, err := ParseIdPMetadata([]byte(`<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="x"/>`)); err == nil {
+		t.Error("ParseIdPMetadata() accepted metadata without an IdP")
+	}
+
+	sp, err := NewServiceProvider(testSP+"/", md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := parseXML(sp.Metadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	acs := root.Child(nsMetadata, "SPSSODescriptor").Child(nsMetadata, "AssertionConsumerService")
+	if root.Attr("entityID") != testSP+"/metadata" || acs.Attr("Location") != testSP+"/acs" || acs.Attr("Binding") != BindingHTTPPost {
+		t.Errorf("SP metadata = %s", sp.Metadata())
+	}
+}
@@ -0,0 +1,265 @@
+// Package slo tracks per-route latency percentiles and error budgets against service level
+// objectives. A Tracker is fed by the metrics middleware and summarized by GET /api/admin/slo;
+// Prometheus histograms and counters cover the same data over longer windows.
+package slo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Objective is a route's service level objective.
+type Objective struct {
+	// Availability is the target fraction of requests answered without a 5xx error.
+	Availability float64
+	// Latency is the response time threshold and LatencyTarget the target fraction of
+	// requests answered within it.
+	Latency       time.Duration
+	LatencyTarget float64
+}
+
+// Validate checks that the targets are fractions below 1, leaving an error budget, and that
+// the latency threshold is positive.
+func (o Objective) Validate() error {
+	if o.Availability <= 0 || o.Availability >= 1 {
+		return fmt.Errorf("availability target must be between 0 and 1 exclusive, got %v", o.Availability)
+	}
+	if o.LatencyTarget <= 0 || o.LatencyTarget >= 1 {
+		return fmt.Errorf("latency target must be between 0 and 1 exclusive, got %v", o.LatencyTarget)
+	}
+	if o.Latency <= 0 {
+		return fmt.Errorf("latency threshold must be positive, got %s", o.Latency)
+	}
+	return nil
+}
+
+// ParseLatencyOverrides parses "METHOD /path=duration" entries into latency thresholds by
+// route, for example "POST /api/auth/login=1s".
+func ParseLatencyOverrides(entries []string) (map[string]time.Duration, error) {
+	overrides := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		route, value, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid latency override %q, use \"METHOD /path=duration\"", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration in latency override %q", entry)
+		}
+		overrides[RouteKey(strings.ToUpper(method), strings.TrimSpace(path))] = d
+	}
+	return overrides, nil
+}
+
+// RouteKey returns the key a route is tracked under, such as "GET /api/users/:id".
+func RouteKey(method, route string) string {
+	return method + " " + route
+}
+
+// Tracker records request outcomes per route. Counters are cumulative since the tracker was
+// created; percentiles are computed over the most recent samples of each route.
+type Tracker struct {
+	objective Objective
+	window    int
+	since     time.Time
+
+	mu        sync.Mutex
+	overrides map[string]Objective
+	routes    map[string]*routeStats
+}
+
+type routeStats struct {
+	requests uint64
+	errors   uint64
+	slow     uint64
+	samples  []time.Duration
+	next     int
+}
+
+// ErrInvalidWindow is returned by NewTracker for a non-positive sample window.
+var ErrInvalidWindow = errors.New("slo: the sample window must be positive")
+
+// NewTracker creates a tracker with a default objective and window samples per route.
+func NewTracker(objective Objective, window int) (*Tracker, error) {
+	if err := objective.Validate(); err != nil {
+		return nil, err
+	}
+	if window <= 0 {
+		return nil, ErrInvalidWindow
+	}
+	return &Tracker{
+		objective: objective,
+		window:    window,
+		since:     time.Now(),
+		overrides: make(map[string]Objective),
+		routes:    make(map[string]*routeStats),
+	}, nil
+}
+
+// SetObjective overrides the objective of the route with the given key (see RouteKey).
+func (t *Tracker) SetObjective(route string, objective Objective) error {
+	if err := objective.Validate(); err != nil {
+		return fmt.Errorf("route %s: %w", route, err)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.overrides[route] = objective
+	return nil
+}
+
+// SetLatency overrides only the latency threshold of each route in overrides.
+func (t *Tracker) SetLatency(overrides map[string]time.Duration) error {
+	for route, latency := range overrides {
+		objective := t.Objective(route)
+		objective.Latency = latency
+		if err := t.SetObjective(route, objective); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Objective returns the objective applied to route.
+func (t *Tracker) Objective(route string) Objective {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.objectiveLocked(route)
+}
+
+func (t *Tracker) objectiveLocked(route string) Objective {
+	if objective, ok := t.overrides[route]; ok {
+		return objective
+	}
+	return t.objective
+}
+
+// Observe records a request to route that ended with status after d. It reports whether the
+// request counted against the availability and the latency objectives.
+func (t *Tracker) Observe(route string, status int, d time.Duration) (failed, slow bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, ok := t.routes[route]
+	if !ok {
+		stats = &routeStats{samples: make([]time.Duration, 0, t.window)}
+		t.routes[route] = stats
+	}
+
+	failed = status >= http.StatusInternalServerError
+	slow = d > t.objectiveLocked(route).Latency
+	stats.requests++
+	if failed {
+		stats.errors++
+	}
+	if slow {
+		stats.slow++
+	}
+	if len(stats.samples) < t.window {
+		stats.samples = append(stats.samples, d)
+	} else {
+		stats.samples[stats.next] = d
+		stats.next = (stats.next + 1) % t.window
+	}
+	return failed, slow
+}
+
+// RouteSummary is the state of one route against its objective. Budgets are the fraction of
+// the error budget left: 1 when untouched, 0 when exhausted, and negative when overspent.
+type RouteSummary struct {
+	Route        string  `json:"route"`
+	Requests     uint64  `json:"requests"`
+	Errors       uint64  `json:"errors"`
+	SlowRequests uint64  `json:"slow_requests"`
+	P50          float64 `json:"p50_ms"`
+	P95          float64 `json:"p95_ms"`
+	P99          float64 `json:"p99_ms"`
+
+	Availability       float64 `json:"availability"`
+	AvailabilityTarget float64 `json:"availability_target"`
+	AvailabilityBudget float64 `json:"availability_budget_remaining"`
+
+	LatencyThreshold float64 `json:"latency_threshold_ms"`
+	LatencyTarget    float64 `json:"latency_target"`
+	LatencyBudget    float64 `json:"latency_budget_remaining"`
+
+	// Met reports whether both objectives are currently met.
+	Met bool `json:"slo_met"`
+}
+
+// Report summarizes every tracked route.
+type Report struct {
+	Since  time.Time      `json:"since"`
+	Routes []RouteSummary `json:"routes"`
+}
+
+// Report returns the summary of every route, sorted by route.
+func (t *Tracker) Report() Report {
+	t.mu.Lock()
+	summaries := make([]RouteSummary, 0, len(t.routes))
+	for route, stats := range t.routes {
+		summaries = append(summaries, summarize(route, stats, t.objectiveLocked(route)))
+	}
+	t.mu.Unlock()
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Route < summaries[j].Route })
+	return Report{Since: t.since, Routes: summaries}
+}
+
+func summarize(route string, stats *routeStats, objective Objective) RouteSummary {
+	sorted := append([]time.Duration(nil), stats.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	s := RouteSummary{
+		Route:              route,
+		Requests:           stats.requests,
+		Errors:             stats.errors,
+		SlowRequests:       stats.slow,
+		P50:                milliseconds(percentile(sorted, 0.50)),
+		P95:                milliseconds(percentile(sorted, 0.95)),
+		P99:                milliseconds(percentile(sorted, 0.99)),
+		Availability:       1,
+		AvailabilityTarget: objective.Availability,
+		AvailabilityBudget: budgetRemaining(stats.errors, stats.requests, objective.Availability),
+		LatencyThreshold:   milliseconds(objective.Latency),
+		LatencyTarget:      objective.LatencyTarget,
+		LatencyBudget:      budgetRemaining(stats.slow, stats.requests, objective.LatencyTarget),
+	}
+	if stats.requests > 0 {
+		s.Availability = 1 - float64(stats.errors)/float64(stats.requests)
+	}
+	s.Met = s.AvailabilityBudget >= 0 && s.LatencyBudget >= 0
+	return s
+}
+
+// budgetRemaining returns the fraction of the error budget of target left after bad of total
+// requests failed it.
+func budgetRemaining(bad, total uint64, target float64) float64 {
+	if total == 0 {
+		return 1
+	}
+	allowed := (1 - target) * float64(total)
+	return math.Round((1-float64(bad)/allowed)*1e4) / 1e4
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*1000) / 1000
+}
@@ -0,0 +1,102 @@
+package slo
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+var testObjective = Objective{Availability: 0.9, Latency: 100 * time.Millisecond, LatencyTarget: 0.8}
+
+func newTestTracker(t *testing.T, window int) *Tracker {
+	t.Helper()
+	tracker, err := NewTracker(testObjective, window)
+	if err != nil {
+		t.Fatalf("NewTracker() error = %v", err)
+	}
+	return tracker
+}
+
+func TestTracker_PercentilesAndBudgets(t *testing.T) {
+	tracker := newTestTracker(t, 100)
+	route := RouteKey(http.MethodGet, "/api/items/:id")
+	for i := 1; i <= 100; i++ {
+		status := http.StatusOK
+		if i <= 5 {
+			status = http.StatusInternalServerError
+		}
+		tracker.Observe(route, status, time.Duration(i)*2*time.Millisecond)
+	}
+
+	report := tracker.Report()
+	if len(report.Routes) != 1 {
+		t.Fatalf("expected one route, got %+v", report.Routes)
+	}
+	s := report.Routes[0]
+	if s.P50 != 100 || s.P95 != 190 || s.P99 != 198 {
+		t.Errorf("percentiles = %v/%v/%v, want 100/190/198", s.P50, s.P95, s.P99)
+	}
+	// 5 errors of a budget of 10, and 50 slow requests of a budget of 20
+	if s.Errors != 5 || s.AvailabilityBudget != 0.5 {
+		t.Errorf("availability: errors %d, budget %v", s.Errors, s.AvailabilityBudget)
+	}
+	if s.SlowRequests != 50 || s.LatencyBudget != -1.5 || s.Met {
+		t.Errorf("latency: slow %d, budget %v, met %v", s.SlowRequests, s.LatencyBudget, s.Met)
+	}
+}
+
+func TestTracker_WindowKeepsRecentSamples(t *testing.T) {
+	tracker := newTestTracker(t, 10)
+	for i := 0; i < 10; i++ {
+		tracker.Observe("GET /slow", http.StatusOK, time.Second)
+	}
+	for i := 0; i < 10; i++ {
+		tracker.Observe("GET /slow", http.StatusOK, time.Millisecond)
+	}
+
+	s := tracker.Report().Routes[0]
+	if s.P99 != 1 {
+		t.Errorf("p99 = %vms, want samples older than the window dropped", s.P99)
+	}
+	if s.Requests != 20 || s.SlowRequests != 10 {
+		t.Errorf("counters should be cumulative, got %+v", s)
+	}
+}
+
+func TestTracker_LatencyOverrides(t *testing.T) {
+	tracker := newTestTracker(t, 10)
+	overrides, err := ParseLatencyOverrides([]string{"post /api/auth/login = 1s"})
+	if err != nil {
+		t.Fatalf("ParseLatencyOverrides() error = %v", err)
+	}
+	if err := tracker.SetLatency(overrides); err != nil {
+		t.Fatalf("SetLatency() error = %v", err)
+	}
+
+	if _, slow := tracker.Observe("POST /api/auth/login", http.StatusOK, 500*time.Millisecond); slow {
+		t.Error("request within the overridden threshold counted as slow")
+	}
+	if _, slow := tracker.Observe("GET /api/users", http.StatusOK, 500*time.Millisecond); !slow {
+		t.Error("request over the default threshold not counted as slow")
+	}
+	if failed, _ := tracker.Observe("GET /api/users", http.StatusNotFound, 0); failed {
+		t.Error("4xx responses must not consume the availability budget")
+	}
+}
+
+func TestParseLatencyOverrides_Invalid(t *testing.T) {
+	for _, entry := range []string{"/api/users=1s", "GET /api/users", "GET /api/users=fast", "GET /api/users=0s"} {
+		if _, err := ParseLatencyOverrides([]string{entry}); err == nil {
+			t.Errorf("expected an error for %q", entry)
+		}
+	}
+}
+
+func TestNewTracker_Validates(t *testing.T) {
+	if _, err := NewTracker(Objective{Availability: 1, Latency: time.Second, LatencyTarget: 0.9}, 10); err == nil {
+		t.Error("expected an error for a target without error budget")
+	}
+	if _, err := NewTracker(testObjective, 0); err != ErrInvalidWindow {
+		t.Errorf("error = %v, want ErrInvalidWindow", err)
+	}
+}
@@ -0,0 +1,339 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3PartSize is the size of the parts of multipart uploads. Objects smaller than one part are
+// uploaded with a single request. S3 allows at most 10,000 parts, so objects up to about 80 GB.
+const S3PartSize = 8 << 20
+
+// emptyHash is the SHA-256 of an empty payload.
+const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Config configures an S3 storage.
+type S3Config struct {
+	Bucket string
+	Region string
+	// Endpoint is the base URL of an S3-compatible service such as MinIO; empty uses AWS.
+	Endpoint string
+	// PathStyle addresses the bucket in the path (endpoint/bucket/key) instead of the host
+	// (bucket.endpoint/key), as most S3-compatible services need.
+	PathStyle       bool
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3 stores objects in an S3 bucket, signing its requests with AWS Signature Version 4.
+type S3 struct {
+	cfg        S3Config
+	base       *url.URL
+	httpClient *http.Client
+	partSize   int
+	now        func() time.Time
+}
+
+// S3Error is an error response from S3.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3 request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3 request failed with status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// NewS3 creates a storage in the bucket of cfg.
+func NewS3(cfg S3Config, httpClient *http.Client) (*S3, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, errors.New("s3 storage needs a bucket and a region")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("s3 storage needs an access key ID and a secret access key")
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	base, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+	if !cfg.PathStyle {
+		base.Host = cfg.Bucket + "." + base.Host
+	}
+	return &S3{cfg: cfg, base: base, httpClient: httpClient, partSize: S3PartSize, now: time.Now}, nil
+}
+
+// Put uploads r in a single request when it fits in one part, and as a multipart upload of
+// S3PartSize parts otherwise, holding one part in memory at a time.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader) error {
+	part := make([]byte, s.partSize)
+	n, err := io.ReadFull(r, part)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return s.do(ctx, http.MethodPut, key, nil, part[:n], nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	uploadID, err := s.CreateMultipartUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := s.uploadParts(ctx, key, uploadID, r, part[:n]); err != nil {
+		// Abort with a fresh context, so that a canceled upload does not leave its parts billed
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		_ = s.AbortMultipartUpload(abortCtx, key, uploadID)
+		return err
+	}
+	return nil
+}
+
+func (s *S3) uploadParts(ctx context.Context, key, uploadID string, r io.Reader, first []byte) error {
+	var parts []Part
+	buf := first
+	for number := 1; ; number++ {
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, err := s.send(ctx, http.MethodPut, key, query, buf)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		parts = append(parts, Part{Number: number, ETag: resp.Header.Get("ETag")})
+
+		n, err := io.ReadFull(r, buf[:cap(buf)])
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		buf = buf[:n]
+		if err == io.ErrUnexpectedEOF {
+			// Upload the last, shorter part and stop
+			query.Set("partNumber", strconv.Itoa(number+1))
+			resp, err := s.send(ctx, http.MethodPut, key, query, buf)
+			if err != nil {
+				return err
+			}
+			_ = resp.Body.Close()
+			parts = append(parts, Part{Number: number + 1, ETag: resp.Header.Get("ETag")})
+			break
+		}
+	}
+	return s.CompleteMultipartUpload(ctx, key, uploadID, parts)
+}
+
+// Get downloads the object of key.
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.send(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		var s3Err *S3Error
+		if errors.As(err, &s3Err) && s3Err.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// List lists the bucket with ListObjectsV2, following continuation tokens.
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		var page struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := s.do(ctx, http.MethodGet, "", query, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, c := range page.Contents {
+			objects = append(objects, Object{Key: c.Key, Size: c.Size, LastModified: c.LastModified})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Delete deletes the object of key.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+}
+
+// do sends a request and decodes its XML response into out, if not nil.
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, body []byte, out interface{}) error {
+	resp, err := s.send(ctx, method, key, query, body)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil
+	}
+	return xml.NewDecoder(resp.Body).Decode(out)
+}
+
+// send sends a signed request, returning an *S3Error for responses other than 2xx.
+func (s *S3) send(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := s.url(key, query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if len(body) > 0 {
+		// Required by the configuration requests, such as lifecycle rules, and checked by all
+		sum := md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	s.sign(req, body)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer func() { _ = resp.Body.Close() }()
+		s3Err := &S3Error{StatusCode: resp.StatusCode}
+		_ = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(s3Err)
+		return nil, s3Err
+	}
+	return resp, nil
+}
+
+// url returns the URL of key, or of the bucket when key is empty, with query.
+func (s *S3) url(key string, query url.Values) *url.URL {
+	u := *s.base
+	u.Path = "/" + key
+	if s.cfg.PathStyle {
+		u.Path = strings.TrimSuffix("/"+s.cfg.Bucket+"/"+key, "/")
+	}
+	u.RawPath = encodePath(u.Path)
+	u.RawQuery = encodeQuery(query)
+	return &u
+}
+
+// sign adds the AWS Signature Version 4 headers to req.
+func (s *S3) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := emptyHash
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := s.scope(date)
+	signature := s.signature(date, amzDate, scope, canonicalRequest)
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// scope returns the credential scope of the requests signed on date.
+func (s *S3) scope(date string) string {
+	return date + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request made at amzDate.
+func (s *S3) signature(date, amzDate, scope, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	for _, part := range []string{s.cfg.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// encodePath escapes every byte of path except the unreserved characters and "/", as SigV4
+// expects.
+func encodePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = encodeURIComponent(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// encodeQuery encodes query sorted by key, as SigV4 expects.
+func encodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			pairs = append(pairs, encodeURIComponent(k)+"="+encodeURIComponent(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func encodeURIComponent(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
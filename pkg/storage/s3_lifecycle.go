@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxPresignExpiry is the longest validity of a presigned URL allowed by S3.
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// Part is an uploaded part of a multipart upload.
+type Part struct {
+	Number int `xml:"PartNumber"`
+	// ETag is the ETag header of the response to the part's upload, quotes included.
+	ETag string `xml:"ETag"`
+}
+
+// LifecycleRule expires the objects of a bucket matching its filter.
+type LifecycleRule struct {
+	ID string `json:"id"`
+	// Prefix and Tags select the objects the rule applies to: those whose key starts with
+	// Prefix and that have every tag of Tags. Both empty select the whole bucket.
+	Prefix string            `json:"prefix,omitempty"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	// ExpirationDays deletes objects this many days after their creation; 0 keeps them.
+	ExpirationDays int `json:"expiration_days,omitempty"`
+	// AbortIncompleteUploadDays aborts multipart uploads not completed this many days after
+	// they were started, whose parts are billed until then; 0 keeps them.
+	AbortIncompleteUploadDays int `json:"abort_incomplete_upload_days,omitempty"`
+}
+
+// Ping checks that the bucket exists and that the credentials can access it (HeadBucket).
+func (s *S3) Ping(ctx context.Context) error {
+	return s.do(ctx, http.MethodHead, "", nil, nil, nil)
+}
+
+// Presign returns a URL letting whoever holds it send a method request for key, with query,
+// without credentials until expires has passed. Browsers can so upload and download objects
+// directly. expires must be at most MaxPresignExpiry.
+func (s *S3) Presign(method, key string, query url.Values, expires time.Duration) (string, error) {
+	if expires < time.Second || expires > MaxPresignExpiry {
+		return "", fmt.Errorf("presigned URLs must expire within 1s and %s, got %s", MaxPresignExpiry, expires)
+	}
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := s.scope(date)
+
+	signed := url.Values{}
+	for k, v := range query {
+		signed[k] = v
+	}
+	signed.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	signed.Set("X-Amz-Credential", s.cfg.AccessKeyID+"/"+scope)
+	signed.Set("X-Amz-Date", amzDate)
+	signed.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	signed.Set("X-Amz-SignedHeaders", "host")
+
+	u := s.url(key, signed)
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	u.RawQuery += "&X-Amz-Signature=" + s.signature(date, amzDate, scope, canonicalRequest)
+	return u.String(), nil
+}
+
+// PresignGet returns a URL downloading key until expires has passed.
+func (s *S3) PresignGet(key string, expires time.Duration) (string, error) {
+	return s.Presign(http.MethodGet, key, nil, expires)
+}
+
+// PresignPut returns a URL uploading key in a single PUT request, of up to 5 GB, until expires
+// has passed.
+func (s *S3) PresignPut(key string, expires time.Duration) (string, error) {
+	return s.Presign(http.MethodPut, key, nil, expires)
+}
+
+// CreateMultipartUpload starts a multipart upload of key and returns its upload ID. Clients
+// upload the parts with PresignUploadPart URLs; the upload is then completed with
+// CompleteMultipartUpload, or aborted with AbortMultipartUpload.
+func (s *S3) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, &initiated); err != nil {
+		return "", err
+	}
+	if initiated.UploadID == "" {
+		return "", errors.New("s3 returned no upload ID")
+	}
+	return initiated.UploadID, nil
+}
+
+// PresignUploadPart returns a URL uploading part number partNumber (1-10000) of an upload with
+// a PUT request, until expires has passed. Every part but the last must be at least 5 MB. The
+// client returns the ETag header of each response, to complete the upload.
+func (s *S3) PresignUploadPart(key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	if partNumber < 1 || partNumber > 10000 {
+		return "", fmt.Errorf("part number %d is not within 1 and 10000", partNumber)
+	}
+	query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+	return s.Presign(http.MethodPut, key, query, expires)
+}
+
+// CompleteMultipartUpload assembles the parts of an upload, in the order of their numbers,
+// into the object of key.
+func (s *S3) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error {
+	if len(parts) == 0 {
+		return errors.New("a multipart upload needs at least one part")
+	}
+	sorted := append([]Part(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []Part   `xml:"Part"`
+	}{Parts: sorted})
+	if err != nil {
+		return err
+	}
+	// A completion may fail after S3 has answered 200, with an error in the body
+	var completed struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body, &completed); err != nil {
+		return err
+	}
+	if completed.XMLName.Local == "Error" {
+		return &S3Error{StatusCode: http.StatusOK, Code: completed.Code, Message: completed.Message}
+	}
+	return nil
+}
+
+// AbortMultipartUpload discards an upload and the parts uploaded so far.
+func (s *S3) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	return s.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+}
+
+// tagXML is a tag in S3 requests and responses.
+type tagXML struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// tagsXML returns tags sorted by key.
+func tagsXML(tags map[string]string) []tagXML {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]tagXML, len(keys))
+	for i, k := range keys {
+		out[i] = tagXML{Key: k, Value: tags[k]}
+	}
+	return out
+}
+
+func tagsMap(tags []tagXML) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(tags))
+	for _, t := range tags {
+		m[t.Key] = t.Value
+	}
+	return m
+}
+
+// SetTags replaces the tags of the object of key (at most 10), which lifecycle rules can
+// select, e.g. to expire the uploads tagged as temporary.
+func (s *S3) SetTags(ctx context.Context, key string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return s.do(ctx, http.MethodDelete, key, url.Values{"tagging": {""}}, nil, nil)
+	}
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"Tagging"`
+		Tags    []tagXML `xml:"TagSet>Tag"`
+	}{Tags: tagsXML(tags)})
+	if err != nil {
+		return err
+	}
+	return s.do(ctx, http.MethodPut, key, url.Values{"tagging": {""}}, body, nil)
+}
+
+// Tags returns the tags of the object of key, or ErrNotFound.
+func (s *S3) Tags(ctx context.Context, key string) (map[string]string, error) {
+	var tagging struct {
+		Tags []tagXML `xml:"TagSet>Tag"`
+	}
+	if err := s.do(ctx, http.MethodGet, key, url.Values{"tagging": {""}}, nil, &tagging); err != nil {
+		var s3Err *S3Error
+		if errors.As(err, &s3Err) && s3Err.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return tagsMap(tagging.Tags), nil
+}
+
+type lifecycleXML struct {
+	XMLName xml.Name  `xml:"LifecycleConfiguration"`
+	Rules   []ruleXML `xml:"Rule"`
+}
+
+type ruleXML struct {
+	ID string `xml:"ID,omitempty"`
+	// Prefix is the filter of rules written before filters existed; only read.
+	Prefix     string    `xml:"Prefix,omitempty"`
+	Filter     filterXML `xml:"Filter"`
+	Status     string    `xml:"Status"`
+	Expiration *struct {
+		Days int `xml:"Days"`
+	} `xml:"Expiration,omitempty"`
+	AbortIncompleteMultipartUpload *struct {
+		DaysAfterInitiation int `xml:"DaysAfterInitiation"`
+	} `xml:"AbortIncompleteMultipartUpload,omitempty"`
+}
+
+type filterXML struct {
+	Prefix string  `xml:"Prefix,omitempty"`
+	Tag    *tagXML `xml:"Tag,omitempty"`
+	And    *struct {
+		Prefix string   `xml:"Prefix,omitempty"`
+		Tags   []tagXML `xml:"Tag"`
+	} `xml:"And,omitempty"`
+}
+
+// SetLifecycle replaces the lifecycle rules of the bucket with rules, or removes them all
+// when rules is empty. Rules set by other tools are replaced too.
+func (s *S3) SetLifecycle(ctx context.Context, rules []LifecycleRule) error {
+	if len(rules) == 0 {
+		return s.do(ctx, http.MethodDelete, "", url.Values{"lifecycle": {""}}, nil, nil)
+	}
+	config := lifecycleXML{}
+	for _, rule := range rules {
+		if rule.ExpirationDays < 0 || rule.AbortIncompleteUploadDays < 0 ||
+			rule.ExpirationDays == 0 && rule.AbortIncompleteUploadDays == 0 {
+			return fmt.Errorf("lifecycle rule %q needs positive expiration or abort days", rule.ID)
+		}
+		r := ruleXML{ID: rule.ID, Status: "Enabled"}
+		switch tags := tagsXML(rule.Tags); {
+		case len(tags) == 0:
+			r.Filter.Prefix = rule.Prefix
+		case len(tags) == 1 && rule.Prefix == "":
+			r.Filter.Tag = &tags[0]
+		default:
+			r.Filter.And = &struct {
+				Prefix string   `xml:"Prefix,omitempty"`
+				Tags   []tagXML `xml:"Tag"`
+			}{Prefix: rule.Prefix, Tags: tags}
+		}
+		if rule.ExpirationDays > 0 {
+			r.Expiration = &struct {
+				Days int `xml:"Days"`
+			}{Days: rule.ExpirationDays}
+		}
+		if rule.AbortIncompleteUploadDays > 0 {
+			r.AbortIncompleteMultipartUpload = &struct {
+				DaysAfterInitiation int `xml:"DaysAfterInitiation"`
+			}{DaysAfterInitiation: rule.AbortIncompleteUploadDays}
+		}
+		config.Rules = append(config.Rules, r)
+	}
+	body, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return s.do(ctx, http.MethodPut, "", url.Values{"lifecycle": {""}}, body, nil)
+}
+
+// Lifecycle returns the enabled lifecycle rules of the bucket. Parts of rules other than the
+// filter, the expiration in days and the aborting of uploads are left out.
+func (s *S3) Lifecycle(ctx context.Context) ([]LifecycleRule, error) {
+	var config lifecycleXML
+	if err := s.do(ctx, http.MethodGet, "", url.Values{"lifecycle": {""}}, nil, &config); err != nil {
+		var s3Err *S3Error
+		if errors.As(err, &s3Err) && s3Err.Code == "NoSuchLifecycleConfiguration" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var rules []LifecycleRule
+	for _, r := range config.Rules {
+		if r.Status != "Enabled" {
+			continue
+		}
+		rule := LifecycleRule{ID: r.ID, Prefix: r.Prefix + r.Filter.Prefix}
+		switch {
+		case r.Filter.Tag != nil:
+			rule.Tags = tagsMap([]tagXML{*r.Filter.Tag})
+		case r.Filter.And != nil:
+			rule.Prefix = r.Filter.And.Prefix
+			rule.Tags = tagsMap(r.Filter.And.Tags)
+		}
+		if r.Expiration != nil {
+			rule.ExpirationDays = r.Expiration.Days
+		}
+		if r.AbortIncompleteMultipartUpload != nil {
+			rule.AbortIncompleteUploadDays = r.AbortIncompleteMultipartUpload.DaysAfterInitiation
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
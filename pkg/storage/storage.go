@@ -0,0 +1,152 @@
+// Package storage stores files such as database backups by key, on the local disk or in an
+// S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when no object has the requested key.
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored object.
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Storage stores objects by key. Keys are slash-separated paths such as "backups/db.gz".
+type Storage interface {
+	// Put stores the contents of r under key, replacing any object with that key. r is read
+	// until EOF as it is uploaded, so it may be larger than memory.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the object stored under key, or returns ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the objects whose key starts with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Delete removes the object stored under key; deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Local stores objects as files under a directory.
+type Local struct {
+	dir string
+}
+
+// NewLocal creates a storage in dir, which is created on the first Put.
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+func (l *Local) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes r to a temporary file and renames it to key, so that a failed upload never leaves
+// a truncated object behind.
+func (l *Local) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if _, err := io.Copy(f, contextReader{ctx: ctx, r: r}); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Get opens the file of key.
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// List walks the directory for files whose key starts with prefix.
+func (l *Local) List(_ context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(l.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == l.dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, err
+}
+
+// Delete removes the file of key.
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// contextReader stops reading once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testStorage exercises the Storage contract on s.
+func testStorage(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, key := range []string{"backups/b.gz", "backups/a.gz", "other/c.gz"} {
+		if err := s.Put(ctx, key, strings.NewReader("data of "+key)); err != nil {
+			t.Fatalf("Put(%q) failed: %v", key, err)
+		}
+	}
+
+	r, err := s.Get(ctx, "backups/a.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	_ = r.Close()
+	if string(data) != "data of backups/a.gz" {
+		t.Fatalf("Get() = %q", data)
+	}
+	if _, err := s.Get(ctx, "backups/missing.gz"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	objects, err := s.List(ctx, "backups/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].Key != "backups/a.gz" || objects[1].Key != "backups/b.gz" {
+		t.Fatalf("List() = %+v", objects)
+	}
+	if objects[0].Size != int64(len("data of backups/a.gz")) || objects[0].LastModified.IsZero() {
+		t.Errorf("unexpected object %+v", objects[0])
+	}
+
+	if err := s.Delete(ctx, "backups/a.gz"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "backups/a.gz"); err != nil {
+		t.Fatalf("expected deleting a missing key to succeed, got %v", err)
+	}
+	if objects, _ := s.List(ctx, "backups/"); len(objects) != 1 {
+		t.Fatalf("expected 1 object after the delete, got %+v", objects)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	testStorage(t, NewLocal(t.TempDir()))
+}
+
+func TestLocalRejectsKeysOutsideTheDirectory(t *testing.T) {
+	s := NewLocal(t.TempDir())
+	for _, key := range []string{"", "../escape", "/etc/passwd"} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
+
+func TestLocalListMissingDirectory(t *testing.T) {
+	objects, err := NewLocal(t.TempDir()+"/missing").List(context.Background(), "")
+	if err != nil || len(objects) != 0 {
+		t.Fatalf("List() = %v, %v", objects, err)
+	}
+}
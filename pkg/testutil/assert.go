@@ -0,0 +1,66 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yeferson59/gin-template/pkg/response"
+)
+
+// AssertStatus fails the test when the response status is not want.
+func AssertStatus(t testing.TB, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("expected status %d, got %d, body: %s", want, w.Code, w.Body.String())
+	}
+}
+
+// DecodeData decodes the data field of a success envelope into v.
+func DecodeData(t testing.TB, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("invalid JSON response: %v, body: %s", err, w.Body.String())
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		t.Fatalf("failed to decode response data: %v, body: %s", err, w.Body.String())
+	}
+}
+
+// DecodeError returns the error of an error envelope.
+func DecodeError(t testing.TB, w *httptest.ResponseRecorder) *response.APIError {
+	t.Helper()
+	var envelope response.APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("invalid JSON response: %v, body: %s", err, w.Body.String())
+	}
+	if envelope.Error == nil {
+		t.Fatalf("expected an error response, body: %s", w.Body.String())
+	}
+	return envelope.Error
+}
+
+// AssertError fails the test unless the response has the given status and error code.
+func AssertError(t testing.TB, w *httptest.ResponseRecorder, status int, code string) *response.APIError {
+	t.Helper()
+	AssertStatus(t, w, status)
+	apiErr := DecodeError(t, w)
+	if apiErr.Code != code {
+		t.Fatalf("expected error code %s, got %s, body: %s", code, apiErr.Code, w.Body.String())
+	}
+	return apiErr
+}
+
+// AssertFieldError fails the test unless the validation error reports field with code.
+func AssertFieldError(t testing.TB, w *httptest.ResponseRecorder, field, code string) {
+	t.Helper()
+	for _, f := range DecodeError(t, w).Fields {
+		if f.Field == field && f.Code == code {
+			return
+		}
+	}
+	t.Fatalf("expected field error %s/%s, body: %s", field, code, w.Body.String())
+}
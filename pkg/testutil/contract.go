@@ -0,0 +1,46 @@
+package testutil
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yeferson59/gin-template/pkg/contract"
+)
+
+// ProviderState loads the fixtures of a Pact provider state into app, such as the user an
+// interaction reads. params are the parameters of the state in v3 pacts. The returned headers
+// are added to the request, such as the Authorization of a signed-in user.
+type ProviderState func(t testing.TB, app *App, params map[string]interface{}) http.Header
+
+// VerifyPact runs provider verification of p as a subtest per interaction, each against a new
+// App set up by register and the states of the interaction, so fixtures never leak between
+// interactions. It reports whether every interaction passed. An interaction with a state
+// missing from states fails.
+func VerifyPact(t *testing.T, p *contract.Pact, register func(app *App), states map[string]ProviderState) bool {
+	t.Helper()
+	return t.Run(p.Name(), func(t *testing.T) {
+		for _, interaction := range p.Interactions {
+			t.Run(interaction.Description, func(t *testing.T) {
+				app := NewApp(t, register)
+				header := make(http.Header)
+				for _, state := range interaction.States() {
+					setup, ok := states[state.Name]
+					if !ok {
+						t.Fatalf("no fixtures for provider state %q", state.Name)
+					}
+					for name, values := range setup(t, app, state.Params) {
+						header[http.CanonicalHeaderKey(name)] = values
+					}
+				}
+
+				mismatches, err := contract.Verify(app.Router, interaction, header)
+				if err != nil {
+					t.Fatalf("invalid interaction: %v", err)
+				}
+				for _, m := range mismatches {
+					t.Errorf("%s %s: %s", interaction.Request.Method, interaction.Request.Path, m)
+				}
+			})
+		}
+	})
+}
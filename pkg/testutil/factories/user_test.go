@@ -0,0 +1,102 @@
+package factories_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/routes"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+	"github.com/yeferson59/gin-template/pkg/testutil/factories"
+)
+
+func TestUsers_DefaultsAndOverrides(t *testing.T) {
+	db := testutil.NewDB(t)
+	users := factories.Users(t, db)
+
+	first := users.Create()
+	if first.Username != "fixture1" || first.Email != "fixture1@example.com" || first.Role != models.RoleUser {
+		t.Fatalf("first user = %+v", first)
+	}
+	if !first.CreatedAt.Equal(factories.Epoch) {
+		t.Errorf("created at %v, want Epoch", first.CreatedAt)
+	}
+
+	admin := users.Create(testutil.WithUsername("root"), testutil.WithRole(models.RoleAdmin))
+	if admin.Username != "root" || admin.Email != "fixture2@example.com" || admin.Role != models.RoleAdmin {
+		t.Fatalf("overridden user = %+v", admin)
+	}
+	if !admin.CreatedAt.Equal(factories.Epoch.Add(time.Minute)) {
+		t.Errorf("created at %v, want a minute after Epoch", admin.CreatedAt)
+	}
+
+	// Another factory of the same database continues the numbering, with its own defaults
+	admins := factories.Users(t, db, testutil.WithRole(models.RoleAdmin))
+	if third := admins.Create(); third.Username != "fixture3" || third.Role != models.RoleAdmin {
+		t.Fatalf("user of the second factory = %+v", third)
+	}
+
+	old := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	if dated := users.Create(testutil.WithCreatedAt(old)); !dated.CreatedAt.Equal(old) || !dated.UpdatedAt.Equal(old) {
+		t.Errorf("dated user = %+v, want it created at %v", dated, old)
+	}
+
+	if built := users.Build(); built.ID != 0 || built.Username != "fixture5" {
+		t.Fatalf("built user = %+v, want it unsaved", built)
+	}
+}
+
+func TestUsers_CreateN(t *testing.T) {
+	db := testutil.NewDB(t)
+
+	created := factories.Users(t, db).CreateN(3, func(i int) []testutil.UserOption {
+		if i == 0 {
+			return []testutil.UserOption{testutil.WithRole(models.RoleAdmin)}
+		}
+		return nil
+	})
+	if len(created) != 3 || created[0].Role != models.RoleAdmin || created[2].Username != "fixture3" || created[2].ID == 0 {
+		t.Fatalf("created users = %+v", created)
+	}
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 3 {
+		t.Fatalf("stored %d users, want 3", count)
+	}
+}
+
+func TestUsers_DeletedWhenTestEnds(t *testing.T) {
+	db := testutil.NewDB(t)
+
+	t.Run("creates", func(t *testing.T) {
+		users := factories.Users(t, db)
+		user := users.Create()
+		users.CreateN(2, nil)
+		db.Delete(user) // soft-deleted rows are removed too
+	})
+
+	var count int64
+	db.Unscoped().Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("%d users left after the subtest, want 0", count)
+	}
+	// The numbering starts again for the next test
+	if user := factories.Users(t, db).Create(); user.Username != "fixture1" {
+		t.Fatalf("username = %q, want fixture1", user.Username)
+	}
+}
+
+func TestUsers_CanLogIn(t *testing.T) {
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		routes.RegisterAPIRoutes(a.Router, a.DB, a.Config, routes.Services{})
+	})
+	user := factories.Users(t, app.DB).Create(testutil.WithPassword("An0ther!Secret"))
+
+	w := testutil.Post("/api/auth/login").WithJSON(map[string]string{
+		"username": user.Username,
+		"password": "An0ther!Secret",
+	}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+}
@@ -0,0 +1,81 @@
+package testutil
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+// DefaultPassword is the plain-text password of users created by CreateUser.
+const DefaultPassword = "TestPass123!"
+
+var userSeq atomic.Int64
+
+// UserOption customizes a user created by CreateUser.
+type UserOption func(u *models.User, password *string)
+
+// WithUsername sets the username.
+func WithUsername(username string) UserOption {
+	return func(u *models.User, _ *string) { u.Username = username }
+}
+
+// WithEmail sets the email.
+func WithEmail(email string) UserOption {
+	return func(u *models.User, _ *string) { u.Email = email }
+}
+
+// WithRole sets the role.
+func WithRole(role string) UserOption {
+	return func(u *models.User, _ *string) { u.Role = role }
+}
+
+// WithPassword sets the plain-text password, stored hashed.
+func WithPassword(password string) UserOption {
+	return func(_ *models.User, p *string) { *p = password }
+}
+
+// WithCreatedAt sets the creation and update times.
+func WithCreatedAt(at time.Time) UserOption {
+	return func(u *models.User, _ *string) { u.CreatedAt, u.UpdatedAt = at, at }
+}
+
+// CreateUser inserts a user with a unique username and email, the user role, and
+// DefaultPassword, as modified by opts.
+func CreateUser(t testing.TB, db *gorm.DB, opts ...UserOption) *models.User {
+	t.Helper()
+
+	n := userSeq.Add(1)
+	user := &models.User{
+		Username: fmt.Sprintf("user%d", n),
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		Role:     models.RoleUser,
+	}
+	password := DefaultPassword
+	for _, opt := range opts {
+		opt(user, &password)
+	}
+
+	// MinCost keeps tests fast; the handlers still verify it like any bcrypt hash.
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("testutil: failed to hash password: %v", err)
+	}
+	user.Password = string(hashed)
+
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("testutil: failed to create user: %v", err)
+	}
+	return user
+}
+
+// CreateAdmin inserts an administrator. See CreateUser.
+func CreateAdmin(t testing.TB, db *gorm.DB, opts ...UserOption) *models.User {
+	t.Helper()
+	return CreateUser(t, db, append([]UserOption{WithRole(models.RoleAdmin)}, opts...)...)
+}
@@ -0,0 +1,116 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden write the golden files
+// instead of comparing with them: UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// goldenMask replaces the values of ignored fields in golden files.
+const goldenMask = "<ignored>"
+
+// goldenVolatile are the fields of the envelope that change on every request.
+var goldenVolatile = []string{"meta.request_id", "meta.timestamp"}
+
+// AssertGolden fails the test unless the JSON body of the response matches the golden file
+// testdata/<name>.golden.json of the package under test. Bodies are compared indented and with
+// sorted keys; meta.request_id, meta.timestamp and the fields of ignore, dotted paths in which
+// * matches any key or array element (e.g. "data.*.token"), are replaced with "<ignored>".
+// Run the tests with UpdateGoldenEnv set to create or update the files.
+func AssertGolden(t testing.TB, w *httptest.ResponseRecorder, name string, ignore ...string) {
+	t.Helper()
+
+	var body interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response: %v, body: %s", err, w.Body.String())
+	}
+	for _, path := range append(append([]string{}, goldenVolatile...), ignore...) {
+		maskGolden(body, strings.Split(path, "."))
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(body); err != nil {
+		t.Fatalf("testutil: failed to encode response: %v", err)
+	}
+	got := buf.Bytes()
+
+	file := filepath.Join("testdata", name+".golden.json")
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatalf("testutil: failed to create %s: %v", filepath.Dir(file), err)
+		}
+		if err := os.WriteFile(file, got, 0o644); err != nil {
+			t.Fatalf("testutil: failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("testutil: failed to read golden file (run with %s=1 to create it): %v", UpdateGoldenEnv, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("response does not match %s (run with %s=1 to update it)\n%s", file, UpdateGoldenEnv, goldenDiff(string(want), string(got)))
+	}
+}
+
+// maskGolden replaces the fields of value at path with goldenMask.
+func maskGolden(value interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	key, rest := path[0], path[1:]
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if key != "*" && key != k {
+				continue
+			}
+			if len(rest) == 0 {
+				v[k] = goldenMask
+			} else {
+				maskGolden(item, rest)
+			}
+		}
+	case []interface{}:
+		if key != "*" {
+			return
+		}
+		for i, item := range v {
+			if len(rest) == 0 {
+				v[i] = goldenMask
+			} else {
+				maskGolden(item, rest)
+			}
+		}
+	}
+}
+
+// goldenDiff describes the first line on which got differs from want.
+func goldenDiff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return "line " + strconv.Itoa(i+1) + ":\n- " + w + "\n+ " + g
+		}
+	}
+	return ""
+}
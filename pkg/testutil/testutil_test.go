@@ -0,0 +1,66 @@
+package testutil_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/routes"
+	"github.com/yeferson59/gin-template/pkg/testutil"
+	"github.com/yeferson59/gin-template/pkg/testutil/factories"
+)
+
+func TestNewApp_FullAPIWithJWT(t *testing.T) {
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		routes.RegisterAPIRoutes(a.Router, a.DB, a.Config, routes.Services{})
+	})
+	user := testutil.CreateUser(t, app.DB, testutil.WithUsername("alice"))
+
+	w := testutil.Get("/api/users/me").Do(t, app.Router)
+	testutil.AssertError(t, w, http.StatusUnauthorized, "UNAUTHORIZED")
+
+	w = testutil.Get("/api/users/me").WithJWT(user).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	var profile struct {
+		Username string `json:"username"`
+	}
+	testutil.DecodeData(t, w, &profile)
+	if profile.Username != "alice" {
+		t.Fatalf("expected profile of alice, got %+v", profile)
+	}
+}
+
+func TestCreateAdmin_CanLogIn(t *testing.T) {
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		routes.RegisterAPIRoutes(a.Router, a.DB, a.Config, routes.Services{})
+	})
+	admin := testutil.CreateAdmin(t, app.DB)
+
+	w := testutil.Post("/api/auth/login").WithJSON(map[string]string{
+		"username": admin.Username,
+		"password": testutil.DefaultPassword,
+	}).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	w = testutil.Get("/api/admin/users").WithJWT(admin).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+}
+
+func TestAssertGolden_AdminUserList(t *testing.T) {
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		routes.RegisterAPIRoutes(a.Router, a.DB, a.Config, routes.Services{})
+	})
+	users := factories.Users(t, app.DB)
+	admin := users.Create(testutil.WithUsername("admin"), testutil.WithRole(models.RoleAdmin))
+	users.CreateN(2, nil)
+
+	w := testutil.Get("/api/admin/users").WithJWT(admin).Do(t, app.Router)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	testutil.AssertGolden(t, w, "admin_users")
+
+	// Ignored fields match whatever their value
+	app.DB.Model(admin).Update("email", "renamed@example.com")
+	w = testutil.Get("/api/admin/users").WithJWT(admin).Do(t, app.Router)
+	testutil.AssertGolden(t, w, "admin_users_ignored", "data.users.*.email", "data.users.*.updated_at")
+}
@@ -0,0 +1,33 @@
+package vcr
+
+import (
+	"net/http"
+	"testing"
+
+	"gopkg.in/dnaeon/go-vcr.v4/pkg/cassette"
+)
+
+func TestRedact(t *testing.T) {
+	i := &cassette.Interaction{
+		Request: cassette.Request{Headers: http.Header{
+			"Authorization": {"Bearer sk_live_secret"},
+			"Content-Type":  {"application/json"},
+		}},
+		Response: cassette.Response{Headers: http.Header{
+			"Set-Cookie": {"session=abc"},
+			"Request-Id": {"req_1"},
+		}},
+	}
+	if err := redact(i); err != nil {
+		t.Fatalf("redact() error = %v", err)
+	}
+	if _, ok := i.Request.Headers["Authorization"]; ok {
+		t.Error("Authorization was recorded")
+	}
+	if _, ok := i.Response.Headers["Set-Cookie"]; ok {
+		t.Error("Set-Cookie was recorded")
+	}
+	if i.Request.Headers.Get("Content-Type") == "" || i.Response.Headers.Get("Request-Id") == "" {
+		t.Errorf("redact() removed other headers: %+v %+v", i.Request.Headers, i.Response.Headers)
+	}
+}
@@ -0,0 +1,194 @@
+// Package watchdog guards the process against running out of memory or goroutines. It samples
+// the heap size and the goroutine count periodically and, above the configured limits, logs a
+// warning, forces a garbage collection and, while a limit is still exceeded, reports the
+// process as overloaded so that the load shedding middleware rejects new requests until it
+// recovers.
+package watchdog
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yeferson59/gin-template/pkg/logger"
+	"github.com/yeferson59/gin-template/pkg/metrics"
+)
+
+// Resources watched, as reported in the limit exceeded counter.
+const (
+	ResourceHeap       = "heap"
+	ResourceGoroutines = "goroutines"
+)
+
+var (
+	overloaded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "runtime_watchdog_overloaded",
+		Help: "Whether the watchdog is shedding load because a runtime limit is exceeded (1) or not (0).",
+	})
+	limitExceededTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "runtime_watchdog_limit_exceeded_total",
+		Help: "Times the heap size or goroutine count went above its watchdog limit, by resource.",
+	}, []string{"resource"})
+	forcedGCTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "runtime_watchdog_forced_gc_total",
+		Help: "Garbage collections forced by the watchdog because the heap was above its limit.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(overloaded, limitExceededTotal, forcedGCTotal)
+}
+
+// Limits are the thresholds of the watchdog; zero disables each one.
+type Limits struct {
+	// HeapBytes bounds the heap, counting the objects not yet collected.
+	HeapBytes uint64
+	// Goroutines bounds the goroutine count.
+	Goroutines int
+	// Shed reports the process as overloaded while a limit is exceeded after the forced
+	// collection; otherwise the watchdog only logs and collects.
+	Shed bool
+}
+
+// Stats are the figures of a check.
+type Stats struct {
+	HeapBytes      uint64    `json:"heap_bytes"`
+	HeapLimit      uint64    `json:"heap_limit,omitempty"`
+	Goroutines     int       `json:"goroutines"`
+	GoroutineLimit int       `json:"goroutine_limit,omitempty"`
+	GCCycles       uint32    `json:"gc_cycles"`
+	Overloaded     bool      `json:"overloaded"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+// Read returns the current figures of the process, without limits.
+func Read() Stats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return Stats{
+		HeapBytes:  mem.HeapAlloc,
+		Goroutines: runtime.NumGoroutine(),
+		GCCycles:   mem.NumGC,
+		CheckedAt:  time.Now().UTC(),
+	}
+}
+
+// Watchdog checks the process against Limits.
+type Watchdog struct {
+	limits Limits
+	// read and collect sample the runtime and force a collection; tests replace them.
+	read    func() Stats
+	collect func()
+
+	mu       sync.Mutex
+	checked  bool
+	last     Stats
+	exceeded map[string]bool
+
+	overloaded atomic.Bool
+}
+
+// New creates a watchdog for limits. It reports the process as not overloaded until a check
+// finds otherwise.
+func New(limits Limits) *Watchdog {
+	overloaded.Set(0)
+	return &Watchdog{
+		limits:   limits,
+		read:     Read,
+		collect:  debug.FreeOSMemory,
+		exceeded: make(map[string]bool),
+	}
+}
+
+// Overloaded reports whether the last check found a limit exceeded while shedding is enabled.
+func (w *Watchdog) Overloaded() bool {
+	return w.overloaded.Load()
+}
+
+// Stats returns the figures of the last check, or the current ones before the first check.
+func (w *Watchdog) Stats() Stats {
+	w.mu.Lock()
+	last, checked := w.last, w.checked
+	w.mu.Unlock()
+	if !checked {
+		last = w.read()
+		last.HeapLimit, last.GoroutineLimit = w.limits.HeapBytes, w.limits.Goroutines
+	}
+	return last
+}
+
+// Check samples the runtime, forces a collection when the heap is above its limit, and updates
+// whether the process is overloaded.
+func (w *Watchdog) Check() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := w.read()
+	heapOver := w.limits.HeapBytes > 0 && stats.HeapBytes > w.limits.HeapBytes
+	if heapOver {
+		// Garbage may be most of the heap; only a heap still too large after a collection counts
+		w.collect()
+		forcedGCTotal.Inc()
+		stats = w.read()
+		heapOver = stats.HeapBytes > w.limits.HeapBytes
+	}
+	goroutinesOver := w.limits.Goroutines > 0 && stats.Goroutines > w.limits.Goroutines
+
+	stats.HeapLimit, stats.GoroutineLimit = w.limits.HeapBytes, w.limits.Goroutines
+	w.transition(ResourceHeap, heapOver, stats)
+	w.transition(ResourceGoroutines, goroutinesOver, stats)
+
+	stats.Overloaded = w.limits.Shed && (heapOver || goroutinesOver)
+	if w.overloaded.Swap(stats.Overloaded) != stats.Overloaded {
+		if stats.Overloaded {
+			overloaded.Set(1)
+			logger.Warn("Runtime limit exceeded, shedding load")
+		} else {
+			overloaded.Set(0)
+			logger.Info("Runtime back within limits, no longer shedding load")
+		}
+	}
+	w.last, w.checked = stats, true
+	return stats
+}
+
+// transition logs when resource goes above or back below its limit.
+func (w *Watchdog) transition(resource string, over bool, stats Stats) {
+	if w.exceeded[resource] == over {
+		return
+	}
+	w.exceeded[resource] = over
+	entry := logger.WithFields(map[string]interface{}{
+		"resource":        resource,
+		"heap_bytes":      stats.HeapBytes,
+		"heap_limit":      stats.HeapLimit,
+		"goroutines":      stats.Goroutines,
+		"goroutine_limit": stats.GoroutineLimit,
+	})
+	if over {
+		limitExceededTotal.WithLabelValues(resource).Inc()
+		entry.Warn("Runtime watchdog limit exceeded")
+	} else {
+		entry.Info("Runtime watchdog limit no longer exceeded")
+	}
+}
+
+// Run checks the runtime every interval until ctx is canceled.
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
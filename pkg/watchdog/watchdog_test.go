@@ -0,0 +1,65 @@
+package watchdog
+
+import "testing"
+
+// fakeRuntime returns a watchdog reading heap and goroutines, where a collection frees garbage.
+func fakeRuntime(limits Limits, heap, garbage uint64, goroutines int) (*Watchdog, *int) {
+	w := New(limits)
+	collections := 0
+	w.read = func() Stats { return Stats{HeapBytes: heap, Goroutines: goroutines} }
+	w.collect = func() {
+		collections++
+		heap -= garbage
+		garbage = 0
+	}
+	return w, &collections
+}
+
+func TestCheck_GarbageOnlyCollects(t *testing.T) {
+	w, collections := fakeRuntime(Limits{HeapBytes: 100, Shed: true}, 150, 80, 10)
+
+	stats := w.Check()
+	if *collections != 1 || stats.HeapBytes != 70 {
+		t.Fatalf("collections = %d, heap = %d; want one collection down to 70", *collections, stats.HeapBytes)
+	}
+	if stats.Overloaded || w.Overloaded() {
+		t.Error("overloaded although the collection freed enough")
+	}
+}
+
+func TestCheck_ShedsWhileExceeded(t *testing.T) {
+	w, _ := fakeRuntime(Limits{HeapBytes: 100, Goroutines: 50, Shed: true}, 150, 10, 10)
+	if stats := w.Check(); !stats.Overloaded || !w.Overloaded() {
+		t.Fatalf("stats = %+v, want overloaded with the heap still above its limit", stats)
+	}
+	if got := w.Stats(); got.HeapLimit != 100 || got.GoroutineLimit != 50 || !got.Overloaded {
+		t.Errorf("Stats() = %+v, want the last check", got)
+	}
+
+	w.read = func() Stats { return Stats{HeapBytes: 60, Goroutines: 80} }
+	if !w.Check().Overloaded {
+		t.Error("not overloaded with too many goroutines")
+	}
+	w.read = func() Stats { return Stats{HeapBytes: 60, Goroutines: 20} }
+	if w.Check().Overloaded || w.Overloaded() {
+		t.Error("still overloaded back within limits")
+	}
+}
+
+func TestCheck_WithoutShedding(t *testing.T) {
+	w, collections := fakeRuntime(Limits{HeapBytes: 100, Goroutines: 5}, 150, 0, 10)
+	if w.Check().Overloaded {
+		t.Error("overloaded although shedding is disabled")
+	}
+	if *collections != 1 {
+		t.Errorf("collections = %d, want the heap still collected", *collections)
+	}
+}
+
+func TestStatsBeforeFirstCheck(t *testing.T) {
+	w := New(Limits{Goroutines: 1000})
+	stats := w.Stats()
+	if stats.Goroutines == 0 || stats.HeapBytes == 0 || stats.GoroutineLimit != 1000 {
+		t.Errorf("Stats() = %+v, want the current figures", stats)
+	}
+}
@@ -0,0 +1,178 @@
+// Package integration runs the repository and migration tests against real PostgreSQL and
+// MySQL servers started with testcontainers-go. It is a separate module so the main module
+// does not depend on Docker client libraries; run it with `make test-integration`.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mysql"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/yeferson59/gin-template/internal/config"
+	"github.com/yeferson59/gin-template/internal/database"
+)
+
+// Images can be overridden to test other server versions.
+var (
+	postgresImage = envOr("INTEGRATION_POSTGRES_IMAGE", "postgres:16-alpine")
+	mysqlImage    = envOr("INTEGRATION_MYSQL_IMAGE", "mysql:8.4")
+)
+
+// server is a database server shared by every test in the package.
+type server struct {
+	once      sync.Once
+	container testcontainers.Container
+	dsn       string
+	err       error
+}
+
+var (
+	servers = map[string]*server{"postgres": {}, "mysql": {}}
+	dbSeq   atomic.Int64
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, s := range servers {
+		if s.container != nil {
+			_ = s.container.Terminate(ctx)
+		}
+	}
+	os.Exit(code)
+}
+
+// forEachDriver runs fn against a fresh, empty database on every server.
+func forEachDriver(t *testing.T, fn func(t *testing.T, db *gorm.DB)) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("integration tests are skipped in -short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	for _, driver := range []string{"postgres", "mysql"} {
+		t.Run(driver, func(t *testing.T) {
+			fn(t, freshDB(t, driver))
+		})
+	}
+}
+
+// freshDB creates a new database on the driver's server, dropped when the test ends.
+func freshDB(t *testing.T, driver string) *gorm.DB {
+	t.Helper()
+
+	s := servers[driver]
+	s.once.Do(func() { s.container, s.dsn, s.err = start(driver) })
+	if s.err != nil {
+		t.Fatalf("failed to start %s: %v", driver, s.err)
+	}
+
+	admin := open(t, driver, s.dsn)
+	name := fmt.Sprintf("test_%d_%d", os.Getpid(), dbSeq.Add(1))
+	if err := admin.Exec("CREATE DATABASE " + name).Error; err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+
+	dsn, err := withDatabase(driver, s.dsn, name)
+	if err != nil {
+		t.Fatalf("failed to build DSN: %v", err)
+	}
+	db := open(t, driver, dsn)
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+		_ = admin.Exec("DROP DATABASE " + name).Error
+		if sqlDB, err := admin.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func start(driver string) (testcontainers.Container, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	switch driver {
+	case "postgres":
+		c, err := postgres.Run(ctx, postgresImage,
+			postgres.WithDatabase("app"),
+			postgres.WithUsername("app"),
+			postgres.WithPassword("app"),
+			postgres.BasicWaitStrategies(),
+		)
+		if err != nil {
+			return c, "", err
+		}
+		dsn, err := c.ConnectionString(ctx, "sslmode=disable")
+		return c, dsn, err
+	default:
+		c, err := mysql.Run(ctx, mysqlImage,
+			mysql.WithDatabase("app"),
+			mysql.WithUsername("root"),
+			mysql.WithPassword("app"),
+		)
+		if err != nil {
+			return c, "", err
+		}
+		dsn, err := c.ConnectionString(ctx, "charset=utf8mb4", "parseTime=True", "loc=Local")
+		return c, dsn, err
+	}
+}
+
+// open connects through database.InitDB so the tests exercise the application's driver setup.
+func open(t *testing.T, driver, dsn string) *gorm.DB {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Database.Driver = driver
+	cfg.Database.DSN = dsn
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", driver, err)
+	}
+	return db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+}
+
+// withDatabase returns dsn pointing at the database name.
+func withDatabase(driver, dsn, name string) (string, error) {
+	if driver == "postgres" {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.Path = "/" + name
+		return u.String(), nil
+	}
+
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	cfg.DBName = name
+	return cfg.FormatDSN(), nil
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
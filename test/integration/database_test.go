@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/app"
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+)
+
+func TestMigrate(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+		if _, err := app.RunChecks(context.Background(), app.DatabaseChecks(db, true), 10*time.Second); err == nil {
+			t.Fatal("expected pending migrations before migrating")
+		}
+
+		if err := database.Migrate(db); err != nil {
+			t.Fatalf("Migrate failed: %v", err)
+		}
+		if err := database.Migrate(db); err != nil {
+			t.Fatalf("second Migrate failed: %v", err)
+		}
+
+		if _, err := app.RunChecks(context.Background(), app.DatabaseChecks(db, true), 10*time.Second); err != nil {
+			t.Fatalf("expected checks to pass after migrating: %v", err)
+		}
+	})
+}
+
+func TestUniqueConstraintsAreCaseInsensitive(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+		if err := database.Migrate(db); err != nil {
+			t.Fatalf("Migrate failed: %v", err)
+		}
+
+		if err := db.Create(&models.User{Username: "Alice", Email: "alice@example.com", Password: "x"}).Error; err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+
+		err := db.Create(&models.User{Username: "alice", Email: "other@example.com", Password: "x"}).Error
+		if err == nil || !database.IsDuplicateKeyError(err) {
+			t.Fatalf("expected duplicate key error for username, got %v", err)
+		}
+
+		err = db.Create(&models.User{Username: "bob", Email: "ALICE@example.com", Password: "x"}).Error
+		if err == nil || !database.IsDuplicateKeyError(err) {
+			t.Fatalf("expected duplicate key error for email, got %v", err)
+		}
+	})
+}
+
+func TestSeedIsIdempotent(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+		if err := database.Migrate(db); err != nil {
+			t.Fatalf("Migrate failed: %v", err)
+		}
+
+		opts := database.SeedOptions{Users: 3, Password: "Demo123!pass"}
+		if created, err := database.Seed(context.Background(), db, opts); err != nil || created != 3 {
+			t.Fatalf("first seed created %d, err %v", created, err)
+		}
+		if created, err := database.Seed(context.Background(), db, opts); err != nil || created != 0 {
+			t.Fatalf("second seed created %d, err %v", created, err)
+		}
+	})
+}
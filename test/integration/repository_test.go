@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeferson59/gin-template/internal/database"
+	"github.com/yeferson59/gin-template/internal/models"
+	"github.com/yeferson59/gin-template/internal/repository"
+	"github.com/yeferson59/gin-template/pkg/pagination"
+)
+
+func TestUserRepositorySearch(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+		if err := database.Migrate(db); err != nil {
+			t.Fatalf("Migrate failed: %v", err)
+		}
+		for _, u := range []models.User{
+			{Username: "Alice_Admin", Email: "alice@example.com", Role: models.RoleAdmin},
+			{Username: "AliceXAdmin", Email: "alicex@example.com", Role: models.RoleUser},
+			{Username: "bob", Email: "bob@example.com", Role: models.RoleUser},
+		} {
+			u.Password = "x"
+			if err := db.Create(&u).Error; err != nil {
+				t.Fatalf("failed to create user: %v", err)
+			}
+		}
+
+		repo := repository.NewUserRepository(db)
+		page := pagination.Params{Page: 1, PerPage: 10}
+
+		tests := []struct {
+			name   string
+			filter repository.UserFilter
+			want   int64
+		}{
+			// Case-insensitive on every driver (no reliance on ILIKE or collations).
+			{"case insensitive", repository.UserFilter{Search: "ALICE"}, 2},
+			// "_" is a LIKE wildcard and must be matched literally.
+			{"escaped wildcard", repository.UserFilter{Search: "alice_"}, 1},
+			{"role", repository.UserFilter{Role: models.RoleAdmin}, 1},
+		}
+		for _, tt := range tests {
+			users, total, err := repo.List(context.Background(), tt.filter, page)
+			if err != nil {
+				t.Fatalf("%s: List failed: %v", tt.name, err)
+			}
+			if total != tt.want || int64(len(users)) != tt.want {
+				t.Errorf("%s: got %d users (total %d), want %d", tt.name, len(users), total, tt.want)
+			}
+		}
+	})
+}
+
+func TestUserRepositoryEstimatedTotal(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+		if err := database.Migrate(db); err != nil {
+			t.Fatalf("Migrate failed: %v", err)
+		}
+		if err := repository.UseCounter(db, repository.NewCounter(repository.CountEstimated, time.Minute, 100)); err != nil {
+			t.Fatal(err)
+		}
+		users := make([]models.User, 500)
+		for i := range users {
+			users[i] = models.User{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i), Password: "x"}
+		}
+		if err := db.CreateInBatches(&users, 100).Error; err != nil {
+			t.Fatalf("failed to create users: %v", err)
+		}
+		// Refresh the planner statistics
+		if db.Dialector.Name() == "postgres" {
+			if err := db.Exec("ANALYZE users").Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ctx := repository.WithCountReport(context.Background())
+		_, total, err := repository.NewUserRepository(db).List(ctx, repository.UserFilter{}, pagination.Params{Page: 1, PerPage: 10})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		estimated := repository.EstimatedCount(ctx)
+		if want := db.Dialector.Name() == "postgres"; estimated != want {
+			t.Fatalf("expected estimated=%v, got %v", want, estimated)
+		}
+		// Statistics of a freshly analyzed table are close to the exact count
+		if total < 400 || total > 600 || (!estimated && total != 500) {
+			t.Fatalf("unexpected total %d (estimated %v)", total, estimated)
+		}
+	})
+}
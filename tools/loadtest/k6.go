@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// k6Script runs the selected scenarios in proportion to their weights. setup() registers
+// and logs in a dedicated user once so that authenticated scenarios share a single token.
+var k6Script = template.Must(template.New("k6").Parse(`// Generated by tools/loadtest. Regenerate instead of editing by hand.
+import http from 'k6/http';
+import { check } from 'k6';
+
+const BASE_URL = __ENV.BASE_URL || {{.BaseURL}};
+
+export const options = {
+  vus: {{.VUs}},
+  duration: '{{.Duration}}',
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    http_req_duration: ['p(95)<250'],
+  },
+};
+
+const scenarios = {{.Scenarios}};
+
+const weighted = scenarios.flatMap((s) => Array(s.weight).fill(s));
+
+export function setup() {
+{{- if .NeedsAuth}}
+  const creds = { username: 'loadtest', email: 'loadtest@loadtest.example.com', password: 'LoadTest123!' };
+  const params = { headers: { 'Content-Type': 'application/json' } };
+  http.post(BASE_URL + '/api/auth/register', JSON.stringify(creds), params);
+  const res = http.post(BASE_URL + '/api/auth/login', JSON.stringify(creds), params);
+  check(res, { 'setup login succeeded': (r) => r.status === 200 });
+  return { token: res.json('data.token') };
+{{- else}}
+  return {};
+{{- end}}
+}
+
+export default function (data) {
+  const s = weighted[Math.floor(Math.random() * weighted.length)];
+  const headers = { 'Content-Type': 'application/json' };
+  if (s.auth) {
+    headers.Authorization = 'Bearer ' + data.token;
+  }
+  const user = 'lt' + __VU + '_' + __ITER + '_' + Date.now();
+  const body = s.body ? s.body.replaceAll('{{"{{"}}user{{"}}"}}', user) : null;
+
+  const res = http.request(s.method, BASE_URL + s.path, body, { headers, tags: { scenario: s.name } });
+  check(res, { [s.name + ' status']: (r) => s.expect.includes(r.status) });
+}
+`))
+
+type k6Scenario struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body,omitempty"`
+	Auth   bool   `json:"auth"`
+	Weight int    `json:"weight"`
+	Expect []int  `json:"expect"`
+}
+
+func writeK6(w io.Writer, opts options, selected []Scenario) error {
+	lines := make([]string, 0, len(selected))
+	for _, s := range selected {
+		line, err := json.Marshal(s.k6())
+		if err != nil {
+			return err
+		}
+		lines = append(lines, "  "+string(line))
+	}
+	baseURL, err := json.Marshal(opts.baseURL)
+	if err != nil {
+		return err
+	}
+
+	return k6Script.Execute(w, map[string]interface{}{
+		"BaseURL":   string(baseURL),
+		"VUs":       opts.vus,
+		"Duration":  opts.duration.String(),
+		"Scenarios": "[\n" + strings.Join(lines, ",\n") + ",\n]",
+		"NeedsAuth": needsAuth(selected),
+	})
+}
+
+func (s Scenario) k6() k6Scenario {
+	return k6Scenario{Name: s.Name, Method: s.Method, Path: s.Path, Body: s.Body, Auth: s.Auth, Weight: s.Weight, Expect: s.Expect}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectScenarios(t *testing.T) {
+	def, err := selectScenarios("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range def {
+		if s.Name == "login" || s.Name == "register" {
+			t.Fatalf("rate-limited scenario %q must be opt-in", s.Name)
+		}
+	}
+
+	if _, err := selectScenarios("live,nope"); err == nil {
+		t.Fatal("expected an error for an unknown scenario")
+	}
+}
+
+func TestWriteVegeta(t *testing.T) {
+	var buf bytes.Buffer
+	opts := options{baseURL: "http://api:8080/", token: "tok"}
+	selected, _ := selectScenarios("me,register")
+	if err := writeVegeta(&buf, opts, selected); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		var target vegetaTarget
+		if err := json.Unmarshal(scanner.Bytes(), &target); err != nil {
+			t.Fatalf("invalid target %q: %v", scanner.Text(), err)
+		}
+		switch {
+		case strings.HasSuffix(target.URL, "/api/users/me"):
+			if target.Header.Get("Authorization") != "Bearer tok" {
+				t.Fatalf("missing token: %+v", target)
+			}
+		case strings.HasSuffix(target.URL, "/api/auth/register"):
+			if strings.Contains(string(target.Body), "{{user}}") {
+				t.Fatalf("placeholder not replaced: %s", target.Body)
+			}
+		default:
+			t.Fatalf("unexpected target %s", target.URL)
+		}
+		if strings.Contains(target.URL, "//api/") {
+			t.Fatalf("double slash in %s", target.URL)
+		}
+		lines++
+	}
+	if lines != 6 {
+		t.Fatalf("expected 6 weighted targets, got %d", lines)
+	}
+}
+
+func TestWriteK6(t *testing.T) {
+	var buf bytes.Buffer
+	opts := options{baseURL: "http://localhost:8080", vus: 5, duration: time.Minute}
+	selected, _ := selectScenarios("")
+	if err := writeK6(&buf, opts, selected); err != nil {
+		t.Fatal(err)
+	}
+
+	script := buf.String()
+	for _, want := range []string{"vus: 5", "duration: '1m0s'", `"/api/users/me"`, "/api/auth/login", "replaceAll('{{user}}'"} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("script missing %q:\n%s", want, script)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Command loadtest generates load-test scenarios for the template's standard endpoints,
+// either as a k6 script or as vegeta JSON targets:
+//
+//	go run ./tools/loadtest -tool k6 -out loadtest.js && k6 run loadtest.js
+//	go run ./tools/loadtest -tool vegeta -token "$TOKEN" | vegeta attack -format=json -rate=200 -duration=30s | vegeta report
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+type options struct {
+	tool      string
+	baseURL   string
+	scenarios string
+	token     string
+	vus       int
+	duration  time.Duration
+	out       string
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.tool, "tool", "k6", "output format: k6 or vegeta")
+	flag.StringVar(&opts.baseURL, "base-url", "http://localhost:8080", "base URL of the API under test")
+	flag.StringVar(&opts.scenarios, "scenarios", "", `comma-separated scenarios, "all", or empty for the default mix`)
+	flag.StringVar(&opts.token, "token", "", "bearer token for authenticated vegeta targets (k6 logs in during setup)")
+	flag.IntVar(&opts.vus, "vus", 20, "k6 virtual users")
+	flag.DurationVar(&opts.duration, "duration", 30*time.Second, "k6 test duration")
+	flag.StringVar(&opts.out, "out", "", "output file (default stdout)")
+	flag.Parse()
+
+	if err := run(opts); err != nil {
+		fmt.Fprintln(os.Stderr, "loadtest:", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts options) error {
+	selected, err := selectScenarios(opts.scenarios)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if opts.out != "" {
+		f, err := os.Create(opts.out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch opts.tool {
+	case "k6":
+		return writeK6(w, opts, selected)
+	case "vegeta":
+		if opts.token == "" && needsAuth(selected) {
+			return fmt.Errorf("the selected scenarios require -token for vegeta")
+		}
+		return writeVegeta(w, opts, selected)
+	default:
+		return fmt.Errorf("unknown tool %q (use k6 or vegeta)", opts.tool)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scenario is a single endpoint exercised by a load test.
+type Scenario struct {
+	Name   string
+	Method string
+	Path   string
+	// Body is the JSON request body, if any. "{{user}}" is replaced with a unique username.
+	Body string
+	// Auth marks endpoints that need a bearer token.
+	Auth bool
+	// Weight is the relative share of requests in a mixed run.
+	Weight int
+	// Expect lists the status codes counted as successful.
+	Expect []int
+	// Default scenarios run when no -scenarios flag is given. The auth endpoints are
+	// rate limited per IP by design, so they are opt-in.
+	Default bool
+}
+
+// scenarios are the standard endpoints of the template.
+var scenarios = []Scenario{
+	{Name: "health", Method: "GET", Path: "/health/", Weight: 1, Expect: []int{200, 206}, Default: true},
+	{Name: "live", Method: "GET", Path: "/health/live", Weight: 2, Expect: []int{200}, Default: true},
+	{Name: "ready", Method: "GET", Path: "/health/ready", Weight: 2, Expect: []int{200}, Default: true},
+	{Name: "me", Method: "GET", Path: "/api/users/me", Auth: true, Weight: 5, Expect: []int{200}, Default: true},
+	{Name: "protected", Method: "GET", Path: "/api/protected/", Auth: true, Weight: 3, Expect: []int{200}, Default: true},
+	{
+		Name: "login", Method: "POST", Path: "/api/auth/login", Weight: 1, Expect: []int{200, 429},
+		Body: `{"username":"loadtest","password":"LoadTest123!"}`,
+	},
+	{
+		Name: "register", Method: "POST", Path: "/api/auth/register", Weight: 1, Expect: []int{201, 429},
+		Body: `{"username":"{{user}}","email":"{{user}}@loadtest.example.com","password":"LoadTest123!"}`,
+	},
+}
+
+// selectScenarios returns the scenarios named in list (comma-separated), the default set
+// when list is empty, or every scenario when list is "all".
+func selectScenarios(list string) ([]Scenario, error) {
+	list = strings.TrimSpace(list)
+	if list == "" {
+		var out []Scenario
+		for _, s := range scenarios {
+			if s.Default {
+				out = append(out, s)
+			}
+		}
+		return out, nil
+	}
+	if list == "all" {
+		return scenarios, nil
+	}
+
+	byName := make(map[string]Scenario, len(scenarios))
+	for _, s := range scenarios {
+		byName[s.Name] = s
+	}
+	var out []Scenario
+	for _, name := range strings.Split(list, ",") {
+		s, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown scenario %q (available: %s)", name, strings.Join(scenarioNames(), ", "))
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func scenarioNames() []string {
+	names := make([]string, 0, len(scenarios))
+	for _, s := range scenarios {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func needsAuth(list []Scenario) bool {
+	for _, s := range list {
+		if s.Auth {
+			return true
+		}
+	}
+	return false
+}
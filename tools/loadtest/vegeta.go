@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// vegetaTarget is a target in vegeta's JSON format (vegeta attack -format=json).
+type vegetaTarget struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Body   []byte      `json:"body,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// writeVegeta writes one target per request, each scenario repeated by its weight so that
+// vegeta's round-robin over the targets reproduces the mix. Bodies get unique usernames.
+func writeVegeta(w io.Writer, opts options, selected []Scenario) error {
+	enc := json.NewEncoder(w)
+	baseURL := strings.TrimRight(opts.baseURL, "/")
+	n := 0
+
+	for _, s := range selected {
+		for i := 0; i < s.Weight; i++ {
+			n++
+			t := vegetaTarget{
+				Method: s.Method,
+				URL:    baseURL + s.Path,
+				Header: http.Header{},
+			}
+			if s.Body != "" {
+				t.Body = []byte(strings.ReplaceAll(s.Body, "{{user}}", fmt.Sprintf("loadtest%d", n)))
+				t.Header.Set("Content-Type", "application/json")
+			}
+			if s.Auth {
+				t.Header.Set("Authorization", "Bearer "+opts.token)
+			}
+			if err := enc.Encode(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}